@@ -0,0 +1,26 @@
+package idgenerator
+
+// Allocator is the allocation interface the generators of the package share, so that a caller can swap one
+// for another without code changes, e.g. an IDGenerator for a generator shared by several processes
+// such as that of the etcdgenerator package.
+// The errors of an Allocator wrap the errors of this package, ErrPoolExhausted, ErrAlreadyAllocated,
+// ErrNotAllocated, ErrOutOfRange and so on.
+type Allocator interface {
+	// Allocate allocates a free ID
+	Allocate() (int64, error)
+	// AllocateSpecific allocates exactly id
+	AllocateSpecific(id int64) error
+	// FreeID frees the allocated id
+	FreeID(id int64) error
+	// IsAllocated reports whether id is allocated
+	IsAllocated(id int64) bool
+	// Used returns the number of allocated IDs
+	Used() int64
+}
+
+var (
+	_ Allocator = (*IDGenerator)(nil)
+	_ Allocator = (*PersistentIDGenerator)(nil)
+	_ Allocator = (*MultiRangeIDGenerator)(nil)
+	_ Allocator = (*ShardedIDGenerator)(nil)
+)
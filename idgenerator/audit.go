@@ -0,0 +1,157 @@
+package idgenerator
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// AuditOp is the operation of an AuditEvent
+type AuditOp string
+
+const (
+	// AuditAllocate is the allocation of an ID, by any method
+	AuditAllocate AuditOp = "allocate"
+	// AuditFree is the free of an ID, by any method including lease expiry and Reset
+	AuditFree AuditOp = "free"
+)
+
+// AuditEvent is an allocation or free recorded by WithAudit. Tag is the owner of AllocateFor, "" for the others.
+type AuditEvent struct {
+	Op  AuditOp
+	ID  int64
+	At  time.Time
+	Tag string
+}
+
+// String returns the event as a line of ExportAudit without the newline,
+// such as `2023-01-01T00:00:00Z allocate 4711 "smf-1"`
+func (e AuditEvent) String() string {
+	if e.Tag == "" {
+		return fmt.Sprintf("%s %s %d", e.At.Format(time.RFC3339Nano), e.Op, e.ID)
+	}
+	return fmt.Sprintf("%s %s %d %q", e.At.Format(time.RFC3339Nano), e.Op, e.ID, e.Tag)
+}
+
+// AuditFilter selects the events of Events, its zero value selects them all
+type AuditFilter struct {
+	// IDs are the IDs whose events are selected, every ID if empty
+	IDs []int64
+	// Since and Until bound the times of the selected events to [Since, Until), a zero bound is open
+	Since time.Time
+	Until time.Time
+}
+
+func (f AuditFilter) matches(e AuditEvent) bool {
+	if !f.Since.IsZero() && e.At.Before(f.Since) || !f.Until.IsZero() && !e.At.Before(f.Until) {
+		return false
+	}
+	if len(f.IDs) == 0 {
+		return true
+	}
+	for _, id := range f.IDs {
+		if id == e.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// WithAudit records the last n allocations and frees with the time of the clock of WithClock,
+// to find out after the fact who allocated an ID and when it was freed, see Events and ExportAudit.
+// The events are kept in a ring of n entries allocated up front, the oldest are overwritten.
+// An n of 0 or below keeps the default, nothing is recorded.
+func WithAudit(n int) Option {
+	return func(idGenerator *IDGenerator) {
+		if n > 0 {
+			idGenerator.audit = &auditLog{events: make([]AuditEvent, n)}
+		}
+	}
+}
+
+// auditLog is the ring of WithAudit holding count events, events[start] is the oldest one
+type auditLog struct {
+	events []AuditEvent
+	start  int
+	count  int
+}
+
+// record adds an event, overwriting the oldest one if the ring is full
+func (l *auditLog) record(e AuditEvent) {
+	l.events[(l.start+l.count)%len(l.events)] = e
+	if l.count < len(l.events) {
+		l.count++
+	} else {
+		l.start = (l.start + 1) % len(l.events)
+	}
+}
+
+// last returns the latest event, nil if there is none
+func (l *auditLog) last() *AuditEvent {
+	if l.count == 0 {
+		return nil
+	}
+	return &l.events[(l.start+l.count-1)%len(l.events)]
+}
+
+// tagLast sets the tag of the latest event
+func (l *auditLog) tagLast(tag string) {
+	if e := l.last(); e != nil {
+		e.Tag = tag
+	}
+}
+
+// dropLast drops the latest event, the one it overwrote in a full ring stays lost
+func (l *auditLog) dropLast() {
+	if l.count > 0 {
+		l.count--
+	}
+}
+
+// each calls f for the events from the oldest to the latest
+func (l *auditLog) each(f func(e AuditEvent)) {
+	for i := 0; i < l.count; i++ {
+		f(l.events[(l.start+i)%len(l.events)])
+	}
+}
+
+func (l *auditLog) clone() *auditLog {
+	return &auditLog{events: append([]AuditEvent(nil), l.events...), start: l.start, count: l.count}
+}
+
+// recordLocked records op on the ID at offset if WithAudit is set, the caller must hold lock
+func (idGenerator *IDGenerator) recordLocked(op AuditOp, offset uint64) {
+	if idGenerator.audit == nil {
+		return
+	}
+	idGenerator.audit.record(AuditEvent{Op: op, ID: idGenerator.toID(offset), At: idGenerator.clock.Now()})
+}
+
+// Events returns the recorded events selected by filter from the oldest to the latest,
+// nil without WithAudit or if none matches
+func (idGenerator *IDGenerator) Events(filter AuditFilter) []AuditEvent {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if idGenerator.audit == nil {
+		return nil
+	}
+	var events []AuditEvent
+	idGenerator.audit.each(func(e AuditEvent) {
+		if filter.matches(e) {
+			events = append(events, e)
+		}
+	})
+	return events
+}
+
+// ExportAudit writes the recorded events to w from the oldest to the latest, one line each as AuditEvent.String.
+// It writes nothing without WithAudit and returns the first error of w.
+func (idGenerator *IDGenerator) ExportAudit(w io.Writer) error {
+	// write outside lock, w may be slow
+	for _, e := range idGenerator.Events(AuditFilter{}) {
+		if _, err := fmt.Fprintln(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package idgenerator
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAudit(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithAudit(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	clock.Advance(time.Second)
+	id, err := idGenerator.AllocateFor("smf-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+	freeAll(t, idGenerator, 1)
+	if err = idGenerator.FreeID(1); err == nil {
+		t.Fatal("expected freeing twice to fail")
+	}
+	if freed := idGenerator.FreeByOwner("smf-1"); !reflect.DeepEqual(freed, []int64{id}) {
+		t.Fatalf("expected ID %d freed, output %v", id, freed)
+	}
+
+	expected := []AuditEvent{
+		{AuditAllocate, 1, start, ""},
+		{AuditAllocate, 2, start, ""},
+		{AuditAllocate, id, start.Add(time.Second), "smf-1"},
+		{AuditFree, 1, start.Add(2 * time.Second), ""},
+		{AuditFree, id, start.Add(2 * time.Second), "smf-1"},
+	}
+	if events := idGenerator.Events(AuditFilter{}); !reflect.DeepEqual(events, expected) {
+		t.Errorf("expected events %v, output %v", expected, events)
+	}
+	if events := idGenerator.Events(AuditFilter{IDs: []int64{1}}); !reflect.DeepEqual(events,
+		[]AuditEvent{expected[0], expected[3]}) {
+		t.Errorf("unexpected events of ID 1: %v", events)
+	}
+	second := AuditFilter{Since: start.Add(time.Second), Until: start.Add(2 * time.Second)}
+	if events := idGenerator.Events(second); !reflect.DeepEqual(events, []AuditEvent{expected[2]}) {
+		t.Errorf("unexpected events of the second second: %v", events)
+	}
+	if events := idGenerator.Events(AuditFilter{IDs: []int64{50}}); events != nil {
+		t.Errorf("expected no event, output %v", events)
+	}
+
+	var buf bytes.Buffer
+	if err = idGenerator.ExportAudit(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := "2023-01-01T00:00:00Z allocate 1\n" +
+		"2023-01-01T00:00:00Z allocate 2\n" +
+		"2023-01-01T00:00:01Z allocate 3 \"smf-1\"\n" +
+		"2023-01-01T00:00:02Z free 1\n" +
+		"2023-01-01T00:00:02Z free 3 \"smf-1\"\n"
+	if buf.String() != lines {
+		t.Errorf("expected export\n%s\noutput\n%s", lines, buf.String())
+	}
+}
+
+func TestAuditRing(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithAudit(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 4)
+	idGenerator.Reset()
+	var ops []string
+	for _, e := range idGenerator.Events(AuditFilter{}) {
+		ops = append(ops, string(e.Op)+" "+strconv.FormatInt(e.ID, 10))
+	}
+	// the oldest events are overwritten, Reset records a free for each ID
+	if expected := []string{"free 2", "free 3", "free 4"}; !reflect.DeepEqual(ops, expected) {
+		t.Errorf("expected events %v, output %v", expected, ops)
+	}
+
+	clone := idGenerator.Clone()
+	allocateN(t, clone, 1)
+	if events := idGenerator.Events(AuditFilter{}); len(events) != 3 || events[0].ID != 2 {
+		t.Errorf("expected the clone to record on its own, output %v", events)
+	}
+}
+
+func TestAuditOff(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	allocateN(t, idGenerator, 1)
+	if events := idGenerator.Events(AuditFilter{}); events != nil {
+		t.Errorf("expected no event without WithAudit, output %v", events)
+	}
+	var buf bytes.Buffer
+	if err := idGenerator.ExportAudit(&buf); err != nil || buf.Len() != 0 {
+		t.Errorf("expected an empty export, output %q, %+v", buf.String(), err)
+	}
+}
+
+type failingWriter struct{}
+
+var errWrite = errors.New("write failed")
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errWrite
+}
+
+func TestExportAuditError(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithAudit(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 1)
+	if err = idGenerator.ExportAudit(failingWriter{}); !errors.Is(err, errWrite) {
+		t.Errorf("expected the error of the writer, got %+v", err)
+	}
+}
+
+func BenchmarkAudit(b *testing.B) {
+	for _, benchmark := range []struct {
+		name    string
+		options []Option
+	}{
+		{"off", nil},
+		{"on", []Option{WithAudit(1 << 16)}},
+	} {
+		b.Run(benchmark.name, func(b *testing.B) {
+			idGenerator, err := NewGeneratorWithOptions(1, 1<<20, benchmark.options...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					b.Fatal(err)
+				}
+				if err = idGenerator.FreeID(id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,290 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math/big"
+	"math/bits"
+	"sort"
+	"sync"
+)
+
+// uint128 is an offset in the range of a BigIDGenerator, which has 2^128 IDs at most
+type uint128 struct {
+	hi uint64
+	lo uint64
+}
+
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// uint128FromBig returns b as an uint128, false if b is negative or does not fit in 128 bits
+func uint128FromBig(b *big.Int) (uint128, bool) {
+	if b.Sign() < 0 || b.Cmp(maxUint128) > 0 {
+		return uint128{}, false
+	}
+	lo := new(big.Int).And(b, new(big.Int).SetUint64(^uint64(0)))
+	return uint128{hi: new(big.Int).Rsh(b, 64).Uint64(), lo: lo.Uint64()}, true
+}
+
+func (u uint128) big() *big.Int {
+	b := new(big.Int).SetUint64(u.hi)
+	return b.Lsh(b, 64).Or(b, new(big.Int).SetUint64(u.lo))
+}
+
+func (u uint128) cmp(v uint128) int {
+	switch {
+	case u.hi < v.hi || u.hi == v.hi && u.lo < v.lo:
+		return -1
+	case u == v:
+		return 0
+	default:
+		return 1
+	}
+}
+
+// inc returns u+1, wrapping to 0 after 2^128-1
+func (u uint128) inc() uint128 {
+	lo, carry := bits.Add64(u.lo, 1, 0)
+	return uint128{hi: u.hi + carry, lo: lo}
+}
+
+// dec returns u-1, wrapping to 2^128-1 before 0
+func (u uint128) dec() uint128 {
+	lo, borrow := bits.Sub64(u.lo, 1, 0)
+	return uint128{hi: u.hi - borrow, lo: lo}
+}
+
+// usedInterval is an inclusive range of allocated offsets
+type usedInterval struct {
+	start uint128
+	end   uint128
+}
+
+// BigIDGenerator allocates the IDs of a range of up to 2^128 IDs as *big.Int, e.g. the addresses of an IPv6 network
+// shorter than /64, which do not fit in the int64 of an IDGenerator. It allocates like the sequential strategy
+// of an IDGenerator, the ID after the last one allocated first, wrapping around at the end of the range.
+// Since such a range cannot be enumerated, it keeps the allocated IDs as a sorted list of disjoint, non-adjacent
+// intervals, whose memory grows with the allocations rather than with the size of the range.
+// It is safe for concurrent use.
+type BigIDGenerator struct {
+	lock       sync.Mutex
+	minValue   *big.Int
+	maxValue   *big.Int
+	lastOffset uint128
+	// offset is the offset the next allocation starts searching from
+	offset uint128
+	used   []usedInterval
+	// count is the number of allocated IDs, which is 2^128 if the range is full and has 2^128 IDs, 0 then
+	count uint128
+}
+
+// NewBigIDGenerator initializes a BigIDGenerator of the range [minValue, maxValue].
+// It returns an error wrapping ErrInvalidRange if a bound is nil, minValue > maxValue
+// or the range has more than 2^128 IDs.
+func NewBigIDGenerator(minValue, maxValue *big.Int) (*BigIDGenerator, error) {
+	if minValue == nil || maxValue == nil {
+		return nil, fmt.Errorf("%w: nil bound", ErrInvalidRange)
+	}
+	if minValue.Cmp(maxValue) > 0 {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidRange, minValue, maxValue)
+	}
+	lastOffset, ok := uint128FromBig(new(big.Int).Sub(maxValue, minValue))
+	if !ok {
+		return nil, fmt.Errorf("%w: [%d, %d] has more than 2^128 IDs", ErrInvalidRange, minValue, maxValue)
+	}
+	return &BigIDGenerator{
+		minValue:   new(big.Int).Set(minValue),
+		maxValue:   new(big.Int).Set(maxValue),
+		lastOffset: lastOffset,
+	}, nil
+}
+
+// Bounds returns the range of the generator
+func (g *BigIDGenerator) Bounds() (minValue, maxValue *big.Int) {
+	return new(big.Int).Set(g.minValue), new(big.Int).Set(g.maxValue)
+}
+
+// toOffset returns the offset of id, false if id is out of the range
+func (g *BigIDGenerator) toOffset(id *big.Int) (uint128, bool) {
+	if id == nil || id.Cmp(g.minValue) < 0 || id.Cmp(g.maxValue) > 0 {
+		return uint128{}, false
+	}
+	return uint128FromBig(new(big.Int).Sub(id, g.minValue))
+}
+
+func (g *BigIDGenerator) toID(offset uint128) *big.Int {
+	id := offset.big()
+	return id.Add(id, g.minValue)
+}
+
+func (g *BigIDGenerator) outOfRangeError(id *big.Int) error {
+	return fmt.Errorf("%w: ID[%d] not in [%d, %d]", ErrOutOfRange, id, g.minValue, g.maxValue)
+}
+
+// search returns the index of the first interval whose end is >= offset
+func (g *BigIDGenerator) search(offset uint128) int {
+	return sort.Search(len(g.used), func(i int) bool {
+		return g.used[i].end.cmp(offset) >= 0
+	})
+}
+
+func (g *BigIDGenerator) has(offset uint128) bool {
+	i := g.search(offset)
+	return i < len(g.used) && g.used[i].start.cmp(offset) <= 0
+}
+
+// full reports whether every offset is allocated. The caller must hold lock.
+func (g *BigIDGenerator) full() bool {
+	return len(g.used) == 1 && g.used[0] == usedInterval{end: g.lastOffset}
+}
+
+// set marks the free offset as allocated, merging it with the intervals around it. The caller must hold lock.
+func (g *BigIDGenerator) set(offset uint128) {
+	i := g.search(offset)
+	mergePrev := i > 0 && g.used[i-1].end == offset.dec()
+	mergeNext := i < len(g.used) && g.used[i].start == offset.inc()
+	switch {
+	case mergePrev && mergeNext:
+		g.used[i-1].end = g.used[i].end
+		g.used = append(g.used[:i], g.used[i+1:]...)
+	case mergePrev:
+		g.used[i-1].end = offset
+	case mergeNext:
+		g.used[i].start = offset
+	default:
+		g.used = append(g.used, usedInterval{})
+		copy(g.used[i+1:], g.used[i:])
+		g.used[i] = usedInterval{start: offset, end: offset}
+	}
+	g.count = g.count.inc()
+}
+
+// clear marks the allocated offset as free, splitting the interval holding it. The caller must hold lock.
+func (g *BigIDGenerator) clear(offset uint128) {
+	i := g.search(offset)
+	interval := &g.used[i]
+	switch {
+	case interval.start == interval.end:
+		g.used = append(g.used[:i], g.used[i+1:]...)
+	case interval.start == offset:
+		interval.start = offset.inc()
+	case interval.end == offset:
+		interval.end = offset.dec()
+	default:
+		// split [start, end] into [start, offset-1] and [offset+1, end]
+		g.used = append(g.used, usedInterval{})
+		copy(g.used[i+1:], g.used[i:])
+		g.used[i].end = offset.dec()
+		g.used[i+1].start = offset.inc()
+	}
+	g.count = g.count.dec()
+}
+
+// nextFree returns the first free offset from offset to the last one, false if there is none.
+// The caller must hold lock.
+func (g *BigIDGenerator) nextFree(offset uint128) (uint128, bool) {
+	i := g.search(offset)
+	if i == len(g.used) || g.used[i].start.cmp(offset) > 0 {
+		return offset, true
+	}
+	// the intervals are not adjacent, the offset after one is free unless it is past the range
+	if g.used[i].end == g.lastOffset {
+		return uint128{}, false
+	}
+	return g.used[i].end.inc(), true
+}
+
+// Allocate allocates the first free ID after the last one allocated, wrapping around at the end of the range.
+// It returns an error wrapping ErrPoolExhausted if every ID is allocated.
+func (g *BigIDGenerator) Allocate() (*big.Int, error) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.full() {
+		return nil, fmt.Errorf("%w: [%d, %d]", ErrPoolExhausted, g.minValue, g.maxValue)
+	}
+	offset, ok := g.nextFree(g.offset)
+	if !ok {
+		offset, _ = g.nextFree(uint128{})
+	}
+	g.set(offset)
+	if g.offset = offset.inc(); offset == g.lastOffset {
+		g.offset = uint128{}
+	}
+	return g.toID(offset), nil
+}
+
+// AllocateSpecific allocates exactly id. It returns an error wrapping ErrOutOfRange if id is out of the range
+// or ErrAlreadyAllocated if it is allocated.
+func (g *BigIDGenerator) AllocateSpecific(id *big.Int) error {
+	offset, ok := g.toOffset(id)
+	if !ok {
+		return g.outOfRangeError(id)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.has(offset) {
+		return fmt.Errorf("%w: ID[%d]", ErrAlreadyAllocated, id)
+	}
+	g.set(offset)
+	return nil
+}
+
+// FreeID releases id. It returns an error wrapping ErrOutOfRange if id is out of the range
+// or ErrNotAllocated if it is not allocated.
+func (g *BigIDGenerator) FreeID(id *big.Int) error {
+	offset, ok := g.toOffset(id)
+	if !ok {
+		return g.outOfRangeError(id)
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if !g.has(offset) {
+		return fmt.Errorf("%w: ID[%d]", ErrNotAllocated, id)
+	}
+	g.clear(offset)
+	return nil
+}
+
+// IsAllocated reports whether id is allocated
+func (g *BigIDGenerator) IsAllocated(id *big.Int) bool {
+	offset, ok := g.toOffset(id)
+	if !ok {
+		return false
+	}
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return g.has(offset)
+}
+
+// Used returns the number of allocated IDs
+func (g *BigIDGenerator) Used() *big.Int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.full() {
+		return g.size()
+	}
+	return g.count.big()
+}
+
+// Available returns the number of free IDs
+func (g *BigIDGenerator) Available() *big.Int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.full() {
+		return new(big.Int)
+	}
+	size := g.size()
+	return size.Sub(size, g.count.big())
+}
+
+// Fragments returns the number of intervals the allocated IDs are kept in, which the memory of the generator grows with
+func (g *BigIDGenerator) Fragments() int {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return len(g.used)
+}
+
+// size returns the number of IDs of the range
+func (g *BigIDGenerator) size() *big.Int {
+	size := g.lastOffset.big()
+	return size.Add(size, big.NewInt(1))
+}
@@ -0,0 +1,155 @@
+package idgenerator
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+)
+
+func mustParseBig(t *testing.T, s string) *big.Int {
+	t.Helper()
+	b, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		t.Fatalf("invalid number %q", s)
+	}
+	return b
+}
+
+func TestBigIDGenerator(t *testing.T) {
+	// the whole 128-bit range above 2^64, far beyond int64
+	minValue := mustParseBig(t, "0x10000000000000000")
+	maxValue := new(big.Int).Add(minValue, maxUint128)
+	generator, err := NewBigIDGenerator(minValue, maxValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available := generator.Available(); available.Cmp(new(big.Int).Lsh(big.NewInt(1), 128)) != 0 {
+		t.Errorf("expected 2^128 available, output %d", available)
+	}
+	for i := int64(0); i < 3; i++ {
+		id, err := generator.Allocate()
+		if expected := new(big.Int).Add(minValue, big.NewInt(i)); err != nil || id.Cmp(expected) != 0 {
+			t.Errorf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if err = generator.AllocateSpecific(maxValue); err != nil {
+		t.Fatal(err)
+	}
+	if err = generator.AllocateSpecific(maxValue); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	outside := new(big.Int).Add(maxValue, big.NewInt(1))
+	if err = generator.AllocateSpecific(outside); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = generator.FreeID(outside); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if used, fragments := generator.Used(), generator.Fragments(); used.Int64() != 4 || fragments != 2 {
+		t.Errorf("expected 4 used in 2 fragments, output %d and %d", used, fragments)
+	}
+
+	one := new(big.Int).Add(minValue, big.NewInt(1))
+	if err = generator.FreeID(one); err != nil {
+		t.Fatal(err)
+	}
+	if err = generator.FreeID(one); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if generator.IsAllocated(one) || !generator.IsAllocated(minValue) {
+		t.Error("unexpected allocation state")
+	}
+	if fragments := generator.Fragments(); fragments != 3 {
+		t.Errorf("expected 3 fragments, output %d", fragments)
+	}
+	// the allocation carries on after the last ID allocated rather than reusing the freed one
+	if id, err := generator.Allocate(); err != nil || new(big.Int).Sub(id, minValue).Int64() != 3 {
+		t.Errorf("expected offset 3, output %d, %+v", id, err)
+	}
+	expected := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(4))
+	if available := generator.Available(); available.Cmp(expected) != 0 {
+		t.Errorf("expected %d available, output %d", expected, available)
+	}
+}
+
+func TestBigIDGeneratorWrapAround(t *testing.T) {
+	generator, err := NewBigIDGenerator(big.NewInt(-2), big.NewInt(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = generator.AllocateSpecific(big.NewInt(2)); err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for i := 0; i < 4; i++ {
+		id, err := generator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id.Int64())
+	}
+	if err = generator.FreeID(big.NewInt(-1)); err != nil {
+		t.Fatal(err)
+	}
+	// the last ID is allocated, the search goes on from the first one
+	if id, err := generator.Allocate(); err != nil || id.Int64() != -1 {
+		t.Errorf("expected ID -1 after %v, output %d, %+v", ids, id, err)
+	}
+	if _, err = generator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if used, available, fragments := generator.Used(), generator.Available(), generator.Fragments(); used.Int64() != 5 ||
+		available.Sign() != 0 || fragments != 1 {
+		t.Errorf("expected 5 used, 0 available in 1 fragment, output %d, %d and %d", used, available, fragments)
+	}
+}
+
+func TestBigIDGeneratorFull(t *testing.T) {
+	// a full range of 2^128 IDs counts 2^128 used
+	generator, err := NewBigIDGenerator(new(big.Int), maxUint128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	generator.used = []usedInterval{{end: generator.lastOffset}}
+	if used := generator.Used(); used.Cmp(new(big.Int).Lsh(big.NewInt(1), 128)) != 0 {
+		t.Errorf("expected 2^128 used, output %d", used)
+	}
+	if _, err = generator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+}
+
+func TestNewBigIDGeneratorErrors(t *testing.T) {
+	testCases := []struct {
+		name     string
+		minValue *big.Int
+		maxValue *big.Int
+	}{
+		{"nil", nil, big.NewInt(1)},
+		{"reversed", big.NewInt(2), big.NewInt(1)},
+		{"over 2^128", big.NewInt(-1), maxUint128},
+	}
+	for _, testCase := range testCases {
+		if _, err := NewBigIDGenerator(testCase.minValue, testCase.maxValue); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%s: expected ErrInvalidRange, got %+v", testCase.name, err)
+		}
+	}
+}
+
+func BenchmarkBigIDGenerator(b *testing.B) {
+	generator, err := NewBigIDGenerator(new(big.Int), maxUint128)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for i := 0; i < b.N; i++ {
+		id, err := generator.Allocate()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if i%2 == 0 {
+			if err = generator.FreeID(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
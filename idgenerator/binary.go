@@ -0,0 +1,252 @@
+package idgenerator
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// The first byte of the binary encoding is its layout, add a version when a layout changes
+const (
+	// binaryVersion lists the gaps between the used IDs
+	binaryVersion byte = 1
+	// binaryRunsVersion run-length-encodes the used and free IDs
+	binaryRunsVersion byte = 2
+)
+
+// MarshalBinary encodes the Snapshot of the generator compactly, in the smaller of two layouts.
+// Both start with the version byte, minValue and maxValue as varints and offset as a uvarint.
+// The gaps layout follows with the number of used IDs and the gaps between consecutive used IDs as uvarints,
+// which costs about one byte per used ID. The runs layout follows with 1 if the range starts with a used ID,
+// 0 otherwise, then the lengths minus one of the alternating runs of used and free IDs as uvarints,
+// which sum to the size of the range: its size scales with the fragmentation rather than with the used IDs,
+// so that a nearly full pool of millions of IDs takes a few bytes.
+func (idGenerator *IDGenerator) MarshalBinary() ([]byte, error) {
+	return encodeBinary(idGenerator.Snapshot()), nil
+}
+
+func encodeBinary(snapshot Snapshot) []byte {
+	gaps, runs := encodeGaps(snapshot), encodeRuns(snapshot)
+	if len(runs) < len(gaps) {
+		return runs
+	}
+	return gaps
+}
+
+func encodeGaps(snapshot Snapshot) []byte {
+	data := make([]byte, 0, 1+4*binary.MaxVarintLen64+len(snapshot.Used))
+	data = append(data, binaryVersion)
+	data = appendVarint(data, snapshot.MinValue)
+	data = appendVarint(data, snapshot.MaxValue)
+	data = appendUvarint(data, snapshot.Offset)
+	data = appendUvarint(data, uint64(len(snapshot.Used)))
+	previous := snapshot.MinValue
+	for i, id := range snapshot.Used {
+		if i == 0 {
+			data = appendUvarint(data, uint64(id-previous))
+		} else {
+			data = appendUvarint(data, uint64(id-previous-1))
+		}
+		previous = id
+	}
+	return data
+}
+
+func encodeRuns(snapshot Snapshot) []byte {
+	data := make([]byte, 0, 1+4*binary.MaxVarintLen64)
+	data = append(data, binaryRunsVersion)
+	data = appendVarint(data, snapshot.MinValue)
+	data = appendVarint(data, snapshot.MaxValue)
+	data = appendUvarint(data, snapshot.Offset)
+	// the positions are relative to minValue, last is that of maxValue: a run of the whole int64 range
+	// is 2^64 IDs long, which only fits a uint64 minus one
+	last := uint64(snapshot.MaxValue - snapshot.MinValue)
+	used := snapshot.Used
+	if len(used) == 0 || used[0] != snapshot.MinValue {
+		data = append(data, 0)
+	} else {
+		data = append(data, 1)
+	}
+	next := uint64(0)
+	for i := 0; i < len(used); {
+		start := uint64(used[i] - snapshot.MinValue)
+		if start > next {
+			data = appendUvarint(data, start-next-1)
+		}
+		j := i + 1
+		for j < len(used) && used[j] == used[j-1]+1 {
+			j++
+		}
+		end := start + uint64(j-i-1)
+		data = appendUvarint(data, end-start)
+		if end == last {
+			return data
+		}
+		next, i = end+1, j
+	}
+	return appendUvarint(data, last-next)
+}
+
+func appendVarint(data []byte, value int64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutVarint(buf[:], value)]...)
+}
+
+func appendUvarint(data []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(data, buf[:binary.PutUvarint(buf[:], value)]...)
+}
+
+// UnmarshalBinary replaces the state of the generator with data encoded by MarshalBinary in either layout.
+// It fails with ErrInvalidEncoding on unknown versions, truncated or trailing data, runs not summing
+// exactly to the size of the range,
+// validates the decoded state like RestoreGenerator and leaves the generator unchanged on error.
+func (idGenerator *IDGenerator) UnmarshalBinary(data []byte) error {
+	snapshot, runs, err := decodeBinary(data)
+	if err != nil {
+		return err
+	}
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	if idGenerator.logger == nil {
+		idGenerator.logger = nopLogger{}
+	}
+	if idGenerator.newStore == nil {
+		idGenerator.newStore = NewTreeStore
+	}
+	if idGenerator.clock == nil {
+		idGenerator.clock = realClock{}
+	}
+	return idGenerator.loadRuns(snapshot, runs)
+}
+
+// usedRun is a run of consecutive used IDs of the runs layout. The runs are loaded into the store as such
+// rather than expanded into the Used of a Snapshot, since a few bytes encode a run of up to 2^64 IDs.
+type usedRun struct {
+	first, last int64
+}
+
+// decodeBinary returns the snapshot encoded in data, with the used IDs in Used for the gaps layout
+// and in the runs for the runs layout
+func decodeBinary(data []byte) (Snapshot, []usedRun, error) {
+	var snapshot Snapshot
+	if len(data) == 0 {
+		return snapshot, nil, fmt.Errorf("%w: empty data", ErrInvalidEncoding)
+	}
+	if data[0] != binaryVersion && data[0] != binaryRunsVersion {
+		return snapshot, nil, fmt.Errorf("%w: unknown version %d", ErrInvalidEncoding, data[0])
+	}
+	decoder := binaryDecoder{data: data[1:]}
+	snapshot.MinValue = decoder.varint()
+	snapshot.MaxValue = decoder.varint()
+	snapshot.Offset = decoder.uvarint()
+	if data[0] == binaryRunsVersion {
+		runs, err := decodeRuns(&decoder, snapshot)
+		return snapshot, runs, err
+	}
+	count := decoder.uvarint()
+	if decoder.err == nil && count > uint64(len(decoder.data)) {
+		// every used ID takes at least one byte
+		return snapshot, nil, fmt.Errorf("%w: %d used IDs in %d bytes", ErrInvalidEncoding, count, len(decoder.data))
+	}
+	snapshot.Used = make([]int64, 0, count)
+	id := snapshot.MinValue
+	for i := uint64(0); i < count && decoder.err == nil; i++ {
+		gap := decoder.uvarint()
+		if i == 0 {
+			id += int64(gap)
+		} else {
+			id += int64(gap) + 1
+		}
+		snapshot.Used = append(snapshot.Used, id)
+	}
+	if decoder.err != nil {
+		return snapshot, nil, decoder.err
+	}
+	if len(decoder.data) != 0 {
+		return snapshot, nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(decoder.data))
+	}
+	return snapshot, nil, nil
+}
+
+// decodeRuns returns the used runs of the runs layout of snapshot, whose number is bounded by the data
+func decodeRuns(decoder *binaryDecoder, snapshot Snapshot) ([]usedRun, error) {
+	if decoder.err != nil {
+		return nil, decoder.err
+	}
+	if len(decoder.data) == 0 {
+		return nil, fmt.Errorf("%w: truncated runs", ErrInvalidEncoding)
+	}
+	if snapshot.MinValue > snapshot.MaxValue {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidEncoding,
+			snapshot.MinValue, snapshot.MaxValue)
+	}
+	if decoder.data[0] > 1 {
+		return nil, fmt.Errorf("%w: first run %d neither free nor used", ErrInvalidEncoding, decoder.data[0])
+	}
+	used := decoder.data[0] == 1
+	decoder.data = decoder.data[1:]
+	// left is the number of IDs not covered by the runs yet minus one, which cannot overflow
+	left := uint64(snapshot.MaxValue - snapshot.MinValue)
+	id := snapshot.MinValue
+	var runs []usedRun
+	for {
+		if len(decoder.data) == 0 {
+			return nil, fmt.Errorf("%w: runs cover %d IDs less than [%d, %d]",
+				ErrInvalidEncoding, left+1, snapshot.MinValue, snapshot.MaxValue)
+		}
+		run := decoder.uvarint()
+		if decoder.err != nil {
+			return nil, decoder.err
+		}
+		if run > left {
+			return nil, fmt.Errorf("%w: runs cover %d IDs more than [%d, %d]",
+				ErrInvalidEncoding, run-left, snapshot.MinValue, snapshot.MaxValue)
+		}
+		if used {
+			// run is at most left, the run ends at maxValue at the latest
+			runs = append(runs, usedRun{first: id, last: id + int64(run)})
+		}
+		if run == left {
+			break
+		}
+		id += int64(run) + 1
+		left -= run + 1
+		used = !used
+	}
+	if len(decoder.data) != 0 {
+		return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidEncoding, len(decoder.data))
+	}
+	return runs, nil
+}
+
+// binaryDecoder reads varints until the first error, which is kept in err
+type binaryDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *binaryDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: truncated or malformed varint", ErrInvalidEncoding)
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
+
+func (d *binaryDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	value, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = fmt.Errorf("%w: truncated or malformed uvarint", ErrInvalidEncoding)
+		return 0
+	}
+	d.data = d.data[n:]
+	return value
+}
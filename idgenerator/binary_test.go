@@ -0,0 +1,368 @@
+package idgenerator
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+var (
+	_ encoding.BinaryMarshaler   = (*IDGenerator)(nil)
+	_ encoding.BinaryUnmarshaler = (*IDGenerator)(nil)
+)
+
+func TestBinary(t *testing.T) {
+	testCases := []struct {
+		name     string
+		minValue int64
+		maxValue int64
+		used     []int64
+	}{
+		{"empty", 1, 10, nil},
+		{"negative bounds", -100, -1, []int64{-100, -50, -1}},
+		{"dense", 0, 999, []int64{0, 1, 2, 3, 4, 5, 500, 998, 999}},
+		{"sparse", 0, 1 << 40, []int64{7, 1 << 20, 1 << 39, 1 << 40}},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			idGenerator := NewGenerator(testCase.minValue, testCase.maxValue)
+			for _, id := range testCase.used {
+				if err := idGenerator.AllocateSpecific(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err := idGenerator.Allocate(); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := idGenerator.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var decoded IDGenerator
+			if err = decoded.UnmarshalBinary(data); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(decoded.Snapshot(), idGenerator.Snapshot()) {
+				t.Errorf("expected snapshot %+v, output %+v", idGenerator.Snapshot(), decoded.Snapshot())
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	idGenerator := NewGenerator(1, 1000)
+	for _, id := range []int64{1, 2, 300, 1000} {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := idGenerator.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every truncation must fail cleanly
+	for n := 0; n < len(data); n++ {
+		if err = NewGenerator(1, 2).UnmarshalBinary(data[:n]); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("truncated to %d bytes: expected ErrInvalidEncoding, got %+v", n, err)
+		}
+	}
+
+	unknownVersion := append([]byte{binaryRunsVersion + 1}, data[1:]...)
+	if err = NewGenerator(1, 2).UnmarshalBinary(unknownVersion); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("unknown version: expected ErrInvalidEncoding, got %+v", err)
+	}
+
+	trailing := append(append([]byte{}, data...), 0)
+	if err = NewGenerator(1, 2).UnmarshalBinary(trailing); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("trailing data: expected ErrInvalidEncoding, got %+v", err)
+	}
+
+	outOfRange := encodeBinary(Snapshot{MinValue: 1, MaxValue: 1000, Used: []int64{1, 2, 1001}})
+	decoded := NewGenerator(1, 2)
+	before := decoded.Snapshot()
+	if err = decoded.UnmarshalBinary(outOfRange); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("out of range: expected ErrOutOfRange, got %+v", err)
+	}
+	if !reflect.DeepEqual(decoded.Snapshot(), before) {
+		t.Errorf("generator changed by a failed UnmarshalBinary: %+v", decoded.Snapshot())
+	}
+
+	// a count larger than the remaining data must not be trusted for allocation
+	hugeCount := []byte{binaryVersion, 2, 4, 0, 0xff, 0xff, 0xff, 0xff, 0x0f}
+	if err = decoded.UnmarshalBinary(hugeCount); !errors.Is(err, ErrInvalidEncoding) {
+		t.Errorf("huge count: expected ErrInvalidEncoding, got %+v", err)
+	}
+}
+
+func TestBinaryRuns(t *testing.T) {
+	const size = 1 << 18
+	testCases := []struct {
+		name  string
+		fill  func(t *testing.T, idGenerator *IDGenerator)
+		runs  bool
+		ratio float64
+	}{
+		{"1% used", func(t *testing.T, idGenerator *IDGenerator) {
+			random := rand.New(rand.NewSource(1))
+			for i := 0; i < size/100; i++ {
+				// a taken ID is drawn again
+				_ = idGenerator.AllocateSpecific(random.Int63n(size) + 1)
+			}
+		}, false, 1},
+		{"99% used", func(t *testing.T, idGenerator *IDGenerator) {
+			if err := idGenerator.ReserveRange(1, size); err != nil {
+				t.Fatal(err)
+			}
+			random := rand.New(rand.NewSource(1))
+			for i := 0; i < size/100; i++ {
+				_ = idGenerator.FreeID(random.Int63n(size) + 1)
+			}
+		}, true, 0.1},
+		{"checkerboard", func(t *testing.T, idGenerator *IDGenerator) {
+			for id := int64(1); id <= size; id += 2 {
+				if err := idGenerator.AllocateSpecific(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}, false, 1},
+		{"full", func(t *testing.T, idGenerator *IDGenerator) {
+			if err := idGenerator.ReserveRange(1, size); err != nil {
+				t.Fatal(err)
+			}
+		}, true, 0.001},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			idGenerator := NewGenerator(1, size)
+			testCase.fill(t, idGenerator)
+			snapshot := idGenerator.Snapshot()
+			gaps, runs := encodeGaps(snapshot), encodeRuns(snapshot)
+			t.Logf("%d used IDs: %d bytes as gaps, %d bytes as runs", len(snapshot.Used), len(gaps), len(runs))
+			if testCase.runs != (len(runs) < len(gaps)) {
+				t.Errorf("expected the runs smaller: %t", testCase.runs)
+			}
+			if testCase.runs && float64(len(runs)) > testCase.ratio*float64(len(gaps)) {
+				t.Errorf("expected the runs under %g of the gaps", testCase.ratio)
+			}
+
+			data, err := idGenerator.MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			expected := gaps
+			if testCase.runs {
+				expected = runs
+			}
+			if !bytes.Equal(data, expected) {
+				t.Errorf("expected the smaller encoding, got %d bytes", len(data))
+			}
+			for _, encoded := range [][]byte{gaps, runs} {
+				var decoded IDGenerator
+				if err = decoded.UnmarshalBinary(encoded); err != nil {
+					t.Fatal(err)
+				}
+				if !reflect.DeepEqual(decoded.Snapshot(), snapshot) {
+					t.Errorf("version %d: snapshot not restored", encoded[0])
+				}
+			}
+		})
+	}
+}
+
+func TestBinaryRunsEdges(t *testing.T) {
+	testCases := []Snapshot{
+		{MinValue: 1, MaxValue: 10, Used: []int64{}},
+		{MinValue: 1, MaxValue: 10, Used: []int64{1, 2, 3}},
+		{MinValue: 1, MaxValue: 10, Used: []int64{8, 9, 10}},
+		{MinValue: 1, MaxValue: 10, Used: []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}},
+		{MinValue: 5, MaxValue: 5, Used: []int64{5}},
+		{MinValue: math.MinInt64, MaxValue: math.MaxInt64, Used: []int64{}},
+		{MinValue: math.MinInt64, MaxValue: math.MaxInt64, Used: []int64{math.MinInt64, -1, 0, math.MaxInt64}},
+	}
+	for _, snapshot := range testCases {
+		decoded, runs, err := decodeBinary(encodeRuns(snapshot))
+		if err != nil {
+			t.Fatalf("%+v: %v", snapshot, err)
+		}
+		decoded.Used = []int64{}
+		for _, run := range runs {
+			for id := run.first; ; id++ {
+				decoded.Used = append(decoded.Used, id)
+				if id == run.last {
+					break
+				}
+			}
+		}
+		if !reflect.DeepEqual(decoded, snapshot) {
+			t.Errorf("expected %+v, output %+v", snapshot, decoded)
+		}
+	}
+}
+
+func TestUnmarshalBinaryRunsErrors(t *testing.T) {
+	idGenerator := NewGenerator(1, 1000)
+	if err := idGenerator.ReserveRange(1, 999); err != nil {
+		t.Fatal(err)
+	}
+	data := encodeRuns(idGenerator.Snapshot())
+	for n := 0; n < len(data); n++ {
+		if err := NewGenerator(1, 2).UnmarshalBinary(data[:n]); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("truncated to %d bytes: expected ErrInvalidEncoding, got %+v", n, err)
+		}
+	}
+
+	// the header of [1, 10] at offset 0
+	header := []byte{binaryRunsVersion, 2, 20, 0}
+	for name, runs := range map[string][]byte{
+		"short":    {0, 4, 3},
+		"long":     {0, 4, 5},
+		"past":     {1, 9, 0},
+		"trailing": {1, 4, 4, 0},
+		"first":    {2, 9},
+		"empty":    {},
+	} {
+		if err := NewGenerator(1, 2).UnmarshalBinary(append(append([]byte{}, header...), runs...)); !errors.Is(err,
+			ErrInvalidEncoding) {
+			t.Errorf("%s runs: expected ErrInvalidEncoding, got %+v", name, err)
+		}
+	}
+	if err := NewGenerator(1, 2).UnmarshalBinary([]byte{binaryRunsVersion, 20, 2, 0, 0, 9}); !errors.Is(err,
+		ErrInvalidEncoding) {
+		t.Errorf("minValue > maxValue: expected ErrInvalidEncoding, got %+v", err)
+	}
+}
+
+func TestUnmarshalBinaryLongRuns(t *testing.T) {
+	// the runs start and end across the words of the bitmap
+	snapshot := Snapshot{MinValue: 0, MaxValue: 200, Offset: 131}
+	for _, run := range [][2]int64{{0, 0}, {60, 130}, {192, 200}} {
+		for id := run[0]; id <= run[1]; id++ {
+			snapshot.Used = append(snapshot.Used, id)
+		}
+	}
+	for _, storeOption := range storeOptions {
+		idGenerator, err := NewGeneratorWithOptions(0, 0, storeOption.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = idGenerator.UnmarshalBinary(encodeRuns(snapshot)); err != nil {
+			t.Fatalf("%s: %v", storeOption.name, err)
+		}
+		if !reflect.DeepEqual(idGenerator.Snapshot(), snapshot) {
+			t.Errorf("%s: snapshot not restored", storeOption.name)
+		}
+		if err = idGenerator.Validate(); err != nil {
+			t.Errorf("%s: %v", storeOption.name, err)
+		}
+	}
+
+	// a run of 2^40 IDs in 16 bytes
+	data := []byte{binaryRunsVersion}
+	data = appendVarint(data, 0)
+	data = appendVarint(data, 1<<40-1)
+	data = appendUvarint(data, 0)
+	data = append(data, 1)
+	data = appendUvarint(data, 1<<40-1)
+	var tree IDGenerator
+	if err := tree.UnmarshalBinary(data); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("tree store: expected ErrInvalidRange, got %+v", err)
+	}
+	intervals, err := NewGeneratorWithOptions(0, 0, WithIntervalStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = intervals.UnmarshalBinary(data); err != nil {
+		t.Fatalf("interval store: %v", err)
+	}
+	if used := intervals.Used(); used != 1<<40 || !intervals.IsAllocated(1<<39) {
+		t.Errorf("expected the 2^40 IDs allocated, output %d", used)
+	}
+	if _, err = intervals.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	// a run over an excluded ID fails the load before setting any ID
+	excluded, err := NewGeneratorWithExclusions(1, 10, []int64{3}, WithIntervalStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = excluded.UnmarshalBinary(encodeRuns(Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{1, 2, 3, 4}}))
+	if !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	if used := excluded.Used(); used != 0 {
+		t.Errorf("expected no ID allocated, output %d", used)
+	}
+}
+
+func TestUnmarshalBinaryCorrupt(t *testing.T) {
+	idGenerator := NewGenerator(1, 1000)
+	allocateN(t, idGenerator, 600)
+	if _, err := idGenerator.FreeRange(100, 200); err != nil {
+		t.Fatal(err)
+	}
+	data := encodeRuns(idGenerator.Snapshot())
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 10000; i++ {
+		corrupt := append([]byte{}, data...)
+		corrupt[random.Intn(len(corrupt))] ^= byte(1 << random.Intn(8))
+		// the load either fails or restores a valid state
+		var decoded IDGenerator
+		if err := decoded.UnmarshalBinary(corrupt); err == nil {
+			if err = decoded.Validate(); err != nil {
+				t.Fatalf("%x: %v", corrupt, err)
+			}
+		}
+	}
+}
+
+func BenchmarkEncoding(b *testing.B) {
+	// 1M used IDs, every other ID of the range
+	idGenerator := NewGenerator(1, 1<<21)
+	for id := int64(1); id <= 1<<21; id += 2 {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("binary", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			data, err := idGenerator.MarshalBinary()
+			if err != nil {
+				b.Fatal(err)
+			}
+			var decoded IDGenerator
+			if err = decoded.UnmarshalBinary(data); err != nil {
+				b.Fatal(err)
+			}
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "encoded-bytes")
+	})
+
+	b.Run("json", func(b *testing.B) {
+		var size int
+		for i := 0; i < b.N; i++ {
+			data, err := json.Marshal(idGenerator)
+			if err != nil {
+				b.Fatal(err)
+			}
+			var decoded IDGenerator
+			if err = json.Unmarshal(data, &decoded); err != nil {
+				b.Fatal(err)
+			}
+			size = len(data)
+		}
+		b.ReportMetric(float64(size), "encoded-bytes")
+	})
+}
@@ -0,0 +1,143 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// maxBitmapLast is the last offset of the largest bitmap, which takes 128 GiB
+const maxBitmapLast = 1<<40 - 1
+
+// bitmapStore is a Store using one bit per offset
+type bitmapStore struct {
+	last  uint64
+	words []uint64
+}
+
+// NewBitmapStore returns the Store of WithBitmapStore for the offsets in [0, last],
+// or an error if last is beyond 2^40 - 1
+func NewBitmapStore(last uint64) (Store, error) {
+	if last > maxBitmapLast {
+		return nil, fmt.Errorf("more than %d IDs for the bitmap store", uint64(maxBitmapLast)+1)
+	}
+	s := &bitmapStore{
+		last:  last,
+		words: make([]uint64, last>>6+1),
+	}
+	s.Reset()
+	return s, nil
+}
+
+func (s *bitmapStore) Has(offset uint64) bool {
+	return s.words[offset>>6]&(1<<(offset&63)) != 0
+}
+
+func (s *bitmapStore) Set(offset uint64) {
+	s.words[offset>>6] |= 1 << (offset & 63)
+}
+
+// SetRange sets [first, last] a word at a time, the words of the bitmap are all allocated already
+func (s *bitmapStore) SetRange(first, last uint64) {
+	for w := first >> 6; w <= last>>6; w++ {
+		mask := ^uint64(0)
+		if w == first>>6 {
+			mask &^= 1<<(first&63) - 1
+		}
+		if w == last>>6 && last&63 != 63 {
+			mask &= 1<<(last&63+1) - 1
+		}
+		s.words[w] |= mask
+	}
+}
+
+func (s *bitmapStore) Clear(offset uint64) {
+	s.words[offset>>6] &^= 1 << (offset & 63)
+}
+
+func (s *bitmapStore) NextClear(from uint64) (uint64, bool) {
+	if from > s.last {
+		return 0, false
+	}
+	i := from >> 6
+	// treat the bits below from as set
+	word := s.words[i] | (1<<(from&63) - 1)
+	for word == ^uint64(0) {
+		i++
+		if i == uint64(len(s.words)) {
+			return 0, false
+		}
+		word = s.words[i]
+	}
+	// the padding bits past last are always set, so the result is not above last
+	return i<<6 + uint64(bits.TrailingZeros64(^word)), true
+}
+
+func (s *bitmapStore) NextSet(from uint64) (uint64, bool) {
+	if from > s.last {
+		return 0, false
+	}
+	i := from >> 6
+	// treat the bits below from as clear
+	word := s.words[i] &^ (1<<(from&63) - 1)
+	for word == 0 {
+		i++
+		if i == uint64(len(s.words)) {
+			return 0, false
+		}
+		word = s.words[i]
+	}
+	if offset := i<<6 + uint64(bits.TrailingZeros64(word)); offset <= s.last {
+		return offset, true
+	}
+	// only the padding bits are set
+	return 0, false
+}
+
+func (s *bitmapStore) NthClear(n uint64) uint64 {
+	// the padding bits are set, so they are never counted
+	i := 0
+	for ; ; i++ {
+		free := uint64(bits.OnesCount64(^s.words[i]))
+		if n < free {
+			break
+		}
+		n -= free
+	}
+	word := ^s.words[i]
+	for ; n > 0; n-- {
+		word &= word - 1
+	}
+	return uint64(i)<<6 + uint64(bits.TrailingZeros64(word))
+}
+
+func (s *bitmapStore) SetOffsets() []uint64 {
+	var offsets []uint64
+	for i, word := range s.words {
+		if i == len(s.words)-1 {
+			// drop the padding bits
+			word &= ^uint64(0) >> (63 - s.last&63)
+		}
+		for word != 0 {
+			offsets = append(offsets, uint64(i)<<6+uint64(bits.TrailingZeros64(word)))
+			word &= word - 1
+		}
+	}
+	return offsets
+}
+
+func (s *bitmapStore) Reset() {
+	for i := range s.words {
+		s.words[i] = 0
+	}
+	// mark the padding bits of the last word as used so nextClear never returns them
+	if tail := s.last&63 + 1; tail != 64 {
+		s.words[len(s.words)-1] = ^uint64(0) << tail
+	}
+}
+
+func (s *bitmapStore) Clone() Store {
+	return &bitmapStore{
+		last:  s.last,
+		words: append([]uint64(nil), s.words...),
+	}
+}
@@ -0,0 +1,58 @@
+package idgenerator
+
+// FreeBlocks returns the free IDs as maximal ranges of consecutive IDs [first, last] in ascending order,
+// e.g. [[1200 1999] [3500 3501]], for capacity reports. The excluded and quarantined IDs are not free.
+// It walks the allocated IDs rather than the free ones, so a large sparse generator gives few blocks quickly,
+// use FreeBlocksFrom to page through a fragmented one.
+func (idGenerator *IDGenerator) FreeBlocks() [][2]int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	blocks, _, _ := idGenerator.freeBlocksLocked(0, 0)
+	return blocks
+}
+
+// FreeBlocksFrom returns at most limit blocks like FreeBlocks, starting with the free IDs from from on,
+// a block around from being cut at from. more reports whether blocks are left,
+// next is then the from of the next page, the first ID of the first block left.
+// A limit of 0 or below returns every block.
+func (idGenerator *IDGenerator) FreeBlocksFrom(from int64, limit int) (blocks [][2]int64, next int64, more bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if from > idGenerator.maxValue {
+		return nil, 0, false
+	}
+	offset := uint64(0)
+	if from > idGenerator.minValue {
+		if offset = idGenerator.step.ceilOffset(idGenerator.minValue, from); offset > idGenerator.lastOffset {
+			return nil, 0, false
+		}
+	}
+	blocks, nextOffset, more := idGenerator.freeBlocksLocked(offset, limit)
+	if more {
+		next = idGenerator.toID(nextOffset)
+	}
+	return blocks, next, more
+}
+
+// freeBlocksLocked returns at most limit free blocks from offset from on, all of them if limit <= 0,
+// and the first offset of the next block if there is one left. The caller must hold lock.
+func (idGenerator *IDGenerator) freeBlocksLocked(from uint64, limit int) ([][2]int64, uint64, bool) {
+	var blocks [][2]int64
+	start, ok := idGenerator.store.NextClear(from)
+	for ok {
+		if limit > 0 && len(blocks) == limit {
+			return blocks, start, true
+		}
+		last := idGenerator.lastOffset
+		end, bounded := idGenerator.store.NextSet(start)
+		if bounded {
+			last = end - 1
+		}
+		blocks = append(blocks, [2]int64{idGenerator.toID(start), idGenerator.toID(last)})
+		if !bounded {
+			break
+		}
+		start, ok = idGenerator.store.NextClear(end)
+	}
+	return blocks, 0, false
+}
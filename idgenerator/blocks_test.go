@@ -0,0 +1,93 @@
+package idgenerator
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestFreeBlocks(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(1000, 3999, []int64{3502}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if expected := [][2]int64{{1000, 3501}, {3503, 3999}}; !reflect.DeepEqual(idGenerator.FreeBlocks(), expected) {
+				t.Errorf("expected %v, output %v", expected, idGenerator.FreeBlocks())
+			}
+			for _, block := range [][2]int64{{1000, 1199}, {2000, 3499}, {3503, 3999}} {
+				if err = idGenerator.ReserveRange(block[0], block[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected := [][2]int64{{1200, 1999}, {3500, 3501}}
+			if blocks := idGenerator.FreeBlocks(); !reflect.DeepEqual(blocks, expected) {
+				t.Errorf("expected %v, output %v", expected, blocks)
+			}
+			allocateN(t, idGenerator, 802)
+			if blocks := idGenerator.FreeBlocks(); blocks != nil {
+				t.Errorf("expected no free block, output %v", blocks)
+			}
+		})
+	}
+}
+
+func TestFreeBlocksSparse(t *testing.T) {
+	idGenerator := NewGenerator(math.MinInt64, math.MaxInt64)
+	for _, id := range []int64{math.MinInt64, 0, math.MaxInt64} {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected := [][2]int64{{math.MinInt64 + 1, -1}, {1, math.MaxInt64 - 1}}
+	if blocks := idGenerator.FreeBlocks(); !reflect.DeepEqual(blocks, expected) {
+		t.Errorf("expected %v, output %v", expected, blocks)
+	}
+}
+
+func TestFreeBlocksFrom(t *testing.T) {
+	idGenerator := NewGenerator(1, 20)
+	// free blocks: 1-2, 4-5, ..., 19-20
+	for id := int64(3); id <= 18; id += 3 {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var pages [][][2]int64
+	from, more := int64(math.MinInt64), true
+	for more {
+		var blocks [][2]int64
+		blocks, from, more = idGenerator.FreeBlocksFrom(from, 3)
+		pages = append(pages, blocks)
+	}
+	expected := [][][2]int64{
+		{{1, 2}, {4, 5}, {7, 8}},
+		{{10, 11}, {13, 14}, {16, 17}},
+		{{19, 20}},
+	}
+	if !reflect.DeepEqual(pages, expected) {
+		t.Errorf("expected pages %v, output %v", expected, pages)
+	}
+
+	testCases := []struct {
+		from     int64
+		limit    int
+		expected [][2]int64
+		next     int64
+		more     bool
+	}{
+		// the block around from is cut
+		{8, 1, [][2]int64{{8, 8}}, 10, true},
+		{17, 0, [][2]int64{{17, 17}, {19, 20}}, 0, false},
+		{20, 5, [][2]int64{{20, 20}}, 0, false},
+		{21, 5, nil, 0, false},
+	}
+	for _, testCase := range testCases {
+		blocks, next, more := idGenerator.FreeBlocksFrom(testCase.from, testCase.limit)
+		if !reflect.DeepEqual(blocks, testCase.expected) || next != testCase.next || more != testCase.more {
+			t.Errorf("FreeBlocksFrom(%d, %d): expected %v, %d, %v, output %v, %d, %v", testCase.from, testCase.limit,
+				testCase.expected, testCase.next, testCase.more, blocks, next, more)
+		}
+	}
+}
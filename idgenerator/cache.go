@@ -0,0 +1,208 @@
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// idCache holds the IDs of Preallocate, ids has room for every cached ID so that filling it never blocks
+type idCache struct {
+	ids chan *cachedID
+}
+
+// cachedID is an ID of the cache, taken once by the FastAllocate handing it out, or by the free of the ID
+// before, such as FreeRange or Reset, so that the FastAllocate popping it then skips it
+type cachedID struct {
+	id    int64
+	taken atomic.Bool
+}
+
+// Preallocate allocates n IDs at once, like AllocateMany, into a cache which FastAllocate takes them from
+// without the lock of the generator, for the callers which cannot afford to wait for it.
+// The cached IDs are allocated: they count as used and the hook of WithOnAllocate is called now rather than
+// when FastAllocate hands them out. Flush returns those not handed out to the generator, Reset drops them,
+// and so does the load of a state. A cached ID freed by other means, e.g. FreeRange, leaves the cache.
+// It fails like AllocateMany, nothing is cached then.
+func (idGenerator *IDGenerator) Preallocate(n int) error {
+	if n < 0 {
+		return fmt.Errorf("Preallocate: invalid count %d", n)
+	}
+	idGenerator.cacheLock.Lock()
+	defer idGenerator.cacheLock.Unlock()
+	// the IDs are cached under the lock they are allocated with, so that no free gets in between
+	_, err := idGenerator.allocateManyCtx(context.Background(), "Preallocate", n, idGenerator.cacheLocked)
+	if idGenerator.recorder != nil {
+		// the cached IDs are recorded as the FastAllocate handing them out returns them
+		idGenerator.record(opPreallocate, []int64{int64(n)}, nil, err)
+	}
+	return err
+}
+
+// cacheLocked adds ids to the cache, the caller must hold cacheLock and lock
+func (idGenerator *IDGenerator) cacheLocked(ids []int64) {
+	cache := idGenerator.cache.Load()
+	if cache == nil || cap(cache.ids)-len(cache.ids) < len(ids) {
+		// a FastAllocate on the old cache meanwhile falls back to Allocate if it finds it drained
+		grown := &idCache{ids: make(chan *cachedID, idGenerator.cachedLen()+len(ids))}
+		if cache != nil {
+			drainCache(cache, grown.ids)
+		}
+		idGenerator.cache.Store(grown)
+		cache = grown
+	}
+	if idGenerator.cachedIDs == nil {
+		idGenerator.cachedIDs = make(map[int64]*cachedID, len(ids))
+	}
+	for _, id := range ids {
+		entry := &cachedID{id: id}
+		idGenerator.cachedIDs[id] = entry
+		idGenerator.cachedLeft.Add(1)
+		cache.ids <- entry
+	}
+}
+
+// FastAllocate returns an ID cached by Preallocate without taking the lock of the generator,
+// or allocates one like Allocate once the cache is empty.
+func (idGenerator *IDGenerator) FastAllocate() (int64, error) {
+	id, err := idGenerator.fastAllocate()
+	if idGenerator.recorder != nil {
+		idGenerator.record(opFast, nil, []int64{id}, err)
+	}
+	return id, err
+}
+
+// fastAllocate is FastAllocate without recording it
+func (idGenerator *IDGenerator) fastAllocate() (int64, error) {
+	for cache := idGenerator.cache.Load(); cache != nil; {
+		select {
+		case entry := <-cache.ids:
+			// an entry taken already was freed since it was cached, the next one may not be
+			if idGenerator.takeCached(entry) {
+				return entry.id, nil
+			}
+		default:
+			cache = nil
+		}
+	}
+	return idGenerator.allocate()
+}
+
+// Cached returns the number of IDs cached by Preallocate which FastAllocate has not handed out yet
+func (idGenerator *IDGenerator) Cached() int {
+	return int(idGenerator.cachedLeft.Load())
+}
+
+// takeCached takes entry unless it is taken already, and reports whether it took it
+func (idGenerator *IDGenerator) takeCached(entry *cachedID) bool {
+	if !entry.taken.CompareAndSwap(false, true) {
+		return false
+	}
+	idGenerator.cachedLeft.Add(-1)
+	return true
+}
+
+// uncacheLocked takes the entry of the ID at offset, which is freed, the caller must hold lock
+func (idGenerator *IDGenerator) uncacheLocked(offset uint64) {
+	if len(idGenerator.cachedIDs) == 0 {
+		return
+	}
+	id := idGenerator.toID(offset)
+	if entry, ok := idGenerator.cachedIDs[id]; ok {
+		idGenerator.takeCached(entry)
+		delete(idGenerator.cachedIDs, id)
+	}
+}
+
+// dropCacheLocked empties the cache without freeing its IDs, the caller must hold lock
+func (idGenerator *IDGenerator) dropCacheLocked() {
+	for _, entry := range idGenerator.cachedIDs {
+		idGenerator.takeCached(entry)
+	}
+	idGenerator.cachedIDs = nil
+	idGenerator.cache.Store(nil)
+}
+
+// cloneCacheLocked returns a cache holding the IDs of the cache not handed out yet in ascending order,
+// with their entries, nil if there is none. The caller must hold lock.
+func (idGenerator *IDGenerator) cloneCacheLocked() (*idCache, map[int64]*cachedID) {
+	var ids []int64
+	for id, entry := range idGenerator.cachedIDs {
+		if !entry.taken.Load() {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	cache := &idCache{ids: make(chan *cachedID, len(ids))}
+	entries := make(map[int64]*cachedID, len(ids))
+	for _, id := range ids {
+		entry := &cachedID{id: id}
+		entries[id] = entry
+		cache.ids <- entry
+	}
+	return cache, entries
+}
+
+func (idGenerator *IDGenerator) cachedLen() int {
+	if cache := idGenerator.cache.Load(); cache != nil {
+		return len(cache.ids)
+	}
+	return 0
+}
+
+// Flush frees the IDs cached by Preallocate which FastAllocate has not handed out, e.g. at shutdown,
+// and returns how many it freed. The cached IDs freed by other means since are skipped.
+func (idGenerator *IDGenerator) Flush() int {
+	idGenerator.cacheLock.Lock()
+	defer idGenerator.cacheLock.Unlock()
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	freed := idGenerator.flushCacheLocked()
+	idGenerator.unlock()
+	if idGenerator.recorder != nil {
+		idGenerator.record(opFlush, nil, []int64{int64(freed)}, nil)
+	}
+	return freed
+}
+
+// flushCacheLocked is Flush, the caller must hold cacheLock and lock
+func (idGenerator *IDGenerator) flushCacheLocked() int {
+	cache := idGenerator.cache.Load()
+	if cache == nil {
+		return 0
+	}
+	drained := make(chan *cachedID, len(cache.ids))
+	drainCache(cache, drained)
+	close(drained)
+
+	freed := 0
+	for entry := range drained {
+		if !idGenerator.takeCached(entry) {
+			continue
+		}
+		if err := idGenerator.freeLocked(entry.id); err == nil {
+			freed++
+		}
+	}
+	return freed
+}
+
+// drainCache moves the entries of cache not taken to ids, which must have room for them, without blocking
+func drainCache(cache *idCache, ids chan<- *cachedID) {
+	for {
+		select {
+		case entry := <-cache.ids:
+			if !entry.taken.Load() {
+				ids <- entry
+			}
+		default:
+			return
+		}
+	}
+}
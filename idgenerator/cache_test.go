@@ -0,0 +1,193 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestPreallocate(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	if err := idGenerator.Preallocate(3); err != nil {
+		t.Fatal(err)
+	}
+	if used, cached := idGenerator.Used(), idGenerator.Cached(); used != 3 || cached != 3 {
+		t.Errorf("expected 3 used and 3 cached, output %d and %d", used, cached)
+	}
+	// the cache grows past its first size
+	if err := idGenerator.Preallocate(4); err != nil {
+		t.Fatal(err)
+	}
+	for expected := int64(1); expected <= 7; expected++ {
+		if id, err := idGenerator.FastAllocate(); err != nil || id != expected {
+			t.Fatalf("expected cached ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	// the cache is empty, FastAllocate falls back to Allocate
+	if id, err := idGenerator.FastAllocate(); err != nil || id != 8 {
+		t.Errorf("expected ID 8, output %d, %+v", id, err)
+	}
+	if err := idGenerator.Preallocate(3); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err := idGenerator.Preallocate(-1); err == nil {
+		t.Error("expected an error for a negative count")
+	}
+	if cached := idGenerator.Cached(); cached != 0 {
+		t.Errorf("expected nothing cached after the failures, output %d", cached)
+	}
+
+	if err := idGenerator.Preallocate(2); err != nil {
+		t.Fatal(err)
+	}
+	// a cached ID freed by mistake is skipped
+	if err := idGenerator.FreeID(9); err != nil {
+		t.Fatal(err)
+	}
+	if freed := idGenerator.Flush(); freed != 1 {
+		t.Errorf("expected 1 cached ID freed, output %d", freed)
+	}
+	if used, cached := idGenerator.Used(), idGenerator.Cached(); used != 8 || cached != 0 {
+		t.Errorf("expected 8 used and nothing cached after Flush, output %d and %d", used, cached)
+	}
+
+	if err := idGenerator.Preallocate(2); err != nil {
+		t.Fatal(err)
+	}
+	idGenerator.Reset()
+	if cached := idGenerator.Cached(); cached != 0 {
+		t.Errorf("expected the cache dropped by Reset, output %d", cached)
+	}
+}
+
+func TestPreallocateFreed(t *testing.T) {
+	idGenerator := NewGenerator(1, 3)
+	if err := idGenerator.Preallocate(3); err != nil {
+		t.Fatal(err)
+	}
+	// the IDs freed by other means leave the cache, they are not handed out twice
+	if freed, err := idGenerator.FreeRange(1, 2); err != nil || freed != 2 {
+		t.Fatalf("expected 2 IDs freed, output %d, %+v", freed, err)
+	}
+	if cached := idGenerator.Cached(); cached != 1 {
+		t.Errorf("expected 1 ID cached, output %d", cached)
+	}
+	if id, err := idGenerator.FastAllocate(); err != nil || id != 3 {
+		t.Fatalf("expected cached ID 3, output %d, %+v", id, err)
+	}
+	if ids := allocateN(t, idGenerator, 2); !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("expected IDs 1 and 2 allocated, output %v", ids)
+	}
+	if _, err := idGenerator.FastAllocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	// a loaded state drops the cache, its IDs are those of the state
+	data, err := NewGenerator(1, 3).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Preallocate(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if cached := idGenerator.Cached(); cached != 0 {
+		t.Errorf("expected the cache dropped by the load, output %d", cached)
+	}
+	if ids := allocateN(t, idGenerator, 3); !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("expected IDs 1 to 3 allocated, output %v", ids)
+	}
+}
+
+func TestCloneCache(t *testing.T) {
+	idGenerator := NewGenerator(1, 3)
+	if err := idGenerator.Preallocate(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idGenerator.FastAllocate(); err != nil {
+		t.Fatal(err)
+	}
+	// the clone hands out ID 2 from its cache and may flush it
+	clone := idGenerator.Clone()
+	if cached := clone.Cached(); cached != 1 {
+		t.Fatalf("expected 1 ID cached in the clone, output %d", cached)
+	}
+	if freed := clone.Flush(); freed != 1 || clone.IsAllocated(2) {
+		t.Errorf("expected ID 2 flushed from the clone, output %d", freed)
+	}
+	if id, err := idGenerator.FastAllocate(); err != nil || id != 2 {
+		t.Errorf("expected cached ID 2, output %d, %+v", id, err)
+	}
+}
+
+func TestFastAllocateConcurrent(t *testing.T) {
+	// half of the IDs come from the cache, the others from Allocate
+	idGenerator := NewGenerator(1, 2000)
+	if err := idGenerator.Preallocate(500); err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 250; j++ {
+				id, err := idGenerator.FastAllocate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("ID %d allocated twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 1000 || idGenerator.Used() != 1000 {
+		t.Errorf("expected 1000 IDs allocated, output %d, used %d", len(seen), idGenerator.Used())
+	}
+}
+
+// BenchmarkFastAllocate compares Allocate with FastAllocate from a cache filled beforehand,
+// for GOMAXPROCS goroutines allocating at once
+func BenchmarkFastAllocate(b *testing.B) {
+	b.Run("Allocate", func(b *testing.B) {
+		idGenerator := NewGenerator(1, int64(b.N)+1)
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := idGenerator.Allocate(); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+	b.Run("FastAllocate", func(b *testing.B) {
+		idGenerator := NewGenerator(1, int64(b.N)+1)
+		if err := idGenerator.Preallocate(b.N); err != nil {
+			b.Fatal(err)
+		}
+		b.ResetTimer()
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := idGenerator.FastAllocate(); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
@@ -0,0 +1,21 @@
+package idgenerator
+
+import "time"
+
+// Clock is the time source of IDGenerator and of the types built on it, replace it with WithClock in tests,
+// e.g. with the FakeClock of idgeneratortest, so that the time-based features run without sleeping
+type Clock interface {
+	Now() time.Time
+	// After sends the time on the channel returned once d has elapsed, like time.After
+	After(d time.Duration) <-chan time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
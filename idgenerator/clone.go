@@ -0,0 +1,146 @@
+package idgenerator
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clone returns an independent copy of the generator, e.g. to try allocations out and throw them away.
+// The copy has the same range, allocated IDs and their owners, leases, exclusions, permanent IDs, quarantine,
+// IDs cached by Preallocate, bindings of AllocateSticky, counters and stacks of WithStrictMode, and the same
+// options, and it is closed if the generator is, with these exceptions:
+//   - it does not run the hooks of WithOnAllocate and WithOnFree, the observer of WithHoldTimes, the watermarks
+//     nor the report of WithLeakDetection, which does not track its IDs;
+//   - it does not write its calls to the writer of WithRecorder, which records the calls of the generator only;
+//   - it does not extend its range with the provider of WithRangeProvider, whose ranges are for the generator.
+//
+// It shares the random source of WithRandomAllocationFrom, which must then be safe for concurrent use
+// if both generators allocate at the same time. The AllocateCtx and WaitForID calls waiting on the generator
+// stay with it, and so do the children of Split, which only the generator joins.
+func (idGenerator *IDGenerator) Clone() *IDGenerator {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+
+	clone := &IDGenerator{
+		allocateFailures:  atomic.LoadUint64(&idGenerator.allocateFailures),
+		allocateRetries:   atomic.LoadUint64(&idGenerator.allocateRetries),
+		logger:            idGenerator.logger,
+		filter:            idGenerator.filter,
+		band:              idGenerator.band,
+		fairWaiting:       idGenerator.fairWaiting,
+		eventLog:          idGenerator.eventLog,
+		name:              idGenerator.name,
+		minValue:          idGenerator.minValue,
+		maxValue:          idGenerator.maxValue,
+		lastOffset:        idGenerator.lastOffset,
+		reservationSerial: idGenerator.reservationSerial,
+		step:              idGenerator.step,
+		offset:            idGenerator.offset,
+		store:             idGenerator.store.Clone(),
+		newStore:          idGenerator.newStore,
+		used:              idGenerator.used,
+		reuseDelay:        idGenerator.reuseDelay,
+		quarantine:        append([]leaseEntry(nil), idGenerator.quarantine...),
+		allocations:       idGenerator.allocations,
+		frees:             idGenerator.frees,
+		peakUsed:          idGenerator.peakUsed,
+		peakAt:            idGenerator.peakAt,
+		clock:             idGenerator.clock,
+		leaseHeap:         append(leaseHeap(nil), idGenerator.leaseHeap...),
+		strategy:          idGenerator.strategy,
+		random:            idGenerator.random,
+		peeked:            idGenerator.peeked,
+		hasPeeked:         idGenerator.hasPeeked,
+		formatter:         idGenerator.formatter,
+		uuids:             idGenerator.uuids,
+		defaultQuota:      idGenerator.defaultQuota,
+		hasDefaultQuota:   idGenerator.hasDefaultQuota,
+		closed:            idGenerator.closed,
+	}
+	if idGenerator.strict != nil {
+		clone.strict = idGenerator.strict.clone()
+	}
+	// the copy hands out the IDs cached and not handed out yet from a cache of its own
+	cache, cachedIDs := idGenerator.cloneCacheLocked()
+	clone.cache.Store(cache)
+	clone.cachedIDs = cachedIDs
+	clone.cachedLeft.Store(int64(len(cachedIDs)))
+	if idGenerator.excluded != nil {
+		clone.excluded = make(map[uint64]struct{}, len(idGenerator.excluded))
+		for offset := range idGenerator.excluded {
+			clone.excluded[offset] = struct{}{}
+		}
+	}
+	if idGenerator.permanent != nil {
+		clone.permanent = make(map[int64]struct{}, len(idGenerator.permanent))
+		for id := range idGenerator.permanent {
+			clone.permanent[id] = struct{}{}
+		}
+	}
+	if idGenerator.generations != nil {
+		clone.generations = make(map[uint64]uint64, len(idGenerator.generations))
+		for offset, generation := range idGenerator.generations {
+			clone.generations[offset] = generation
+		}
+	}
+	if idGenerator.refs != nil {
+		clone.refs = make(map[uint64]uint64, len(idGenerator.refs))
+		for offset, refs := range idGenerator.refs {
+			clone.refs[offset] = refs
+		}
+		clone.extraRefs = idGenerator.extraRefs
+	}
+	if idGenerator.recycling != nil {
+		clone.recycling = idGenerator.recycling.clone()
+	}
+	// the copy has a limit of its own, starting with the tokens left
+	if idGenerator.limiter != nil {
+		clone.limiter = idGenerator.limiter.clone()
+	}
+	if idGenerator.audit != nil {
+		clone.audit = idGenerator.audit.clone()
+	}
+	if idGenerator.sticky != nil {
+		clone.sticky = idGenerator.sticky.clone()
+	}
+	if idGenerator.heldSince != nil {
+		clone.heldSince = make(map[uint64]time.Time, len(idGenerator.heldSince))
+		for offset, since := range idGenerator.heldSince {
+			clone.heldSince[offset] = since
+		}
+	}
+	for offset, set := range idGenerator.ownerOf {
+		clone.ownLocked(offset, set.name)
+	}
+	if idGenerator.quotas != nil {
+		clone.quotas = make(map[string]int64, len(idGenerator.quotas))
+		for owner, quota := range idGenerator.quotas {
+			clone.quotas[owner] = quota
+		}
+	}
+	if idGenerator.quarantined != nil {
+		clone.quarantined = make(map[uint64]struct{}, len(idGenerator.quarantined))
+		for offset := range idGenerator.quarantined {
+			clone.quarantined[offset] = struct{}{}
+		}
+	}
+	if idGenerator.leases != nil {
+		clone.leases = make(map[uint64]time.Time, len(idGenerator.leases))
+		for offset, expiry := range idGenerator.leases {
+			clone.leases[offset] = expiry
+		}
+	}
+	if idGenerator.reservations != nil {
+		clone.reservations = make(map[uint64]uint64, len(idGenerator.reservations))
+		for offset, serial := range idGenerator.reservations {
+			clone.reservations[offset] = serial
+		}
+	}
+	if idGenerator.expired != nil {
+		clone.expired = make(map[uint64]struct{}, len(idGenerator.expired))
+		for offset := range idGenerator.expired {
+			clone.expired[offset] = struct{}{}
+		}
+	}
+	return clone
+}
@@ -0,0 +1,116 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClone(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			opts := append([]Option{WithLowestFreeAllocation()}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithExclusions(1, 10000, []int64{2}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = idGenerator.AllocateMany(3); err != nil {
+				t.Fatal(err)
+			}
+			// IDs in several leaves of the tree store
+			if err = idGenerator.AllocateSpecific(9000); err != nil {
+				t.Fatal(err)
+			}
+
+			clone := idGenerator.Clone()
+			if !reflect.DeepEqual(clone.Stats(), idGenerator.Stats()) {
+				t.Errorf("expected the stats of the original %+v, output %+v", idGenerator.Stats(), clone.Stats())
+			}
+			if err = clone.FreeID(2); !errors.Is(err, ErrReserved) {
+				t.Errorf("expected the exclusion to be kept, got %+v", err)
+			}
+			// the lowest-free strategy is kept
+			if id, err := clone.Allocate(); err != nil || id != 5 {
+				t.Errorf("expected id: 5, output id: %d, %+v", id, err)
+			}
+			if err = clone.FreeID(9000); err != nil {
+				t.Fatal(err)
+			}
+			if idGenerator.IsAllocated(5) || !idGenerator.IsAllocated(9000) {
+				t.Error("allocations on the clone changed the original")
+			}
+
+			if err = idGenerator.FreeID(1); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(7000); err != nil {
+				t.Fatal(err)
+			}
+			if !clone.IsAllocated(1) || clone.IsAllocated(7000) {
+				t.Error("allocations on the original changed the clone")
+			}
+			expected := []int64{1, 3, 4, 5}
+			if ids := clone.AllocatedIDs(); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected the IDs of the clone: %v, output: %v", expected, ids)
+			}
+			expected = []int64{3, 4, 7000, 9000}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected the IDs of the original: %v, output: %v", expected, ids)
+			}
+		})
+	}
+}
+
+func TestCloneLeases(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := idGenerator.Clone()
+	if err = clone.Commit(id); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Minute)
+	if idGenerator.IsAllocated(id) {
+		t.Error("the lease of the original did not expire")
+	}
+	if !clone.IsAllocated(id) {
+		t.Error("the lease committed on the clone expired")
+	}
+}
+
+func TestCloneClosed(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithStrictMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocated := allocateN(t, idGenerator, 2)
+	freeAll(t, idGenerator, allocated[0])
+	// the copy keeps the stacks of strict mode
+	e := misuse(t, func() error { return idGenerator.Clone().FreeID(allocated[0]) })
+	if !errors.Is(e, ErrNotAllocated) || e.Freed == "" {
+		t.Errorf("expected the stack of the free, output %+v", e)
+	}
+
+	if _, err = idGenerator.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// a copy of a closed generator is sealed too
+	clone := idGenerator.Clone()
+	e = misuse(t, func() error {
+		_, err := clone.Allocate()
+		return err
+	})
+	if !errors.Is(e, ErrClosed) || e.Closed == "" {
+		t.Errorf("expected ErrClosed with the stack of Close, output %+v", e)
+	}
+	if ids := clone.AllocatedIDs(); !reflect.DeepEqual(ids, allocated[1:]) {
+		t.Errorf("expected the IDs %v allocated, output %v", allocated[1:], ids)
+	}
+}
@@ -0,0 +1,61 @@
+package idgenerator
+
+import "fmt"
+
+// Close seals the generator at shutdown, e.g. to check that every component gave its IDs back:
+// it returns the IDs still allocated in ascending order, whose owners Owner still reports
+// for those allocated by AllocateFor. The IDs cached by Preallocate are freed first, they were never handed out.
+// After Close the calls allocating or freeing IDs, or changing the range, fail with an error wrapping ErrClosed,
+// the waiting AllocateCtx and WaitForID calls return one, Reset does nothing and leases no longer expire,
+// so that Snapshot, Stats and the other calls reading the state see it as Close left it.
+// Closing again returns the IDs again. It returns an error wrapping ErrRangeInUse, without closing,
+// while children of Split are not joined yet.
+func (idGenerator *IDGenerator) Close() (leaked []int64, err error) {
+	idGenerator.cacheLock.Lock()
+	defer idGenerator.cacheLock.Unlock()
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.closed {
+		if len(idGenerator.children) > 0 {
+			return nil, fmt.Errorf("%w: [%d, %d] is split into %d generators not joined yet", ErrRangeInUse,
+				idGenerator.minValue, idGenerator.maxValue, len(idGenerator.children))
+		}
+		idGenerator.flushCacheLocked()
+		idGenerator.dropCacheLocked()
+		idGenerator.closed = true
+		if idGenerator.strict != nil {
+			idGenerator.strict.closed = callers()
+		}
+		for idGenerator.waiters != nil && idGenerator.waiters.len > 0 {
+			w := idGenerator.waiters.next(func(*waiter) bool { return true })
+			idGenerator.waiters.remove(w)
+			close(w.id)
+		}
+		for id, queue := range idGenerator.idWaiters {
+			for queue.Len() > 0 {
+				close(queue.Remove(queue.Front()).(*waiter).id)
+			}
+			delete(idGenerator.idWaiters, id)
+		}
+	}
+	offsets := idGenerator.allocatedOffsetsLocked()
+	leaked = make([]int64, len(offsets))
+	for i, offset := range offsets {
+		leaked[i] = idGenerator.toID(offset)
+	}
+	return leaked, nil
+}
+
+// checkOpenLocked returns an error wrapping ErrClosed after Close, the caller must hold lock
+func (idGenerator *IDGenerator) checkOpenLocked() error {
+	if idGenerator.closed {
+		return idGenerator.misuseLocked(idGenerator.closedError(), 0)
+	}
+	return nil
+}
+
+// closedError returns the error wrapping ErrClosed, the range never changes after Close
+func (idGenerator *IDGenerator) closedError() error {
+	return fmt.Errorf("%w: idgenerator[%d-%d]", ErrClosed, idGenerator.minValue, idGenerator.maxValue)
+}
@@ -0,0 +1,144 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestClose(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithRefCounting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 3)
+	if _, err = idGenerator.AllocateFor("smf-1"); err != nil {
+		t.Fatal(err)
+	}
+	lease, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Preallocate(5); err != nil {
+		t.Fatal(err)
+	}
+	freeAll(t, idGenerator, 2)
+
+	leaked, err := idGenerator.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the cached IDs are not leaked
+	expected := []int64{1, 3, 4, lease}
+	if !reflect.DeepEqual(leaked, expected) {
+		t.Errorf("expected leaked IDs %v, output %v", expected, leaked)
+	}
+	if owner, ok := idGenerator.Owner(4); !ok || owner != "smf-1" {
+		t.Errorf("expected the owner of ID 4 kept, output %q, %v", owner, ok)
+	}
+
+	calls := map[string]func() error{
+		"Allocate":               func() error { _, err := idGenerator.Allocate(); return err },
+		"FastAllocate":           func() error { _, err := idGenerator.FastAllocate(); return err },
+		"AllocateMany":           func() error { _, err := idGenerator.AllocateMany(2); return err },
+		"AllocateContiguous":     func() error { _, err := idGenerator.AllocateContiguous(2); return err },
+		"AllocateAligned":        func() error { _, err := idGenerator.AllocateAligned(2, 2); return err },
+		"AllocateSpecific":       func() error { return idGenerator.AllocateSpecific(50) },
+		"AllocateWithPreference": func() error { _, _, err := idGenerator.AllocateWithPreference(50); return err },
+		"AllocateWithOffset":     func() error { _, err := idGenerator.AllocateWithOffset(50); return err },
+		"AllocateFor":            func() error { _, err := idGenerator.AllocateFor("smf-2"); return err },
+		"AllocateLease":          func() error { _, err := idGenerator.AllocateLease(time.Minute); return err },
+		"AllocateCtx":            func() error { _, err := idGenerator.AllocateCtx(context.Background()); return err },
+		"ReserveRange":           func() error { return idGenerator.ReserveRange(50, 60) },
+		"FreeID":                 func() error { return idGenerator.FreeID(1) },
+		"FreeRange":              func() error { _, err := idGenerator.FreeRange(1, 100); return err },
+		"Renew":                  func() error { return idGenerator.Renew(lease, time.Minute) },
+		"Commit":                 func() error { return idGenerator.Commit(lease) },
+		"Acquire":                func() error { return idGenerator.Acquire(1) },
+		"Release":                func() error { return idGenerator.Release(1) },
+		"Resize":                 func() error { return idGenerator.Resize(1, 200) },
+		"Merge":                  func() error { return idGenerator.Merge(NewGenerator(101, 200)) },
+		"Split":                  func() error { _, err := idGenerator.Split(2); return err },
+		"AllocateManyCtx": func() error {
+			_, err := idGenerator.AllocateManyCtx(context.Background(), 2)
+			return err
+		},
+		"AllocateContiguousCtx": func() error {
+			_, err := idGenerator.AllocateContiguousCtx(context.Background(), 2)
+			return err
+		},
+		"AllocateAlignedCtx": func() error {
+			_, err := idGenerator.AllocateAlignedCtx(context.Background(), 2, 2)
+			return err
+		},
+		"UnmarshalJSON": func() error {
+			return idGenerator.UnmarshalJSON([]byte(`{"minValue":1,"maxValue":100}`))
+		},
+	}
+	for name, call := range calls {
+		if err = call(); !errors.Is(err, ErrClosed) {
+			t.Errorf("%s: expected ErrClosed, got %+v", name, err)
+		}
+	}
+	if _, errs := idGenerator.FreeMany([]int64{1, 3}); len(errs) != 2 || !errors.Is(errs[0], ErrClosed) {
+		t.Errorf("expected ErrClosed for each ID, got %+v", errs)
+	}
+	if freed := idGenerator.FreeByOwner("smf-1"); freed != nil {
+		t.Errorf("expected nothing freed, output %v", freed)
+	}
+	idGenerator.Reset()
+	// the lease does not expire either
+	clock.Advance(time.Hour)
+	if stats := idGenerator.Stats(); stats.Used != 4 || stats.AllocationFailures != 0 {
+		t.Errorf("expected the state of Close kept, output %#v", stats)
+	}
+
+	leaked, err = idGenerator.Close()
+	if err != nil || !reflect.DeepEqual(leaked, expected) {
+		t.Errorf("expected closing again to return %v, output %v, %+v", expected, leaked, err)
+	}
+}
+
+func TestCloseWaiters(t *testing.T) {
+	idGenerator := NewGenerator(1, 1)
+	allocateN(t, idGenerator, 1)
+	errs := make(chan error)
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, err := idGenerator.AllocateCtx(context.Background())
+			errs <- err
+		}()
+	}
+	waitForWaiters(t, idGenerator, 3)
+	if leaked, err := idGenerator.Close(); err != nil || !reflect.DeepEqual(leaked, []int64{1}) {
+		t.Fatalf("expected ID 1 leaked, output %v, %+v", leaked, err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-errs; !errors.Is(err, ErrClosed) {
+			t.Errorf("expected ErrClosed for the waiting calls, got %+v", err)
+		}
+	}
+}
+
+func TestCloseSplit(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	children, err := idGenerator.Split(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.Close(); !errors.Is(err, ErrRangeInUse) {
+		t.Errorf("expected ErrRangeInUse while split, got %+v", err)
+	}
+	for _, child := range children {
+		allocateN(t, child, 1)
+		if err = idGenerator.Join(child); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if leaked, err := idGenerator.Close(); err != nil || len(leaked) != 2 {
+		t.Errorf("expected the IDs of the children leaked, output %v, %+v", leaked, err)
+	}
+}
@@ -0,0 +1,216 @@
+package idgenerator
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+// ImportMode is how ImportCSV loads the rows into the generator
+type ImportMode int
+
+const (
+	// ImportMerge allocates the IDs of the rows in addition to those already allocated
+	ImportMerge ImportMode = iota
+	// ImportReplace frees every allocated ID like Reset, then allocates the IDs of the rows
+	ImportReplace
+)
+
+// The columns of ExportCSV and ImportCSV
+const (
+	csvID          = "id"
+	csvOwner       = "owner"
+	csvAllocatedAt = "allocated_at"
+)
+
+// csvRow is an allocated ID of ExportCSV or ImportCSV, line is its line in the file for the errors of ImportCSV
+type csvRow struct {
+	id          int64
+	owner       string
+	allocatedAt time.Time
+	line        int
+}
+
+// ExportCSV writes the allocated IDs to w in ascending order, one row per ID after a header row, e.g.
+//
+//	id,owner,allocated_at
+//	1,upf-1,2023-01-01T00:00:00Z
+//	2,,
+//
+// The owner is the owner of AllocateFor and allocated_at the time of the allocation in RFC 3339,
+// they are empty for the IDs without an owner and if WithHoldTimes does not record the times.
+// The rows are collected under the lock at once, so that they describe one point in time,
+// then written after it is released.
+func (idGenerator *IDGenerator) ExportCSV(w io.Writer) error {
+	idGenerator.rlock()
+	offsets := idGenerator.allocatedOffsetsLocked()
+	rows := make([]csvRow, len(offsets))
+	for i, offset := range offsets {
+		rows[i].id = idGenerator.toID(offset)
+		if set, ok := idGenerator.ownerOf[offset]; ok {
+			rows[i].owner = set.name
+		}
+		rows[i].allocatedAt = idGenerator.heldSince[offset]
+	}
+	idGenerator.lock.RUnlock()
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{csvID, csvOwner, csvAllocatedAt}); err != nil {
+		return err
+	}
+	record := make([]string, 3)
+	for _, row := range rows {
+		record[0], record[1], record[2] = strconv.FormatInt(row.id, 10), row.owner, ""
+		if !row.allocatedAt.IsZero() {
+			record[2] = row.allocatedAt.Format(time.RFC3339Nano)
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// ImportCSV allocates the IDs of the rows read from r, in the format of ExportCSV, according to mode.
+// The header row names the columns, id is required, owner and allocated_at are optional and may come
+// in any order. A non-empty owner tags the ID like AllocateFor, without checking its quota,
+// and allocated_at sets its hold time if WithHoldTimes records them; the IDs are allocated as by
+// AllocateSpecific, so the hook of WithOnAllocate is called for each and they have no lease.
+// r is read row by row rather than at once, the IDs are kept until they are all checked: either every row
+// is loaded, or none is and the generator is left unchanged, in both modes.
+// It returns an error naming the line of the first malformed row, wrapping ErrMalformedID if its ID is not
+// a number, ErrOutOfRange if it is outside [minValue, maxValue] or ErrAlreadyAllocated if it is listed twice.
+// With ImportMerge, it also returns an error wrapping ErrAlreadyAllocated, ErrReserved or ErrQuarantined
+// if an ID cannot be allocated, like AllocateSpecific. With ImportReplace, the permanent IDs of MarkPermanent
+// stay allocated through the Reset, the rows listing them set their owner and hold time only.
+func (idGenerator *IDGenerator) ImportCSV(r io.Reader, mode ImportMode) error {
+	if mode != ImportMerge && mode != ImportReplace {
+		return fmt.Errorf("ImportCSV: invalid mode %d", mode)
+	}
+	rows, err := readCSV(r)
+	if err != nil {
+		return err
+	}
+
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if err = idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		if err = idGenerator.checkImportLocked(row, mode); err != nil {
+			return fmt.Errorf("invalid CSV: line %d: %w", row.line, err)
+		}
+	}
+	if mode == ImportReplace {
+		idGenerator.resetLocked()
+	}
+	for _, row := range rows {
+		offset := idGenerator.toOffset(row.id)
+		// Reset keeps the permanent IDs allocated, their rows only tag them
+		if mode != ImportReplace || !idGenerator.isPermanent(offset) {
+			idGenerator.markUsed(offset)
+		}
+		if row.owner != "" {
+			idGenerator.ownLocked(offset, row.owner)
+			if idGenerator.audit != nil {
+				idGenerator.audit.tagLast(row.owner)
+			}
+		}
+		if idGenerator.heldSince != nil && !row.allocatedAt.IsZero() {
+			idGenerator.heldSince[offset] = row.allocatedAt
+		}
+	}
+	return nil
+}
+
+// checkImportLocked returns the error of loading row with mode, the caller must hold lock
+func (idGenerator *IDGenerator) checkImportLocked(row csvRow, mode ImportMode) error {
+	if !idGenerator.inRange(row.id) {
+		return idGenerator.outOfRangeError(row.id)
+	}
+	offset := idGenerator.toOffset(row.id)
+	switch {
+	case idGenerator.isExcluded(offset):
+		// the exclusions outlive Reset
+		return idGenerator.reservedError(row.id)
+	case mode == ImportReplace:
+		// Reset ends the quarantine and frees the allocated IDs
+		return nil
+	case idGenerator.isQuarantined(offset):
+		return idGenerator.quarantinedError(row.id)
+	case idGenerator.store.Has(offset):
+		return fmt.Errorf("%w: ID[%d]", ErrAlreadyAllocated, row.id)
+	}
+	return nil
+}
+
+// readCSV reads the rows of ImportCSV from r, failing at the first malformed one
+func readCSV(r io.Reader) ([]csvRow, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid CSV: no header")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid CSV: %w", err)
+	}
+	idColumn, ownerColumn, allocatedAtColumn := -1, -1, -1
+	for i, name := range header {
+		column := &idColumn
+		switch name {
+		case csvID:
+		case csvOwner:
+			column = &ownerColumn
+		case csvAllocatedAt:
+			column = &allocatedAtColumn
+		default:
+			return nil, fmt.Errorf("invalid CSV: line 1: unknown column %q", name)
+		}
+		if *column >= 0 {
+			return nil, fmt.Errorf("invalid CSV: line 1: column %q listed twice", name)
+		}
+		*column = i
+	}
+	if idColumn < 0 {
+		return nil, fmt.Errorf("invalid CSV: line 1: no %q column", csvID)
+	}
+
+	var rows []csvRow
+	lines := make(map[int64]int)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			// the error of csv.Reader names the line
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(idColumn)
+		row := csvRow{line: line}
+		if row.id, err = strconv.ParseInt(record[idColumn], 10, 64); err != nil {
+			return nil, fmt.Errorf("invalid CSV: line %d: %w: %q", line, ErrMalformedID, record[idColumn])
+		}
+		if first, ok := lines[row.id]; ok {
+			return nil, fmt.Errorf("invalid CSV: line %d: %w: ID[%d] listed on line %d as well",
+				line, ErrAlreadyAllocated, row.id, first)
+		}
+		lines[row.id] = line
+		if ownerColumn >= 0 {
+			row.owner = record[ownerColumn]
+		}
+		if allocatedAtColumn >= 0 && record[allocatedAtColumn] != "" {
+			if row.allocatedAt, err = time.Parse(time.RFC3339Nano, record[allocatedAtColumn]); err != nil {
+				return nil, fmt.Errorf("invalid CSV: line %d: invalid allocated_at: %v", line, err)
+			}
+		}
+		rows = append(rows, row)
+	}
+}
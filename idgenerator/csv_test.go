@@ -0,0 +1,170 @@
+package idgenerator
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExportImportCSV(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock), WithHoldTimes(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("upf-1"); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Second)
+	allocateN(t, idGenerator, 2)
+	if err = idGenerator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err = idGenerator.ExportCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := "id,owner,allocated_at\n" +
+		"1,upf-1,2023-01-01T00:00:00Z\n" +
+		"3,,2023-01-01T00:00:01Z\n"
+	if buf.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	clock.Advance(time.Minute)
+	restored, err := NewGeneratorWithOptions(1, 10, WithClock(clock), WithHoldTimes(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = restored.ImportCSV(strings.NewReader(buf.String()), ImportMerge); err != nil {
+		t.Fatal(err)
+	}
+	if ids := restored.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 3}) {
+		t.Errorf("expected IDs [1 3], got %v", ids)
+	}
+	if owner, ok := restored.Owner(1); !ok || owner != "upf-1" {
+		t.Errorf("expected owner upf-1 of ID 1, got %q, %v", owner, ok)
+	}
+	if _, ok := restored.Owner(3); ok {
+		t.Error("expected no owner of ID 3")
+	}
+	if held, ok := restored.HoldTime(3); !ok || held != time.Minute {
+		t.Errorf("expected ID 3 held for 1m, got %v, %v", held, ok)
+	}
+}
+
+func TestImportCSVModes(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	allocateN(t, idGenerator, 3)
+
+	if err := idGenerator.ImportCSV(strings.NewReader("owner,id\nsmf,5\n,7\n"), ImportMerge); err != nil {
+		t.Fatal(err)
+	}
+	if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 2, 3, 5, 7}) {
+		t.Errorf("expected IDs [1 2 3 5 7] after merge, got %v", ids)
+	}
+	if owner, _ := idGenerator.Owner(5); owner != "smf" {
+		t.Errorf("expected owner smf of ID 5, got %q", owner)
+	}
+
+	err := idGenerator.ImportCSV(strings.NewReader("id\n8\n2\n"), ImportMerge)
+	if !errors.Is(err, ErrAlreadyAllocated) || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected ErrAlreadyAllocated on line 3, got %v", err)
+	}
+	if idGenerator.IsAllocated(8) {
+		t.Error("expected a failed merge to allocate nothing")
+	}
+
+	if err = idGenerator.ImportCSV(strings.NewReader("id\n2\n9\n"), ImportReplace); err != nil {
+		t.Fatal(err)
+	}
+	if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{2, 9}) {
+		t.Errorf("expected IDs [2 9] after replace, got %v", ids)
+	}
+	if _, ok := idGenerator.Owner(5); ok {
+		t.Error("expected replace to drop the owners")
+	}
+
+	if err = idGenerator.ImportCSV(strings.NewReader("id\n"), ImportReplace); err != nil {
+		t.Fatal(err)
+	}
+	if used := idGenerator.Used(); used != 0 {
+		t.Errorf("expected an empty generator after replacing with no rows, got %d IDs", used)
+	}
+
+	if err = idGenerator.ImportCSV(strings.NewReader("id\n"), ImportMode(2)); err == nil {
+		t.Error("expected an error for an invalid mode")
+	}
+}
+
+func TestImportCSVPermanent(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithPermanentIDs(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 1)
+	var buf bytes.Buffer
+	if err = idGenerator.ExportCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// the permanent ID listed in the file is allocated once
+	if err = idGenerator.ImportCSV(&buf, ImportReplace); err != nil {
+		t.Fatal(err)
+	}
+	if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 2}) {
+		t.Errorf("expected IDs [1 2] after replace, got %v", ids)
+	}
+	if used := idGenerator.Used(); used != 2 {
+		t.Errorf("expected 2 IDs used, got %d", used)
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestImportCSVMalformed(t *testing.T) {
+	testCases := []struct {
+		name        string
+		csv         string
+		line        string
+		expectedErr error
+	}{
+		{"empty", "", "", nil},
+		{"no id column", "owner\nsmf\n", "line 1", nil},
+		{"unknown column", "id,name\n1,a\n", "line 1", nil},
+		{"column twice", "id,id\n1,1\n", "line 1", nil},
+		{"not a number", "id\n1\nx\n", "line 3", ErrMalformedID},
+		{"out of range", "id\n1\n\n11\n", "line 4", ErrOutOfRange},
+		{"excluded", "id\n5\n", "line 2", ErrReserved},
+		{"duplicate", "id\n1\n2\n1\n", "line 4", ErrAlreadyAllocated},
+		{"wrong field count", "id,owner\n1,a\n2\n", "line 3", nil},
+		{"bad time", "id,allocated_at\n1,yesterday\n", "line 2", nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{5})
+			if err != nil {
+				t.Fatal(err)
+			}
+			allocateN(t, idGenerator, 2)
+			err = idGenerator.ImportCSV(strings.NewReader(testCase.csv), ImportReplace)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+			if !strings.Contains(err.Error(), testCase.line) {
+				t.Errorf("expected an error on %s, got %v", testCase.line, err)
+			}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 2}) {
+				t.Errorf("expected the generator unchanged, got IDs %v", ids)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Encoding is the alphabet EncodeID spells IDs in for embedding them in URLs, its digits in ascending order of value
+// and of ASCII code. Every ID has a single spelling, without leading zero digit,
+// which DecodeID requires: it rejects the lower case and the ambiguous letters some decoders accept.
+type Encoding struct {
+	name     string
+	alphabet string
+	decode   [256]byte
+}
+
+// invalidDigit marks the bytes out of the alphabet in the decode table of an Encoding
+const invalidDigit = 0xff
+
+// The encodings of EncodeID: the Crockford base32, without the letters I, L, O and U,
+// and the base58 of Bitcoin, without 0, O, I and l
+var (
+	Base32Crockford = newEncoding("base32", "0123456789ABCDEFGHJKMNPQRSTVWXYZ")
+	Base58          = newEncoding("base58", "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz")
+)
+
+func newEncoding(name, alphabet string) *Encoding {
+	e := &Encoding{name: name, alphabet: alphabet}
+	for i := range e.decode {
+		e.decode[i] = invalidDigit
+	}
+	for i := 0; i < len(alphabet); i++ {
+		e.decode[alphabet[i]] = byte(i)
+	}
+	return e
+}
+
+// String returns the name of the encoding
+func (e *Encoding) String() string {
+	return e.name
+}
+
+// EncodeID returns the spelling of id in encoding. A negative ID is spelled as its two's complement uint64,
+// so that every int64 has a spelling and the order of the non-negative IDs is kept by their length then digits.
+func EncodeID(id int64, encoding *Encoding) string {
+	base := uint64(len(encoding.alphabet))
+	var digits [64]byte
+	i := len(digits)
+	for value := uint64(id); ; value /= base {
+		i--
+		digits[i] = encoding.alphabet[value%base]
+		if value < base {
+			break
+		}
+	}
+	return string(digits[i:])
+}
+
+// DecodeID returns the ID spelled s in encoding. It returns an error wrapping ErrMalformedID
+// if s is empty, has a character out of the alphabet or a leading zero digit, or overflows 64 bits.
+func DecodeID(s string, encoding *Encoding) (int64, error) {
+	if s == "" {
+		return 0, fmt.Errorf("%w: empty %s ID", ErrMalformedID, encoding)
+	}
+	if len(s) > 1 && s[0] == encoding.alphabet[0] {
+		return 0, fmt.Errorf("%w: %s ID %q has a leading zero digit", ErrMalformedID, encoding, s)
+	}
+	base := uint64(len(encoding.alphabet))
+	value := uint64(0)
+	for i := 0; i < len(s); i++ {
+		digit := encoding.decode[s[i]]
+		if digit == invalidDigit {
+			return 0, fmt.Errorf("%w: %s ID %q has the invalid character %q", ErrMalformedID, encoding, s, s[i])
+		}
+		hi, lo := bits.Mul64(value, base)
+		sum, carry := bits.Add64(lo, uint64(digit), 0)
+		if hi != 0 || carry != 0 {
+			return 0, fmt.Errorf("%w: %s ID %q overflows 64 bits", ErrMalformedID, encoding, s)
+		}
+		value = sum
+	}
+	return int64(value), nil
+}
+
+// AllocateEncoded allocates an ID like Allocate and returns it spelled in encoding by EncodeID
+func (idGenerator *IDGenerator) AllocateEncoded(encoding *Encoding) (string, error) {
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		return "", err
+	}
+	return EncodeID(id, encoding), nil
+}
+
+// FreeEncoded frees the ID spelled s in encoding like FreeID.
+// It fails like DecodeID if s is malformed, and with an error wrapping ErrOutOfRange
+// if the ID is outside of the range of the generator.
+func (idGenerator *IDGenerator) FreeEncoded(s string, encoding *Encoding) error {
+	id, err := DecodeID(s, encoding)
+	if err != nil {
+		return err
+	}
+	return idGenerator.FreeID(id)
+}
@@ -0,0 +1,126 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var testEncodings = []*Encoding{Base32Crockford, Base58}
+
+func TestEncodeID(t *testing.T) {
+	testCases := []struct {
+		id       int64
+		encoding *Encoding
+		expected string
+	}{
+		{0, Base32Crockford, "0"},
+		{31, Base32Crockford, "Z"},
+		{32, Base32Crockford, "10"},
+		{1234567, Base32Crockford, "15NM7"},
+		{math.MaxInt64, Base32Crockford, "7ZZZZZZZZZZZZ"},
+		{-1, Base32Crockford, "FZZZZZZZZZZZZ"},
+		{0, Base58, "1"},
+		{57, Base58, "z"},
+		{58, Base58, "21"},
+		{math.MaxInt64, Base58, "NQm6nKp8qFC"},
+		{-1, Base58, "jpXCZedGfVQ"},
+	}
+	for _, testCase := range testCases {
+		if output := EncodeID(testCase.id, testCase.encoding); output != testCase.expected {
+			t.Errorf("%s: expected %d spelled %q, output %q", testCase.encoding, testCase.id, testCase.expected, output)
+		}
+		if id, err := DecodeID(testCase.expected, testCase.encoding); err != nil || id != testCase.id {
+			t.Errorf("%s: expected %q decoded to %d, output %d, %+v", testCase.encoding, testCase.expected,
+				testCase.id, id, err)
+		}
+	}
+}
+
+func TestDecodeIDMalformed(t *testing.T) {
+	testCases := []struct {
+		s        string
+		encoding *Encoding
+	}{
+		{"", Base32Crockford},
+		{"00", Base32Crockford},
+		{"01", Base32Crockford},
+		{"abc", Base32Crockford},
+		{"1I", Base32Crockford},
+		{"1L", Base32Crockford},
+		{"1O", Base32Crockford},
+		{"1U", Base32Crockford},
+		{"1-2", Base32Crockford},
+		{"G0000000000000", Base32Crockford},
+		{"1000000000000000", Base32Crockford},
+		{"", Base58},
+		{"11", Base58},
+		{"0", Base58},
+		{"zO", Base58},
+		{"zI", Base58},
+		{"zl", Base58},
+		{"jpXCZedGfVR", Base58},
+		{"jpXCZedGfVQ1", Base58},
+		{"2é", Base58},
+	}
+	for _, testCase := range testCases {
+		if id, err := DecodeID(testCase.s, testCase.encoding); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("%s: expected ErrMalformedID for %q, output %d, %+v", testCase.encoding, testCase.s, id, err)
+		}
+	}
+}
+
+func TestAllocateEncoded(t *testing.T) {
+	idGenerator := NewGenerator(100, 101)
+	s, err := idGenerator.AllocateEncoded(Base58)
+	if err != nil || s != "2j" {
+		t.Fatalf("expected ID 100 spelled \"2j\", output %q, %+v", s, err)
+	}
+	if err = idGenerator.FreeEncoded(s, Base58); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeEncoded(s, Base58); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err = idGenerator.FreeEncoded(EncodeID(102, Base58), Base58); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.FreeEncoded("2j", Base32Crockford); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("expected ErrMalformedID for a base58 ID decoded as base32, got %+v", err)
+	}
+}
+
+func FuzzEncodeIDRoundTrip(f *testing.F) {
+	for _, seed := range []int64{0, 1, -1, 31, 32, 57, 58, math.MaxInt64, math.MinInt64} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, id int64) {
+		for _, encoding := range testEncodings {
+			s := EncodeID(id, encoding)
+			if decoded, err := DecodeID(s, encoding); err != nil || decoded != id {
+				t.Errorf("%s: EncodeID(%d) = %q, which decodes as %d, %+v", encoding, id, s, decoded, err)
+			}
+		}
+	})
+}
+
+func FuzzDecodeID(f *testing.F) {
+	for _, seed := range []string{"0", "1", "Z", "zz", "00", "11", "1I", "abc", "FZZZZZZZZZZZZ", "G0000000000000"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, encoding := range testEncodings {
+			id, err := DecodeID(s, encoding)
+			if err != nil {
+				if !errors.Is(err, ErrMalformedID) {
+					t.Errorf("%s: DecodeID(%q) failed with %+v", encoding, s, err)
+				}
+				continue
+			}
+			// only the spelling of an ID decodes
+			if encoded := EncodeID(id, encoding); encoded != s {
+				t.Errorf("%s: DecodeID(%q) = %d, which encodes as %q", encoding, s, id, encoded)
+			}
+		}
+	})
+}
@@ -0,0 +1,60 @@
+package idgenerator
+
+import "errors"
+
+// The errors returned by the package wrap these with the IDs and range concerned,
+// they are matched with errors.Is rather than by their message
+var (
+	// ErrInvalidRange is returned when constructing a generator with an invalid range
+	ErrInvalidRange = errors.New("invalid ID range")
+	// ErrInvalidEncoding is returned when decoding malformed generator state
+	ErrInvalidEncoding = errors.New("invalid encoding")
+	// ErrPoolExhausted is returned when there is no ID left to allocate
+	ErrPoolExhausted = errors.New("No available value range to allocate id")
+	// ErrNoContiguousBlock is returned when enough IDs are free but not consecutive
+	ErrNoContiguousBlock = errors.New("no contiguous block of free IDs")
+	// ErrNoAlignedBlock is returned when there are contiguous blocks of free IDs but none of them aligned
+	ErrNoAlignedBlock = errors.New("no aligned block of free IDs")
+	// ErrOutOfRange is returned when an ID is outside [minValue, maxValue]
+	ErrOutOfRange = errors.New("ID out of range")
+	// ErrAlreadyAllocated is returned when allocating an ID which is in use
+	ErrAlreadyAllocated = errors.New("ID already allocated")
+	// ErrNotLeased is returned when renewing or committing an ID which is not leased
+	ErrNotLeased = errors.New("ID not leased")
+	// ErrLeaseExpired is returned when renewing or committing a lease which has expired
+	ErrLeaseExpired = errors.New("lease expired")
+	// ErrNotReserved is returned when committing or cancelling a reservation of Reserve which is over
+	ErrNotReserved = errors.New("ID not reserved")
+	// ErrNotAllocated is returned when freeing an ID which is not allocated
+	ErrNotAllocated = errors.New("ID not allocated")
+	// ErrReserved is returned when allocating or freeing an ID excluded from allocation
+	ErrReserved = errors.New("ID reserved")
+	// ErrPermanent is returned when freeing an ID marked by MarkPermanent
+	ErrPermanent = errors.New("ID permanent")
+	// ErrRangeInUse is returned when shrinking the range of a generator would drop allocated IDs
+	ErrRangeInUse = errors.New("range in use")
+	// ErrGeneratorExists is returned when getting a generator by name with bounds other than its own
+	ErrGeneratorExists = errors.New("generator exists with other bounds")
+	// ErrQuarantined is returned when allocating an ID freed less than the delay of WithReuseDelay ago
+	ErrQuarantined = errors.New("ID quarantined")
+	// ErrStaleToken is returned when freeing a Token of an ID which has been reallocated since
+	ErrStaleToken = errors.New("stale token")
+	// ErrMalformedID is returned when parsing a string which is not formatted like an ID
+	ErrMalformedID = errors.New("malformed ID")
+	// ErrRateLimited is returned when allocating faster than the limit of WithRateLimit
+	ErrRateLimited = errors.New("allocation rate limited")
+	// ErrClockBackwards is returned when a SnowflakeGenerator finds its clock behind the last ID it generated
+	ErrClockBackwards = errors.New("clock moved backwards")
+	// ErrClosed is returned when allocating or freeing an ID with a generator which has been closed
+	ErrClosed = errors.New("generator closed")
+	// ErrNotChild is returned when joining a generator which is not a child of Split not joined yet
+	ErrNotChild = errors.New("not a child generator")
+	// ErrQuotaExceeded is returned when allocating for an owner which holds as many IDs as its quota of SetQuota
+	ErrQuotaExceeded = errors.New("owner quota exceeded")
+	// ErrFiltered is returned when allocating an ID vetoed by the filter of WithAllocationFilter
+	ErrFiltered = errors.New("ID vetoed by the allocation filter")
+	// ErrAlreadyPublished is returned when publishing the stats of a generator under a name expvar already has
+	ErrAlreadyPublished = errors.New("expvar name already published")
+	// ErrLockTimeout is returned when the file lock of a FileSharedGenerator is not acquired within WithLockTimeout
+	ErrLockTimeout = errors.New("file lock timed out")
+)
@@ -0,0 +1,66 @@
+package idgenerator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSentinelErrors(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(10, 11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(10); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(11); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name     string
+		call     func() error
+		sentinel error
+		// message is part of the error text giving its context
+		message string
+	}{
+		{"Allocate", func() error {
+			_, err := idGenerator.Allocate()
+			return err
+		}, ErrPoolExhausted, "[10, 11]"},
+		{"Peek", func() error {
+			_, err := idGenerator.Peek()
+			return err
+		}, ErrPoolExhausted, "[10, 11]"},
+		{"AllocateMany", func() error {
+			_, err := idGenerator.AllocateMany(1)
+			return err
+		}, ErrPoolExhausted, "requested 1 IDs"},
+		{"AllocateSpecific in use", func() error {
+			return idGenerator.AllocateSpecific(11)
+		}, ErrAlreadyAllocated, "ID[11]"},
+		{"AllocateSpecific out of range", func() error {
+			return idGenerator.AllocateSpecific(12)
+		}, ErrOutOfRange, "ID[12] not in [10, 11]"},
+		{"FreeID out of range", func() error {
+			return idGenerator.FreeID(9)
+		}, ErrOutOfRange, "ID[9] not in [10, 11]"},
+		{"FreeID twice", func() error {
+			if err := idGenerator.FreeID(10); err != nil {
+				return err
+			}
+			return idGenerator.FreeID(10)
+		}, ErrNotAllocated, "ID[10]"},
+	}
+	for _, testCase := range testCases {
+		err := testCase.call()
+		if !errors.Is(err, testCase.sentinel) {
+			t.Errorf("%s: expected %v, got %+v", testCase.name, testCase.sentinel, err)
+			continue
+		}
+		if !strings.Contains(err.Error(), testCase.message) {
+			t.Errorf("%s: expected %q in the error, output %q", testCase.name, testCase.message, err)
+		}
+	}
+}
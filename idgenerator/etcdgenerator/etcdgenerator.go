@@ -0,0 +1,243 @@
+// etcdgenerator allocates IDs from a pool kept in etcd, so that the processes of an HA deployment can share it, e.g.
+//
+//	generator, err := etcdgenerator.New(ctx, client, "/smf/teid/", 1, 1<<20)
+//	...
+//	generator.AttachLease(session.Lease())
+//	teid, err := generator.Allocate()
+//
+// Every allocated ID is a key under the prefix, created by a transaction failing if the key exists,
+// so two processes never claim the same ID. The keys of a lease attached with AttachLease are deleted
+// by etcd once the lease expires, which frees the IDs of a process which died.
+// A local copy of the pool kept up to date by a watch lets Allocate pick a free ID without reading the range.
+package etcdgenerator
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// Generator allocates IDs in [minValue, maxValue] from the pool of the keys under its prefix in etcd.
+// The generators of every process sharing the pool must be created with the same prefix and range.
+// A failed etcd call fails the method with its error, there is no fallback to local allocation.
+// The methods without a context are bounded by the timeouts of the etcd client only.
+type Generator struct {
+	client   *clientv3.Client
+	prefix   string
+	minValue int64
+	maxValue int64
+
+	// local mirrors the keys under prefix, with the candidates of the allocations in flight allocated as well
+	local *idgenerator.IDGenerator
+
+	leaseLock sync.Mutex
+	lease     clientv3.LeaseID
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// New returns a Generator of the pool under prefix in [minValue, maxValue], which is empty if no key is under it yet.
+// It reads the keys under prefix and watches them until Close. The keys under prefix which are not IDs in the range
+// are ignored. It returns an error wrapping idgenerator.ErrInvalidRange if minValue > maxValue,
+// or the error of etcd if the keys cannot be read.
+func New(ctx context.Context, client *clientv3.Client, prefix string, minValue, maxValue int64) (*Generator, error) {
+	local, err := idgenerator.NewGeneratorWithOptions(minValue, maxValue)
+	if err != nil {
+		return nil, err
+	}
+	g := &Generator{
+		client:   client,
+		prefix:   prefix,
+		minValue: minValue,
+		maxValue: maxValue,
+		local:    local,
+		done:     make(chan struct{}),
+	}
+	revision, err := g.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	watchCtx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	go g.watch(watchCtx, revision)
+	return g, nil
+}
+
+func (g *Generator) etcdError(err error) error {
+	return fmt.Errorf("etcdgenerator %s: %w", g.prefix, err)
+}
+
+func (g *Generator) key(id int64) string {
+	return g.prefix + strconv.FormatInt(id, 10)
+}
+
+// parseKey returns the ID of key, or false if it is not the key of an ID in the range,
+// e.g. "+7" or "07", which are not the key of 7 but would parse as 7
+func (g *Generator) parseKey(key []byte) (int64, bool) {
+	if !bytes.HasPrefix(key, []byte(g.prefix)) {
+		return 0, false
+	}
+	name := string(key[len(g.prefix):])
+	id, err := strconv.ParseInt(name, 10, 64)
+	if err != nil || id < g.minValue || id > g.maxValue || strconv.FormatInt(id, 10) != name {
+		return 0, false
+	}
+	return id, true
+}
+
+// load replaces the local copy by the keys under prefix and returns the revision they were read at
+func (g *Generator) load(ctx context.Context) (int64, error) {
+	resp, err := g.client.Get(ctx, g.prefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return 0, g.etcdError(err)
+	}
+	g.local.Reset()
+	for _, kv := range resp.Kvs {
+		if id, ok := g.parseKey(kv.Key); ok {
+			g.markUsed(id)
+		}
+	}
+	return resp.Header.Revision, nil
+}
+
+// markUsed allocates id in the local copy, where it may be allocated already
+func (g *Generator) markUsed(id int64) {
+	if err := g.local.AllocateSpecific(id); err != nil && !errors.Is(err, idgenerator.ErrAlreadyAllocated) {
+		panic(fmt.Sprintf("etcdgenerator %s: %v", g.prefix, err))
+	}
+}
+
+// markFree frees id in the local copy, where it may be free already
+func (g *Generator) markFree(id int64) {
+	if err := g.local.FreeID(id); err != nil && !errors.Is(err, idgenerator.ErrNotAllocated) {
+		panic(fmt.Sprintf("etcdgenerator %s: %v", g.prefix, err))
+	}
+}
+
+// Close stops the watch of the generator, which must not be used afterwards. The allocated IDs stay allocated.
+func (g *Generator) Close() {
+	g.cancel()
+	<-g.done
+}
+
+// AttachLease makes the IDs allocated from then on bound to lease, etcd frees them once it expires.
+// A lease of clientv3.NoLease, the default, allocates IDs which stay until they are freed.
+func (g *Generator) AttachLease(lease clientv3.LeaseID) {
+	g.leaseLock.Lock()
+	g.lease = lease
+	g.leaseLock.Unlock()
+}
+
+func (g *Generator) putOptions() []clientv3.OpOption {
+	g.leaseLock.Lock()
+	defer g.leaseLock.Unlock()
+	if g.lease == clientv3.NoLease {
+		return nil
+	}
+	return []clientv3.OpOption{clientv3.WithLease(g.lease)}
+}
+
+// create creates the key of id unless it exists and reports whether it did
+func (g *Generator) create(ctx context.Context, id int64) (bool, error) {
+	key := g.key(id)
+	resp, err := g.client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(key), "=", 0)).
+		Then(clientv3.OpPut(key, "", g.putOptions()...)).
+		Commit()
+	if err != nil {
+		return false, g.etcdError(err)
+	}
+	return resp.Succeeded, nil
+}
+
+// Allocate allocates an ID like AllocateCtx without a deadline
+func (g *Generator) Allocate() (int64, error) {
+	return g.AllocateCtx(context.Background())
+}
+
+// AllocateCtx allocates an ID which is free in the local copy of the pool, or returns an error wrapping
+// idgenerator.ErrPoolExhausted if every ID is in use. It tries the next free ID if another process claimed
+// the first one before its key was seen. Unlike IDGenerator.AllocateCtx it does not wait for an ID to be freed,
+// ctx bounds the etcd calls.
+func (g *Generator) AllocateCtx(ctx context.Context) (int64, error) {
+	for {
+		// taking the candidate locally keeps the other calls of the process from trying it as well
+		id, err := g.local.Allocate()
+		if err != nil {
+			return 0, err
+		}
+		created, err := g.create(ctx, id)
+		if err != nil {
+			g.markFree(id)
+			return 0, err
+		}
+		if created {
+			return id, nil
+		}
+		// claimed by another process, the watch confirms it
+	}
+}
+
+// AllocateSpecific allocates id like AllocateSpecificCtx without a deadline
+func (g *Generator) AllocateSpecific(id int64) error {
+	return g.AllocateSpecificCtx(context.Background(), id)
+}
+
+// AllocateSpecificCtx allocates exactly id. It returns an error wrapping idgenerator.ErrOutOfRange
+// if id is outside [minValue, maxValue] or idgenerator.ErrAlreadyAllocated if id is in use.
+func (g *Generator) AllocateSpecificCtx(ctx context.Context, id int64) error {
+	if err := g.local.AllocateSpecific(id); err != nil {
+		return err
+	}
+	created, err := g.create(ctx, id)
+	if err != nil {
+		g.markFree(id)
+		return err
+	}
+	if !created {
+		return fmt.Errorf("%w: ID[%d]", idgenerator.ErrAlreadyAllocated, id)
+	}
+	return nil
+}
+
+// FreeID frees id like FreeIDCtx without a deadline
+func (g *Generator) FreeID(id int64) error {
+	return g.FreeIDCtx(context.Background(), id)
+}
+
+// FreeIDCtx frees the allocated id, by any process. It returns an error wrapping idgenerator.ErrOutOfRange
+// if id is outside [minValue, maxValue] or idgenerator.ErrNotAllocated if id is not allocated.
+func (g *Generator) FreeIDCtx(ctx context.Context, id int64) error {
+	if id < g.minValue || id > g.maxValue {
+		return fmt.Errorf("%w: ID[%d] not in [%d, %d]", idgenerator.ErrOutOfRange, id, g.minValue, g.maxValue)
+	}
+	resp, err := g.client.Delete(ctx, g.key(id))
+	if err != nil {
+		return g.etcdError(err)
+	}
+	if resp.Deleted == 0 {
+		return fmt.Errorf("%w: ID[%d]", idgenerator.ErrNotAllocated, id)
+	}
+	g.markFree(id)
+	return nil
+}
+
+// IsAllocated reports whether id is allocated by any process as seen by the watch of the generator
+func (g *Generator) IsAllocated(id int64) bool {
+	return g.local.IsAllocated(id)
+}
+
+// Used returns the number of IDs allocated by all processes as seen by the watch of the generator
+func (g *Generator) Used() int64 {
+	return g.local.Used()
+}
+
+var _ idgenerator.Allocator = (*Generator)(nil)
@@ -0,0 +1,174 @@
+//go:build integration
+
+package etcdgenerator
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// newTestClient connects to the etcd of ETCD_ENDPOINTS, a comma separated list, and returns a prefix
+// of the test to keep its keys under. The test is skipped if ETCD_ENDPOINTS is not set.
+func newTestClient(t *testing.T) (*clientv3.Client, string) {
+	t.Helper()
+	endpoints := os.Getenv("ETCD_ENDPOINTS")
+	if endpoints == "" {
+		t.Skip("ETCD_ENDPOINTS not set")
+	}
+	client, err := clientv3.New(clientv3.Config{Endpoints: strings.Split(endpoints, ","), DialTimeout: 5 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	prefix := "/idgenerator-test/" + t.Name() + "/" + strconv.FormatInt(time.Now().UnixNano(), 10) + "/"
+	t.Cleanup(func() {
+		if _, err := client.Delete(context.Background(), prefix, clientv3.WithPrefix()); err != nil {
+			t.Error(err)
+		}
+		if err := client.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	return client, prefix
+}
+
+func newTestGenerator(t *testing.T, client *clientv3.Client, prefix string, minValue, maxValue int64) *Generator {
+	t.Helper()
+	generator, err := New(context.Background(), client, prefix, minValue, maxValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(generator.Close)
+	return generator
+}
+
+// waitUsed waits for the watch of generator to count expected used IDs
+func waitUsed(t *testing.T, generator *Generator, expected int64) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for generator.Used() != expected {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d used, output %d", expected, generator.Used())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	client, prefix := newTestClient(t)
+	generator := newTestGenerator(t, client, prefix, 1, 3)
+	for expected := int64(1); expected <= 3; expected++ {
+		if id, err := generator.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if _, err := generator.Allocate(); !errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err := generator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.FreeID(2); !errors.Is(err, idgenerator.ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err := generator.FreeID(4); !errors.Is(err, idgenerator.ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err := generator.AllocateSpecific(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.AllocateSpecific(2); !errors.Is(err, idgenerator.ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+
+	// a generator created later starts from the keys
+	later := newTestGenerator(t, client, prefix, 1, 3)
+	if used := later.Used(); used != 3 {
+		t.Errorf("expected 3 used, output %d", used)
+	}
+}
+
+func TestGeneratorShared(t *testing.T) {
+	client, prefix := newTestClient(t)
+	generators := []*Generator{
+		newTestGenerator(t, client, prefix, 1, 200),
+		newTestGenerator(t, client, prefix, 1, 200),
+	}
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	for _, generator := range generators {
+		wg.Add(1)
+		go func(generator *Generator) {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				id, err := generator.Allocate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("ID %d allocated twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}(generator)
+	}
+	wg.Wait()
+	for _, generator := range generators {
+		waitUsed(t, generator, 200)
+	}
+
+	// an ID freed by one process is seen free by the other
+	if err := generators[0].FreeID(7); err != nil {
+		t.Fatal(err)
+	}
+	waitUsed(t, generators[1], 199)
+	if generators[1].IsAllocated(7) || !generators[1].IsAllocated(8) {
+		t.Error("expected 8 allocated and 7 free")
+	}
+	if err := generators[1].AllocateSpecific(7); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestGeneratorLease(t *testing.T) {
+	client, prefix := newTestClient(t)
+	owner := newTestGenerator(t, client, prefix, 1, 10)
+	other := newTestGenerator(t, client, prefix, 1, 10)
+	lease, err := client.Grant(context.Background(), 60)
+	if err != nil {
+		t.Fatal(err)
+	}
+	owner.AttachLease(lease.ID)
+	for i := 0; i < 3; i++ {
+		if _, err = owner.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owner.AttachLease(clientv3.NoLease)
+	if _, err = owner.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	waitUsed(t, other, 4)
+
+	// the owner dies, its lease is gone
+	if _, err = client.Revoke(context.Background(), lease.ID); err != nil {
+		t.Fatal(err)
+	}
+	waitUsed(t, other, 1)
+	if !errors.Is(other.FreeID(1), idgenerator.ErrNotAllocated) || other.FreeID(4) != nil {
+		t.Error("expected only the ID allocated without a lease to stay")
+	}
+}
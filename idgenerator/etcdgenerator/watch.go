@@ -0,0 +1,70 @@
+package etcdgenerator
+
+import (
+	"context"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/free5gc/util/idgenerator/internal/jitter"
+)
+
+// The backoff of the reloads of the watch while etcd fails them
+const (
+	reloadBackoffInitial = 100 * time.Millisecond
+	reloadBackoffMax     = 10 * time.Second
+)
+
+// watch applies the changes under prefix after revision to the local copy until ctx is done.
+// A watch canceled by etcd, e.g. as its revision has been compacted, is started again after reloading the keys.
+// A failed reload is retried with a backoff doubling from reloadBackoffInitial to reloadBackoffMax,
+// so that an unreachable etcd is not called in a tight loop.
+func (g *Generator) watch(ctx context.Context, revision int64) {
+	defer close(g.done)
+	for ctx.Err() == nil {
+		watchCh := g.client.Watch(ctx, g.prefix, clientv3.WithPrefix(), clientv3.WithRev(revision+1))
+		for resp := range watchCh {
+			if resp.Err() != nil {
+				break
+			}
+			for _, ev := range resp.Events {
+				g.apply(ev.Kv.Key, ev.Type == clientv3.EventTypeDelete)
+			}
+			revision = resp.Header.Revision
+		}
+		for delay := reloadBackoffInitial; ctx.Err() == nil; delay = nextReloadDelay(delay) {
+			var err error
+			if revision, err = g.load(ctx); err == nil {
+				break
+			}
+			timer := time.NewTimer(jitter.Draw(delay))
+			select {
+			case <-ctx.Done():
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+	}
+}
+
+// apply applies the change of key to the local copy, freeing its ID if deleted and allocating it otherwise.
+// The keys which are not IDs in the range are ignored.
+func (g *Generator) apply(key []byte, deleted bool) {
+	id, ok := g.parseKey(key)
+	if !ok {
+		return
+	}
+	if deleted {
+		g.markFree(id)
+	} else {
+		g.markUsed(id)
+	}
+}
+
+// nextReloadDelay returns the delay after delay, doubled and capped at reloadBackoffMax
+func nextReloadDelay(delay time.Duration) time.Duration {
+	if delay *= 2; delay > reloadBackoffMax {
+		return reloadBackoffMax
+	}
+	return delay
+}
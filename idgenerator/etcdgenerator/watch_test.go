@@ -0,0 +1,82 @@
+package etcdgenerator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// newLocalGenerator returns a Generator of prefix in [minValue, maxValue] without an etcd client,
+// for the methods which only update the local copy
+func newLocalGenerator(prefix string, minValue, maxValue int64) *Generator {
+	return &Generator{
+		prefix:   prefix,
+		minValue: minValue,
+		maxValue: maxValue,
+		local:    idgenerator.NewGenerator(minValue, maxValue),
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	g := newLocalGenerator("/smf/teid/", 1, 100)
+	testCases := []struct {
+		key string
+		id  int64
+		ok  bool
+	}{
+		{"/smf/teid/1", 1, true},
+		{"/smf/teid/100", 100, true},
+		{"/smf/teid/0", 0, false},
+		{"/smf/teid/101", 0, false},
+		{"/smf/teid/-1", 0, false},
+		{"/smf/teid/07", 0, false},
+		{"/smf/teid/+7", 0, false},
+		{"/smf/teid/7/lock", 0, false},
+		{"/smf/teid/", 0, false},
+		{"/smf/teid/x", 0, false},
+		{"/smf/seid/7", 0, false},
+		{"7", 0, false},
+	}
+	for _, testCase := range testCases {
+		if id, ok := g.parseKey([]byte(testCase.key)); id != testCase.id || ok != testCase.ok {
+			t.Errorf("%q: expected %d, %v, output %d, %v", testCase.key, testCase.id, testCase.ok, id, ok)
+		}
+	}
+	if key := g.key(7); key != "/smf/teid/7" {
+		t.Errorf("expected the key /smf/teid/7, output %q", key)
+	}
+}
+
+func TestApply(t *testing.T) {
+	g := newLocalGenerator("/smf/teid/", 1, 10)
+	for _, key := range []string{"/smf/teid/3", "/smf/teid/5", "/smf/teid/5", "/smf/teid/07", "/smf/teid/11"} {
+		g.apply([]byte(key), false)
+	}
+	if ids := g.local.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{3, 5}) {
+		t.Fatalf("expected IDs 3 and 5 in the local copy, output %v", ids)
+	}
+	// a delete of a free ID or of a key other than an ID changes nothing
+	for _, key := range []string{"/smf/teid/5", "/smf/teid/5", "/smf/teid/7", "/smf/teid/+3"} {
+		g.apply([]byte(key), true)
+	}
+	if ids := g.local.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{3}) {
+		t.Errorf("expected ID 3 in the local copy, output %v", ids)
+	}
+	if used := g.Used(); used != 1 || !g.IsAllocated(3) {
+		t.Errorf("expected ID 3 used, output %d used", used)
+	}
+}
+
+func TestReloadBackoff(t *testing.T) {
+	delay := reloadBackoffInitial
+	for _, expected := range []time.Duration{
+		200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, 1600 * time.Millisecond,
+		3200 * time.Millisecond, 6400 * time.Millisecond, reloadBackoffMax, reloadBackoffMax,
+	} {
+		if delay = nextReloadDelay(delay); delay != expected {
+			t.Errorf("expected a delay of %v, output %v", expected, delay)
+		}
+	}
+}
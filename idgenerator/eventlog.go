@@ -0,0 +1,66 @@
+package idgenerator
+
+// eventLogger logs the structured events of WithSlog, which is only built with a toolchain providing log/slog
+type eventLogger interface {
+	// enabled reports whether the events are logged at all, so that they are not built otherwise
+	enabled() bool
+	logEvent(e logEvent)
+}
+
+// logEvent is an event of an eventLogger with its attributes. hasID is false for the events of no single ID,
+// watermark is the fraction of a crossed watermark, high whether it is a watermark of WithHighWatermark.
+type logEvent struct {
+	msg       string
+	pool      string
+	id        int64
+	hasID     bool
+	used      int64
+	capacity  int64
+	err       error
+	watermark float64
+	high      bool
+}
+
+// The messages of the events of an eventLogger
+const (
+	logAllocateFailed   = "allocate failed"
+	logFreeNotAllocated = "free of an ID not allocated"
+	logWatermarkCrossed = "watermark crossed"
+	logLeaseExpired     = "lease expired"
+	logFreeOutOfRange   = "free of an ID out of range"
+)
+
+// logEnabled reports whether the events of WithSlog are logged, the callers check it before building one
+func (idGenerator *IDGenerator) logEnabled() bool {
+	return idGenerator.eventLog != nil && idGenerator.eventLog.enabled()
+}
+
+// newLogEventLocked returns the logEvent msg of id with the usage of the generator, the caller must hold lock
+func (idGenerator *IDGenerator) newLogEventLocked(msg string, id int64, hasID bool, err error) logEvent {
+	capacity := idGenerator.capacityLocked()
+	return logEvent{
+		msg:      msg,
+		pool:     idGenerator.name,
+		id:       id,
+		hasID:    hasID,
+		used:     clampInt64(capacity - idGenerator.availableLocked()),
+		capacity: clampInt64(capacity),
+		err:      err,
+	}
+}
+
+// queueLogLocked logs e after lock is released like the hooks, the caller must hold lock and check logEnabled
+func (idGenerator *IDGenerator) queueLogLocked(e logEvent) {
+	eventLog := idGenerator.eventLog
+	idGenerator.events = append(idGenerator.events, event{hook: func(int64) {
+		eventLog.logEvent(e)
+	}})
+}
+
+// logUnlocked logs the event msg of id right away, the caller must not hold lock and must check logEnabled
+func (idGenerator *IDGenerator) logUnlocked(msg string, id int64, hasID bool, err error) {
+	idGenerator.lock.RLock()
+	e := idGenerator.newLogEventLocked(msg, id, hasID, err)
+	idGenerator.lock.RUnlock()
+	idGenerator.eventLog.logEvent(e)
+}
@@ -0,0 +1,73 @@
+package idgenerator
+
+import "fmt"
+
+// NewGeneratorWithExclusions initializes an IDGenerator with minValue and maxValue like NewGeneratorWithOptions,
+// with the IDs in excluded never allocated: Allocate and its variants skip them,
+// AllocateSpecific, ReserveRange and FreeID reject them with an error wrapping ErrReserved,
+// and they are counted neither as used nor as available.
+// It returns an error wrapping ErrInvalidRange if an excluded ID is outside [minValue, maxValue]
+// or is a permanent ID of WithPermanentIDs.
+// The exclusions are not part of snapshots, a state loaded into the generator keeps them.
+func NewGeneratorWithExclusions(minValue, maxValue int64, excluded []int64, opts ...Option) (*IDGenerator, error) {
+	idGenerator, err := NewGeneratorWithOptions(minValue, maxValue, opts...)
+	if err != nil {
+		return nil, err
+	}
+	offsets := make(map[uint64]struct{}, len(excluded))
+	for _, id := range excluded {
+		if !idGenerator.inRange(id) {
+			return nil, fmt.Errorf("%w: excluded ID[%d] not in [%d, %d]", ErrInvalidRange, id, minValue, maxValue)
+		}
+		if _, ok := idGenerator.permanent[id]; ok {
+			return nil, fmt.Errorf("%w: excluded ID[%d] is permanent", ErrInvalidRange, id)
+		}
+		offsets[idGenerator.toOffset(id)] = struct{}{}
+	}
+	idGenerator.excluded = offsets
+	idGenerator.setExcluded(idGenerator.store)
+	idGenerator.resetRecyclingLocked()
+	return idGenerator, nil
+}
+
+// setExcluded sets the excluded offsets in store, so that the searches for a free offset skip them
+func (idGenerator *IDGenerator) setExcluded(store Store) {
+	for offset := range idGenerator.excluded {
+		store.Set(offset)
+	}
+}
+
+func (idGenerator *IDGenerator) isExcluded(offset uint64) bool {
+	_, ok := idGenerator.excluded[offset]
+	return ok
+}
+
+func (idGenerator *IDGenerator) reservedError(id int64) error {
+	return fmt.Errorf("%w: ID[%d] is excluded", ErrReserved, id)
+}
+
+// isHeld reports whether offset is set in store without being allocated, as it is excluded or quarantined
+func (idGenerator *IDGenerator) isHeld(offset uint64) bool {
+	return idGenerator.isExcluded(offset) || idGenerator.isQuarantined(offset)
+}
+
+// occupiedLocked returns the number of offsets set in store, the caller must hold lock
+func (idGenerator *IDGenerator) occupiedLocked() uint64 {
+	return idGenerator.used + uint64(len(idGenerator.excluded)) + uint64(len(idGenerator.quarantined)) +
+		uint64(len(idGenerator.vetoes))
+}
+
+// allocatedOffsetsLocked returns the offsets of the allocated IDs in ascending order, the caller must hold lock
+func (idGenerator *IDGenerator) allocatedOffsetsLocked() []uint64 {
+	offsets := idGenerator.store.SetOffsets()
+	if len(idGenerator.excluded) == 0 && len(idGenerator.quarantined) == 0 {
+		return offsets
+	}
+	allocated := offsets[:0]
+	for _, offset := range offsets {
+		if !idGenerator.isHeld(offset) {
+			allocated = append(allocated, offset)
+		}
+	}
+	return allocated
+}
@@ -0,0 +1,149 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestExclusions(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			// the exclusions at minValue and maxValue are where the sequential scan starts and wraps around
+			idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{1, 5, 10, 5}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if used, available := idGenerator.Used(), idGenerator.Available(); used != 0 || available != 7 {
+				t.Fatalf("expected used: 0, available: 7, output used: %d, available: %d", used, available)
+			}
+
+			for _, expected := range []int64{2, 3, 4, 6, 7, 8, 9} {
+				if id, err := idGenerator.Allocate(); err != nil || id != expected {
+					t.Fatalf("expected id: %d, output: %d, %+v", expected, id, err)
+				}
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+			}
+			// after the wrap-around, the free ID is found past the exclusion at minValue
+			if err = idGenerator.FreeID(2); err != nil {
+				t.Fatal(err)
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 2 {
+				t.Errorf("expected id: 2, output: %d, %+v", id, err)
+			}
+
+			for _, id := range []int64{1, 5, 10} {
+				if err = idGenerator.AllocateSpecific(id); !errors.Is(err, ErrReserved) {
+					t.Errorf("AllocateSpecific(%d): expected ErrReserved, got %+v", id, err)
+				}
+				if err = idGenerator.FreeID(id); !errors.Is(err, ErrReserved) {
+					t.Errorf("FreeID(%d): expected ErrReserved, got %+v", id, err)
+				}
+				if idGenerator.IsAllocated(id) {
+					t.Errorf("excluded ID[%d] reported allocated", id)
+				}
+			}
+			if used, available := idGenerator.Used(), idGenerator.Available(); used != 7 || available != 0 {
+				t.Errorf("expected used: 7, available: 0, output used: %d, available: %d", used, available)
+			}
+			if capacity := idGenerator.Stats().Capacity; capacity != 7 {
+				t.Errorf("expected capacity: 7, output capacity: %d", capacity)
+			}
+			if used := idGenerator.Snapshot().Used; !reflect.DeepEqual(used, []int64{2, 3, 4, 6, 7, 8, 9}) {
+				t.Errorf("unexpected snapshot used: %v", used)
+			}
+
+			// Reset frees the allocated IDs only
+			idGenerator.Reset()
+			if available := idGenerator.Available(); available != 7 {
+				t.Errorf("expected available: 7, output available: %d", available)
+			}
+			if err = idGenerator.ReserveRange(4, 6); !errors.Is(err, ErrReserved) {
+				t.Errorf("expected ErrReserved, got %+v", err)
+			}
+			if err = idGenerator.ReserveRange(6, 9); err != nil {
+				t.Fatal(err)
+			}
+			if ids, err := idGenerator.AllocateMany(3); err != nil || !reflect.DeepEqual(ids, []int64{2, 3, 4}) {
+				t.Errorf("expected ids: [2 3 4], output: %v, %+v", ids, err)
+			}
+			if _, err = idGenerator.AllocateContiguous(1); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+		})
+	}
+}
+
+func TestExclusionsStrategies(t *testing.T) {
+	excluded := []int64{0, 3, 7}
+	strategies := []Option{WithSequentialAllocation(), WithLowestFreeAllocation(), WithRandomAllocation()}
+	for i, strategy := range strategies {
+		t.Run(fmt.Sprintf("strategy %d", i), func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(0, 7, excluded, strategy)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id == 0 || id == 3 || id == 7 {
+					t.Fatalf("excluded ID[%d] allocated", id)
+				}
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+		})
+	}
+}
+
+func TestExclusionsLoad(t *testing.T) {
+	idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = idGenerator.UnmarshalJSON([]byte(`{"minValue":1,"maxValue":10,"offset":0,"used":[4,5]}`))
+	if !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	err = idGenerator.UnmarshalJSON([]byte(`{"minValue":1,"maxValue":4,"offset":0,"used":[]}`))
+	if !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	// a loaded state keeps the exclusions, also when the range moves
+	if err = idGenerator.UnmarshalJSON([]byte(`{"minValue":3,"maxValue":6,"offset":0,"used":[3]}`)); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []int64{4, 6} {
+		if id, err := idGenerator.Allocate(); err != nil || id != expected {
+			t.Errorf("expected id: %d, output: %d, %+v", expected, id, err)
+		}
+	}
+	if err = idGenerator.FreeID(5); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+}
+
+func TestExclusionsOutOfRange(t *testing.T) {
+	for _, excluded := range [][]int64{{0}, {11}, {1, 10, 20}} {
+		if _, err := NewGeneratorWithExclusions(1, 10, excluded); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("excluded %v: expected ErrInvalidRange, got %+v", excluded, err)
+		}
+	}
+	// excluding every ID is valid, the generator is exhausted from the start
+	idGenerator, err := NewGeneratorWithExclusions(1, 2, []int64{1, 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if stats := idGenerator.Stats(); stats.Capacity != 0 {
+		t.Errorf("expected capacity: 0, output capacity: %d", stats.Capacity)
+	}
+}
@@ -0,0 +1,83 @@
+package idgenerator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// WithName names the generator in its errors, e.g. after the interface or the kind of its IDs.
+// The generators of a GeneratorPool are named after their name in the pool.
+func WithName(name string) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.name = name
+	}
+}
+
+// ExhaustedError is the error of an allocation which found too few free IDs, it wraps ErrPoolExhausted.
+// It describes the pool at the time of the failure: Used counts the allocated IDs, Leased those of them
+// allocated by AllocateLease, which free themselves once their lease expires, and Quarantined the freed IDs
+// waiting for the delay of WithReuseDelay. Requested is the number of IDs asked for by AllocateMany
+// or AllocateContiguous, Contiguous set for the latter, 0 for a single ID.
+type ExhaustedError struct {
+	Name        string
+	MinValue    int64
+	MaxValue    int64
+	Capacity    uint64
+	Used        uint64
+	Leased      uint64
+	Quarantined uint64
+	Requested   uint64
+	Contiguous  bool
+}
+
+// Error returns a one line description of the pool, such as
+// `No available value range to allocate id: "teid" [1, 10]: 10/10 used, 3 of them leased, 0 quarantined`
+func (e *ExhaustedError) Error() string {
+	var b strings.Builder
+	b.WriteString(ErrPoolExhausted.Error())
+	b.WriteString(": ")
+	if e.Name != "" {
+		fmt.Fprintf(&b, "%q ", e.Name)
+	}
+	fmt.Fprintf(&b, "[%d, %d]: %d/%d used", e.MinValue, e.MaxValue, e.Used, e.Capacity)
+	if e.Leased > 0 {
+		fmt.Fprintf(&b, ", %d of them leased", e.Leased)
+	}
+	fmt.Fprintf(&b, ", %d quarantined", e.Quarantined)
+	if e.Requested > 0 {
+		consecutive := ""
+		if e.Contiguous {
+			consecutive = " consecutive"
+		}
+		fmt.Fprintf(&b, ": requested %d%s IDs, only %d available", e.Requested, consecutive, e.available())
+	}
+	return b.String()
+}
+
+func (e *ExhaustedError) Unwrap() error {
+	return ErrPoolExhausted
+}
+
+// available returns the number of IDs which were free
+func (e *ExhaustedError) available() uint64 {
+	if occupied := e.Used + e.Quarantined; occupied < e.Capacity {
+		return e.Capacity - occupied
+	}
+	return 0
+}
+
+// exhaustedError returns the *ExhaustedError of an allocation of requested IDs, 0 for a single one,
+// the caller must hold lock
+func (idGenerator *IDGenerator) exhaustedError(requested uint64, contiguous bool) *ExhaustedError {
+	return &ExhaustedError{
+		Name:        idGenerator.name,
+		MinValue:    idGenerator.minValue,
+		MaxValue:    idGenerator.maxValue,
+		Capacity:    idGenerator.capacityLocked(),
+		Used:        idGenerator.used,
+		Leased:      uint64(len(idGenerator.leases)),
+		Quarantined: uint64(len(idGenerator.quarantined)),
+		Requested:   requested,
+		Contiguous:  contiguous,
+	}
+}
@@ -0,0 +1,99 @@
+package idgenerator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExhaustedError(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{10},
+		WithName("teid"), WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if _, err = idGenerator.AllocateLease(time.Hour); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids := allocateN(t, idGenerator, 6)
+	freeAll(t, idGenerator, ids[0])
+
+	_, err = idGenerator.Allocate()
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) || !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected an *ExhaustedError, got %+v", err)
+	}
+	expected := ExhaustedError{
+		Name: "teid", MinValue: 1, MaxValue: 10, Capacity: 9, Used: 8, Leased: 3, Quarantined: 1,
+	}
+	if *exhausted != expected {
+		t.Errorf("expected %#v, output %#v", expected, *exhausted)
+	}
+	if expected := `No available value range to allocate id: "teid" [1, 10]: 8/9 used, 3 of them leased, ` +
+		`1 quarantined`; err.Error() != expected {
+		t.Errorf("expected %q, output %q", expected, err)
+	}
+
+	testCases := []struct {
+		name     string
+		call     func() error
+		expected ExhaustedError
+		message  string
+	}{
+		{"AllocateMany", func() error {
+			_, err := idGenerator.AllocateMany(2)
+			return err
+		}, ExhaustedError{Requested: 2}, ": requested 2 IDs, only 0 available"},
+		{"AllocateContiguous", func() error {
+			_, err := idGenerator.AllocateContiguous(3)
+			return err
+		}, ExhaustedError{Requested: 3, Contiguous: true}, ": requested 3 consecutive IDs, only 0 available"},
+		{"AllocateWithOffset", func() error {
+			_, err := idGenerator.AllocateWithOffset(5)
+			return err
+		}, ExhaustedError{}, ""},
+		{"AllocateAtOrAbove", func() error {
+			_, err := idGenerator.AllocateAtOrAbove(5)
+			return err
+		}, ExhaustedError{}, ""},
+	}
+	for _, testCase := range testCases {
+		err := testCase.call()
+		if !errors.As(err, &exhausted) {
+			t.Errorf("%s: expected an *ExhaustedError, got %+v", testCase.name, err)
+			continue
+		}
+		if exhausted.Requested != testCase.expected.Requested || exhausted.Contiguous != testCase.expected.Contiguous ||
+			exhausted.Used != 8 {
+			t.Errorf("%s: unexpected %#v", testCase.name, *exhausted)
+		}
+		if !strings.HasSuffix(exhausted.Error(), testCase.message) {
+			t.Errorf("%s: expected %q at the end of %q", testCase.name, testCase.message, exhausted)
+		}
+	}
+
+	// the expired leases are quarantined in turn, the first freed ID is free again
+	clock.Advance(2 * time.Hour)
+	_, err = idGenerator.AllocateMany(2)
+	if !errors.As(err, &exhausted) || exhausted.Used != 5 || exhausted.Leased != 0 || exhausted.Quarantined != 3 {
+		t.Errorf("unexpected %+v", err)
+	}
+}
+
+func TestExhaustedErrorPoolName(t *testing.T) {
+	pool := NewGeneratorPool(WithName("ignored"))
+	generator, err := pool.GetOrCreate("seid", 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, generator, 1)
+	_, err = generator.Allocate()
+	var exhausted *ExhaustedError
+	if !errors.As(err, &exhausted) || exhausted.Name != "seid" {
+		t.Errorf("expected an *ExhaustedError of seid, got %+v", err)
+	}
+}
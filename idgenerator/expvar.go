@@ -0,0 +1,30 @@
+package idgenerator
+
+import (
+	"expvar"
+	"fmt"
+	"sync"
+)
+
+// publishLock serializes the check and the registration of PublishExpvar, since expvar.Publish panics on a name
+// which is already published
+var publishLock sync.Mutex
+
+// PublishExpvar publishes the Stats of idGenerator with expvar under name, as a JSON object served on /debug/vars.
+// The values are read from the counters under the read lock on every request, so that they block the allocations
+// no longer than Used: LargestFreeBlock, FreeFragments, MeanHoldTime and MaxHoldTime, which walk the IDs,
+// are reported as 0 and Owners is left out. A name can only be published once, expvar has no way to unpublish it.
+// It returns an error wrapping ErrAlreadyPublished if name is already published, by this package or another.
+func PublishExpvar(name string, idGenerator *IDGenerator) error {
+	publishLock.Lock()
+	defer publishLock.Unlock()
+	if expvar.Get(name) != nil {
+		return fmt.Errorf("%w: %q", ErrAlreadyPublished, name)
+	}
+	expvar.Publish(name, expvar.Func(func() interface{} {
+		idGenerator.rlock()
+		defer idGenerator.lock.RUnlock()
+		return idGenerator.countersLocked()
+	}))
+	return nil
+}
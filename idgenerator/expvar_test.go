@@ -0,0 +1,69 @@
+package idgenerator
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"reflect"
+	"testing"
+)
+
+func TestPublishExpvar(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithHoldTimes(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("upf"); err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	if err = idGenerator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if err = PublishExpvar("idgenerator_test_teid", idGenerator); err != nil {
+		t.Fatal(err)
+	}
+
+	variable := expvar.Get("idgenerator_test_teid")
+	if variable == nil {
+		t.Fatal("expected the stats to be published")
+	}
+	var stats Stats
+	if err = json.Unmarshal([]byte(variable.String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	expected := Stats{
+		MinValue:    1,
+		MaxValue:    10,
+		Strategy:    StrategySequential,
+		Used:        2,
+		References:  2,
+		Free:        8,
+		Capacity:    10,
+		Offset:      3,
+		Allocations: 3,
+		Frees:       1,
+		PeakUsed:    3,
+	}
+	stats.PeakAt = expected.PeakAt
+	if !reflect.DeepEqual(stats, expected) {
+		t.Fatalf("expected %+v, got %+v", expected, stats)
+	}
+
+	// the values are read on every request
+	allocateN(t, idGenerator, 1)
+	if err = json.Unmarshal([]byte(variable.String()), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Used != 3 {
+		t.Errorf("expected 3 IDs used, got %d", stats.Used)
+	}
+
+	if err = PublishExpvar("idgenerator_test_teid", idGenerator); !errors.Is(err, ErrAlreadyPublished) {
+		t.Errorf("expected ErrAlreadyPublished, got %v", err)
+	}
+	expvar.NewInt("idgenerator_test_other")
+	if err = PublishExpvar("idgenerator_test_other", idGenerator); !errors.Is(err, ErrAlreadyPublished) {
+		t.Errorf("expected ErrAlreadyPublished, got %v", err)
+	}
+}
@@ -0,0 +1,90 @@
+package idgenerator
+
+import "container/list"
+
+// WithFairWaiting makes the waiting calls of AllocateCtx and AllocateForCtx served round-robin per owner
+// rather than in FIFO order: a freed ID goes to the longest waiting call of the owner whose turn it is,
+// then the turn passes to the next owner with a waiting call, so that each owner waiting gets an equal share
+// of the freed IDs however many calls it has waiting. The calls of AllocateCtx take their turns as the owner "".
+// An owner takes turns from its first waiting call until its last one returns.
+func WithFairWaiting() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.fairWaiting = true
+	}
+}
+
+// waitQueue holds the AllocateCtx and AllocateForCtx calls waiting for a free ID, in a queue per owner
+// with WithFairWaiting or in a single queue otherwise. turns holds the queues with waiting calls,
+// the front one is served next; a queue is dropped once it is empty, so that owners do not pile up.
+type waitQueue struct {
+	fair   bool
+	queues map[string]*ownerQueue
+	turns  *list.List
+	len    int
+}
+
+// ownerQueue are the waiting calls of an owner in FIFO order, turn is its element in the turns of the waitQueue
+type ownerQueue struct {
+	owner   string
+	waiters *list.List
+	turn    *list.Element
+}
+
+func newWaitQueue(fair bool) *waitQueue {
+	return &waitQueue{fair: fair, queues: make(map[string]*ownerQueue), turns: list.New()}
+}
+
+// push queues w behind the other calls of its owner
+func (q *waitQueue) push(w *waiter) {
+	key := ""
+	if q.fair {
+		key = w.owner
+	}
+	queue, ok := q.queues[key]
+	if !ok {
+		queue = &ownerQueue{owner: key, waiters: list.New()}
+		queue.turn = q.turns.PushBack(queue)
+		q.queues[key] = queue
+	}
+	w.queue, w.elem = queue, queue.waiters.PushBack(w)
+	q.len++
+}
+
+// remove takes w out of the queue, dropping the queue of its owner once empty
+func (q *waitQueue) remove(w *waiter) {
+	queue := w.queue
+	queue.waiters.Remove(w.elem)
+	w.queue, w.elem = nil, nil
+	q.len--
+	if queue.waiters.Len() == 0 {
+		q.turns.Remove(queue.turn)
+		delete(q.queues, queue.owner)
+	}
+}
+
+// next returns the call to serve next, the first servable call of the owners in turn order, nil if none is.
+// Without WithFairWaiting it is the first servable call in FIFO order.
+func (q *waitQueue) next(servable func(w *waiter) bool) *waiter {
+	for turn := q.turns.Front(); turn != nil; turn = turn.Next() {
+		queue := turn.Value.(*ownerQueue)
+		for e := queue.waiters.Front(); e != nil; e = e.Next() {
+			if w := e.Value.(*waiter); servable(w) {
+				return w
+			}
+			if q.fair {
+				// the calls of an owner are served in order
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// served removes w, which has been served, and passes the turn to the next owner
+func (q *waitQueue) served(w *waiter) {
+	queue := w.queue
+	q.remove(w)
+	if queue.waiters.Len() > 0 {
+		q.turns.MoveToBack(queue.turn)
+	}
+}
@@ -0,0 +1,126 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// competeForIDs queues the waiting calls of the owners in order on an exhausted generator, frees trickle IDs
+// one at a time and returns how many each owner got, then cancels the calls left waiting
+func competeForIDs(t *testing.T, fair bool, owners []string, calls, trickle int) map[string]int {
+	t.Helper()
+	opts := []Option{}
+	if fair {
+		opts = append(opts, WithFairWaiting())
+	}
+	idGenerator, err := NewGeneratorWithOptions(1, int64(trickle), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := allocateN(t, idGenerator, trickle)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type result struct {
+		owner string
+		err   error
+	}
+	results := make(chan result, len(owners)*calls)
+	queued := 0
+	for _, owner := range owners {
+		for i := 0; i < calls; i++ {
+			go func(owner string) {
+				_, err := idGenerator.AllocateForCtx(ctx, owner)
+				results <- result{owner, err}
+			}(owner)
+			queued++
+			// the calls are queued in order
+			waitForWaiters(t, idGenerator, queued)
+		}
+	}
+
+	got := make(map[string]int)
+	for _, id := range ids {
+		if err = idGenerator.FreeID(id); err != nil {
+			t.Fatal(err)
+		}
+		r := <-results
+		if r.err != nil {
+			t.Fatal(r.err)
+		}
+		got[r.owner]++
+	}
+	cancel()
+	for i := trickle; i < queued; i++ {
+		if r := <-results; !errors.Is(r.err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", r.err)
+		}
+	}
+	// the queues of the owners are dropped with their last call
+	idGenerator.lock.Lock()
+	defer idGenerator.lock.Unlock()
+	if idGenerator.waiters.len != 0 || len(idGenerator.waiters.queues) != 0 || idGenerator.waiters.turns.Len() != 0 {
+		t.Errorf("expected an empty wait queue, got %d calls of %d owners",
+			idGenerator.waiters.len, len(idGenerator.waiters.queues))
+	}
+	for owner, n := range got {
+		if used := len(idGenerator.owners[owner].offsets); used != n {
+			t.Errorf("owner %q: expected %d IDs tagged, output %d", owner, n, used)
+		}
+	}
+	return got
+}
+
+func TestFairWaiting(t *testing.T) {
+	owners := []string{"busy", "tenant-1", "tenant-2"}
+	got := competeForIDs(t, true, owners, 9, 9)
+	for _, owner := range owners {
+		if got[owner] != 3 {
+			t.Errorf("expected an even split, got %v", got)
+			break
+		}
+	}
+
+	// in FIFO order the owner queued first takes every ID
+	if got = competeForIDs(t, false, owners, 9, 9); got["busy"] != 9 {
+		t.Errorf("expected the first owner to take every ID, got %v", got)
+	}
+}
+
+func TestWaitingQuota(t *testing.T) {
+	idGenerator := NewGenerator(1, 2)
+	idGenerator.SetQuota("capped", 1)
+	ids := allocateN(t, idGenerator, 2)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	type result struct {
+		owner string
+		id    int64
+		err   error
+	}
+	results := make(chan result, 3)
+	for i, owner := range []string{"capped", "capped", "other"} {
+		go func(owner string) {
+			id, err := idGenerator.AllocateForCtx(ctx, owner)
+			results <- result{owner, id, err}
+		}(owner)
+		waitForWaiters(t, idGenerator, i+1)
+	}
+	// the second call of the capped owner waits for its quota, the call behind it is served instead
+	for _, expected := range []string{"capped", "other"} {
+		freeAll(t, idGenerator, ids[0])
+		ids = ids[1:]
+		if r := <-results; r.err != nil || r.owner != expected {
+			t.Fatalf("expected a call of %q served, got %+v", expected, r)
+		}
+	}
+	if _, err := idGenerator.AllocateForCtx(ctx, "capped"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	cancel()
+	if r := <-results; !errors.Is(r.err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", r)
+	}
+}
@@ -0,0 +1,23 @@
+//go:build !(darwin || dragonfly || freebsd || linux || netbsd || openbsd || windows)
+
+package idgenerator
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+var errLockUnsupported = fmt.Errorf("file locking not supported on %s", runtime.GOOS)
+
+func lockFile(*os.File, bool) error {
+	return errLockUnsupported
+}
+
+func tryLockFile(*os.File, bool) (bool, error) {
+	return false, errLockUnsupported
+}
+
+func unlockFile(*os.File) error {
+	return errLockUnsupported
+}
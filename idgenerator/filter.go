@@ -0,0 +1,97 @@
+package idgenerator
+
+import "fmt"
+
+// WithAllocationFilter makes Allocate skip the free IDs allow returns false for, e.g. those still draining
+// in a downstream cache, and carry on with the next one its strategy picks. It fails with ErrPoolExhausted
+// only if allow vetoes every free ID. The allocations picking an ID like Allocate, such as AllocateMany,
+// AllocateFor, AllocateLease and the waiting ones, as well as Peek, consult allow too,
+// AllocateWithPreference falls back to another ID if preferred is vetoed and AllocateSpecific fails
+// with ErrFiltered. AllocateWithOffset, AllocateAtOrAbove, AllocateContiguous and ReserveRange do not consult it.
+//
+// allow is called with the lock of the generator held, since the ID it is asked about could be taken otherwise:
+// it must be fast and must not call the generator, which would deadlock.
+// A vetoed ID is asked about again by the next allocation.
+func WithAllocationFilter(allow func(id int64) bool) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.filter = allow
+	}
+}
+
+// veto is an offset vetoed by the filter of WithAllocationFilter, or skipped in the priority band,
+// set in store while the allocation runs.
+// queued and touched tell how it was held from WithFIFORecycling, taken off the queue or marked used.
+type veto struct {
+	offset  uint64
+	queued  bool
+	touched bool
+}
+
+// pickAllowedLocked is pickLocked skipping the offsets the filter of WithAllocationFilter vetoes,
+// and those of the priority band of WithPriorityBand while another one is free,
+// which are held meanwhile so that the strategy moves on to another one.
+// The caller must hold lock and ensure an offset is free.
+func (idGenerator *IDGenerator) pickAllowedLocked() (uint64, error) {
+	first, last, skipBand := idGenerator.bandLocked()
+	if idGenerator.filter == nil && !skipBand {
+		return idGenerator.pickLocked()
+	}
+	defer idGenerator.releaseVetoesLocked()
+	for {
+		if skipBand && !idGenerator.restFreeLocked(first, last) {
+			if idGenerator.band.exclusive {
+				return 0, fmt.Errorf("%w, the free IDs are in the priority band", idGenerator.exhaustedError(0, false))
+			}
+			skipBand = false
+		}
+		offset, err := idGenerator.pickLocked()
+		if err != nil {
+			return 0, err
+		}
+		inBand := skipBand && offset >= first && offset <= last
+		if !inBand && (idGenerator.filter == nil || idGenerator.filter(idGenerator.toID(offset))) {
+			return offset, nil
+		}
+		idGenerator.holdVetoLocked(offset)
+		if idGenerator.availableLocked() == 0 {
+			return 0, fmt.Errorf("%w, %d free vetoed by the allocation filter",
+				idGenerator.exhaustedError(0, false), len(idGenerator.vetoes))
+		}
+	}
+}
+
+// holdVetoLocked sets the free offset in store and in the state of WithFIFORecycling, which pick another one then.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) holdVetoLocked(offset uint64) {
+	v := veto{offset: offset}
+	idGenerator.store.Set(offset)
+	if recycling := idGenerator.recycling; recycling != nil {
+		if e, ok := recycling.queued[offset]; ok {
+			recycling.queue.Remove(e)
+			delete(recycling.queued, offset)
+			v.queued = true
+		} else if !recycling.touched.Has(offset) {
+			recycling.touched.Set(offset)
+			recycling.occupied++
+			v.touched = true
+		}
+	}
+	idGenerator.vetoes = append(idGenerator.vetoes, v)
+}
+
+// releaseVetoesLocked frees the offsets of holdVetoLocked, the caller must hold lock
+func (idGenerator *IDGenerator) releaseVetoesLocked() {
+	// the queued offsets were taken off the front of the queue one by one, they are put back in reverse order
+	for i := len(idGenerator.vetoes) - 1; i >= 0; i-- {
+		v := idGenerator.vetoes[i]
+		idGenerator.store.Clear(v.offset)
+		if v.queued {
+			idGenerator.recycling.queued[v.offset] = idGenerator.recycling.queue.PushFront(v.offset)
+		}
+		if v.touched {
+			idGenerator.recycling.touched.Clear(v.offset)
+			idGenerator.recycling.occupied--
+		}
+	}
+	idGenerator.vetoes = idGenerator.vetoes[:0]
+}
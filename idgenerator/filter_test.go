@@ -0,0 +1,128 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAllocationFilter(t *testing.T) {
+	strategies := []struct {
+		name string
+		opts []Option
+	}{
+		{"sequential", nil},
+		{"lowest free", []Option{WithLowestFreeAllocation()}},
+		{"random", []Option{WithRandomAllocation()}},
+		{"recycling", []Option{WithFIFORecycling(RecycleFirst)}},
+	}
+	for _, strategy := range strategies {
+		t.Run(strategy.name, func(t *testing.T) {
+			odd := func(id int64) bool { return id%2 == 1 }
+			idGenerator, err := NewGeneratorWithOptions(1, 10, append(strategy.opts, WithAllocationFilter(odd))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the freed IDs of the recycling queue are vetoed like the never used ones
+			freeAll(t, idGenerator, allocateN(t, idGenerator, 2)...)
+
+			for i := 0; i < 5; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !odd(id) {
+					t.Errorf("allocated vetoed ID %d", id)
+				}
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted with every free ID vetoed, got %v", err)
+			}
+			if _, err = idGenerator.Peek(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected Peek to fail with ErrPoolExhausted, got %v", err)
+			}
+			if available := idGenerator.Available(); available != 5 {
+				t.Errorf("expected the 5 vetoed IDs free, got %d available", available)
+			}
+			for id := int64(2); id <= 10; id += 2 {
+				if idGenerator.IsAllocated(id) {
+					t.Errorf("expected vetoed ID %d free", id)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocationFilterRollback(t *testing.T) {
+	low := func(id int64) bool { return id <= 5 }
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithAllocationFilter(low), WithReuseDelay(time.Hour),
+		WithAudit(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateMany(8); err == nil {
+		t.Fatal("expected AllocateMany to fail with 5 IDs allowed")
+	}
+	// the IDs allocated before the failure are given back unseen, not quarantined
+	stats := idGenerator.Stats()
+	if stats.Used != 0 || stats.Quarantined != 0 || stats.Allocations != 0 || stats.Frees != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if events := idGenerator.Events(AuditFilter{}); events != nil {
+		t.Errorf("expected no event recorded, output %v", events)
+	}
+	if ids := allocateN(t, idGenerator, 5); !reflect.DeepEqual(ids, []int64{1, 2, 3, 4, 5}) {
+		t.Errorf("expected IDs 1 to 5, output %v", ids)
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAllocationFilterRecyclingOrder(t *testing.T) {
+	vetoed := map[int64]bool{}
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithFIFORecycling(RecycleFirst),
+		WithAllocationFilter(func(id int64) bool { return !vetoed[id] }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 8)
+	freeAll(t, idGenerator, 5, 3, 7)
+
+	vetoed[5], vetoed[3] = true, true
+	if ids := allocateN(t, idGenerator, 1); !reflect.DeepEqual(ids, []int64{7}) {
+		t.Errorf("expected 7, the first freed ID allowed, got %v", ids)
+	}
+	// the vetoed IDs keep their place in the queue
+	vetoed[5], vetoed[3] = false, false
+	if ids := allocateN(t, idGenerator, 2); !reflect.DeepEqual(ids, []int64{5, 3}) {
+		t.Errorf("expected 5 and 3 in the order they were freed, got %v", ids)
+	}
+}
+
+func TestAllocationFilterSpecific(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithAllocationFilter(func(id int64) bool { return id != 3 }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(3); !errors.Is(err, ErrFiltered) {
+		t.Errorf("expected ErrFiltered, got %v", err)
+	}
+	if err = idGenerator.AllocateSpecific(4); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(4); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %v", err)
+	}
+	id, preferred, err := idGenerator.AllocateWithPreference(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if preferred || id == 3 {
+		t.Errorf("expected another ID than the vetoed preferred one, got %d, %v", id, preferred)
+	}
+	if ids := allocateN(t, idGenerator, 2); !reflect.DeepEqual(ids, []int64{2, 5}) {
+		t.Errorf("expected 2 and 5 past the vetoed 3, got %v", ids)
+	}
+}
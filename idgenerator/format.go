@@ -0,0 +1,95 @@
+package idgenerator
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Formatter converts IDs to strings such as "SESS-000042" and back: Prefix, then a minus sign for negative IDs,
+// then the digits in Base, lower case, padded with zeros to Width digits.
+// Every ID has a single string, Parse rejects any other spelling of it, such as a missing or extra padding zero.
+// The zero Formatter spells IDs in plain decimal.
+type Formatter struct {
+	Prefix string
+	// Width is the minimum number of digits, larger IDs take more
+	Width int
+	// Base is 10, 16 or 36, 0 stands for 10
+	Base int
+}
+
+func (f Formatter) base() int {
+	if f.Base == 0 {
+		return 10
+	}
+	return f.Base
+}
+
+func (f Formatter) validate() error {
+	switch f.base() {
+	case 10, 16, 36:
+	default:
+		return fmt.Errorf("invalid formatter: unsupported base %d", f.Base)
+	}
+	if f.Width < 0 {
+		return fmt.Errorf("invalid formatter: negative width %d", f.Width)
+	}
+	return nil
+}
+
+// Format returns the string of id
+func (f Formatter) Format(id int64) string {
+	digits := strconv.FormatInt(id, f.base())
+	sign := ""
+	if id < 0 {
+		sign, digits = "-", digits[1:]
+	}
+	if pad := f.Width - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	return f.Prefix + sign + digits
+}
+
+// Parse returns the ID of s, or an error wrapping ErrMalformedID if s is not the string of an ID
+func (f Formatter) Parse(s string) (int64, error) {
+	if !strings.HasPrefix(s, f.Prefix) {
+		return 0, fmt.Errorf("%w: %q does not start with %q", ErrMalformedID, s, f.Prefix)
+	}
+	number := s[len(f.Prefix):]
+	// ParseInt accepts a leading '+' and upper case digits, the comparison below rejects them
+	id, err := strconv.ParseInt(number, f.base(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q: %v", ErrMalformedID, s, err)
+	}
+	if f.Format(id) != s {
+		return 0, fmt.Errorf("%w: %q is not spelled as %q", ErrMalformedID, s, f.Format(id))
+	}
+	return id, nil
+}
+
+// WithFormatter sets the Formatter of AllocateString and FreeString,
+// NewGeneratorWithOptions fails if its Base or Width is invalid
+func WithFormatter(f Formatter) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.formatter = f
+	}
+}
+
+// AllocateString allocates an ID like Allocate and returns it formatted by the Formatter of the generator
+func (idGenerator *IDGenerator) AllocateString() (string, error) {
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		return "", err
+	}
+	return idGenerator.formatter.Format(id), nil
+}
+
+// FreeString frees the ID of s like FreeID.
+// It returns an error wrapping ErrMalformedID if s is not formatted by the Formatter of the generator.
+func (idGenerator *IDGenerator) FreeString(s string) error {
+	id, err := idGenerator.formatter.Parse(s)
+	if err != nil {
+		return err
+	}
+	return idGenerator.FreeID(id)
+}
@@ -0,0 +1,128 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+var testFormatters = []Formatter{
+	{},
+	{Prefix: "SESS-", Width: 6},
+	{Prefix: "0x", Width: 4, Base: 16},
+	{Prefix: "id", Width: 3, Base: 36},
+}
+
+func TestFormatter(t *testing.T) {
+	testCases := []struct {
+		formatter Formatter
+		id        int64
+		expected  string
+	}{
+		{Formatter{}, 42, "42"},
+		{Formatter{}, -42, "-42"},
+		{Formatter{Prefix: "SESS-", Width: 6}, 42, "SESS-000042"},
+		{Formatter{Prefix: "SESS-", Width: 6}, -42, "SESS--000042"},
+		{Formatter{Prefix: "SESS-", Width: 6}, 1234567, "SESS-1234567"},
+		{Formatter{Prefix: "SESS-", Width: 6}, math.MaxInt64, "SESS-9223372036854775807"},
+		{Formatter{Prefix: "SESS-", Width: 6}, math.MinInt64, "SESS--9223372036854775808"},
+		{Formatter{Prefix: "0x", Width: 4, Base: 16}, 255, "0x00ff"},
+		{Formatter{Prefix: "0x", Width: 4, Base: 16}, math.MaxInt64, "0x7fffffffffffffff"},
+		{Formatter{Base: 36, Width: 2}, 35, "0z"},
+	}
+	for _, testCase := range testCases {
+		if s := testCase.formatter.Format(testCase.id); s != testCase.expected {
+			t.Errorf("%+v: expected %q for %d, output %q", testCase.formatter, testCase.expected, testCase.id, s)
+		}
+		if id, err := testCase.formatter.Parse(testCase.expected); err != nil || id != testCase.id {
+			t.Errorf("%+v: expected %d for %q, output %d, %+v", testCase.formatter, testCase.id, testCase.expected, id, err)
+		}
+	}
+
+	formatter := Formatter{Prefix: "SESS-", Width: 6}
+	for _, s := range []string{
+		"", "42", "sess-000042", "SESS-42", "SESS-0000042", "SESS-+00042", "SESS-00004a",
+		"SESS- 00042", "SESS-92233720368547758070", "SESS--000000", "SESS-",
+	} {
+		if _, err := formatter.Parse(s); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("Parse(%q): expected ErrMalformedID, got %+v", s, err)
+		}
+	}
+	if _, err := (Formatter{Base: 16}).Parse("FF"); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("expected ErrMalformedID for upper case digits, got %+v", err)
+	}
+}
+
+func TestAllocateString(t *testing.T) {
+	formatter := Formatter{Prefix: "SESS-", Width: 6}
+	idGenerator, err := NewGeneratorWithOptions(999998, 1000000, WithFormatter(formatter))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the widest IDs at the top of the range round trip as well
+	for _, expected := range []string{"SESS-999998", "SESS-999999", "SESS-1000000"} {
+		s, err := idGenerator.AllocateString()
+		if err != nil || s != expected {
+			t.Fatalf("expected %q, output %q, %+v", expected, s, err)
+		}
+	}
+	if _, err = idGenerator.AllocateString(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err = idGenerator.FreeString("SESS-1000000"); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(1000000) {
+		t.Error("freed ID is still allocated")
+	}
+	if err = idGenerator.FreeString("SESS-1000000"); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err = idGenerator.FreeString("SESS-0999999"); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("expected ErrMalformedID, got %+v", err)
+	}
+	if err = idGenerator.FreeString("SESS-000001"); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+
+	for _, invalid := range []Formatter{{Base: 8}, {Base: 37}, {Width: -1}} {
+		if _, err = NewGeneratorWithOptions(1, 10, WithFormatter(invalid)); err == nil {
+			t.Errorf("invalid formatter %+v accepted", invalid)
+		}
+	}
+}
+
+func FuzzFormatterParse(f *testing.F) {
+	for _, seed := range []string{"SESS-000042", "0x00ff", "id0z", "-1", "+1", "SESS--000001", "0x7FFFFFFFFFFFFFFF"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		for _, formatter := range testFormatters {
+			id, err := formatter.Parse(s)
+			if err != nil {
+				if !errors.Is(err, ErrMalformedID) {
+					t.Errorf("%+v: Parse(%q) failed with %+v", formatter, s, err)
+				}
+				continue
+			}
+			// only the string of an ID parses
+			if formatted := formatter.Format(id); formatted != s {
+				t.Errorf("%+v: Parse(%q) = %d, which formats as %q", formatter, s, id, formatted)
+			}
+		}
+	})
+}
+
+func FuzzFormatterRoundTrip(f *testing.F) {
+	for _, seed := range []int64{0, 1, -1, 42, math.MaxInt64, math.MinInt64} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, id int64) {
+		for _, formatter := range testFormatters {
+			s := formatter.Format(id)
+			if parsed, err := formatter.Parse(s); err != nil || parsed != id {
+				t.Errorf("%+v: Format(%d) = %q, which parses as %d, %+v", formatter, id, s, parsed, err)
+			}
+		}
+	})
+}
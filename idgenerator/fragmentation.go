@@ -0,0 +1,47 @@
+package idgenerator
+
+import "math"
+
+// LargestFreeBlock returns the length of the longest run of consecutive free IDs, the largest n AllocateContiguous
+// may succeed with, capped at math.MaxInt64. It is 0 if no ID is free.
+func (idGenerator *IDGenerator) LargestFreeBlock() int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	largest, _ := idGenerator.fragmentationLocked()
+	return clampInt64(largest)
+}
+
+// FragmentCount returns the number of maximal runs of consecutive free IDs, the blocks of FreeBlocks:
+// 1 for an empty generator without exclusions, 0 for a full one. Together with Available it tells
+// how fragmented the free IDs are.
+func (idGenerator *IDGenerator) FragmentCount() int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	_, fragments := idGenerator.fragmentationLocked()
+	return clampInt64(fragments)
+}
+
+// fragmentationLocked returns the length of the longest free run, capped at math.MaxUint64, and the number of runs.
+// It takes a step per run, derived from the set offsets, rather than one per free offset. The caller must hold lock.
+func (idGenerator *IDGenerator) fragmentationLocked() (largest, fragments uint64) {
+	start, ok := idGenerator.store.NextClear(0)
+	for ok {
+		fragments++
+		end, bounded := idGenerator.store.NextSet(start)
+		length := end - start
+		if !bounded {
+			// all 2^64 offsets of the full int64 range may be free
+			if length = idGenerator.lastOffset - start + 1; length == 0 {
+				length = math.MaxUint64
+			}
+		}
+		if length > largest {
+			largest = length
+		}
+		if !bounded {
+			break
+		}
+		start, ok = idGenerator.store.NextClear(end)
+	}
+	return largest, fragments
+}
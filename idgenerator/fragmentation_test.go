@@ -0,0 +1,104 @@
+package idgenerator
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestFragmentation(t *testing.T) {
+	idGenerator, err := NewGeneratorWithExclusions(1, 20, []int64{20})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if largest, fragments := idGenerator.LargestFreeBlock(), idGenerator.FragmentCount(); largest != 19 || fragments != 1 {
+		t.Errorf("expected a block of 19, output %d in %d fragments", largest, fragments)
+	}
+	// free: 1-4, 6-9, 11-19
+	for _, id := range []int64{5, 10} {
+		if err = idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if largest, fragments := idGenerator.LargestFreeBlock(), idGenerator.FragmentCount(); largest != 9 || fragments != 3 {
+		t.Errorf("expected the largest block 9 of 3 fragments, output %d of %d", largest, fragments)
+	}
+	// freeing 5 merges 1-4 and 6-9
+	if err = idGenerator.FreeID(5); err != nil {
+		t.Fatal(err)
+	}
+	if largest, fragments := idGenerator.LargestFreeBlock(), idGenerator.FragmentCount(); largest != 9 || fragments != 2 {
+		t.Errorf("expected the largest block 9 of 2 fragments, output %d of %d", largest, fragments)
+	}
+	stats := idGenerator.DetailedStats()
+	if stats.LargestFreeBlock != 9 || stats.FreeFragments != 2 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	allocateN(t, idGenerator, 18)
+	if largest, fragments := idGenerator.LargestFreeBlock(), idGenerator.FragmentCount(); largest != 0 || fragments != 0 {
+		t.Errorf("expected no free block, output %d of %d", largest, fragments)
+	}
+
+	full := NewGenerator(math.MinInt64, math.MaxInt64)
+	if largest, fragments := full.LargestFreeBlock(), full.FragmentCount(); largest != math.MaxInt64 || fragments != 1 {
+		t.Errorf("expected a capped block of the full range, output %d of %d", largest, fragments)
+	}
+}
+
+// bruteFragmentation returns the largest free run and the number of runs of idGenerator, checking every offset
+func bruteFragmentation(idGenerator *IDGenerator) (largest, fragments uint64) {
+	run := uint64(0)
+	for offset := uint64(0); offset <= idGenerator.lastOffset; offset++ {
+		if idGenerator.store.Has(offset) {
+			run = 0
+			continue
+		}
+		if run == 0 {
+			fragments++
+		}
+		if run++; run > largest {
+			largest = run
+		}
+	}
+	return largest, fragments
+}
+
+// TestFragmentationRandom compares the fragmentation with a brute force count after random operations
+func TestFragmentationRandom(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			idGenerator, err := NewGeneratorWithExclusions(0, 199, []int64{0, 100, 199}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 2000; i++ {
+				id := r.Int63n(200)
+				switch r.Intn(4) {
+				case 0:
+					if _, err = idGenerator.AllocateContiguous(r.Int63n(8) + 1); err != nil {
+						continue
+					}
+				case 1:
+					if _, err = idGenerator.FreeRange(id, id+r.Int63n(200-id)); err != nil {
+						t.Fatal(err)
+					}
+				case 2:
+					if err = idGenerator.AllocateSpecific(id); err != nil {
+						continue
+					}
+				default:
+					if err = idGenerator.FreeID(id); err != nil {
+						continue
+					}
+				}
+				stats := idGenerator.DetailedStats()
+				largest, fragments := bruteFragmentation(idGenerator)
+				if stats.LargestFreeBlock != largest || stats.FreeFragments != fragments {
+					t.Fatalf("step %d: expected the largest block %d of %d fragments, output %d of %d",
+						i, largest, fragments, stats.LargestFreeBlock, stats.FreeFragments)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package idgenerator
+
+import "fmt"
+
+// Token is an allocated ID with its generation, the number of times the ID has been allocated.
+// FreeToken only frees the ID if it has not been reallocated since, so a delayed retry of a free
+// cannot release the ID from under its next owner.
+type Token struct {
+	ID         int64
+	Generation uint64
+}
+
+// WithGenerations makes the generator count the allocations of each ID, by any method,
+// for AllocateToken, FreeToken and Generation. The counts take memory for every ID ever allocated.
+func WithGenerations() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.generations = make(map[uint64]uint64)
+	}
+}
+
+func (idGenerator *IDGenerator) generationsDisabledError() error {
+	return fmt.Errorf("idgenerator[%d-%d]: generations are not counted without WithGenerations",
+		idGenerator.minValue, idGenerator.maxValue)
+}
+
+// AllocateToken allocates an ID like Allocate and returns it with its generation,
+// it fails without WithGenerations
+func (idGenerator *IDGenerator) AllocateToken() (Token, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	if idGenerator.generations == nil {
+		idGenerator.unlock()
+		return Token{}, idGenerator.generationsDisabledError()
+	}
+	id, err := idGenerator.allocateLocked()
+	if err != nil {
+		idGenerator.unlock()
+		idGenerator.allocateFailed(err)
+		return Token{}, err
+	}
+	token := Token{ID: id, Generation: idGenerator.generations[idGenerator.toOffset(id)]}
+	idGenerator.unlock()
+	return token, nil
+}
+
+// FreeToken frees the ID of token like FreeID if it is still of the generation of token.
+// It returns an error wrapping ErrStaleToken if the ID has been allocated again since, by any method,
+// or fails like FreeID otherwise, e.g. with ErrNotAllocated if the ID has been freed already.
+func (idGenerator *IDGenerator) FreeToken(token Token) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(token.ID) {
+		return idGenerator.outOfRangeFreeError(token.ID)
+	}
+	if idGenerator.generations == nil {
+		return idGenerator.generationsDisabledError()
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if generation := idGenerator.generations[idGenerator.toOffset(token.ID)]; generation != token.Generation {
+		return fmt.Errorf("%w: ID[%d] of generation %d, token of generation %d",
+			ErrStaleToken, token.ID, generation, token.Generation)
+	}
+	return idGenerator.misuseLocked(idGenerator.freeLocked(token.ID), token.ID)
+}
+
+// Generation returns the Token of id, e.g. for an ID allocated by Allocate,
+// or false if id is not allocated or generations are not counted
+func (idGenerator *IDGenerator) Generation(id int64) (Token, bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return Token{}, false
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.generations == nil || !idGenerator.store.Has(offset) || idGenerator.isHeld(offset) {
+		return Token{}, false
+	}
+	return Token{ID: id, Generation: idGenerator.generations[offset]}, true
+}
+
+// movedGenerationsLocked returns the generations of the IDs of idGenerator which are in the range of resized,
+// keyed by their offsets in resized, nil if generations are not counted. The caller must hold lock.
+func (idGenerator *IDGenerator) movedGenerationsLocked(resized *IDGenerator) map[uint64]uint64 {
+	if idGenerator.generations == nil {
+		return nil
+	}
+	generations := make(map[uint64]uint64, len(idGenerator.generations))
+	for offset, generation := range idGenerator.generations {
+		if id := idGenerator.toID(offset); resized.inRange(id) {
+			generations[resized.toOffset(id)] = generation
+		}
+	}
+	return generations
+}
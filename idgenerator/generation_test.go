@@ -0,0 +1,109 @@
+package idgenerator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestGenerations(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(7, 7, append([]Option{WithGenerations()}, storeOption.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, err := idGenerator.AllocateToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first != (Token{ID: 7, Generation: 1}) {
+				t.Errorf("unexpected first token: %+v", first)
+			}
+			if _, err = idGenerator.AllocateToken(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if err = idGenerator.FreeToken(first); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.FreeToken(first); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("expected ErrNotAllocated, got %+v", err)
+			}
+
+			// A frees 7, B allocates it, then a retry of A must not free it from under B
+			second, err := idGenerator.AllocateToken()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if second.Generation != 2 {
+				t.Errorf("expected generation 2, output %d", second.Generation)
+			}
+			if err = idGenerator.FreeToken(first); !errors.Is(err, ErrStaleToken) {
+				t.Errorf("expected ErrStaleToken, got %+v", err)
+			}
+			if !idGenerator.IsAllocated(7) {
+				t.Error("stale token freed the ID")
+			}
+
+			// the plain API shares the generations
+			if err = idGenerator.FreeID(7); err != nil {
+				t.Fatal(err)
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 7 {
+				t.Fatalf("expected id: 7, output id: %d, %+v", id, err)
+			}
+			if err = idGenerator.FreeToken(second); !errors.Is(err, ErrStaleToken) {
+				t.Errorf("expected ErrStaleToken, got %+v", err)
+			}
+			token, ok := idGenerator.Generation(7)
+			if !ok || token != (Token{ID: 7, Generation: 3}) {
+				t.Errorf("unexpected token of ID 7: %+v, %v", token, ok)
+			}
+			if err = idGenerator.FreeToken(token); err != nil {
+				t.Error(err)
+			}
+			if _, ok = idGenerator.Generation(7); ok {
+				t.Error("token of a free ID returned")
+			}
+			if err = idGenerator.FreeToken(Token{ID: 8, Generation: 1}); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("expected ErrOutOfRange, got %+v", err)
+			}
+		})
+	}
+}
+
+func TestGenerationsResizeAndRestore(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithGenerations())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := idGenerator.AllocateToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Resize(0, 20); err != nil {
+		t.Fatal(err)
+	}
+	if again, ok := idGenerator.Generation(token.ID); !ok || again != token {
+		t.Errorf("expected the token %+v after Resize, output %+v, %v", token, again, ok)
+	}
+
+	// a restored ID starts a new generation
+	data, err := idGenerator.MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeToken(token); !errors.Is(err, ErrStaleToken) {
+		t.Errorf("expected ErrStaleToken after restoring, got %+v", err)
+	}
+
+	plain := NewGenerator(1, 10)
+	if _, err = plain.AllocateToken(); err == nil {
+		t.Error("token allocated without WithGenerations")
+	}
+	if _, ok := plain.Generation(1); ok {
+		t.Error("token returned without WithGenerations")
+	}
+}
@@ -0,0 +1,170 @@
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+)
+
+// Integer is the constraint of the ID types of Generator, the same as golang.org/x/exp/constraints.Integer
+type Integer interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64 | ~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+// Generator is an IDGenerator handing out IDs of type T, e.g. Generator[uint32] for TEIDs,
+// so that callers do not have to convert and possibly truncate the int64 IDs of IDGenerator.
+// It allocates exactly like the IDGenerator it wraps, the hooks and the logger set by opts see the IDs as int64.
+type Generator[T Integer] struct {
+	generator *IDGenerator
+}
+
+// NewTypedGenerator initializes a Generator in range [minValue, maxValue] and applies opts to it.
+// It fails like NewGeneratorWithOptions, or with an error wrapping ErrInvalidRange
+// if a bound is not representable as an int64, i.e. for uint64 bounds above math.MaxInt64.
+// The range of WithRangeProvider must be of IDs of T as well, Allocate fails with an error wrapping
+// ErrPoolExhausted otherwise, as if the provider failed.
+func NewTypedGenerator[T Integer](minValue, maxValue T, opts ...Option) (*Generator[T], error) {
+	for _, bound := range []T{minValue, maxValue} {
+		if !fitsInt64(bound) {
+			return nil, fmt.Errorf("%w: bound %d is not representable as int64", ErrInvalidRange, uint64(bound))
+		}
+	}
+	generator, err := NewGeneratorWithOptions(int64(minValue), int64(maxValue), opts...)
+	if err != nil {
+		return nil, err
+	}
+	if provider := generator.rangeProvider; provider != nil {
+		generator.rangeProvider = func(current [2]int64) ([2]int64, error) {
+			provided, err := provider(current)
+			if err != nil {
+				return provided, err
+			}
+			for _, bound := range provided {
+				if !fitsType[T](bound) {
+					return provided, fmt.Errorf("bound %d is not representable as %T", bound, T(0))
+				}
+			}
+			return provided, nil
+		}
+	}
+	return &Generator[T]{generator: generator}, nil
+}
+
+// fitsType reports whether converting v to T keeps its value
+func fitsType[T Integer](v int64) bool {
+	converted := T(v)
+	return int64(converted) == v && (converted < 0) == (v < 0)
+}
+
+// fitsInt64 reports whether converting v to int64 keeps its value
+func fitsInt64[T Integer](v T) bool {
+	converted := int64(v)
+	return T(converted) == v && (converted < 0) == (v < 0)
+}
+
+// Allocate allocates an ID like IDGenerator.Allocate
+func (g *Generator[T]) Allocate() (T, error) {
+	id, err := g.generator.Allocate()
+	return T(id), err
+}
+
+// AllocateCtx allocates an ID like IDGenerator.AllocateCtx
+func (g *Generator[T]) AllocateCtx(ctx context.Context) (T, error) {
+	id, err := g.generator.AllocateCtx(ctx)
+	return T(id), err
+}
+
+// AllocateMany allocates n IDs like IDGenerator.AllocateMany
+func (g *Generator[T]) AllocateMany(n int) ([]T, error) {
+	ids, err := g.generator.AllocateMany(n)
+	if err != nil {
+		return nil, err
+	}
+	typed := make([]T, len(ids))
+	for i, id := range ids {
+		typed[i] = T(id)
+	}
+	return typed, nil
+}
+
+// AllocateSpecific allocates exactly id like IDGenerator.AllocateSpecific
+func (g *Generator[T]) AllocateSpecific(id T) error {
+	if !fitsInt64(id) {
+		return g.outOfRangeError(id)
+	}
+	return g.generator.AllocateSpecific(int64(id))
+}
+
+// AllocateWithOffset allocates the first free ID from offset on like IDGenerator.AllocateWithOffset
+func (g *Generator[T]) AllocateWithOffset(offset T) (T, error) {
+	if !fitsInt64(offset) {
+		return 0, g.outOfRangeError(offset)
+	}
+	id, err := g.generator.AllocateWithOffset(int64(offset))
+	return T(id), err
+}
+
+// AllocateAtOrAbove allocates the first free ID in [offset, maxValue] like IDGenerator.AllocateAtOrAbove
+func (g *Generator[T]) AllocateAtOrAbove(offset T) (T, error) {
+	if !fitsInt64(offset) {
+		return 0, g.outOfRangeError(offset)
+	}
+	id, err := g.generator.AllocateAtOrAbove(int64(offset))
+	return T(id), err
+}
+
+// ReserveRange allocates every ID in [start, end] like IDGenerator.ReserveRange
+func (g *Generator[T]) ReserveRange(start, end T) error {
+	for _, id := range []T{start, end} {
+		if !fitsInt64(id) {
+			return g.outOfRangeError(id)
+		}
+	}
+	return g.generator.ReserveRange(int64(start), int64(end))
+}
+
+// FreeID releases id like IDGenerator.FreeID
+func (g *Generator[T]) FreeID(id T) error {
+	if !fitsInt64(id) {
+		return g.outOfRangeError(id)
+	}
+	return g.generator.FreeID(int64(id))
+}
+
+// IsAllocated reports whether id is allocated
+func (g *Generator[T]) IsAllocated(id T) bool {
+	return fitsInt64(id) && g.generator.IsAllocated(int64(id))
+}
+
+// Used returns the number of allocated IDs
+func (g *Generator[T]) Used() int64 {
+	return g.generator.Used()
+}
+
+// Available returns the number of IDs that can still be allocated
+func (g *Generator[T]) Available() int64 {
+	return g.generator.Available()
+}
+
+// Reset frees all allocated IDs like IDGenerator.Reset
+func (g *Generator[T]) Reset() {
+	g.generator.Reset()
+}
+
+// Stats returns the counters of the generator
+func (g *Generator[T]) Stats() Stats {
+	return g.generator.Stats()
+}
+
+// DetailedStats returns the DetailedStats of the generator
+func (g *Generator[T]) DetailedStats() Stats {
+	return g.generator.DetailedStats()
+}
+
+func (g *Generator[T]) outOfRangeError(id T) error {
+	return fmt.Errorf("%w: ID[%d] not in [%d, %d]", ErrOutOfRange, id, T(g.generator.minValue), T(g.generator.maxValue))
+}
+
+// IDGenerator returns the generator g wraps, e.g. to take a Snapshot of it
+func (g *Generator[T]) IDGenerator() *IDGenerator {
+	return g.generator
+}
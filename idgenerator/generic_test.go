@@ -0,0 +1,156 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestGenericUint8FullRange(t *testing.T) {
+	idGenerator, err := NewTypedGenerator[uint8](0, math.MaxUint8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocated := make(map[uint8]bool)
+	for i := 0; i <= math.MaxUint8; i++ {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if allocated[id] {
+			t.Fatalf("ID[%d] allocated twice", id)
+		}
+		allocated[id] = true
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if used := idGenerator.Used(); used != 256 {
+		t.Errorf("expected used: 256, output used: %d", used)
+	}
+
+	// the highest ID frees and wraps around like any other
+	if err = idGenerator.FreeID(math.MaxUint8); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != math.MaxUint8 {
+		t.Errorf("expected id: %d, output: %d, %+v", math.MaxUint8, id, err)
+	}
+}
+
+func TestGenericUint32(t *testing.T) {
+	idGenerator, err := NewTypedGenerator[uint32](math.MaxUint32-9, math.MaxUint32)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := idGenerator.AllocateMany(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if expected := uint32(math.MaxUint32 - 9 + i); id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err = idGenerator.FreeID(7); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.FreeID(math.MaxUint32); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(math.MaxUint32) {
+		t.Error("freed ID is still allocated")
+	}
+	if err = idGenerator.AllocateSpecific(math.MaxUint32); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenericInt64(t *testing.T) {
+	idGenerator, err := NewTypedGenerator[int64](-5, 5, WithLowestFreeAllocation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for expected := int64(-5); expected <= 5; expected++ {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+	if stats := idGenerator.Stats(); stats.Used != 11 || stats.Strategy != StrategyLowestFree {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	idGenerator.Reset()
+	if available := idGenerator.Available(); available != 11 {
+		t.Errorf("expected available: 11, output available: %d", available)
+	}
+}
+
+func TestGenericRangeProvider(t *testing.T) {
+	// the extension past 255 would allocate IDs truncated to uint8
+	provider := func(current [2]int64) ([2]int64, error) {
+		return [2]int64{current[1] + 1, current[1] + 10}, nil
+	}
+	idGenerator, err := NewTypedGenerator[uint8](255, 255, WithRangeProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != 255 {
+		t.Fatalf("expected ID 255, output %d, %+v", id, err)
+	}
+	if id, err := idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, output %d, %+v", id, err)
+	}
+	if maxValue := idGenerator.IDGenerator().MaxValue(); maxValue != 255 {
+		t.Errorf("expected the range not extended, output max %d", maxValue)
+	}
+
+	// an extension of IDs of T is taken
+	idGenerator, err = NewTypedGenerator[uint8](1, 1, WithRangeProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator.IDGenerator(), 1)
+	if id, err := idGenerator.Allocate(); err != nil || id != 2 {
+		t.Errorf("expected ID 2 of the extension, output %d, %+v", id, err)
+	}
+}
+
+func TestGenericBounds(t *testing.T) {
+	if _, err := NewTypedGenerator[uint64](0, math.MaxInt64+1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for a bound above MaxInt64, got %+v", err)
+	}
+	if _, err := NewTypedGenerator[uint16](10, 1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for minValue > maxValue, got %+v", err)
+	}
+
+	idGenerator, err := NewTypedGenerator[uint64](math.MaxInt64-1, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeID(math.MaxUint64); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.AllocateSpecific(math.MaxUint64); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if idGenerator.IsAllocated(math.MaxUint64) {
+		t.Error("ID out of range reported allocated")
+	}
+
+	int8Generator, err := NewTypedGenerator[int8](math.MinInt8, math.MaxInt8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := int8Generator.Allocate(); err != nil || id != math.MinInt8 {
+		t.Errorf("expected id: %d, output: %d, %+v", math.MinInt8, id, err)
+	}
+}
@@ -0,0 +1,381 @@
+// grpcgenerator serves the generators of an idgenerator.GeneratorPool over gRPC, e.g. from a sidecar owning them,
+// and allocates from them remotely:
+//
+//	server := grpc.NewServer()
+//	idgeneratorpb.RegisterIDGeneratorServer(server, grpcgenerator.NewServer(pool))
+//	...
+//	teids := grpcgenerator.NewClient(conn, "teid")
+//	teid, err := teids.Allocate()
+//
+// A Client satisfies idgenerator.Allocator, so it can stand in for a local generator.
+// The errors of the package cross the wire as gRPC status codes with an ErrorInfo naming them,
+// so that those of a Client wrap the same errors as those of the local generator.
+package grpcgenerator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/free5gc/util/idgenerator"
+	"github.com/free5gc/util/idgenerator/grpcgenerator/idgeneratorpb"
+)
+
+// ErrPoolNotFound is returned for a pool the server has no generator of
+var ErrPoolNotFound = errors.New("pool not found")
+
+// errorDomain is the domain of the ErrorInfo of the errors of the package
+const errorDomain = "idgenerator"
+
+// wireErrors are the errors which keep their identity over the wire, by code and reason
+var wireErrors = []struct {
+	err    error
+	code   codes.Code
+	reason string
+}{
+	{ErrPoolNotFound, codes.NotFound, "POOL_NOT_FOUND"},
+	{idgenerator.ErrPoolExhausted, codes.ResourceExhausted, "POOL_EXHAUSTED"},
+	{idgenerator.ErrRateLimited, codes.ResourceExhausted, "RATE_LIMITED"},
+	{idgenerator.ErrQuotaExceeded, codes.ResourceExhausted, "QUOTA_EXCEEDED"},
+	{idgenerator.ErrClosed, codes.Unavailable, "CLOSED"},
+	{idgenerator.ErrOutOfRange, codes.OutOfRange, "OUT_OF_RANGE"},
+	{idgenerator.ErrAlreadyAllocated, codes.AlreadyExists, "ALREADY_ALLOCATED"},
+	{idgenerator.ErrNotAllocated, codes.FailedPrecondition, "NOT_ALLOCATED"},
+	{idgenerator.ErrReserved, codes.FailedPrecondition, "RESERVED"},
+	{idgenerator.ErrQuarantined, codes.FailedPrecondition, "QUARANTINED"},
+	{idgenerator.ErrPermanent, codes.FailedPrecondition, "PERMANENT"},
+	{idgenerator.ErrFiltered, codes.FailedPrecondition, "FILTERED"},
+}
+
+// toStatus returns the status error of err, codes.Internal if err is none of wireErrors
+func toStatus(err error) error {
+	for _, wireError := range wireErrors {
+		if errors.Is(err, wireError.err) {
+			st, detailsErr := status.New(wireError.code, err.Error()).WithDetails(&errdetails.ErrorInfo{
+				Reason: wireError.reason,
+				Domain: errorDomain,
+			})
+			if detailsErr != nil {
+				return status.Error(wireError.code, err.Error())
+			}
+			return st.Err()
+		}
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// fromStatus returns the error of a status error, wrapping the error of wireErrors named by its ErrorInfo
+func fromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.Domain != errorDomain {
+			continue
+		}
+		for _, wireError := range wireErrors {
+			if info.Reason == wireError.reason {
+				return fmt.Errorf("%w: %s", wireError.err, st.Message())
+			}
+		}
+	}
+	return err
+}
+
+// unixNano returns t in nanoseconds since the Unix epoch, 0 for the zero time
+func unixNano(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
+// fromUnixNano returns the UTC time of nanoseconds since the Unix epoch, the zero time for 0
+func fromUnixNano(nanoseconds int64) time.Time {
+	if nanoseconds == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, nanoseconds).UTC()
+}
+
+func ownersToProto(owners map[string]idgenerator.OwnerStats) map[string]*idgeneratorpb.OwnerStats {
+	if owners == nil {
+		return nil
+	}
+	pb := make(map[string]*idgeneratorpb.OwnerStats, len(owners))
+	for owner, stats := range owners {
+		pb[owner] = &idgeneratorpb.OwnerStats{Used: stats.Used, Quota: stats.Quota}
+	}
+	return pb
+}
+
+func ownersFromProto(pb map[string]*idgeneratorpb.OwnerStats) map[string]idgenerator.OwnerStats {
+	if len(pb) == 0 {
+		return nil
+	}
+	owners := make(map[string]idgenerator.OwnerStats, len(pb))
+	for owner, stats := range pb {
+		owners[owner] = idgenerator.OwnerStats{Used: stats.GetUsed(), Quota: stats.GetQuota()}
+	}
+	return owners
+}
+
+// Server serves the generators of a pool, each under its name
+type Server struct {
+	idgeneratorpb.UnimplementedIDGeneratorServer
+	pool *idgenerator.GeneratorPool
+}
+
+// NewServer returns a Server of the generators of pool, which may be added to or deleted from while it serves
+func NewServer(pool *idgenerator.GeneratorPool) *Server {
+	return &Server{pool: pool}
+}
+
+func (s *Server) generator(name string) (*idgenerator.IDGenerator, error) {
+	generator, ok := s.pool.Get(name)
+	if !ok {
+		return nil, toStatus(fmt.Errorf("%w: %q", ErrPoolNotFound, name))
+	}
+	return generator, nil
+}
+
+// Allocate allocates an ID from the generator of the pool of req
+func (s *Server) Allocate(_ context.Context, req *idgeneratorpb.AllocateRequest) (
+	*idgeneratorpb.AllocateResponse, error,
+) {
+	generator, err := s.generator(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	id, err := generator.Allocate()
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &idgeneratorpb.AllocateResponse{Id: id}, nil
+}
+
+// AllocateSpecific allocates the ID of req from the generator of its pool
+func (s *Server) AllocateSpecific(_ context.Context, req *idgeneratorpb.AllocateSpecificRequest) (
+	*idgeneratorpb.AllocateSpecificResponse, error,
+) {
+	generator, err := s.generator(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if err = generator.AllocateSpecific(req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &idgeneratorpb.AllocateSpecificResponse{}, nil
+}
+
+// Free frees the ID of req in the generator of its pool
+func (s *Server) Free(_ context.Context, req *idgeneratorpb.FreeRequest) (*idgeneratorpb.FreeResponse, error) {
+	generator, err := s.generator(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	if err = generator.FreeID(req.Id); err != nil {
+		return nil, toStatus(err)
+	}
+	return &idgeneratorpb.FreeResponse{}, nil
+}
+
+// IsAllocated reports whether the ID of req is allocated in the generator of its pool
+func (s *Server) IsAllocated(_ context.Context, req *idgeneratorpb.IsAllocatedRequest) (
+	*idgeneratorpb.IsAllocatedResponse, error,
+) {
+	generator, err := s.generator(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	return &idgeneratorpb.IsAllocatedResponse{Allocated: generator.IsAllocated(req.Id)}, nil
+}
+
+// Stats returns the Stats of the generator of the pool of req, or its DetailedStats if req is detailed
+func (s *Server) Stats(_ context.Context, req *idgeneratorpb.StatsRequest) (*idgeneratorpb.StatsResponse, error) {
+	generator, err := s.generator(req.Pool)
+	if err != nil {
+		return nil, err
+	}
+	var stats idgenerator.Stats
+	if req.Detailed {
+		stats = generator.DetailedStats()
+	} else {
+		stats = generator.Stats()
+	}
+	return &idgeneratorpb.StatsResponse{
+		MinValue:           stats.MinValue,
+		MaxValue:           stats.MaxValue,
+		Strategy:           stats.Strategy,
+		Used:               stats.Used,
+		References:         stats.References,
+		Free:               stats.Free,
+		Quarantined:        stats.Quarantined,
+		Reserved:           stats.Reserved,
+		Permanent:          stats.Permanent,
+		Capacity:           stats.Capacity,
+		Offset:             stats.Offset,
+		Allocations:        stats.Allocations,
+		Frees:              stats.Frees,
+		AllocationFailures: stats.AllocationFailures,
+		AllocationRetries:  stats.AllocationRetries,
+		PeakUsed:           stats.PeakUsed,
+		PeakAtUnixNano:     unixNano(stats.PeakAt),
+		LargestFreeBlock:   stats.LargestFreeBlock,
+		FreeFragments:      stats.FreeFragments,
+		MeanHoldTimeNanos:  int64(stats.MeanHoldTime),
+		MaxHoldTimeNanos:   int64(stats.MaxHoldTime),
+		Owners:             ownersToProto(stats.Owners),
+		PriorityUsed:       stats.PriorityUsed,
+		PriorityFree:       stats.PriorityFree,
+	}, nil
+}
+
+// Client allocates IDs from a pool of a Server.
+// A failed call fails the method with its error, the methods without a context wait as long as the connection does.
+type Client struct {
+	client idgeneratorpb.IDGeneratorClient
+	pool   string
+}
+
+// NewClient returns a Client of the pool named pool of the Server at the other end of conn
+func NewClient(conn grpc.ClientConnInterface, pool string) *Client {
+	return &Client{client: idgeneratorpb.NewIDGeneratorClient(conn), pool: pool}
+}
+
+// Allocate allocates an ID like AllocateCtx without a deadline
+func (c *Client) Allocate() (int64, error) {
+	return c.AllocateCtx(context.Background())
+}
+
+// AllocateCtx allocates an ID like IDGenerator.Allocate, it returns an error wrapping ErrPoolNotFound
+// if the server has no such pool. Unlike IDGenerator.AllocateCtx it does not wait for an ID to be freed,
+// ctx bounds the call.
+func (c *Client) AllocateCtx(ctx context.Context) (int64, error) {
+	resp, err := c.client.Allocate(ctx, &idgeneratorpb.AllocateRequest{Pool: c.pool})
+	if err != nil {
+		return 0, fromStatus(err)
+	}
+	return resp.Id, nil
+}
+
+// AllocateSpecific allocates id like AllocateSpecificCtx without a deadline
+func (c *Client) AllocateSpecific(id int64) error {
+	return c.AllocateSpecificCtx(context.Background(), id)
+}
+
+// AllocateSpecificCtx allocates exactly id like IDGenerator.AllocateSpecific
+func (c *Client) AllocateSpecificCtx(ctx context.Context, id int64) error {
+	_, err := c.client.AllocateSpecific(ctx, &idgeneratorpb.AllocateSpecificRequest{Pool: c.pool, Id: id})
+	if err != nil {
+		return fromStatus(err)
+	}
+	return nil
+}
+
+// FreeID frees id like FreeIDCtx without a deadline
+func (c *Client) FreeID(id int64) error {
+	return c.FreeIDCtx(context.Background(), id)
+}
+
+// FreeIDCtx frees id like IDGenerator.FreeID
+func (c *Client) FreeIDCtx(ctx context.Context, id int64) error {
+	_, err := c.client.Free(ctx, &idgeneratorpb.FreeRequest{Pool: c.pool, Id: id})
+	if err != nil {
+		return fromStatus(err)
+	}
+	return nil
+}
+
+// IsAllocated reports whether id is allocated like IsAllocatedCtx, or false if the call fails
+func (c *Client) IsAllocated(id int64) bool {
+	allocated, err := c.IsAllocatedCtx(context.Background(), id)
+	return err == nil && allocated
+}
+
+// IsAllocatedCtx reports whether id is allocated in the pool
+func (c *Client) IsAllocatedCtx(ctx context.Context, id int64) (bool, error) {
+	resp, err := c.client.IsAllocated(ctx, &idgeneratorpb.IsAllocatedRequest{Pool: c.pool, Id: id})
+	if err != nil {
+		return false, fromStatus(err)
+	}
+	return resp.Allocated, nil
+}
+
+// Used returns the number of allocated IDs of the pool, or 0 if the call fails
+func (c *Client) Used() int64 {
+	return c.Stats().Used
+}
+
+// Stats returns the counters of the pool like StatsCtx, or zero counters if the call fails
+func (c *Client) Stats() idgenerator.Stats {
+	stats, err := c.StatsCtx(context.Background())
+	if err != nil {
+		return idgenerator.Stats{}
+	}
+	return stats
+}
+
+// StatsCtx returns the counters of the pool, those of its Stats
+func (c *Client) StatsCtx(ctx context.Context) (idgenerator.Stats, error) {
+	return c.stats(ctx, false)
+}
+
+// DetailedStats returns the counters of the pool like DetailedStatsCtx, or zero counters if the call fails
+func (c *Client) DetailedStats() idgenerator.Stats {
+	stats, err := c.DetailedStatsCtx(context.Background())
+	if err != nil {
+		return idgenerator.Stats{}
+	}
+	return stats
+}
+
+// DetailedStatsCtx returns the counters of the pool with the fields walking its IDs, those of its DetailedStats.
+// The server walks the IDs of the pool under its lock, which makes it slower than StatsCtx in a large range.
+func (c *Client) DetailedStatsCtx(ctx context.Context) (idgenerator.Stats, error) {
+	return c.stats(ctx, true)
+}
+
+// stats is StatsCtx, or DetailedStatsCtx if detailed
+func (c *Client) stats(ctx context.Context, detailed bool) (idgenerator.Stats, error) {
+	resp, err := c.client.Stats(ctx, &idgeneratorpb.StatsRequest{Pool: c.pool, Detailed: detailed})
+	if err != nil {
+		return idgenerator.Stats{}, fromStatus(err)
+	}
+	return idgenerator.Stats{
+		MinValue:           resp.MinValue,
+		MaxValue:           resp.MaxValue,
+		Strategy:           resp.Strategy,
+		Used:               resp.Used,
+		References:         resp.References,
+		Free:               resp.Free,
+		Quarantined:        resp.Quarantined,
+		Reserved:           resp.Reserved,
+		Permanent:          resp.Permanent,
+		Capacity:           resp.Capacity,
+		Offset:             resp.Offset,
+		Allocations:        resp.Allocations,
+		Frees:              resp.Frees,
+		AllocationFailures: resp.AllocationFailures,
+		AllocationRetries:  resp.AllocationRetries,
+		PeakUsed:           resp.PeakUsed,
+		PeakAt:             fromUnixNano(resp.PeakAtUnixNano),
+		LargestFreeBlock:   resp.LargestFreeBlock,
+		FreeFragments:      resp.FreeFragments,
+		MeanHoldTime:       time.Duration(resp.MeanHoldTimeNanos),
+		MaxHoldTime:        time.Duration(resp.MaxHoldTimeNanos),
+		Owners:             ownersFromProto(resp.Owners),
+		PriorityUsed:       resp.PriorityUsed,
+		PriorityFree:       resp.PriorityFree,
+	}, nil
+}
+
+var _ idgenerator.Allocator = (*Client)(nil)
@@ -0,0 +1,182 @@
+package grpcgenerator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/free5gc/util/idgenerator"
+	"github.com/free5gc/util/idgenerator/grpcgenerator/idgeneratorpb"
+)
+
+// newTestConn serves pool over an in-memory listener and returns a connection to it
+func newTestConn(t *testing.T, pool *idgenerator.GeneratorPool) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer()
+	idgeneratorpb.RegisterIDGeneratorServer(server, NewServer(pool))
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			t.Error(err)
+		}
+	}()
+	conn, err := grpc.Dial("bufconn", grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+		server.Stop()
+	})
+	return conn
+}
+
+// fixedClock is a clock which stays at a time in UTC, as times cross the wire
+type fixedClock struct{}
+
+func (fixedClock) Now() time.Time {
+	return time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// After never fires, the time does not move
+func (fixedClock) After(time.Duration) <-chan time.Time {
+	return nil
+}
+
+func TestClient(t *testing.T) {
+	pool := idgenerator.NewGeneratorPool(idgenerator.WithClock(fixedClock{}))
+	for name, r := range map[string][2]int64{"teid": {1, 3}, "seid": {100, 199}} {
+		if _, err := pool.GetOrCreate(name, r[0], r[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	conn := newTestConn(t, pool)
+	var teids idgenerator.Allocator = NewClient(conn, "teid")
+	for expected := int64(1); expected <= 3; expected++ {
+		if id, err := teids.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if _, err := teids.Allocate(); !errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err := teids.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if err := teids.FreeID(2); !errors.Is(err, idgenerator.ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err := teids.AllocateSpecific(3); !errors.Is(err, idgenerator.ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	if err := teids.AllocateSpecific(4); !errors.Is(err, idgenerator.ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if teids.IsAllocated(2) || !teids.IsAllocated(3) {
+		t.Error("expected 3 allocated and 2 free")
+	}
+	if used := teids.Used(); used != 2 {
+		t.Errorf("expected 2 used, output %d", used)
+	}
+
+	// the pools are independent
+	seids := NewClient(conn, "seid")
+	if id, err := seids.Allocate(); err != nil || id != 100 {
+		t.Errorf("expected ID 100, output %d, %+v", id, err)
+	}
+	// the owners allocated for on the server side are counted with their quotas
+	generator, _ := pool.Get("seid")
+	generator.SetQuota("smf", 2)
+	if _, err := generator.AllocateFor("smf"); err != nil {
+		t.Fatal(err)
+	}
+	stats, err := seids.StatsCtx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := generator.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	if used := seids.Used(); used != 2 {
+		t.Errorf("expected 2 IDs used, output %d", used)
+	}
+	stats, err = seids.DetailedStatsCtx(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner := stats.Owners["smf"]; owner != (idgenerator.OwnerStats{Used: 1, Quota: 2}) {
+		t.Errorf("unexpected stats of the owner: %#v", owner)
+	}
+	if expected := generator.DetailedStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	conn := newTestConn(t, idgenerator.NewGeneratorPool())
+	client := NewClient(conn, "missing")
+	_, err := client.Allocate()
+	if !errors.Is(err, ErrPoolNotFound) {
+		t.Errorf("expected ErrPoolNotFound, got %+v", err)
+	}
+	if _, err = client.IsAllocatedCtx(context.Background(), 1); !errors.Is(err, ErrPoolNotFound) {
+		t.Errorf("expected ErrPoolNotFound, got %+v", err)
+	}
+	if stats := client.Stats(); !reflect.DeepEqual(stats, idgenerator.Stats{}) {
+		t.Errorf("expected zero stats, output %#v", stats)
+	}
+	if _, err = client.DetailedStatsCtx(context.Background()); !errors.Is(err, ErrPoolNotFound) {
+		t.Errorf("expected ErrPoolNotFound, got %+v", err)
+	}
+
+	// the raw status carries the code of the error
+	raw := idgeneratorpb.NewIDGeneratorClient(conn)
+	_, err = raw.Free(context.Background(), &idgeneratorpb.FreeRequest{Pool: "missing"})
+	if code := status.Code(err); code != codes.NotFound {
+		t.Errorf("expected NotFound, got %v", code)
+	}
+}
+
+func TestToStatus(t *testing.T) {
+	testCases := []struct {
+		err  error
+		code codes.Code
+	}{
+		{idgenerator.ErrPoolExhausted, codes.ResourceExhausted},
+		{idgenerator.ErrRateLimited, codes.ResourceExhausted},
+		{idgenerator.ErrQuotaExceeded, codes.ResourceExhausted},
+		{idgenerator.ErrClosed, codes.Unavailable},
+		{idgenerator.ErrOutOfRange, codes.OutOfRange},
+		{idgenerator.ErrAlreadyAllocated, codes.AlreadyExists},
+		{idgenerator.ErrNotAllocated, codes.FailedPrecondition},
+		{idgenerator.ErrReserved, codes.FailedPrecondition},
+		{idgenerator.ErrQuarantined, codes.FailedPrecondition},
+		{idgenerator.ErrPermanent, codes.FailedPrecondition},
+		{idgenerator.ErrFiltered, codes.FailedPrecondition},
+		{ErrPoolNotFound, codes.NotFound},
+		{errors.New("disk on fire"), codes.Internal},
+	}
+	for _, testCase := range testCases {
+		err := toStatus(testCase.err)
+		if code := status.Code(err); code != testCase.code {
+			t.Errorf("%v: expected %v, output %v", testCase.err, testCase.code, code)
+		}
+		if testCase.code != codes.Internal && !errors.Is(fromStatus(err), testCase.err) {
+			t.Errorf("%v: lost over the wire as %+v", testCase.err, fromStatus(err))
+		}
+	}
+}
@@ -0,0 +1,4 @@
+// idgeneratorpb is the protobuf definition of the service of the grpcgenerator package and its generated code
+package idgeneratorpb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative idgenerator.proto
@@ -0,0 +1,255 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+
+package idgeneratorpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// IDGeneratorClient is the client API for IDGenerator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type IDGeneratorClient interface {
+	// Allocate allocates a free ID
+	Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error)
+	// AllocateSpecific allocates exactly the ID of the request
+	AllocateSpecific(ctx context.Context, in *AllocateSpecificRequest, opts ...grpc.CallOption) (*AllocateSpecificResponse, error)
+	// Free frees the allocated ID of the request
+	Free(ctx context.Context, in *FreeRequest, opts ...grpc.CallOption) (*FreeResponse, error)
+	// IsAllocated reports whether the ID of the request is allocated
+	IsAllocated(ctx context.Context, in *IsAllocatedRequest, opts ...grpc.CallOption) (*IsAllocatedResponse, error)
+	// Stats returns the counters of a pool
+	Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error)
+}
+
+type iDGeneratorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewIDGeneratorClient(cc grpc.ClientConnInterface) IDGeneratorClient {
+	return &iDGeneratorClient{cc}
+}
+
+func (c *iDGeneratorClient) Allocate(ctx context.Context, in *AllocateRequest, opts ...grpc.CallOption) (*AllocateResponse, error) {
+	out := new(AllocateResponse)
+	err := c.cc.Invoke(ctx, "/free5gc.idgenerator.v1.IDGenerator/Allocate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) AllocateSpecific(ctx context.Context, in *AllocateSpecificRequest, opts ...grpc.CallOption) (*AllocateSpecificResponse, error) {
+	out := new(AllocateSpecificResponse)
+	err := c.cc.Invoke(ctx, "/free5gc.idgenerator.v1.IDGenerator/AllocateSpecific", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) Free(ctx context.Context, in *FreeRequest, opts ...grpc.CallOption) (*FreeResponse, error) {
+	out := new(FreeResponse)
+	err := c.cc.Invoke(ctx, "/free5gc.idgenerator.v1.IDGenerator/Free", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) IsAllocated(ctx context.Context, in *IsAllocatedRequest, opts ...grpc.CallOption) (*IsAllocatedResponse, error) {
+	out := new(IsAllocatedResponse)
+	err := c.cc.Invoke(ctx, "/free5gc.idgenerator.v1.IDGenerator/IsAllocated", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *iDGeneratorClient) Stats(ctx context.Context, in *StatsRequest, opts ...grpc.CallOption) (*StatsResponse, error) {
+	out := new(StatsResponse)
+	err := c.cc.Invoke(ctx, "/free5gc.idgenerator.v1.IDGenerator/Stats", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// IDGeneratorServer is the server API for IDGenerator service.
+// All implementations must embed UnimplementedIDGeneratorServer
+// for forward compatibility
+type IDGeneratorServer interface {
+	// Allocate allocates a free ID
+	Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error)
+	// AllocateSpecific allocates exactly the ID of the request
+	AllocateSpecific(context.Context, *AllocateSpecificRequest) (*AllocateSpecificResponse, error)
+	// Free frees the allocated ID of the request
+	Free(context.Context, *FreeRequest) (*FreeResponse, error)
+	// IsAllocated reports whether the ID of the request is allocated
+	IsAllocated(context.Context, *IsAllocatedRequest) (*IsAllocatedResponse, error)
+	// Stats returns the counters of a pool
+	Stats(context.Context, *StatsRequest) (*StatsResponse, error)
+	mustEmbedUnimplementedIDGeneratorServer()
+}
+
+// UnimplementedIDGeneratorServer must be embedded to have forward compatible implementations.
+type UnimplementedIDGeneratorServer struct {
+}
+
+func (UnimplementedIDGeneratorServer) Allocate(context.Context, *AllocateRequest) (*AllocateResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Allocate not implemented")
+}
+func (UnimplementedIDGeneratorServer) AllocateSpecific(context.Context, *AllocateSpecificRequest) (*AllocateSpecificResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AllocateSpecific not implemented")
+}
+func (UnimplementedIDGeneratorServer) Free(context.Context, *FreeRequest) (*FreeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Free not implemented")
+}
+func (UnimplementedIDGeneratorServer) IsAllocated(context.Context, *IsAllocatedRequest) (*IsAllocatedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method IsAllocated not implemented")
+}
+func (UnimplementedIDGeneratorServer) Stats(context.Context, *StatsRequest) (*StatsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Stats not implemented")
+}
+func (UnimplementedIDGeneratorServer) mustEmbedUnimplementedIDGeneratorServer() {}
+
+// UnsafeIDGeneratorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to IDGeneratorServer will
+// result in compilation errors.
+type UnsafeIDGeneratorServer interface {
+	mustEmbedUnimplementedIDGeneratorServer()
+}
+
+func RegisterIDGeneratorServer(s grpc.ServiceRegistrar, srv IDGeneratorServer) {
+	s.RegisterService(&IDGenerator_ServiceDesc, srv)
+}
+
+func _IDGenerator_Allocate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).Allocate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/free5gc.idgenerator.v1.IDGenerator/Allocate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).Allocate(ctx, req.(*AllocateRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_AllocateSpecific_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AllocateSpecificRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).AllocateSpecific(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/free5gc.idgenerator.v1.IDGenerator/AllocateSpecific",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).AllocateSpecific(ctx, req.(*AllocateSpecificRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_Free_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FreeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).Free(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/free5gc.idgenerator.v1.IDGenerator/Free",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).Free(ctx, req.(*FreeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_IsAllocated_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IsAllocatedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).IsAllocated(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/free5gc.idgenerator.v1.IDGenerator/IsAllocated",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).IsAllocated(ctx, req.(*IsAllocatedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _IDGenerator_Stats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StatsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(IDGeneratorServer).Stats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/free5gc.idgenerator.v1.IDGenerator/Stats",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(IDGeneratorServer).Stats(ctx, req.(*StatsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// IDGenerator_ServiceDesc is the grpc.ServiceDesc for IDGenerator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var IDGenerator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "free5gc.idgenerator.v1.IDGenerator",
+	HandlerType: (*IDGeneratorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Allocate",
+			Handler:    _IDGenerator_Allocate_Handler,
+		},
+		{
+			MethodName: "AllocateSpecific",
+			Handler:    _IDGenerator_AllocateSpecific_Handler,
+		},
+		{
+			MethodName: "Free",
+			Handler:    _IDGenerator_Free_Handler,
+		},
+		{
+			MethodName: "IsAllocated",
+			Handler:    _IDGenerator_IsAllocated_Handler,
+		},
+		{
+			MethodName: "Stats",
+			Handler:    _IDGenerator_Stats_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "idgenerator.proto",
+}
@@ -0,0 +1,123 @@
+package idgenerator
+
+import (
+	"fmt"
+	"sync"
+)
+
+// HierarchicalGenerator allocates parent IDs and, under each allocated parent, child IDs of a pool of its own,
+// e.g. session IDs with bearer IDs 1 to 15 in each session.
+// The pool of a parent is created by its first AllocateChild and dropped once it is empty or the parent is freed,
+// so that only the parents with children take memory for them. It is safe for concurrent use.
+type HierarchicalGenerator struct {
+	mtx      sync.Mutex
+	parents  *IDGenerator
+	childMin int64
+	childMax int64
+	children map[int64]*IDGenerator
+}
+
+// NewHierarchicalGenerator initializes a HierarchicalGenerator allocating parents in [parentMin, parentMax]
+// with opts applied to their generator, and children in [childMin, childMax] under each parent.
+// It fails like NewGeneratorWithOptions for either range.
+func NewHierarchicalGenerator(parentMin, parentMax, childMin, childMax int64,
+	opts ...Option,
+) (*HierarchicalGenerator, error) {
+	if _, err := NewGeneratorE(childMin, childMax); err != nil {
+		return nil, err
+	}
+	parents, err := NewGeneratorWithOptions(parentMin, parentMax, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &HierarchicalGenerator{
+		parents:  parents,
+		childMin: childMin,
+		childMax: childMax,
+		children: make(map[int64]*IDGenerator),
+	}, nil
+}
+
+// AllocateParent allocates a parent ID like IDGenerator.Allocate
+func (h *HierarchicalGenerator) AllocateParent() (int64, error) {
+	return h.parents.Allocate()
+}
+
+// AllocateChild allocates a child ID under parentID like IDGenerator.Allocate, creating the pool of the parent
+// if it has no child yet. It returns an error wrapping ErrNotAllocated if parentID is not an allocated parent,
+// or ErrPoolExhausted if every child ID of the parent is in use.
+func (h *HierarchicalGenerator) AllocateChild(parentID int64) (int64, error) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if !h.parents.IsAllocated(parentID) {
+		return 0, fmt.Errorf("%w: parent ID[%d]", ErrNotAllocated, parentID)
+	}
+	children, ok := h.children[parentID]
+	if !ok {
+		children = NewGenerator(h.childMin, h.childMax)
+		h.children[parentID] = children
+	}
+	childID, err := children.Allocate()
+	if err != nil {
+		return 0, fmt.Errorf("parent ID[%d]: %w", parentID, err)
+	}
+	return childID, nil
+}
+
+// FreeChild frees childID under parentID, dropping the pool of the parent with its last child.
+// It returns an error wrapping ErrNotAllocated if childID is not allocated under parentID.
+func (h *HierarchicalGenerator) FreeChild(parentID, childID int64) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	children, ok := h.children[parentID]
+	if !ok {
+		return fmt.Errorf("%w: child ID[%d] of parent ID[%d]", ErrNotAllocated, childID, parentID)
+	}
+	if err := children.FreeID(childID); err != nil {
+		return fmt.Errorf("parent ID[%d]: %w", parentID, err)
+	}
+	if children.Used() == 0 {
+		delete(h.children, parentID)
+	}
+	return nil
+}
+
+// FreeParent frees parentID with all its children at once: no AllocateChild sees the parent without its children.
+// It fails like IDGenerator.FreeID if parentID is not an allocated parent.
+func (h *HierarchicalGenerator) FreeParent(parentID int64) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if err := h.parents.FreeID(parentID); err != nil {
+		return err
+	}
+	delete(h.children, parentID)
+	return nil
+}
+
+// IsAllocated reports whether parentID is an allocated parent
+func (h *HierarchicalGenerator) IsAllocated(parentID int64) bool {
+	return h.parents.IsAllocated(parentID)
+}
+
+// IsChildAllocated reports whether childID is allocated under parentID
+func (h *HierarchicalGenerator) IsChildAllocated(parentID, childID int64) bool {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	children, ok := h.children[parentID]
+	return ok && children.IsAllocated(childID)
+}
+
+// Used returns the number of allocated parents
+func (h *HierarchicalGenerator) Used() int64 {
+	return h.parents.Used()
+}
+
+// ChildUsed returns the number of children allocated under parentID
+func (h *HierarchicalGenerator) ChildUsed(parentID int64) int64 {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if children, ok := h.children[parentID]; ok {
+		return children.Used()
+	}
+	return 0
+}
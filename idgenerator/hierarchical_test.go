@@ -0,0 +1,122 @@
+package idgenerator
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestHierarchicalGenerator(t *testing.T) {
+	h, err := NewHierarchicalGenerator(1, 100, 1, 15)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.AllocateChild(1); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated under a free parent, got %+v", err)
+	}
+	if len(h.children) != 0 {
+		t.Errorf("expected no child pool for a free parent, output %d", len(h.children))
+	}
+
+	session, err := h.AllocateParent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for expected := int64(1); expected <= 15; expected++ {
+		if bearer, err := h.AllocateChild(session); err != nil || bearer != expected {
+			t.Fatalf("expected child ID %d, output %d, %+v", expected, bearer, err)
+		}
+	}
+	if _, err = h.AllocateChild(session); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if used := h.ChildUsed(session); used != 15 {
+		t.Errorf("expected 15 children, output %d", used)
+	}
+
+	// the children of another parent are apart
+	other, err := h.AllocateParent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bearer, err := h.AllocateChild(other); err != nil || bearer != 1 {
+		t.Errorf("expected child ID 1, output %d, %+v", bearer, err)
+	}
+	if err = h.FreeChild(other, 2); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	// the pool of a parent goes with its last child
+	if err = h.FreeChild(other, 1); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := h.children[other]; ok {
+		t.Error("expected the empty child pool dropped")
+	}
+	if err = h.FreeChild(other, 1); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+
+	if err = h.FreeParent(session); err != nil {
+		t.Fatal(err)
+	}
+	if h.IsAllocated(session) || h.IsChildAllocated(session, 1) || h.ChildUsed(session) != 0 {
+		t.Error("expected the parent freed with its children")
+	}
+	if err = h.FreeParent(session); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	// the next owner of the ID starts with no child
+	if err = h.parents.AllocateSpecific(session); err != nil {
+		t.Fatal(err)
+	}
+	if bearer, err := h.AllocateChild(session); err != nil || bearer != 1 {
+		t.Errorf("expected child ID 1, output %d, %+v", bearer, err)
+	}
+	if used := h.Used(); used != 2 {
+		t.Errorf("expected 2 parents, output %d", used)
+	}
+
+	if _, err = NewHierarchicalGenerator(1, 100, 16, 1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for the child range, got %+v", err)
+	}
+}
+
+func TestHierarchicalGeneratorConcurrent(t *testing.T) {
+	h, err := NewHierarchicalGenerator(1, 10, 1, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parent, err := h.AllocateParent()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				child, err := h.AllocateChild(parent)
+				if errors.Is(err, ErrNotAllocated) {
+					// the parent has been freed
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				if err = h.FreeChild(parent, child); err != nil && !errors.Is(err, ErrNotAllocated) {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	if err = h.FreeParent(parent); err != nil {
+		t.Error(err)
+	}
+	wg.Wait()
+	if h.ChildUsed(parent) != 0 || len(h.children) != 0 {
+		t.Errorf("expected no child left, output %d", h.ChildUsed(parent))
+	}
+}
@@ -0,0 +1,36 @@
+package idgenerator
+
+import "time"
+
+// Utilization returns the ratio of the allocated IDs to the capacity of the generator, in [0, 1]
+func (idGenerator *IDGenerator) Utilization() float64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	capacity := idGenerator.capacityLocked()
+	if capacity == 0 {
+		// every ID is excluded
+		return 0
+	}
+	return float64(idGenerator.used) / float64(capacity)
+}
+
+// HighWaterMark returns the highest number of IDs allocated at once since the generator was created
+// or ResetHighWaterMark was last called, and the time of the clock of WithClock it was first reached at.
+// The time is zero if no ID has been allocated since.
+func (idGenerator *IDGenerator) HighWaterMark() (usedPeak int64, at time.Time) {
+	idGenerator.lock.RLock()
+	defer idGenerator.lock.RUnlock()
+	return clampInt64(idGenerator.peakUsed), idGenerator.peakAt
+}
+
+// ResetHighWaterMark restarts the high-water mark from the number of IDs allocated now,
+// e.g. once a known burst is over
+func (idGenerator *IDGenerator) ResetHighWaterMark() {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	idGenerator.peakUsed, idGenerator.peakAt = idGenerator.used, time.Time{}
+	if idGenerator.used > 0 {
+		idGenerator.peakAt = idGenerator.clock.Now()
+	}
+}
@@ -0,0 +1,74 @@
+package idgenerator
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHighWaterMark(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if peak, at := idGenerator.HighWaterMark(); peak != 0 || !at.IsZero() {
+		t.Errorf("expected no high-water mark, output %d at %v", peak, at)
+	}
+
+	ids, err := idGenerator.AllocateMany(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peakAt := clock.Now()
+	clock.Advance(time.Minute)
+	freeAll(t, idGenerator, ids[:3]...)
+	// reaching the mark again does not move it
+	allocateN(t, idGenerator, 3)
+	if peak, at := idGenerator.HighWaterMark(); peak != 4 || !at.Equal(peakAt) {
+		t.Errorf("expected high-water mark 4 at %v, output %d at %v", peakAt, peak, at)
+	}
+	if utilization := idGenerator.Utilization(); utilization != 0.4 {
+		t.Errorf("expected utilization 0.4, output %v", utilization)
+	}
+
+	clock.Advance(time.Minute)
+	allocateN(t, idGenerator, 1)
+	if peak, at := idGenerator.HighWaterMark(); peak != 5 || !at.Equal(clock.Now()) {
+		t.Errorf("expected high-water mark 5 at %v, output %d at %v", clock.Now(), peak, at)
+	}
+	stats := idGenerator.Stats()
+	if stats.PeakUsed != 5 || !stats.PeakAt.Equal(clock.Now()) {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+
+	// the mark survives Reset, it is re-baselined on demand only
+	idGenerator.Reset()
+	if peak, _ := idGenerator.HighWaterMark(); peak != 5 {
+		t.Errorf("expected high-water mark 5 after Reset, output %d", peak)
+	}
+	idGenerator.ResetHighWaterMark()
+	if peak, at := idGenerator.HighWaterMark(); peak != 0 || !at.IsZero() {
+		t.Errorf("expected no high-water mark after ResetHighWaterMark, output %d at %v", peak, at)
+	}
+	allocateN(t, idGenerator, 2)
+	clock.Advance(time.Minute)
+	idGenerator.ResetHighWaterMark()
+	if peak, at := idGenerator.HighWaterMark(); peak != 2 || !at.Equal(clock.Now()) {
+		t.Errorf("expected high-water mark 2 at %v, output %d at %v", clock.Now(), peak, at)
+	}
+}
+
+func TestUtilization(t *testing.T) {
+	idGenerator, err := NewGeneratorWithExclusions(1, 5, []int64{5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if utilization := idGenerator.Utilization(); utilization != 0 {
+		t.Errorf("expected utilization 0, output %v", utilization)
+	}
+	// the excluded IDs are not part of the capacity
+	allocateN(t, idGenerator, 4)
+	if utilization := idGenerator.Utilization(); utilization != 1 {
+		t.Errorf("expected utilization 1, output %v", utilization)
+	}
+}
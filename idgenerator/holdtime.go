@@ -0,0 +1,86 @@
+package idgenerator
+
+import "time"
+
+// WithHoldTimes records when each ID was allocated, by any method, for HoldTime and the hold times of Stats,
+// e.g. to find the IDs of leaked sessions. The times take memory for every allocated ID.
+// observe, unless nil, is called with every ID freed and how long it was held,
+// by FreeID as well as by lease expiry or Reset, like the hook of WithOnFree;
+// see the metrics package for a histogram of them.
+// The IDs loaded by RestoreGenerator, UnmarshalJSON or UnmarshalBinary are held from the time they are loaded.
+func WithHoldTimes(observe func(id int64, held time.Duration)) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.heldSince = make(map[uint64]time.Time)
+		idGenerator.observeHold = observe
+	}
+}
+
+// HoldTime returns how long id has been allocated, since its last allocation if it was freed and allocated again,
+// or false if id is not allocated or hold times are not recorded
+func (idGenerator *IDGenerator) HoldTime(id int64) (time.Duration, bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return 0, false
+	}
+	since, ok := idGenerator.heldSince[idGenerator.toOffset(id)]
+	if !ok {
+		return 0, false
+	}
+	return idGenerator.clock.Now().Sub(since), true
+}
+
+// holdTimesLocked returns the mean and the longest hold time of the allocated IDs, 0 if none or if hold times
+// are not recorded. The caller must hold lock.
+func (idGenerator *IDGenerator) holdTimesLocked() (mean, longest time.Duration) {
+	if len(idGenerator.heldSince) == 0 {
+		return 0, 0
+	}
+	now := idGenerator.clock.Now()
+	// a sum of nanoseconds overflows an int64 within a day for a million IDs
+	sum := 0.0
+	for _, since := range idGenerator.heldSince {
+		held := now.Sub(since)
+		sum += float64(held)
+		if held > longest {
+			longest = held
+		}
+	}
+	return time.Duration(sum / float64(len(idGenerator.heldSince))), longest
+}
+
+// holdLocked starts the hold time of offset, the caller must hold lock
+func (idGenerator *IDGenerator) holdLocked(offset uint64) {
+	if idGenerator.heldSince != nil {
+		idGenerator.heldSince[offset] = idGenerator.clock.Now()
+	}
+}
+
+// releaseLocked ends the hold time of offset and queues the call of the observer of WithHoldTimes,
+// the caller must hold lock
+func (idGenerator *IDGenerator) releaseLocked(offset uint64) {
+	since, ok := idGenerator.heldSince[offset]
+	if !ok {
+		return
+	}
+	delete(idGenerator.heldSince, offset)
+	if observe := idGenerator.observeHold; observe != nil {
+		held := idGenerator.clock.Now().Sub(since)
+		idGenerator.queueEvent(func(id int64) { observe(id, held) }, offset)
+	}
+}
+
+// movedHoldTimesLocked returns the hold times of the IDs of idGenerator which are in the range of resized,
+// keyed by their offsets in resized, nil if hold times are not recorded. The caller must hold lock.
+func (idGenerator *IDGenerator) movedHoldTimesLocked(resized *IDGenerator) map[uint64]time.Time {
+	if idGenerator.heldSince == nil {
+		return nil
+	}
+	heldSince := make(map[uint64]time.Time, len(idGenerator.heldSince))
+	for offset, since := range idGenerator.heldSince {
+		if id := idGenerator.toID(offset); resized.inRange(id) {
+			heldSince[resized.toOffset(id)] = since
+		}
+	}
+	return heldSince
+}
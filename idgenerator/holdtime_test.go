@@ -0,0 +1,150 @@
+package idgenerator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHoldTime(t *testing.T) {
+	clock := newFakeClock()
+	type observation struct {
+		id   int64
+		held time.Duration
+	}
+	var observed []observation
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock),
+		WithHoldTimes(func(id int64, held time.Duration) {
+			observed = append(observed, observation{id, held})
+		}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 1)
+	clock.Advance(time.Hour)
+	allocateN(t, idGenerator, 1)
+	clock.Advance(time.Hour)
+
+	if held, ok := idGenerator.HoldTime(1); !ok || held != 2*time.Hour {
+		t.Errorf("expected ID 1 held for 2h, output %v, %v", held, ok)
+	}
+	stats := idGenerator.DetailedStats()
+	if stats.MeanHoldTime != 90*time.Minute || stats.MaxHoldTime != 2*time.Hour {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	if _, ok := idGenerator.HoldTime(3); ok {
+		t.Error("expected no hold time of a free ID")
+	}
+	if _, ok := idGenerator.HoldTime(1000); ok {
+		t.Error("expected no hold time of an ID out of range")
+	}
+
+	// a freed and reallocated ID restarts its clock
+	freeAll(t, idGenerator, 1)
+	if err = idGenerator.AllocateSpecific(1); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if held, ok := idGenerator.HoldTime(1); !ok || held != time.Minute {
+		t.Errorf("expected ID 1 held for 1m, output %v, %v", held, ok)
+	}
+	idGenerator.Reset()
+	expected := []observation{{1, 2 * time.Hour}, {1, time.Minute}, {2, time.Hour + time.Minute}}
+	if !reflect.DeepEqual(observed, expected) {
+		t.Errorf("expected observations %v, output %v", expected, observed)
+	}
+	if stats := idGenerator.DetailedStats(); stats.MeanHoldTime != 0 || stats.MaxHoldTime != 0 {
+		t.Errorf("unexpected stats after Reset: %#v", stats)
+	}
+}
+
+func TestHoldTimeLease(t *testing.T) {
+	clock := newFakeClock()
+	var held time.Duration
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock),
+		WithHoldTimes(func(id int64, d time.Duration) { held = d }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if _, ok := idGenerator.HoldTime(id); ok {
+		t.Error("expected no hold time of an expired lease")
+	}
+	if held != time.Hour {
+		t.Errorf("expected the lease observed held until it was found expired after 1h, output %v", held)
+	}
+}
+
+func TestHoldTimeKept(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithHoldTimes(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	clock.Advance(time.Hour)
+
+	clone := idGenerator.Clone()
+	if err = idGenerator.Resize(1, 200); err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewGeneratorWithOptions(201, 300, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, other, 1)
+	if err = idGenerator.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	for _, testCase := range []struct {
+		generator *IDGenerator
+		id        int64
+		held      time.Duration
+	}{
+		{clone, 1, 2 * time.Hour},
+		{idGenerator, 2, 2 * time.Hour},
+		// the ID of a generator without hold times is held from the merge
+		{idGenerator, 201, time.Hour},
+	} {
+		if held, ok := testCase.generator.HoldTime(testCase.id); !ok || held != testCase.held {
+			t.Errorf("expected ID %d held for %v, output %v, %v", testCase.id, testCase.held, held, ok)
+		}
+	}
+
+	// the restored IDs are held from the restore
+	if err = idGenerator.UnmarshalJSON([]byte(`{"minValue":1,"maxValue":100,"offset":0,"used":[7]}`)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Minute)
+	if held, ok := idGenerator.HoldTime(7); !ok || held != time.Minute {
+		t.Errorf("expected ID 7 held for 1m, output %v, %v", held, ok)
+	}
+	if _, ok := idGenerator.HoldTime(1); ok {
+		t.Error("expected no hold time of an ID dropped by the restore")
+	}
+}
+
+func TestStatsAddHoldTimes(t *testing.T) {
+	stats := Stats{Used: 1, MeanHoldTime: time.Hour, MaxHoldTime: time.Hour}
+	stats.add(Stats{Used: 3, MeanHoldTime: 5 * time.Hour, MaxHoldTime: 10 * time.Hour})
+	stats.add(Stats{})
+	if stats.MeanHoldTime != 4*time.Hour || stats.MaxHoldTime != 10*time.Hour {
+		t.Errorf("expected the means weighted by Used, output %#v", stats)
+	}
+}
+
+func TestHoldTimeOff(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	allocateN(t, idGenerator, 1)
+	if _, ok := idGenerator.HoldTime(1); ok {
+		t.Error("expected no hold time without WithHoldTimes")
+	}
+	if stats := idGenerator.DetailedStats(); stats.MeanHoldTime != 0 || stats.MaxHoldTime != 0 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
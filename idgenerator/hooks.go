@@ -0,0 +1,71 @@
+package idgenerator
+
+// event is a hook call queued under lock, it is made by unlock once lock is released
+type event struct {
+	hook func(id int64)
+	id   int64
+}
+
+// WithOnAllocate calls hook with every ID allocated by the generator, whatever method allocated it,
+// including the IDs handed to waiting AllocateCtx calls. A failed allocation calls nothing.
+//
+// Hooks run after lock is released, so they may call back into the generator,
+// and always in the order the IDs were allocated and freed:
+// while one call is running hooks, the hooks queued by concurrent calls are run by it too.
+// IDs loaded by RestoreGenerator, UnmarshalJSON or UnmarshalBinary are not reported.
+func WithOnAllocate(hook func(id int64)) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.onAllocate = hook
+	}
+}
+
+// WithOnFree calls hook with every ID freed by the generator, by FreeID as well as by lease expiry or Reset,
+// which reports each ID that was allocated. It runs like the hook of WithOnAllocate.
+// IDs dropped by RestoreGenerator, UnmarshalJSON or UnmarshalBinary are not reported.
+func WithOnFree(hook func(id int64)) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.onFree = hook
+	}
+}
+
+// unlock releases lock and runs the hooks queued meanwhile, unless another call is already running them.
+// It checks the watermarks first, so that each call crossing one queues its callback,
+// and panics with the misuse of WithStrictMode last.
+func (idGenerator *IDGenerator) unlock() {
+	if len(idGenerator.watermarks) > 0 {
+		idGenerator.checkWatermarksLocked()
+	}
+	if misuse := idGenerator.takeMisuseLocked(); misuse != nil {
+		defer panic(misuse)
+	}
+	if len(idGenerator.events) == 0 || idGenerator.dispatching {
+		idGenerator.lock.Unlock()
+		return
+	}
+	idGenerator.dispatching = true
+	for len(idGenerator.events) > 0 {
+		events := idGenerator.events
+		idGenerator.events = nil
+		idGenerator.lock.Unlock()
+		idGenerator.dispatch(events)
+		idGenerator.lock.Lock()
+	}
+	idGenerator.dispatching = false
+	idGenerator.lock.Unlock()
+}
+
+func (idGenerator *IDGenerator) dispatch(events []event) {
+	done := false
+	defer func() {
+		if !done {
+			// a hook panicked, let the next unlock run the hooks queued meanwhile
+			idGenerator.lock.Lock()
+			idGenerator.dispatching = false
+			idGenerator.lock.Unlock()
+		}
+	}()
+	for _, e := range events {
+		e.hook(e.id)
+	}
+	done = true
+}
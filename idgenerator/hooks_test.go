@@ -0,0 +1,184 @@
+package idgenerator
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// hookRecorder records the calls of the hooks as "+id" for an allocation and "-id" for a free
+type hookRecorder struct {
+	mtx    sync.Mutex
+	events []string
+}
+
+func (r *hookRecorder) options() []Option {
+	return []Option{
+		WithOnAllocate(func(id int64) { r.record(fmt.Sprintf("+%d", id)) }),
+		WithOnFree(func(id int64) { r.record(fmt.Sprintf("-%d", id)) }),
+	}
+}
+
+func (r *hookRecorder) record(event string) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.events = append(r.events, event)
+}
+
+func (r *hookRecorder) take() []string {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	events := r.events
+	r.events = nil
+	return events
+}
+
+func TestHooks(t *testing.T) {
+	recorder := &hookRecorder{}
+	idGenerator, err := NewGeneratorWithOptions(1, 3, recorder.options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err = idGenerator.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = idGenerator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := recorder.take(), []string{"+1", "+2", "+3", "-2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks called %v, want %v", got, want)
+	}
+
+	// failed calls fire nothing
+	if _, err = idGenerator.AllocateMany(2); err == nil {
+		t.Error("AllocateMany(2) with 1 free ID should fail")
+	}
+	if err = idGenerator.AllocateSpecific(1); err == nil {
+		t.Error("AllocateSpecific of an allocated ID should fail")
+	}
+	if err = idGenerator.FreeID(2); err == nil {
+		t.Error("FreeID of a free ID should fail")
+	}
+	if err = idGenerator.FreeID(4); err == nil {
+		t.Error("FreeID out of range should fail")
+	}
+	if _, err = idGenerator.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.Allocate(); err == nil {
+		t.Error("Allocate from an exhausted pool should fail")
+	}
+	if got, want := recorder.take(), []string{"+2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks called %v, want %v", got, want)
+	}
+}
+
+func TestHooksResetAndRestore(t *testing.T) {
+	recorder := &hookRecorder{}
+	idGenerator, err := NewGeneratorWithOptions(10, 19, recorder.options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.ReserveRange(12, 13); err != nil {
+		t.Fatal(err)
+	}
+	snapshot := idGenerator.Snapshot()
+	recorder.take()
+
+	// Reset frees every allocated ID
+	idGenerator.Reset()
+	if got, want := recorder.take(), []string{"-12", "-13"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Reset called hooks %v, want %v", got, want)
+	}
+
+	// loading a state reports nothing
+	restored, err := RestoreGenerator(snapshot, recorder.options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := recorder.take(); len(got) != 0 {
+		t.Errorf("RestoreGenerator called hooks %v, want none", got)
+	}
+	if err = restored.FreeID(12); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := recorder.take(), []string{"-12"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("hooks called %v, want %v", got, want)
+	}
+}
+
+func TestHooksReentrant(t *testing.T) {
+	var idGenerator *IDGenerator
+	var freed []int64
+	var err error
+	idGenerator, err = NewGeneratorWithOptions(1, 10,
+		WithOnAllocate(func(id int64) {
+			// calling back into the generator must not deadlock
+			if !idGenerator.IsAllocated(id) {
+				t.Errorf("ID[%d] reported allocated is not allocated", id)
+			}
+			if id%2 == 0 {
+				if freeErr := idGenerator.FreeID(id); freeErr != nil {
+					t.Error(freeErr)
+				}
+			}
+		}),
+		WithOnFree(func(id int64) { freed = append(freed, id) }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = idGenerator.AllocateMany(4); err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{2, 4}; !reflect.DeepEqual(freed, want) {
+		t.Errorf("freed %v, want %v", freed, want)
+	}
+}
+
+func TestHooksOrder(t *testing.T) {
+	recorder := &hookRecorder{}
+	idGenerator, err := NewGeneratorWithOptions(1, 8, recorder.options()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 500; j++ {
+				id, allocErr := idGenerator.Allocate()
+				if allocErr != nil {
+					t.Error(allocErr)
+					return
+				}
+				if freeErr := idGenerator.FreeID(id); freeErr != nil {
+					t.Error(freeErr)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// in allocation order, each ID alternates between allocated and freed
+	allocated := make(map[string]bool)
+	events := recorder.take()
+	for _, event := range events {
+		id := event[1:]
+		if (event[0] == '+') == allocated[id] {
+			t.Fatalf("event %s out of order", event)
+		}
+		allocated[id] = event[0] == '+'
+	}
+	if len(events) != 2*8*500 {
+		t.Errorf("got %d events, want %d", len(events), 2*8*500)
+	}
+}
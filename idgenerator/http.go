@@ -0,0 +1,189 @@
+package idgenerator
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// HTTPHandlerOption configures the handler of NewHTTPHandler
+type HTTPHandlerOption func(*httpHandler)
+
+// WithBearerToken makes the handler reject with 401 Unauthorized the requests without the header
+// "Authorization: Bearer <token>". An empty token is ignored.
+func WithBearerToken(token string) HTTPHandlerOption {
+	return func(h *httpHandler) {
+		if token != "" {
+			h.token = token
+		}
+	}
+}
+
+// httpErrors are the errors named in the error bodies of the handler, with their status codes
+var httpErrors = []struct {
+	err    error
+	status int
+	name   string
+}{
+	{ErrPoolExhausted, http.StatusInsufficientStorage, "ErrPoolExhausted"},
+	{ErrRateLimited, http.StatusTooManyRequests, "ErrRateLimited"},
+	{ErrQuotaExceeded, http.StatusTooManyRequests, "ErrQuotaExceeded"},
+	{ErrClosed, http.StatusServiceUnavailable, "ErrClosed"},
+	{ErrOutOfRange, http.StatusBadRequest, "ErrOutOfRange"},
+	{ErrMalformedID, http.StatusBadRequest, "ErrMalformedID"},
+	{ErrAlreadyAllocated, http.StatusConflict, "ErrAlreadyAllocated"},
+	{ErrNotAllocated, http.StatusConflict, "ErrNotAllocated"},
+	{ErrReserved, http.StatusConflict, "ErrReserved"},
+	{ErrQuarantined, http.StatusConflict, "ErrQuarantined"},
+	{ErrPermanent, http.StatusConflict, "ErrPermanent"},
+	{ErrFiltered, http.StatusConflict, "ErrFiltered"},
+}
+
+type httpHandler struct {
+	generator *IDGenerator
+	token     string
+}
+
+// NewHTTPHandler returns an http.Handler serving generator as JSON, for operational tooling:
+//
+//	POST /allocate           allocates an ID, {"id":7}
+//	POST /allocate?id=7      allocates exactly 7, {"id":7}
+//	POST /free?id=7          frees 7, {"id":7}
+//	GET  /allocated          the allocated IDs in ascending order, {"ids":[1,2,7]}
+//	GET  /stats              the DetailedStats of the generator
+//
+// Errors are answered with a status code and a body such as {"error":"ID already allocated: ID[7]",
+// "name":"ErrAlreadyAllocated"}: 400 for a malformed or out of range ID, 409 for an ID in the wrong state
+// or vetoed by the allocation filter, 429 over the limit of WithRateLimit or a quota, 503 once the generator
+// is closed and 507 when the pool is exhausted. The paths are relative to the root,
+// a handler mounted under a prefix of a mux needs http.StripPrefix. It is safe for concurrent requests
+// like the generator.
+func NewHTTPHandler(generator *IDGenerator, opts ...HTTPHandlerOption) http.Handler {
+	h := &httpHandler{generator: generator}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.token != "" && !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSONError(w, http.StatusUnauthorized, "", "unauthorized")
+		return
+	}
+	method := http.MethodGet
+	switch r.URL.Path {
+	case "/allocate", "/free":
+		method = http.MethodPost
+	case "/allocated", "/stats":
+	default:
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeJSONError(w, http.StatusMethodNotAllowed, "", "method not allowed")
+		return
+	}
+	switch r.URL.Path {
+	case "/allocate":
+		h.allocate(w, r)
+	case "/free":
+		h.free(w, r)
+	case "/allocated":
+		writeJSON(w, http.StatusOK, struct {
+			IDs []int64 `json:"ids"`
+		}{h.generator.AllocatedIDs()})
+	case "/stats":
+		writeJSON(w, http.StatusOK, h.generator.DetailedStats())
+	}
+}
+
+// authorized reports whether r carries the bearer token of the handler
+func (h *httpHandler) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if len(header) < len(prefix) || !strings.EqualFold(header[:len(prefix)], prefix) {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(header[len(prefix):]), []byte(h.token)) == 1
+}
+
+type idResponse struct {
+	ID int64 `json:"id"`
+}
+
+func (h *httpHandler) allocate(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Has("id") {
+		id, err := queryID(r)
+		if err == nil {
+			err = h.generator.AllocateSpecific(id)
+		}
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, idResponse{id})
+		return
+	}
+	id, err := h.generator.Allocate()
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, idResponse{id})
+}
+
+func (h *httpHandler) free(w http.ResponseWriter, r *http.Request) {
+	id, err := queryID(r)
+	if err == nil {
+		err = h.generator.FreeID(id)
+	}
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, idResponse{id})
+}
+
+// queryID returns the id query parameter of r, or an error wrapping ErrMalformedID
+func queryID(r *http.Request) (int64, error) {
+	value := r.URL.Query().Get("id")
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: id=%q", ErrMalformedID, value)
+	}
+	return id, nil
+}
+
+// writeError answers err with the status code of httpErrors, 500 Internal Server Error for any other error
+func writeError(w http.ResponseWriter, err error) {
+	for _, httpError := range httpErrors {
+		if errors.Is(err, httpError.err) {
+			writeJSONError(w, httpError.status, httpError.name, err.Error())
+			return
+		}
+	}
+	writeJSONError(w, http.StatusInternalServerError, "", err.Error())
+}
+
+func writeJSONError(w http.ResponseWriter, status int, name, message string) {
+	writeJSON(w, status, struct {
+		Error string `json:"error"`
+		Name  string `json:"name,omitempty"`
+	}{message, name})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	// the status is sent, a failed write leaves nothing to answer
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		return
+	}
+}
@@ -0,0 +1,174 @@
+package idgenerator
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// serveJSON serves a request of method to target and decodes the JSON body of the response into body
+func serveJSON(t *testing.T, handler http.Handler, method, target, token string, body interface{}) int {
+	t.Helper()
+	req := httptest.NewRequest(method, target, nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if body != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), body); err != nil {
+			t.Fatalf("%s %s: %v, body %q", method, target, err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+type httpErrorBody struct {
+	Error string `json:"error"`
+	Name  string `json:"name"`
+}
+
+func TestHTTPHandler(t *testing.T) {
+	// the fake clock is in UTC, as the times decoded from JSON
+	generator, err := NewGeneratorWithOptions(1, 3, WithClock(newFakeClock()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHTTPHandler(generator)
+	for expected := int64(1); expected <= 3; expected++ {
+		var resp idResponse
+		if code := serveJSON(t, handler, http.MethodPost, "/allocate", "", &resp); code != http.StatusOK ||
+			resp.ID != expected {
+			t.Fatalf("expected ID %d, output %d, status %d", expected, resp.ID, code)
+		}
+	}
+
+	testCases := []struct {
+		method string
+		target string
+		status int
+		name   string
+	}{
+		{http.MethodPost, "/allocate", http.StatusInsufficientStorage, "ErrPoolExhausted"},
+		{http.MethodPost, "/allocate?id=2", http.StatusConflict, "ErrAlreadyAllocated"},
+		{http.MethodPost, "/allocate?id=4", http.StatusBadRequest, "ErrOutOfRange"},
+		{http.MethodPost, "/free?id=x", http.StatusBadRequest, "ErrMalformedID"},
+		{http.MethodPost, "/free", http.StatusBadRequest, "ErrMalformedID"},
+		{http.MethodPost, "/free?id=2", http.StatusOK, ""},
+		{http.MethodPost, "/free?id=2", http.StatusConflict, "ErrNotAllocated"},
+		{http.MethodGet, "/free?id=3", http.StatusMethodNotAllowed, ""},
+		{http.MethodPost, "/stats", http.StatusMethodNotAllowed, ""},
+	}
+	for _, testCase := range testCases {
+		var body httpErrorBody
+		code := serveJSON(t, handler, testCase.method, testCase.target, "", &body)
+		if code != testCase.status || body.Name != testCase.name {
+			t.Errorf("%s %s: expected %d %q, output %d %q", testCase.method, testCase.target,
+				testCase.status, testCase.name, code, body.Name)
+		}
+	}
+
+	var allocated struct {
+		IDs []int64 `json:"ids"`
+	}
+	serveJSON(t, handler, http.MethodGet, "/allocated", "", &allocated)
+	if expected := []int64{1, 3}; !reflect.DeepEqual(allocated.IDs, expected) {
+		t.Errorf("expected IDs %v, output %v", expected, allocated.IDs)
+	}
+	var stats Stats
+	serveJSON(t, handler, http.MethodGet, "/stats", "", &stats)
+	if expected := generator.DetailedStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	if code := serveJSON(t, handler, http.MethodGet, "/missing", "", nil); code != http.StatusNotFound {
+		t.Errorf("expected 404, output %d", code)
+	}
+}
+
+func TestHTTPHandlerBearerToken(t *testing.T) {
+	generator := NewGenerator(1, 3)
+	handler := NewHTTPHandler(generator, WithBearerToken("secret"))
+	for _, token := range []string{"", "wrong"} {
+		if code := serveJSON(t, handler, http.MethodPost, "/allocate", token, nil); code != http.StatusUnauthorized {
+			t.Errorf("token %q: expected 401, output %d", token, code)
+		}
+	}
+	if generator.Used() != 0 {
+		t.Errorf("expected no ID allocated without the token, used %d", generator.Used())
+	}
+	if code := serveJSON(t, handler, http.MethodPost, "/allocate", "secret", nil); code != http.StatusOK {
+		t.Errorf("expected 200, output %d", code)
+	}
+}
+
+func TestHTTPHandlerRateLimited(t *testing.T) {
+	generator, err := NewGeneratorWithOptions(1, 3, WithClock(newFakeClock()), WithRateLimit(1.0/3600, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHTTPHandler(generator)
+	if code := serveJSON(t, handler, http.MethodPost, "/allocate", "", nil); code != http.StatusOK {
+		t.Fatalf("expected 200, output %d", code)
+	}
+	var body httpErrorBody
+	code := serveJSON(t, handler, http.MethodPost, "/allocate", "", &body)
+	if code != http.StatusTooManyRequests || body.Name != "ErrRateLimited" {
+		t.Errorf("expected 429 %q, output %d %q", "ErrRateLimited", code, body.Name)
+	}
+}
+
+func TestHTTPHandlerClosed(t *testing.T) {
+	generator, err := NewGeneratorWithOptions(1, 3, WithAllocationFilter(func(id int64) bool {
+		return id != 2
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewHTTPHandler(generator)
+	var body httpErrorBody
+	code := serveJSON(t, handler, http.MethodPost, "/allocate?id=2", "", &body)
+	if code != http.StatusConflict || body.Name != "ErrFiltered" {
+		t.Errorf("expected 409 %q, output %d %q", "ErrFiltered", code, body.Name)
+	}
+	if _, err = generator.Close(); err != nil {
+		t.Fatal(err)
+	}
+	code = serveJSON(t, handler, http.MethodPost, "/allocate", "", &body)
+	if code != http.StatusServiceUnavailable || body.Name != "ErrClosed" {
+		t.Errorf("expected 503 %q, output %d %q", "ErrClosed", code, body.Name)
+	}
+}
+
+func TestHTTPHandlerConcurrent(t *testing.T) {
+	generator := NewGenerator(1, 100)
+	handler := NewHTTPHandler(generator)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 25; j++ {
+				var resp idResponse
+				if code := serveJSON(t, handler, http.MethodPost, "/allocate", "", &resp); code != http.StatusOK {
+					t.Errorf("expected 200, output %d", code)
+					return
+				}
+				mu.Lock()
+				if seen[resp.ID] {
+					t.Errorf("ID %d allocated twice", resp.ID)
+				}
+				seen[resp.ID] = true
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if len(seen) != 100 {
+		t.Errorf("expected 100 IDs allocated, output %d", len(seen))
+	}
+}
@@ -4,69 +4,1139 @@
 package idgenerator
 
 import (
+	"container/list"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"math"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type IDGenerator struct {
-	lock       sync.Mutex
-	minValue   int64
-	maxValue   int64
-	valueRange int64
-	offset     int64
-	usedMap    map[int64]bool
+	// allocateFailures and allocateRetries are updated atomically outside lock and must stay first
+	// to be 64-bit aligned
+	allocateFailures uint64
+	allocateRetries  uint64
+
+	// lock guards the state below, the methods which only read it take its read lock with rlock
+	lock   generatorLock
+	logger Logger
+	// eventLog logs the structured events of WithSlog, nil without it
+	eventLog eventLogger
+	// recorder records the calls of WithRecorder, nil without it
+	recorder *recorder
+	// name is the name of WithName, "" if unnamed
+	name     string
+	minValue int64
+	maxValue int64
+	// lastOffset is maxValue - minValue, unsigned so that it cannot overflow, or the offset of the last ID
+	// of the step of WithStep
+	lastOffset uint64
+	step       stepping
+	offset     uint64
+	// store is keyed by the offset of an ID (id - minValue), never by the ID itself
+	store    Store
+	newStore func(last uint64) (Store, error)
+	// used is the number of offsets set in store, excluded ones aside
+	used uint64
+	// excluded are the offsets never allocated, see NewGeneratorWithExclusions, they are set in store
+	excluded map[uint64]struct{}
+	// generations counts the allocations of each offset, nil unless WithGenerations is set
+	generations map[uint64]uint64
+	// refs counts the references Acquire added to each offset, beyond the one of the allocation,
+	// nil unless WithRefCounting is set. extraRefs is their sum.
+	refs      map[uint64]uint64
+	extraRefs uint64
+	// heldSince maps each allocated offset to the time it was allocated, nil unless WithHoldTimes is set,
+	// observeHold is the observer of WithHoldTimes
+	heldSince   map[uint64]time.Time
+	observeHold func(id int64, held time.Duration)
+	// strict holds the stacks of WithStrictMode, nil unless it is set
+	strict *strictState
+	// leaks queues the allocated IDs for WithLeakDetection, nil unless it is set
+	leaks *leakDetector
+	// recycling queues the freed offsets, nil unless WithFIFORecycling is set
+	recycling *recycler
+	// owners are the owners of AllocateFor by name, ownerOf the owner of each tagged offset
+	owners  map[string]*ownerSet
+	ownerOf map[uint64]*ownerSet
+	// quotas are the quotas of SetQuota by owner, defaultQuota that of WithDefaultQuota if hasDefaultQuota
+	quotas          map[string]int64
+	defaultQuota    int64
+	hasDefaultQuota bool
+	// quarantined are the freed offsets waiting for reuseDelay to pass, see WithReuseDelay, they are set in store.
+	// quarantine holds them in the order they are released.
+	reuseDelay  time.Duration
+	quarantined map[uint64]struct{}
+	quarantine  []leaseEntry
+	// allocations and frees count every markUsed and markFree since creation
+	allocations uint64
+	frees       uint64
+	// peakUsed is the highest used since creation or ResetHighWaterMark, reached at peakAt
+	peakUsed uint64
+	peakAt   time.Time
+	// cache holds the IDs of Preallocate, taken by FastAllocate without lock,
+	// cacheLock serializes Preallocate and Flush. cachedIDs are the entries of the IDs cached and not freed since,
+	// handed out or not, cachedLeft the number of those not handed out yet.
+	cache      atomic.Pointer[idCache]
+	cacheLock  sync.Mutex
+	cachedIDs  map[int64]*cachedID
+	cachedLeft atomic.Int64
+	// audit is the ring of events of WithAudit, nil if nothing is recorded
+	audit *auditLog
+	// limiter is the token bucket of WithRateLimit, nil without a limit
+	limiter *rateLimiter
+	// closed is set by Close, which seals the state
+	closed bool
+	// children are the generators of Split which have not been joined back yet
+	children map[*IDGenerator]struct{}
+
+	clock Clock
+	// leases maps the offsets allocated by AllocateLease to their expiry
+	leases map[uint64]time.Time
+	// reservations maps the leased offsets of Reserve to the serial of their ReservationToken
+	reservations      map[uint64]uint64
+	reservationSerial uint64
+	leaseHeap         leaseHeap
+	// expired holds the offsets freed by lease expiry and not reallocated since
+	expired map[uint64]struct{}
+
+	// waiters are the AllocateCtx and AllocateForCtx calls waiting for a free ID,
+	// idWaiters the WaitForID calls waiting for each ID, keyed by the ID so that Resize does not move them
+	waiters   *waitQueue
+	idWaiters map[int64]*list.List
+	// fairWaiting serves waiters round-robin per owner, see WithFairWaiting
+	fairWaiting bool
+
+	// strategy picks the offset allocateLocked takes, "" is StrategySequential
+	strategy string
+	// random is the source of StrategyRandom
+	random    io.Reader
+	randomBuf [8]byte
+	// peeked is the random offset drawn by Peek, taken by the next allocation if it is still free
+	peeked    uint64
+	hasPeeked bool
+
+	// formatter spells the IDs of AllocateString and FreeString
+	formatter Formatter
+	// uuids maps the IDs of AllocateUUID and FreeUUID to UUIDs
+	uuids *UUIDMapper
+
+	onAllocate func(id int64)
+	onFree     func(id int64)
+	// rangeProvider extends the range once Allocate finds it exhausted, see WithRangeProvider.
+	// extendLock serializes the calls extending it.
+	rangeProvider func(current [2]int64) ([2]int64, error)
+	extendLock    sync.Mutex
+	// watermarks are the utilization thresholds of WithHighWatermark and WithLowWatermark
+	watermarks []*watermark
+	// filter is the filter of WithAllocationFilter, vetoes holds the offsets it vetoed while an allocation runs
+	filter func(id int64) bool
+	vetoes []veto
+	// band is the priority band of WithPriorityBand, nil without it
+	band *priorityBand
+	// permanent are the IDs of MarkPermanent, set in store and counted as used,
+	// keyed by the ID so that Resize does not move them
+	permanent map[int64]struct{}
+	// sticky are the key bindings of AllocateSticky, nil until the first call without WithStickyBindings
+	sticky *stickyBindings
+	// events are the hook calls queued under lock, dispatching is set while unlock runs them
+	events      []event
+	dispatching bool
 }
 
 // Initialize an IDGenerator with minValue and maxValue.
+// It panics if the range is invalid, use NewGeneratorE to handle the error instead.
 func NewGenerator(minValue, maxValue int64) *IDGenerator {
-	idGenerator := &IDGenerator{}
-	idGenerator.init(minValue, maxValue)
+	idGenerator, err := NewGeneratorE(minValue, maxValue)
+	if err != nil {
+		panic(fmt.Sprintf("idgenerator: NewGenerator(%d, %d): %v", minValue, maxValue, err))
+	}
 	return idGenerator
 }
 
-func (idGenerator *IDGenerator) init(minValue, maxValue int64) {
+// Initialize an IDGenerator with minValue and maxValue.
+// It returns an error wrapping ErrInvalidRange if minValue > maxValue.
+// minValue == maxValue is valid and gives a generator with a single ID,
+// math.MinInt64 and math.MaxInt64 give one over all 2^64 int64 values.
+func NewGeneratorE(minValue, maxValue int64) (*IDGenerator, error) {
+	return NewGeneratorWithOptions(minValue, maxValue)
+}
+
+// Initialize an IDGenerator with minValue and maxValue, then apply opts to it.
+// The range is validated the same way as NewGeneratorE.
+// Without options the generator allocates sequentially, logs nothing and keeps its state in a tree of bitmaps,
+// the options are applied in order, so the last one wins where they conflict.
+func NewGeneratorWithOptions(minValue, maxValue int64, opts ...Option) (*IDGenerator, error) {
+	if minValue > maxValue {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidRange, minValue, maxValue)
+	}
+	idGenerator := &IDGenerator{
+		logger:   nopLogger{},
+		newStore: NewTreeStore,
+		clock:    realClock{},
+	}
+	for _, opt := range opts {
+		opt(idGenerator)
+	}
+	if err := idGenerator.formatter.validate(); err != nil {
+		return nil, err
+	}
+	if err := idGenerator.step.validate(); err != nil {
+		return nil, err
+	}
+	if err := idGenerator.init(minValue, maxValue); err != nil {
+		return nil, err
+	}
+	if err := idGenerator.validateBand(); err != nil {
+		return nil, err
+	}
+	if err := idGenerator.validatePermanent(); err != nil {
+		return nil, err
+	}
+	return idGenerator, nil
+}
+
+func (idGenerator *IDGenerator) init(minValue, maxValue int64) error {
+	lastOffset, ok := idGenerator.step.lastOffset(minValue, maxValue)
+	if !ok {
+		return fmt.Errorf("%w: [%d, %d] has no ID of remainder %d", ErrInvalidRange, minValue, maxValue,
+			idGenerator.step.remainder)
+	}
+	store, err := idGenerator.newStore(lastOffset)
+	if err != nil {
+		return fmt.Errorf("%w: [%d, %d]: %v", ErrInvalidRange, minValue, maxValue, err)
+	}
 	idGenerator.minValue = minValue
 	idGenerator.maxValue = maxValue
-	idGenerator.valueRange = maxValue - minValue + 1
+	idGenerator.lastOffset = lastOffset
 	idGenerator.offset = 0
-	idGenerator.usedMap = make(map[int64]bool)
+	idGenerator.store = store
+	idGenerator.used = 0
+	idGenerator.resetRecyclingLocked()
+	return nil
 }
 
-// Allocate and return an id in range [minValue, maxValue]
+// Allocate and return an id in range [minValue, maxValue], extended by the provider of WithRangeProvider if set,
+// or an error wrapping ErrPoolExhausted if every ID is in use, or ErrRateLimited over the limit of WithRateLimit
 func (idGenerator *IDGenerator) Allocate() (int64, error) {
+	id, err := idGenerator.allocate()
+	if idGenerator.recorder != nil {
+		idGenerator.record(opAllocate, nil, []int64{id}, err)
+	}
+	return id, err
+}
+
+// allocate is Allocate without recording it
+func (idGenerator *IDGenerator) allocate() (int64, error) {
+	if err := idGenerator.limitRate(1); err != nil {
+		return 0, err
+	}
+	id, err := idGenerator.tryAllocate()
+	if err != nil && idGenerator.rangeProvider != nil && errors.Is(err, ErrPoolExhausted) {
+		id, err = idGenerator.allocateExtending()
+	}
+	if err != nil {
+		idGenerator.refundRate(1)
+		idGenerator.allocateFailed(err)
+	}
+	return id, err
+}
+
+// tryAllocate is Allocate without counting and logging a failure
+func (idGenerator *IDGenerator) tryAllocate() (int64, error) {
 	idGenerator.lock.Lock()
-	defer idGenerator.lock.Unlock()
+	idGenerator.expireLeasesLocked()
+	id, err := idGenerator.allocateLocked()
+	idGenerator.unlock()
+	return id, err
+}
 
-	offsetBegin := idGenerator.offset
-	for {
-		if _, ok := idGenerator.usedMap[idGenerator.offset]; ok {
-			idGenerator.updateOffset()
+// Peek returns the ID the next Allocate would return, without allocating it,
+// or an error wrapping ErrPoolExhausted if no ID is free. It follows the allocation strategy of the generator,
+// with StrategyRandom it draws the ID the next allocation takes as long as it is still free.
+// The ID is only a hint: another goroutine may allocate it, or free a lower one, before the next Allocate.
+func (idGenerator *IDGenerator) Peek() (int64, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if idGenerator.availableLocked() == 0 {
+		return 0, idGenerator.exhaustedError(0, false)
+	}
+	offset, err := idGenerator.pickAllowedLocked()
+	if err != nil {
+		return 0, err
+	}
+	if idGenerator.strategy == StrategyRandom {
+		idGenerator.peeked, idGenerator.hasPeeked = offset, true
+	}
+	return idGenerator.toID(offset), nil
+}
+
+// AllocateMany allocates n IDs in range [minValue, maxValue] at once.
+// The IDs are not necessarily contiguous. If fewer than n IDs are available,
+// nothing is allocated and an error wrapping ErrPoolExhausted is returned.
+func (idGenerator *IDGenerator) AllocateMany(n int) ([]int64, error) {
+	ids, err := idGenerator.allocateMany(n)
+	if idGenerator.recorder != nil {
+		idGenerator.record(opMany, []int64{int64(n)}, ids, err)
+	}
+	return ids, err
+}
+
+// allocateMany is AllocateMany without recording it
+func (idGenerator *IDGenerator) allocateMany(n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("AllocateMany: invalid count %d", n)
+	}
+	return idGenerator.allocateManyCtx(context.Background(), "AllocateMany", n, nil)
+}
+
+// AllocateContiguous allocates the lowest block of n consecutive free IDs and returns the first one.
+// It returns an error wrapping ErrPoolExhausted if fewer than n IDs are free,
+// or ErrNoContiguousBlock if enough IDs are free but no n of them are consecutive.
+// Nothing is allocated on error.
+func (idGenerator *IDGenerator) AllocateContiguous(n int64) (int64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("AllocateContiguous: invalid count %d", n)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	first, err := idGenerator.allocateContiguousLocked(n)
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+	}
+	if idGenerator.recorder != nil {
+		idGenerator.record(opContiguous, []int64{n}, []int64{first}, err)
+	}
+	return first, err
+}
+
+func (idGenerator *IDGenerator) allocateContiguousLocked(n int64) (int64, error) {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	if uint64(n) > idGenerator.availableLocked() {
+		return 0, idGenerator.exhaustedError(uint64(n), true)
+	}
+	first, ok := idGenerator.findFreeRunLocked(uint64(n))
+	if !ok {
+		return 0, fmt.Errorf("%w: requested %d consecutive IDs", ErrNoContiguousBlock, n)
+	}
+	idGenerator.markRunUsedLocked(first, uint64(n))
+	return idGenerator.toID(first), nil
+}
+
+// findFreeRunLocked returns the lowest offset starting n > 0 consecutive free offsets, the caller must hold lock
+func (idGenerator *IDGenerator) findFreeRunLocked(n uint64) (uint64, bool) {
+	first, ok, _, _ := idGenerator.scanFreeRunsLocked(n, 0, -1)
+	return first, ok
+}
 
-			if idGenerator.offset == offsetBegin {
-				return 0, errors.New("No available value range to allocate id")
+// scanFreeRunsLocked is findFreeRunLocked from offset from, looking at no more than runs free runs if runs >= 0.
+// If it runs out of runs first, more is true and next is the offset to resume from. The caller must hold lock.
+func (idGenerator *IDGenerator) scanFreeRunsLocked(n, from uint64, runs int) (first uint64, ok bool, next uint64,
+	more bool,
+) {
+	start, found := idGenerator.store.NextClear(from)
+	for found && n-1 <= idGenerator.lastOffset-start {
+		if runs == 0 {
+			return 0, false, start, true
+		}
+		runs--
+		set, bounded := idGenerator.store.NextSet(start)
+		if !bounded || set-start >= n {
+			return start, true, 0, false
+		}
+		start, found = idGenerator.store.NextClear(set)
+	}
+	return 0, false, 0, false
+}
+
+// AllocateAligned allocates the lowest block of n consecutive free IDs whose first ID is a multiple of align
+// from minValue, i.e. (first-minValue) % align == 0, and returns the first one.
+// It fails like AllocateContiguous, or with an error wrapping ErrNoAlignedBlock if there are blocks
+// of n consecutive free IDs but none of them aligned. Nothing is allocated on error.
+func (idGenerator *IDGenerator) AllocateAligned(n, align int64) (int64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("AllocateAligned: invalid count %d", n)
+	}
+	if align <= 0 {
+		return 0, fmt.Errorf("AllocateAligned: invalid alignment %d", align)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	first, err := idGenerator.allocateAlignedLocked(uint64(n), uint64(align))
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+	}
+	return first, err
+}
+
+func (idGenerator *IDGenerator) allocateAlignedLocked(n, align uint64) (int64, error) {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	if n > idGenerator.availableLocked() {
+		return 0, idGenerator.exhaustedError(n, true)
+	}
+	first, ok, contiguous := idGenerator.findAlignedRunLocked(n, align)
+	if !ok {
+		return 0, noAlignedRunError(n, align, contiguous)
+	}
+	idGenerator.markRunUsedLocked(first, n)
+	return idGenerator.toID(first), nil
+}
+
+// findAlignedRunLocked returns the lowest offset which is a multiple of align starting n > 0 consecutive free
+// offsets. If there is none, contiguous reports whether there are n consecutive free offsets anywhere.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) findAlignedRunLocked(n, align uint64) (first uint64, ok, contiguous bool) {
+	first, ok, contiguous, _, _ = idGenerator.scanAlignedRunsLocked(n, align, 0, -1)
+	return first, ok, contiguous
+}
+
+// scanAlignedRunsLocked is findAlignedRunLocked from offset from, looking at no more than runs free runs
+// if runs >= 0. If it runs out of runs first, more is true and next is the offset to resume from,
+// contiguous then only covers the runs looked at. The caller must hold lock.
+func (idGenerator *IDGenerator) scanAlignedRunsLocked(n, align, from uint64, runs int) (first uint64, ok,
+	contiguous bool, next uint64, more bool,
+) {
+	start, found := idGenerator.store.NextClear(from)
+	for found {
+		if runs == 0 {
+			return 0, false, contiguous, start, true
+		}
+		runs--
+		// the free run is [start, end), or [start, lastOffset] if unbounded
+		end, bounded := idGenerator.store.NextSet(start)
+		fits := func(from uint64) bool {
+			if bounded {
+				return end-from >= n
 			}
-		} else {
+			return n-1 <= idGenerator.lastOffset-from
+		}
+		if fits(start) {
+			contiguous = true
+			aligned := start + (align-start%align)%align
+			if aligned >= start && aligned <= idGenerator.lastOffset && (!bounded || aligned < end) && fits(aligned) {
+				return aligned, true, true, 0, false
+			}
+		}
+		if !bounded {
 			break
 		}
+		start, found = idGenerator.store.NextClear(end)
+	}
+	return 0, false, contiguous, 0, false
+}
+
+// allocateLocked allocates a free ID picked by the strategy of the generator, the caller must hold lock
+func (idGenerator *IDGenerator) allocateLocked() (int64, error) {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	if idGenerator.availableLocked() == 0 {
+		return 0, idGenerator.exhaustedError(0, false)
+	}
+	offset, err := idGenerator.pickAllowedLocked()
+	if err != nil {
+		return 0, err
+	}
+	idGenerator.markUsedAdvancing(offset)
+	idGenerator.hasPeeked = false
+	return idGenerator.toID(offset), nil
+}
+
+// markUsedAdvancing marks offset used and moves the sequential search of Allocate just past it,
+// so that Allocate and AllocateWithOffset share one cursor. The caller must hold lock.
+func (idGenerator *IDGenerator) markUsedAdvancing(offset uint64) {
+	// a recycled offset does not move the sequential search for a never used one
+	recycled := idGenerator.recycling != nil && idGenerator.recycling.isQueued(offset)
+	idGenerator.markUsed(offset)
+	if idGenerator.strategyName() == StrategySequential && !recycled {
+		idGenerator.offset = offset
+		idGenerator.updateOffset()
+	}
+}
+
+// pickLocked returns the free offset the strategy of the generator allocates next,
+// or the freed one next in line with WithFIFORecycling. The caller must hold lock and ensure one is free.
+func (idGenerator *IDGenerator) pickLocked() (uint64, error) {
+	store, occupied := idGenerator.store, idGenerator.occupiedLocked()
+	if idGenerator.recycling != nil {
+		if offset, ok := idGenerator.recycling.next(idGenerator.lastOffset); ok {
+			return offset, nil
+		}
+		// the strategy picks among the never used offsets
+		store, occupied = idGenerator.recycling.touched, idGenerator.recycling.occupied
+	}
+	switch idGenerator.strategy {
+	case StrategyRandom:
+		// the offset drawn by Peek may have been allocated since, or dropped by Resize
+		if idGenerator.hasPeeked && idGenerator.peeked <= idGenerator.lastOffset && !store.Has(idGenerator.peeked) {
+			return idGenerator.peeked, nil
+		}
+		return idGenerator.randomOffsetLocked(store, occupied)
+	case StrategyLowestFree:
+		offset, _ := store.NextClear(0)
+		return offset, nil
+	}
+	// StrategySequential continues after the last allocated ID
+	offset, ok := store.NextClear(idGenerator.offset)
+	if !ok {
+		// wrap around, there must be a free offset below idGenerator.offset
+		offset, _ = store.NextClear(0)
+	}
+	return offset, nil
+}
+
+// allocateFailed counts and logs an Allocate of any kind which found no free ID
+func (idGenerator *IDGenerator) allocateFailed(err error) {
+	if errors.Is(err, ErrClosed) {
+		// a closed generator is not short of IDs
+		return
+	}
+	atomic.AddUint64(&idGenerator.allocateFailures, 1)
+	idGenerator.logger.Printf("idgenerator[%d-%d]: allocate failed: %v",
+		idGenerator.minValue, idGenerator.maxValue, err)
+	if idGenerator.logEnabled() {
+		idGenerator.logUnlocked(logAllocateFailed, 0, false, err)
+	}
+}
+
+// AllocateSpecific allocates exactly id.
+// It returns an error wrapping ErrOutOfRange if id is outside [minValue, maxValue],
+// ErrAlreadyAllocated if id is in use, ErrReserved if id is excluded or ErrQuarantined if it is quarantined.
+// IDs allocated by AllocateSpecific are freed with FreeID like any other ID.
+func (idGenerator *IDGenerator) AllocateSpecific(id int64) error {
+	err := idGenerator.allocateSpecific(id)
+	if idGenerator.recorder != nil {
+		idGenerator.record(opSpecific, []int64{id}, nil, err)
 	}
-	idGenerator.usedMap[idGenerator.offset] = true
-	id := idGenerator.offset + idGenerator.minValue
-	idGenerator.updateOffset()
-	return id, nil
+	return err
 }
 
+// allocateSpecific is AllocateSpecific without recording it
+func (idGenerator *IDGenerator) allocateSpecific(id int64) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) {
+		return idGenerator.outOfRangeError(id)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.isExcluded(offset) {
+		return idGenerator.reservedError(id)
+	}
+	if idGenerator.isQuarantined(offset) {
+		return idGenerator.quarantinedError(id)
+	}
+	if idGenerator.store.Has(offset) {
+		return fmt.Errorf("%w: ID[%d]", ErrAlreadyAllocated, id)
+	}
+	if idGenerator.filter != nil && !idGenerator.filter(id) {
+		return fmt.Errorf("%w: ID[%d]", ErrFiltered, id)
+	}
+	idGenerator.markUsed(offset)
+	return nil
+}
+
+// AllocateWithPreference allocates preferred if it is in range and free, e.g. to keep the ID of a UE on handover,
+// or else a free ID like Allocate. The bool reports whether preferred was allocated.
+// It returns an error wrapping ErrPoolExhausted only if every ID is in use.
+func (idGenerator *IDGenerator) AllocateWithPreference(preferred int64) (int64, bool, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	// excluded and quarantined offsets are set in store as well, allocateLocked fails after Close
+	if offset := idGenerator.toOffset(preferred); idGenerator.inRange(preferred) && !idGenerator.store.Has(offset) &&
+		!idGenerator.closed && (idGenerator.filter == nil || idGenerator.filter(preferred)) {
+		idGenerator.markUsed(offset)
+		idGenerator.unlock()
+		return preferred, true, nil
+	}
+	id, err := idGenerator.allocateLocked()
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+		return 0, false, err
+	}
+	return id, false, nil
+}
+
+// AllocateWithOffset allocates the first free ID from offset on, wrapping around to minValue past maxValue
+// like the sequential Allocate, e.g. to spread the IDs of a node over the range from a seed.
+// Despite its name, offset is an ID of the range rather than a distance from minValue:
+// AllocateWithOffset(minValue) starts the scan at the first ID.
+// The sequential Allocate continues after the ID it allocates, as after one of its own,
+// unless the ID is a freed one of WithFIFORecycling.
+// It returns an error wrapping ErrOutOfRange if offset is outside [minValue, maxValue], nothing is allocated then,
+// or ErrPoolExhausted only if every ID is in use.
+func (idGenerator *IDGenerator) AllocateWithOffset(offset int64) (int64, error) {
+	return idGenerator.allocateFrom(offset, true)
+}
+
+// AllocateAtOrAbove allocates the first free ID in [offset, maxValue] like AllocateWithOffset without wrapping
+// around, offset is an ID as well. It returns an error wrapping ErrPoolExhausted if every ID from offset on is in use.
+func (idGenerator *IDGenerator) AllocateAtOrAbove(offset int64) (int64, error) {
+	return idGenerator.allocateFrom(offset, false)
+}
+
+func (idGenerator *IDGenerator) allocateFrom(id int64, wrap bool) (int64, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	if !idGenerator.inRange(id) {
+		err := idGenerator.outOfRangeError(id)
+		idGenerator.unlock()
+		return 0, err
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		idGenerator.unlock()
+		return 0, err
+	}
+	var err error
+	// excluded and quarantined offsets are set in store as well
+	offset, ok := idGenerator.store.NextClear(idGenerator.toOffset(id))
+	if !ok && wrap {
+		offset, ok = idGenerator.store.NextClear(0)
+	}
+	switch {
+	case ok:
+		idGenerator.markUsedAdvancing(offset)
+	case wrap:
+		err = idGenerator.exhaustedError(0, false)
+	default:
+		err = fmt.Errorf("no free ID in [%d, %d]: %w", id, idGenerator.maxValue, idGenerator.exhaustedError(0, false))
+	}
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+		return 0, err
+	}
+	return idGenerator.toID(offset), nil
+}
+
+// ReserveRange allocates every ID in [start, end], e.g. to keep statically configured IDs
+// from being handed out by Allocate. Either the whole range is allocated or nothing is:
+// it fails if start > end, if the range is not within [minValue, maxValue],
+// with ErrAlreadyAllocated if any ID in it is in use, with ErrReserved if any is excluded
+// or with ErrQuarantined if any is quarantined.
+// The reserved IDs are released with FreeID.
+func (idGenerator *IDGenerator) ReserveRange(start, end int64) error {
+	err := idGenerator.reserveRange(start, end)
+	if idGenerator.recorder != nil {
+		idGenerator.record(opReserveRange, []int64{start, end}, nil, err)
+	}
+	return err
+}
+
+// reserveRange is ReserveRange without recording it
+func (idGenerator *IDGenerator) reserveRange(start, end int64) error {
+	if start > end {
+		return fmt.Errorf("%w: start %d > end %d", ErrInvalidRange, start, end)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(start) {
+		return idGenerator.outOfRangeError(start)
+	}
+	if !idGenerator.inRange(end) {
+		return idGenerator.outOfRangeError(end)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	first, last := idGenerator.toOffset(start), idGenerator.toOffset(end)
+	if offset, ok := idGenerator.store.NextSet(first); ok && offset <= last {
+		if idGenerator.isExcluded(offset) {
+			return fmt.Errorf("%w in range [%d, %d]", idGenerator.reservedError(idGenerator.toID(offset)), start, end)
+		}
+		if idGenerator.isQuarantined(offset) {
+			return fmt.Errorf("%w in range [%d, %d]", idGenerator.quarantinedError(idGenerator.toID(offset)), start, end)
+		}
+		return fmt.Errorf("%w: ID[%d] in range [%d, %d]", ErrAlreadyAllocated, idGenerator.toID(offset), start, end)
+	}
+	for offset := first; ; offset++ {
+		idGenerator.markUsed(offset)
+		if offset == last {
+			return nil
+		}
+	}
+}
+
+// FreeID releases id so that it can be allocated again.
+// It returns an error wrapping ErrOutOfRange if id is outside [minValue, maxValue],
+// ErrNotAllocated if id is not allocated, ErrReserved if id is excluded or ErrPermanent if id is marked
+// by MarkPermanent; the generator is left unchanged in these cases.
 // param:
 //   - id: id to free
-func (idGenerator *IDGenerator) FreeID(id int64) {
-	if id < idGenerator.minValue || id > idGenerator.maxValue {
-		return
+func (idGenerator *IDGenerator) FreeID(id int64) error {
+	err := idGenerator.freeID(id)
+	if idGenerator.recorder != nil {
+		idGenerator.record(opFree, []int64{id}, nil, err)
+	}
+	return err
+}
+
+// freeID is FreeID without recording it
+func (idGenerator *IDGenerator) freeID(id int64) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) {
+		idGenerator.logger.Printf("idgenerator[%d-%d]: ignore freeing ID[%d] out of range",
+			idGenerator.minValue, idGenerator.maxValue, id)
+		if idGenerator.logEnabled() {
+			idGenerator.queueLogLocked(idGenerator.newLogEventLocked(logFreeOutOfRange, id, true,
+				idGenerator.outOfRangeError(id)))
+		}
+		return idGenerator.outOfRangeFreeError(id)
+	}
+	return idGenerator.misuseLocked(idGenerator.freeLocked(id), id)
+}
+
+// freeLocked is FreeID for an id in range, the caller must hold lock
+func (idGenerator *IDGenerator) freeLocked(id int64) error {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.isExcluded(offset) {
+		return idGenerator.reservedError(id)
+	}
+	if idGenerator.isPermanent(offset) {
+		return fmt.Errorf("%w: ID[%d]", ErrPermanent, id)
+	}
+	if _, ok := idGenerator.expired[offset]; ok {
+		// the lease of id expired, it is already free
+		delete(idGenerator.expired, offset)
+		return nil
+	}
+	if !idGenerator.store.Has(offset) || idGenerator.isQuarantined(offset) {
+		err := fmt.Errorf("%w: ID[%d]", ErrNotAllocated, id)
+		if idGenerator.logEnabled() {
+			idGenerator.queueLogLocked(idGenerator.newLogEventLocked(logFreeNotAllocated, id, true, err))
+		}
+		return err
+	}
+	idGenerator.markFree(offset)
+	idGenerator.serveWaitersLocked()
+	return nil
+}
+
+// FreeMany frees ids at once, under a single acquisition of the lock, and returns how many it freed.
+// Each ID is freed like FreeID and one that cannot be freed does not keep the others from being freed:
+// errs holds the error of each of them, in the order of ids, and is nil if every ID has been freed.
+// An ID listed twice fails with ErrNotAllocated the second time.
+func (idGenerator *IDGenerator) FreeMany(ids []int64) (freed int, errs []error) {
+	var results []error
+	if idGenerator.recorder != nil {
+		// recorded as a free per ID once the lock is released
+		results = make([]error, len(ids))
+		defer func() {
+			for i, id := range ids {
+				idGenerator.record(opFree, []int64{id}, nil, results[i])
+			}
+		}()
 	}
 	idGenerator.lock.Lock()
-	defer idGenerator.lock.Unlock()
-	delete(idGenerator.usedMap, id-idGenerator.minValue)
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	for i, id := range ids {
+		if !idGenerator.inRange(id) {
+			idGenerator.logger.Printf("idgenerator[%d-%d]: ignore freeing ID[%d] out of range",
+				idGenerator.minValue, idGenerator.maxValue, id)
+			if idGenerator.logEnabled() {
+				idGenerator.queueLogLocked(idGenerator.newLogEventLocked(logFreeOutOfRange, id, true,
+					idGenerator.outOfRangeError(id)))
+			}
+			errs = append(errs, idGenerator.misuseLocked(idGenerator.outOfRangeError(id), id))
+			if results != nil {
+				results[i] = errs[len(errs)-1]
+			}
+			continue
+		}
+		if err := idGenerator.misuseLocked(idGenerator.freeLocked(id), id); err != nil {
+			errs = append(errs, err)
+			if results != nil {
+				results[i] = err
+			}
+			continue
+		}
+		freed++
+	}
+	return freed, errs
+}
+
+// FreeRange frees every allocated ID in [start, end] at once and returns how many it freed,
+// the free, excluded, quarantined and permanent IDs in the range are skipped.
+// It fails like ReserveRange if start > end or if the range is not within [minValue, maxValue],
+// nothing is freed then. The hook of WithOnFree is called for each freed ID.
+func (idGenerator *IDGenerator) FreeRange(start, end int64) (int64, error) {
+	freed, err := idGenerator.freeRange(start, end)
+	if idGenerator.recorder != nil {
+		idGenerator.record(opFreeRange, []int64{start, end}, []int64{freed}, err)
+	}
+	return freed, err
+}
+
+// freeRange is FreeRange without recording it
+func (idGenerator *IDGenerator) freeRange(start, end int64) (int64, error) {
+	if start > end {
+		return 0, fmt.Errorf("%w: start %d > end %d", ErrInvalidRange, start, end)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(start) {
+		return 0, idGenerator.outOfRangeError(start)
+	}
+	if !idGenerator.inRange(end) {
+		return 0, idGenerator.outOfRangeError(end)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	first, last := idGenerator.toOffset(start), idGenerator.toOffset(end)
+	// the IDs whose lease expired are already free
+	for offset := range idGenerator.expired {
+		if offset >= first && offset <= last {
+			delete(idGenerator.expired, offset)
+		}
+	}
+	freed := int64(0)
+	for offset, ok := idGenerator.store.NextSet(first); ok && offset <= last; {
+		if !idGenerator.isHeld(offset) && !idGenerator.isPermanent(offset) {
+			idGenerator.markFree(offset)
+			freed++
+		}
+		if offset == last {
+			break
+		}
+		offset, ok = idGenerator.store.NextSet(offset + 1)
+	}
+	if freed > 0 {
+		idGenerator.serveWaitersLocked()
+	}
+	return freed, nil
+}
+
+// Reset frees all allocated IDs but the permanent ones of MarkPermanent and restarts allocation from minValue,
+// without quarantine.
+// IDs handed out before Reset must not be freed afterwards, since they may have been reallocated.
+// The hook of WithOnFree and the observer of WithHoldTimes are called and a free recorded by WithAudit
+// for each of them.
+func (idGenerator *IDGenerator) Reset() {
+	idGenerator.lock.Lock()
+	if !idGenerator.closed {
+		idGenerator.resetLocked()
+	}
+	idGenerator.unlock()
+	if idGenerator.recorder != nil {
+		idGenerator.record(opReset, nil, nil, nil)
+	}
+}
+
+// resetLocked is Reset, the caller must hold lock
+func (idGenerator *IDGenerator) resetLocked() {
+	if idGenerator.onFree != nil || idGenerator.audit != nil || idGenerator.heldSince != nil {
+		for _, offset := range idGenerator.allocatedOffsetsLocked() {
+			if idGenerator.isPermanent(offset) {
+				continue
+			}
+			idGenerator.recordLocked(AuditFree, offset)
+			idGenerator.queueEvent(idGenerator.onFree, offset)
+			idGenerator.releaseLocked(offset)
+		}
+	}
+	idGenerator.offset = 0
+	idGenerator.store.Reset()
+	idGenerator.setExcluded(idGenerator.store)
+	// the cached IDs are freed with the others
+	idGenerator.dropCacheLocked()
+	idGenerator.frees += idGenerator.used - uint64(len(idGenerator.permanent))
+	idGenerator.used = 0
+	idGenerator.leases = nil
+	idGenerator.reservations = nil
+	idGenerator.leaseHeap = nil
+	idGenerator.expired = nil
+	idGenerator.owners = nil
+	idGenerator.ownerOf = nil
+	if idGenerator.leaks != nil {
+		idGenerator.leaks.reset()
+	}
+	idGenerator.clearRefsLocked()
+	idGenerator.endQuarantineLocked()
+	idGenerator.setPermanentLocked()
+	idGenerator.resetRecyclingLocked()
+	idGenerator.stickyFreedLocked()
+	idGenerator.serveWaitersLocked()
+}
+
+// IsAllocated reports whether id is allocated,
+// it is false for any id outside [minValue, maxValue] and for the excluded and quarantined IDs
+func (idGenerator *IDGenerator) IsAllocated(id int64) bool {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return false
+	}
+	offset := idGenerator.toOffset(id)
+	return idGenerator.store.Has(offset) && !idGenerator.isHeld(offset)
+}
+
+// AllocatedIDs returns the allocated IDs in ascending order
+func (idGenerator *IDGenerator) AllocatedIDs() []int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	offsets := idGenerator.allocatedOffsetsLocked()
+	ids := make([]int64, len(offsets))
+	for i, offset := range offsets {
+		ids[i] = idGenerator.toID(offset)
+	}
+	return ids
+}
+
+// ForEachAllocated calls f with the allocated IDs in ascending order until f returns false.
+// f runs without lock, so it may call any method of the generator, FreeID included.
+// It looks up each ID in turn, so it takes no memory
+// and reports the IDs allocated or freed meanwhile if they are above the last ID passed to f.
+func (idGenerator *IDGenerator) ForEachAllocated(f func(id int64) bool) {
+	for next, ok := idGenerator.nextAllocated(math.MinInt64); ok && f(next); {
+		if next == math.MaxInt64 {
+			return
+		}
+		next, ok = idGenerator.nextAllocated(next + 1)
+	}
+}
+
+// nextAllocated returns the lowest allocated ID from id on, or false if there is none
+func (idGenerator *IDGenerator) nextAllocated(id int64) (int64, bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if id > idGenerator.maxValue {
+		return 0, false
+	}
+	from := uint64(0)
+	if id > idGenerator.minValue {
+		if from = idGenerator.step.ceilOffset(idGenerator.minValue, id); from > idGenerator.lastOffset {
+			return 0, false
+		}
+	}
+	for {
+		offset, ok := idGenerator.store.NextSet(from)
+		if !ok {
+			return 0, false
+		}
+		if !idGenerator.isHeld(offset) {
+			return idGenerator.toID(offset), true
+		}
+		if offset == idGenerator.lastOffset {
+			return 0, false
+		}
+		from = offset + 1
+	}
+}
+
+// Used returns the number of allocated IDs
+func (idGenerator *IDGenerator) Used() int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return clampInt64(idGenerator.used)
+}
+
+// Available returns the number of IDs that can still be allocated,
+// capped at math.MaxInt64 for ranges larger than that. The quarantined IDs are counted by Quarantined instead.
+func (idGenerator *IDGenerator) Available() int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return clampInt64(idGenerator.availableLocked())
+}
+
+// availableLocked returns the number of free offsets, capped at math.MaxUint64
+// since all 2^64 of them are free in an empty generator over the full int64 range
+func (idGenerator *IDGenerator) availableLocked() uint64 {
+	occupied := idGenerator.occupiedLocked()
+	if occupied > idGenerator.lastOffset {
+		return 0
+	}
+	if free := idGenerator.lastOffset - occupied; free != math.MaxUint64 {
+		return free + 1
+	}
+	return math.MaxUint64
+}
+
+func clampInt64(v uint64) int64 {
+	if v > math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return int64(v)
+}
+
+// markUsed and markFree keep store and the used counter in step, the caller must hold lock
+func (idGenerator *IDGenerator) markUsed(offset uint64) {
+	idGenerator.store.Set(offset)
+	if idGenerator.recycling != nil {
+		idGenerator.recycling.take(offset)
+	}
+	if idGenerator.generations != nil {
+		idGenerator.generations[offset]++
+	}
+	idGenerator.used++
+	idGenerator.allocations++
+	if idGenerator.used > idGenerator.peakUsed {
+		idGenerator.peakUsed, idGenerator.peakAt = idGenerator.used, idGenerator.clock.Now()
+	}
+	delete(idGenerator.expired, offset)
+	idGenerator.holdLocked(offset)
+	idGenerator.trackLocked(offset)
+	idGenerator.noteAllocatedLocked(offset)
+	idGenerator.recordLocked(AuditAllocate, offset)
+	idGenerator.queueEvent(idGenerator.onAllocate, offset)
+}
+
+func (idGenerator *IDGenerator) markFree(offset uint64) {
+	if idGenerator.audit != nil {
+		idGenerator.recordLocked(AuditFree, offset)
+		if set, ok := idGenerator.ownerOf[offset]; ok {
+			idGenerator.audit.tagLast(set.name)
+		}
+	}
+	if idGenerator.reuseDelay > 0 {
+		idGenerator.quarantineLocked(offset)
+	} else {
+		idGenerator.clearLocked(offset)
+	}
+	idGenerator.used--
+	idGenerator.frees++
+	delete(idGenerator.leases, offset)
+	delete(idGenerator.reservations, offset)
+	idGenerator.untrackLocked(offset)
+	idGenerator.uncacheLocked(offset)
+	idGenerator.noteFreedLocked(offset)
+	idGenerator.disownLocked(offset)
+	if idGenerator.sticky != nil {
+		idGenerator.sticky.freed(idGenerator.toID(offset), idGenerator.clock)
+	}
+	if idGenerator.extraRefs > 0 {
+		idGenerator.unrefLocked(offset)
+	}
+	idGenerator.queueEvent(idGenerator.onFree, offset)
+	idGenerator.releaseLocked(offset)
+}
+
+// unmarkUsed undoes the markUsed of offset by a call giving it back before handing it out, e.g. AllocateMany
+// rolling back: the ID is free at once, without the quarantine of WithReuseDelay, it keeps its generation
+// and it counts neither as allocated nor as freed. Its allocation is dropped from the audit if it is the
+// latest event, or followed by a free otherwise. The caller must hold lock and drop the events it queued.
+func (idGenerator *IDGenerator) unmarkUsed(offset uint64) {
+	if idGenerator.audit != nil {
+		if e := idGenerator.audit.last(); e != nil && e.Op == AuditAllocate && e.ID == idGenerator.toID(offset) {
+			idGenerator.audit.dropLast()
+		} else {
+			idGenerator.recordLocked(AuditFree, offset)
+		}
+	}
+	idGenerator.clearLocked(offset)
+	if idGenerator.generations != nil {
+		idGenerator.generations[offset]--
+	}
+	idGenerator.used--
+	idGenerator.allocations--
+	delete(idGenerator.heldSince, offset)
+	delete(idGenerator.leases, offset)
+	delete(idGenerator.reservations, offset)
+	idGenerator.untrackLocked(offset)
+	idGenerator.uncacheLocked(offset)
+	idGenerator.unnoteAllocatedLocked(offset)
+	idGenerator.disownLocked(offset)
+}
+
+// queueEvent queues a call of hook for the ID at offset if hook is set, the caller must hold lock
+func (idGenerator *IDGenerator) queueEvent(hook func(id int64), offset uint64) {
+	if hook != nil {
+		idGenerator.events = append(idGenerator.events, event{hook: hook, id: idGenerator.toID(offset)})
+	}
+}
+
+// MinValue returns the lowest ID of the range. Resize, Merge and the provider of WithRangeProvider move the bounds,
+// so the accessors of the range read them under the read lock of the generator,
+// and a concurrent call of those may outdate the result.
+func (idGenerator *IDGenerator) MinValue() int64 {
+	minValue, _ := idGenerator.bounds()
+	return minValue
+}
+
+// MaxValue returns the highest ID of the range, read like MinValue
+func (idGenerator *IDGenerator) MaxValue() int64 {
+	_, maxValue := idGenerator.bounds()
+	return maxValue
+}
+
+// Capacity returns the number of IDs the generator may allocate at once, the size of the range
+// less the excluded IDs, capped at math.MaxInt64. It is read like MinValue.
+func (idGenerator *IDGenerator) Capacity() int64 {
+	idGenerator.lock.RLock()
+	defer idGenerator.lock.RUnlock()
+	return clampInt64(idGenerator.capacityLocked())
+}
+
+// Contains reports whether the generator may allocate id, that is whether id is in the range and not excluded,
+// whether it is allocated or not. It is read like MinValue.
+func (idGenerator *IDGenerator) Contains(id int64) bool {
+	idGenerator.lock.RLock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return false
+	}
+	_, excluded := idGenerator.excluded[idGenerator.toOffset(id)]
+	return !excluded
+}
+
+// bounds returns minValue and maxValue, which Resize may change
+func (idGenerator *IDGenerator) bounds() (int64, int64) {
+	idGenerator.lock.RLock()
+	defer idGenerator.lock.RUnlock()
+	return idGenerator.minValue, idGenerator.maxValue
+}
+
+// inRange reports whether id is an ID of the generator, in [minValue, maxValue] and of the step of WithStep
+func (idGenerator *IDGenerator) inRange(id int64) bool {
+	return id >= idGenerator.minValue && id <= idGenerator.maxValue && idGenerator.step.contains(idGenerator.minValue, id)
+}
+
+func (idGenerator *IDGenerator) outOfRangeError(id int64) error {
+	if id >= idGenerator.minValue && id <= idGenerator.maxValue {
+		return fmt.Errorf("%w: ID[%d] not a multiple of %d past %d", ErrOutOfRange, id, idGenerator.step.modulus,
+			idGenerator.toID(0))
+	}
+	return fmt.Errorf("%w: ID[%d] not in [%d, %d]", ErrOutOfRange, id, idGenerator.minValue, idGenerator.maxValue)
+}
+
+// toOffset converts an ID of the generator to its key in store,
+// the subtraction wraps around exactly like the conversion back in toID
+func (idGenerator *IDGenerator) toOffset(id int64) uint64 {
+	return idGenerator.step.toOffset(idGenerator.minValue, id)
+}
+
+// toID converts a key of store back to the ID handed out to callers
+func (idGenerator *IDGenerator) toID(offset uint64) int64 {
+	return idGenerator.step.toID(idGenerator.minValue, offset)
 }
 
 func (idGenerator *IDGenerator) updateOffset() {
-	idGenerator.offset++
-	idGenerator.offset = idGenerator.offset % idGenerator.valueRange
+	if idGenerator.offset == idGenerator.lastOffset {
+		idGenerator.offset = 0
+	} else {
+		idGenerator.offset++
+	}
 }
@@ -1,8 +1,17 @@
 package idgenerator
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math"
 	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -34,7 +43,10 @@ func TestAllocate(t *testing.T) {
 			}
 
 			for i := testCase.minValue; i <= testCase.maxValue; i++ {
-				idGenerator.FreeID(i)
+				if err := idGenerator.FreeID(i); err != nil {
+					t.Error(err)
+					t.FailNow()
+				}
 			}
 		})
 	}
@@ -62,7 +74,10 @@ func TestConcurrency(t *testing.T) {
 			}
 			usedMap.Range(func(key, value interface{}) bool {
 				id := key.(int64)
-				idGenerator.FreeID(id)
+				// IDs of other routines may have been freed already
+				if err := idGenerator.FreeID(id); err != nil && !errors.Is(err, ErrNotAllocated) {
+					t.Errorf("idGenerator.FreeID fail: %+v", err)
+				}
 				return true
 			})
 			wg.Done()
@@ -114,7 +129,10 @@ func TestUnique(t *testing.T) {
 
 					keyIdx := rand.Intn(len(keys))
 					idToFree := keys[keyIdx]
-					idGenerator.FreeID(idToFree)
+					if err := idGenerator.FreeID(idToFree); err != nil {
+						t.Error(err)
+						t.FailNow()
+					}
 					delete(usedMap, idToFree)
 				}
 			}
@@ -152,3 +170,1514 @@ func TestTriggerNoSpaceToAllocateError(t *testing.T) {
 		})
 	}
 }
+
+func TestFreeAndReallocate(t *testing.T) {
+	testCases := []struct {
+		minValue int64
+		maxValue int64
+	}{
+		{0, 9},
+		{1, 10},
+		{100, 200},
+		{5000, 5000},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("minValue: %d, maxValue: %d", testCase.minValue, testCase.maxValue), func(t *testing.T) {
+			idGenerator := NewGenerator(testCase.minValue, testCase.maxValue)
+
+			// several full allocate/free cycles must never report exhaustion
+			for round := 0; round < 3; round++ {
+				for i := testCase.minValue; i <= testCase.maxValue; i++ {
+					if _, err := idGenerator.Allocate(); err != nil {
+						t.Errorf("round %d: %+v", round, err)
+						t.FailNow()
+					}
+				}
+				for i := testCase.minValue; i <= testCase.maxValue; i++ {
+					if err := idGenerator.FreeID(i); err != nil {
+						t.Errorf("round %d: %+v", round, err)
+						t.FailNow()
+					}
+				}
+			}
+
+			// once the pool is full, freeing a single ID must make exactly that ID allocatable again
+			for i := testCase.minValue; i <= testCase.maxValue; i++ {
+				if _, err := idGenerator.Allocate(); err != nil {
+					t.Error(err)
+					t.FailNow()
+				}
+			}
+			for _, idToFree := range []int64{testCase.maxValue, testCase.minValue} {
+				if err := idGenerator.FreeID(idToFree); err != nil {
+					t.Error(err)
+					t.FailNow()
+				}
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Error(err)
+					t.FailNow()
+				}
+				if id != idToFree {
+					t.Errorf("expected id: %d, output id: %d", idToFree, id)
+					t.FailNow()
+				}
+			}
+			if _, err := idGenerator.Allocate(); err == nil {
+				t.Error("expect return error, but error is nil")
+			}
+		})
+	}
+}
+
+func TestLogger(t *testing.T) {
+	t.Run("no logger configured", func(t *testing.T) {
+		stdout := os.Stdout
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		os.Stdout = w
+
+		idGenerator := NewGenerator(1, 2)
+		for i := 0; i < 3; i++ {
+			if _, err = idGenerator.Allocate(); err != nil && i < 2 {
+				t.Error(err)
+			}
+		}
+		if err = idGenerator.FreeID(1); err != nil {
+			t.Error(err)
+		}
+		if err = idGenerator.FreeID(100); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("expected ErrOutOfRange, got %+v", err)
+		}
+
+		os.Stdout = stdout
+		if err = w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		output, err := io.ReadAll(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(output) != 0 {
+			t.Errorf("expected no output on stdout, got %q", output)
+		}
+	})
+
+	t.Run("with logger", func(t *testing.T) {
+		var buf bytes.Buffer
+		idGenerator, err := NewGeneratorWithOptions(1, 2, WithLogger(log.New(&buf, "", 0)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := idGenerator.Allocate(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if buf.Len() != 0 {
+			t.Errorf("expected no log for successful allocations, got %q", buf.String())
+		}
+
+		if _, err = idGenerator.Allocate(); err == nil {
+			t.Fatal("expect return error, but error is nil")
+		}
+		if !strings.Contains(buf.String(), "idgenerator[1-2]: allocate failed") {
+			t.Errorf("expected allocation failure to be logged, got %q", buf.String())
+		}
+	})
+}
+
+func TestFreeIDErrors(t *testing.T) {
+	idGenerator := NewGenerator(100, 200)
+
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		name        string
+		id          int64
+		expectedErr error
+	}{
+		{"allocated ID", id, nil},
+		{"double free", id, ErrNotAllocated},
+		{"never allocated", 150, ErrNotAllocated},
+		{"below minValue", 99, ErrOutOfRange},
+		{"above maxValue", 201, ErrOutOfRange},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := idGenerator.FreeID(testCase.id)
+			if testCase.expectedErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %+v", err)
+				}
+			} else if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+		})
+	}
+}
+
+func TestNewGeneratorRange(t *testing.T) {
+	testCases := []struct {
+		minValue    int64
+		maxValue    int64
+		expectedErr error
+	}{
+		{5, 5, nil},
+		{-5, -5, nil},
+		{-10, -5, nil},
+		{-10, 10, nil},
+		{10, 5, ErrInvalidRange},
+		{-5, -10, ErrInvalidRange},
+		{1, -1, ErrInvalidRange},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("minValue: %d, maxValue: %d", testCase.minValue, testCase.maxValue), func(t *testing.T) {
+			idGenerator, err := NewGeneratorE(testCase.minValue, testCase.maxValue)
+			if testCase.expectedErr != nil {
+				if !errors.Is(err, testCase.expectedErr) {
+					t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+				}
+				if idGenerator != nil {
+					t.Error("expected nil generator on error")
+				}
+				defer func() {
+					if recover() == nil {
+						t.Error("expected NewGenerator to panic")
+					}
+				}()
+				NewGenerator(testCase.minValue, testCase.maxValue)
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := testCase.minValue; i <= testCase.maxValue; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != i {
+					t.Fatalf("expected id: %d, output id: %d", i, id)
+				}
+			}
+			if _, err = idGenerator.Allocate(); err == nil {
+				t.Fatal("expect return error, but error is nil")
+			}
+
+			// a single-element generator must hand its only ID out again once freed
+			if err = idGenerator.FreeID(testCase.maxValue); err != nil {
+				t.Fatal(err)
+			}
+			id, err := idGenerator.Allocate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != testCase.maxValue {
+				t.Errorf("expected id: %d, output id: %d", testCase.maxValue, id)
+			}
+		})
+	}
+}
+
+func TestRangeBoundaries(t *testing.T) {
+	testCases := []struct {
+		name      string
+		minValue  int64
+		maxValue  int64
+		available int64
+		capacity  uint64
+	}{
+		{"full range", math.MinInt64, math.MaxInt64, math.MaxInt64, math.MaxUint64},
+		{"2^63 non-negative", 0, math.MaxInt64, math.MaxInt64, 1 << 63},
+		{"2^63 negative", math.MinInt64, -1, math.MaxInt64, 1 << 63},
+		{"2^63 - 1 below max", 1, math.MaxInt64, math.MaxInt64, math.MaxInt64},
+		{"small at max", math.MaxInt64 - 9, math.MaxInt64, 10, 10},
+		{"small at min", math.MinInt64, math.MinInt64 + 9, 10, 10},
+		{"single at max", math.MaxInt64, math.MaxInt64, 1, 1},
+	}
+
+	for _, testCase := range testCases {
+		for _, storeOption := range storeOptions {
+			// a bitmap of a huge range cannot be allocated
+			if storeOption.name == "bitmap" && testCase.capacity > 10 {
+				continue
+			}
+			t.Run(fmt.Sprintf("%s %s", testCase.name, storeOption.name), func(t *testing.T) {
+				idGenerator, err := NewGeneratorWithOptions(testCase.minValue, testCase.maxValue, storeOption.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if available := idGenerator.Available(); available != testCase.available {
+					t.Errorf("expected available: %d, output available: %d", testCase.available, available)
+				}
+				if capacity := idGenerator.Stats().Capacity; capacity != testCase.capacity {
+					t.Errorf("expected capacity: %d, output capacity: %d", testCase.capacity, capacity)
+				}
+
+				// allocation starts at minValue and the bounds can be allocated and freed
+				if id, err := idGenerator.Allocate(); err != nil || id != testCase.minValue {
+					t.Fatalf("expected id: %d, output: %d, %+v", testCase.minValue, id, err)
+				}
+				if testCase.maxValue != testCase.minValue {
+					if err = idGenerator.AllocateSpecific(testCase.maxValue); err != nil {
+						t.Fatal(err)
+					}
+				}
+				if !idGenerator.IsAllocated(testCase.maxValue) {
+					t.Errorf("ID[%d] should be allocated", testCase.maxValue)
+				}
+				if testCase.maxValue != math.MaxInt64 {
+					if err = idGenerator.FreeID(testCase.maxValue + 1); !errors.Is(err, ErrOutOfRange) {
+						t.Errorf("expected ErrOutOfRange, got %+v", err)
+					}
+				}
+				if err = idGenerator.FreeID(testCase.maxValue); err != nil {
+					t.Fatal(err)
+				}
+
+				// the scan wraps around from maxValue to minValue
+				snapshot := idGenerator.Snapshot()
+				snapshot.Offset = uint64(testCase.maxValue) - uint64(testCase.minValue)
+				restored, err := RestoreGenerator(snapshot, storeOption.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id, err := restored.Allocate(); err != nil || id != testCase.maxValue {
+					t.Fatalf("expected id: %d, output: %d, %+v", testCase.maxValue, id, err)
+				}
+				if testCase.capacity > 1 {
+					if err = restored.FreeID(testCase.minValue); err != nil {
+						t.Fatal(err)
+					}
+					if id, err := restored.Allocate(); err != nil || id != testCase.minValue {
+						t.Fatalf("expected id after wrap-around: %d, output: %d, %+v", testCase.minValue, id, err)
+					}
+				}
+
+				// blocks at the end of the range
+				idGenerator.Reset()
+				n := int64(3)
+				if testCase.capacity < 3 {
+					n = int64(testCase.capacity)
+				}
+				if err = idGenerator.ReserveRange(testCase.maxValue-(n-1), testCase.maxValue); err != nil {
+					t.Fatal(err)
+				}
+				if err = idGenerator.ReserveRange(testCase.maxValue, testCase.maxValue); !errors.Is(err, ErrAlreadyAllocated) {
+					t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+				}
+				for id := testCase.maxValue - (n - 1); ; id++ {
+					if err = idGenerator.FreeID(id); err != nil {
+						t.Fatal(err)
+					}
+					if id == testCase.maxValue {
+						break
+					}
+				}
+				if testCase.capacity < 100 {
+					first, err := idGenerator.AllocateContiguous(int64(testCase.capacity))
+					if err != nil || first != testCase.minValue {
+						t.Fatalf("expected block at: %d, output: %d, %+v", testCase.minValue, first, err)
+					}
+					if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+						t.Errorf("expected ErrPoolExhausted, got %+v", err)
+					}
+				}
+			})
+		}
+	}
+
+	t.Run("random full range", func(t *testing.T) {
+		idGenerator, err := NewGeneratorWithOptions(math.MinInt64, math.MaxInt64,
+			WithRandomAllocationFrom(rand.New(rand.NewSource(1))))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids, err := idGenerator.AllocateMany(100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range ids {
+			if !idGenerator.IsAllocated(id) {
+				t.Errorf("ID[%d] should be allocated", id)
+			}
+		}
+	})
+
+	t.Run("bitmap too large", func(t *testing.T) {
+		if _, err := NewGeneratorWithOptions(0, math.MaxInt64, WithBitmapStore()); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("expected ErrInvalidRange, got %+v", err)
+		}
+	})
+}
+
+func TestSignedRanges(t *testing.T) {
+	testCases := []struct {
+		name     string
+		minValue int64
+		maxValue int64
+	}{
+		{"negative", -1000, -996},
+		{"straddling zero", -2, 2},
+		{"positive", 996, 1000},
+	}
+
+	for _, testCase := range testCases {
+		for _, storeOption := range storeOptions {
+			t.Run(fmt.Sprintf("%s %s", testCase.name, storeOption.name), func(t *testing.T) {
+				idGenerator, err := NewGeneratorWithOptions(testCase.minValue, testCase.maxValue, storeOption.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				// allocation starts at minValue
+				for expected := testCase.minValue; expected < testCase.minValue+3; expected++ {
+					if id, err := idGenerator.Allocate(); err != nil || id != expected {
+						t.Fatalf("expected id: %d, output: %d, %+v", expected, id, err)
+					}
+				}
+				// the mirrored IDs of the other sign do not collide
+				for id := testCase.minValue; id < testCase.minValue+3; id++ {
+					if id != 0 && idGenerator.IsAllocated(-id) {
+						t.Errorf("ID[%d] reported allocated along with ID[%d]", -id, id)
+					}
+				}
+
+				// and wraps around to minValue past maxValue, across zero if the range straddles it
+				if err = idGenerator.FreeID(testCase.minValue); err != nil {
+					t.Fatal(err)
+				}
+				for _, expected := range []int64{testCase.minValue + 3, testCase.maxValue, testCase.minValue} {
+					if id, err := idGenerator.Allocate(); err != nil || id != expected {
+						t.Fatalf("expected id: %d, output: %d, %+v", expected, id, err)
+					}
+				}
+				if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+					t.Errorf("expected ErrPoolExhausted, got %+v", err)
+				}
+
+				for id := testCase.minValue; id <= testCase.maxValue; id++ {
+					if err = idGenerator.FreeID(id); err != nil {
+						t.Fatal(err)
+					}
+					if err = idGenerator.FreeID(id); !errors.Is(err, ErrNotAllocated) {
+						t.Errorf("expected ErrNotAllocated, got %+v", err)
+					}
+				}
+				if err = idGenerator.AllocateSpecific(testCase.maxValue - 1); err != nil {
+					t.Fatal(err)
+				}
+				if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{testCase.maxValue - 1}) {
+					t.Errorf("unexpected allocated ids: %v", ids)
+				}
+				outOfRange := []int64{testCase.minValue - 1, testCase.maxValue + 1}
+				if testCase.minValue > 0 || testCase.maxValue < 0 {
+					outOfRange = append(outOfRange, -testCase.maxValue)
+				}
+				for _, id := range outOfRange {
+					if err = idGenerator.AllocateSpecific(id); !errors.Is(err, ErrOutOfRange) {
+						t.Errorf("AllocateSpecific(%d): expected ErrOutOfRange, got %+v", id, err)
+					}
+					if err = idGenerator.FreeID(id); !errors.Is(err, ErrOutOfRange) {
+						t.Errorf("FreeID(%d): expected ErrOutOfRange, got %+v", id, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestAllocateSpecific(t *testing.T) {
+	idGenerator := NewGenerator(100, 104)
+
+	testCases := []struct {
+		name        string
+		id          int64
+		expectedErr error
+	}{
+		{"free ID", 102, nil},
+		{"minValue", 100, nil},
+		{"maxValue", 104, nil},
+		{"already allocated", 102, ErrAlreadyAllocated},
+		{"below minValue", 99, ErrOutOfRange},
+		{"above maxValue", 105, ErrOutOfRange},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := idGenerator.AllocateSpecific(testCase.id)
+			if testCase.expectedErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %+v", err)
+				}
+			} else if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+		})
+	}
+
+	// Allocate must skip the IDs claimed by AllocateSpecific
+	for _, expected := range []int64{101, 103} {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+	if _, err := idGenerator.Allocate(); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+
+	// FreeID must release an ID claimed by AllocateSpecific
+	if err := idGenerator.FreeID(102); err != nil {
+		t.Fatal(err)
+	}
+	if err := idGenerator.AllocateSpecific(102); err != nil {
+		t.Errorf("expected no error, got %+v", err)
+	}
+}
+
+func TestAllocateMany(t *testing.T) {
+	idGenerator := NewGenerator(10, 19)
+
+	ids, err := idGenerator.AllocateMany(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if expected := int64(10 + i); id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+
+	if ids, err = idGenerator.AllocateMany(0); err != nil || len(ids) != 0 {
+		t.Errorf("expected no IDs and no error, got %v, %+v", ids, err)
+	}
+	if _, err = idGenerator.AllocateMany(-1); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+
+	// 6 IDs left: asking for 7 must fail without allocating anything
+	if _, err = idGenerator.AllocateMany(7); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	for id := int64(14); id <= 19; id++ {
+		if err = idGenerator.AllocateSpecific(id); err != nil {
+			t.Errorf("ID %d should not be allocated after a failed AllocateMany: %+v", id, err)
+		}
+	}
+	if _, err = idGenerator.AllocateMany(1); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	// freed IDs are picked up again
+	for _, id := range []int64{12, 17} {
+		if err = idGenerator.FreeID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ids, err = idGenerator.AllocateMany(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != 2 || ids[0] == ids[1] || (ids[0] != 12 && ids[0] != 17) || (ids[1] != 12 && ids[1] != 17) {
+		t.Errorf("expected IDs 12 and 17, got %v", ids)
+	}
+}
+
+func TestReset(t *testing.T) {
+	idGenerator := NewGenerator(100, 109)
+
+	for i := 0; i < 7; i++ {
+		if _, err := idGenerator.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := idGenerator.FreeID(100); err != nil {
+		t.Fatal(err)
+	}
+
+	idGenerator.Reset()
+
+	// all IDs are free again and allocation restarts from minValue
+	for i := int64(100); i <= 109; i++ {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != i {
+			t.Errorf("expected id: %d, output id: %d", i, id)
+		}
+	}
+
+	t.Run("concurrent with Allocate", func(t *testing.T) {
+		idGenerator := NewGenerator(1, 1000)
+
+		wg := sync.WaitGroup{}
+		for routineID := 0; routineID < 4; routineID++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					if _, err := idGenerator.Allocate(); err != nil && !errors.Is(err, ErrPoolExhausted) {
+						t.Errorf("idGenerator.Allocate fail: %+v", err)
+					}
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			idGenerator.Reset()
+		}
+		wg.Wait()
+
+		idGenerator.Reset()
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 1 {
+			t.Errorf("expected id: 1, output id: %d", id)
+		}
+	})
+}
+
+func TestIsAllocated(t *testing.T) {
+	idGenerator := NewGenerator(100, 199)
+
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(150); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		id       int64
+		expected bool
+	}{
+		{id, true},
+		{150, true},
+		{101, false},
+		{199, false},
+		// offsets of the allocated IDs must not be reported as allocated
+		{0, false},
+		{50, false},
+		{99, false},
+		{200, false},
+	}
+
+	for _, testCase := range testCases {
+		if allocated := idGenerator.IsAllocated(testCase.id); allocated != testCase.expected {
+			t.Errorf("IsAllocated(%d): expected %v, output %v", testCase.id, testCase.expected, allocated)
+		}
+	}
+
+	if err = idGenerator.FreeID(150); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(150) {
+		t.Error("IsAllocated(150): expected false after FreeID")
+	}
+}
+
+func TestAllocateWithPreference(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(1, 5, []int64{4}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			testCases := []struct {
+				preferred int64
+				expected  int64
+				honored   bool
+			}{
+				{3, 3, true},
+				// taken, excluded or out of range, the next ID is allocated instead
+				{3, 1, false},
+				{4, 2, false},
+				{0, 5, false},
+			}
+			for _, testCase := range testCases {
+				id, honored, err := idGenerator.AllocateWithPreference(testCase.preferred)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != testCase.expected || honored != testCase.honored {
+					t.Errorf("preferred %d: expected (%d, %v), output (%d, %v)",
+						testCase.preferred, testCase.expected, testCase.honored, id, honored)
+				}
+			}
+			if _, honored, err := idGenerator.AllocateWithPreference(1); !errors.Is(err, ErrPoolExhausted) || honored {
+				t.Errorf("expected ErrPoolExhausted, got %v, %+v", honored, err)
+			}
+			if failures := idGenerator.Stats().AllocationFailures; failures != 1 {
+				t.Errorf("expected 1 allocation failure, output %d", failures)
+			}
+
+			if err = idGenerator.FreeID(5); err != nil {
+				t.Fatal(err)
+			}
+			if id, honored, err := idGenerator.AllocateWithPreference(5); err != nil || id != 5 || !honored {
+				t.Errorf("expected the freed preferred ID 5, output %d, %v, %+v", id, honored, err)
+			}
+		})
+	}
+}
+
+func TestAllocateWithOffset(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{9}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(6, 8); err != nil {
+				t.Fatal(err)
+			}
+			testCases := []struct {
+				offset   int64
+				expected int64
+			}{
+				{2, 2},
+				{2, 3},
+				// the allocated and excluded IDs are skipped
+				{6, 10},
+				// only the IDs below the offset are free, the scan wraps around
+				{7, 1},
+				{10, 4},
+				{10, 5},
+			}
+			for _, testCase := range testCases {
+				if id, err := idGenerator.AllocateWithOffset(testCase.offset); err != nil || id != testCase.expected {
+					t.Errorf("offset %d: expected ID %d, output %d, %+v", testCase.offset, testCase.expected, id, err)
+				}
+			}
+			// the pool is full
+			if _, err = idGenerator.AllocateWithOffset(5); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if failures := idGenerator.Stats().AllocationFailures; failures != 1 {
+				t.Errorf("expected 1 allocation failure, output %d", failures)
+			}
+			for _, offset := range []int64{0, 11} {
+				if _, err = idGenerator.AllocateWithOffset(offset); !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("offset %d: expected ErrOutOfRange, got %+v", offset, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateWithOffsetOutOfRange(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(100, 109, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// below minValue, above maxValue, negative and offsets from minValue rather than IDs
+			for _, offset := range []int64{99, 110, -1, -100, math.MinInt64, math.MaxInt64, 0, 5} {
+				if id, err := idGenerator.AllocateWithOffset(offset); !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("offset %d: expected ErrOutOfRange, output %d, %+v", offset, id, err)
+				}
+				if id, err := idGenerator.AllocateAtOrAbove(offset); !errors.Is(err, ErrOutOfRange) {
+					t.Errorf("AllocateAtOrAbove(%d): expected ErrOutOfRange, output %d, %+v", offset, id, err)
+				}
+			}
+			// nothing was allocated, the whole range is still available
+			if used := idGenerator.Used(); used != 0 {
+				t.Fatalf("expected no ID in use, output %d", used)
+			}
+			ids := allocateN(t, idGenerator, 10)
+			if ids[0] != 100 || ids[9] != 109 {
+				t.Fatalf("expected IDs 100 to 109, output %v", ids)
+			}
+			freeAll(t, idGenerator, ids...)
+		})
+	}
+}
+
+func TestAllocateWithOffsetCursor(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(1, 10, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 0 stands for Allocate, other offsets for AllocateWithOffset or, negated, AllocateAtOrAbove
+			testCases := []struct {
+				offset   int64
+				expected int64
+			}{
+				{0, 1},
+				// Allocate continues after the ID allocated with an offset
+				{5, 5},
+				{0, 6},
+				{-3, 3},
+				{0, 4},
+				{0, 7},
+				{9, 9},
+				{0, 10},
+				// and wraps around to the IDs it skipped
+				{0, 2},
+				{0, 8},
+			}
+			for i, testCase := range testCases {
+				var id int64
+				switch {
+				case testCase.offset == 0:
+					id, err = idGenerator.Allocate()
+				case testCase.offset > 0:
+					id, err = idGenerator.AllocateWithOffset(testCase.offset)
+				default:
+					id, err = idGenerator.AllocateAtOrAbove(-testCase.offset)
+				}
+				if err != nil || id != testCase.expected {
+					t.Fatalf("call %d, offset %d: expected ID %d, output %d, %+v",
+						i, testCase.offset, testCase.expected, id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateAtOrAbove(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	if err := idGenerator.ReserveRange(8, 10); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := idGenerator.AllocateAtOrAbove(5); err != nil || id != 5 {
+		t.Errorf("expected ID 5, output %d, %+v", id, err)
+	}
+	// the IDs below the offset are free, but not searched
+	for _, offset := range []int64{8, 10} {
+		if _, err := idGenerator.AllocateAtOrAbove(offset); !errors.Is(err, ErrPoolExhausted) {
+			t.Errorf("offset %d: expected ErrPoolExhausted, got %+v", offset, err)
+		}
+	}
+	if id, err := idGenerator.AllocateAtOrAbove(6); err != nil || id != 6 {
+		t.Errorf("expected ID 6, output %d, %+v", id, err)
+	}
+	if used := idGenerator.Used(); used != 5 {
+		t.Errorf("expected used: 5, output used: %d", used)
+	}
+}
+
+func TestReserveRange(t *testing.T) {
+	idGenerator := NewGenerator(1, 20)
+
+	testCases := []struct {
+		name        string
+		start       int64
+		end         int64
+		expectedErr error
+	}{
+		{"start > end", 10, 5, ErrInvalidRange},
+		{"below minValue", 0, 5, ErrOutOfRange},
+		{"above maxValue", 15, 21, ErrOutOfRange},
+		{"reserve", 1, 5, nil},
+		{"single ID", 10, 10, nil},
+		{"overlap", 8, 12, ErrAlreadyAllocated},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := idGenerator.ReserveRange(testCase.start, testCase.end)
+			if testCase.expectedErr == nil {
+				if err != nil {
+					t.Errorf("expected no error, got %+v", err)
+				}
+			} else if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+		})
+	}
+
+	// the failed reservation must not have allocated anything
+	if used := idGenerator.Used(); used != 6 {
+		t.Errorf("expected used: 6, output used: %d", used)
+	}
+	for _, id := range []int64{8, 9, 11, 12} {
+		if idGenerator.IsAllocated(id) {
+			t.Errorf("ID %d is allocated by a failed ReserveRange", id)
+		}
+	}
+
+	t.Run("ahead of the scan offset", func(t *testing.T) {
+		// offset is at 6 after the first Allocate, the reservation covers it
+		idGenerator := NewGenerator(1, 20)
+		if err := idGenerator.ReserveRange(1, 4); err != nil {
+			t.Fatal(err)
+		}
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != 5 {
+			t.Fatalf("expected id: 5, output id: %d", id)
+		}
+		if err = idGenerator.ReserveRange(6, 15); err != nil {
+			t.Fatal(err)
+		}
+
+		var ids []int64
+		for {
+			id, err := idGenerator.Allocate()
+			if err != nil {
+				break
+			}
+			ids = append(ids, id)
+		}
+		expected := []int64{16, 17, 18, 19, 20}
+		if fmt.Sprint(ids) != fmt.Sprint(expected) {
+			t.Errorf("expected ids %v, output ids %v", expected, ids)
+		}
+
+		// reserved IDs are released with FreeID
+		if err = idGenerator.FreeID(10); err != nil {
+			t.Fatal(err)
+		}
+		if id, err = idGenerator.Allocate(); err != nil || id != 10 {
+			t.Errorf("expected id 10, got %d, %+v", id, err)
+		}
+	})
+}
+
+func TestAllocateContiguous(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(100, 199, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// used: 100-104, 107, 110-119; free runs: 105-106, 108-109, 120-199
+			for _, block := range [][2]int64{{100, 104}, {107, 107}, {110, 119}} {
+				if err = idGenerator.ReserveRange(block[0], block[1]); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			testCases := []struct {
+				n             int64
+				expectedFirst int64
+			}{
+				{2, 105},
+				{1, 108},
+				{1, 109},
+				{80, 120},
+			}
+			for _, testCase := range testCases {
+				first, err := idGenerator.AllocateContiguous(testCase.n)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if first != testCase.expectedFirst {
+					t.Errorf("AllocateContiguous(%d): expected first id %d, output %d", testCase.n, testCase.expectedFirst, first)
+				}
+			}
+			if used := idGenerator.Used(); used != 100 {
+				t.Errorf("expected used: 100, output used: %d", used)
+			}
+
+			// free every other ID: 50 IDs are free but no two are consecutive
+			for id := int64(100); id <= 199; id += 2 {
+				if err = idGenerator.FreeID(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err = idGenerator.AllocateContiguous(2); !errors.Is(err, ErrNoContiguousBlock) {
+				t.Errorf("expected ErrNoContiguousBlock, got %+v", err)
+			}
+			if _, err = idGenerator.AllocateContiguous(51); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if _, err = idGenerator.AllocateContiguous(0); err == nil {
+				t.Error("expect return error, but error is nil")
+			}
+			if used := idGenerator.Used(); used != 50 {
+				t.Errorf("failed AllocateContiguous changed used to %d", used)
+			}
+
+			// a block ending at maxValue
+			if err = idGenerator.FreeID(199); err != nil {
+				t.Fatal(err)
+			}
+			first, err := idGenerator.AllocateContiguous(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if first != 198 {
+				t.Errorf("expected first id 198, output %d", first)
+			}
+		})
+	}
+}
+
+func TestAllocateAligned(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			// the alignment is relative to minValue 100
+			idGenerator, err := NewGeneratorWithOptions(100, 299, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(100, 101); err != nil {
+				t.Fatal(err)
+			}
+			testCases := []struct {
+				n             int64
+				align         int64
+				expectedFirst int64
+			}{
+				{4, 64, 164},
+				{2, 3, 103},
+				// 102 is alone between 101 and 103
+				{1, 7, 107},
+				{3, 100, 200},
+				{1, 1, 102},
+			}
+			for _, testCase := range testCases {
+				first, err := idGenerator.AllocateAligned(testCase.n, testCase.align)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if first != testCase.expectedFirst {
+					t.Errorf("AllocateAligned(%d, %d): expected first id %d, output %d",
+						testCase.n, testCase.align, testCase.expectedFirst, first)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateAlignedErrors(t *testing.T) {
+	idGenerator := NewGenerator(0, 9)
+	if err := idGenerator.ReserveRange(0, 0); err != nil {
+		t.Fatal(err)
+	}
+	// 1-9 are free, but 8-9 is the only block aligned to 8
+	if _, err := idGenerator.AllocateAligned(5, 8); !errors.Is(err, ErrNoAlignedBlock) {
+		t.Errorf("expected ErrNoAlignedBlock, got %+v", err)
+	}
+	if _, err := idGenerator.AllocateAligned(10, 1); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if first, err := idGenerator.AllocateAligned(4, 4); err != nil || first != 4 {
+		t.Errorf("expected first id 4, output %d, %+v", first, err)
+	}
+	// 1-3 and 8-9 are free
+	if _, err := idGenerator.AllocateAligned(4, 1); !errors.Is(err, ErrNoContiguousBlock) {
+		t.Errorf("expected ErrNoContiguousBlock, got %+v", err)
+	}
+	for _, args := range [][2]int64{{0, 1}, {1, 0}, {-1, 1}, {1, -8}} {
+		if _, err := idGenerator.AllocateAligned(args[0], args[1]); err == nil {
+			t.Errorf("AllocateAligned(%d, %d): expected an error", args[0], args[1])
+		}
+	}
+	if used := idGenerator.Used(); used != 5 {
+		t.Errorf("failed AllocateAligned changed used to %d", used)
+	}
+	if first, err := idGenerator.AllocateAligned(2, 8); err != nil || first != 8 {
+		t.Errorf("expected the block ending at maxValue, output %d, %+v", first, err)
+	}
+
+	full := NewGenerator(math.MinInt64, math.MaxInt64)
+	for _, expected := range []int64{math.MinInt64, -1} {
+		if first, err := full.AllocateAligned(2, math.MaxInt64); err != nil || first != expected {
+			t.Errorf("expected first id %d, output %d, %+v", expected, first, err)
+		}
+	}
+}
+
+func TestUsedAndAvailable(t *testing.T) {
+	idGenerator := NewGenerator(100, 199)
+
+	checkCounters := func(expectedUsed int64) {
+		t.Helper()
+		if used := idGenerator.Used(); used != expectedUsed {
+			t.Errorf("expected used: %d, output used: %d", expectedUsed, used)
+		}
+		if available := idGenerator.Available(); available != 100-expectedUsed {
+			t.Errorf("expected available: %d, output available: %d", 100-expectedUsed, available)
+		}
+	}
+
+	checkCounters(0)
+	if _, err := idGenerator.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(1)
+	if err := idGenerator.AllocateSpecific(150); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(2)
+	if _, err := idGenerator.AllocateMany(8); err != nil {
+		t.Fatal(err)
+	}
+	checkCounters(10)
+	if _, err := idGenerator.AllocateMany(91); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	checkCounters(10)
+
+	// failed frees must not decrement
+	if err := idGenerator.FreeID(180); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	if err := idGenerator.FreeID(10); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	checkCounters(10)
+	if err := idGenerator.FreeID(150); err != nil {
+		t.Fatal(err)
+	}
+	if err := idGenerator.FreeID(150); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	checkCounters(9)
+
+	idGenerator.Reset()
+	checkCounters(0)
+
+	t.Run("concurrent", func(t *testing.T) {
+		idGenerator := NewGenerator(1, 100000)
+
+		var allocated int64
+		var mtx sync.Mutex
+		wg := sync.WaitGroup{}
+		for routineID := 0; routineID < 8; routineID++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				var held []int64
+				for i := 0; i < 5000; i++ {
+					if i%3 == 2 {
+						if err := idGenerator.FreeID(held[0]); err != nil {
+							t.Errorf("idGenerator.FreeID fail: %+v", err)
+						}
+						held = held[1:]
+						continue
+					}
+					id, err := idGenerator.Allocate()
+					if err != nil {
+						t.Errorf("idGenerator.Allocate fail: %+v", err)
+						return
+					}
+					held = append(held, id)
+				}
+				mtx.Lock()
+				allocated += int64(len(held))
+				mtx.Unlock()
+			}()
+		}
+		done := make(chan struct{})
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				if used, available := idGenerator.Used(), idGenerator.Available(); used < 0 || available < 0 {
+					t.Errorf("invalid counters: used %d, available %d", used, available)
+				}
+			}
+		}()
+		wg.Wait()
+		close(done)
+
+		if used := idGenerator.Used(); used != allocated {
+			t.Errorf("expected used: %d, output used: %d", allocated, used)
+		}
+		if available := idGenerator.Available(); available != 100000-allocated {
+			t.Errorf("expected available: %d, output available: %d", 100000-allocated, available)
+		}
+	})
+}
+
+func BenchmarkAllocateMany(b *testing.B) {
+	const batch = 32
+
+	b.Run("AllocateMany", func(b *testing.B) {
+		idGenerator := NewGenerator(1, 1<<20)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				ids, err := idGenerator.AllocateMany(batch)
+				if err != nil {
+					b.Error(err)
+					return
+				}
+				for _, id := range ids {
+					if err = idGenerator.FreeID(id); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}
+		})
+	})
+
+	b.Run("Allocate loop", func(b *testing.B) {
+		idGenerator := NewGenerator(1, 1<<20)
+		b.RunParallel(func(pb *testing.PB) {
+			ids := make([]int64, 0, batch)
+			for pb.Next() {
+				ids = ids[:0]
+				for j := 0; j < batch; j++ {
+					id, err := idGenerator.Allocate()
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					ids = append(ids, id)
+				}
+				for _, id := range ids {
+					if err := idGenerator.FreeID(id); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			}
+		})
+	})
+}
+
+func BenchmarkFreeMany(b *testing.B) {
+	const batch = 1000
+
+	for _, bench := range []struct {
+		name string
+		free func(idGenerator *IDGenerator, ids []int64) error
+	}{
+		{"FreeMany", func(idGenerator *IDGenerator, ids []int64) error {
+			if _, errs := idGenerator.FreeMany(ids); errs != nil {
+				return errs[0]
+			}
+			return nil
+		}},
+		{"FreeID loop", func(idGenerator *IDGenerator, ids []int64) error {
+			for _, id := range ids {
+				if err := idGenerator.FreeID(id); err != nil {
+					return err
+				}
+			}
+			return nil
+		}},
+	} {
+		b.Run(bench.name, func(b *testing.B) {
+			idGenerator := NewGenerator(1, 1<<20)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				ids, err := idGenerator.AllocateMany(batch)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if err = bench.free(idGenerator, ids); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocatedIDs(t *testing.T) {
+	testCases := []struct {
+		minValue int64
+		maxValue int64
+		ids      []int64
+	}{
+		{1, 10, []int64{10, 1, 5}},
+		{-5, 5, []int64{5, -5, 0}},
+		{math.MinInt64, math.MaxInt64, []int64{math.MaxInt64, math.MinInt64, -1, 0}},
+	}
+
+	for _, testCase := range testCases {
+		for _, storeOption := range storeOptions {
+			t.Run(fmt.Sprintf("[%d, %d] %s", testCase.minValue, testCase.maxValue, storeOption.name), func(t *testing.T) {
+				idGenerator, err := NewGeneratorWithOptions(testCase.minValue, testCase.maxValue, storeOption.opts...)
+				if err != nil {
+					// a bitmap of a huge range cannot be allocated
+					t.Skip(err)
+				}
+				if ids := idGenerator.AllocatedIDs(); len(ids) != 0 {
+					t.Errorf("expected no IDs, output: %v", ids)
+				}
+				for _, id := range testCase.ids {
+					if err = idGenerator.AllocateSpecific(id); err != nil {
+						t.Fatal(err)
+					}
+				}
+				expected := append([]int64(nil), testCase.ids...)
+				sort.Slice(expected, func(i, j int) bool { return expected[i] < expected[j] })
+				if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, expected) {
+					t.Errorf("expected ids: %v, output ids: %v", expected, ids)
+				}
+
+				var visited []int64
+				idGenerator.ForEachAllocated(func(id int64) bool {
+					visited = append(visited, id)
+					return true
+				})
+				if !reflect.DeepEqual(visited, expected) {
+					t.Errorf("expected visited ids: %v, output: %v", expected, visited)
+				}
+			})
+		}
+	}
+}
+
+func TestForEachAllocated(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{4}, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(1, 3); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(5, 8); err != nil {
+				t.Fatal(err)
+			}
+
+			// freeing from f does not deadlock, the excluded ID is skipped
+			var visited []int64
+			idGenerator.ForEachAllocated(func(id int64) bool {
+				visited = append(visited, id)
+				if err := idGenerator.FreeID(id); err != nil {
+					t.Error(err)
+				}
+				if id == 2 {
+					if err := idGenerator.AllocateSpecific(10); err != nil {
+						t.Error(err)
+					}
+				}
+				return id != 8
+			})
+			expected := []int64{1, 2, 3, 5, 6, 7, 8}
+			if !reflect.DeepEqual(visited, expected) {
+				t.Errorf("expected visited ids: %v, output: %v", expected, visited)
+			}
+
+			// an ID allocated above the last one passed to f is reported
+			if err = idGenerator.FreeID(10); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(9); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(5); err != nil {
+				t.Fatal(err)
+			}
+			visited = nil
+			idGenerator.ForEachAllocated(func(id int64) bool {
+				visited = append(visited, id)
+				if id == 5 {
+					if err := idGenerator.AllocateSpecific(10); err != nil {
+						t.Error(err)
+					}
+				}
+				return true
+			})
+			expected = []int64{5, 9, 10}
+			if !reflect.DeepEqual(visited, expected) {
+				t.Errorf("expected visited ids: %v, output: %v", expected, visited)
+			}
+		})
+	}
+}
+
+func TestFreeMany(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			var freedByHook []int64
+			opts := append([]Option{WithOnFree(func(id int64) {
+				freedByHook = append(freedByHook, id)
+			})}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithExclusions(1, 20, []int64{6}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(1, 5); err != nil {
+				t.Fatal(err)
+			}
+
+			// the failures do not keep the other IDs from being freed
+			freed, errs := idGenerator.FreeMany([]int64{4, 0, 2, 6, 2, 10, 21, 5})
+			if freed != 3 {
+				t.Errorf("expected freed: 3, output: %d", freed)
+			}
+			expected := []error{ErrOutOfRange, ErrReserved, ErrNotAllocated, ErrNotAllocated, ErrOutOfRange}
+			if len(errs) != len(expected) {
+				t.Fatalf("expected errors: %v, output: %v", expected, errs)
+			}
+			for i, err := range errs {
+				if !errors.Is(err, expected[i]) {
+					t.Errorf("expected error %d wrapping %v, output %+v", i, expected[i], err)
+				}
+			}
+			if expected := []int64{4, 2, 5}; !reflect.DeepEqual(freedByHook, expected) {
+				t.Errorf("expected hook calls: %v, output: %v", expected, freedByHook)
+			}
+			if stats := idGenerator.Stats(); stats.Used != 2 || stats.Frees != 3 {
+				t.Errorf("unexpected stats: %#v", stats)
+			}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 3}) {
+				t.Errorf("unexpected allocated ids: %v", ids)
+			}
+
+			if freed, errs = idGenerator.FreeMany([]int64{1, 3}); freed != 2 || errs != nil {
+				t.Errorf("expected freed: 2 and no error, output: %d, %v", freed, errs)
+			}
+			if freed, errs = idGenerator.FreeMany(nil); freed != 0 || errs != nil {
+				t.Errorf("expected freed: 0 and no error, output: %d, %v", freed, errs)
+			}
+		})
+	}
+}
+
+func TestFreeRange(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			var freedByHook []int64
+			opts := append([]Option{WithOnFree(func(id int64) {
+				freedByHook = append(freedByHook, id)
+			})}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithExclusions(1, 20, []int64{6}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(1, 5); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(8, 12); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(20); err != nil {
+				t.Fatal(err)
+			}
+
+			// the free and the excluded IDs are skipped
+			freed, err := idGenerator.FreeRange(4, 9)
+			if err != nil || freed != 4 {
+				t.Fatalf("expected freed: 4, output: %d, %+v", freed, err)
+			}
+			if expected := []int64{4, 5, 8, 9}; !reflect.DeepEqual(freedByHook, expected) {
+				t.Errorf("expected hook calls: %v, output: %v", expected, freedByHook)
+			}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 2, 3, 10, 11, 12, 20}) {
+				t.Errorf("unexpected allocated ids: %v", ids)
+			}
+			if freed, err = idGenerator.FreeRange(4, 9); err != nil || freed != 0 {
+				t.Errorf("expected freed: 0, output: %d, %+v", freed, err)
+			}
+			// the upper bound is inclusive, also at maxValue
+			if freed, err = idGenerator.FreeRange(12, 20); err != nil || freed != 2 {
+				t.Errorf("expected freed: 2, output: %d, %+v", freed, err)
+			}
+			stats := idGenerator.Stats()
+			if stats.Used != 5 || stats.Frees != 6 {
+				t.Errorf("unexpected stats: %#v", stats)
+			}
+			if err = idGenerator.AllocateSpecific(6); !errors.Is(err, ErrReserved) {
+				t.Errorf("the excluded ID was freed: %+v", err)
+			}
+
+			// the bounds are rejected rather than clamped, nothing is freed then
+			for _, bounds := range [][2]int64{{0, 5}, {10, 21}, {3, 2}} {
+				if _, err = idGenerator.FreeRange(bounds[0], bounds[1]); err == nil {
+					t.Errorf("FreeRange(%d, %d) accepted", bounds[0], bounds[1])
+				}
+			}
+			if used := idGenerator.Used(); used != 5 {
+				t.Errorf("expected used: 5, output used: %d", used)
+			}
+		})
+	}
+}
+
+// BenchmarkReadWrite runs readers of IsAllocated, Used and Available against allocating writers,
+// the readers share the read lock
+func BenchmarkReadWrite(b *testing.B) {
+	for _, writeEvery := range []int{2, 10, 100} {
+		b.Run(fmt.Sprintf("1 write in %d", writeEvery), func(b *testing.B) {
+			idGenerator := NewGenerator(1, 1<<20)
+			allocateN(b, idGenerator, 1000)
+			b.RunParallel(func(pb *testing.PB) {
+				for i := 0; pb.Next(); i++ {
+					if i%writeEvery == 0 {
+						id, err := idGenerator.Allocate()
+						if err != nil {
+							b.Error(err)
+							return
+						}
+						if err = idGenerator.FreeID(id); err != nil {
+							b.Error(err)
+							return
+						}
+						continue
+					}
+					if !idGenerator.IsAllocated(int64(i%1000) + 1) {
+						b.Errorf("ID %d not allocated", i%1000+1)
+						return
+					}
+					// the writers hold a few more IDs meanwhile
+					if used, available := idGenerator.Used(), idGenerator.Available(); used < 1000 || available > 1<<20-1000 {
+						b.Errorf("unexpected %d used and %d available", used, available)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func TestBoundsAccessors(t *testing.T) {
+	idGenerator, err := NewGeneratorWithExclusions(10, 19, []int64{12, 13})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minValue, maxValue, capacity := idGenerator.MinValue(), idGenerator.MaxValue(),
+		idGenerator.Capacity(); minValue != 10 || maxValue != 19 || capacity != 8 {
+		t.Errorf("expected [10, 19] of capacity 8, output [%d, %d] of %d", minValue, maxValue, capacity)
+	}
+	allocateN(t, idGenerator, 3)
+	for id, expected := range map[int64]bool{9: false, 10: true, 11: true, 12: false, 13: false, 19: true, 20: false} {
+		if contains := idGenerator.Contains(id); contains != expected {
+			t.Errorf("Contains(%d): expected %v, output %v", id, expected, contains)
+		}
+	}
+
+	if err = idGenerator.Resize(10, 29); err != nil {
+		t.Fatal(err)
+	}
+	if maxValue, capacity := idGenerator.MaxValue(), idGenerator.Capacity(); maxValue != 29 || capacity != 18 {
+		t.Errorf("expected the resized range [10, 29] of capacity 18, output max %d and %d", maxValue, capacity)
+	}
+	if !idGenerator.Contains(25) {
+		t.Error("expected 25 in the resized range")
+	}
+
+	full := NewGenerator(math.MinInt64, math.MaxInt64)
+	if capacity := full.Capacity(); capacity != math.MaxInt64 {
+		t.Errorf("expected the capacity of the full range capped at math.MaxInt64, output %d", capacity)
+	}
+	if !full.Contains(math.MinInt64) || !full.Contains(math.MaxInt64) {
+		t.Error("expected the full range to contain its bounds")
+	}
+}
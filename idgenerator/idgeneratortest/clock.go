@@ -0,0 +1,92 @@
+package idgeneratortest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// FakeClock is an idgenerator.Clock which only moves when told to, for WithClock and the Clock of
+// SnowflakeConfig, so that the leases, quarantines, hold times, rate limits and the other time-based features
+// are tested without sleeping, e.g.
+//
+//	clock := idgeneratortest.NewFakeClock(time.Now())
+//	generator, _ := idgenerator.NewGeneratorWithOptions(1, 100, idgenerator.WithClock(clock))
+//	id, _ := generator.AllocateLease(time.Minute)
+//	clock.Advance(time.Minute)
+//	... id has expired ...
+//
+// It is safe for concurrent use.
+type FakeClock struct {
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []fakeTimer
+}
+
+// fakeTimer is a channel of After waiting for the clock to reach at
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+// NewFakeClock returns a FakeClock at now
+func NewFakeClock(now time.Time) *FakeClock {
+	c := &FakeClock{now: now}
+	c.cond = sync.NewCond(&c.mtx)
+	return c
+}
+
+// Now returns the time of the clock
+func (c *FakeClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+// After returns a channel receiving its deadline once Advance has moved the clock d past now,
+// or at once if d is 0 or below
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer.c
+	}
+	c.timers = append(c.timers, timer)
+	c.cond.Broadcast()
+	return timer.c
+}
+
+// Advance moves the clock by d, which may be negative, and fires the timers of After due by then
+// in the order of their deadlines before it returns
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+	due := 0
+	for due < len(c.timers) && !c.timers[due].at.After(c.now) {
+		c.timers[due].c <- c.timers[due].at
+		due++
+	}
+	c.timers = c.timers[due:]
+}
+
+// Timers returns the number of timers of After waiting for Advance
+func (c *FakeClock) Timers() int {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return len(c.timers)
+}
+
+// WaitForTimers blocks until n timers of After are waiting for Advance, e.g. until a goroutine under test
+// waits for the clock before moving it
+func (c *FakeClock) WaitForTimers(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
@@ -0,0 +1,79 @@
+package idgeneratortest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+var _ idgenerator.Clock = (*FakeClock)(nil)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+	late, early := clock.After(2*time.Second), clock.After(time.Second)
+	select {
+	case <-clock.After(0):
+	default:
+		t.Fatal("expected After(0) to fire at once")
+	}
+	if n := clock.Timers(); n != 2 {
+		t.Fatalf("expected 2 timers, output %d", n)
+	}
+
+	clock.Advance(1500 * time.Millisecond)
+	select {
+	case at := <-early:
+		if !at.Equal(start.Add(time.Second)) {
+			t.Errorf("expected the deadline, output %v", at)
+		}
+	default:
+		t.Fatal("expected the due timer fired")
+	}
+	select {
+	case <-late:
+		t.Fatal("timer fired before its deadline")
+	default:
+	}
+	clock.Advance(time.Second)
+	if at := <-late; !at.Equal(start.Add(2 * time.Second)) {
+		t.Errorf("expected the deadline, output %v", at)
+	}
+	if now := clock.Now(); !now.Equal(start.Add(2500 * time.Millisecond)) {
+		t.Errorf("expected %v, output %v", start.Add(2500*time.Millisecond), now)
+	}
+}
+
+func TestFakeClockGenerator(t *testing.T) {
+	clock := NewFakeClock(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+	generator, err := idgenerator.NewGeneratorWithOptions(1, 10, idgenerator.WithClock(clock),
+		idgenerator.WithRateLimit(1, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := generator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = generator.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the token is taken, AllocateCtx waits a second of the clock for the next one
+	allocated := make(chan error, 1)
+	go func() {
+		_, err := generator.AllocateCtx(context.Background())
+		allocated <- err
+	}()
+	clock.WaitForTimers(1)
+	clock.Advance(time.Minute)
+	if err = <-allocated; err != nil {
+		t.Fatal(err)
+	}
+	if err = generator.Renew(id, time.Minute); !errors.Is(err, idgenerator.ErrLeaseExpired) {
+		t.Errorf("expected the lease expired, got %+v", err)
+	}
+}
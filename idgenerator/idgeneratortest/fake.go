@@ -0,0 +1,206 @@
+// idgeneratortest provides a scriptable idgenerator.Allocator for the tests of the callers of the generators, e.g.
+//
+//	teids := idgeneratortest.NewFakeAllocator(1, 100)
+//	teids.OnAllocate(idgeneratortest.Result{Err: idgenerator.ErrPoolExhausted})
+//	... run the code under test with teids ...
+//	teids.ExpectCalls(t, idgeneratortest.Call{Method: "Allocate"})
+//
+// and a FakeClock moving the time of the generators when told to.
+package idgeneratortest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// Call is a call made to a FakeAllocator. Method is the name of the method, ID its argument,
+// the ID returned for Allocate or 0 for Used, and Err the error it returned.
+type Call struct {
+	Method string
+	ID     int64
+	Err    error
+}
+
+func (c Call) String() string {
+	if c.Err != nil {
+		return fmt.Sprintf("%s(%d): %v", c.Method, c.ID, c.Err)
+	}
+	return fmt.Sprintf("%s(%d)", c.Method, c.ID)
+}
+
+// Result is a scripted result of Allocate, the ID it returns if Err is nil
+type Result struct {
+	ID  int64
+	Err error
+}
+
+// FakeAllocator is an idgenerator.Allocator which records its calls and returns scripted results.
+// The calls without a scripted result left are served by an IDGenerator of the range of the fake,
+// so that a fake behaves like the real generator unless told otherwise.
+// It is safe for concurrent use.
+type FakeAllocator struct {
+	mu        sync.Mutex
+	generator *idgenerator.IDGenerator
+	// extra are the IDs allocated by scripted results which the generator cannot hold, e.g. out of its range
+	extra map[int64]struct{}
+
+	allocates         []Result
+	allocateSpecifics []error
+	freeIDs           []error
+	calls             []Call
+}
+
+// NewFakeAllocator returns a FakeAllocator serving the calls without a scripted result from [minValue, maxValue]
+func NewFakeAllocator(minValue, maxValue int64) *FakeAllocator {
+	return &FakeAllocator{
+		generator: idgenerator.NewGenerator(minValue, maxValue),
+		extra:     make(map[int64]struct{}),
+	}
+}
+
+// OnAllocate scripts the results of the next calls of Allocate, one each, in order.
+// An ID returned without error is allocated, so that it may be freed and is counted by Used.
+func (f *FakeAllocator) OnAllocate(results ...Result) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allocates = append(f.allocates, results...)
+}
+
+// OnAllocateSpecific scripts the errors of the next calls of AllocateSpecific, one each, in order.
+// A nil error allocates the ID of the call.
+func (f *FakeAllocator) OnAllocateSpecific(errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.allocateSpecifics = append(f.allocateSpecifics, errs...)
+}
+
+// OnFreeID scripts the errors of the next calls of FreeID, one each, in order. A nil error frees the ID of the call.
+func (f *FakeAllocator) OnFreeID(errs ...error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.freeIDs = append(f.freeIDs, errs...)
+}
+
+// Calls returns the calls made to the fake so far, in order
+func (f *FakeAllocator) Calls() []Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Call(nil), f.calls...)
+}
+
+// ExpectCalls fails t unless the calls made to the fake so far are expected, in order.
+// The error of a call matches that of expected if it wraps it, a nil expected error matches no error only.
+func (f *FakeAllocator) ExpectCalls(t testing.TB, expected ...Call) {
+	t.Helper()
+	calls := f.Calls()
+	matches := len(calls) == len(expected)
+	for i := 0; matches && i < len(calls); i++ {
+		matches = calls[i].Method == expected[i].Method && calls[i].ID == expected[i].ID &&
+			(calls[i].Err == nil) == (expected[i].Err == nil) && errors.Is(calls[i].Err, expected[i].Err)
+	}
+	if !matches {
+		t.Errorf("expected calls %v, output calls %v", expected, calls)
+	}
+}
+
+func (f *FakeAllocator) record(method string, id int64, err error) {
+	f.calls = append(f.calls, Call{Method: method, ID: id, Err: err})
+}
+
+// Allocate returns the next result of OnAllocate, or allocates an ID of the range of the fake
+func (f *FakeAllocator) Allocate() (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var result Result
+	if len(f.allocates) > 0 {
+		result, f.allocates = f.allocates[0], f.allocates[1:]
+		if result.Err == nil {
+			f.markUsed(result.ID)
+		}
+	} else {
+		result.ID, result.Err = f.generator.Allocate()
+	}
+	f.record("Allocate", result.ID, result.Err)
+	return result.ID, result.Err
+}
+
+// AllocateSpecific returns the next error of OnAllocateSpecific, or allocates exactly id like IDGenerator
+func (f *FakeAllocator) AllocateSpecific(id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var err error
+	if len(f.allocateSpecifics) > 0 {
+		err, f.allocateSpecifics = f.allocateSpecifics[0], f.allocateSpecifics[1:]
+		if err == nil {
+			f.markUsed(id)
+		}
+	} else {
+		err = f.generator.AllocateSpecific(id)
+	}
+	f.record("AllocateSpecific", id, err)
+	return err
+}
+
+// FreeID returns the next error of OnFreeID, or frees id like IDGenerator
+func (f *FakeAllocator) FreeID(id int64) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var err error
+	if len(f.freeIDs) > 0 {
+		err, f.freeIDs = f.freeIDs[0], f.freeIDs[1:]
+		if err == nil {
+			f.markFree(id)
+		}
+	} else if _, ok := f.extra[id]; ok {
+		delete(f.extra, id)
+	} else {
+		err = f.generator.FreeID(id)
+	}
+	f.record("FreeID", id, err)
+	return err
+}
+
+// IsAllocated reports whether id is allocated, by the range of the fake or by a scripted result
+func (f *FakeAllocator) IsAllocated(id int64) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("IsAllocated", id, nil)
+	_, ok := f.extra[id]
+	return ok || f.generator.IsAllocated(id)
+}
+
+// Used returns the number of allocated IDs, by the range of the fake or by scripted results
+func (f *FakeAllocator) Used() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.record("Used", 0, nil)
+	return f.generator.Used() + int64(len(f.extra))
+}
+
+// markUsed allocates an id returned by a scripted result, the caller must hold mu
+func (f *FakeAllocator) markUsed(id int64) {
+	if f.generator.IsAllocated(id) {
+		return
+	}
+	if err := f.generator.AllocateSpecific(id); err != nil {
+		f.extra[id] = struct{}{}
+	}
+}
+
+// markFree frees an id freed by a scripted result, the caller must hold mu
+func (f *FakeAllocator) markFree(id int64) {
+	if _, ok := f.extra[id]; ok {
+		delete(f.extra, id)
+		return
+	}
+	if err := f.generator.FreeID(id); err != nil {
+		// not allocated, there is nothing to free
+		return
+	}
+}
+
+var _ idgenerator.Allocator = (*FakeAllocator)(nil)
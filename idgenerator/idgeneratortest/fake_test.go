@@ -0,0 +1,106 @@
+package idgeneratortest
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+func TestFakeAllocator(t *testing.T) {
+	fake := NewFakeAllocator(1, 2)
+	fake.OnAllocate(Result{ID: 42}, Result{Err: idgenerator.ErrPoolExhausted})
+	if id, err := fake.Allocate(); err != nil || id != 42 {
+		t.Fatalf("expected scripted ID 42, output %d, %+v", id, err)
+	}
+	if _, err := fake.Allocate(); !errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Fatalf("expected scripted ErrPoolExhausted, got %+v", err)
+	}
+	// the script is over, the range serves the calls
+	for expected := int64(1); expected <= 2; expected++ {
+		if id, err := fake.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if _, err := fake.Allocate(); !errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if used := fake.Used(); used != 3 {
+		t.Errorf("expected 3 used, output %d", used)
+	}
+	if !fake.IsAllocated(42) {
+		t.Error("expected the scripted ID 42 allocated")
+	}
+	if err := fake.FreeID(42); err != nil {
+		t.Error(err)
+	}
+
+	fake.OnAllocateSpecific(idgenerator.ErrReserved, nil)
+	errBoom := errors.New("boom")
+	fake.OnFreeID(errBoom)
+	if err := fake.AllocateSpecific(7); !errors.Is(err, idgenerator.ErrReserved) {
+		t.Errorf("expected scripted ErrReserved, got %+v", err)
+	}
+	if err := fake.AllocateSpecific(7); err != nil {
+		t.Error(err)
+	}
+	if err := fake.FreeID(7); !errors.Is(err, errBoom) {
+		t.Errorf("expected the scripted error, got %+v", err)
+	}
+	if err := fake.FreeID(7); err != nil {
+		t.Error(err)
+	}
+	if err := fake.FreeID(7); !errors.Is(err, idgenerator.ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+
+	fake.ExpectCalls(t,
+		Call{Method: "Allocate", ID: 42},
+		Call{Method: "Allocate", Err: idgenerator.ErrPoolExhausted},
+		Call{Method: "Allocate", ID: 1},
+		Call{Method: "Allocate", ID: 2},
+		Call{Method: "Allocate", Err: idgenerator.ErrPoolExhausted},
+		Call{Method: "Used"},
+		Call{Method: "IsAllocated", ID: 42},
+		Call{Method: "FreeID", ID: 42},
+		Call{Method: "AllocateSpecific", ID: 7, Err: idgenerator.ErrReserved},
+		Call{Method: "AllocateSpecific", ID: 7},
+		Call{Method: "FreeID", ID: 7, Err: errBoom},
+		Call{Method: "FreeID", ID: 7},
+		Call{Method: "FreeID", ID: 7, Err: idgenerator.ErrOutOfRange},
+	)
+}
+
+// failRecorder records the failure of ExpectCalls instead of failing the test
+type failRecorder struct {
+	testing.TB
+	failed bool
+}
+
+func (r *failRecorder) Errorf(string, ...interface{}) {
+	r.failed = true
+}
+
+func TestExpectCalls(t *testing.T) {
+	fake := NewFakeAllocator(1, 10)
+	if _, err := fake.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		expected []Call
+		fails    bool
+	}{
+		{[]Call{{Method: "Allocate", ID: 1}}, false},
+		{nil, true},
+		{[]Call{{Method: "Allocate", ID: 2}}, true},
+		{[]Call{{Method: "Allocate", ID: 1, Err: idgenerator.ErrPoolExhausted}}, true},
+		{[]Call{{Method: "Allocate", ID: 1}, {Method: "Used"}}, true},
+	}
+	for _, testCase := range testCases {
+		recorder := &failRecorder{TB: t}
+		fake.ExpectCalls(recorder, testCase.expected...)
+		if recorder.failed != testCase.fails {
+			t.Errorf("%v: expected failed %v, output %v", testCase.expected, testCase.fails, recorder.failed)
+		}
+	}
+}
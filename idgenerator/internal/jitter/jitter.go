@@ -0,0 +1,23 @@
+// jitter draws the random waits of the backoffs of idgenerator and its backends,
+// so that the callers backing off from the same failure do not retry in lockstep.
+package jitter
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// source is seeded so that processes started together draw apart
+var source = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// Draw returns a wait drawn at random in [delay/2, delay]
+func Draw(delay time.Duration) time.Duration {
+	half := delay / 2
+	source.Lock()
+	defer source.Unlock()
+	return delay - half + time.Duration(source.Int63n(int64(half)+1))
+}
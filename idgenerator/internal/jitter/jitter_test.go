@@ -0,0 +1,21 @@
+package jitter
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestDraw(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		if wait := Draw(time.Millisecond); wait < time.Millisecond/2 || wait > time.Millisecond {
+			t.Fatalf("expected a wait in [500µs, 1ms], output %v", wait)
+		}
+	}
+	if wait := Draw(math.MaxInt64); wait < math.MaxInt64/2 {
+		t.Errorf("expected a wait of at least half of the delay, output %v", wait)
+	}
+	if wait := Draw(0); wait != 0 {
+		t.Errorf("expected no wait for no delay, output %v", wait)
+	}
+}
@@ -0,0 +1,162 @@
+package idgenerator
+
+import "sort"
+
+// freeInterval is an inclusive range of free offsets
+type freeInterval struct {
+	start uint64
+	end   uint64
+}
+
+// intervalStore is a Store keeping the free offsets as a sorted list of
+// disjoint, non-adjacent intervals. Its memory grows with the number of fragments,
+// not with the size of the range, and lookups are O(log n) in the number of fragments.
+type intervalStore struct {
+	last uint64
+	free []freeInterval
+}
+
+// NewIntervalStore returns the Store of WithIntervalStore for the offsets in [0, last]
+func NewIntervalStore(last uint64) (Store, error) {
+	s := &intervalStore{last: last}
+	s.Reset()
+	return s, nil
+}
+
+// search returns the index of the first interval whose end is >= offset
+func (s *intervalStore) search(offset uint64) int {
+	return sort.Search(len(s.free), func(i int) bool {
+		return s.free[i].end >= offset
+	})
+}
+
+func (s *intervalStore) Has(offset uint64) bool {
+	i := s.search(offset)
+	return i == len(s.free) || s.free[i].start > offset
+}
+
+func (s *intervalStore) Set(offset uint64) {
+	i := s.search(offset)
+	if i == len(s.free) || s.free[i].start > offset {
+		return
+	}
+	interval := &s.free[i]
+	switch {
+	case interval.start == interval.end:
+		s.free = append(s.free[:i], s.free[i+1:]...)
+	case interval.start == offset:
+		interval.start++
+	case interval.end == offset:
+		interval.end--
+	default:
+		// split [start, end] into [start, offset-1] and [offset+1, end]
+		s.free = append(s.free, freeInterval{})
+		copy(s.free[i+1:], s.free[i:])
+		s.free[i].end = offset - 1
+		s.free[i+1].start = offset + 1
+	}
+}
+
+// SetRange drops [first, last] from the free intervals, taking a step per interval it overlaps
+func (s *intervalStore) SetRange(first, last uint64) {
+	i := s.search(first)
+	j := i
+	var kept []freeInterval
+	for ; j < len(s.free) && s.free[j].start <= last; j++ {
+		if s.free[j].start < first {
+			kept = append(kept, freeInterval{start: s.free[j].start, end: first - 1})
+		}
+		if s.free[j].end > last {
+			kept = append(kept, freeInterval{start: last + 1, end: s.free[j].end})
+		}
+	}
+	s.free = append(s.free[:i], append(kept, s.free[j:]...)...)
+}
+
+func (s *intervalStore) Clear(offset uint64) {
+	i := s.search(offset)
+	if i < len(s.free) && s.free[i].start <= offset {
+		return
+	}
+	mergePrev := i > 0 && s.free[i-1].end == offset-1
+	mergeNext := i < len(s.free) && s.free[i].start == offset+1
+	switch {
+	case mergePrev && mergeNext:
+		s.free[i-1].end = s.free[i].end
+		s.free = append(s.free[:i], s.free[i+1:]...)
+	case mergePrev:
+		s.free[i-1].end = offset
+	case mergeNext:
+		s.free[i].start = offset
+	default:
+		s.free = append(s.free, freeInterval{})
+		copy(s.free[i+1:], s.free[i:])
+		s.free[i] = freeInterval{start: offset, end: offset}
+	}
+}
+
+func (s *intervalStore) NextClear(from uint64) (uint64, bool) {
+	i := s.search(from)
+	if i == len(s.free) {
+		return 0, false
+	}
+	if start := s.free[i].start; start > from {
+		return start, true
+	}
+	return from, true
+}
+
+func (s *intervalStore) NextSet(from uint64) (uint64, bool) {
+	i := s.search(from)
+	if i == len(s.free) || s.free[i].start > from {
+		// from itself is set, or everything from it to the end is
+		if from <= s.last {
+			return from, true
+		}
+		return 0, false
+	}
+	if end := s.free[i].end; end < s.last {
+		return end + 1, true
+	}
+	return 0, false
+}
+
+func (s *intervalStore) NthClear(n uint64) uint64 {
+	i := 0
+	for ; n > s.free[i].end-s.free[i].start; i++ {
+		n -= s.free[i].end - s.free[i].start + 1
+	}
+	return s.free[i].start + n
+}
+
+func (s *intervalStore) SetOffsets() []uint64 {
+	var offsets []uint64
+	next := uint64(0)
+	for _, interval := range s.free {
+		for offset := next; offset < interval.start; offset++ {
+			offsets = append(offsets, offset)
+		}
+		if interval.end == s.last {
+			return offsets
+		}
+		next = interval.end + 1
+	}
+	for offset := next; offset <= s.last; offset++ {
+		offsets = append(offsets, offset)
+		if offset == s.last {
+			break
+		}
+	}
+	return offsets
+}
+
+func (s *intervalStore) Reset() {
+	s.free = append(s.free[:0], freeInterval{start: 0, end: s.last})
+}
+
+func (s *intervalStore) Clone() Store {
+	return &intervalStore{
+		last: s.last,
+		free: append([]freeInterval(nil), s.free...),
+	}
+}
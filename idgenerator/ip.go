@@ -0,0 +1,225 @@
+package idgenerator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+)
+
+// IPAllocator allocates the addresses of an IPv4 network, or of an IPv6 network of prefix /64 or longer,
+// e.g. for UE IP addresses. It maps each address to an ID of an IDGenerator,
+// so it is safe for concurrent use and allocates like an IDGenerator.
+// The first and last address of the network, the network and broadcast addresses in IPv4, are never allocated,
+// except in the /31 and /32 networks of IPv4 and the /127 and /128 networks of IPv6 which have no others.
+type IPAllocator struct {
+	network *net.IPNet
+	// hostBits is the number of bits of the host part, at most 32 in IPv4 and 64 in IPv6
+	hostBits  int
+	generator *IDGenerator
+
+	generatorOpts []Option
+	reserved      [][2]net.IP
+}
+
+// IPAllocatorOption configures an IPAllocator
+type IPAllocatorOption func(*IPAllocator)
+
+// WithIPGeneratorOptions sets the options of the underlying IDGenerator,
+// which keeps its state in the interval store unless they select another one
+func WithIPGeneratorOptions(opts ...Option) IPAllocatorOption {
+	return func(a *IPAllocator) {
+		a.generatorOpts = append(a.generatorOpts, opts...)
+	}
+}
+
+// WithReservedRange reserves the addresses from first to last at construction like ReserveRange,
+// e.g. for statically configured hosts
+func WithReservedRange(first, last net.IP) IPAllocatorOption {
+	return func(a *IPAllocator) {
+		a.reserved = append(a.reserved, [2]net.IP{first, last})
+	}
+}
+
+// NewIPAllocator initializes an IPAllocator for network and applies opts to it.
+// It returns an error wrapping ErrInvalidRange if network is an IPv6 network with a prefix shorter than /64,
+// or fails like ReserveRange for the ranges of WithReservedRange.
+func NewIPAllocator(network *net.IPNet, opts ...IPAllocatorOption) (*IPAllocator, error) {
+	ones, bits := network.Mask.Size()
+	ip := network.IP.To4()
+	if bits == 8*net.IPv6len {
+		ip = network.IP.To16()
+	}
+	if ip == nil || bits == 0 || len(ip)*8 != bits {
+		return nil, fmt.Errorf("%w: invalid network %v", ErrInvalidRange, network)
+	}
+	if bits-ones > 64 {
+		return nil, fmt.Errorf("%w: network %v has more than 2^64 addresses", ErrInvalidRange, network)
+	}
+	a := &IPAllocator{
+		network:  &net.IPNet{IP: ip.Mask(network.Mask), Mask: network.Mask},
+		hostBits: bits - ones,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+
+	// the shift by 64 of a /32 or /128 network gives 0
+	first, last := uint64(0), ^uint64(0)>>(64-a.hostBits)
+	if a.hostBits > 1 {
+		first++
+		last--
+	}
+	generator, err := NewGeneratorWithOptions(a.hostToID(first), a.hostToID(last),
+		append([]Option{WithIntervalStore()}, a.generatorOpts...)...)
+	if err != nil {
+		return nil, err
+	}
+	a.generator = generator
+	for _, reserved := range a.reserved {
+		if err = a.ReserveRange(reserved[0], reserved[1]); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// hostToID maps the host part of an address to an ID, which is the host part itself
+// unless the network is an IPv6 /64: its 2^64 hosts are fit into int64 by flipping the top bit, keeping the order
+func (a *IPAllocator) hostToID(host uint64) int64 {
+	if a.hostBits == 64 {
+		return int64(host ^ 1<<63)
+	}
+	return int64(host)
+}
+
+func (a *IPAllocator) idToHost(id int64) uint64 {
+	if a.hostBits == 64 {
+		return uint64(id) ^ 1<<63
+	}
+	return uint64(id)
+}
+
+// toID returns the ID of ip, or an error wrapping ErrOutOfRange if ip is not in the network
+// and ErrReserved if it is its first or last address
+func (a *IPAllocator) toID(ip net.IP) (int64, error) {
+	var addr net.IP
+	if len(a.network.IP) == net.IPv4len {
+		addr = ip.To4()
+	} else if ip.To4() == nil {
+		// an IPv4 address is in no IPv6 network, not even in ::ffff:0:0/96
+		addr = ip.To16()
+	}
+	if addr == nil || !a.network.Contains(addr) {
+		return 0, fmt.Errorf("%w: %v not in %v", ErrOutOfRange, ip, a.network)
+	}
+	var host uint64
+	if len(addr) == net.IPv4len {
+		host = uint64(binary.BigEndian.Uint32(addr))
+	} else {
+		host = binary.BigEndian.Uint64(addr[8:])
+	}
+	if a.hostBits < 64 {
+		host &= 1<<a.hostBits - 1
+	}
+	id := a.hostToID(host)
+	if !a.generator.inRange(id) {
+		return 0, fmt.Errorf("%w: %v is the first or last address of %v", ErrReserved, ip, a.network)
+	}
+	return id, nil
+}
+
+func (a *IPAllocator) toIP(id int64) net.IP {
+	host := a.idToHost(id)
+	ip := make(net.IP, len(a.network.IP))
+	copy(ip, a.network.IP)
+	if len(ip) == net.IPv4len {
+		binary.BigEndian.PutUint32(ip, binary.BigEndian.Uint32(ip)|uint32(host))
+	} else {
+		binary.BigEndian.PutUint64(ip[8:], binary.BigEndian.Uint64(ip[8:])|host)
+	}
+	return ip
+}
+
+// Network returns the network the addresses are allocated in
+func (a *IPAllocator) Network() *net.IPNet {
+	return &net.IPNet{IP: append(net.IP(nil), a.network.IP...), Mask: append(net.IPMask(nil), a.network.Mask...)}
+}
+
+// Allocate allocates a free address of the network like IDGenerator.Allocate,
+// the address has 4 bytes in IPv4 and 16 in IPv6
+func (a *IPAllocator) Allocate() (net.IP, error) {
+	id, err := a.generator.Allocate()
+	if err != nil {
+		return nil, err
+	}
+	return a.toIP(id), nil
+}
+
+// AllocateSpecific allocates exactly ip like IDGenerator.AllocateSpecific.
+// It returns an error wrapping ErrOutOfRange if ip is not in the network,
+// or ErrReserved if ip is its first or last address.
+func (a *IPAllocator) AllocateSpecific(ip net.IP) error {
+	id, err := a.toID(ip)
+	if err != nil {
+		return err
+	}
+	if err = a.generator.AllocateSpecific(id); err != nil {
+		return fmt.Errorf("%v: %w", ip, err)
+	}
+	return nil
+}
+
+// ReserveRange allocates every address from first to last like IDGenerator.ReserveRange,
+// they are released with Free. It fails like AllocateSpecific for first and last.
+func (a *IPAllocator) ReserveRange(first, last net.IP) error {
+	start, err := a.toID(first)
+	if err != nil {
+		return err
+	}
+	end, err := a.toID(last)
+	if err != nil {
+		return err
+	}
+	if err = a.generator.ReserveRange(start, end); err != nil {
+		return fmt.Errorf("%v-%v: %w", first, last, err)
+	}
+	return nil
+}
+
+// Free releases ip like IDGenerator.FreeID, it fails like AllocateSpecific if ip cannot be allocated
+func (a *IPAllocator) Free(ip net.IP) error {
+	id, err := a.toID(ip)
+	if err != nil {
+		return err
+	}
+	if err = a.generator.FreeID(id); err != nil {
+		return fmt.Errorf("%v: %w", ip, err)
+	}
+	return nil
+}
+
+// IsAllocated reports whether ip is allocated
+func (a *IPAllocator) IsAllocated(ip net.IP) bool {
+	id, err := a.toID(ip)
+	return err == nil && a.generator.IsAllocated(id)
+}
+
+// Used returns the number of allocated addresses
+func (a *IPAllocator) Used() int64 {
+	return a.generator.Used()
+}
+
+// Available returns the number of addresses that can still be allocated, capped at math.MaxInt64
+func (a *IPAllocator) Available() int64 {
+	return a.generator.Available()
+}
+
+// Stats returns the counters of the underlying generator
+func (a *IPAllocator) Stats() Stats {
+	return a.generator.Stats()
+}
+
+// DetailedStats returns the DetailedStats of the underlying generator
+func (a *IPAllocator) DetailedStats() Stats {
+	return a.generator.DetailedStats()
+}
@@ -0,0 +1,195 @@
+package idgenerator
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func mustParseCIDR(t *testing.T, cidr string) *net.IPNet {
+	t.Helper()
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return network
+}
+
+func TestIPAllocatorRoundTrip(t *testing.T) {
+	testCases := []struct {
+		cidr  string
+		first string
+		last  string
+		size  int64
+	}{
+		{"192.168.0.4/30", "192.168.0.5", "192.168.0.6", 2},
+		{"10.60.0.0/24", "10.60.0.1", "10.60.0.254", 254},
+		{"10.60.3.7/16", "10.60.0.1", "10.60.255.254", 65534},
+		{"192.168.0.2/31", "192.168.0.2", "192.168.0.3", 2},
+		{"192.168.0.9/32", "192.168.0.9", "192.168.0.9", 1},
+		{"2001:db8::/120", "2001:db8::1", "2001:db8::fe", 254},
+		{"2001:db8:1:2::/127", "2001:db8:1:2::", "2001:db8:1:2::1", 2},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.cidr, func(t *testing.T) {
+			allocator, err := NewIPAllocator(mustParseCIDR(t, testCase.cidr))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if available := allocator.Available(); available != testCase.size {
+				t.Fatalf("expected available: %d, output available: %d", testCase.size, available)
+			}
+			ip, err := allocator.Allocate()
+			if err != nil || ip.String() != testCase.first {
+				t.Errorf("expected first ip: %s, output: %v, %+v", testCase.first, ip, err)
+			}
+			last := net.ParseIP(testCase.last)
+			if testCase.size == 1 {
+				if err = allocator.Free(last); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err = allocator.AllocateSpecific(last); err != nil {
+				t.Fatal(err)
+			}
+			if !allocator.IsAllocated(last) {
+				t.Errorf("%v not reported allocated", last)
+			}
+			if err = allocator.AllocateSpecific(last); !errors.Is(err, ErrAlreadyAllocated) {
+				t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+			}
+			if err = allocator.Free(last); err != nil {
+				t.Fatal(err)
+			}
+			if err = allocator.Free(last); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("expected ErrNotAllocated, got %+v", err)
+			}
+
+			// every address comes back unchanged through the conversions
+			allocated := make(map[string]bool)
+			if testCase.size > 1 {
+				allocated[ip.String()] = true
+			}
+			for allocator.Available() > 0 && len(allocated) < 1000 {
+				ip, err := allocator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if allocated[ip.String()] || !allocator.IsAllocated(ip) {
+					t.Fatalf("unexpected ip: %v", ip)
+				}
+				allocated[ip.String()] = true
+			}
+			if testCase.size <= 1000 {
+				if !allocated[testCase.last] {
+					t.Errorf("last ip %s not allocated", testCase.last)
+				}
+				if _, err = allocator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+					t.Errorf("expected ErrPoolExhausted, got %+v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestIPAllocatorRejects(t *testing.T) {
+	allocator, err := NewIPAllocator(mustParseCIDR(t, "10.60.0.0/24"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCases := []struct {
+		ip       net.IP
+		expected error
+	}{
+		{net.ParseIP("10.60.0.0"), ErrReserved},
+		{net.ParseIP("10.60.0.255"), ErrReserved},
+		{net.ParseIP("10.60.1.1"), ErrOutOfRange},
+		{net.ParseIP("10.59.255.255"), ErrOutOfRange},
+		{net.ParseIP("2001:db8::1"), ErrOutOfRange},
+		{nil, ErrOutOfRange},
+	}
+	for _, testCase := range testCases {
+		if err = allocator.AllocateSpecific(testCase.ip); !errors.Is(err, testCase.expected) {
+			t.Errorf("AllocateSpecific(%v): expected %v, got %+v", testCase.ip, testCase.expected, err)
+		}
+		if err = allocator.Free(testCase.ip); !errors.Is(err, testCase.expected) {
+			t.Errorf("Free(%v): expected %v, got %+v", testCase.ip, testCase.expected, err)
+		}
+		if allocator.IsAllocated(testCase.ip) {
+			t.Errorf("%v reported allocated", testCase.ip)
+		}
+	}
+
+	v6, err := NewIPAllocator(mustParseCIDR(t, "2001:db8::/64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = v6.AllocateSpecific(net.ParseIP("10.60.0.1")); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	for _, cidr := range []string{"2001:db8::/63", "::/0"} {
+		if _, err = NewIPAllocator(mustParseCIDR(t, cidr)); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%s: expected ErrInvalidRange, got %+v", cidr, err)
+		}
+	}
+	if _, err = NewIPAllocator(&net.IPNet{IP: net.ParseIP("10.0.0.0")}); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for a network without mask, got %+v", err)
+	}
+}
+
+func TestIPAllocatorIPv6Slash64(t *testing.T) {
+	allocator, err := NewIPAllocator(mustParseCIDR(t, "2001:db8:0:1::/64"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{"2001:db8:0:1::1", "2001:db8:0:1::2"} {
+		if ip, err := allocator.Allocate(); err != nil || ip.String() != expected {
+			t.Errorf("expected ip: %s, output: %v, %+v", expected, ip, err)
+		}
+	}
+	last := net.ParseIP("2001:db8:0:1:ffff:ffff:ffff:fffe")
+	if err = allocator.AllocateSpecific(last); err != nil {
+		t.Fatal(err)
+	}
+	if err = allocator.AllocateSpecific(net.ParseIP("2001:db8:0:1:ffff:ffff:ffff:ffff")); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	if stats := allocator.Stats(); stats.Used != 3 || stats.Capacity != 1<<64-2 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestIPAllocatorReservedRange(t *testing.T) {
+	network := mustParseCIDR(t, "10.60.0.0/24")
+	allocator, err := NewIPAllocator(network,
+		WithReservedRange(net.ParseIP("10.60.0.1"), net.ParseIP("10.60.0.10")),
+		WithIPGeneratorOptions(WithLowestFreeAllocation()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := allocator.Used(); used != 10 {
+		t.Errorf("expected used: 10, output used: %d", used)
+	}
+	if ip, err := allocator.Allocate(); err != nil || ip.String() != "10.60.0.11" {
+		t.Errorf("expected ip: 10.60.0.11, output: %v, %+v", ip, err)
+	}
+	// the reserved addresses are released like any other
+	if err = allocator.Free(net.ParseIP("10.60.0.3")); err != nil {
+		t.Fatal(err)
+	}
+	if ip, err := allocator.Allocate(); err != nil || ip.String() != "10.60.0.3" {
+		t.Errorf("expected ip: 10.60.0.3, output: %v, %+v", ip, err)
+	}
+	if allocator.Network().String() != "10.60.0.0/24" {
+		t.Errorf("unexpected network: %v", allocator.Network())
+	}
+
+	invalid := [][2]string{{"10.60.0.0", "10.60.0.5"}, {"10.60.0.5", "10.60.1.5"}, {"10.60.0.9", "10.60.0.8"}}
+	for i, reserved := range invalid {
+		_, err = NewIPAllocator(network, WithReservedRange(net.ParseIP(reserved[0]), net.ParseIP(reserved[1])))
+		if err == nil {
+			t.Errorf("reserved range %d: %v accepted", i, reserved)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math/big"
+	"net"
+)
+
+// IPv6Allocator allocates the addresses of an IPv6 network of any prefix, e.g. a /48 or a /64 of a UE pool,
+// over a BigIDGenerator, so that it is not limited to the 2^64 addresses of an IPAllocator.
+// The first and last address of the network are never allocated like in an IPAllocator,
+// except in the /127 and /128 networks which have no others. It is safe for concurrent use.
+type IPv6Allocator struct {
+	network   *net.IPNet
+	generator *BigIDGenerator
+}
+
+// NewIPv6Allocator initializes an IPv6Allocator for network.
+// It returns an error wrapping ErrInvalidRange if network is not an IPv6 network.
+func NewIPv6Allocator(network *net.IPNet) (*IPv6Allocator, error) {
+	ones, bits := network.Mask.Size()
+	ip := network.IP.To16()
+	if ip == nil || network.IP.To4() != nil || bits != 8*net.IPv6len {
+		return nil, fmt.Errorf("%w: invalid IPv6 network %v", ErrInvalidRange, network)
+	}
+	first, last := new(big.Int), new(big.Int).Rsh(maxUint128, uint(ones))
+	if bits-ones > 1 {
+		first.SetInt64(1)
+		last.Sub(last, big.NewInt(1))
+	}
+	generator, err := NewBigIDGenerator(first, last)
+	if err != nil {
+		return nil, err
+	}
+	return &IPv6Allocator{
+		network:   &net.IPNet{IP: ip.Mask(network.Mask), Mask: network.Mask},
+		generator: generator,
+	}, nil
+}
+
+// toID returns the host part of ip, or an error wrapping ErrOutOfRange if ip is not in the network
+// and ErrReserved if it is its first or last address
+func (a *IPv6Allocator) toID(ip net.IP) (*big.Int, error) {
+	if ip.To4() != nil || ip.To16() == nil || !a.network.Contains(ip) {
+		return nil, fmt.Errorf("%w: %v not in %v", ErrOutOfRange, ip, a.network)
+	}
+	host := new(big.Int).SetBytes(ip.To16())
+	host.AndNot(host, new(big.Int).SetBytes(a.network.IP))
+	if _, ok := a.generator.toOffset(host); !ok {
+		return nil, fmt.Errorf("%w: %v is the first or last address of %v", ErrReserved, ip, a.network)
+	}
+	return host, nil
+}
+
+func (a *IPv6Allocator) toIP(host *big.Int) net.IP {
+	ip := make(net.IP, net.IPv6len)
+	host.Or(host, new(big.Int).SetBytes(a.network.IP)).FillBytes(ip)
+	return ip
+}
+
+// Network returns the network the addresses are allocated in
+func (a *IPv6Allocator) Network() *net.IPNet {
+	return &net.IPNet{IP: append(net.IP(nil), a.network.IP...), Mask: append(net.IPMask(nil), a.network.Mask...)}
+}
+
+// Allocate allocates a free address of the network like BigIDGenerator.Allocate
+func (a *IPv6Allocator) Allocate() (net.IP, error) {
+	host, err := a.generator.Allocate()
+	if err != nil {
+		return nil, err
+	}
+	return a.toIP(host), nil
+}
+
+// AllocateSpecific allocates exactly ip like BigIDGenerator.AllocateSpecific.
+// It returns an error wrapping ErrOutOfRange if ip is not in the network,
+// or ErrReserved if ip is its first or last address.
+func (a *IPv6Allocator) AllocateSpecific(ip net.IP) error {
+	host, err := a.toID(ip)
+	if err != nil {
+		return err
+	}
+	if err = a.generator.AllocateSpecific(host); err != nil {
+		return fmt.Errorf("%v: %w", ip, err)
+	}
+	return nil
+}
+
+// Free releases ip like BigIDGenerator.FreeID, it fails like AllocateSpecific if ip cannot be allocated
+func (a *IPv6Allocator) Free(ip net.IP) error {
+	host, err := a.toID(ip)
+	if err != nil {
+		return err
+	}
+	if err = a.generator.FreeID(host); err != nil {
+		return fmt.Errorf("%v: %w", ip, err)
+	}
+	return nil
+}
+
+// IsAllocated reports whether ip is allocated
+func (a *IPv6Allocator) IsAllocated(ip net.IP) bool {
+	host, err := a.toID(ip)
+	return err == nil && a.generator.IsAllocated(host)
+}
+
+// Used returns the number of allocated addresses
+func (a *IPv6Allocator) Used() *big.Int {
+	return a.generator.Used()
+}
+
+// Available returns the number of addresses which may be allocated
+func (a *IPv6Allocator) Available() *big.Int {
+	return a.generator.Available()
+}
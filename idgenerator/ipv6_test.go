@@ -0,0 +1,79 @@
+package idgenerator
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestIPv6Allocator(t *testing.T) {
+	allocator, err := NewIPv6Allocator(mustParseCIDR(t, "2001:db8:a::/48"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2^80 addresses but the first and last
+	if available := allocator.Available().String(); available != "1208925819614629174706174" {
+		t.Errorf("unexpected available %s", available)
+	}
+	ip, err := allocator.Allocate()
+	if err != nil || ip.String() != "2001:db8:a::1" {
+		t.Errorf("expected 2001:db8:a::1, output %v, %+v", ip, err)
+	}
+	last := net.ParseIP("2001:db8:a:ffff:ffff:ffff:ffff:fffe")
+	if err = allocator.AllocateSpecific(last); err != nil {
+		t.Fatal(err)
+	}
+	if err = allocator.AllocateSpecific(last); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	if !allocator.IsAllocated(last) {
+		t.Errorf("%v not reported allocated", last)
+	}
+	if err = allocator.Free(last); err != nil {
+		t.Fatal(err)
+	}
+	if used := allocator.Used(); used.Int64() != 1 {
+		t.Errorf("expected 1 used, output %d", used)
+	}
+
+	for _, testCase := range []struct {
+		ip       string
+		expected error
+	}{
+		{"2001:db8:b::1", ErrOutOfRange},
+		{"10.0.0.1", ErrOutOfRange},
+		{"2001:db8:a::", ErrReserved},
+		{"2001:db8:a:ffff:ffff:ffff:ffff:ffff", ErrReserved},
+	} {
+		if err = allocator.AllocateSpecific(net.ParseIP(testCase.ip)); !errors.Is(err, testCase.expected) {
+			t.Errorf("%s: expected %v, got %+v", testCase.ip, testCase.expected, err)
+		}
+	}
+}
+
+func TestIPv6AllocatorSmallNetworks(t *testing.T) {
+	for cidr, expected := range map[string][]string{
+		"2001:db8::/127":   {"2001:db8::", "2001:db8::1"},
+		"2001:db8::5/128":  {"2001:db8::5"},
+		"2001:db8::10/126": {"2001:db8::11", "2001:db8::12"},
+	} {
+		allocator, err := NewIPv6Allocator(mustParseCIDR(t, cidr))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, address := range expected {
+			if ip, err := allocator.Allocate(); err != nil || ip.String() != address {
+				t.Errorf("%s: expected %s, output %v, %+v", cidr, address, ip, err)
+			}
+		}
+		if _, err = allocator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+			t.Errorf("%s: expected ErrPoolExhausted, got %+v", cidr, err)
+		}
+	}
+}
+
+func TestNewIPv6AllocatorInvalid(t *testing.T) {
+	if _, err := NewIPv6Allocator(mustParseCIDR(t, "10.0.0.0/8")); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package idgenerator
+
+import "encoding/json"
+
+// MarshalJSON encodes the Snapshot of the generator, e.g.
+//
+//	{"minValue":1,"maxValue":10,"offset":3,"used":[1,2,3]}
+func (idGenerator *IDGenerator) MarshalJSON() ([]byte, error) {
+	return json.Marshal(idGenerator.Snapshot())
+}
+
+// UnmarshalJSON replaces the state of the generator with the decoded Snapshot.
+// It validates the document like RestoreGenerator and leaves the generator unchanged on error.
+// Options set at construction, such as the logger or the store, are kept;
+// a zero IDGenerator gets the defaults of NewGenerator.
+func (idGenerator *IDGenerator) UnmarshalJSON(data []byte) error {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return err
+	}
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	if idGenerator.logger == nil {
+		idGenerator.logger = nopLogger{}
+	}
+	if idGenerator.newStore == nil {
+		idGenerator.newStore = NewTreeStore
+	}
+	if idGenerator.clock == nil {
+		idGenerator.clock = realClock{}
+	}
+	return idGenerator.load(snapshot)
+}
@@ -0,0 +1,136 @@
+package idgenerator
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata")
+
+func checkGolden(t *testing.T, name string, output []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, output, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(output, expected) {
+		t.Errorf("output does not match %s:\n%s\nexpected:\n%s", path, output, expected)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	idGenerator := NewGenerator(100, 199)
+	for _, id := range []int64{150, 120, 199, 100} {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := idGenerator.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := json.Marshal(idGenerator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "idgenerator.json", data)
+
+	t.Run("round trip", func(t *testing.T) {
+		var decoded IDGenerator
+		if err = json.Unmarshal(data, &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(decoded.Snapshot(), idGenerator.Snapshot()) {
+			t.Errorf("expected snapshot %+v, output %+v", idGenerator.Snapshot(), decoded.Snapshot())
+		}
+		for i := 0; i < 10; i++ {
+			expected, err := idGenerator.Allocate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := decoded.Allocate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id != expected {
+				t.Errorf("expected id: %d, output id: %d", expected, id)
+			}
+		}
+	})
+
+	t.Run("empty generator", func(t *testing.T) {
+		data, err := json.Marshal(NewGenerator(1, 10))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if expected := `{"minValue":1,"maxValue":10,"offset":0,"used":[]}`; string(data) != expected {
+			t.Errorf("expected %s, output %s", expected, data)
+		}
+	})
+
+	t.Run("keeps options", func(t *testing.T) {
+		decoded, err := NewGeneratorWithOptions(1, 2, WithBitmapStore())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = json.Unmarshal(data, decoded); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := decoded.store.(*bitmapStore); !ok {
+			t.Errorf("expected the bitmap store to be kept, output %T", decoded.store)
+		}
+		if !decoded.IsAllocated(150) {
+			t.Error("expected ID 150 to be allocated")
+		}
+	})
+}
+
+func TestUnmarshalJSONValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		data        string
+		expectedErr error
+	}{
+		{"min > max", `{"minValue":10,"maxValue":1,"offset":0,"used":[]}`, ErrInvalidRange},
+		{"used below min", `{"minValue":1,"maxValue":10,"offset":0,"used":[0]}`, ErrOutOfRange},
+		{"used above max", `{"minValue":1,"maxValue":10,"offset":0,"used":[3,11]}`, ErrOutOfRange},
+		{"offset past range", `{"minValue":1,"maxValue":10,"offset":10,"used":[]}`, ErrOutOfRange},
+		{"duplicate used", `{"minValue":1,"maxValue":10,"offset":0,"used":[3,3]}`, ErrAlreadyAllocated},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			idGenerator := NewGenerator(1, 5)
+			if err := idGenerator.AllocateSpecific(2); err != nil {
+				t.Fatal(err)
+			}
+			before := idGenerator.Snapshot()
+
+			err := json.Unmarshal([]byte(testCase.data), idGenerator)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+			if !reflect.DeepEqual(idGenerator.Snapshot(), before) {
+				t.Errorf("generator changed by a failed UnmarshalJSON: %+v", idGenerator.Snapshot())
+			}
+		})
+	}
+
+	if err := json.Unmarshal([]byte(`{"minValue":"1"}`), NewGenerator(1, 5)); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+}
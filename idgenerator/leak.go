@@ -0,0 +1,100 @@
+package idgenerator
+
+import (
+	"container/list"
+	"time"
+)
+
+// WithLeakDetection calls report with the IDs allocated for longer than maxAge, e.g. 24 hours,
+// to warn about sessions which never freed theirs. Each ID is reported once per allocation:
+// it is reported again only if it is freed, allocated again and held for maxAge again.
+// The check is made lazily like the expiry of leases, by every call on the generator, e.g. a periodic Stats,
+// and costs a step per ID reported: the IDs are queued in the order they are allocated.
+// report runs like the hook of WithOnFree, after lock is released.
+// Leases are exempt since they expire on their own, a lease made permanent by Commit is held from the Commit.
+// The IDs restored by RestoreGenerator, UnmarshalJSON or UnmarshalBinary are held from the restore,
+// those taken over by Merge or Join from the call. Clone and the children of Split do not report leaks.
+// A maxAge of 0 or below, or a nil report, detects nothing.
+func WithLeakDetection(maxAge time.Duration, report func(ids []int64)) Option {
+	return func(idGenerator *IDGenerator) {
+		if maxAge > 0 && report != nil {
+			idGenerator.leaks = &leakDetector{maxAge: maxAge, report: report}
+			idGenerator.leaks.reset()
+		}
+	}
+}
+
+// leakDetector queues the allocated IDs of WithLeakDetection which are not reported yet in the order they were
+// allocated, queued maps them to their elements. The IDs are keyed by value so that Resize does not move them.
+type leakDetector struct {
+	maxAge time.Duration
+	report func(ids []int64)
+	queue  *list.List
+	queued map[int64]*list.Element
+}
+
+// held is an ID of the queue with the time it was allocated at
+type held struct {
+	id    int64
+	since time.Time
+}
+
+func (d *leakDetector) reset() {
+	d.queue = list.New()
+	d.queued = make(map[int64]*list.Element)
+}
+
+// track queues id allocated at since, which must not be before the IDs queued already
+func (d *leakDetector) track(id int64, since time.Time) {
+	if _, ok := d.queued[id]; !ok {
+		d.queued[id] = d.queue.PushBack(held{id: id, since: since})
+	}
+}
+
+// untrack drops id from the queue if it is queued
+func (d *leakDetector) untrack(id int64) {
+	if e, ok := d.queued[id]; ok {
+		d.queue.Remove(e)
+		delete(d.queued, id)
+	}
+}
+
+// overAge dequeues and returns the IDs allocated at now - maxAge or before, nil if none
+func (d *leakDetector) overAge(now time.Time) []int64 {
+	var ids []int64
+	for e := d.queue.Front(); e != nil; e = d.queue.Front() {
+		entry := e.Value.(held)
+		if now.Sub(entry.since) < d.maxAge {
+			break
+		}
+		ids = append(ids, entry.id)
+		d.untrack(entry.id)
+	}
+	return ids
+}
+
+// trackLocked starts the leak detection of the ID at offset if WithLeakDetection is set, the caller must hold lock
+func (idGenerator *IDGenerator) trackLocked(offset uint64) {
+	if idGenerator.leaks != nil {
+		idGenerator.leaks.track(idGenerator.toID(offset), idGenerator.clock.Now())
+	}
+}
+
+// untrackLocked ends the leak detection of the ID at offset, the caller must hold lock
+func (idGenerator *IDGenerator) untrackLocked(offset uint64) {
+	if idGenerator.leaks != nil {
+		idGenerator.leaks.untrack(idGenerator.toID(offset))
+	}
+}
+
+// detectLeaksLocked queues the report of the IDs held for longer than the maxAge of WithLeakDetection,
+// the caller must hold lock
+func (idGenerator *IDGenerator) detectLeaksLocked() {
+	if idGenerator.leaks == nil {
+		return
+	}
+	if ids := idGenerator.leaks.overAge(idGenerator.clock.Now()); len(ids) > 0 {
+		report := idGenerator.leaks.report
+		idGenerator.events = append(idGenerator.events, event{hook: func(int64) { report(ids) }})
+	}
+}
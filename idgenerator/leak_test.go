@@ -0,0 +1,153 @@
+package idgenerator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// leakReports returns an option of leak detection after maxAge
+// and a function returning the IDs reported since its last call
+func leakReports(maxAge time.Duration) (Option, func() [][]int64) {
+	var reports [][]int64
+	option := WithLeakDetection(maxAge, func(ids []int64) {
+		reports = append(reports, ids)
+	})
+	return option, func() [][]int64 {
+		reported := reports
+		reports = nil
+		return reported
+	}
+}
+
+func TestLeakDetection(t *testing.T) {
+	clock := newFakeClock()
+	option, reported := leakReports(24 * time.Hour)
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	clock.Advance(time.Hour)
+	allocateN(t, idGenerator, 1)
+	if _, err = idGenerator.AllocateLease(48 * time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	freeAll(t, idGenerator, 2)
+	clock.Advance(23 * time.Hour)
+
+	if used := idGenerator.Used(); used != 3 {
+		t.Fatalf("expected 3 used, output %d", used)
+	}
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{1}}) {
+		t.Errorf("expected ID 1 reported, output %v", reports)
+	}
+	// reported once per allocation
+	clock.Advance(time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{3}}) {
+		t.Errorf("expected ID 3 reported, output %v", reports)
+	}
+	clock.Advance(48 * time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); reports != nil {
+		t.Errorf("expected no report, the lease is exempt, output %v", reports)
+	}
+
+	// an ID freed and allocated again is reported again
+	freeAll(t, idGenerator, 1)
+	if err = idGenerator.AllocateSpecific(1); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(24 * time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{1}}) {
+		t.Errorf("expected ID 1 reported again, output %v", reports)
+	}
+}
+
+func TestLeakDetectionCommit(t *testing.T) {
+	clock := newFakeClock()
+	option, reported := leakReports(time.Hour)
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := idGenerator.AllocateLease(10 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	if err = idGenerator.Commit(id); err != nil {
+		t.Fatal(err)
+	}
+	if reports := reported(); reports != nil {
+		t.Errorf("expected no report of the lease, output %v", reports)
+	}
+	clock.Advance(time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{id}}) {
+		t.Errorf("expected the committed lease reported an hour after the Commit, output %v", reports)
+	}
+}
+
+func TestLeakDetectionKept(t *testing.T) {
+	clock := newFakeClock()
+	option, reported := leakReports(time.Hour)
+	idGenerator, err := NewGeneratorWithOptions(10, 100, WithClock(clock), option)
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	if err = idGenerator.Resize(1, 100); err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewGeneratorWithOptions(101, 200, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, other, 1)
+	clock.Advance(30 * time.Minute)
+	if err = idGenerator.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(30 * time.Minute)
+	idGenerator.Stats()
+	// the ID of other is held from the Merge
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{10, 11}}) {
+		t.Errorf("expected IDs 10 and 11 reported, output %v", reports)
+	}
+	clock.Advance(30 * time.Minute)
+	idGenerator.Stats()
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{101}}) {
+		t.Errorf("expected ID 101 reported, output %v", reports)
+	}
+
+	if err = idGenerator.UnmarshalJSON([]byte(`{"minValue":1,"maxValue":100,"offset":0,"used":[7]}`)); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); !reflect.DeepEqual(reports, [][]int64{{7}}) {
+		t.Errorf("expected the restored ID 7 reported, output %v", reports)
+	}
+	allocateN(t, idGenerator, 1)
+	idGenerator.Reset()
+	clock.Advance(time.Hour)
+	idGenerator.Stats()
+	if reports := reported(); reports != nil {
+		t.Errorf("expected no report after Reset, output %v", reports)
+	}
+}
+
+func TestLeakDetectionOff(t *testing.T) {
+	for _, option := range []Option{WithLeakDetection(0, func([]int64) {}), WithLeakDetection(time.Hour, nil)} {
+		idGenerator, err := NewGeneratorWithOptions(1, 100, option)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if idGenerator.leaks != nil {
+			t.Error("expected no leak detection")
+		}
+	}
+}
@@ -0,0 +1,180 @@
+package idgenerator
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+)
+
+// leaseEntry is the expiry of a lease at the time it was pushed,
+// entries outdated by Renew, Commit or FreeID are skipped when popped
+type leaseEntry struct {
+	expiry time.Time
+	offset uint64
+}
+
+type leaseHeap []leaseEntry
+
+func (h leaseHeap) Len() int            { return len(h) }
+func (h leaseHeap) Less(i, j int) bool  { return h[i].expiry.Before(h[j].expiry) }
+func (h leaseHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *leaseHeap) Push(x interface{}) { *h = append(*h, x.(leaseEntry)) }
+
+func (h *leaseHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
+
+// AllocateLease allocates an ID like Allocate which is freed automatically once ttl has elapsed,
+// unless it is extended with Renew or made permanent with Commit before.
+// Expired leases are released lazily, by the next call on the generator.
+func (idGenerator *IDGenerator) AllocateLease(ttl time.Duration) (int64, error) {
+	if ttl <= 0 {
+		return 0, fmt.Errorf("AllocateLease: invalid ttl %v", ttl)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	id, err := idGenerator.allocateLocked()
+	if err == nil {
+		idGenerator.setLeaseLocked(idGenerator.toOffset(id), ttl)
+	}
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+	}
+	return id, err
+}
+
+// Renew extends the lease of id to expire ttl from now.
+// It returns an error wrapping ErrLeaseExpired if the lease has already expired,
+// or ErrNotLeased if id is not allocated with AllocateLease.
+func (idGenerator *IDGenerator) Renew(id int64, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("Renew: invalid ttl %v", ttl)
+	}
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset, err := idGenerator.leaseOffsetLocked(id)
+	if err != nil {
+		return err
+	}
+	idGenerator.setLeaseLocked(offset, ttl)
+	return nil
+}
+
+// Commit turns the lease of id into a normal allocation which never expires, committing its reservation
+// if it is reserved by Reserve. It fails like Renew if id is not leased.
+func (idGenerator *IDGenerator) Commit(id int64) error {
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset, err := idGenerator.leaseOffsetLocked(id)
+	if err != nil {
+		return err
+	}
+	delete(idGenerator.leases, offset)
+	delete(idGenerator.reservations, offset)
+	idGenerator.trackLocked(offset)
+	return nil
+}
+
+func (idGenerator *IDGenerator) leaseOffsetLocked(id int64) (uint64, error) {
+	if !idGenerator.inRange(id) {
+		return 0, idGenerator.outOfRangeError(id)
+	}
+	idGenerator.expireLeasesLocked()
+	offset := idGenerator.toOffset(id)
+	if _, ok := idGenerator.expired[offset]; ok {
+		return 0, fmt.Errorf("%w: ID[%d]", ErrLeaseExpired, id)
+	}
+	if _, ok := idGenerator.leases[offset]; !ok {
+		return 0, fmt.Errorf("%w: ID[%d]", ErrNotLeased, id)
+	}
+	return offset, nil
+}
+
+func (idGenerator *IDGenerator) setLeaseLocked(offset uint64, ttl time.Duration) {
+	if idGenerator.leases == nil {
+		idGenerator.leases = make(map[uint64]time.Time)
+		idGenerator.expired = make(map[uint64]struct{})
+	}
+	// leases expire on their own, they are not leaked
+	idGenerator.untrackLocked(offset)
+	expiry := idGenerator.clock.Now().Add(ttl)
+	idGenerator.leases[offset] = expiry
+	heap.Push(&idGenerator.leaseHeap, leaseEntry{expiry: expiry, offset: offset})
+}
+
+// expireLeasesLocked frees the IDs whose lease has expired, the caller must hold lock.
+// They are remembered in expired until reallocated, so that freeing them is not an error.
+// It also releases the IDs whose quarantine is over and reports the leaks of WithLeakDetection.
+func (idGenerator *IDGenerator) expireLeasesLocked() {
+	if idGenerator.closed {
+		return
+	}
+	if idGenerator.releaseQuarantinedLocked() {
+		idGenerator.serveWaitersLocked()
+	}
+	idGenerator.detectLeaksLocked()
+	if len(idGenerator.leaseHeap) == 0 {
+		return
+	}
+	now := idGenerator.clock.Now()
+	for len(idGenerator.leaseHeap) > 0 && !idGenerator.leaseHeap[0].expiry.After(now) {
+		entry := heap.Pop(&idGenerator.leaseHeap).(leaseEntry)
+		if expiry, ok := idGenerator.leases[entry.offset]; !ok || !expiry.Equal(entry.expiry) {
+			// renewed, committed or freed since this entry was pushed
+			continue
+		}
+		idGenerator.markFree(entry.offset)
+		idGenerator.expired[entry.offset] = struct{}{}
+		if idGenerator.logEnabled() {
+			idGenerator.queueLogLocked(idGenerator.newLogEventLocked(logLeaseExpired, idGenerator.toID(entry.offset),
+				true, nil))
+		}
+	}
+	idGenerator.serveWaitersLocked()
+}
+
+// rlock takes the read lock for the methods which only read the state of the generator.
+// The leases, quarantines and leaks which expireLeasesLocked would handle are handled first under the write lock,
+// so that the readers see the same state as under it without writing anything themselves.
+func (idGenerator *IDGenerator) rlock() {
+	for {
+		idGenerator.lock.RLock()
+		if !idGenerator.expiryDueLocked() {
+			return
+		}
+		idGenerator.lock.RUnlock()
+		idGenerator.lock.Lock()
+		idGenerator.expireLeasesLocked()
+		idGenerator.unlock()
+	}
+}
+
+// expiryDueLocked reports whether expireLeasesLocked has anything to do, without changing anything,
+// the caller must hold lock or its read lock
+func (idGenerator *IDGenerator) expiryDueLocked() bool {
+	if idGenerator.closed {
+		return false
+	}
+	leaks := idGenerator.leaks != nil && idGenerator.leaks.queue.Len() > 0
+	if !leaks && len(idGenerator.quarantine) == 0 && len(idGenerator.leaseHeap) == 0 {
+		return false
+	}
+	now := idGenerator.clock.Now()
+	if leaks && now.Sub(idGenerator.leaks.queue.Front().Value.(held).since) >= idGenerator.leaks.maxAge {
+		return true
+	}
+	if len(idGenerator.quarantine) > 0 && !idGenerator.quarantine[0].expiry.After(now) {
+		return true
+	}
+	return len(idGenerator.leaseHeap) > 0 && !idGenerator.leaseHeap[0].expiry.After(now)
+}
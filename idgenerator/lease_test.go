@@ -0,0 +1,251 @@
+package idgenerator
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock which only moves when told to, the FakeClock of idgeneratortest,
+// which the tests of the package cannot import
+type fakeClock struct {
+	mtx    sync.Mutex
+	cond   *sync.Cond
+	now    time.Time
+	timers []fakeTimer
+}
+
+// fakeTimer is a channel of After waiting for the clock to reach at
+type fakeTimer struct {
+	at time.Time
+	c  chan time.Time
+}
+
+func newFakeClock() *fakeClock {
+	c := &fakeClock{now: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)}
+	c.cond = sync.NewCond(&c.mtx)
+	return c
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	timer := fakeTimer{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		timer.c <- c.now
+		return timer.c
+	}
+	c.timers = append(c.timers, timer)
+	c.cond.Broadcast()
+	return timer.c
+}
+
+// Advance moves the clock by d and fires the timers due by then in the order of their deadlines
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.now = c.now.Add(d)
+	sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+	due := 0
+	for due < len(c.timers) && !c.timers[due].at.After(c.now) {
+		c.timers[due].c <- c.timers[due].at
+		due++
+	}
+	c.timers = c.timers[due:]
+}
+
+// waitForTimers waits until n timers of After are pending
+func (c *fakeClock) waitForTimers(n int) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	for len(c.timers) < n {
+		c.cond.Wait()
+	}
+}
+
+func TestLease(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 3, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	leased, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	committed, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateLease(time.Minute); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	clock.Advance(30 * time.Second)
+	if err = idGenerator.Renew(renewed, 2*time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Commit(committed); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Commit(committed); !errors.Is(err, ErrNotLeased) {
+		t.Errorf("expected ErrNotLeased, got %+v", err)
+	}
+
+	// only the lease which was neither renewed nor committed expires
+	clock.Advance(30 * time.Second)
+	if idGenerator.IsAllocated(leased) {
+		t.Errorf("ID %d is still allocated after its lease expired", leased)
+	}
+	if !idGenerator.IsAllocated(renewed) || !idGenerator.IsAllocated(committed) {
+		t.Error("renewed or committed IDs expired")
+	}
+	if used := idGenerator.Used(); used != 2 {
+		t.Errorf("expected used: 2, output used: %d", used)
+	}
+	if err = idGenerator.Renew(leased, time.Minute); !errors.Is(err, ErrLeaseExpired) {
+		t.Errorf("expected ErrLeaseExpired, got %+v", err)
+	}
+
+	// freeing an expired lease is a no-op
+	if err = idGenerator.FreeID(leased); err != nil {
+		t.Errorf("expected no error freeing an expired lease, got %+v", err)
+	}
+	if err = idGenerator.FreeID(leased); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+
+	clock.Advance(time.Hour)
+	if idGenerator.IsAllocated(renewed) {
+		t.Errorf("ID %d is still allocated after its renewed lease expired", renewed)
+	}
+	if !idGenerator.IsAllocated(committed) {
+		t.Error("committed ID expired")
+	}
+
+	// a freed lease does not expire its reallocation later
+	id, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(id); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if !idGenerator.IsAllocated(id) {
+		t.Errorf("ID %d expired although it was reallocated without a lease", id)
+	}
+	if err = idGenerator.Renew(id, time.Minute); !errors.Is(err, ErrNotLeased) {
+		t.Errorf("expected ErrNotLeased, got %+v", err)
+	}
+}
+
+func TestLeaseExpiryOnAllocate(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 2, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateLease(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	clock.Advance(time.Second)
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 1 {
+		t.Errorf("expected the expired ID 1, output id: %d", id)
+	}
+
+	if _, err = idGenerator.AllocateLease(0); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	if err = idGenerator.Renew(100, time.Second); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+}
+
+func TestFreeRangeExpiredLease(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 3, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leased, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(2); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+
+	// the expired lease is already free, FreeRange forgets it like FreeID would
+	if freed, err := idGenerator.FreeRange(1, 3); err != nil || freed != 1 {
+		t.Errorf("expected freed: 1, output: %d, %+v", freed, err)
+	}
+	if err = idGenerator.FreeID(leased); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+}
+
+func TestLeaseExpiryOnRead(t *testing.T) {
+	clock := newFakeClock()
+	var freed []int64
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithOnFree(func(id int64) {
+		freed = append(freed, id)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err = idGenerator.AllocateLease(time.Duration(i+1) * time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the readers share the read lock, the first one to see a lease expired frees it under the write lock
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		clock.Advance(time.Minute)
+		for reader := 0; reader < 4; reader++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				idGenerator.Stats()
+				idGenerator.AllocatedIDs()
+			}()
+		}
+		wg.Wait()
+		if used := idGenerator.Used(); used != int64(9-i) {
+			t.Fatalf("after %d minutes: expected %d used, output %d", i+1, 9-i, used)
+		}
+	}
+	if len(freed) != 10 {
+		t.Errorf("expected the hook called for the 10 leases, output %v", freed)
+	}
+}
@@ -0,0 +1,155 @@
+package idgenerator
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// Merge makes the generator cover the union of its range and the range of other, which must be adjacent to it,
+// e.g. [0, 4999] and [5000, 9999] merge into [0, 9999], with the allocated IDs of both.
+// The IDs of other keep their leases, owners, exclusions and quarantine, and its counters are added to those
+// of the generator; generations and references are kept if the generator counts them.
+// The options of the generator apply to the whole range, the hooks are not called for the IDs of other.
+// other must not be used after Merge, nor be merging the generator at the same time.
+// It returns an error wrapping ErrInvalidRange if the ranges overlap, or are apart, which NewGeneratorFromRanges
+// allocates from as one pool instead; the generator is left unchanged on error.
+func (idGenerator *IDGenerator) Merge(other *IDGenerator) error {
+	if other == idGenerator {
+		return fmt.Errorf("%w: cannot merge a generator with itself", ErrInvalidRange)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	other.lock.Lock()
+	other.expireLeasesLocked()
+	defer other.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if err := other.checkOpenLocked(); err != nil {
+		return err
+	}
+
+	if !idGenerator.step.sameIDs(idGenerator.minValue, other.step, other.minValue) {
+		return fmt.Errorf("%w: [%d, %d] and [%d, %d] have different steps", ErrInvalidRange,
+			idGenerator.minValue, idGenerator.maxValue, other.minValue, other.maxValue)
+	}
+	minValue, maxValue := idGenerator.minValue, idGenerator.maxValue
+	switch {
+	case other.minValue <= idGenerator.maxValue && idGenerator.minValue <= other.maxValue:
+		return fmt.Errorf("%w: [%d, %d] overlaps [%d, %d]", ErrInvalidRange,
+			idGenerator.minValue, idGenerator.maxValue, other.minValue, other.maxValue)
+	case other.maxValue < idGenerator.minValue && other.maxValue == idGenerator.minValue-1:
+		minValue = other.minValue
+	case other.minValue > idGenerator.maxValue && other.minValue-1 == idGenerator.maxValue:
+		maxValue = other.maxValue
+	default:
+		return fmt.Errorf("%w: [%d, %d] and [%d, %d] are not adjacent", ErrInvalidRange,
+			idGenerator.minValue, idGenerator.maxValue, other.minValue, other.maxValue)
+	}
+	if err := idGenerator.resizeLocked(minValue, maxValue); err != nil {
+		return err
+	}
+	for offset := range other.excluded {
+		if idGenerator.excluded == nil {
+			idGenerator.excluded = make(map[uint64]struct{})
+		}
+		moved := idGenerator.toOffset(other.toID(offset))
+		idGenerator.excluded[moved] = struct{}{}
+		idGenerator.store.Set(moved)
+		if idGenerator.recycling != nil {
+			idGenerator.recycling.take(moved)
+		}
+	}
+	idGenerator.absorbLocked(other)
+	idGenerator.serveWaitersLocked()
+	return nil
+}
+
+// absorbLocked moves the allocated and quarantined IDs of other, whose range is within that of the generator,
+// to the generator with their leases, owners, references, generations and hold times, and adds up their counters.
+// The excluded IDs of other are left out. The caller must hold the locks of both.
+func (idGenerator *IDGenerator) absorbLocked(other *IDGenerator) {
+	// moves an offset of other to the same ID in the generator
+	move := func(offset uint64) uint64 {
+		return idGenerator.toOffset(other.toID(offset))
+	}
+	for _, offset := range other.allocatedOffsetsLocked() {
+		moved := move(offset)
+		idGenerator.store.Set(moved)
+		if idGenerator.recycling != nil {
+			idGenerator.recycling.take(moved)
+		}
+		idGenerator.used++
+		// the leases are exempt, they are moved below
+		if _, ok := other.leases[offset]; !ok {
+			idGenerator.trackLocked(moved)
+		}
+		if idGenerator.heldSince != nil {
+			// the IDs of a generator without hold times are held from now on
+			since, ok := other.heldSince[offset]
+			if !ok {
+				since = idGenerator.clock.Now()
+			}
+			idGenerator.heldSince[moved] = since
+		}
+	}
+	if idGenerator.generations != nil {
+		for offset, generation := range other.generations {
+			if !other.isExcluded(offset) {
+				idGenerator.generations[move(offset)] = generation
+			}
+		}
+	}
+	if other.leases != nil && idGenerator.leases == nil {
+		idGenerator.leases = make(map[uint64]time.Time)
+		idGenerator.expired = make(map[uint64]struct{})
+	}
+	for offset, expiry := range other.leases {
+		idGenerator.leases[move(offset)] = expiry
+		heap.Push(&idGenerator.leaseHeap, leaseEntry{expiry: expiry, offset: move(offset)})
+	}
+	for offset := range other.expired {
+		idGenerator.expired[move(offset)] = struct{}{}
+	}
+	for offset, set := range other.ownerOf {
+		idGenerator.ownLocked(move(offset), set.name)
+	}
+	if idGenerator.refs != nil {
+		for offset, refs := range other.refs {
+			idGenerator.refs[move(offset)] = refs
+			idGenerator.extraRefs += refs
+		}
+	}
+	if len(other.quarantine) > 0 {
+		for _, entry := range other.quarantine {
+			offset := move(entry.offset)
+			if idGenerator.quarantined == nil {
+				idGenerator.quarantined = make(map[uint64]struct{})
+			}
+			idGenerator.store.Set(offset)
+			idGenerator.quarantined[offset] = struct{}{}
+			idGenerator.quarantine = append(idGenerator.quarantine, leaseEntry{expiry: entry.expiry, offset: offset})
+		}
+		// the queue stays in order of release
+		sort.SliceStable(idGenerator.quarantine, func(i, j int) bool {
+			return idGenerator.quarantine[i].expiry.Before(idGenerator.quarantine[j].expiry)
+		})
+	}
+	if idGenerator.recycling != nil && other.recycling != nil {
+		for e := other.recycling.queue.Front(); e != nil; e = e.Next() {
+			idGenerator.recycling.push(move(e.Value.(uint64)))
+		}
+	}
+
+	idGenerator.allocations += other.allocations
+	idGenerator.frees += other.frees
+	atomic.AddUint64(&idGenerator.allocateFailures, atomic.LoadUint64(&other.allocateFailures))
+	atomic.AddUint64(&idGenerator.allocateRetries, atomic.LoadUint64(&other.allocateRetries))
+	if idGenerator.used > idGenerator.peakUsed {
+		idGenerator.peakUsed, idGenerator.peakAt = idGenerator.used, idGenerator.clock.Now()
+	}
+}
@@ -0,0 +1,92 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	clock := newFakeClock()
+	low, err := NewGeneratorWithOptions(0, 4999, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	high, err := NewGeneratorWithExclusions(5000, 9999, []int64{5001}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, low, 3)
+	if _, err = high.AllocateLease(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err = high.AllocateSpecific(9999); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = low.Merge(high); err != nil {
+		t.Fatal(err)
+	}
+	if minValue, maxValue := low.bounds(); minValue != 0 || maxValue != 9999 {
+		t.Errorf("expected [0, 9999], output [%d, %d]", minValue, maxValue)
+	}
+	ids := []int64{0, 1, 2, 5000, 9999}
+	if snapshot := low.Snapshot(); !reflect.DeepEqual(snapshot.Used, ids) || snapshot.MaxValue != 9999 {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+	stats := low.Stats()
+	if stats.Used != 5 || stats.Capacity != 9999 || stats.Allocations != 5 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	if err = low.AllocateSpecific(5001); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected the exclusion of the other generator kept, got %+v", err)
+	}
+	clock.Advance(time.Hour)
+	if low.IsAllocated(5000) {
+		t.Error("expected the lease of the other generator to expire")
+	}
+	// Allocate carries on in the range of the generator
+	if id, err := low.Allocate(); err != nil || id != 3 {
+		t.Errorf("expected ID 3, output %d, %+v", id, err)
+	}
+}
+
+func TestMergeBelow(t *testing.T) {
+	idGenerator := NewGenerator(10, 19)
+	other := NewGenerator(0, 9)
+	allocateN(t, other, 10)
+	if err := idGenerator.Merge(other); err != nil {
+		t.Fatal(err)
+	}
+	if minValue, maxValue := idGenerator.bounds(); minValue != 0 || maxValue != 19 {
+		t.Errorf("expected [0, 19], output [%d, %d]", minValue, maxValue)
+	}
+	if used, available := idGenerator.Used(), idGenerator.Available(); used != 10 || available != 10 {
+		t.Errorf("expected 10 used and 10 available, output %d and %d", used, available)
+	}
+}
+
+func TestMergeErrors(t *testing.T) {
+	idGenerator := NewGenerator(100, 199)
+	allocateN(t, idGenerator, 1)
+	expected := idGenerator.Snapshot()
+	testCases := []struct {
+		name  string
+		other *IDGenerator
+	}{
+		{"overlapping", NewGenerator(150, 249)},
+		{"inside", NewGenerator(120, 130)},
+		{"same ID", NewGenerator(199, 300)},
+		{"apart", NewGenerator(201, 300)},
+		{"itself", idGenerator},
+	}
+	for _, testCase := range testCases {
+		if err := idGenerator.Merge(testCase.other); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%s: expected ErrInvalidRange, got %+v", testCase.name, err)
+		}
+		if snapshot := idGenerator.Snapshot(); !reflect.DeepEqual(snapshot, expected) {
+			t.Errorf("%s: expected the generator unchanged %+v, output %+v", testCase.name, expected, snapshot)
+		}
+	}
+}
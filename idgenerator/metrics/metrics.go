@@ -0,0 +1,261 @@
+// metrics exports the usage of ID generators as Prometheus metrics.
+// Every metric is labelled with the name the generator has been added with, e.g.
+//
+//	collector := metrics.NewCollector("smf")
+//	if err := collector.Add("teid", teidGenerator); err != nil { ... }
+//	prometheus.MustRegister(collector)
+package metrics
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// ErrAlreadyAdded is returned by Add and AddPool for a name which is already in use
+var ErrAlreadyAdded = errors.New("generator already added")
+
+// StatsSource is what Collector reads the metrics from,
+// *idgenerator.IDGenerator and *idgenerator.PersistentIDGenerator both satisfy it.
+type StatsSource interface {
+	Stats() idgenerator.Stats
+}
+
+// PoolSource is what Collector reads the metrics of several generators from,
+// *idgenerator.GeneratorPool satisfies it. Names returns the names StatsByName currently reads.
+type PoolSource interface {
+	Names() []string
+	StatsByName() map[string]idgenerator.Stats
+}
+
+// DetailedStatsSource is a StatsSource which also reads the fields walking the IDs, a Collector of
+// WithFragmentation reads largest_free_block and free_fragments from its DetailedStats,
+// and reports them as 0 for the other sources.
+// *idgenerator.IDGenerator and *idgenerator.PersistentIDGenerator both satisfy it.
+type DetailedStatsSource interface {
+	StatsSource
+	DetailedStats() idgenerator.Stats
+}
+
+// DetailedPoolSource is a PoolSource which also reads the fields walking the IDs, like DetailedStatsSource.
+// *idgenerator.GeneratorPool satisfies it.
+type DetailedPoolSource interface {
+	PoolSource
+	DetailedStatsByName() map[string]idgenerator.Stats
+}
+
+// Collector is a prometheus.Collector reporting the usage of named ID generators
+type Collector struct {
+	mtx        sync.Mutex
+	generators map[string]StatsSource
+	pools      []PoolSource
+	// fragmentation is set by WithFragmentation
+	fragmentation bool
+
+	used               *prometheus.Desc
+	capacity           *prometheus.Desc
+	utilization        *prometheus.Desc
+	peakUsed           *prometheus.Desc
+	largestFreeBlock   *prometheus.Desc
+	freeFragments      *prometheus.Desc
+	allocations        *prometheus.Desc
+	frees              *prometheus.Desc
+	allocationFailures *prometheus.Desc
+	allocationRetries  *prometheus.Desc
+	holdTimes          *prometheus.HistogramVec
+}
+
+// Option configures a Collector
+type Option func(*Collector)
+
+// WithFragmentation reports largest_free_block and free_fragments too. They are read from DetailedStats,
+// which walks the free IDs of every generator under its lock at each scrape, holding up its allocations
+// for as long as its range takes; so they are not reported by default.
+func WithFragmentation() Option {
+	return func(c *Collector) {
+		c.fragmentation = true
+	}
+}
+
+// NewCollector returns a Collector without generators,
+// its metrics are named <namespace>_idgenerator_<metric>, or idgenerator_<metric> if namespace is empty.
+// The metrics are read from the Stats of the generators, which take them in constant time.
+func NewCollector(namespace string, options ...Option) *Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "idgenerator", name),
+			help, []string{"generator"}, nil)
+	}
+	c := &Collector{
+		generators:         make(map[string]StatsSource),
+		used:               desc("used", "Number of allocated IDs."),
+		capacity:           desc("capacity", "Number of IDs in the range of the generator."),
+		utilization:        desc("utilization_ratio", "Ratio of allocated IDs to the capacity."),
+		peakUsed:           desc("peak_used", "Highest number of allocated IDs since the high-water mark was reset."),
+		largestFreeBlock:   desc("largest_free_block", "Length of the longest run of consecutive free IDs."),
+		freeFragments:      desc("free_fragments", "Number of runs of consecutive free IDs."),
+		allocations:        desc("allocations_total", "Total number of allocated IDs."),
+		frees:              desc("frees_total", "Total number of freed IDs."),
+		allocationFailures: desc("allocation_failures_total", "Total number of allocations failed for lack of a free ID."),
+		allocationRetries:  desc("allocation_retries_total", "Total number of allocations retried after an exhaustion."),
+		// from a second to about 48 days
+		holdTimes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "idgenerator",
+			Name:      "hold_time_seconds",
+			Help:      "Time the freed IDs were allocated for.",
+			Buckets:   prometheus.ExponentialBuckets(1, 4, 12),
+		}, []string{"generator"}),
+	}
+	for _, option := range options {
+		option(c)
+	}
+	return c
+}
+
+// ObserveHoldTime returns an observer for idgenerator.WithHoldTimes which records the hold times
+// of the IDs the generator frees in the histogram hold_time_seconds under name, e.g.
+//
+//	teidGenerator, err := idgenerator.NewGeneratorWithOptions(1, math.MaxUint32,
+//		idgenerator.WithHoldTimes(collector.ObserveHoldTime("teid")))
+//
+// The histogram is reported once an ID is freed, whether or not the generator is added under name.
+func (c *Collector) ObserveHoldTime(name string) func(id int64, held time.Duration) {
+	observer := c.holdTimes.WithLabelValues(name)
+	return func(id int64, held time.Duration) {
+		observer.Observe(held.Seconds())
+	}
+}
+
+// Add reports the metrics of generator under name.
+// It returns an error wrapping ErrAlreadyAdded if name is already in use by Add or by a pool of AddPool.
+func (c *Collector) Add(name string, generator StatsSource) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if _, ok := c.namesLocked()[name]; ok {
+		return fmt.Errorf("%w: %q", ErrAlreadyAdded, name)
+	}
+	c.generators[name] = generator
+	return nil
+}
+
+// AddPool reports the metrics of every generator in pool under its name in the pool,
+// including the generators created after AddPool. It returns an error wrapping ErrAlreadyAdded
+// if one of the names in pool is already in use by Add or by another pool. A generator created
+// in pool later under a name in use is not reported, as the metrics of a name must come from one generator.
+func (c *Collector) AddPool(pool PoolSource) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	names := c.namesLocked()
+	for _, name := range pool.Names() {
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("%w: %q", ErrAlreadyAdded, name)
+		}
+	}
+	c.pools = append(c.pools, pool)
+	return nil
+}
+
+// namesLocked returns the names in use by Add and by the pools of AddPool, the caller must hold mtx
+func (c *Collector) namesLocked() map[string]struct{} {
+	names := make(map[string]struct{}, len(c.generators))
+	for name := range c.generators {
+		names[name] = struct{}{}
+	}
+	for _, pool := range c.pools {
+		for _, name := range pool.Names() {
+			names[name] = struct{}{}
+		}
+	}
+	return names
+}
+
+// Remove stops reporting the generator added under name and its hold times, it does nothing if there is none
+func (c *Collector) Remove(name string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	delete(c.generators, name)
+	c.holdTimes.DeleteLabelValues(name)
+}
+
+// Describe implements prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.used
+	ch <- c.capacity
+	ch <- c.utilization
+	ch <- c.peakUsed
+	if c.fragmentation {
+		ch <- c.largestFreeBlock
+		ch <- c.freeFragments
+	}
+	ch <- c.allocations
+	ch <- c.frees
+	ch <- c.allocationFailures
+	ch <- c.allocationRetries
+	c.holdTimes.Describe(ch)
+}
+
+// Collect implements prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	// read the generators outside mtx, so that a slow one does not block Add and Remove
+	c.mtx.Lock()
+	generators := make(map[string]StatsSource, len(c.generators))
+	for name, generator := range c.generators {
+		generators[name] = generator
+	}
+	pools := append([]PoolSource(nil), c.pools...)
+	c.mtx.Unlock()
+
+	// a name is reported once, the generators of Add first, then the pools in the order of AddPool
+	collected := make(map[string]struct{}, len(generators))
+	for name, generator := range generators {
+		collected[name] = struct{}{}
+		if detailed, ok := generator.(DetailedStatsSource); ok && c.fragmentation {
+			c.collect(ch, name, detailed.DetailedStats())
+		} else {
+			c.collect(ch, name, generator.Stats())
+		}
+	}
+	for _, pool := range pools {
+		byName := pool.StatsByName
+		if detailed, ok := pool.(DetailedPoolSource); ok && c.fragmentation {
+			byName = detailed.DetailedStatsByName
+		}
+		for name, stats := range byName() {
+			if _, ok := collected[name]; ok {
+				continue
+			}
+			collected[name] = struct{}{}
+			c.collect(ch, name, stats)
+		}
+	}
+	c.holdTimes.Collect(ch)
+}
+
+// collect sends the metrics of the generator named name
+func (c *Collector) collect(ch chan<- prometheus.Metric, name string, stats idgenerator.Stats) {
+	utilization := 0.0
+	if stats.Capacity > 0 {
+		utilization = float64(stats.Used) / float64(stats.Capacity)
+	}
+	ch <- prometheus.MustNewConstMetric(c.used, prometheus.GaugeValue, float64(stats.Used), name)
+	ch <- prometheus.MustNewConstMetric(c.capacity, prometheus.GaugeValue, float64(stats.Capacity), name)
+	ch <- prometheus.MustNewConstMetric(c.utilization, prometheus.GaugeValue, utilization, name)
+	ch <- prometheus.MustNewConstMetric(c.peakUsed, prometheus.GaugeValue, float64(stats.PeakUsed), name)
+	if c.fragmentation {
+		ch <- prometheus.MustNewConstMetric(c.largestFreeBlock, prometheus.GaugeValue,
+			float64(stats.LargestFreeBlock), name)
+		ch <- prometheus.MustNewConstMetric(c.freeFragments, prometheus.GaugeValue,
+			float64(stats.FreeFragments), name)
+	}
+	ch <- prometheus.MustNewConstMetric(c.allocations, prometheus.CounterValue, float64(stats.Allocations), name)
+	ch <- prometheus.MustNewConstMetric(c.frees, prometheus.CounterValue, float64(stats.Frees), name)
+	ch <- prometheus.MustNewConstMetric(c.allocationFailures, prometheus.CounterValue,
+		float64(stats.AllocationFailures), name)
+	ch <- prometheus.MustNewConstMetric(c.allocationRetries, prometheus.CounterValue,
+		float64(stats.AllocationRetries), name)
+}
@@ -0,0 +1,251 @@
+package metrics
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+func TestCollector(t *testing.T) {
+	teid := idgenerator.NewGenerator(1, 4)
+	for i := 0; i < 4; i++ {
+		if _, err := teid.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := teid.Allocate(); err == nil {
+		t.Fatal("Allocate from an exhausted pool should fail")
+	}
+	if err := teid.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	seid := idgenerator.NewGenerator(0, 9)
+
+	collector := NewCollector("smf")
+	if err := collector.Add("teid", teid); err != nil {
+		t.Fatal(err)
+	}
+	if err := collector.Add("seid", seid); err != nil {
+		t.Fatal(err)
+	}
+	if err := collector.Add("teid", seid); !errors.Is(err, ErrAlreadyAdded) {
+		t.Errorf("adding a name twice returned %v, want ErrAlreadyAdded", err)
+	}
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP smf_idgenerator_allocation_failures_total Total number of allocations failed for lack of a free ID.
+# TYPE smf_idgenerator_allocation_failures_total counter
+smf_idgenerator_allocation_failures_total{generator="seid"} 0
+smf_idgenerator_allocation_failures_total{generator="teid"} 1
+# HELP smf_idgenerator_allocation_retries_total Total number of allocations retried after an exhaustion.
+# TYPE smf_idgenerator_allocation_retries_total counter
+smf_idgenerator_allocation_retries_total{generator="seid"} 0
+smf_idgenerator_allocation_retries_total{generator="teid"} 0
+# HELP smf_idgenerator_allocations_total Total number of allocated IDs.
+# TYPE smf_idgenerator_allocations_total counter
+smf_idgenerator_allocations_total{generator="seid"} 0
+smf_idgenerator_allocations_total{generator="teid"} 4
+# HELP smf_idgenerator_capacity Number of IDs in the range of the generator.
+# TYPE smf_idgenerator_capacity gauge
+smf_idgenerator_capacity{generator="seid"} 10
+smf_idgenerator_capacity{generator="teid"} 4
+# HELP smf_idgenerator_frees_total Total number of freed IDs.
+# TYPE smf_idgenerator_frees_total counter
+smf_idgenerator_frees_total{generator="seid"} 0
+smf_idgenerator_frees_total{generator="teid"} 1
+# HELP smf_idgenerator_peak_used Highest number of allocated IDs since the high-water mark was reset.
+# TYPE smf_idgenerator_peak_used gauge
+smf_idgenerator_peak_used{generator="seid"} 0
+smf_idgenerator_peak_used{generator="teid"} 4
+# HELP smf_idgenerator_used Number of allocated IDs.
+# TYPE smf_idgenerator_used gauge
+smf_idgenerator_used{generator="seid"} 0
+smf_idgenerator_used{generator="teid"} 3
+# HELP smf_idgenerator_utilization_ratio Ratio of allocated IDs to the capacity.
+# TYPE smf_idgenerator_utilization_ratio gauge
+smf_idgenerator_utilization_ratio{generator="seid"} 0
+smf_idgenerator_utilization_ratio{generator="teid"} 0.75
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected)); err != nil {
+		t.Error(err)
+	}
+
+	collector.Remove("seid")
+	if got := testutil.CollectAndCount(collector); got != 8 {
+		t.Errorf("collected %d metrics after Remove, want 8", got)
+	}
+}
+
+func TestCollectorFragmentation(t *testing.T) {
+	teid := idgenerator.NewGenerator(1, 10)
+	if err := teid.AllocateSpecific(4); err != nil {
+		t.Fatal(err)
+	}
+	pool := idgenerator.NewGeneratorPool()
+	if _, err := pool.GetOrCreate("n3", 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	collector := NewCollector("smf", WithFragmentation())
+	if err := collector.Add("teid", teid); err != nil {
+		t.Fatal(err)
+	}
+	if err := collector.AddPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP smf_idgenerator_free_fragments Number of runs of consecutive free IDs.
+# TYPE smf_idgenerator_free_fragments gauge
+smf_idgenerator_free_fragments{generator="n3"} 1
+smf_idgenerator_free_fragments{generator="teid"} 2
+# HELP smf_idgenerator_largest_free_block Length of the longest run of consecutive free IDs.
+# TYPE smf_idgenerator_largest_free_block gauge
+smf_idgenerator_largest_free_block{generator="n3"} 10
+smf_idgenerator_largest_free_block{generator="teid"} 6
+`
+	if err := testutil.GatherAndCompare(registry, strings.NewReader(expected),
+		"smf_idgenerator_free_fragments", "smf_idgenerator_largest_free_block"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorPool(t *testing.T) {
+	pool := idgenerator.NewGeneratorPool()
+	if _, err := pool.GetOrCreate("n3", 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	collector := NewCollector("")
+	if err := collector.AddPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.CollectAndCount(collector, "idgenerator_used"); got != 1 {
+		t.Errorf("collected %d used metrics, want 1", got)
+	}
+
+	// the generators created later are reported too
+	n9, err := pool.GetOrCreate("n9", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n9.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP idgenerator_used Number of allocated IDs.
+# TYPE idgenerator_used gauge
+idgenerator_used{generator="n3"} 0
+idgenerator_used{generator="n9"} 1
+`
+	if err = testutil.CollectAndCompare(collector, strings.NewReader(expected), "idgenerator_used"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorNames(t *testing.T) {
+	pool := idgenerator.NewGeneratorPool()
+	if _, err := pool.GetOrCreate("n3", 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	collector := NewCollector("")
+	if err := collector.Add("n3", idgenerator.NewGenerator(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if err := collector.AddPool(pool); !errors.Is(err, ErrAlreadyAdded) {
+		t.Errorf("adding a pool with a name in use returned %v, want ErrAlreadyAdded", err)
+	}
+	collector.Remove("n3")
+	if err := collector.AddPool(pool); err != nil {
+		t.Fatal(err)
+	}
+	if err := collector.AddPool(pool); !errors.Is(err, ErrAlreadyAdded) {
+		t.Errorf("adding a pool twice returned %v, want ErrAlreadyAdded", err)
+	}
+	if err := collector.Add("n3", idgenerator.NewGenerator(1, 10)); !errors.Is(err, ErrAlreadyAdded) {
+		t.Errorf("adding a name of a pool returned %v, want ErrAlreadyAdded", err)
+	}
+
+	// a clash created in a pool later is reported once, so that Gather does not fail
+	if err := collector.Add("n9", idgenerator.NewGenerator(1, 10)); err != nil {
+		t.Fatal(err)
+	}
+	n9, err := pool.GetOrCreate("n9", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n9.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err = registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	expected := `
+# HELP idgenerator_used Number of allocated IDs.
+# TYPE idgenerator_used gauge
+idgenerator_used{generator="n3"} 0
+idgenerator_used{generator="n9"} 0
+`
+	if err = testutil.GatherAndCompare(registry, strings.NewReader(expected), "idgenerator_used"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCollectorHoldTimes(t *testing.T) {
+	collector := NewCollector("smf")
+	teid, err := idgenerator.NewGeneratorWithOptions(1, 10,
+		idgenerator.WithHoldTimes(collector.ObserveHoldTime("teid")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.CollectAndCount(collector, "smf_idgenerator_hold_time_seconds"); got != 1 {
+		t.Errorf("collected %d hold time metrics, want 1", got)
+	}
+	id, err := teid.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = teid.FreeID(id); err != nil {
+		t.Fatal(err)
+	}
+	registry := prometheus.NewPedanticRegistry()
+	if err = registry.Register(collector); err != nil {
+		t.Fatal(err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	observed := false
+	for _, family := range families {
+		if family.GetName() != "smf_idgenerator_hold_time_seconds" {
+			continue
+		}
+		histogram := family.GetMetric()[0].GetHistogram()
+		// the ID was held for well under a second
+		if histogram.GetSampleCount() != 1 || histogram.GetSampleSum() >= 1 ||
+			histogram.GetBucket()[0].GetCumulativeCount() != 1 {
+			t.Errorf("unexpected histogram %v", histogram)
+		}
+		observed = true
+	}
+	if !observed {
+		t.Error("expected the hold time histogram to be gathered")
+	}
+
+	collector.Remove("teid")
+	if got := testutil.CollectAndCount(collector, "smf_idgenerator_hold_time_seconds"); got != 0 {
+		t.Errorf("collected %d hold time metrics after Remove, want 0", got)
+	}
+}
@@ -0,0 +1,109 @@
+package idgenerator
+
+import (
+	"crypto/rand"
+	"io"
+	mathrand "math/rand"
+)
+
+// Logger is the logging interface used by IDGenerator.
+// *log.Logger and *logrus.Entry both satisfy it.
+type Logger interface {
+	Printf(format string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Printf(string, ...interface{}) {}
+
+// Option configures an IDGenerator at construction time
+type Option func(*IDGenerator)
+
+// WithLogger routes the internal logging of IDGenerator to l.
+// Without this option IDGenerator logs nothing.
+func WithLogger(l Logger) Option {
+	return func(idGenerator *IDGenerator) {
+		if l != nil {
+			idGenerator.logger = l
+		}
+	}
+}
+
+// WithBitmapStore keeps the allocation state in a flat bitmap of one bit per ID instead of a tree of bitmaps.
+// The bitmap is allocated up front, (maxValue - minValue + 1) / 8 bytes,
+// so it pays off for small dense pools and costs memory for large, mostly empty ones.
+// Allocation scans it word by word, which gets slow in large pools close to exhaustion.
+// Constructing a generator of more than 2^40 IDs with it fails with ErrInvalidRange.
+func WithBitmapStore() Option {
+	return WithStore(NewBitmapStore)
+}
+
+// WithIntervalStore keeps the allocation state as a sorted list of free intervals instead of a tree of bitmaps.
+// Its memory grows with the fragmentation of the pool rather than with the number of allocated IDs
+// or the size of the range, which suits huge ranges with few allocations, e.g. the uint32 TEID space.
+func WithIntervalStore() Option {
+	return WithStore(NewIntervalStore)
+}
+
+// WithStore keeps the allocation state in the stores returned by newStore, e.g. to experiment with
+// another representation than those of the package, such as a compressed or a memory-mapped bitmap.
+// The generator calls newStore with the last offset, maxValue - minValue, on construction and again
+// whenever it needs a store of another size, on Resize, Split or restoring a snapshot;
+// it fails then with newStore. NewTreeStore is the default.
+func WithStore(newStore func(last uint64) (Store, error)) Option {
+	return func(idGenerator *IDGenerator) {
+		if newStore != nil {
+			idGenerator.newStore = newStore
+		}
+	}
+}
+
+// WithSequentialAllocation makes the generator allocate the next free ID after the last allocated one,
+// which is the default. Freed IDs are only reused after the allocation has wrapped around the range.
+func WithSequentialAllocation() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.strategy = StrategySequential
+		idGenerator.random = nil
+	}
+}
+
+// WithLowestFreeAllocation makes Allocate, and every other call taking the next free ID,
+// allocate the lowest free ID instead, so that freed low IDs are reused first and the used IDs stay compact.
+func WithLowestFreeAllocation() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.strategy = StrategyLowestFree
+		idGenerator.random = nil
+	}
+}
+
+// WithRandomAllocation makes Allocate, and every other call taking the next free ID,
+// pick a free ID uniformly at random from crypto/rand instead, so that the allocated IDs cannot be guessed.
+// AllocateContiguous, AllocateSpecific and ReserveRange are not affected.
+func WithRandomAllocation() Option {
+	return WithRandomAllocationFrom(rand.Reader)
+}
+
+// WithRandomAllocationFrom is WithRandomAllocation reading the randomness from source,
+// an error reading it fails the allocation.
+func WithRandomAllocationFrom(source io.Reader) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.strategy = StrategyRandom
+		idGenerator.random = source
+	}
+}
+
+// WithRandSource is WithRandomAllocation drawing from a math/rand source such as rand.NewSource(seed),
+// so that the same seed gives the same allocation order.
+// It is meant for tests and simulations only, as the IDs it allocates can be predicted.
+func WithRandSource(source mathrand.Source) Option {
+	return WithRandomAllocationFrom(mathrand.New(source))
+}
+
+// WithClock makes the generator read the time from clock instead of the system clock
+func WithClock(clock Clock) Option {
+	return func(idGenerator *IDGenerator) {
+		if clock != nil {
+			idGenerator.clock = clock
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package idgenerator
+
+import (
+	"errors"
+	"sort"
+)
+
+// AllocateFor allocates an ID like Allocate and tags it with owner, e.g. the peer connection it belongs to,
+// so that it can be looked up with Owner and freed with the other IDs of owner by FreeByOwner.
+// The tag is dropped when the ID is freed by any method. Tags are not part of snapshots.
+// It returns an error wrapping ErrQuotaExceeded if owner holds as many IDs as its quota, see SetQuota.
+func (idGenerator *IDGenerator) AllocateFor(owner string) (int64, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	// the quota is checked under the same lock as the allocation, so concurrent calls cannot overrun it
+	var id int64
+	err := idGenerator.checkQuotaLocked(owner)
+	if err == nil {
+		id, err = idGenerator.allocateLocked()
+	}
+	if err == nil {
+		idGenerator.ownLocked(idGenerator.toOffset(id), owner)
+		if idGenerator.audit != nil {
+			idGenerator.audit.tagLast(owner)
+		}
+	}
+	idGenerator.unlock()
+	if err != nil && !errors.Is(err, ErrQuotaExceeded) {
+		// an owner over its quota does not make the generator short of IDs
+		idGenerator.allocateFailed(err)
+	}
+	if idGenerator.recorder != nil {
+		idGenerator.recordFor(owner, id, err)
+	}
+	return id, err
+}
+
+// Owner returns the owner id is allocated for, or false if id is not allocated by AllocateFor
+func (idGenerator *IDGenerator) Owner(id int64) (string, bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return "", false
+	}
+	set, ok := idGenerator.ownerOf[idGenerator.toOffset(id)]
+	if !ok {
+		return "", false
+	}
+	return set.name, true
+}
+
+// FreeByOwner frees every ID allocated for owner at once and returns them in ascending order,
+// the permanent IDs of MarkPermanent stay allocated. The hook of WithOnFree is called for each freed ID.
+func (idGenerator *IDGenerator) FreeByOwner(owner string) []int64 {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	set, ok := idGenerator.owners[owner]
+	if !ok || idGenerator.closed {
+		return nil
+	}
+	offsets := make([]uint64, 0, len(set.offsets))
+	for offset := range set.offsets {
+		if !idGenerator.isPermanent(offset) {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+	if len(offsets) == 0 {
+		return nil
+	}
+	freed := make([]int64, len(offsets))
+	for i, offset := range offsets {
+		idGenerator.markFree(offset)
+		freed[i] = idGenerator.toID(offset)
+	}
+	idGenerator.serveWaitersLocked()
+	return freed
+}
+
+// ownerSet is an owner of AllocateFor with its offsets, the offsets point to it so that its name is stored once
+type ownerSet struct {
+	name    string
+	offsets map[uint64]struct{}
+}
+
+// ownLocked tags offset with owner, the caller must hold lock
+func (idGenerator *IDGenerator) ownLocked(offset uint64, owner string) {
+	if idGenerator.owners == nil {
+		idGenerator.owners = make(map[string]*ownerSet)
+		idGenerator.ownerOf = make(map[uint64]*ownerSet)
+	}
+	set, ok := idGenerator.owners[owner]
+	if !ok {
+		set = &ownerSet{name: owner, offsets: make(map[uint64]struct{})}
+		idGenerator.owners[owner] = set
+	}
+	set.offsets[offset] = struct{}{}
+	idGenerator.ownerOf[offset] = set
+}
+
+// disownLocked drops the tag of offset if it has one, the caller must hold lock
+func (idGenerator *IDGenerator) disownLocked(offset uint64) {
+	set, ok := idGenerator.ownerOf[offset]
+	if !ok {
+		return
+	}
+	delete(idGenerator.ownerOf, offset)
+	delete(set.offsets, offset)
+	if len(set.offsets) == 0 {
+		delete(idGenerator.owners, set.name)
+	}
+}
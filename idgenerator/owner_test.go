@@ -0,0 +1,107 @@
+package idgenerator
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFreeByOwner(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			var freedByHook []int64
+			opts := append([]Option{WithOnFree(func(id int64) {
+				freedByHook = append(freedByHook, id)
+			})}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, owner := range []string{"a", "b", "a", "", "b", "a"} {
+				if _, err = idGenerator.AllocateFor(owner); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err = idGenerator.Allocate(); err != nil {
+				t.Fatal(err)
+			}
+			for id, expected := range map[int64]string{1: "a", 2: "b", 4: "", 6: "a"} {
+				if owner, ok := idGenerator.Owner(id); !ok || owner != expected {
+					t.Errorf("Owner(%d): expected %q, output %q, %v", id, expected, owner, ok)
+				}
+			}
+			for _, id := range []int64{0, 7, 8} {
+				if owner, ok := idGenerator.Owner(id); ok {
+					t.Errorf("Owner(%d): expected no owner, output %q", id, owner)
+				}
+			}
+
+			// FreeID drops the tag
+			if err = idGenerator.FreeID(3); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := idGenerator.Owner(3); ok {
+				t.Error("freed ID still has an owner")
+			}
+			if freed := idGenerator.FreeByOwner("a"); !reflect.DeepEqual(freed, []int64{1, 6}) {
+				t.Errorf("expected [1 6] freed, output %v", freed)
+			}
+			if !reflect.DeepEqual(freedByHook, []int64{3, 1, 6}) {
+				t.Errorf("expected hook calls for [3 1 6], output %v", freedByHook)
+			}
+			if idGenerator.IsAllocated(1) || idGenerator.IsAllocated(6) || !idGenerator.IsAllocated(7) {
+				t.Error("FreeByOwner freed the wrong IDs")
+			}
+			if freed := idGenerator.FreeByOwner("a"); len(freed) != 0 {
+				t.Errorf("expected nothing freed twice, output %v", freed)
+			}
+			if freed := idGenerator.FreeByOwner("unknown"); len(freed) != 0 {
+				t.Errorf("expected nothing freed for an unknown owner, output %v", freed)
+			}
+
+			// a reallocated ID is not tagged with its former owner
+			if err = idGenerator.AllocateSpecific(1); err != nil {
+				t.Fatal(err)
+			}
+			if _, ok := idGenerator.Owner(1); ok {
+				t.Error("reallocated ID kept its former owner")
+			}
+			if freed := idGenerator.FreeByOwner("b"); !reflect.DeepEqual(freed, []int64{2, 5}) {
+				t.Errorf("expected [2 5] freed, output %v", freed)
+			}
+			if len(idGenerator.owners) != 1 || len(idGenerator.ownerOf) != 1 {
+				t.Errorf("owner index not cleaned up: %d owners, %d tagged IDs", len(idGenerator.owners), len(idGenerator.ownerOf))
+			}
+		})
+	}
+}
+
+func TestOwnerResizeAndClone(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(10, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, owner := range []string{"a", "b", "a"} {
+		if _, err = idGenerator.AllocateFor(owner); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = idGenerator.Resize(5, 15); err != nil {
+		t.Fatal(err)
+	}
+	if owner, ok := idGenerator.Owner(11); !ok || owner != "b" {
+		t.Errorf("expected owner b after Resize, output %q, %v", owner, ok)
+	}
+
+	clone := idGenerator.Clone()
+	if freed := clone.FreeByOwner("a"); !reflect.DeepEqual(freed, []int64{10, 12}) {
+		t.Errorf("expected [10 12] freed in the clone, output %v", freed)
+	}
+	if owner, ok := idGenerator.Owner(10); !ok || owner != "a" {
+		t.Errorf("FreeByOwner of the clone changed the original: %q, %v", owner, ok)
+	}
+
+	idGenerator.Reset()
+	if _, ok := idGenerator.Owner(11); ok || len(idGenerator.FreeByOwner("b")) != 0 {
+		t.Error("Reset kept the owners")
+	}
+}
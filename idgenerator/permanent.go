@@ -0,0 +1,141 @@
+package idgenerator
+
+import (
+	"fmt"
+	"sort"
+)
+
+// WithPermanentIDs marks ids permanent from the start, like MarkPermanent, e.g. for the statically provisioned
+// entities of the range. NewGeneratorWithOptions fails with ErrInvalidRange if one of them is out of the range,
+// NewGeneratorWithExclusions as well if one of them is excluded.
+func WithPermanentIDs(ids ...int64) Option {
+	return func(idGenerator *IDGenerator) {
+		if idGenerator.permanent == nil {
+			idGenerator.permanent = make(map[int64]struct{}, len(ids))
+		}
+		for _, id := range ids {
+			idGenerator.permanent[id] = struct{}{}
+		}
+	}
+}
+
+// validatePermanent returns the error of a permanent ID of WithPermanentIDs out of the range,
+// then sets the others in store
+func (idGenerator *IDGenerator) validatePermanent() error {
+	for id := range idGenerator.permanent {
+		if !idGenerator.inRange(id) {
+			return fmt.Errorf("%w: permanent ID[%d] not in [%d, %d]", ErrInvalidRange, id,
+				idGenerator.minValue, idGenerator.maxValue)
+		}
+	}
+	if len(idGenerator.permanent) > 0 {
+		idGenerator.setPermanentLocked()
+		idGenerator.resetRecyclingLocked()
+	}
+	return nil
+}
+
+// MarkPermanent marks id permanent, allocating it if it is free: it stays allocated through Reset, FreeRange,
+// FreeByOwner and the loads of a snapshot, and FreeID fails with ErrPermanent until UnmarkPermanent.
+// A lease of id is over, it is not freed when the lease would have expired, and so is its reservation of Reserve.
+// An ID marked twice is marked once. It returns an error wrapping ErrOutOfRange if id is outside
+// [minValue, maxValue], ErrReserved if id is excluded or ErrQuarantined if it is quarantined.
+// The marks are not part of snapshots, a state loaded into the generator keeps them.
+func (idGenerator *IDGenerator) MarkPermanent(id int64) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) {
+		return idGenerator.outOfRangeError(id)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.isExcluded(offset) {
+		return idGenerator.reservedError(id)
+	}
+	if idGenerator.isQuarantined(offset) {
+		return idGenerator.quarantinedError(id)
+	}
+	if !idGenerator.store.Has(offset) {
+		idGenerator.markUsed(offset)
+	}
+	// the stale entries of the lease heap are skipped
+	delete(idGenerator.leases, offset)
+	delete(idGenerator.reservations, offset)
+	// permanent IDs are held on purpose, they are not leaked
+	idGenerator.untrackLocked(offset)
+	if idGenerator.permanent == nil {
+		idGenerator.permanent = make(map[int64]struct{})
+	}
+	idGenerator.permanent[id] = struct{}{}
+	return nil
+}
+
+// UnmarkPermanent drops the mark of MarkPermanent on id, which stays allocated until FreeID,
+// and reports whether id was permanent
+func (idGenerator *IDGenerator) UnmarkPermanent(id int64) bool {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if idGenerator.closed {
+		return false
+	}
+	_, ok := idGenerator.permanent[id]
+	if ok {
+		delete(idGenerator.permanent, id)
+		idGenerator.trackLocked(idGenerator.toOffset(id))
+	}
+	return ok
+}
+
+// IsPermanent reports whether id is marked by MarkPermanent
+func (idGenerator *IDGenerator) IsPermanent(id int64) bool {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	_, ok := idGenerator.permanent[id]
+	return ok
+}
+
+// PermanentIDs returns the IDs marked by MarkPermanent in ascending order
+func (idGenerator *IDGenerator) PermanentIDs() []int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	ids := make([]int64, 0, len(idGenerator.permanent))
+	for id := range idGenerator.permanent {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// isPermanent reports whether the ID at offset is marked by MarkPermanent, the caller must hold lock
+func (idGenerator *IDGenerator) isPermanent(offset uint64) bool {
+	if len(idGenerator.permanent) == 0 {
+		return false
+	}
+	_, ok := idGenerator.permanent[idGenerator.toID(offset)]
+	return ok
+}
+
+// setPermanentLocked sets the permanent IDs in store which are not, e.g. after Reset, without counting them
+// as allocations, and drops the marks of those out of the range or excluded. The caller must hold lock.
+func (idGenerator *IDGenerator) setPermanentLocked() {
+	for id := range idGenerator.permanent {
+		offset := idGenerator.toOffset(id)
+		if !idGenerator.inRange(id) || idGenerator.isExcluded(offset) {
+			delete(idGenerator.permanent, id)
+			continue
+		}
+		if !idGenerator.store.Has(offset) {
+			idGenerator.store.Set(offset)
+			idGenerator.used++
+		}
+	}
+	if idGenerator.used > idGenerator.peakUsed {
+		idGenerator.peakUsed, idGenerator.peakAt = idGenerator.used, idGenerator.clock.Now()
+	}
+}
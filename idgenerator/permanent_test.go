@@ -0,0 +1,139 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPermanentIDs(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(1, 10, append(storeOption.opts, WithPermanentIDs(1, 5))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ids := allocateN(t, idGenerator, 3); !reflect.DeepEqual(ids, []int64{2, 3, 4}) {
+				t.Fatalf("expected the permanent IDs skipped, output %v", ids)
+			}
+			if err = idGenerator.FreeID(5); !errors.Is(err, ErrPermanent) {
+				t.Errorf("expected ErrPermanent, got %+v", err)
+			}
+			if stats := idGenerator.Stats(); stats.Used != 5 || stats.Permanent != 2 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+
+			// the permanent IDs stay allocated through the bulk frees
+			if freed, err := idGenerator.FreeRange(1, 10); err != nil || freed != 3 {
+				t.Fatalf("expected 3 IDs freed, output %d, %+v", freed, err)
+			}
+			allocateN(t, idGenerator, 8)
+			idGenerator.Reset()
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 5}) {
+				t.Fatalf("expected the permanent IDs allocated, output %v", ids)
+			}
+			if frees := idGenerator.Stats().Frees; frees != 11 {
+				t.Errorf("expected 11 frees, output %d", frees)
+			}
+
+			if !idGenerator.UnmarkPermanent(5) || idGenerator.UnmarkPermanent(5) {
+				t.Error("expected ID 5 unmarked once")
+			}
+			if !idGenerator.IsAllocated(5) {
+				t.Error("expected ID 5 allocated until freed")
+			}
+			freeAll(t, idGenerator, 5)
+			if ids := idGenerator.PermanentIDs(); !reflect.DeepEqual(ids, []int64{1}) {
+				t.Errorf("expected the permanent ID 1, output %v", ids)
+			}
+			if err = idGenerator.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestMarkPermanent(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a free ID is allocated, a leased one is not freed when its lease would have expired
+	if err = idGenerator.MarkPermanent(7); err != nil {
+		t.Fatal(err)
+	}
+	leased, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.MarkPermanent(leased); err != nil {
+		t.Fatal(err)
+	}
+	owned, err := idGenerator.AllocateFor("provisioning")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.MarkPermanent(owned); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(time.Hour)
+	if freed := idGenerator.FreeByOwner("provisioning"); freed != nil {
+		t.Errorf("expected no ID freed, output %v", freed)
+	}
+	for _, id := range []int64{7, leased, owned} {
+		if !idGenerator.IsAllocated(id) || !idGenerator.IsPermanent(id) {
+			t.Errorf("expected ID %d allocated and permanent", id)
+		}
+	}
+	if used := idGenerator.Used(); used != 3 {
+		t.Errorf("expected 3 IDs used, output %d", used)
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// a snapshot loaded into the generator keeps the marks
+	data, err := NewGenerator(1, 10).MarshalJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.UnmarshalJSON(data); err != nil {
+		t.Fatal(err)
+	}
+	if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{leased, owned, 7}) {
+		t.Errorf("expected the permanent IDs allocated, output %v", ids)
+	}
+	if clone := idGenerator.Clone(); !clone.IsPermanent(7) {
+		t.Error("expected the clone to keep the permanent IDs")
+	}
+}
+
+func TestPermanentInvalid(t *testing.T) {
+	if _, err := NewGeneratorWithOptions(1, 10, WithPermanentIDs(11)); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+	_, err := NewGeneratorWithExclusions(1, 10, []int64{3}, WithPermanentIDs(3))
+	if !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+	idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{3}, WithReuseDelay(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.MarkPermanent(11); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.MarkPermanent(3); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	freeAll(t, idGenerator, id)
+	if err = idGenerator.MarkPermanent(id); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %+v", err)
+	}
+}
@@ -0,0 +1,384 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PersistentIDGenerator is an IDGenerator whose state is kept in a file.
+// The file is rewritten atomically (write to a temporary file, then rename over the
+// original), so a crash leaves either the previous or the new state, never a partial one.
+//
+// By default the state is written by every mutating call before it returns,
+// and a failed write undoes the change and is returned to the caller.
+// With WithFlushInterval the state is written in the background instead, a failed write is logged
+// and retried at the next interval, Flush or Close, which return its error; the mutating calls do not fail with it.
+type PersistentIDGenerator struct {
+	mu        sync.Mutex
+	generator *IDGenerator
+	path      string
+
+	generatorOpts []Option
+	flushInterval time.Duration
+	dirty         bool
+	stop          chan struct{}
+	done          chan struct{}
+	closed        bool
+}
+
+// PersistentOption configures a PersistentIDGenerator
+type PersistentOption func(*PersistentIDGenerator)
+
+// WithGeneratorOptions passes opts to the underlying IDGenerator.
+// The hooks of WithOnAllocate and WithOnFree see an allocation undone after a failed write
+// as the allocation followed by a free, a FreeID whose write fails does not call them.
+func WithGeneratorOptions(opts ...Option) PersistentOption {
+	return func(p *PersistentIDGenerator) {
+		p.generatorOpts = append(p.generatorOpts, opts...)
+	}
+}
+
+// WithFlushInterval writes the state every interval of the clock of WithClock, if passed with WithGeneratorOptions,
+// instead of after every mutating call
+func WithFlushInterval(interval time.Duration) PersistentOption {
+	return func(p *PersistentIDGenerator) {
+		p.flushInterval = interval
+	}
+}
+
+// NewPersistentGenerator opens the generator stored at path, or creates it if the file does not exist.
+// An existing file must hold a generator over [minValue, maxValue], otherwise an error wrapping
+// ErrInvalidRange is returned. Leftover temporary files of an interrupted write are ignored.
+func NewPersistentGenerator(path string, minValue, maxValue int64, opts ...PersistentOption) (
+	*PersistentIDGenerator, error,
+) {
+	p := &PersistentIDGenerator{path: path}
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	generator, err := NewGeneratorWithOptions(minValue, maxValue, p.generatorOpts...)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = generator.UnmarshalBinary(data); err != nil {
+			return nil, fmt.Errorf("load %s: %w", path, err)
+		}
+		if generator.minValue != minValue || generator.maxValue != maxValue {
+			return nil, fmt.Errorf("load %s: %w: file holds [%d, %d], requested [%d, %d]", path,
+				ErrInvalidRange, generator.minValue, generator.maxValue, minValue, maxValue)
+		}
+	case errors.Is(err, os.ErrNotExist):
+		if err = writeFileAtomic(path, encodeBinary(generator.Snapshot())); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+	p.generator = generator
+
+	if p.flushInterval > 0 {
+		p.stop = make(chan struct{})
+		p.done = make(chan struct{})
+		go p.flushLoop()
+	}
+	return p, nil
+}
+
+// Allocate allocates an ID like IDGenerator.Allocate and persists the new state
+func (p *PersistentIDGenerator) Allocate() (int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkLocked(); err != nil {
+		return 0, err
+	}
+	mark := p.generator.markUndo()
+	id, err := p.generator.Allocate()
+	if err != nil {
+		return 0, err
+	}
+	if err = p.persistLocked(); err != nil {
+		p.generator.undoAllocations(mark, []int64{id})
+		return 0, err
+	}
+	return id, nil
+}
+
+// AllocateMany allocates n IDs like IDGenerator.AllocateMany and persists the new state
+func (p *PersistentIDGenerator) AllocateMany(n int) ([]int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkLocked(); err != nil {
+		return nil, err
+	}
+	mark := p.generator.markUndo()
+	ids, err := p.generator.AllocateMany(n)
+	if err != nil {
+		return nil, err
+	}
+	if err = p.persistLocked(); err != nil {
+		p.generator.undoAllocations(mark, ids)
+		return nil, err
+	}
+	return ids, nil
+}
+
+// AllocateSpecific allocates id like IDGenerator.AllocateSpecific and persists the new state
+func (p *PersistentIDGenerator) AllocateSpecific(id int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkLocked(); err != nil {
+		return err
+	}
+	mark := p.generator.markUndo()
+	if err := p.generator.AllocateSpecific(id); err != nil {
+		return err
+	}
+	if err := p.persistLocked(); err != nil {
+		p.generator.undoAllocations(mark, []int64{id})
+		return err
+	}
+	return nil
+}
+
+// FreeID frees id like IDGenerator.FreeID and persists the new state.
+// Without WithFlushInterval the state without id is written first, id is freed once the write succeeded,
+// so that a free would not have to be undone: its quarantine, lease or owner could not be restored.
+func (p *PersistentIDGenerator) FreeID(id int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := p.checkLocked(); err != nil {
+		return err
+	}
+	if p.flushInterval == 0 {
+		if snapshot, ok := p.generator.snapshotFreeing(id); ok {
+			if err := writeFileAtomic(p.path, encodeBinary(snapshot)); err != nil {
+				return err
+			}
+		}
+		// a free failing or changing nothing is not written
+		return p.generator.FreeID(id)
+	}
+	if err := p.generator.FreeID(id); err != nil {
+		return err
+	}
+	p.dirty = true
+	return nil
+}
+
+// IsAllocated reports whether id is allocated
+func (p *PersistentIDGenerator) IsAllocated(id int64) bool {
+	return p.generator.IsAllocated(id)
+}
+
+// Used returns the number of allocated IDs
+func (p *PersistentIDGenerator) Used() int64 {
+	return p.generator.Used()
+}
+
+// Available returns the number of IDs that can still be allocated
+func (p *PersistentIDGenerator) Available() int64 {
+	return p.generator.Available()
+}
+
+// Stats returns the counters of the underlying generator, the changes undone after a failed write are not counted
+func (p *PersistentIDGenerator) Stats() Stats {
+	return p.generator.Stats()
+}
+
+// DetailedStats returns the DetailedStats of the underlying generator, like Stats
+func (p *PersistentIDGenerator) DetailedStats() Stats {
+	return p.generator.DetailedStats()
+}
+
+// Snapshot returns the current in-memory state, which may be ahead of the file with WithFlushInterval
+func (p *PersistentIDGenerator) Snapshot() Snapshot {
+	return p.generator.Snapshot()
+}
+
+// Flush writes the current state to the file if it changed since the last successful write
+func (p *PersistentIDGenerator) Flush() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.flushLocked()
+}
+
+// Close stops the background flush, if any, writes the final state and closes the underlying generator.
+// The calls changing the state fail with an error wrapping ErrClosed after Close.
+func (p *PersistentIDGenerator) Close() error {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return nil
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	if p.stop != nil {
+		close(p.stop)
+		<-p.done
+	}
+	if err := p.Flush(); err != nil {
+		return err
+	}
+	// the allocated IDs are kept in the file for the next run, they are not leaked
+	_, err := p.generator.Close()
+	return err
+}
+
+// checkLocked fails if p is closed
+func (p *PersistentIDGenerator) checkLocked() error {
+	if p.closed {
+		return fmt.Errorf("%w: persistent generator %s", ErrClosed, p.path)
+	}
+	return nil
+}
+
+func (p *PersistentIDGenerator) persistLocked() error {
+	if p.flushInterval > 0 {
+		p.dirty = true
+		return nil
+	}
+	return writeFileAtomic(p.path, encodeBinary(p.generator.Snapshot()))
+}
+
+func (p *PersistentIDGenerator) flushLocked() error {
+	if !p.dirty {
+		return nil
+	}
+	if err := writeFileAtomic(p.path, encodeBinary(p.generator.Snapshot())); err != nil {
+		return err
+	}
+	p.dirty = false
+	return nil
+}
+
+func (p *PersistentIDGenerator) flushLoop() {
+	defer close(p.done)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-p.generator.clock.After(p.flushInterval):
+			p.mu.Lock()
+			if p.dirty {
+				if err := writeFileAtomic(p.path, encodeBinary(p.generator.Snapshot())); err != nil {
+					p.generator.logger.Printf("idgenerator: persistent generator %s: flush failed: %v", p.path, err)
+				} else {
+					p.dirty = false
+				}
+			}
+			p.mu.Unlock()
+		}
+	}
+}
+
+// undoMark is the state markUndo reads before an allocation, which undoAllocations restores
+type undoMark struct {
+	offset   uint64
+	peakUsed uint64
+	peakAt   time.Time
+}
+
+// markUndo returns the scan offset and the high-water mark, for undoAllocations
+func (idGenerator *IDGenerator) markUndo() undoMark {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return undoMark{offset: idGenerator.offset, peakUsed: idGenerator.peakUsed, peakAt: idGenerator.peakAt}
+}
+
+// undoAllocations gives back ids, allocated since mark, after the write of a PersistentIDGenerator failed:
+// they are free at once like with unmarkUsed, without quarantine nor counting as freed, and the scan offset
+// and the high-water mark of mark are restored. The hooks of WithOnFree see them freed.
+// The PersistentIDGenerator serializes its changes, so the IDs are still allocated
+// unless the generator is used around it, the ones which are not are skipped.
+func (idGenerator *IDGenerator) undoAllocations(mark undoMark, ids []int64) {
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	// in reverse order, so that the allocations are dropped from the audit
+	for i := len(ids) - 1; i >= 0; i-- {
+		if !idGenerator.inRange(ids[i]) {
+			continue
+		}
+		offset := idGenerator.toOffset(ids[i])
+		if !idGenerator.store.Has(offset) || idGenerator.isHeld(offset) || idGenerator.isPermanent(offset) {
+			continue
+		}
+		idGenerator.unmarkUsed(offset)
+		idGenerator.queueEvent(idGenerator.onFree, offset)
+	}
+	idGenerator.offset = mark.offset
+	idGenerator.peakUsed, idGenerator.peakAt = mark.peakUsed, mark.peakAt
+	idGenerator.serveWaitersLocked()
+}
+
+// snapshotFreeing returns the snapshot of the state FreeID(id) would leave, and false if it would fail
+// or leave the state unchanged, e.g. for an ID whose lease expired
+func (idGenerator *IDGenerator) snapshotFreeing(id int64) (Snapshot, bool) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) || idGenerator.closed {
+		return Snapshot{}, false
+	}
+	offset := idGenerator.toOffset(id)
+	if _, ok := idGenerator.expired[offset]; ok || !idGenerator.store.Has(offset) || idGenerator.isHeld(offset) ||
+		idGenerator.isPermanent(offset) {
+		return Snapshot{}, false
+	}
+	snapshot := idGenerator.snapshotLocked()
+	i := sort.Search(len(snapshot.Used), func(i int) bool {
+		return snapshot.Used[i] >= id
+	})
+	if i == len(snapshot.Used) || snapshot.Used[i] != id {
+		return Snapshot{}, false
+	}
+	snapshot.Used = append(snapshot.Used[:i], snapshot.Used[i+1:]...)
+	return snapshot, true
+}
+
+// writeFileAtomic replaces the file at path with data via a temporary file in the same directory
+func writeFileAtomic(path string, data []byte) error {
+	tmpPath := path + ".tmp"
+	file, err := os.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		if removeErr := os.Remove(tmpPath); removeErr != nil && !errors.Is(removeErr, os.ErrNotExist) {
+			return fmt.Errorf("%v (remove %s: %v)", err, tmpPath, removeErr)
+		}
+		return err
+	}
+	// make the rename durable, directories cannot be synced on windows
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	dir, err := os.Open(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if closeErr := dir.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
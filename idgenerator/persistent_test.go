@@ -0,0 +1,290 @@
+package idgenerator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPersistentGenerator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.idgen")
+
+	p, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(path); err != nil {
+		t.Fatalf("expected the state file to be created: %+v", err)
+	}
+	if _, err = p.AllocateMany(3); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.AllocateSpecific(8); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.FreeID(2); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	expected := p.Snapshot()
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed from a closed generator, got %+v", err)
+	}
+
+	reopened, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(reopened.Snapshot(), expected) {
+		t.Errorf("expected snapshot %+v, output %+v", expected, reopened.Snapshot())
+	}
+	if err = reopened.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("range mismatch", func(t *testing.T) {
+		if _, err := NewPersistentGenerator(path, 1, 20); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("expected ErrInvalidRange, got %+v", err)
+		}
+	})
+
+	t.Run("corrupt file", func(t *testing.T) {
+		corruptPath := filepath.Join(t.TempDir(), "corrupt.idgen")
+		if err := os.WriteFile(corruptPath, []byte{binaryVersion, 2}, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewPersistentGenerator(corruptPath, 1, 10); !errors.Is(err, ErrInvalidEncoding) {
+			t.Errorf("expected ErrInvalidEncoding, got %+v", err)
+		}
+	})
+}
+
+func TestPersistentGeneratorRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.idgen")
+
+	p, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.AllocateMany(4); err != nil {
+		t.Fatal(err)
+	}
+	expected := p.Snapshot()
+
+	// a crash in the middle of the next write leaves a partial temporary file behind
+	data, err := p.generator.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path+".tmp", data[:len(data)/2], 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	recovered, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(recovered.Snapshot(), expected) {
+		t.Errorf("expected snapshot %+v, output %+v", expected, recovered.Snapshot())
+	}
+	id, err := recovered.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 5 {
+		t.Errorf("expected id: 5, output id: %d", id)
+	}
+
+	t.Run("write failure", func(t *testing.T) {
+		// a directory in place of the temporary file makes every write fail
+		if err := os.Mkdir(path+".tmp", 0o700); err != nil {
+			t.Fatal(err)
+		}
+		defer func() {
+			if err := os.Remove(path + ".tmp"); err != nil {
+				t.Error(err)
+			}
+		}()
+		before := recovered.Snapshot()
+
+		if _, err := recovered.Allocate(); err == nil {
+			t.Error("expect return error, but error is nil")
+		}
+		if _, err := recovered.AllocateMany(2); err == nil {
+			t.Error("expect return error, but error is nil")
+		}
+		if err := recovered.AllocateSpecific(9); err == nil {
+			t.Error("expect return error, but error is nil")
+		}
+		if err := recovered.FreeID(1); err == nil {
+			t.Error("expect return error, but error is nil")
+		}
+		// failed writes are rolled back, memory still matches the file
+		if !reflect.DeepEqual(recovered.Snapshot(), before) {
+			t.Errorf("expected snapshot %+v, output %+v", before, recovered.Snapshot())
+		}
+	})
+}
+
+func TestPersistentGeneratorFlushInterval(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.idgen")
+
+	clock := newFakeClock()
+	p, err := NewPersistentGenerator(path, 1, 10, WithFlushInterval(time.Hour),
+		WithGeneratorOptions(WithBitmapStore(), WithClock(clock)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.AllocateMany(3); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing is written before the interval elapses
+	onDisk, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := onDisk.Used(); used != 0 {
+		t.Errorf("expected used: 0 on disk, output used: %d", used)
+	}
+	// the flush loop waits for the next interval once it has written the state
+	clock.waitForTimers(1)
+	clock.Advance(time.Hour)
+	clock.waitForTimers(1)
+	if onDisk, err = NewPersistentGenerator(path, 1, 10); err != nil {
+		t.Fatal(err)
+	}
+	if used := onDisk.Used(); used != 3 {
+		t.Errorf("expected used: 3 on disk after the interval, output used: %d", used)
+	}
+
+	if err = p.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Errorf("second Close: %+v", err)
+	}
+
+	onDisk, err = NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := onDisk.Used(); used != 4 {
+		t.Errorf("expected used: 4 on disk, output used: %d", used)
+	}
+}
+
+func TestPersistentGeneratorUndo(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.idgen")
+	var frees []int64
+	p, err := NewPersistentGenerator(path, 1, 8, WithGeneratorOptions(WithClock(newFakeClock()),
+		WithReuseDelay(time.Hour), WithOnFree(func(id int64) { frees = append(frees, id) })))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	expected := p.Stats()
+
+	// a directory in place of the temporary file makes every write fail
+	if err = os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	if _, err = p.AllocateMany(3); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	if err = p.AllocateSpecific(5); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	if err = p.FreeID(1); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	// the undone allocations are neither quarantined nor counted, the failed free is not done at all
+	if stats := p.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	if available := p.Available(); available != 7 || !p.IsAllocated(1) {
+		t.Errorf("expected ID 1 allocated and 7 IDs available, output %d", available)
+	}
+	if !reflect.DeepEqual(frees, []int64{2, 4, 3, 2, 5}) {
+		t.Errorf("expected the hooks to see the undone allocations freed, output %v", frees)
+	}
+
+	if err = os.Remove(path + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.FreeID(1); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := p.Allocate(); err != nil || id != 2 {
+		t.Errorf("expected ID 2 at the restored offset, output %d, %+v", id, err)
+	}
+	onDisk, err := NewPersistentGenerator(path, 1, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := onDisk.generator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{2}) {
+		t.Errorf("expected ID 2 on disk, output %v", ids)
+	}
+}
+
+func TestPersistentGeneratorFlushFailure(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "pool.idgen")
+	clock := newFakeClock()
+	p, err := NewPersistentGenerator(path, 1, 10, WithFlushInterval(time.Hour),
+		WithGeneratorOptions(WithClock(clock)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Mkdir(path+".tmp", 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = p.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	clock.waitForTimers(1)
+	clock.Advance(time.Hour)
+	clock.waitForTimers(1)
+
+	// the failed background write does not fail the next call, Flush retries it
+	if _, err = p.Allocate(); err != nil {
+		t.Errorf("expected the failed flush left to Flush, got %+v", err)
+	}
+	if err = p.Flush(); err == nil {
+		t.Error("expect return error, but error is nil")
+	}
+	if err = os.Remove(path + ".tmp"); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.Close(); err != nil {
+		t.Fatal(err)
+	}
+	onDisk, err := NewPersistentGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := onDisk.Used(); used != 2 {
+		t.Errorf("expected used: 2 on disk, output used: %d", used)
+	}
+}
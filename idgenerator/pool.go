@@ -0,0 +1,121 @@
+package idgenerator
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// GeneratorPool is a registry of generators by name, e.g. one per interface or per network slice.
+// It is safe for concurrent use.
+type GeneratorPool struct {
+	mtx        sync.Mutex
+	opts       []Option
+	generators map[string]*IDGenerator
+}
+
+// NewGeneratorPool returns an empty GeneratorPool, opts are applied to every generator it creates
+func NewGeneratorPool(opts ...Option) *GeneratorPool {
+	return &GeneratorPool{
+		opts:       opts,
+		generators: make(map[string]*IDGenerator),
+	}
+}
+
+// GetOrCreate returns the generator named name, creating it with minValue and maxValue if there is none.
+// It returns an error wrapping ErrGeneratorExists if the generator has other bounds,
+// or fails like NewGeneratorWithOptions.
+func (pool *GeneratorPool) GetOrCreate(name string, minValue, maxValue int64) (*IDGenerator, error) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	if generator, ok := pool.generators[name]; ok {
+		if currentMin, currentMax := generator.bounds(); currentMin != minValue || currentMax != maxValue {
+			return nil, fmt.Errorf("%w: %q has range [%d, %d], not [%d, %d]",
+				ErrGeneratorExists, name, currentMin, currentMax, minValue, maxValue)
+		}
+		return generator, nil
+	}
+	opts := append(append([]Option(nil), pool.opts...), WithName(name))
+	generator, err := NewGeneratorWithOptions(minValue, maxValue, opts...)
+	if err != nil {
+		return nil, err
+	}
+	pool.generators[name] = generator
+	return generator, nil
+}
+
+// Get returns the generator named name, or false if there is none
+func (pool *GeneratorPool) Get(name string) (*IDGenerator, bool) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	generator, ok := pool.generators[name]
+	return generator, ok
+}
+
+// Delete removes the generator named name from the pool, it does nothing if there is none.
+// The generator itself is left as is for the callers still holding it.
+func (pool *GeneratorPool) Delete(name string) {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	delete(pool.generators, name)
+}
+
+// Names returns the names of the generators in the pool in ascending order
+func (pool *GeneratorPool) Names() []string {
+	pool.mtx.Lock()
+	defer pool.mtx.Unlock()
+	names := make([]string, 0, len(pool.generators))
+	for name := range pool.generators {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// StatsByName returns the counters of every generator in the pool by name.
+// The generators are read one after the other, so concurrent calls may be counted in part.
+func (pool *GeneratorPool) StatsByName() map[string]Stats {
+	return pool.statsByName((*IDGenerator).Stats)
+}
+
+// DetailedStatsByName returns the DetailedStats of every generator in the pool by name, it is read like StatsByName
+func (pool *GeneratorPool) DetailedStatsByName() map[string]Stats {
+	return pool.statsByName((*IDGenerator).DetailedStats)
+}
+
+// statsByName returns the counters read by read of every generator in the pool by name
+func (pool *GeneratorPool) statsByName(read func(*IDGenerator) Stats) map[string]Stats {
+	// read the generators outside mtx, so that a busy one does not block the pool
+	pool.mtx.Lock()
+	generators := make(map[string]*IDGenerator, len(pool.generators))
+	for name, generator := range pool.generators {
+		generators[name] = generator
+	}
+	pool.mtx.Unlock()
+
+	stats := make(map[string]Stats, len(generators))
+	for name, generator := range generators {
+		stats[name] = read(generator)
+	}
+	return stats
+}
+
+// Stats returns the sum of the counters of the generators in the pool, it is read like StatsByName.
+// MinValue, MaxValue and Offset are 0, as the generators have ranges of their own.
+func (pool *GeneratorPool) Stats() Stats {
+	return sumStats(pool.StatsByName())
+}
+
+// DetailedStats returns the sum of the DetailedStats of the generators in the pool, like Stats
+func (pool *GeneratorPool) DetailedStats() Stats {
+	return sumStats(pool.DetailedStatsByName())
+}
+
+// sumStats returns the sum of the counters of byName
+func sumStats(byName map[string]Stats) Stats {
+	var stats Stats
+	for _, generatorStats := range byName {
+		stats.add(generatorStats)
+	}
+	return stats
+}
@@ -0,0 +1,116 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestGeneratorPool(t *testing.T) {
+	clock := newFakeClock()
+	pool := NewGeneratorPool(WithIntervalStore(), WithClock(clock))
+	n3, err := pool.GetOrCreate("n3", 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n3.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	// the same generator is returned for the same name and bounds
+	if again, err := pool.GetOrCreate("n3", 1, 10); err != nil || again != n3 {
+		t.Errorf("expected the generator of n3, output: %p, %+v", again, err)
+	}
+	if _, err = pool.GetOrCreate("n3", 1, 20); !errors.Is(err, ErrGeneratorExists) {
+		t.Errorf("expected ErrGeneratorExists, got %+v", err)
+	}
+	if _, err = pool.GetOrCreate("n9", 10, 1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+	n9, err := pool.GetOrCreate("n9", 100, 199)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = n9.AllocateMany(2); err != nil {
+		t.Fatal(err)
+	}
+
+	if names := pool.Names(); !reflect.DeepEqual(names, []string{"n3", "n9"}) {
+		t.Errorf("unexpected names: %v", names)
+	}
+	expected := Stats{
+		Strategy:    StrategySequential,
+		Used:        3,
+		References:  3,
+		Free:        107,
+		Capacity:    110,
+		Allocations: 3,
+		PeakUsed:    3,
+		PeakAt:      clock.Now(),
+		// the blocks of n3 and n9, the largest one in n3
+		LargestFreeBlock: 98,
+		FreeFragments:    2,
+	}
+	if stats := pool.DetailedStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	expected.LargestFreeBlock, expected.FreeFragments = 0, 0
+	if stats := pool.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	if stats := pool.StatsByName(); len(stats) != 2 || stats["n9"].Used != 2 {
+		t.Errorf("unexpected stats by name: %#v", stats)
+	}
+
+	// a resized generator is only found with its new bounds
+	if err = n3.Resize(1, 20); err != nil {
+		t.Fatal(err)
+	}
+	if again, err := pool.GetOrCreate("n3", 1, 20); err != nil || again != n3 {
+		t.Errorf("expected the generator of n3, output: %p, %+v", again, err)
+	}
+
+	pool.Delete("n3")
+	pool.Delete("n4")
+	if _, ok := pool.Get("n3"); ok {
+		t.Error("deleted generator still in the pool")
+	}
+	if generator, ok := pool.Get("n9"); !ok || generator != n9 {
+		t.Errorf("expected the generator of n9, output: %p, %v", generator, ok)
+	}
+	if created, err := pool.GetOrCreate("n3", 1, 10); err != nil || created == n3 || created.Used() != 0 {
+		t.Errorf("expected a new generator for n3, output: %p, %+v", created, err)
+	}
+}
+
+func TestGeneratorPoolConcurrency(t *testing.T) {
+	pool := NewGeneratorPool()
+	generators := make([]*IDGenerator, 16)
+	wg := sync.WaitGroup{}
+	for routineID := range generators {
+		wg.Add(1)
+		go func(routineID int) {
+			defer wg.Done()
+			generator, err := pool.GetOrCreate(fmt.Sprintf("slice-%d", routineID%4), 1, 1000)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			if _, err = generator.Allocate(); err != nil {
+				t.Error(err)
+			}
+			generators[routineID] = generator
+		}(routineID)
+	}
+	wg.Wait()
+
+	for routineID, generator := range generators {
+		if generator != generators[routineID%4] {
+			t.Errorf("routine %d got another generator than routine %d", routineID, routineID%4)
+		}
+	}
+	if used := pool.Stats().Used; used != 16 {
+		t.Errorf("expected used: 16, output used: %d", used)
+	}
+}
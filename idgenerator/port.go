@@ -0,0 +1,182 @@
+package idgenerator
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+)
+
+// PortAllocator allocates TCP or UDP port numbers, in [1024, 65535] unless WithPortRange sets other bounds.
+// It is safe for concurrent use and allocates like an IDGenerator.
+type PortAllocator struct {
+	generator *IDGenerator
+
+	minPort  uint16
+	maxPort  uint16
+	reserved [][2]uint16
+	// bindNetwork is the network of WithBindCheck, "" if ports are not checked
+	bindNetwork string
+}
+
+// PortAllocatorOption configures a PortAllocator
+type PortAllocatorOption func(*PortAllocator)
+
+// WithPortRange sets the bounds of the allocated ports to [minPort, maxPort]
+func WithPortRange(minPort, maxPort uint16) PortAllocatorOption {
+	return func(a *PortAllocator) {
+		a.minPort = minPort
+		a.maxPort = maxPort
+	}
+}
+
+// WithReservedPorts keeps ports from ever being allocated, see WithReservedPortRange
+func WithReservedPorts(ports ...uint16) PortAllocatorOption {
+	return func(a *PortAllocator) {
+		for _, port := range ports {
+			a.reserved = append(a.reserved, [2]uint16{port, port})
+		}
+	}
+}
+
+// WithReservedPortRange keeps the ports in [first, last] from ever being allocated:
+// AllocateSpecificPort and FreePort reject them with an error wrapping ErrReserved.
+// The ports outside the bounds of the allocator are ignored.
+func WithReservedPortRange(first, last uint16) PortAllocatorOption {
+	return func(a *PortAllocator) {
+		a.reserved = append(a.reserved, [2]uint16{first, last})
+	}
+}
+
+// WithBindCheck makes the allocator bind every port on network, "tcp" or "udp", before handing it out
+// and close the listener right away, so that the ports in use by other sockets of the host are skipped.
+// It is off by default, as it costs two system calls per allocation.
+func WithBindCheck(network string) PortAllocatorOption {
+	return func(a *PortAllocator) {
+		a.bindNetwork = network
+	}
+}
+
+// NewPortAllocator initializes a PortAllocator and applies opts to it.
+// It returns an error wrapping ErrInvalidRange if the bounds are reversed or include port 0,
+// or if a reserved range of WithReservedPortRange is reversed.
+func NewPortAllocator(opts ...PortAllocatorOption) (*PortAllocator, error) {
+	a := &PortAllocator{
+		minPort: 1024,
+		maxPort: 65535,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.minPort == 0 || a.minPort > a.maxPort {
+		return nil, fmt.Errorf("%w: invalid port range [%d, %d]", ErrInvalidRange, a.minPort, a.maxPort)
+	}
+	switch a.bindNetwork {
+	case "", "tcp", "udp":
+	default:
+		return nil, fmt.Errorf("bind check on unsupported network %q", a.bindNetwork)
+	}
+	var excluded []int64
+	for _, reserved := range a.reserved {
+		if reserved[0] > reserved[1] {
+			return nil, fmt.Errorf("%w: invalid reserved ports [%d, %d]", ErrInvalidRange, reserved[0], reserved[1])
+		}
+		for port := int64(reserved[0]); port <= int64(reserved[1]); port++ {
+			if port >= int64(a.minPort) && port <= int64(a.maxPort) {
+				excluded = append(excluded, port)
+			}
+		}
+	}
+	generator, err := NewGeneratorWithExclusions(int64(a.minPort), int64(a.maxPort), excluded, WithBitmapStore())
+	if err != nil {
+		return nil, err
+	}
+	a.generator = generator
+	return a, nil
+}
+
+// bind checks that port is not in use on the host, if WithBindCheck is set
+func (a *PortAllocator) bind(port uint16) error {
+	address := net.JoinHostPort("", strconv.Itoa(int(port)))
+	switch a.bindNetwork {
+	case "tcp":
+		listener, err := net.Listen(a.bindNetwork, address)
+		if err != nil {
+			return err
+		}
+		return listener.Close()
+	case "udp":
+		conn, err := net.ListenPacket(a.bindNetwork, address)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+	return nil
+}
+
+// AllocatePort allocates a free port like IDGenerator.Allocate.
+// With WithBindCheck, the ports which cannot be bound are skipped and left free for later calls,
+// it returns an error wrapping ErrPoolExhausted if none of the free ports can be bound.
+func (a *PortAllocator) AllocatePort() (uint16, error) {
+	var unbound []int64
+	defer func() {
+		for _, id := range unbound {
+			if err := a.generator.FreeID(id); err != nil {
+				a.generator.logger.Printf("idgenerator: port allocator: release port %d: %v", id, err)
+			}
+		}
+	}()
+	for {
+		id, err := a.generator.Allocate()
+		if err != nil {
+			return 0, err
+		}
+		if err = a.bind(uint16(id)); err == nil {
+			return uint16(id), nil
+		}
+		unbound = append(unbound, id)
+	}
+}
+
+// AllocateSpecificPort allocates exactly port like IDGenerator.AllocateSpecific.
+// It returns an error wrapping ErrOutOfRange if port is outside the bounds,
+// ErrReserved if it is reserved, or ErrAlreadyAllocated if it is allocated
+// or, with WithBindCheck, cannot be bound.
+func (a *PortAllocator) AllocateSpecificPort(port uint16) error {
+	if err := a.generator.AllocateSpecific(int64(port)); err != nil {
+		return err
+	}
+	if err := a.bind(port); err != nil {
+		if freeErr := a.generator.FreeID(int64(port)); freeErr != nil {
+			return freeErr
+		}
+		return fmt.Errorf("%w: port %d cannot be bound: %v", ErrAlreadyAllocated, port, err)
+	}
+	return nil
+}
+
+// FreePort releases port like IDGenerator.FreeID, it returns an error wrapping ErrNotAllocated
+// if port is not allocated, ErrOutOfRange if it is outside the bounds or ErrReserved if it is reserved
+func (a *PortAllocator) FreePort(port uint16) error {
+	return a.generator.FreeID(int64(port))
+}
+
+// IsAllocated reports whether port is allocated
+func (a *PortAllocator) IsAllocated(port uint16) bool {
+	return a.generator.IsAllocated(int64(port))
+}
+
+// Available returns the number of ports that can still be allocated, including those that cannot be bound
+func (a *PortAllocator) Available() int64 {
+	return a.generator.Available()
+}
+
+// Stats returns the counters of the underlying generator
+func (a *PortAllocator) Stats() Stats {
+	return a.generator.Stats()
+}
+
+// DetailedStats returns the DetailedStats of the underlying generator
+func (a *PortAllocator) DetailedStats() Stats {
+	return a.generator.DetailedStats()
+}
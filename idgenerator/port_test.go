@@ -0,0 +1,142 @@
+package idgenerator
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestPortAllocator(t *testing.T) {
+	allocator, err := NewPortAllocator(WithPortRange(1000, 1009), WithReservedPorts(1001, 80),
+		WithReservedPortRange(1005, 1007))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []uint16{1000, 1002, 1003, 1004, 1008, 1009} {
+		if port, err := allocator.AllocatePort(); err != nil || port != expected {
+			t.Fatalf("expected port: %d, output: %d, %+v", expected, port, err)
+		}
+	}
+	if _, err = allocator.AllocatePort(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	if err = allocator.FreePort(1003); err != nil {
+		t.Fatal(err)
+	}
+	if err = allocator.FreePort(1003); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if allocator.IsAllocated(1003) || allocator.Available() != 1 {
+		t.Errorf("unexpected state: IsAllocated(1003) %v, Available %d", allocator.IsAllocated(1003), allocator.Available())
+	}
+	if err = allocator.AllocateSpecificPort(1003); err != nil {
+		t.Fatal(err)
+	}
+	if err = allocator.AllocateSpecificPort(1003); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	for _, port := range []uint16{1001, 1006} {
+		if err = allocator.AllocateSpecificPort(port); !errors.Is(err, ErrReserved) {
+			t.Errorf("AllocateSpecificPort(%d): expected ErrReserved, got %+v", port, err)
+		}
+		if err = allocator.FreePort(port); !errors.Is(err, ErrReserved) {
+			t.Errorf("FreePort(%d): expected ErrReserved, got %+v", port, err)
+		}
+	}
+	if err = allocator.FreePort(80); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if stats := allocator.Stats(); stats.Capacity != 6 || stats.Used != 6 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestPortAllocatorBounds(t *testing.T) {
+	allocator, err := NewPortAllocator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port, err := allocator.AllocatePort(); err != nil || port != 1024 {
+		t.Errorf("expected port: 1024, output: %d, %+v", port, err)
+	}
+	if err = allocator.AllocateSpecificPort(65535); err != nil {
+		t.Errorf("allocating port 65535: %+v", err)
+	}
+	if err = allocator.AllocateSpecificPort(1023); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+
+	invalid := [][]PortAllocatorOption{
+		{WithPortRange(0, 10)},
+		{WithPortRange(2000, 1000)},
+		{WithReservedPortRange(2000, 1999)},
+		{WithBindCheck("sctp")},
+	}
+	for i, opts := range invalid {
+		if _, err = NewPortAllocator(opts...); err == nil {
+			t.Errorf("invalid options %d accepted", i)
+		}
+	}
+}
+
+func TestPortAllocatorBindCheck(t *testing.T) {
+	for _, network := range []string{"tcp", "udp"} {
+		t.Run(network, func(t *testing.T) {
+			// take a free port from the system and keep it busy
+			port, closeListener := listenAnyPort(t, network)
+			unchecked, err := NewPortAllocator(WithPortRange(port, port))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if allocated, err := unchecked.AllocatePort(); err != nil || allocated != port {
+				t.Errorf("expected port: %d, output: %d, %+v", port, allocated, err)
+			}
+
+			checked, err := NewPortAllocator(WithPortRange(port, port), WithBindCheck(network))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = checked.AllocatePort(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if err = checked.AllocateSpecificPort(port); !errors.Is(err, ErrAlreadyAllocated) {
+				t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+			}
+			// the busy port is left free for later calls
+			if checked.IsAllocated(port) {
+				t.Error("port which cannot be bound left allocated")
+			}
+
+			closeListener()
+			if allocated, err := checked.AllocatePort(); err != nil || allocated != port {
+				t.Errorf("expected port: %d, output: %d, %+v", port, allocated, err)
+			}
+		})
+	}
+}
+
+// listenAnyPort binds a port chosen by the system on network and returns it with the function closing it
+func listenAnyPort(t *testing.T, network string) (uint16, func()) {
+	t.Helper()
+	if network == "tcp" {
+		listener, err := net.Listen(network, ":0")
+		if err != nil {
+			t.Skipf("cannot listen on %s: %v", network, err)
+		}
+		return uint16(listener.Addr().(*net.TCPAddr).Port), func() {
+			if err := listener.Close(); err != nil {
+				t.Error(err)
+			}
+		}
+	}
+	conn, err := net.ListenPacket(network, ":0")
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", network, err)
+	}
+	return uint16(conn.LocalAddr().(*net.UDPAddr).Port), func() {
+		if err := conn.Close(); err != nil {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package idgenerator
+
+import "fmt"
+
+// priorityBand is the band of WithPriorityBand, kept as IDs so that it follows the range through Resize
+type priorityBand struct {
+	start     int64
+	end       int64
+	exclusive bool
+}
+
+// WithPriorityBand sets aside the IDs in [start, end] for AllocatePriority, e.g. the bottom tenth of the range
+// for the high-priority sessions, within the same generator so that Used, Available and Capacity still count
+// every ID. The allocations picking an ID like Allocate skip the band as long as an ID out of it is free,
+// and take its IDs only once the rest of the range is exhausted; AllocateSpecific, ReserveRange, AllocateWithOffset
+// and the other calls naming the IDs they take ignore it. A freed ID returns to the band it is in.
+// NewGeneratorWithOptions fails with ErrInvalidRange if start > end or the band has no ID of the range.
+// The band keeps its IDs through Resize, the IDs of the new range out of it are not part of it.
+func WithPriorityBand(start, end int64) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.band = &priorityBand{start: start, end: end}
+	}
+}
+
+// WithExclusivePriorityBand is WithPriorityBand keeping the band to AllocatePriority: Allocate fails with
+// ErrPoolExhausted once the rest of the range is exhausted, even with free IDs in the band.
+func WithExclusivePriorityBand(start, end int64) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.band = &priorityBand{start: start, end: end, exclusive: true}
+	}
+}
+
+// validateBand returns the error of a priority band with no ID of the range
+func (idGenerator *IDGenerator) validateBand() error {
+	band := idGenerator.band
+	if band == nil {
+		return nil
+	}
+	if band.start > band.end {
+		return fmt.Errorf("%w: priority band start %d > end %d", ErrInvalidRange, band.start, band.end)
+	}
+	if _, _, ok := idGenerator.bandLocked(); !ok {
+		return fmt.Errorf("%w: priority band [%d, %d] has no ID of [%d, %d]", ErrInvalidRange,
+			band.start, band.end, idGenerator.minValue, idGenerator.maxValue)
+	}
+	return nil
+}
+
+// bandLocked returns the offsets [first, last] of the priority band in the range, false if there is none.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) bandLocked() (first, last uint64, ok bool) {
+	band := idGenerator.band
+	if band == nil || band.start > idGenerator.maxValue || band.end < idGenerator.minValue {
+		return 0, 0, false
+	}
+	low, high := band.start, band.end
+	if low < idGenerator.minValue {
+		low = idGenerator.minValue
+	}
+	if high > idGenerator.maxValue {
+		high = idGenerator.maxValue
+	}
+	first = idGenerator.step.ceilOffset(idGenerator.minValue, low)
+	last = idGenerator.step.ceilOffset(idGenerator.minValue, high)
+	if !idGenerator.step.contains(idGenerator.minValue, high) {
+		// the lowest ID of the step past high is out of the band
+		if last == 0 {
+			return 0, 0, false
+		}
+		last--
+	}
+	if last > idGenerator.lastOffset {
+		last = idGenerator.lastOffset
+	}
+	return first, last, first <= last
+}
+
+// restFreeLocked reports whether an offset out of the band [first, last] is free, the caller must hold lock
+func (idGenerator *IDGenerator) restFreeLocked(first, last uint64) bool {
+	if offset, ok := idGenerator.store.NextClear(0); ok && offset < first {
+		return true
+	}
+	if last == idGenerator.lastOffset {
+		return false
+	}
+	_, ok := idGenerator.store.NextClear(last + 1)
+	return ok
+}
+
+// AllocatePriority allocates the lowest free ID of the priority band of WithPriorityBand, whatever the strategy
+// of the generator, or an ID like Allocate once every ID of the band is in use, so that a high-priority request
+// is only denied when the whole range is exhausted. The filter of WithAllocationFilter is consulted,
+// the lowest ID of the band it allows is allocated. It fails like Allocate,
+// and returns an error if the generator has no priority band.
+func (idGenerator *IDGenerator) AllocatePriority() (int64, error) {
+	if idGenerator.band == nil {
+		return 0, fmt.Errorf("AllocatePriority: no priority band set with WithPriorityBand")
+	}
+	if err := idGenerator.limitRate(1); err != nil {
+		return 0, err
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	id, err := idGenerator.allocatePriorityLocked()
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.refundRate(1)
+		idGenerator.allocateFailed(err)
+	}
+	return id, err
+}
+
+// allocatePriorityLocked is AllocatePriority, the caller must hold lock
+func (idGenerator *IDGenerator) allocatePriorityLocked() (int64, error) {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	first, last, ok := idGenerator.bandLocked()
+	for offset := first; ok; offset++ {
+		// excluded and quarantined offsets are set in store as well
+		if offset, ok = idGenerator.store.NextClear(offset); !ok || offset > last {
+			break
+		}
+		if idGenerator.filter == nil || idGenerator.filter(idGenerator.toID(offset)) {
+			// the sequential search of Allocate stays out of the band
+			idGenerator.markUsed(offset)
+			return idGenerator.toID(offset), nil
+		}
+		if offset == last {
+			break
+		}
+	}
+	// the band is exhausted, falling back to Allocate does not take it either
+	return idGenerator.allocateLocked()
+}
+
+// bandStatsLocked returns the number of used and of free IDs of the priority band,
+// taking a step per set offset in it. The caller must hold lock.
+func (idGenerator *IDGenerator) bandStatsLocked() (used int64, free uint64) {
+	first, last, ok := idGenerator.bandLocked()
+	if !ok {
+		return 0, 0
+	}
+	set := uint64(0)
+	for offset, ok := idGenerator.store.NextSet(first); ok && offset <= last; {
+		set++
+		if !idGenerator.isHeld(offset) {
+			used++
+		}
+		if offset == last {
+			break
+		}
+		offset, ok = idGenerator.store.NextSet(offset + 1)
+	}
+	return used, last - first - set + 1
+}
@@ -0,0 +1,139 @@
+package idgenerator
+
+import (
+	"errors"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPriorityBand(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(1, 10, append(storeOption.opts, WithPriorityBand(1, 3))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Allocate skips the band until the rest is exhausted
+			if ids := allocateN(t, idGenerator, 8); !reflect.DeepEqual(ids, []int64{4, 5, 6, 7, 8, 9, 10, 1}) {
+				t.Fatalf("expected the band last, output %v", ids)
+			}
+			if id, err := idGenerator.AllocatePriority(); err != nil || id != 2 {
+				t.Fatalf("expected ID 2, output %d, %+v", id, err)
+			}
+			stats := idGenerator.DetailedStats()
+			if stats.PriorityUsed != 2 || stats.PriorityFree != 1 || stats.Used != 9 || stats.Free != 1 {
+				t.Errorf("unexpected stats %+v", stats)
+			}
+
+			// a freed ID returns to its band
+			freeAll(t, idGenerator, 1, 2, 5)
+			if id, err := idGenerator.AllocatePriority(); err != nil || id != 1 {
+				t.Fatalf("expected ID 1, output %d, %+v", id, err)
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 5 {
+				t.Fatalf("expected ID 5, output %d, %+v", id, err)
+			}
+			// the band is full, AllocatePriority falls back to the rest of the range
+			if id, err := idGenerator.AllocatePriority(); err != nil || id != 2 {
+				t.Fatalf("expected ID 2, output %d, %+v", id, err)
+			}
+			if id, err := idGenerator.AllocatePriority(); err != nil || id != 3 {
+				t.Fatalf("expected ID 3, output %d, %+v", id, err)
+			}
+			if _, err = idGenerator.AllocatePriority(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if err = idGenerator.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestExclusivePriorityBand(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithExclusivePriorityBand(9, 20))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 8)
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted with the band free, got %+v", err)
+	}
+	if _, err = idGenerator.AllocateMany(1); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted with the band free, got %+v", err)
+	}
+	if id, err := idGenerator.AllocatePriority(); err != nil || id != 9 {
+		t.Fatalf("expected ID 9, output %d, %+v", id, err)
+	}
+	// the IDs are named, the band does not matter
+	if err = idGenerator.AllocateSpecific(10); err != nil {
+		t.Fatal(err)
+	}
+	if stats := idGenerator.DetailedStats(); stats.PriorityUsed != 2 || stats.PriorityFree != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if failures := idGenerator.Stats().AllocationFailures; failures != 2 {
+		t.Errorf("expected 2 allocation failures, output %d", failures)
+	}
+}
+
+func TestPriorityBandStrategies(t *testing.T) {
+	options := map[string][]Option{
+		"random":    {WithRandSource(rand.NewSource(1))},
+		"recycling": {WithFIFORecycling(RecycleFirst)},
+		"filter":    {WithAllocationFilter(func(id int64) bool { return id%7 != 0 })},
+		"step":      {WithStep(2, 1)},
+	}
+	for name, opts := range options {
+		t.Run(name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(0, 99, append(opts, WithPriorityBand(20, 39))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			capacity := int(idGenerator.Capacity())
+			ids := allocateN(t, idGenerator, capacity/2)
+			freeAll(t, idGenerator, ids[:capacity/4]...)
+			for i := 0; i < capacity/2; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					break
+				}
+				if id >= 20 && id <= 39 && idGenerator.Available()-int64(idGenerator.DetailedStats().PriorityFree) > 0 {
+					t.Fatalf("allocated ID %d of the band with IDs free out of it", id)
+				}
+			}
+			priority, err := NewGeneratorWithOptions(0, 99, append(opts, WithPriorityBand(20, 39))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < 5; i++ {
+				if id, err := priority.AllocatePriority(); err != nil || id < 20 || id > 39 {
+					t.Fatalf("expected an ID of the band, output %d, %+v", id, err)
+				}
+			}
+			if err = idGenerator.Validate(); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestPriorityBandInvalid(t *testing.T) {
+	for _, band := range [][2]int64{{5, 4}, {11, 20}, {-5, 0}} {
+		if _, err := NewGeneratorWithOptions(1, 10, WithPriorityBand(band[0], band[1])); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("band %v: expected ErrInvalidRange, got %+v", band, err)
+		}
+	}
+	// the band [4, 4] has no ID of the step
+	if _, err := NewGeneratorWithOptions(1, 10, WithStep(2, 0), WithPriorityBand(4, 4)); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+	idGenerator, err := NewGeneratorWithOptions(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocatePriority(); err == nil {
+		t.Error("AllocatePriority without a band should fail")
+	}
+}
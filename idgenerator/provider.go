@@ -0,0 +1,68 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math"
+)
+
+// WithRangeProvider makes Allocate extend the range once it is exhausted, e.g. with a block requested from IPAM:
+// it calls provider with the current range [minValue, maxValue], extends the generator by the range returned
+// and retries the allocation once. The range provided must border on the current one, below minValue or above maxValue,
+// the extension is then part of Stats and of the snapshots like a Resize.
+// A provider error or a range which does not border on the current one fails Allocate with an error
+// wrapping ErrPoolExhausted. provider runs without lock held, one call at a time, so it may take a while.
+// Only Allocate and AllocateString extend the range, neither the other allocations nor those of
+// a MultiRangeIDGenerator or ShardedIDGenerator of such generators do, and Clone does not keep the provider.
+func WithRangeProvider(provider func(current [2]int64) ([2]int64, error)) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.rangeProvider = provider
+	}
+}
+
+// allocateExtending extends the exhausted range by the range of the provider and allocates from it
+func (idGenerator *IDGenerator) allocateExtending() (int64, error) {
+	idGenerator.extendLock.Lock()
+	defer idGenerator.extendLock.Unlock()
+	// another call may have extended the range, or freed an ID, while this one was waiting
+	if id, err := idGenerator.tryAllocate(); err == nil {
+		return id, nil
+	}
+	if err := idGenerator.extend(); err != nil {
+		return 0, err
+	}
+	return idGenerator.tryAllocate()
+}
+
+// extend asks the provider for a range and adds it to the range of the generator, the caller must hold extendLock
+func (idGenerator *IDGenerator) extend() error {
+	minValue, maxValue := idGenerator.bounds()
+	provided, err := idGenerator.rangeProvider([2]int64{minValue, maxValue})
+	if err != nil {
+		return fmt.Errorf("%w: range provider for [%d, %d]: %v", ErrPoolExhausted, minValue, maxValue, err)
+	}
+	newMin, newMax := minValue, maxValue
+	switch {
+	case provided[0] > provided[1]:
+		return fmt.Errorf("%w: range provider for [%d, %d]: invalid range [%d, %d]",
+			ErrPoolExhausted, minValue, maxValue, provided[0], provided[1])
+	case maxValue < math.MaxInt64 && provided[0] == maxValue+1:
+		newMax = provided[1]
+	case minValue > math.MinInt64 && provided[1] == minValue-1:
+		newMin = provided[0]
+	default:
+		return fmt.Errorf("%w: range provider for [%d, %d]: [%d, %d] does not border on it",
+			ErrPoolExhausted, minValue, maxValue, provided[0], provided[1])
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if idGenerator.minValue != minValue || idGenerator.maxValue != maxValue {
+		return fmt.Errorf("%w: [%d, %d] resized to [%d, %d] while extending it",
+			ErrPoolExhausted, minValue, maxValue, idGenerator.minValue, idGenerator.maxValue)
+	}
+	if err := idGenerator.resizeLocked(newMin, newMax); err != nil {
+		return err
+	}
+	idGenerator.serveWaitersLocked()
+	return nil
+}
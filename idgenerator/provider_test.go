@@ -0,0 +1,74 @@
+package idgenerator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRangeProvider(t *testing.T) {
+	blocks := [][2]int64{{11, 12}, {-1, 0}}
+	var calls [][2]int64
+	provider := func(current [2]int64) ([2]int64, error) {
+		calls = append(calls, current)
+		if len(blocks) == 0 {
+			return [2]int64{}, errors.New("no block left")
+		}
+		block := blocks[0]
+		blocks = blocks[1:]
+		return block, nil
+	}
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithRangeProvider(provider))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 10)
+	if len(calls) != 0 {
+		t.Fatalf("provider called before exhaustion: %v", calls)
+	}
+	for _, expected := range []int64{11, 12, -1, 0} {
+		if id, err := idGenerator.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if expected := [][2]int64{{1, 10}, {1, 12}}; len(calls) != 2 || calls[0] != expected[0] || calls[1] != expected[1] {
+		t.Errorf("expected provider calls %v, output %v", expected, calls)
+	}
+	if stats := idGenerator.Stats(); stats.MinValue != -1 || stats.MaxValue != 12 || stats.Capacity != 14 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	_, err = idGenerator.Allocate()
+	if !errors.Is(err, ErrPoolExhausted) || !strings.Contains(err.Error(), "no block left") {
+		t.Errorf("expected ErrPoolExhausted with the provider error, got %+v", err)
+	}
+	if stats := idGenerator.Stats(); stats.AllocationFailures != 1 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+
+	// the extended range survives a snapshot
+	restored, err := RestoreGenerator(idGenerator.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if minValue, maxValue := restored.bounds(); minValue != -1 || maxValue != 12 || !restored.IsAllocated(12) {
+		t.Errorf("expected the restored range [-1, 12] with 12 allocated, output [%d, %d]", minValue, maxValue)
+	}
+}
+
+func TestRangeProviderInvalid(t *testing.T) {
+	for _, provided := range [][2]int64{{12, 20}, {5, 15}, {-5, -1}, {20, 11}} {
+		idGenerator, err := NewGeneratorWithOptions(1, 10, WithRangeProvider(func([2]int64) ([2]int64, error) {
+			return provided, nil
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		allocateN(t, idGenerator, 10)
+		if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+			t.Errorf("%v: expected ErrPoolExhausted, got %+v", provided, err)
+		}
+		if minValue, maxValue := idGenerator.bounds(); minValue != 1 || maxValue != 10 {
+			t.Errorf("%v: range changed to [%d, %d]", provided, minValue, maxValue)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package idgenerator
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithReuseDelay keeps every freed ID in quarantine for d before it can be allocated again,
+// so that the late messages of a peer referring to it are not taken for its next owner.
+// Quarantined IDs are neither allocated nor free: AllocateSpecific and ReserveRange reject them
+// with an error wrapping ErrQuarantined, FreeID with ErrNotAllocated, and Available does not count them.
+// The quarantine is over once the clock of WithClock has moved d past the free,
+// the IDs are released lazily by the next call on the generator. Reset and restoring a snapshot end it at once.
+// A d of 0 or below keeps the default, freed IDs are reused right away.
+func WithReuseDelay(d time.Duration) Option {
+	return func(idGenerator *IDGenerator) {
+		if d > 0 {
+			idGenerator.reuseDelay = d
+		}
+	}
+}
+
+// Quarantined returns the number of freed IDs waiting for the delay of WithReuseDelay to be reused
+func (idGenerator *IDGenerator) Quarantined() int64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return int64(len(idGenerator.quarantined))
+}
+
+func (idGenerator *IDGenerator) isQuarantined(offset uint64) bool {
+	_, ok := idGenerator.quarantined[offset]
+	return ok
+}
+
+func (idGenerator *IDGenerator) quarantinedError(id int64) error {
+	return fmt.Errorf("%w: ID[%d] freed less than %v ago", ErrQuarantined, id, idGenerator.reuseDelay)
+}
+
+// quarantineLocked parks a freed offset, which stays set in store until releaseQuarantinedLocked clears it.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) quarantineLocked(offset uint64) {
+	if idGenerator.quarantined == nil {
+		idGenerator.quarantined = make(map[uint64]struct{})
+	}
+	idGenerator.quarantined[offset] = struct{}{}
+	// the delay is the same for all, so the queue is in order of release
+	idGenerator.quarantine = append(idGenerator.quarantine, leaseEntry{
+		expiry: idGenerator.clock.Now().Add(idGenerator.reuseDelay),
+		offset: offset,
+	})
+}
+
+// releaseQuarantinedLocked frees the offsets whose quarantine is over and reports whether there were any,
+// the caller must hold lock
+func (idGenerator *IDGenerator) releaseQuarantinedLocked() bool {
+	if len(idGenerator.quarantine) == 0 {
+		return false
+	}
+	now := idGenerator.clock.Now()
+	released := 0
+	for _, entry := range idGenerator.quarantine {
+		if entry.expiry.After(now) {
+			break
+		}
+		idGenerator.clearLocked(entry.offset)
+		delete(idGenerator.quarantined, entry.offset)
+		released++
+	}
+	idGenerator.quarantine = idGenerator.quarantine[released:]
+	return released > 0
+}
+
+// endQuarantineLocked drops the quarantine without touching store, the caller must hold lock
+func (idGenerator *IDGenerator) endQuarantineLocked() {
+	idGenerator.quarantined = nil
+	idGenerator.quarantine = nil
+}
@@ -0,0 +1,141 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReuseDelay(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			clock := newFakeClock()
+			opts := append([]Option{WithClock(clock), WithReuseDelay(time.Minute)}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithOptions(1, 3, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err = idGenerator.AllocateMany(3); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.FreeID(2); err != nil {
+				t.Fatal(err)
+			}
+
+			// 2 is neither allocated nor free
+			_, err = idGenerator.Allocate()
+			if !errors.Is(err, ErrPoolExhausted) || !strings.Contains(err.Error(), "2/3 used, 1 quarantined") {
+				t.Errorf("expected ErrPoolExhausted with the quarantined IDs, got %+v", err)
+			}
+			if err = idGenerator.AllocateSpecific(2); !errors.Is(err, ErrQuarantined) {
+				t.Errorf("expected ErrQuarantined, got %+v", err)
+			}
+			if err = idGenerator.ReserveRange(2, 2); !errors.Is(err, ErrQuarantined) {
+				t.Errorf("expected ErrQuarantined, got %+v", err)
+			}
+			if err = idGenerator.FreeID(2); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("expected ErrNotAllocated, got %+v", err)
+			}
+			if freed, err := idGenerator.FreeRange(2, 2); err != nil || freed != 0 {
+				t.Errorf("expected nothing freed, output %d, %+v", freed, err)
+			}
+			if idGenerator.IsAllocated(2) {
+				t.Error("quarantined ID reported allocated")
+			}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{1, 3}) {
+				t.Errorf("expected allocated IDs [1 3], output %v", ids)
+			}
+			stats := idGenerator.Stats()
+			if stats.Used != 2 || stats.Free != 0 || stats.Quarantined != 1 || idGenerator.Available() != 0 {
+				t.Errorf("unexpected stats: %#v", stats)
+			}
+
+			// the IDs leave the quarantine in the order they were freed
+			clock.Advance(30 * time.Second)
+			if err = idGenerator.FreeID(3); err != nil {
+				t.Fatal(err)
+			}
+			clock.Advance(30*time.Second - time.Nanosecond)
+			if quarantined := idGenerator.Quarantined(); quarantined != 2 {
+				t.Errorf("expected 2 quarantined IDs, output %d", quarantined)
+			}
+			clock.Advance(time.Nanosecond)
+			if quarantined := idGenerator.Quarantined(); quarantined != 1 {
+				t.Errorf("expected 1 quarantined ID, output %d", quarantined)
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 2 {
+				t.Errorf("expected id: 2, output id: %d, %+v", id, err)
+			}
+			clock.Advance(30 * time.Second)
+			if id, err := idGenerator.Allocate(); err != nil || id != 3 {
+				t.Errorf("expected id: 3, output id: %d, %+v", id, err)
+			}
+		})
+	}
+}
+
+func TestReuseDelayLease(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 1, WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an expired lease is quarantined like a freed ID, freeing it is still no error
+	clock.Advance(time.Minute)
+	if quarantined := idGenerator.Quarantined(); quarantined != 1 {
+		t.Errorf("expected 1 quarantined ID, output %d", quarantined)
+	}
+	if err = idGenerator.FreeID(id); err != nil {
+		t.Errorf("freeing an expired lease: %+v", err)
+	}
+	clock.Advance(time.Minute)
+	if allocated, err := idGenerator.Allocate(); err != nil || allocated != id {
+		t.Errorf("expected id: %d, output id: %d, %+v", id, allocated, err)
+	}
+}
+
+func TestReuseDelayResizeAndReset(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.ReserveRange(1, 10); err != nil {
+		t.Fatal(err)
+	}
+	if freed, err := idGenerator.FreeRange(4, 7); err != nil || freed != 4 {
+		t.Fatalf("expected 4 IDs freed, output %d, %+v", freed, err)
+	}
+	if freed, err := idGenerator.FreeRange(8, 10); err != nil || freed != 3 {
+		t.Fatalf("expected 3 IDs freed, output %d, %+v", freed, err)
+	}
+
+	// the quarantined IDs in the new range are kept, the others dropped
+	if err = idGenerator.Resize(1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if quarantined, available := idGenerator.Quarantined(), idGenerator.Available(); quarantined != 2 || available != 0 {
+		t.Errorf("expected 2 quarantined and no available IDs, output %d and %d", quarantined, available)
+	}
+	if err = idGenerator.AllocateSpecific(5); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %+v", err)
+	}
+	clock.Advance(time.Minute)
+	if err = idGenerator.AllocateSpecific(5); err != nil {
+		t.Error(err)
+	}
+
+	if err = idGenerator.FreeID(5); err != nil {
+		t.Fatal(err)
+	}
+	idGenerator.Reset()
+	if quarantined, available := idGenerator.Quarantined(), idGenerator.Available(); quarantined != 0 || available != 5 {
+		t.Errorf("expected no quarantined and 5 available IDs after Reset, output %d and %d", quarantined, available)
+	}
+}
@@ -0,0 +1,110 @@
+package idgenerator
+
+import "fmt"
+
+// OwnerStats are the counters of an owner of AllocateFor in Stats: Used is the number of IDs it holds,
+// Quota its quota of SetQuota or WithDefaultQuota, -1 without any
+type OwnerStats struct {
+	Used  int64 `json:"used"`
+	Quota int64 `json:"quota"`
+}
+
+// WithDefaultQuota sets the quota of the owners of AllocateFor without one of SetQuota, a negative limit removes it.
+// The IDs allocated without owner are never limited.
+func WithDefaultQuota(limit int64) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.defaultQuota = limit
+		idGenerator.hasDefaultQuota = limit >= 0
+	}
+}
+
+// SetQuota limits owner to limit IDs allocated by AllocateFor at a time, beyond which AllocateFor fails
+// with an error wrapping ErrQuotaExceeded until the owner frees some; a negative limit removes the quota of owner,
+// which falls back to that of WithDefaultQuota. A quota below the IDs owner holds keeps them allocated.
+func (idGenerator *IDGenerator) SetQuota(owner string, limit int64) {
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	if limit < 0 {
+		delete(idGenerator.quotas, owner)
+		return
+	}
+	if idGenerator.quotas == nil {
+		idGenerator.quotas = make(map[string]int64)
+	}
+	idGenerator.quotas[owner] = limit
+}
+
+// quotaLocked returns the quota of owner, false if it has none. The caller must hold lock.
+func (idGenerator *IDGenerator) quotaLocked(owner string) (int64, bool) {
+	if quota, ok := idGenerator.quotas[owner]; ok {
+		return quota, true
+	}
+	return idGenerator.defaultQuota, idGenerator.hasDefaultQuota
+}
+
+// checkQuotaLocked returns an error wrapping ErrQuotaExceeded if owner may not be allocated another ID.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) checkQuotaLocked(owner string) error {
+	quota, ok := idGenerator.quotaLocked(owner)
+	if !ok {
+		return nil
+	}
+	used := int64(0)
+	if set, ok := idGenerator.owners[owner]; ok {
+		used = int64(len(set.offsets))
+	}
+	if used >= quota {
+		return fmt.Errorf("%w: owner %q holds %d of %d IDs", ErrQuotaExceeded, owner, used, quota)
+	}
+	return nil
+}
+
+// ownerStatsLocked returns the OwnerStats of the owners holding IDs or with a quota of SetQuota, nil if none.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) ownerStatsLocked() map[string]OwnerStats {
+	if len(idGenerator.owners) == 0 && len(idGenerator.quotas) == 0 {
+		return nil
+	}
+	owners := make(map[string]OwnerStats, len(idGenerator.owners)+len(idGenerator.quotas))
+	stats := func(owner string) OwnerStats {
+		quota, ok := idGenerator.quotaLocked(owner)
+		if !ok {
+			quota = -1
+		}
+		return OwnerStats{Quota: quota}
+	}
+	for owner := range idGenerator.quotas {
+		owners[owner] = stats(owner)
+	}
+	for owner, set := range idGenerator.owners {
+		ownerStats := stats(owner)
+		ownerStats.Used = int64(len(set.offsets))
+		owners[owner] = ownerStats
+	}
+	return owners
+}
+
+// addOwners adds the OwnerStats of other to owners, adding up the quotas unless one of them is unlimited
+func addOwners(owners, other map[string]OwnerStats) map[string]OwnerStats {
+	if len(other) == 0 {
+		return owners
+	}
+	if owners == nil {
+		owners = make(map[string]OwnerStats, len(other))
+	}
+	for owner, otherStats := range other {
+		ownerStats, ok := owners[owner]
+		if !ok {
+			owners[owner] = otherStats
+			continue
+		}
+		ownerStats.Used += otherStats.Used
+		if ownerStats.Quota < 0 || otherStats.Quota < 0 {
+			ownerStats.Quota = -1
+		} else {
+			ownerStats.Quota += otherStats.Quota
+		}
+		owners[owner] = ownerStats
+	}
+	return owners
+}
@@ -0,0 +1,112 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestQuota(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithDefaultQuota(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	idGenerator.SetQuota("tenant-a", 2)
+	for i := 0; i < 2; i++ {
+		if _, err = idGenerator.AllocateFor("tenant-a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = idGenerator.AllocateFor("tenant-a")
+	if !errors.Is(err, ErrQuotaExceeded) || !strings.Contains(err.Error(), `"tenant-a" holds 2 of 2 IDs`) {
+		t.Errorf("expected ErrQuotaExceeded with the usage, got %+v", err)
+	}
+	// the other owners fall under the default quota, the IDs without owner are not limited
+	if _, err = idGenerator.AllocateFor("tenant-b"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("tenant-b"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded under the default quota, got %+v", err)
+	}
+	allocateN(t, idGenerator, 10)
+	if stats := idGenerator.Stats(); stats.AllocationFailures != 0 || stats.Used != 13 {
+		t.Errorf("expected the refusals not counted as failures, output %#v", stats)
+	}
+
+	// freeing an ID of the owner gives it room again
+	if err = idGenerator.FreeID(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("tenant-a"); err != nil {
+		t.Errorf("expected room after freeing, got %+v", err)
+	}
+
+	idGenerator.SetQuota("tenant-c", 0)
+	expected := map[string]OwnerStats{
+		"tenant-a": {Used: 2, Quota: 2},
+		"tenant-b": {Used: 1, Quota: 1},
+		"tenant-c": {Used: 0, Quota: 0},
+	}
+	if owners := idGenerator.DetailedStats().Owners; !reflect.DeepEqual(owners, expected) {
+		t.Errorf("expected owners %v, output %v", expected, owners)
+	}
+
+	// removing the quota of an owner falls back to the default one, lowering one keeps the IDs allocated
+	idGenerator.SetQuota("tenant-a", -1)
+	if _, err = idGenerator.AllocateFor("tenant-a"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Errorf("expected ErrQuotaExceeded under the default quota, got %+v", err)
+	}
+	if used := idGenerator.Used(); used != 13 {
+		t.Errorf("expected 13 used, output %d", used)
+	}
+}
+
+func TestQuotaUnlimited(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	for i := 0; i < 5; i++ {
+		if _, err := idGenerator.AllocateFor("a"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	owners := idGenerator.DetailedStats().Owners
+	if !reflect.DeepEqual(owners, map[string]OwnerStats{"a": {Used: 5, Quota: -1}}) {
+		t.Errorf("unexpected owners %v", owners)
+	}
+	if owners := NewGenerator(1, 10).DetailedStats().Owners; owners != nil {
+		t.Errorf("expected no owners, output %v", owners)
+	}
+}
+
+func TestQuotaConcurrent(t *testing.T) {
+	idGenerator := NewGenerator(1, 1000)
+	idGenerator.SetQuota("a", 10)
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if _, err := idGenerator.AllocateFor("a"); err != nil && !errors.Is(err, ErrQuotaExceeded) {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if used := idGenerator.Used(); used != 10 {
+		t.Errorf("expected the quota of 10 held, output %d used", used)
+	}
+}
+
+func TestQuotaStatsAdd(t *testing.T) {
+	stats := Stats{Owners: map[string]OwnerStats{"a": {Used: 1, Quota: 2}, "b": {Used: 1, Quota: -1}}}
+	stats.add(Stats{Owners: map[string]OwnerStats{
+		"a": {Used: 2, Quota: 3}, "b": {Used: 1, Quota: 4}, "c": {Used: 1, Quota: 1},
+	}})
+	expected := map[string]OwnerStats{"a": {Used: 3, Quota: 5}, "b": {Used: 2, Quota: -1}, "c": {Used: 1, Quota: 1}}
+	if !reflect.DeepEqual(stats.Owners, expected) {
+		t.Errorf("expected owners %v, output %v", expected, stats.Owners)
+	}
+}
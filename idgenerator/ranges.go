@@ -0,0 +1,250 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// Range is the closed interval of IDs [Min, Max]
+type Range struct {
+	Min int64
+	Max int64
+}
+
+// RangeStats are the counters of one range of a MultiRangeIDGenerator
+type RangeStats struct {
+	Range
+	Stats
+}
+
+// MultiRangeIDGenerator allocates IDs from several disjoint ranges as one pool,
+// e.g. for a numbering plan giving [100, 199] and [500, 599].
+// Each range is kept by its own IDGenerator, Allocate takes an ID from the range of the last allocation
+// and moves on to the next range upward once it is exhausted, wrapping around to the lowest one.
+type MultiRangeIDGenerator struct {
+	// current and allocateFailures are updated atomically and must stay first to be 64-bit aligned
+	current          uint64
+	allocateFailures uint64
+
+	// ranges are sorted by Min, generators[i] allocates in ranges[i]
+	ranges     []Range
+	generators []*IDGenerator
+}
+
+// NewGeneratorFromRanges initializes a MultiRangeIDGenerator with ranges, given in any order,
+// then applies opts to the generator of every range. It fails like NewGeneratorWithOptions,
+// or with an error wrapping ErrInvalidRange if ranges is empty or two ranges overlap.
+func NewGeneratorFromRanges(ranges []Range, opts ...Option) (*MultiRangeIDGenerator, error) {
+	if len(ranges) == 0 {
+		return nil, fmt.Errorf("%w: no range", ErrInvalidRange)
+	}
+	sorted := append([]Range(nil), ranges...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Min < sorted[j].Min })
+	multi := &MultiRangeIDGenerator{
+		ranges:     sorted,
+		generators: make([]*IDGenerator, len(sorted)),
+	}
+	for i, r := range sorted {
+		if i > 0 && r.Min <= sorted[i-1].Max {
+			return nil, fmt.Errorf("%w: [%d, %d] overlaps [%d, %d]",
+				ErrInvalidRange, sorted[i-1].Min, sorted[i-1].Max, r.Min, r.Max)
+		}
+		generator, err := NewGeneratorWithOptions(r.Min, r.Max, opts...)
+		if err != nil {
+			return nil, err
+		}
+		multi.generators[i] = generator
+	}
+	return multi, nil
+}
+
+// RestoreGeneratorFromRanges builds a MultiRangeIDGenerator with a range for each of snapshots,
+// as returned by Snapshots, then applies opts to the generator of every range.
+// It validates the ranges like NewGeneratorFromRanges and each snapshot like RestoreGenerator.
+func RestoreGeneratorFromRanges(snapshots []Snapshot, opts ...Option) (*MultiRangeIDGenerator, error) {
+	ranges := make([]Range, len(snapshots))
+	for i, snapshot := range snapshots {
+		ranges[i] = Range{Min: snapshot.MinValue, Max: snapshot.MaxValue}
+	}
+	multi, err := NewGeneratorFromRanges(ranges, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	for _, snapshot := range snapshots {
+		if err = multi.generator(snapshot.MinValue).load(snapshot); err != nil {
+			return nil, err
+		}
+	}
+	return multi, nil
+}
+
+// generator returns the generator of the range holding id, or nil if there is none
+func (multi *MultiRangeIDGenerator) generator(id int64) *IDGenerator {
+	i := sort.Search(len(multi.ranges), func(i int) bool { return multi.ranges[i].Max >= id })
+	if i == len(multi.ranges) || id < multi.ranges[i].Min {
+		return nil
+	}
+	return multi.generators[i]
+}
+
+func (multi *MultiRangeIDGenerator) outOfRangeError(id int64) error {
+	return fmt.Errorf("%w: ID[%d] not in any of %v", ErrOutOfRange, id, multi.ranges)
+}
+
+// Allocate allocates a free ID from the range of the last allocation, or from the following ones if it is exhausted.
+// It returns an error wrapping ErrPoolExhausted only if every range is.
+func (multi *MultiRangeIDGenerator) Allocate() (int64, error) {
+	n := uint64(len(multi.generators))
+	start := atomic.LoadUint64(&multi.current)
+	var err error
+	for i := uint64(0); i < n; i++ {
+		current := (start + i) % n
+		var id int64
+		if id, err = multi.generators[current].tryAllocate(); err == nil {
+			if current != start {
+				atomic.StoreUint64(&multi.current, current)
+			}
+			return id, nil
+		}
+		if !errors.Is(err, ErrPoolExhausted) {
+			break
+		}
+	}
+	atomic.AddUint64(&multi.allocateFailures, 1)
+	multi.generators[0].logger.Printf("idgenerator%v: allocate failed: %v", multi.ranges, err)
+	return 0, err
+}
+
+// AllocateSpecific allocates exactly id like IDGenerator.AllocateSpecific,
+// it returns an error wrapping ErrOutOfRange if id is in none of the ranges
+func (multi *MultiRangeIDGenerator) AllocateSpecific(id int64) error {
+	generator := multi.generator(id)
+	if generator == nil {
+		return multi.outOfRangeError(id)
+	}
+	return generator.AllocateSpecific(id)
+}
+
+// FreeID releases id to the generator of its range like IDGenerator.FreeID,
+// it returns an error wrapping ErrOutOfRange if id is in none of the ranges
+func (multi *MultiRangeIDGenerator) FreeID(id int64) error {
+	generator := multi.generator(id)
+	if generator == nil {
+		return multi.outOfRangeError(id)
+	}
+	return generator.FreeID(id)
+}
+
+// IsAllocated reports whether id is allocated, it is false for any id in none of the ranges
+func (multi *MultiRangeIDGenerator) IsAllocated(id int64) bool {
+	generator := multi.generator(id)
+	return generator != nil && generator.IsAllocated(id)
+}
+
+// Ranges returns the ranges of the generator sorted in ascending order
+func (multi *MultiRangeIDGenerator) Ranges() []Range {
+	return append([]Range(nil), multi.ranges...)
+}
+
+// MinValue returns the lowest ID of the lowest range
+func (multi *MultiRangeIDGenerator) MinValue() int64 {
+	return multi.ranges[0].Min
+}
+
+// MaxValue returns the highest ID of the highest range
+func (multi *MultiRangeIDGenerator) MaxValue() int64 {
+	return multi.ranges[len(multi.ranges)-1].Max
+}
+
+// Capacity returns the number of IDs the generator may allocate at once over all ranges,
+// less the excluded IDs, capped at math.MaxInt64. The IDs between the ranges are not counted.
+func (multi *MultiRangeIDGenerator) Capacity() int64 {
+	capacity := uint64(0)
+	for _, generator := range multi.generators {
+		rangeCapacity := uint64(generator.Capacity())
+		if capacity += rangeCapacity; capacity < rangeCapacity {
+			return math.MaxInt64
+		}
+	}
+	return clampInt64(capacity)
+}
+
+// Contains reports whether id is in one of the ranges and not excluded, like IDGenerator.Contains
+func (multi *MultiRangeIDGenerator) Contains(id int64) bool {
+	generator := multi.generator(id)
+	return generator != nil && generator.Contains(id)
+}
+
+// Used returns the number of allocated IDs over all ranges.
+// The ranges are read one after the other, so concurrent calls may be counted in part.
+func (multi *MultiRangeIDGenerator) Used() int64 {
+	return multi.Stats().Used
+}
+
+// Available returns the number of IDs that can still be allocated over all ranges, capped at math.MaxInt64.
+// It is read like Used.
+func (multi *MultiRangeIDGenerator) Available() int64 {
+	available := uint64(0)
+	for _, generator := range multi.generators {
+		rangeAvailable := uint64(generator.Available())
+		if available += rangeAvailable; available < rangeAvailable {
+			return math.MaxInt64
+		}
+	}
+	return clampInt64(available)
+}
+
+// Reset frees all allocated IDs of every range and restarts allocation from the lowest one
+func (multi *MultiRangeIDGenerator) Reset() {
+	for _, generator := range multi.generators {
+		generator.Reset()
+	}
+	atomic.StoreUint64(&multi.current, 0)
+}
+
+// Stats returns the sum of the counters of the ranges, AllocationFailures only counts the calls
+// for which every range was exhausted. MinValue and MaxValue span all ranges, Offset is 0. It is read like Used.
+func (multi *MultiRangeIDGenerator) Stats() Stats {
+	return multi.sumStats((*IDGenerator).Stats)
+}
+
+// DetailedStats returns the sum of the DetailedStats of the ranges, like Stats
+func (multi *MultiRangeIDGenerator) DetailedStats() Stats {
+	return multi.sumStats((*IDGenerator).DetailedStats)
+}
+
+// sumStats returns the sum of the counters read by read of the ranges
+func (multi *MultiRangeIDGenerator) sumStats(read func(*IDGenerator) Stats) Stats {
+	var stats Stats
+	for _, generator := range multi.generators {
+		stats.add(read(generator))
+	}
+	stats.MinValue = multi.ranges[0].Min
+	stats.MaxValue = multi.ranges[len(multi.ranges)-1].Max
+	stats.AllocationFailures = atomic.LoadUint64(&multi.allocateFailures)
+	return stats
+}
+
+// RangeStats returns the counters of every range in ascending order.
+// Their AllocationFailures are 0, as Allocate moves on to the next range instead of failing,
+// the calls for which every range was exhausted are counted by Stats only.
+func (multi *MultiRangeIDGenerator) RangeStats() []RangeStats {
+	stats := make([]RangeStats, len(multi.generators))
+	for i, generator := range multi.generators {
+		stats[i] = RangeStats{Range: multi.ranges[i], Stats: generator.Stats()}
+	}
+	return stats
+}
+
+// Snapshots returns the Snapshot of every range in ascending order, for RestoreGeneratorFromRanges.
+// The ranges are read one after the other, so the snapshots of a generator in use may not match.
+func (multi *MultiRangeIDGenerator) Snapshots() []Snapshot {
+	snapshots := make([]Snapshot, len(multi.generators))
+	for i, generator := range multi.generators {
+		snapshots[i] = generator.Snapshot()
+	}
+	return snapshots
+}
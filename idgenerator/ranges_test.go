@@ -0,0 +1,175 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestMultiRangeGenerator(t *testing.T) {
+	// the ranges are sorted whatever their order
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorFromRanges([]Range{{500, 502}, {100, 101}}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranges := idGenerator.Ranges(); !reflect.DeepEqual(ranges, []Range{{100, 101}, {500, 502}}) {
+		t.Errorf("unexpected ranges: %v", ranges)
+	}
+
+	for _, expected := range []int64{100, 101, 500, 501, 502} {
+		if id, err := idGenerator.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected id: %d, output: %d, %+v", expected, id, err)
+		}
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	// FreeID goes to the range owning the ID
+	if err = idGenerator.FreeID(101); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(101) || !idGenerator.IsAllocated(501) {
+		t.Errorf("unexpected state: IsAllocated(101) %v, IsAllocated(501) %v",
+			idGenerator.IsAllocated(101), idGenerator.IsAllocated(501))
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != 101 {
+		t.Errorf("expected id: 101, output: %d, %+v", id, err)
+	}
+	for _, id := range []int64{99, 102, 300, 499, 503} {
+		if err = idGenerator.FreeID(id); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("FreeID(%d): expected ErrOutOfRange, got %+v", id, err)
+		}
+		if err = idGenerator.AllocateSpecific(id); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("AllocateSpecific(%d): expected ErrOutOfRange, got %+v", id, err)
+		}
+		if idGenerator.IsAllocated(id) {
+			t.Errorf("ID[%d] out of range reported allocated", id)
+		}
+	}
+	if err = idGenerator.FreeID(500); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(500); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(500); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+
+	expected := Stats{
+		MinValue:           100,
+		MaxValue:           502,
+		Strategy:           StrategySequential,
+		Used:               5,
+		References:         5,
+		Capacity:           5,
+		Allocations:        7,
+		Frees:              2,
+		AllocationFailures: 1,
+		PeakUsed:           5,
+		PeakAt:             clock.Now(),
+	}
+	if stats := idGenerator.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	rangeStats := idGenerator.RangeStats()
+	if len(rangeStats) != 2 || rangeStats[0].Range != (Range{100, 101}) || rangeStats[0].Used != 2 ||
+		rangeStats[1].Range != (Range{500, 502}) || rangeStats[1].Used != 3 {
+		t.Errorf("unexpected range stats: %#v", rangeStats)
+	}
+
+	idGenerator.Reset()
+	if used, available := idGenerator.Used(), idGenerator.Available(); used != 0 || available != 5 {
+		t.Errorf("expected used: 0, available: 5, output used: %d, available: %d", used, available)
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != 100 {
+		t.Errorf("expected id: 100, output: %d, %+v", id, err)
+	}
+}
+
+func TestMultiRangeGeneratorInvalid(t *testing.T) {
+	testCases := [][]Range{
+		nil,
+		{{10, 1}},
+		{{1, 10}, {10, 20}},
+		{{1, 10}, {5, 6}},
+		{{20, 30}, {1, 10}, {25, 40}},
+	}
+	for _, ranges := range testCases {
+		if _, err := NewGeneratorFromRanges(ranges); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("ranges %v: expected ErrInvalidRange, got %+v", ranges, err)
+		}
+	}
+
+	// the ranges may span the whole int64 range together
+	idGenerator, err := NewGeneratorFromRanges([]Range{{math.MinInt64, -1}, {0, math.MaxInt64}}, WithIntervalStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if available := idGenerator.Available(); available != math.MaxInt64 {
+		t.Errorf("expected available: %d, output available: %d", int64(math.MaxInt64), available)
+	}
+	if capacity := idGenerator.Stats().Capacity; capacity != math.MaxUint64 {
+		t.Errorf("expected capacity: %d, output capacity: %d", uint64(math.MaxUint64), capacity)
+	}
+}
+
+func TestMultiRangeGeneratorSnapshots(t *testing.T) {
+	idGenerator, err := NewGeneratorFromRanges([]Range{{100, 199}, {500, 599}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{150, 500, 599} {
+		if err = idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	snapshots := idGenerator.Snapshots()
+	expected := []Snapshot{
+		{MinValue: 100, MaxValue: 199, Used: []int64{150}},
+		{MinValue: 500, MaxValue: 599, Used: []int64{500, 599}},
+	}
+	if !reflect.DeepEqual(snapshots, expected) {
+		t.Fatalf("expected snapshots: %+v, output snapshots: %+v", expected, snapshots)
+	}
+
+	restored, err := RestoreGeneratorFromRanges(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Snapshots(), snapshots) {
+		t.Errorf("restored snapshots differ: %+v", restored.Snapshots())
+	}
+	if used := restored.Used(); used != 3 {
+		t.Errorf("expected used: 3, output used: %d", used)
+	}
+
+	snapshots[1].Used = append(snapshots[1].Used, 600)
+	if _, err = RestoreGeneratorFromRanges(snapshots); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if _, err = RestoreGeneratorFromRanges(append(snapshots, snapshots[0])); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+}
+
+func TestMultiRangeGeneratorBounds(t *testing.T) {
+	idGenerator, err := NewGeneratorFromRanges([]Range{{500, 599}, {100, 199}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the IDs between the ranges are not counted
+	if minValue, maxValue, capacity := idGenerator.MinValue(), idGenerator.MaxValue(),
+		idGenerator.Capacity(); minValue != 100 || maxValue != 599 || capacity != 200 {
+		t.Errorf("expected [100, 599] of capacity 200, output [%d, %d] of %d", minValue, maxValue, capacity)
+	}
+	for id, expected := range map[int64]bool{99: false, 100: true, 199: true, 300: false, 500: true, 600: false} {
+		if contains := idGenerator.Contains(id); contains != expected {
+			t.Errorf("Contains(%d): expected %v, output %v", id, expected, contains)
+		}
+	}
+}
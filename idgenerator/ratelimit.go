@@ -0,0 +1,114 @@
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WithRateLimit limits the allocations picking an ID to perSecond IDs per second on average,
+// with bursts of up to burst IDs, so that one client allocating in a tight loop cannot exhaust a shared pool
+// within seconds. Allocate, AllocateMany, AllocateManyCtx, Preallocate, AllocatePriority and AllocateSticky
+// fail with an error wrapping ErrRateLimited over the limit, AllocateCtx and AllocateForCtx wait for the limit
+// or ctx instead. The other ways of allocating, such as AllocateSpecific, AllocateFor or AllocateContiguous,
+// are not limited. A call failing once it passed the limit, e.g. on an exhausted pool, gives its IDs back
+// to the limit, so that the failed calls do not count against it.
+// The limit follows the clock of WithClock, a perSecond of 0 or below keeps the default, no limit,
+// and a burst below 1 is 1, so AllocateMany of more than burst IDs always fails.
+// The limiter has a lock of its own, an allocation over the limit does not take the lock of the generator.
+func WithRateLimit(perSecond float64, burst int) Option {
+	return func(idGenerator *IDGenerator) {
+		if perSecond <= 0 {
+			idGenerator.limiter = nil
+			return
+		}
+		if burst < 1 {
+			burst = 1
+		}
+		idGenerator.limiter = &rateLimiter{perSecond: perSecond, burst: float64(burst), tokens: float64(burst)}
+	}
+}
+
+// rateLimiter is the token bucket of WithRateLimit, it holds up to burst tokens and gains perSecond a second
+type rateLimiter struct {
+	mtx       sync.Mutex
+	perSecond float64
+	burst     float64
+	tokens    float64
+	// last is when tokens was brought up to date, zero before the first call
+	last time.Time
+}
+
+// take takes n tokens at now and returns 0 if there are enough,
+// or how long to wait for them otherwise, taking none then
+func (l *rateLimiter) take(now time.Time, n float64) time.Duration {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if !l.last.IsZero() && now.After(l.last) {
+		if l.tokens += now.Sub(l.last).Seconds() * l.perSecond; l.tokens > l.burst {
+			l.tokens = l.burst
+		}
+	}
+	if l.last.IsZero() || now.After(l.last) {
+		l.last = now
+	}
+	if l.tokens >= n {
+		l.tokens -= n
+		return 0
+	}
+	// at least a nanosecond, so that a wait is never mistaken for success
+	return time.Duration((n-l.tokens)/l.perSecond*float64(time.Second)) + 1
+}
+
+// refund gives back n tokens taken by a call which allocated nothing
+func (l *rateLimiter) refund(n float64) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if l.tokens += n; l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+}
+
+func (l *rateLimiter) clone() *rateLimiter {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	return &rateLimiter{perSecond: l.perSecond, burst: l.burst, tokens: l.tokens, last: l.last}
+}
+
+// limitRate takes n tokens of the limiter of WithRateLimit, or returns an error wrapping ErrRateLimited
+func (idGenerator *IDGenerator) limitRate(n int) error {
+	if idGenerator.limiter == nil {
+		return nil
+	}
+	if wait := idGenerator.limiter.take(idGenerator.clock.Now(), float64(n)); wait > 0 {
+		return fmt.Errorf("%w: %d IDs over %v per second, retry in %v", ErrRateLimited,
+			n, idGenerator.limiter.perSecond, wait)
+	}
+	return nil
+}
+
+// refundRate gives back the n tokens of limitRate or waitRate of a call which failed
+func (idGenerator *IDGenerator) refundRate(n int) {
+	if idGenerator.limiter != nil {
+		idGenerator.limiter.refund(float64(n))
+	}
+}
+
+// waitRate waits for a token of the limiter of WithRateLimit or for ctx, whichever comes first
+func (idGenerator *IDGenerator) waitRate(ctx context.Context) error {
+	if idGenerator.limiter == nil {
+		return nil
+	}
+	for {
+		wait := idGenerator.limiter.take(idGenerator.clock.Now(), 1)
+		if wait == 0 {
+			return nil
+		}
+		select {
+		case <-idGenerator.clock.After(wait):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
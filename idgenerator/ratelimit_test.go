@@ -0,0 +1,129 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRateLimit(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithRateLimit(10, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the burst, then nothing until the clock moves
+	allocateN(t, idGenerator, 3)
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %+v", err)
+	}
+	clock.Advance(100 * time.Millisecond)
+	allocateN(t, idGenerator, 1)
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %+v", err)
+	}
+
+	// the bucket holds burst tokens at most
+	clock.Advance(time.Hour)
+	if _, err = idGenerator.AllocateMany(4); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited over the burst, got %+v", err)
+	}
+	if _, err = idGenerator.AllocateMany(3); err != nil {
+		t.Error(err)
+	}
+	if used := idGenerator.Used(); used != 7 {
+		t.Errorf("expected 7 used, output %d", used)
+	}
+	if stats := idGenerator.Stats(); stats.AllocationFailures != 0 {
+		t.Errorf("expected the limited calls not counted as failures, output %d", stats.AllocationFailures)
+	}
+
+	// the other ways of allocating are not limited
+	if err = idGenerator.AllocateSpecific(50); err != nil {
+		t.Error(err)
+	}
+	// a copy has its own limit
+	clone := idGenerator.Clone()
+	clock.Advance(100 * time.Millisecond)
+	allocateN(t, clone, 1)
+	allocateN(t, idGenerator, 1)
+}
+
+func TestRateLimitRefund(t *testing.T) {
+	// no token comes back with time
+	idGenerator, err := NewGeneratorWithOptions(1, 3, WithClock(newFakeClock()), WithRateLimit(1.0/3600, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := allocateN(t, idGenerator, 2)
+	if err = idGenerator.AllocateSpecific(3); err != nil {
+		t.Fatal(err)
+	}
+	// the calls failing on the exhausted pool give their token back
+	for i := 0; i < 3; i++ {
+		if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+			t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+		}
+	}
+	freeAll(t, idGenerator, ids...)
+	allocateN(t, idGenerator, 1)
+	// the other calls picking an ID are limited as well
+	if _, err = idGenerator.AllocateSticky("ue"); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %+v", err)
+	}
+	if _, err = idGenerator.AllocateManyCtx(context.Background(), 1); !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited, got %+v", err)
+	}
+}
+
+func TestRateLimitCtx(t *testing.T) {
+	// a token every 20ms
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithRateLimit(50, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	start := clock.Now()
+	errs := make(chan error, 1)
+	go func() {
+		for i := 0; i < 3; i++ {
+			if _, err := idGenerator.AllocateCtx(ctx); err != nil {
+				errs <- err
+				return
+			}
+		}
+		errs <- nil
+	}()
+	// the second and third calls wait for a token, and a nanosecond more
+	for i := 0; i < 2; i++ {
+		clock.waitForTimers(1)
+		clock.Advance(20*time.Millisecond + time.Nanosecond)
+	}
+	if err = <-errs; err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed < 40*time.Millisecond {
+		t.Errorf("expected AllocateCtx to wait for the limit, 3 IDs in %v", elapsed)
+	}
+
+	// a token an hour, the context is done first
+	slow, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithRateLimit(1.0/3600, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, slow, 1)
+	cancelled, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		clock.waitForTimers(1)
+		cancel()
+	}()
+	if _, err = slow.AllocateCtx(cancelled); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", err)
+	}
+	if used := slow.Used(); used != 1 {
+		t.Errorf("expected 1 used, output %d", used)
+	}
+}
@@ -0,0 +1,256 @@
+package idgenerator
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// The operations of WithRecorder and Replay
+const (
+	opAllocate     = "allocate"
+	opSpecific     = "specific"
+	opMany         = "many"
+	opFree         = "free"
+	opReserveRange = "reserve-range"
+	opFreeRange    = "free-range"
+	opReset        = "reset"
+	opPreallocate  = "preallocate"
+	opFast         = "fast"
+	opFlush        = "flush"
+	opFor          = "for"
+	opContiguous   = "contiguous"
+)
+
+// recordedErrors name the errors of the recorded results, an error which is none of them is recorded as "error"
+var recordedErrors = []struct {
+	err  error
+	name string
+}{
+	{ErrPoolExhausted, "exhausted"},
+	{ErrOutOfRange, "out-of-range"},
+	{ErrInvalidRange, "invalid-range"},
+	{ErrAlreadyAllocated, "allocated"},
+	{ErrNotAllocated, "not-allocated"},
+	{ErrReserved, "reserved"},
+	{ErrQuarantined, "quarantined"},
+	{ErrNoContiguousBlock, "no-contiguous-block"},
+	{ErrQuotaExceeded, "quota-exceeded"},
+	{ErrRateLimited, "rate-limited"},
+	{ErrFiltered, "filtered"},
+	{ErrClosed, "closed"},
+}
+
+// recorder writes the operations of WithRecorder, mtx keeps the lines whole
+type recorder struct {
+	mtx    sync.Mutex
+	w      io.Writer
+	failed bool
+}
+
+// WithRecorder writes every call of Allocate, AllocateSpecific, AllocateMany, AllocateFor, AllocateContiguous,
+// Preallocate, FastAllocate, Flush, FreeID, FreeMany, ReserveRange, FreeRange and Reset to w as a line with
+// its arguments and its result, for Replay to run them again, e.g.
+//
+//	allocate = 1
+//	specific 7 = ok
+//	many 2 = 2,3
+//	for "smf" = 4
+//	free 9 = !not-allocated
+//	free-range 1 3 = 2
+//
+// A failed call is recorded with the kind of its error, such as !exhausted, and FreeMany as a free per ID.
+// The other calls are not recorded, a sequence using them, the leases, the Ctx variants or Resize for instance,
+// does not replay.
+// The calls are recorded in the order they return, w is written by at most one of them at a time:
+// the order is the one they took effect in only if they are not concurrent.
+// Recording stops at the first error of w, which is logged by the Logger of WithLogger.
+func WithRecorder(w io.Writer) Option {
+	return func(idGenerator *IDGenerator) {
+		if w == nil {
+			idGenerator.recorder = nil
+			return
+		}
+		idGenerator.recorder = &recorder{w: w}
+	}
+}
+
+// recordedResult returns the result of a call as recorded: the kind of err, or ids, or ok if there are none
+func recordedResult(ids []int64, err error) string {
+	if err != nil {
+		for _, recorded := range recordedErrors {
+			if errors.Is(err, recorded.err) {
+				return "!" + recorded.name
+			}
+		}
+		return "!error"
+	}
+	if len(ids) == 0 {
+		return "ok"
+	}
+	return joinIDs(ids, ",")
+}
+
+func joinIDs(ids []int64, sep string) string {
+	var b strings.Builder
+	for i, id := range ids {
+		if i > 0 {
+			b.WriteString(sep)
+		}
+		b.WriteString(strconv.FormatInt(id, 10))
+	}
+	return b.String()
+}
+
+// recordLine returns the line of the call op of args with its result
+func recordLine(op string, args []int64, result string) string {
+	if len(args) == 0 {
+		return op + " = " + result
+	}
+	return op + " " + joinIDs(args, " ") + " = " + result
+}
+
+// record writes the call op of args which returned ids and err, if the generator has a recorder
+func (idGenerator *IDGenerator) record(op string, args, ids []int64, err error) {
+	idGenerator.writeRecord(recordLine(op, args, recordedResult(ids, err)))
+}
+
+// recordFor writes the call of AllocateFor for owner which returned id and err, the owner is quoted
+func (idGenerator *IDGenerator) recordFor(owner string, id int64, err error) {
+	idGenerator.writeRecord(opFor + " " + strconv.Quote(owner) + " = " + recordedResult([]int64{id}, err))
+}
+
+// writeRecord writes line to the recorder of the generator, if it has one
+func (idGenerator *IDGenerator) writeRecord(line string) {
+	r := idGenerator.recorder
+	if r == nil {
+		return
+	}
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	if r.failed {
+		return
+	}
+	if _, werr := io.WriteString(r.w, line+"\n"); werr != nil {
+		r.failed = true
+		idGenerator.logger.Printf("idgenerator[%d-%d]: recording stopped: %v",
+			idGenerator.minValue, idGenerator.maxValue, werr)
+	}
+}
+
+// ReplayError is the first call of Replay whose result differs from the recorded one.
+// Index counts the calls from 0, Recorded and Replayed are the results as recorded by WithRecorder.
+type ReplayError struct {
+	Index     int
+	Operation string
+	Recorded  string
+	Replayed  string
+}
+
+func (e *ReplayError) Error() string {
+	return fmt.Sprintf("replay diverged at operation %d %q: recorded %s, replayed %s",
+		e.Index, e.Operation, e.Recorded, e.Replayed)
+}
+
+// Replay runs the calls recorded by WithRecorder, read from r, on idGenerator and checks that they return
+// the recorded results. idGenerator must be in the state the recording started from, usually a fresh generator
+// of the same range and options; with WithRandomAllocation, a random source of WithRandSource seeded like
+// the one of the recording. It returns a *ReplayError for the first call returning another result,
+// or an error naming the line of the first malformed one.
+func Replay(r io.Reader, idGenerator *IDGenerator) error {
+	scanner := bufio.NewScanner(r)
+	index := 0
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		// a result holds no " = ", unlike the quoted owner of AllocateFor maybe
+		i := strings.LastIndex(text, " = ")
+		if i < 0 {
+			return fmt.Errorf("replay: line %d: no result in %q", line, text)
+		}
+		call, recorded := text[:i], text[i+len(" = "):]
+		op, rest, _ := strings.Cut(strings.TrimSpace(call), " ")
+		if op == "" {
+			return fmt.Errorf("replay: line %d: no operation in %q", line, text)
+		}
+		var replayed string
+		var err error
+		if op == opFor {
+			owner, uerr := strconv.Unquote(strings.TrimSpace(rest))
+			if uerr != nil {
+				return fmt.Errorf("replay: line %d: invalid owner %q", line, rest)
+			}
+			id, aerr := idGenerator.AllocateFor(owner)
+			replayed = recordedResult([]int64{id}, aerr)
+		} else {
+			fields := strings.Fields(rest)
+			args := make([]int64, len(fields))
+			for i, field := range fields {
+				arg, perr := strconv.ParseInt(field, 10, 64)
+				if perr != nil {
+					return fmt.Errorf("replay: line %d: invalid argument %q", line, field)
+				}
+				args[i] = arg
+			}
+			replayed, err = replayCall(idGenerator, op, args)
+		}
+		if err != nil {
+			return fmt.Errorf("replay: line %d: %v", line, err)
+		}
+		if replayed != recorded {
+			return &ReplayError{Index: index, Operation: call, Recorded: recorded, Replayed: replayed}
+		}
+		index++
+	}
+	return scanner.Err()
+}
+
+// replayCall runs the call op of args on idGenerator and returns its result as recorded
+func replayCall(idGenerator *IDGenerator, op string, args []int64) (string, error) {
+	arity := map[string]int{
+		opAllocate: 0, opSpecific: 1, opMany: 1, opFree: 1, opReserveRange: 2, opFreeRange: 2, opReset: 0,
+		opPreallocate: 1, opFast: 0, opFlush: 0, opContiguous: 1,
+	}
+	n, ok := arity[op]
+	if !ok {
+		return "", fmt.Errorf("unknown operation %q", op)
+	}
+	if len(args) != n {
+		return "", fmt.Errorf("%s takes %d arguments, got %d", op, n, len(args))
+	}
+	switch op {
+	case opAllocate:
+		id, err := idGenerator.Allocate()
+		return recordedResult([]int64{id}, err), nil
+	case opSpecific:
+		return recordedResult(nil, idGenerator.AllocateSpecific(args[0])), nil
+	case opMany:
+		ids, err := idGenerator.AllocateMany(int(args[0]))
+		return recordedResult(ids, err), nil
+	case opFree:
+		return recordedResult(nil, idGenerator.FreeID(args[0])), nil
+	case opReserveRange:
+		return recordedResult(nil, idGenerator.ReserveRange(args[0], args[1])), nil
+	case opFreeRange:
+		freed, err := idGenerator.FreeRange(args[0], args[1])
+		return recordedResult([]int64{freed}, err), nil
+	case opPreallocate:
+		return recordedResult(nil, idGenerator.Preallocate(int(args[0]))), nil
+	case opFast:
+		id, err := idGenerator.FastAllocate()
+		return recordedResult([]int64{id}, err), nil
+	case opFlush:
+		return recordedResult([]int64{int64(idGenerator.Flush())}, nil), nil
+	case opContiguous:
+		first, err := idGenerator.AllocateContiguous(args[0])
+		return recordedResult([]int64{first}, err), nil
+	}
+	idGenerator.Reset()
+	return recordedResult(nil, nil), nil
+}
@@ -0,0 +1,176 @@
+package idgenerator
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var buf bytes.Buffer
+	idGenerator, err := NewGeneratorWithOptions(1, 5, WithRecorder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 1)
+	if err = idGenerator.AllocateSpecific(4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateMany(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateMany(2); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %v", err)
+	}
+	if freed, errs := idGenerator.FreeMany([]int64{2, 2, 9}); freed != 1 || len(errs) != 2 {
+		t.Fatalf("expected 1 freed and 2 errors, got %d and %v", freed, errs)
+	}
+	if _, err = idGenerator.FreeRange(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.ReserveRange(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	idGenerator.Reset()
+
+	expected := "allocate = 1\n" +
+		"specific 4 = ok\n" +
+		"many 2 = 2,3\n" +
+		"many 2 = !exhausted\n" +
+		"free 2 = ok\n" +
+		"free 2 = !not-allocated\n" +
+		"free 9 = !out-of-range\n" +
+		"free-range 1 3 = 2\n" +
+		"reserve-range 1 2 = ok\n" +
+		"reset = ok\n"
+	if buf.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	replayed, err := NewGeneratorWithOptions(1, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Replay(strings.NewReader(expected), replayed); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReplayRandom(t *testing.T) {
+	var buf bytes.Buffer
+	idGenerator, err := NewGeneratorWithOptions(1, 1000, WithRandSource(rand.NewSource(1)), WithRecorder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := allocateN(t, idGenerator, 50)
+	freeAll(t, idGenerator, ids[:25]...)
+	allocateN(t, idGenerator, 10)
+
+	replayed, err := NewGeneratorWithOptions(1, 1000, WithRandSource(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = Replay(bytes.NewReader(buf.Bytes()), replayed); err != nil {
+		t.Fatal(err)
+	}
+	if replayed.Used() != idGenerator.Used() {
+		t.Fatalf("expected %d IDs used, got %d", idGenerator.Used(), replayed.Used())
+	}
+
+	diverging, err := NewGeneratorWithOptions(1, 1000, WithRandSource(rand.NewSource(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = Replay(bytes.NewReader(buf.Bytes()), diverging)
+	var replayErr *ReplayError
+	if !errors.As(err, &replayErr) {
+		t.Fatalf("expected a ReplayError, got %v", err)
+	}
+	if replayErr.Index != 0 || replayErr.Operation != "allocate" || replayErr.Recorded == replayErr.Replayed {
+		t.Fatalf("unexpected divergence %+v", replayErr)
+	}
+}
+
+func TestReplayMalformed(t *testing.T) {
+	for _, input := range []string{
+		"allocate\n",
+		"free x = ok\n",
+		"allocate = 1\nfly 1 = ok\n",
+		"specific = ok\n",
+		"= ok\n",
+		"for smf = 1\n",
+	} {
+		idGenerator, err := NewGeneratorWithOptions(1, 5)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = Replay(strings.NewReader(input), idGenerator)
+		var replayErr *ReplayError
+		if err == nil || errors.As(err, &replayErr) || !strings.HasPrefix(err.Error(), "replay: line ") {
+			t.Errorf("%q: expected a malformed line error, got %v", input, err)
+		}
+	}
+}
+
+func TestRecorderCache(t *testing.T) {
+	var buf bytes.Buffer
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithRecorder(&buf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Preallocate(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.FastAllocate(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.FreeRange(2, 2); err != nil {
+		t.Fatal(err)
+	}
+	if freed := idGenerator.Flush(); freed != 1 {
+		t.Fatalf("expected 1 ID flushed, got %d", freed)
+	}
+	if _, err = idGenerator.FastAllocate(); err != nil {
+		t.Fatal(err)
+	}
+	idGenerator.SetQuota("smf = 1", 1)
+	if _, err = idGenerator.AllocateFor("smf = 1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("smf = 1"); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("expected ErrQuotaExceeded, got %v", err)
+	}
+	if _, err = idGenerator.AllocateContiguous(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateContiguous(3); !errors.Is(err, ErrNoContiguousBlock) {
+		t.Fatalf("expected ErrNoContiguousBlock, got %v", err)
+	}
+
+	expected := "preallocate 3 = ok\n" +
+		"fast = 1\n" +
+		"free-range 2 2 = 1\n" +
+		"flush = 1\n" +
+		"fast = 4\n" +
+		"for \"smf = 1\" = 5\n" +
+		"for \"smf = 1\" = !quota-exceeded\n" +
+		"contiguous 3 = 6\n" +
+		"contiguous 3 = !no-contiguous-block\n"
+	if buf.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+
+	replayed, err := NewGeneratorWithOptions(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	replayed.SetQuota("smf = 1", 1)
+	if err = Replay(strings.NewReader(expected), replayed); err != nil {
+		t.Fatal(err)
+	}
+	if owner, ok := replayed.Owner(5); !ok || owner != "smf = 1" {
+		t.Fatalf("expected 5 owned by smf = 1, got %q %v", owner, ok)
+	}
+}
@@ -0,0 +1,112 @@
+package idgenerator
+
+import "container/list"
+
+// RecycleOrder is when WithFIFORecycling reuses the freed IDs
+type RecycleOrder int
+
+const (
+	// RecycleFirst reuses the freed IDs before any never used one is allocated
+	RecycleFirst RecycleOrder = iota
+	// RecycleLast allocates every never used ID before reusing the freed ones
+	RecycleLast
+)
+
+// WithFIFORecycling makes Allocate and its variants reuse the freed IDs strictly in the order they were freed,
+// before or after the never used IDs depending on order, instead of wherever the strategy of the generator
+// finds them. The strategy still picks among the never used IDs. A freed ID taken by AllocateSpecific,
+// ReserveRange or AllocateWithPreference leaves the queue, the quarantined IDs of WithReuseDelay join it
+// once their delay is over. Reset and restoring a snapshot empty the queue,
+// Resize keeps the queued IDs in the new range.
+// The generator keeps a second store for the IDs ever allocated, which takes as much memory as the first.
+func WithFIFORecycling(order RecycleOrder) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.recycling = &recycler{order: order}
+	}
+}
+
+// recycler queues the freed offsets of WithFIFORecycling
+type recycler struct {
+	order RecycleOrder
+	// touched has the offsets allocated at least once and the excluded ones set, the others are never used and free.
+	// occupied is the number of offsets set in touched.
+	touched  Store
+	occupied uint64
+	// queue holds the freed offsets which are free in order of release, queued maps them to their elements
+	queue  *list.List
+	queued map[uint64]*list.Element
+}
+
+// reset forgets every freed offset, touched is set exactly where store is
+func (r *recycler) reset(store Store, occupied uint64) {
+	r.touched = store.Clone()
+	r.occupied = occupied
+	r.queue = list.New()
+	r.queued = make(map[uint64]*list.Element)
+}
+
+func (r *recycler) clone() *recycler {
+	clone := &recycler{order: r.order, touched: r.touched.Clone(), occupied: r.occupied}
+	clone.queue = list.New()
+	clone.queued = make(map[uint64]*list.Element, len(r.queued))
+	for e := r.queue.Front(); e != nil; e = e.Next() {
+		offset := e.Value.(uint64)
+		clone.queued[offset] = clone.queue.PushBack(offset)
+	}
+	return clone
+}
+
+// push queues the free offset
+func (r *recycler) push(offset uint64) {
+	if !r.touched.Has(offset) {
+		r.touched.Set(offset)
+		r.occupied++
+	}
+	r.queued[offset] = r.queue.PushBack(offset)
+}
+
+// take removes offset, which is being allocated, from the queue, or marks it as used otherwise
+func (r *recycler) take(offset uint64) {
+	if e, ok := r.queued[offset]; ok {
+		r.queue.Remove(e)
+		delete(r.queued, offset)
+		return
+	}
+	if !r.touched.Has(offset) {
+		r.touched.Set(offset)
+		r.occupied++
+	}
+}
+
+// next returns the queued offset to allocate, or false if a never used one is to be allocated instead.
+// last is the last offset of the generator.
+func (r *recycler) next(last uint64) (uint64, bool) {
+	if r.queue.Len() == 0 {
+		return 0, false
+	}
+	if r.order == RecycleFirst || r.occupied > last {
+		return r.queue.Front().Value.(uint64), true
+	}
+	return 0, false
+}
+
+func (r *recycler) isQueued(offset uint64) bool {
+	_, ok := r.queued[offset]
+	return ok
+}
+
+// clearLocked clears the freed offset in store and queues it for WithFIFORecycling, the caller must hold lock
+func (idGenerator *IDGenerator) clearLocked(offset uint64) {
+	idGenerator.store.Clear(offset)
+	if idGenerator.recycling != nil {
+		idGenerator.recycling.push(offset)
+	}
+}
+
+// resetRecyclingLocked empties the queue of WithFIFORecycling, every free offset counts as never used then.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) resetRecyclingLocked() {
+	if idGenerator.recycling != nil {
+		idGenerator.recycling.reset(idGenerator.store, idGenerator.occupiedLocked())
+	}
+}
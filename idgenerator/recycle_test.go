@@ -0,0 +1,155 @@
+package idgenerator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// allocateN allocates n IDs one by one
+func allocateN(t testing.TB, idGenerator *IDGenerator, n int) []int64 {
+	t.Helper()
+	ids := make([]int64, n)
+	for i := range ids {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[i] = id
+	}
+	return ids
+}
+
+func freeAll(t *testing.T, idGenerator *IDGenerator, ids ...int64) {
+	t.Helper()
+	for _, id := range ids {
+		if err := idGenerator.FreeID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFIFORecycling(t *testing.T) {
+	testCases := []struct {
+		name     string
+		order    RecycleOrder
+		strategy Option
+		expected []int64
+	}{
+		// 7, 3 and 5 are freed in that order, 6 and up are never used
+		{"first sequential", RecycleFirst, WithSequentialAllocation(), []int64{7, 3, 5, 6, 8, 9}},
+		{"last sequential", RecycleLast, WithSequentialAllocation(), []int64{6, 8, 9, 10, 7, 3}},
+		{"first lowest free", RecycleFirst, WithLowestFreeAllocation(), []int64{7, 3, 5, 6, 8, 9}},
+		{"last lowest free", RecycleLast, WithLowestFreeAllocation(), []int64{6, 8, 9, 10, 7, 3}},
+	}
+	for _, testCase := range testCases {
+		for _, storeOption := range storeOptions {
+			t.Run(testCase.name+" "+storeOption.name, func(t *testing.T) {
+				opts := append([]Option{WithFIFORecycling(testCase.order), testCase.strategy}, storeOption.opts...)
+				idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				allocateN(t, idGenerator, 5)
+				if err = idGenerator.AllocateSpecific(7); err != nil {
+					t.Fatal(err)
+				}
+				freeAll(t, idGenerator, 7, 3, 5)
+				if ids := allocateN(t, idGenerator, 6); !reflect.DeepEqual(ids, testCase.expected) {
+					t.Errorf("expected %v, output %v", testCase.expected, ids)
+				}
+			})
+		}
+	}
+}
+
+func TestFIFORecyclingOutOfOrder(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			clock := newFakeClock()
+			opts := append([]Option{WithFIFORecycling(RecycleFirst), WithClock(clock)}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			allocateN(t, idGenerator, 6)
+			freeAll(t, idGenerator, 4, 2, 6, 1)
+			// the queued IDs taken out of order leave the queue
+			if err = idGenerator.AllocateSpecific(6); err != nil {
+				t.Fatal(err)
+			}
+			if id, ok, err := idGenerator.AllocateWithPreference(2); err != nil || !ok || id != 2 {
+				t.Fatalf("expected preferred ID 2, output %d, %v, %+v", id, ok, err)
+			}
+			if id, err := idGenerator.Peek(); err != nil || id != 4 {
+				t.Errorf("expected Peek 4, output %d, %+v", id, err)
+			}
+			if ids := allocateN(t, idGenerator, 3); !reflect.DeepEqual(ids, []int64{4, 1, 7}) {
+				t.Errorf("expected [4 1 7], output %v", ids)
+			}
+
+			// expired leases are recycled like freed IDs
+			lease, err := idGenerator.AllocateLease(time.Minute)
+			if err != nil {
+				t.Fatal(err)
+			}
+			freeAll(t, idGenerator, 3)
+			clock.Advance(time.Minute)
+			if ids := allocateN(t, idGenerator, 3); !reflect.DeepEqual(ids, []int64{3, lease, 9}) {
+				t.Errorf("expected [3 %d 9], output %v", lease, ids)
+			}
+		})
+	}
+}
+
+func TestFIFORecyclingRandom(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithFIFORecycling(RecycleFirst), WithRandomAllocation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := allocateN(t, idGenerator, 50)
+	freeAll(t, idGenerator, ids[10], ids[3], ids[40])
+	if recycled := allocateN(t, idGenerator, 3); !reflect.DeepEqual(recycled, []int64{ids[10], ids[3], ids[40]}) {
+		t.Errorf("expected %v, output %v", []int64{ids[10], ids[3], ids[40]}, recycled)
+	}
+	// the never used IDs are all allocated before the pool is exhausted
+	allocateN(t, idGenerator, 50)
+	if available := idGenerator.Available(); available != 0 {
+		t.Errorf("expected an exhausted pool, %d available", available)
+	}
+}
+
+func TestFIFORecyclingQuarantineAndResize(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithFIFORecycling(RecycleFirst),
+		WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 8)
+	freeAll(t, idGenerator, 5)
+	clock.Advance(time.Minute)
+	freeAll(t, idGenerator, 2, 8)
+	// only 5 is out of quarantine, 2 and 8 follow once theirs is over
+	if ids := allocateN(t, idGenerator, 2); !reflect.DeepEqual(ids, []int64{5, 9}) {
+		t.Errorf("expected [5 9], output %v", ids)
+	}
+	clock.Advance(time.Minute)
+	freeAll(t, idGenerator, 1)
+	clock.Advance(time.Minute)
+
+	// 1 is out of the new range, the others keep their order
+	if err = idGenerator.Resize(2, 9); err != nil {
+		t.Fatal(err)
+	}
+	clone := idGenerator.Clone()
+	for _, g := range []*IDGenerator{idGenerator, clone} {
+		if ids := allocateN(t, g, 2); !reflect.DeepEqual(ids, []int64{2, 8}) {
+			t.Errorf("expected [2 8], output %v", ids)
+		}
+	}
+	idGenerator.Reset()
+	if ids := allocateN(t, idGenerator, 2); !reflect.DeepEqual(ids, []int64{2, 3}) {
+		t.Errorf("expected [2 3] after Reset, output %v", ids)
+	}
+}
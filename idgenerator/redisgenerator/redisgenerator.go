@@ -0,0 +1,221 @@
+// redisgenerator allocates IDs from a pool kept in Redis, so that several processes can share it, e.g.
+//
+//	generator, err := redisgenerator.New(client, "smf:teid", 1, 1<<20)
+//	...
+//	teid, err := generator.Allocate()
+//
+// The used IDs are a bitmap in Redis and every change is made by a Lua script, atomically.
+// Every method returns the errors of Redis, IsAllocated and Used as well, so unlike an in-memory generator
+// a Generator does not satisfy idgenerator.Allocator.
+package redisgenerator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+// maxSize is the number of bits a Redis string holds, the largest range a Generator can keep
+const maxSize = 1 << 32
+
+// allocateScript sets the first clear bit from the next key on, wrapping around to 0, and returns its offset,
+// or -1 if the used count has reached the size of ARGV[1].
+// KEYS are the bitmap, the offset the next allocation starts searching at and the used count.
+var allocateScript = redis.NewScript(`
+local size = tonumber(ARGV[1])
+local used = tonumber(redis.call('GET', KEYS[3]) or '0')
+if used >= size then
+	return -1
+end
+local next = tonumber(redis.call('GET', KEYS[2]) or '0')
+local offset = -1
+-- BITPOS searches whole bytes, check the rest of the byte of next bit by bit
+local byteEnd = math.min(next - next % 8 + 7, size - 1)
+for bit = next, byteEnd do
+	if redis.call('GETBIT', KEYS[1], bit) == 0 then
+		offset = bit
+		break
+	end
+end
+if offset < 0 and byteEnd + 1 < size then
+	offset = redis.call('BITPOS', KEYS[1], 0, (byteEnd + 1) / 8)
+	-- BITPOS finds no bit from a start past the end of the string, where every bit is clear
+	if offset < 0 then
+		offset = byteEnd + 1
+	end
+end
+if offset < 0 or offset >= size then
+	offset = redis.call('BITPOS', KEYS[1], 0)
+end
+redis.call('SETBIT', KEYS[1], offset, 1)
+redis.call('INCR', KEYS[3])
+redis.call('SET', KEYS[2], (offset + 1) % size)
+return offset
+`)
+
+// setScript sets the bit of ARGV[1] to ARGV[2] and adjusts the used count, it returns 0 if the bit was set already.
+// KEYS are those of allocateScript.
+var setScript = redis.NewScript(`
+local value = tonumber(ARGV[2])
+if redis.call('SETBIT', KEYS[1], ARGV[1], value) == value then
+	return 0
+end
+if value == 1 then
+	redis.call('INCR', KEYS[3])
+else
+	redis.call('DECR', KEYS[3])
+end
+return 1
+`)
+
+// Generator allocates IDs in [minValue, maxValue] from a pool kept in Redis under its key.
+// The generators of every process sharing the pool must be created with the same key and range.
+// A failed Redis call fails the method with its error, there is no fallback to local allocation.
+// The methods without a context are bounded by the timeouts of the Redis client only.
+type Generator struct {
+	client   redis.Cmdable
+	key      string
+	keys     []string
+	minValue int64
+	maxValue int64
+}
+
+// New returns a Generator of the pool of key in [minValue, maxValue], which is empty if key is not in Redis yet.
+// It returns an error wrapping idgenerator.ErrInvalidRange if minValue > maxValue
+// or if the range holds more than 2^32 IDs, the size of a Redis bitmap.
+func New(client redis.Cmdable, key string, minValue, maxValue int64) (*Generator, error) {
+	if minValue > maxValue {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", idgenerator.ErrInvalidRange, minValue, maxValue)
+	}
+	if uint64(maxValue)-uint64(minValue) >= maxSize {
+		return nil, fmt.Errorf("%w: [%d, %d] holds more than %d IDs", idgenerator.ErrInvalidRange,
+			minValue, maxValue, uint64(maxSize))
+	}
+	// the hash tag keeps the keys on one node of a Redis cluster, scripts cannot span nodes
+	tag := "{" + key + "}"
+	return &Generator{
+		client:   client,
+		key:      key,
+		keys:     []string{tag + ":bits", tag + ":next", tag + ":used"},
+		minValue: minValue,
+		maxValue: maxValue,
+	}, nil
+}
+
+func (g *Generator) size() uint64 {
+	return uint64(g.maxValue) - uint64(g.minValue) + 1
+}
+
+func (g *Generator) redisError(err error) error {
+	return fmt.Errorf("redisgenerator %s: %w", g.key, err)
+}
+
+func (g *Generator) outOfRangeError(id int64) error {
+	return fmt.Errorf("%w: ID[%d] not in [%d, %d]", idgenerator.ErrOutOfRange, id, g.minValue, g.maxValue)
+}
+
+// Allocate allocates an ID like AllocateCtx without a deadline
+func (g *Generator) Allocate() (int64, error) {
+	return g.AllocateCtx(context.Background())
+}
+
+// AllocateCtx allocates the next free ID after the last one allocated by any process, wrapping around to minValue,
+// or returns an error wrapping idgenerator.ErrPoolExhausted if every ID is in use.
+// Unlike IDGenerator.AllocateCtx it does not wait for an ID to be freed, ctx bounds the Redis call.
+func (g *Generator) AllocateCtx(ctx context.Context) (int64, error) {
+	offset, err := allocateScript.Run(ctx, g.client, g.keys, g.size()).Int64()
+	if err != nil {
+		return 0, g.redisError(err)
+	}
+	if offset < 0 {
+		return 0, fmt.Errorf("%w: every ID of [%d, %d] in use", idgenerator.ErrPoolExhausted, g.minValue, g.maxValue)
+	}
+	return int64(uint64(g.minValue) + uint64(offset)), nil
+}
+
+// AllocateSpecific allocates id like AllocateSpecificCtx without a deadline
+func (g *Generator) AllocateSpecific(id int64) error {
+	return g.AllocateSpecificCtx(context.Background(), id)
+}
+
+// AllocateSpecificCtx allocates exactly id. It returns an error wrapping idgenerator.ErrOutOfRange
+// if id is outside [minValue, maxValue] or idgenerator.ErrAlreadyAllocated if id is in use.
+func (g *Generator) AllocateSpecificCtx(ctx context.Context, id int64) error {
+	changed, err := g.set(ctx, id, 1)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return fmt.Errorf("%w: ID[%d]", idgenerator.ErrAlreadyAllocated, id)
+	}
+	return nil
+}
+
+// FreeID frees id like FreeIDCtx without a deadline
+func (g *Generator) FreeID(id int64) error {
+	return g.FreeIDCtx(context.Background(), id)
+}
+
+// FreeIDCtx frees the allocated id. It returns an error wrapping idgenerator.ErrOutOfRange
+// if id is outside [minValue, maxValue] or idgenerator.ErrNotAllocated if id is not allocated.
+func (g *Generator) FreeIDCtx(ctx context.Context, id int64) error {
+	changed, err := g.set(ctx, id, 0)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		return fmt.Errorf("%w: ID[%d]", idgenerator.ErrNotAllocated, id)
+	}
+	return nil
+}
+
+// set sets the bit of id to value and reports whether it changed
+func (g *Generator) set(ctx context.Context, id int64, value int) (bool, error) {
+	if id < g.minValue || id > g.maxValue {
+		return false, g.outOfRangeError(id)
+	}
+	offset := uint64(id) - uint64(g.minValue)
+	changed, err := setScript.Run(ctx, g.client, g.keys, offset, value).Int64()
+	if err != nil {
+		return false, g.redisError(err)
+	}
+	return changed == 1, nil
+}
+
+// IsAllocated reports whether id is allocated like IsAllocatedCtx without a deadline
+func (g *Generator) IsAllocated(id int64) (bool, error) {
+	return g.IsAllocatedCtx(context.Background(), id)
+}
+
+// IsAllocatedCtx reports whether id is allocated by any process, it is false for any id outside [minValue, maxValue]
+func (g *Generator) IsAllocatedCtx(ctx context.Context, id int64) (bool, error) {
+	if id < g.minValue || id > g.maxValue {
+		return false, nil
+	}
+	bit, err := g.client.GetBit(ctx, g.keys[0], int64(uint64(id)-uint64(g.minValue))).Result()
+	if err != nil {
+		return false, g.redisError(err)
+	}
+	return bit == 1, nil
+}
+
+// Used returns the number of allocated IDs like UsedCtx without a deadline
+func (g *Generator) Used() (int64, error) {
+	return g.UsedCtx(context.Background())
+}
+
+// UsedCtx returns the number of IDs allocated by all processes
+func (g *Generator) UsedCtx(ctx context.Context) (int64, error) {
+	used, err := g.client.Get(ctx, g.keys[2]).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, g.redisError(err)
+	}
+	return used, nil
+}
@@ -0,0 +1,189 @@
+package redisgenerator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/free5gc/util/idgenerator"
+)
+
+func newTestGenerator(t *testing.T, minValue, maxValue int64) (*Generator, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() {
+		if err := client.Close(); err != nil {
+			t.Error(err)
+		}
+	})
+	generator, err := New(client, "test", minValue, maxValue)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return generator, server
+}
+
+func TestGenerator(t *testing.T) {
+	generator, _ := newTestGenerator(t, 100, 119)
+	for expected := int64(100); expected <= 119; expected++ {
+		if id, err := generator.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	if _, err := generator.Allocate(); !errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if used, err := generator.Used(); err != nil || used != 20 {
+		t.Errorf("expected 20 used, output %d, %+v", used, err)
+	}
+
+	// the search wraps around to the IDs freed below the last allocation
+	for _, id := range []int64{103, 117} {
+		if err := generator.FreeID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, expected := range []int64{103, 117} {
+		if id, err := generator.Allocate(); err != nil || id != expected {
+			t.Errorf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+
+	if err := generator.FreeID(105); err != nil {
+		t.Fatal(err)
+	}
+	for id, expected := range map[int64]bool{105: false, 106: true, 120: false} {
+		if allocated, err := generator.IsAllocated(id); err != nil || allocated != expected {
+			t.Errorf("IsAllocated(%d): expected %v, output %v, %+v", id, expected, allocated, err)
+		}
+	}
+	if err := generator.FreeID(105); !errors.Is(err, idgenerator.ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err := generator.AllocateSpecific(105); err != nil {
+		t.Fatal(err)
+	}
+	if err := generator.AllocateSpecific(105); !errors.Is(err, idgenerator.ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	for _, id := range []int64{99, 120} {
+		if err := generator.AllocateSpecific(id); !errors.Is(err, idgenerator.ErrOutOfRange) {
+			t.Errorf("AllocateSpecific(%d): expected ErrOutOfRange, got %+v", id, err)
+		}
+		if err := generator.FreeID(id); !errors.Is(err, idgenerator.ErrOutOfRange) {
+			t.Errorf("FreeID(%d): expected ErrOutOfRange, got %+v", id, err)
+		}
+	}
+}
+
+func TestGeneratorEndOfBitmap(t *testing.T) {
+	generator, _ := newTestGenerator(t, 0, 23)
+	for expected := int64(0); expected < 8; expected++ {
+		if id, err := generator.Allocate(); err != nil || id != expected {
+			t.Fatalf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+	// the byte of the next ID is the last one of the bitmap, and full
+	for id := int64(8); id < 16; id++ {
+		if err := generator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := generator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := generator.Allocate(); err != nil || id != 16 {
+		t.Errorf("expected ID 16 past the end of the bitmap, output %d, %+v", id, err)
+	}
+}
+
+func TestGeneratorShared(t *testing.T) {
+	generator, server := newTestGenerator(t, 1, 1000)
+	// each generator stands for a process with its own connection
+	generators := make([]*Generator, 4)
+	for i := range generators {
+		client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+		defer func() {
+			if err := client.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+		shared, err := New(client, "test", 1, 1000)
+		if err != nil {
+			t.Fatal(err)
+		}
+		generators[i] = shared
+	}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	seen := make(map[int64]bool)
+	for _, shared := range generators {
+		wg.Add(1)
+		go func(shared *Generator) {
+			defer wg.Done()
+			for i := 0; i < 250; i++ {
+				id, err := shared.Allocate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mu.Lock()
+				if seen[id] {
+					t.Errorf("ID %d allocated twice", id)
+				}
+				seen[id] = true
+				mu.Unlock()
+			}
+		}(shared)
+	}
+	wg.Wait()
+	if used, err := generator.Used(); len(seen) != 1000 || err != nil || used != 1000 {
+		t.Errorf("expected 1000 IDs allocated, output %d, used %d, %+v", len(seen), used, err)
+	}
+}
+
+func TestGeneratorRedisDown(t *testing.T) {
+	generator, server := newTestGenerator(t, 1, 10)
+	if _, err := generator.Allocate(); err != nil {
+		t.Fatal(err)
+	}
+	server.Close()
+	if _, err := generator.Allocate(); err == nil || errors.Is(err, idgenerator.ErrPoolExhausted) {
+		t.Errorf("expected a connection error, got %+v", err)
+	}
+	if err := generator.FreeID(1); err == nil {
+		t.Error("FreeID succeeded without Redis")
+	}
+	if _, err := generator.UsedCtx(context.Background()); err == nil {
+		t.Error("UsedCtx succeeded without Redis")
+	}
+	if _, err := generator.Used(); err == nil {
+		t.Error("Used succeeded without Redis")
+	}
+	if _, err := generator.IsAllocated(1); err == nil {
+		t.Error("IsAllocated succeeded without Redis")
+	}
+	if _, err := generator.IsAllocatedCtx(context.Background(), 1); err == nil {
+		t.Error("IsAllocatedCtx succeeded without Redis")
+	}
+}
+
+func TestNewInvalidRange(t *testing.T) {
+	client := redis.NewClient(&redis.Options{})
+	defer func() {
+		if err := client.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	for _, r := range [][2]int64{{10, 1}, {0, 1 << 32}} {
+		if _, err := New(client, "test", r[0], r[1]); !errors.Is(err, idgenerator.ErrInvalidRange) {
+			t.Errorf("%v: expected ErrInvalidRange, got %+v", r, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package idgenerator
+
+import "fmt"
+
+// WithRefCounting makes the IDs reference counted for Acquire and Release: an allocated ID holds one reference,
+// Acquire adds one and Release drops one, freeing the ID with the last one.
+// FreeID and the other ways of freeing an ID still free it at once, with all its references.
+func WithRefCounting() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.refs = make(map[uint64]uint64)
+	}
+}
+
+func (idGenerator *IDGenerator) refCountingDisabledError() error {
+	return fmt.Errorf("idgenerator[%d-%d]: references are not counted without WithRefCounting",
+		idGenerator.minValue, idGenerator.maxValue)
+}
+
+// Acquire adds a reference to the allocated id, which Release drops again.
+// It returns an error wrapping ErrNotAllocated if id is not allocated, and fails without WithRefCounting.
+func (idGenerator *IDGenerator) Acquire(id int64) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) {
+		return idGenerator.outOfRangeError(id)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if idGenerator.refs == nil {
+		return idGenerator.refCountingDisabledError()
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.isExcluded(offset) {
+		return idGenerator.reservedError(id)
+	}
+	if !idGenerator.store.Has(offset) || idGenerator.isQuarantined(offset) {
+		return fmt.Errorf("%w: ID[%d]", ErrNotAllocated, id)
+	}
+	idGenerator.refs[offset]++
+	idGenerator.extraRefs++
+	return nil
+}
+
+// Release drops a reference to id and frees it like FreeID if it was the last one.
+// It returns an error wrapping ErrNotAllocated if id holds no reference, and fails without WithRefCounting.
+func (idGenerator *IDGenerator) Release(id int64) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if !idGenerator.inRange(id) {
+		return idGenerator.outOfRangeError(id)
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if idGenerator.refs == nil {
+		return idGenerator.refCountingDisabledError()
+	}
+	offset := idGenerator.toOffset(id)
+	if refs, ok := idGenerator.refs[offset]; ok {
+		if refs == 1 {
+			delete(idGenerator.refs, offset)
+		} else {
+			idGenerator.refs[offset] = refs - 1
+		}
+		idGenerator.extraRefs--
+		return nil
+	}
+	return idGenerator.freeLocked(id)
+}
+
+// RefCount returns the number of references to id, 0 if it is not allocated
+func (idGenerator *IDGenerator) RefCount(id int64) uint64 {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if !idGenerator.inRange(id) {
+		return 0
+	}
+	offset := idGenerator.toOffset(id)
+	if !idGenerator.store.Has(offset) || idGenerator.isHeld(offset) {
+		return 0
+	}
+	return idGenerator.refs[offset] + 1
+}
+
+// unrefLocked drops the references Acquire added to offset, the caller must hold lock
+func (idGenerator *IDGenerator) unrefLocked(offset uint64) {
+	if refs, ok := idGenerator.refs[offset]; ok {
+		delete(idGenerator.refs, offset)
+		idGenerator.extraRefs -= refs
+	}
+}
+
+// clearRefsLocked drops every reference Acquire added, the caller must hold lock
+func (idGenerator *IDGenerator) clearRefsLocked() {
+	if idGenerator.refs != nil {
+		idGenerator.refs = make(map[uint64]uint64)
+	}
+	idGenerator.extraRefs = 0
+}
@@ -0,0 +1,134 @@
+package idgenerator
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRefCounting(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			var freed []int64
+			opts := append([]Option{WithRefCounting(), WithOnFree(func(id int64) {
+				freed = append(freed, id)
+			})}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			id, err := idGenerator.Allocate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.Acquire(2); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("expected ErrNotAllocated, got %+v", err)
+			}
+			if err = idGenerator.Acquire(11); !errors.Is(err, ErrOutOfRange) {
+				t.Errorf("expected ErrOutOfRange, got %+v", err)
+			}
+			for i := 0; i < 2; i++ {
+				if err = idGenerator.Acquire(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if refs := idGenerator.RefCount(id); refs != 3 {
+				t.Errorf("expected 3 references, output %d", refs)
+			}
+			if stats := idGenerator.Stats(); stats.Used != 1 || stats.References != 3 {
+				t.Errorf("unexpected stats: %#v", stats)
+			}
+
+			// only the last Release frees the ID
+			for expected := uint64(2); expected > 0; expected-- {
+				if err = idGenerator.Release(id); err != nil {
+					t.Fatal(err)
+				}
+				if refs := idGenerator.RefCount(id); refs != expected || !idGenerator.IsAllocated(id) {
+					t.Fatalf("expected %d references to the allocated ID, output %d", expected, refs)
+				}
+			}
+			if err = idGenerator.Release(id); err != nil {
+				t.Fatal(err)
+			}
+			if idGenerator.IsAllocated(id) || idGenerator.RefCount(id) != 0 || len(freed) != 1 {
+				t.Errorf("ID not freed by its last reference, freed %v", freed)
+			}
+			if err = idGenerator.Release(id); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("expected ErrNotAllocated for a Release below zero, got %+v", err)
+			}
+
+			// FreeID drops every reference, a reallocated ID starts at one
+			if id, err = idGenerator.Allocate(); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.Acquire(id); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.FreeID(id); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(id); err != nil {
+				t.Fatal(err)
+			}
+			if refs := idGenerator.RefCount(id); refs != 1 {
+				t.Errorf("expected 1 reference to the reallocated ID, output %d", refs)
+			}
+			if stats := idGenerator.Stats(); stats.References != 1 {
+				t.Errorf("unexpected stats: %#v", stats)
+			}
+		})
+	}
+}
+
+func TestRefCountingDisabled(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := idGenerator.Allocate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Acquire(id); err == nil {
+		t.Error("Acquire succeeded without WithRefCounting")
+	}
+	if err = idGenerator.Release(id); err == nil {
+		t.Error("Release succeeded without WithRefCounting")
+	}
+	if refs := idGenerator.RefCount(id); refs != 1 {
+		t.Errorf("expected 1 reference, output %d", refs)
+	}
+	if stats := idGenerator.Stats(); stats.References != 1 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+}
+
+func TestRefCountingResizeAndReset(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(10, 20, WithRefCounting())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(15); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Acquire(15); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Resize(12, 16); err != nil {
+		t.Fatal(err)
+	}
+	if refs := idGenerator.RefCount(15); refs != 2 {
+		t.Errorf("expected 2 references after Resize, output %d", refs)
+	}
+	clone := idGenerator.Clone()
+	if err = clone.Release(15); err != nil {
+		t.Fatal(err)
+	}
+	if refs := idGenerator.RefCount(15); refs != 2 {
+		t.Errorf("Release of the clone changed the original: %d references", refs)
+	}
+	idGenerator.Reset()
+	if stats := idGenerator.Stats(); stats.References != 0 {
+		t.Errorf("unexpected stats after Reset: %#v", stats)
+	}
+}
@@ -0,0 +1,100 @@
+package idgenerator
+
+import (
+	"fmt"
+	"time"
+)
+
+// ReservationToken is a reservation of Reserve, to be committed with CommitReservation or cancelled with
+// CancelReservation. Serial tells the reservations of an ID apart, so that a token is not valid for
+// a later reservation of its ID once its own is over.
+type ReservationToken struct {
+	ID     int64
+	Serial uint64
+}
+
+// Reserve allocates an ID like AllocateLease for an offer which may not be taken, e.g. an ID proposed to a peer
+// which only keeps it if the peer accepts: CommitReservation turns it into a normal allocation,
+// CancelReservation frees it at once, and it is freed automatically once ttl has elapsed if neither is called.
+// A reserved ID is allocated, Allocate does not return it, and it counts in the Reserved of Stats as well as
+// in Used. Renew extends the reservation, Commit commits it and FreeID frees it like CancelReservation.
+// Resize and Clone keep the reservations, Merge, Split and restoring a snapshot keep them as plain leases
+// without their tokens.
+func (idGenerator *IDGenerator) Reserve(ttl time.Duration) (id int64, token ReservationToken, err error) {
+	if ttl <= 0 {
+		return 0, ReservationToken{}, fmt.Errorf("Reserve: invalid ttl %v", ttl)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	id, err = idGenerator.allocateLocked()
+	if err == nil {
+		offset := idGenerator.toOffset(id)
+		idGenerator.setLeaseLocked(offset, ttl)
+		if idGenerator.reservations == nil {
+			idGenerator.reservations = make(map[uint64]uint64)
+		}
+		idGenerator.reservationSerial++
+		idGenerator.reservations[offset] = idGenerator.reservationSerial
+		token = ReservationToken{ID: id, Serial: idGenerator.reservationSerial}
+	}
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.allocateFailed(err)
+	}
+	return id, token, err
+}
+
+// CommitReservation turns the reservation of token into a normal allocation which never expires.
+// It returns an error wrapping ErrLeaseExpired if the reservation has expired,
+// or ErrNotReserved if it was committed or cancelled.
+func (idGenerator *IDGenerator) CommitReservation(token ReservationToken) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset, err := idGenerator.reservationOffsetLocked(token)
+	if err != nil {
+		return err
+	}
+	delete(idGenerator.reservations, offset)
+	delete(idGenerator.leases, offset)
+	idGenerator.trackLocked(offset)
+	return nil
+}
+
+// CancelReservation frees the ID of the reservation of token like FreeID.
+// It fails like CommitReservation if the reservation is over.
+func (idGenerator *IDGenerator) CancelReservation(token ReservationToken) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	offset, err := idGenerator.reservationOffsetLocked(token)
+	if err != nil {
+		return err
+	}
+	idGenerator.markFree(offset)
+	idGenerator.serveWaitersLocked()
+	return nil
+}
+
+// reservationOffsetLocked returns the offset of the ID of token if token is its current reservation,
+// the caller must hold lock
+func (idGenerator *IDGenerator) reservationOffsetLocked(token ReservationToken) (uint64, error) {
+	if !idGenerator.inRange(token.ID) {
+		return 0, idGenerator.outOfRangeError(token.ID)
+	}
+	offset := idGenerator.toOffset(token.ID)
+	if serial, ok := idGenerator.reservations[offset]; ok && serial == token.Serial {
+		return offset, nil
+	}
+	// the ID is not reallocated since its lease expired, so the last reservation of it did
+	if _, ok := idGenerator.expired[offset]; ok {
+		return 0, fmt.Errorf("%w: reservation of ID[%d]", ErrLeaseExpired, token.ID)
+	}
+	return 0, fmt.Errorf("%w: ID[%d] of serial %d", ErrNotReserved, token.ID, token.Serial)
+}
@@ -0,0 +1,116 @@
+package idgenerator
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReservation(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 3, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	committed, commitToken, err := idGenerator.Reserve(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, cancelToken, err := idGenerator.Reserve(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expireToken, err := idGenerator.Reserve(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats := idGenerator.Stats(); stats.Used != 3 || stats.Reserved != 3 {
+		t.Errorf("expected 3 IDs used and reserved, got %d used and %d reserved", stats.Used, stats.Reserved)
+	}
+	// the reserved IDs are not allocated again
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %v", err)
+	}
+
+	if err = idGenerator.CommitReservation(commitToken); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.CancelReservation(cancelToken); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(cancelled) {
+		t.Errorf("expected the cancelled ID %d free", cancelled)
+	}
+	if stats := idGenerator.Stats(); stats.Used != 2 || stats.Reserved != 1 {
+		t.Errorf("expected 2 IDs used and 1 reserved, got %d used and %d reserved", stats.Used, stats.Reserved)
+	}
+	for _, token := range []ReservationToken{commitToken, cancelToken} {
+		if err = idGenerator.CommitReservation(token); !errors.Is(err, ErrNotReserved) {
+			t.Errorf("CommitReservation(%+v): expected ErrNotReserved, got %v", token, err)
+		}
+		if err = idGenerator.CancelReservation(token); !errors.Is(err, ErrNotReserved) {
+			t.Errorf("CancelReservation(%+v): expected ErrNotReserved, got %v", token, err)
+		}
+	}
+
+	clock.Advance(time.Minute)
+	if err = idGenerator.CommitReservation(expireToken); !errors.Is(err, ErrLeaseExpired) {
+		t.Errorf("expected ErrLeaseExpired after the ttl, got %v", err)
+	}
+	if !idGenerator.IsAllocated(committed) {
+		t.Errorf("expected the committed ID %d to outlive the ttl", committed)
+	}
+	if stats := idGenerator.Stats(); stats.Used != 1 || stats.Reserved != 0 {
+		t.Errorf("expected 1 ID used and none reserved, got %d used and %d reserved", stats.Used, stats.Reserved)
+	}
+
+	// a token is not valid for a later reservation of its ID, the two free IDs are reserved again
+	var id int64
+	var token ReservationToken
+	for i := 0; i < 2 && id != expireToken.ID; i++ {
+		if id, token, err = idGenerator.Reserve(time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if id != expireToken.ID {
+		t.Fatalf("expected ID %d reserved again, got %d", expireToken.ID, id)
+	}
+	if err = idGenerator.CancelReservation(expireToken); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("expected ErrNotReserved for the token of the expired reservation, got %v", err)
+	}
+	if err = idGenerator.CancelReservation(ReservationToken{ID: 4, Serial: token.Serial}); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %v", err)
+	}
+	if err = idGenerator.FreeID(id); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.CommitReservation(token); !errors.Is(err, ErrNotReserved) {
+		t.Errorf("expected ErrNotReserved after FreeID, got %v", err)
+	}
+	if _, _, err = idGenerator.Reserve(0); err == nil {
+		t.Error("expected an error for a ttl of 0")
+	}
+}
+
+func TestReservationResize(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(10, 20, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := idGenerator.Reserve(time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Resize(0, 30); err != nil {
+		t.Fatal(err)
+	}
+	clone := idGenerator.Clone()
+	if err = idGenerator.CommitReservation(token); err != nil {
+		t.Errorf("expected the reservation kept by Resize, got %v", err)
+	}
+	if err = clone.CancelReservation(token); err != nil {
+		t.Errorf("expected the reservation kept by Clone, got %v", err)
+	}
+}
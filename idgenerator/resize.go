@@ -0,0 +1,187 @@
+package idgenerator
+
+import (
+	"container/heap"
+	"fmt"
+	"time"
+)
+
+// maxResizeErrorIDs is how many of the blocking IDs ResizeError.Error lists
+const maxResizeErrorIDs = 10
+
+// ResizeError is returned by Resize when allocated IDs are outside the new range,
+// it wraps ErrRangeInUse.
+type ResizeError struct {
+	MinValue int64
+	MaxValue int64
+	// Blocking are the allocated IDs outside [MinValue, MaxValue] in ascending order
+	Blocking []int64
+}
+
+func (e *ResizeError) Error() string {
+	if len(e.Blocking) > maxResizeErrorIDs {
+		return fmt.Sprintf("%v: %d IDs allocated outside [%d, %d], such as %v", ErrRangeInUse,
+			len(e.Blocking), e.MinValue, e.MaxValue, e.Blocking[:maxResizeErrorIDs])
+	}
+	return fmt.Sprintf("%v: IDs %v allocated outside [%d, %d]", ErrRangeInUse, e.Blocking, e.MinValue, e.MaxValue)
+}
+
+func (e *ResizeError) Unwrap() error {
+	return ErrRangeInUse
+}
+
+// Resize changes the range of the generator to [newMin, newMax], keeping the allocated IDs, leases and exclusions.
+// Allocate carries on from the same ID if it is in the new range, or from newMin otherwise.
+// It returns an error wrapping ErrInvalidRange if newMin > newMax,
+// a *ResizeError listing the allocated IDs outside the new range,
+// or an error wrapping ErrReserved if an excluded ID is outside of it; the generator is left unchanged on error.
+func (idGenerator *IDGenerator) Resize(newMin, newMax int64) error {
+	if newMin > newMax {
+		return fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidRange, newMin, newMax)
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if err := idGenerator.resizeLocked(newMin, newMax); err != nil {
+		return err
+	}
+	idGenerator.serveWaitersLocked()
+	return nil
+}
+
+// resizeLocked is Resize for newMin <= newMax without serving the waiters, so that Merge may fill the new IDs first.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) resizeLocked(newMin, newMax int64) error {
+	resized := &IDGenerator{
+		minValue: newMin,
+		maxValue: newMax,
+		step:     idGenerator.step.rebase(idGenerator.minValue, newMin),
+	}
+	var ok bool
+	if resized.lastOffset, ok = resized.step.lastOffset(newMin, newMax); !ok {
+		return fmt.Errorf("%w: [%d, %d] has no ID of the step", ErrInvalidRange, newMin, newMax)
+	}
+	// moves an offset of idGenerator to the same ID in resized
+	move := func(offset uint64) uint64 {
+		return resized.toOffset(idGenerator.toID(offset))
+	}
+
+	offsets := idGenerator.allocatedOffsetsLocked()
+	var blocking []int64
+	for _, offset := range offsets {
+		if id := idGenerator.toID(offset); !resized.inRange(id) {
+			blocking = append(blocking, id)
+		}
+	}
+	if len(blocking) > 0 {
+		return &ResizeError{MinValue: newMin, MaxValue: newMax, Blocking: blocking}
+	}
+	for offset := range idGenerator.excluded {
+		if id := idGenerator.toID(offset); !resized.inRange(id) {
+			return fmt.Errorf("%w: excluded ID[%d] not in [%d, %d]", ErrReserved, id, newMin, newMax)
+		}
+	}
+	store, err := idGenerator.newStore(resized.lastOffset)
+	if err != nil {
+		return fmt.Errorf("%w: [%d, %d]: %v", ErrInvalidRange, newMin, newMax, err)
+	}
+
+	for _, offset := range offsets {
+		store.Set(move(offset))
+	}
+	if len(idGenerator.excluded) > 0 {
+		resized.excluded = make(map[uint64]struct{}, len(idGenerator.excluded))
+		for offset := range idGenerator.excluded {
+			resized.excluded[move(offset)] = struct{}{}
+		}
+		resized.setExcluded(store)
+	}
+	if idGenerator.leases != nil {
+		resized.leases = make(map[uint64]time.Time, len(idGenerator.leases))
+		for offset, expiry := range idGenerator.leases {
+			resized.leases[move(offset)] = expiry
+		}
+		// the outdated entries may be out of the new range, drop them
+		for _, entry := range idGenerator.leaseHeap {
+			if _, ok := idGenerator.leases[entry.offset]; ok {
+				resized.leaseHeap = append(resized.leaseHeap, leaseEntry{expiry: entry.expiry, offset: move(entry.offset)})
+			}
+		}
+		heap.Init(&resized.leaseHeap)
+		if idGenerator.reservations != nil {
+			resized.reservations = make(map[uint64]uint64, len(idGenerator.reservations))
+			for offset, serial := range idGenerator.reservations {
+				resized.reservations[move(offset)] = serial
+			}
+		}
+		resized.expired = make(map[uint64]struct{}, len(idGenerator.expired))
+		for offset := range idGenerator.expired {
+			if id := idGenerator.toID(offset); resized.inRange(id) {
+				resized.expired[resized.toOffset(id)] = struct{}{}
+			}
+		}
+	}
+	resized.generations = idGenerator.movedGenerationsLocked(resized)
+	resized.heldSince = idGenerator.movedHoldTimesLocked(resized)
+	// the owned and referenced IDs are allocated, so they are all in the new range
+	for offset, set := range idGenerator.ownerOf {
+		resized.ownLocked(move(offset), set.name)
+	}
+	if idGenerator.refs != nil {
+		resized.refs = make(map[uint64]uint64, len(idGenerator.refs))
+		for offset, refs := range idGenerator.refs {
+			resized.refs[move(offset)] = refs
+		}
+	}
+	// the quarantined IDs out of the new range are dropped, they are free already
+	for _, entry := range idGenerator.quarantine {
+		if id := idGenerator.toID(entry.offset); resized.inRange(id) {
+			if resized.quarantined == nil {
+				resized.quarantined = make(map[uint64]struct{})
+			}
+			offset := resized.toOffset(id)
+			store.Set(offset)
+			resized.quarantined[offset] = struct{}{}
+			resized.quarantine = append(resized.quarantine, leaseEntry{expiry: entry.expiry, offset: offset})
+		}
+	}
+	// the queue of WithFIFORecycling keeps the order of the freed IDs in the new range
+	var recycled []int64
+	if idGenerator.recycling != nil {
+		for e := idGenerator.recycling.queue.Front(); e != nil; e = e.Next() {
+			if id := idGenerator.toID(e.Value.(uint64)); resized.inRange(id) {
+				recycled = append(recycled, id)
+			}
+		}
+	}
+	if id := idGenerator.toID(idGenerator.offset); resized.inRange(id) {
+		resized.offset = resized.toOffset(id)
+	}
+
+	idGenerator.minValue = resized.minValue
+	idGenerator.maxValue = resized.maxValue
+	idGenerator.lastOffset = resized.lastOffset
+	idGenerator.step = resized.step
+	idGenerator.offset = resized.offset
+	idGenerator.store = store
+	idGenerator.excluded = resized.excluded
+	idGenerator.leases = resized.leases
+	idGenerator.reservations = resized.reservations
+	idGenerator.leaseHeap = resized.leaseHeap
+	idGenerator.expired = resized.expired
+	idGenerator.generations = resized.generations
+	idGenerator.heldSince = resized.heldSince
+	idGenerator.refs = resized.refs
+	idGenerator.owners = resized.owners
+	idGenerator.ownerOf = resized.ownerOf
+	idGenerator.quarantined = resized.quarantined
+	idGenerator.quarantine = resized.quarantine
+	idGenerator.resetRecyclingLocked()
+	for _, id := range recycled {
+		idGenerator.recycling.push(idGenerator.toOffset(id))
+	}
+	return nil
+}
@@ -0,0 +1,197 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestResize(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(10, 14, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, id := range []int64{10, 11, 13} {
+				if err = idGenerator.AllocateSpecific(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 12 {
+				t.Fatalf("expected id: 12, output: %d, %+v", id, err)
+			}
+
+			// growing on both ends keeps the allocations and carries on after 12
+			if err = idGenerator.Resize(5, 20); err != nil {
+				t.Fatal(err)
+			}
+			if used := idGenerator.Snapshot().Used; !reflect.DeepEqual(used, []int64{10, 11, 12, 13}) {
+				t.Errorf("unexpected used: %v", used)
+			}
+			for _, expected := range []int64{14, 15} {
+				if id, err := idGenerator.Allocate(); err != nil || id != expected {
+					t.Errorf("expected id: %d, output: %d, %+v", expected, id, err)
+				}
+			}
+			if available := idGenerator.Available(); available != 10 {
+				t.Errorf("expected available: 10, output available: %d", available)
+			}
+
+			// shrinking is refused while allocated IDs are outside the new range
+			err = idGenerator.Resize(11, 13)
+			var resizeErr *ResizeError
+			if !errors.As(err, &resizeErr) || !errors.Is(err, ErrRangeInUse) {
+				t.Fatalf("expected ResizeError, got %+v", err)
+			}
+			if !reflect.DeepEqual(resizeErr.Blocking, []int64{10, 14, 15}) {
+				t.Errorf("unexpected blocking IDs: %v", resizeErr.Blocking)
+			}
+			if err = idGenerator.FreeID(20); !errors.Is(err, ErrNotAllocated) {
+				t.Errorf("the failed resize changed the range: %+v", err)
+			}
+
+			for _, id := range []int64{10, 14, 15} {
+				if err = idGenerator.FreeID(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			// the scan was at 16, which is gone, so allocation starts over from the new minValue
+			if err = idGenerator.Resize(11, 13); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.FreeID(11); err != nil {
+				t.Fatal(err)
+			}
+			if id, err := idGenerator.Allocate(); err != nil || id != 11 {
+				t.Errorf("expected id: 11, output: %d, %+v", id, err)
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if err = idGenerator.Resize(13, 11); !errors.Is(err, ErrInvalidRange) {
+				t.Errorf("expected ErrInvalidRange, got %+v", err)
+			}
+		})
+	}
+}
+
+func TestResizeLeasesAndExclusions(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithExclusions(1, 4, []int64{2}, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	leased, err := idGenerator.AllocateLease(time.Minute)
+	if err != nil || leased != 1 {
+		t.Fatalf("expected id: 1, output: %d, %+v", leased, err)
+	}
+	if err = idGenerator.Resize(2, 4); !errors.Is(err, ErrRangeInUse) {
+		t.Errorf("expected ErrRangeInUse, got %+v", err)
+	}
+	if err = idGenerator.Resize(0, 1); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+
+	// the lease and the exclusion follow their IDs as minValue moves
+	if err = idGenerator.Resize(-10, 10); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(2); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	if err = idGenerator.Renew(leased, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(2 * time.Hour)
+	if idGenerator.IsAllocated(leased) {
+		t.Error("leased ID not freed after expiry")
+	}
+	if err = idGenerator.FreeID(leased); err != nil {
+		t.Errorf("freeing an expired lease: %+v", err)
+	}
+}
+
+func TestResizeConcurrency(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	var mtx sync.Mutex
+	allocated := make(map[int64]bool)
+	wg := sync.WaitGroup{}
+	for routineID := 0; routineID < 8; routineID++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				// waits until a Resize makes room
+				id, err := idGenerator.AllocateCtx(context.Background())
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mtx.Lock()
+				if allocated[id] {
+					t.Errorf("ID[%d] allocated twice", id)
+				}
+				allocated[id] = true
+				mtx.Unlock()
+			}
+		}()
+	}
+	// grow while the allocations, which need 800 IDs in total, run
+	for maxValue := int64(200); maxValue <= 800; maxValue += 100 {
+		if err := idGenerator.Resize(1, maxValue); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wg.Wait()
+
+	if used := idGenerator.Used(); used != 800 || len(allocated) != 800 {
+		t.Errorf("expected used: 800, output used: %d, %d distinct IDs", used, len(allocated))
+	}
+}
+
+func TestResizeConcurrentRange(t *testing.T) {
+	// the calls on an ID check it against the range under the lock Resize moves the range with
+	idGenerator := NewGenerator(1, 100)
+	done := make(chan struct{})
+	wg := sync.WaitGroup{}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; ; i++ {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if err := idGenerator.Resize(1, 100+int64(i%2)*100); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		if err := idGenerator.AllocateSpecific(50); err != nil {
+			t.Fatal(err)
+		}
+		if !idGenerator.IsAllocated(50) {
+			t.Fatal("expected ID 50 allocated")
+		}
+		if err := idGenerator.FreeID(50); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := idGenerator.FreeRange(1, 100); err != nil {
+			t.Fatal(err)
+		}
+		// out of range half of the time
+		if err := idGenerator.FreeID(150); err != nil && !errors.Is(err, ErrOutOfRange) &&
+			!errors.Is(err, ErrNotAllocated) {
+			t.Fatal(err)
+		}
+	}
+	close(done)
+	wg.Wait()
+}
@@ -0,0 +1,94 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/free5gc/util/idgenerator/internal/jitter"
+)
+
+// The defaults of the fields of Backoff left at 0
+const (
+	defaultBackoffInitial    = 10 * time.Millisecond
+	defaultBackoffMultiplier = 2
+	defaultBackoffMax        = time.Second
+)
+
+// Backoff is the retry policy of AllocateRetry. The delay before a retry starts at Initial, is multiplied
+// by Multiplier after each retry and capped at Max; each wait is drawn at random between half the delay
+// and the delay, so that the callers blocked on the same exhaustion do not retry in lockstep.
+type Backoff struct {
+	// Initial is the delay before the first retry, 10ms if 0 or below
+	Initial time.Duration
+	// Multiplier scales the delay after each retry, 2 if below 1
+	Multiplier float64
+	// Max caps the delay, 1s if 0 or below
+	Max time.Duration
+	// MaxAttempts bounds the allocations tried, the first one included, 0 or below retries until ctx is done
+	MaxAttempts int
+}
+
+// AllocateRetry allocates an ID like Allocate, retrying with the backoff of policy while the pool is exhausted,
+// e.g. when the IDs are freed a few milliseconds later, instead of queueing like AllocateCtx. It waits
+// on the clock of WithClock and gives up at once on an error other than ErrPoolExhausted, returning it,
+// or once ctx is done, returning ctx.Err(). It returns the last error wrapping ErrPoolExhausted once
+// MaxAttempts allocations failed. Every failed allocation counts in the AllocationFailures of Stats
+// and every retry in its AllocationRetries.
+func (idGenerator *IDGenerator) AllocateRetry(ctx context.Context, policy Backoff) (int64, error) {
+	delay := policy.initial()
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		id, err := idGenerator.Allocate()
+		if err == nil || !errors.Is(err, ErrPoolExhausted) {
+			return id, err
+		}
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return 0, fmt.Errorf("%w, gave up after %d attempts", err, attempt)
+		}
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-idGenerator.clock.After(jitter.Draw(delay)):
+		}
+		atomic.AddUint64(&idGenerator.allocateRetries, 1)
+		delay = policy.next(delay)
+	}
+}
+
+func (policy Backoff) initial() time.Duration {
+	if policy.Initial <= 0 {
+		return policy.max(defaultBackoffInitial)
+	}
+	return policy.max(policy.Initial)
+}
+
+// next returns the delay after delay, multiplied and capped
+func (policy Backoff) next(delay time.Duration) time.Duration {
+	multiplier := policy.Multiplier
+	if multiplier < 1 {
+		multiplier = defaultBackoffMultiplier
+	}
+	next := float64(delay) * multiplier
+	if next >= float64(math.MaxInt64) {
+		return policy.max(math.MaxInt64)
+	}
+	return policy.max(time.Duration(next))
+}
+
+// max caps delay at Max
+func (policy Backoff) max(delay time.Duration) time.Duration {
+	limit := policy.Max
+	if limit <= 0 {
+		limit = defaultBackoffMax
+	}
+	if delay > limit {
+		return limit
+	}
+	return delay
+}
@@ -0,0 +1,129 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+// pendingWait waits for the AllocateRetry call to wait on clock and returns how long it waits for
+func pendingWait(clock *fakeClock) time.Duration {
+	clock.waitForTimers(1)
+	clock.mtx.Lock()
+	defer clock.mtx.Unlock()
+	return clock.timers[0].at.Sub(clock.now)
+}
+
+func TestAllocateRetry(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 1, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := allocateN(t, idGenerator, 1)[0]
+
+	type result struct {
+		id  int64
+		err error
+	}
+	results := make(chan result, 1)
+	policy := Backoff{Initial: 10 * time.Millisecond, Multiplier: 2, Max: 40 * time.Millisecond}
+	go func() {
+		id, err := idGenerator.AllocateRetry(context.Background(), policy)
+		results <- result{id, err}
+	}()
+	for i, delay := range []time.Duration{10, 20, 40, 40} {
+		delay *= time.Millisecond
+		if wait := pendingWait(clock); wait < delay/2 || wait > delay {
+			t.Fatalf("retry %d: expected a wait in [%v, %v], output %v", i, delay/2, delay, wait)
+		}
+		if i == 3 {
+			freeAll(t, idGenerator, id)
+		}
+		clock.Advance(delay)
+	}
+	if r := <-results; r.err != nil || r.id != id {
+		t.Fatalf("expected ID %d, output %d, %+v", id, r.id, r.err)
+	}
+	if stats := idGenerator.Stats(); stats.AllocationRetries != 4 || stats.AllocationFailures != 4 {
+		t.Errorf("expected 4 retries and 4 failures, output %d and %d", stats.AllocationRetries, stats.AllocationFailures)
+	}
+
+	// the attempts are bounded by MaxAttempts
+	go func() {
+		id, err := idGenerator.AllocateRetry(context.Background(), Backoff{MaxAttempts: 2})
+		results <- result{id, err}
+	}()
+	clock.Advance(pendingWait(clock))
+	r := <-results
+	if !errors.Is(r.err, ErrPoolExhausted) || !strings.Contains(r.err.Error(), "after 2 attempts") {
+		t.Errorf("expected ErrPoolExhausted after 2 attempts, got %+v", r.err)
+	}
+}
+
+func TestAllocateRetryGivesUp(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 1, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = idGenerator.AllocateRetry(ctx, Backoff{}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %+v", err)
+	}
+	if allocations := idGenerator.Stats().Allocations; allocations != 0 {
+		t.Errorf("expected no allocation with ctx done, output %d", allocations)
+	}
+
+	allocateN(t, idGenerator, 1)
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := idGenerator.AllocateRetry(ctx, Backoff{})
+		errs <- err
+	}()
+	pendingWait(clock)
+	cancel()
+	if err = <-errs; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", err)
+	}
+
+	// the errors but exhaustion are not retried
+	if _, err = idGenerator.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateRetry(context.Background(), Backoff{}); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %+v", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	testCases := []struct {
+		name   string
+		policy Backoff
+		delays []time.Duration
+	}{
+		{"defaults", Backoff{}, []time.Duration{
+			10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 80 * time.Millisecond,
+			160 * time.Millisecond, 320 * time.Millisecond, 640 * time.Millisecond, time.Second, time.Second,
+		}},
+		{"capped initial", Backoff{Initial: time.Minute, Max: time.Second}, []time.Duration{time.Second, time.Second}},
+		{"overflow", Backoff{Initial: time.Hour, Multiplier: math.MaxFloat64, Max: math.MaxInt64}, []time.Duration{
+			time.Hour, math.MaxInt64,
+		}},
+	}
+	for _, testCase := range testCases {
+		delay := testCase.policy.initial()
+		for i, expected := range testCase.delays {
+			if delay != expected {
+				t.Errorf("%s: delay %d: expected %v, output %v", testCase.name, i, expected, delay)
+			}
+			delay = testCase.policy.next(delay)
+		}
+	}
+}
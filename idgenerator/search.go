@@ -0,0 +1,196 @@
+package idgenerator
+
+import (
+	"context"
+	"fmt"
+)
+
+// searchChunk is the number of free runs AllocateContiguousCtx and AllocateAlignedCtx look at,
+// and of IDs AllocateManyCtx allocates, between two checks of their context
+const searchChunk = 1024
+
+// AllocateContiguousCtx allocates a block of n consecutive free IDs like AllocateContiguous, checking ctx
+// as it searches a fragmented range: it releases the lock every searchChunk free runs, so that the other calls
+// are not held up by a long search, and resumes the search where it stopped. Since the IDs below it may be freed
+// meanwhile, the block is the lowest one from where the search was when it was found.
+// Once ctx is done it returns the error of ctx wrapped with the IDs scanned so far, nothing is allocated then.
+func (idGenerator *IDGenerator) AllocateContiguousCtx(ctx context.Context, n int64) (int64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("AllocateContiguousCtx: invalid count %d", n)
+	}
+	scan := func(from uint64) (uint64, bool, uint64, bool) {
+		return idGenerator.scanFreeRunsLocked(uint64(n), from, searchChunk)
+	}
+	return idGenerator.allocateRunCtx(ctx, "AllocateContiguousCtx", uint64(n), scan, func() error {
+		return fmt.Errorf("%w: requested %d consecutive IDs", ErrNoContiguousBlock, n)
+	})
+}
+
+// AllocateAlignedCtx allocates an aligned block of n consecutive free IDs like AllocateAligned,
+// checking ctx and releasing the lock as it searches like AllocateContiguousCtx.
+func (idGenerator *IDGenerator) AllocateAlignedCtx(ctx context.Context, n, align int64) (int64, error) {
+	if n <= 0 {
+		return 0, fmt.Errorf("AllocateAlignedCtx: invalid count %d", n)
+	}
+	if align <= 0 {
+		return 0, fmt.Errorf("AllocateAlignedCtx: invalid alignment %d", align)
+	}
+	contiguous := false
+	scan := func(from uint64) (uint64, bool, uint64, bool) {
+		first, ok, found, next, more := idGenerator.scanAlignedRunsLocked(uint64(n), uint64(align), from, searchChunk)
+		contiguous = contiguous || found
+		return first, ok, next, more
+	}
+	return idGenerator.allocateRunCtx(ctx, "AllocateAlignedCtx", uint64(n), scan, func() error {
+		return noAlignedRunError(uint64(n), uint64(align), contiguous)
+	})
+}
+
+// allocateRunCtx allocates the n offsets from the one scan finds, calling scan from offset 0 and then
+// from the offset it returned, with the lock held, until it finds one or there is none,
+// when it returns the error of notFound. The lock is released and ctx checked between two calls.
+func (idGenerator *IDGenerator) allocateRunCtx(ctx context.Context, name string, n uint64,
+	scan func(from uint64) (first uint64, ok bool, next uint64, more bool), notFound func() error,
+) (int64, error) {
+	// the search resumes from an ID, which stays put if the range is resized meanwhile
+	resumed, resume := false, int64(0)
+	for {
+		if err := ctx.Err(); err != nil {
+			if !resumed {
+				return 0, err
+			}
+			return 0, fmt.Errorf("%s: %w with the IDs below %d scanned", name, err, resume)
+		}
+		idGenerator.lock.Lock()
+		idGenerator.expireLeasesLocked()
+		first, done, err := idGenerator.allocateRunChunkLocked(n, resumed, resume, scan, notFound)
+		if done {
+			idGenerator.unlock()
+			if err != nil {
+				idGenerator.allocateFailed(err)
+			}
+			return first, err
+		}
+		resumed, resume = true, first
+		idGenerator.unlock()
+	}
+}
+
+// allocateRunChunkLocked is a call of scan of allocateRunCtx from resume, or from offset 0 unless resumed.
+// It returns the ID to resume from if the search is not done. The caller must hold lock.
+func (idGenerator *IDGenerator) allocateRunChunkLocked(n uint64, resumed bool, resume int64,
+	scan func(from uint64) (first uint64, ok bool, next uint64, more bool), notFound func() error,
+) (id int64, done bool, err error) {
+	if err = idGenerator.checkOpenLocked(); err != nil {
+		return 0, true, err
+	}
+	if n > idGenerator.availableLocked() {
+		return 0, true, idGenerator.exhaustedError(n, true)
+	}
+	from := uint64(0)
+	if resumed && resume > idGenerator.minValue {
+		if resume > idGenerator.maxValue {
+			return 0, true, notFound()
+		}
+		if from = idGenerator.step.ceilOffset(idGenerator.minValue, resume); from > idGenerator.lastOffset {
+			return 0, true, notFound()
+		}
+	}
+	first, ok, next, more := scan(from)
+	switch {
+	case ok:
+		idGenerator.markRunUsedLocked(first, n)
+		return idGenerator.toID(first), true, nil
+	case more:
+		return idGenerator.toID(next), false, nil
+	default:
+		return 0, true, notFound()
+	}
+}
+
+// AllocateManyCtx allocates n IDs like AllocateMany, checking ctx every searchChunk IDs.
+// It keeps the lock throughout, so that no other call sees part of the IDs allocated,
+// and returns the error of ctx wrapped with how many IDs it had allocated once ctx is done,
+// giving them back so that nothing is allocated then.
+func (idGenerator *IDGenerator) AllocateManyCtx(ctx context.Context, n int) ([]int64, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("AllocateManyCtx: invalid count %d", n)
+	}
+	return idGenerator.allocateManyCtx(ctx, "AllocateManyCtx", n, nil)
+}
+
+// allocateManyCtx is AllocateManyCtx for n >= 0, name being the call reported in the errors.
+// allocated is called with the IDs before lock is released if it is not nil and the allocation succeeds.
+func (idGenerator *IDGenerator) allocateManyCtx(ctx context.Context, name string, n int,
+	allocated func(ids []int64),
+) ([]int64, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if err := idGenerator.limitRate(n); err != nil {
+		return nil, err
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		idGenerator.unlock()
+		idGenerator.refundRate(n)
+		return nil, err
+	}
+	if available := idGenerator.availableLocked(); uint64(n) > available {
+		idGenerator.unlock()
+		idGenerator.refundRate(n)
+		err := idGenerator.exhaustedError(uint64(n), false)
+		idGenerator.allocateFailed(err)
+		return nil, err
+	}
+	ids := make([]int64, 0, n)
+	queued, peakUsed, peakAt := len(idGenerator.events), idGenerator.peakUsed, idGenerator.peakAt
+	rollback := func() {
+		// in reverse order, so that the audit drops the allocations one by one
+		for i := len(ids) - 1; i >= 0; i-- {
+			idGenerator.unmarkUsed(idGenerator.toOffset(ids[i]))
+		}
+		idGenerator.events = idGenerator.events[:queued]
+		idGenerator.peakUsed, idGenerator.peakAt = peakUsed, peakAt
+		idGenerator.unlock()
+		idGenerator.refundRate(n)
+	}
+	for i := 0; i < n; i++ {
+		if i > 0 && i%searchChunk == 0 {
+			if err := ctx.Err(); err != nil {
+				rollback()
+				return nil, fmt.Errorf("%s: %w with %d of %d IDs allocated", name, err, i, n)
+			}
+		}
+		id, err := idGenerator.allocateLocked()
+		if err != nil {
+			// the capacity has been checked, but the random source can fail halfway
+			rollback()
+			idGenerator.allocateFailed(err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if allocated != nil {
+		allocated(ids)
+	}
+	idGenerator.unlock()
+	return ids, nil
+}
+
+// markRunUsedLocked marks the n offsets from first used, the caller must hold lock
+func (idGenerator *IDGenerator) markRunUsedLocked(first, n uint64) {
+	for i := uint64(0); i < n; i++ {
+		idGenerator.markUsed(first + i)
+	}
+}
+
+// noAlignedRunError is the error of AllocateAligned finding no block,
+// contiguous reporting whether it found unaligned ones
+func noAlignedRunError(n, align uint64, contiguous bool) error {
+	if contiguous {
+		return fmt.Errorf("%w: requested %d consecutive IDs aligned to %d", ErrNoAlignedBlock, n, align)
+	}
+	return fmt.Errorf("%w: requested %d consecutive IDs", ErrNoContiguousBlock, n)
+}
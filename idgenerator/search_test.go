@@ -0,0 +1,130 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// checkedContext is done once Err has been called checks times, never if checks is negative,
+// and calls onCheck on each call of Err
+type checkedContext struct {
+	context.Context
+	checks  int
+	onCheck func()
+}
+
+func (ctx *checkedContext) Err() error {
+	if ctx.onCheck != nil {
+		ctx.onCheck()
+	}
+	if ctx.checks == 0 {
+		return context.Canceled
+	}
+	ctx.checks--
+	return nil
+}
+
+// newCheckerboard returns a generator of [1, size] with every other ID allocated,
+// so that the free runs are a single ID long
+func newCheckerboard(t *testing.T, size int64) *IDGenerator {
+	t.Helper()
+	idGenerator := NewGenerator(1, size)
+	for id := int64(1); id <= size; id += 2 {
+		if err := idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return idGenerator
+}
+
+func TestAllocateContiguousCtx(t *testing.T) {
+	const size = 64 * searchChunk
+	idGenerator := newCheckerboard(t, size)
+	used := idGenerator.Used()
+
+	// the lock is released between the chunks of the search, a read would deadlock otherwise
+	checks := 0
+	ctx := &checkedContext{Context: context.Background(), checks: 5, onCheck: func() {
+		checks++
+		idGenerator.Used()
+	}}
+	_, err := idGenerator.AllocateContiguousCtx(ctx, 2)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "scanned") {
+		t.Fatalf("expected context.Canceled with the IDs scanned, got %+v", err)
+	}
+	if checks != 6 {
+		t.Errorf("expected 6 checks of the context, output %d", checks)
+	}
+	if idGenerator.Used() != used {
+		t.Errorf("expected %d IDs used, output %d", used, idGenerator.Used())
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	if _, err = idGenerator.AllocateContiguousCtx(context.Background(), 2); !errors.Is(err, ErrNoContiguousBlock) {
+		t.Fatalf("expected ErrNoContiguousBlock, got %+v", err)
+	}
+	// an ID freed while the search has released the lock is seen once the search gets to it
+	checks = 0
+	ctx = &checkedContext{Context: context.Background(), checks: -1, onCheck: func() {
+		if checks++; checks == 3 {
+			freeAll(t, idGenerator, size-3)
+		}
+	}}
+	if first, err := idGenerator.AllocateContiguousCtx(ctx, 3); err != nil || first != size-4 {
+		t.Fatalf("expected ID %d, output %d, %+v", size-4, first, err)
+	}
+	if idGenerator.Used() != used+2 {
+		t.Errorf("expected %d IDs used, output %d", used+2, idGenerator.Used())
+	}
+}
+
+func TestAllocateAlignedCtx(t *testing.T) {
+	idGenerator := newCheckerboard(t, 8*searchChunk)
+	freeAll(t, idGenerator, 3, 5*searchChunk+1, 5*searchChunk+3)
+	// [2, 4] is free but not aligned to 4 from 1
+	if first, err := idGenerator.AllocateAlignedCtx(context.Background(), 3, 4); err != nil || first != 5*searchChunk+1 {
+		t.Fatalf("expected ID %d, output %d, %+v", 5*searchChunk+1, first, err)
+	}
+	if _, err := idGenerator.AllocateAlignedCtx(context.Background(), 3, 1024); !errors.Is(err, ErrNoAlignedBlock) {
+		t.Errorf("expected ErrNoAlignedBlock, got %+v", err)
+	}
+	ctx := &checkedContext{Context: context.Background(), checks: 1}
+	if _, err := idGenerator.AllocateAlignedCtx(ctx, 3, 1024); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", err)
+	}
+	if _, err := idGenerator.AllocateAlignedCtx(context.Background(), 3, 0); err == nil {
+		t.Error("AllocateAlignedCtx with alignment 0 should fail")
+	}
+}
+
+func TestAllocateManyCtx(t *testing.T) {
+	freed := 0
+	idGenerator, err := NewGeneratorWithOptions(1, 16*searchChunk, WithOnFree(func(id int64) { freed++ }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := &checkedContext{Context: context.Background(), checks: 2}
+	_, err = idGenerator.AllocateManyCtx(ctx, 8*searchChunk)
+	if !errors.Is(err, context.Canceled) || !strings.Contains(err.Error(), "2048 of 8192") {
+		t.Fatalf("expected context.Canceled after 2048 IDs, got %+v", err)
+	}
+	// the IDs allocated before the cancellation are given back unseen
+	if idGenerator.Used() != 0 || freed != 0 {
+		t.Errorf("expected no ID used nor freed, output %d used and %d freed", idGenerator.Used(), freed)
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+	if ids, err := idGenerator.AllocateManyCtx(context.Background(), 3); err != nil || len(ids) != 3 {
+		t.Errorf("expected 3 IDs, output %v, %+v", ids, err)
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err = idGenerator.AllocateManyCtx(canceled, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", err)
+	}
+}
@@ -0,0 +1,62 @@
+package idgenerator
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// SequentialGenerator hands out the IDs in range [minValue, maxValue] in order, each one once,
+// for callers which never free their IDs. It keeps a single atomic counter, so NextID takes no lock
+// and the generator has no per ID state.
+type SequentialGenerator struct {
+	// next counts the calls to NextID, as an offset from minValue
+	next atomic.Uint64
+
+	minValue   int64
+	maxValue   int64
+	lastOffset uint64
+	wrapAround bool
+}
+
+// SequentialOption configures a SequentialGenerator
+type SequentialOption func(*SequentialGenerator)
+
+// WithWrapAround makes NextID start over from minValue once maxValue has been handed out,
+// instead of failing: an ID is then handed out again every maxValue-minValue+1 calls.
+func WithWrapAround() SequentialOption {
+	return func(generator *SequentialGenerator) {
+		generator.wrapAround = true
+	}
+}
+
+// NewSequentialGenerator initializes a SequentialGenerator with minValue and maxValue and applies opts to it.
+// It returns an error wrapping ErrInvalidRange if minValue > maxValue.
+func NewSequentialGenerator(minValue, maxValue int64, opts ...SequentialOption) (*SequentialGenerator, error) {
+	if minValue > maxValue {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidRange, minValue, maxValue)
+	}
+	generator := &SequentialGenerator{
+		minValue:   minValue,
+		maxValue:   maxValue,
+		lastOffset: uint64(maxValue) - uint64(minValue),
+	}
+	for _, opt := range opts {
+		opt(generator)
+	}
+	return generator, nil
+}
+
+// NextID returns the ID following the one returned by the previous call, starting at minValue.
+// Past maxValue, it returns an error wrapping ErrPoolExhausted, or minValue again with WithWrapAround.
+func (generator *SequentialGenerator) NextID() (int64, error) {
+	offset := generator.next.Add(1) - 1
+	if offset > generator.lastOffset {
+		if !generator.wrapAround {
+			return 0, fmt.Errorf("%w: all of [%d, %d] handed out",
+				ErrPoolExhausted, generator.minValue, generator.maxValue)
+		}
+		// lastOffset+1 is 0 for the full int64 range, where the counter wraps around by itself
+		offset %= generator.lastOffset + 1
+	}
+	return int64(uint64(generator.minValue) + offset), nil
+}
@@ -0,0 +1,146 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestSequentialGenerator(t *testing.T) {
+	testCases := []struct {
+		minValue   int64
+		maxValue   int64
+		wrapAround bool
+		expected   []int64
+	}{
+		{1, 3, false, []int64{1, 2, 3}},
+		{1, 3, true, []int64{1, 2, 3, 1, 2, 3, 1}},
+		{-1, 1, false, []int64{-1, 0, 1}},
+		{5, 5, true, []int64{5, 5, 5}},
+		{math.MaxInt64 - 1, math.MaxInt64, false, []int64{math.MaxInt64 - 1, math.MaxInt64}},
+		{math.MaxInt64 - 1, math.MaxInt64, true, []int64{math.MaxInt64 - 1, math.MaxInt64, math.MaxInt64 - 1}},
+		{math.MinInt64, math.MaxInt64, false, []int64{math.MinInt64, math.MinInt64 + 1}},
+	}
+
+	for _, testCase := range testCases {
+		name := fmt.Sprintf("[%d, %d] wrap-around %v", testCase.minValue, testCase.maxValue, testCase.wrapAround)
+		t.Run(name, func(t *testing.T) {
+			var opts []SequentialOption
+			if testCase.wrapAround {
+				opts = append(opts, WithWrapAround())
+			}
+			idGenerator, err := NewSequentialGenerator(testCase.minValue, testCase.maxValue, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, expected := range testCase.expected {
+				if id, err := idGenerator.NextID(); err != nil || id != expected {
+					t.Fatalf("expected id: %d, output: %d, %+v", expected, id, err)
+				}
+			}
+			if testCase.wrapAround || testCase.maxValue-testCase.minValue+1 != int64(len(testCase.expected)) {
+				return
+			}
+			for i := 0; i < 2; i++ {
+				if _, err = idGenerator.NextID(); !errors.Is(err, ErrPoolExhausted) {
+					t.Errorf("expected ErrPoolExhausted, got %+v", err)
+				}
+			}
+		})
+	}
+
+	if _, err := NewSequentialGenerator(10, 1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+}
+
+func TestSequentialGeneratorFullRangeWrapAround(t *testing.T) {
+	idGenerator, err := NewSequentialGenerator(math.MinInt64, math.MaxInt64, WithWrapAround())
+	if err != nil {
+		t.Fatal(err)
+	}
+	// move the counter to the last offset
+	idGenerator.next.Store(math.MaxUint64)
+	for _, expected := range []int64{math.MaxInt64, math.MinInt64, math.MinInt64 + 1} {
+		if id, err := idGenerator.NextID(); err != nil || id != expected {
+			t.Errorf("expected id: %d, output: %d, %+v", expected, id, err)
+		}
+	}
+}
+
+func TestSequentialGeneratorConcurrency(t *testing.T) {
+	idGenerator, err := NewSequentialGenerator(1, 10000)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mtx sync.Mutex
+	allocated := make(map[int64]bool)
+	wg := sync.WaitGroup{}
+	for routineID := 0; routineID < 16; routineID++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, err := idGenerator.NextID()
+				if errors.Is(err, ErrPoolExhausted) {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mtx.Lock()
+				if allocated[id] {
+					t.Errorf("ID[%d] allocated twice", id)
+				}
+				allocated[id] = true
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(allocated) != 10000 {
+		t.Errorf("allocated %d IDs, expected 10000", len(allocated))
+	}
+}
+
+// BenchmarkSequential compares SequentialGenerator with the locked IDGenerator for 32 goroutines
+// allocating IDs without freeing them
+func BenchmarkSequential(b *testing.B) {
+	locked, err := NewGeneratorWithOptions(1, math.MaxInt64, WithIntervalStore())
+	if err != nil {
+		b.Fatal(err)
+	}
+	sequential, err := NewSequentialGenerator(1, math.MaxInt64)
+	if err != nil {
+		b.Fatal(err)
+	}
+	generators := []struct {
+		name     string
+		allocate func() (int64, error)
+	}{
+		{"locked", locked.Allocate},
+		{"sequential", sequential.NextID},
+	}
+
+	// RunParallel starts parallelism*GOMAXPROCS goroutines
+	parallelism := (32 + runtime.GOMAXPROCS(0) - 1) / runtime.GOMAXPROCS(0)
+	for _, generator := range generators {
+		b.Run(generator.name, func(b *testing.B) {
+			b.SetParallelism(parallelism)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := generator.allocate(); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
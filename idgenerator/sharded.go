@@ -0,0 +1,185 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync/atomic"
+)
+
+// ShardedIDGenerator allocates IDs in range [minValue, maxValue] like IDGenerator,
+// but splits the range into shards of about the same size with a lock each,
+// so that concurrent calls rarely wait for each other.
+// Allocate takes the shards in turns and moves on to the next one when a shard is exhausted,
+// so it hands out any free ID in range: the IDs are neither sequential nor in order across shards.
+type ShardedIDGenerator struct {
+	// next and allocateFailures are updated atomically and must stay first to be 64-bit aligned
+	next             uint64
+	allocateFailures uint64
+
+	minValue int64
+	maxValue int64
+	shards   []*IDGenerator
+	// the shards have q offsets, q being shortLast+1, except the first longShards which have q+1
+	shortLast  uint64
+	longShards uint64
+}
+
+// NewShardedGenerator initializes a ShardedIDGenerator with minValue and maxValue split into n shards,
+// then applies opts to every shard. It fails like NewGeneratorWithOptions,
+// or with an error wrapping ErrInvalidRange if n < 1 or the range has fewer than n IDs.
+// A random source given to WithRandomAllocationFrom must be safe for concurrent use, as the shards share it,
+// and hooks are called in order for the IDs of each shard, not across shards.
+func NewShardedGenerator(minValue, maxValue int64, n int, opts ...Option) (*ShardedIDGenerator, error) {
+	if minValue > maxValue {
+		return nil, fmt.Errorf("%w: minValue %d > maxValue %d", ErrInvalidRange, minValue, maxValue)
+	}
+	lastOffset := uint64(maxValue) - uint64(minValue)
+	if n < 1 || uint64(n)-1 > lastOffset {
+		return nil, fmt.Errorf("%w: cannot split [%d, %d] into %d shards", ErrInvalidRange, minValue, maxValue, n)
+	}
+	sharded := &ShardedIDGenerator{
+		minValue: minValue,
+		maxValue: maxValue,
+		shards:   make([]*IDGenerator, n),
+	}
+	// split the lastOffset+1 offsets, which may not fit in an uint64, as n*q + longShards
+	if q, rem := lastOffset/uint64(n), lastOffset%uint64(n); rem+1 == uint64(n) {
+		sharded.shortLast = q
+	} else {
+		sharded.shortLast = q - 1
+		sharded.longShards = rem + 1
+	}
+	first := uint64(0)
+	for i := range sharded.shards {
+		last := first + sharded.shortLast
+		if uint64(i) < sharded.longShards {
+			last++
+		}
+		shard, err := NewGeneratorWithOptions(sharded.toID(first), sharded.toID(last), opts...)
+		if err != nil {
+			return nil, err
+		}
+		sharded.shards[i] = shard
+		first = last + 1
+	}
+	return sharded, nil
+}
+
+func (sharded *ShardedIDGenerator) toID(offset uint64) int64 {
+	return int64(uint64(sharded.minValue) + offset)
+}
+
+// shard returns the shard owning id, which must be in range [minValue, maxValue]
+func (sharded *ShardedIDGenerator) shard(id int64) *IDGenerator {
+	if len(sharded.shards) == 1 {
+		// q may be 2^64
+		return sharded.shards[0]
+	}
+	offset := uint64(id) - uint64(sharded.minValue)
+	q := sharded.shortLast + 1
+	long := sharded.longShards * (q + 1)
+	if offset < long {
+		return sharded.shards[offset/(q+1)]
+	}
+	return sharded.shards[sharded.longShards+(offset-long)/q]
+}
+
+func (sharded *ShardedIDGenerator) inRange(id int64) bool {
+	return id >= sharded.minValue && id <= sharded.maxValue
+}
+
+func (sharded *ShardedIDGenerator) outOfRangeError(id int64) error {
+	return fmt.Errorf("%w: ID[%d] not in [%d, %d]", ErrOutOfRange, id, sharded.minValue, sharded.maxValue)
+}
+
+// Allocate allocates a free ID from the next shard, or from the following ones if it is exhausted.
+// It returns an error wrapping ErrPoolExhausted only if every shard is.
+func (sharded *ShardedIDGenerator) Allocate() (int64, error) {
+	n := uint64(len(sharded.shards))
+	start := atomic.AddUint64(&sharded.next, 1)
+	var err error
+	for i := uint64(0); i < n; i++ {
+		var id int64
+		if id, err = sharded.shards[(start+i)%n].tryAllocate(); err == nil {
+			return id, nil
+		}
+		if !errors.Is(err, ErrPoolExhausted) {
+			break
+		}
+	}
+	atomic.AddUint64(&sharded.allocateFailures, 1)
+	sharded.shards[0].logger.Printf("idgenerator[%d-%d]: allocate failed: %v",
+		sharded.minValue, sharded.maxValue, err)
+	return 0, err
+}
+
+// AllocateSpecific allocates exactly id like IDGenerator.AllocateSpecific
+func (sharded *ShardedIDGenerator) AllocateSpecific(id int64) error {
+	if !sharded.inRange(id) {
+		return sharded.outOfRangeError(id)
+	}
+	return sharded.shard(id).AllocateSpecific(id)
+}
+
+// FreeID releases id like IDGenerator.FreeID
+func (sharded *ShardedIDGenerator) FreeID(id int64) error {
+	if !sharded.inRange(id) {
+		return sharded.outOfRangeError(id)
+	}
+	return sharded.shard(id).FreeID(id)
+}
+
+// IsAllocated reports whether id is allocated, it is false for any id outside [minValue, maxValue]
+func (sharded *ShardedIDGenerator) IsAllocated(id int64) bool {
+	return sharded.inRange(id) && sharded.shard(id).IsAllocated(id)
+}
+
+// Used returns the number of allocated IDs.
+// The shards are read one after the other, so concurrent calls may be counted in part.
+func (sharded *ShardedIDGenerator) Used() int64 {
+	return sharded.Stats().Used
+}
+
+// Available returns the number of IDs that can still be allocated, capped at math.MaxInt64.
+// It is read like Used.
+func (sharded *ShardedIDGenerator) Available() int64 {
+	available := uint64(0)
+	for _, shard := range sharded.shards {
+		shardAvailable := uint64(shard.Available())
+		if available += shardAvailable; available < shardAvailable {
+			return math.MaxInt64
+		}
+	}
+	return clampInt64(available)
+}
+
+// Reset frees all allocated IDs of every shard
+func (sharded *ShardedIDGenerator) Reset() {
+	for _, shard := range sharded.shards {
+		shard.Reset()
+	}
+}
+
+// Stats returns the sum of the counters of the shards, AllocationFailures only counts the calls
+// for which every shard was exhausted. MinValue and MaxValue span all shards, Offset is 0. It is read like Used.
+func (sharded *ShardedIDGenerator) Stats() Stats {
+	return sharded.sumStats((*IDGenerator).Stats)
+}
+
+// DetailedStats returns the sum of the DetailedStats of the shards, like Stats
+func (sharded *ShardedIDGenerator) DetailedStats() Stats {
+	return sharded.sumStats((*IDGenerator).DetailedStats)
+}
+
+// sumStats returns the sum of the counters read by read of the shards
+func (sharded *ShardedIDGenerator) sumStats(read func(*IDGenerator) Stats) Stats {
+	var stats Stats
+	for _, shard := range sharded.shards {
+		stats.add(read(shard))
+	}
+	stats.MinValue, _ = sharded.shards[0].bounds()
+	_, stats.MaxValue = sharded.shards[len(sharded.shards)-1].bounds()
+	stats.AllocationFailures = atomic.LoadUint64(&sharded.allocateFailures)
+	return stats
+}
@@ -0,0 +1,223 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func TestShardedGenerator(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewShardedGenerator(1, 10, 3, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	allocated := make(map[int64]bool)
+	for i := 0; i < 10; i++ {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id < 1 || id > 10 || allocated[id] {
+			t.Fatalf("unexpected id: %d", id)
+		}
+		allocated[id] = true
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+
+	// FreeID goes to the shard owning the ID, which then allocates it again
+	if err = idGenerator.FreeID(6); err != nil {
+		t.Fatal(err)
+	}
+	if idGenerator.IsAllocated(6) || idGenerator.Available() != 1 {
+		t.Errorf("unexpected state: IsAllocated(6) %v, Available %d", idGenerator.IsAllocated(6), idGenerator.Available())
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != 6 {
+		t.Errorf("expected id: 6, output: %d, %+v", id, err)
+	}
+	if err = idGenerator.FreeID(6); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeID(6); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err = idGenerator.FreeID(11); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.AllocateSpecific(7); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+	if err = idGenerator.AllocateSpecific(6); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := Stats{
+		MinValue:           1,
+		MaxValue:           10,
+		Strategy:           StrategySequential,
+		Used:               10,
+		References:         10,
+		Capacity:           10,
+		Allocations:        12,
+		Frees:              2,
+		AllocationFailures: 1,
+		PeakUsed:           10,
+		PeakAt:             clock.Now(),
+	}
+	if stats := idGenerator.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+
+	idGenerator.Reset()
+	if used := idGenerator.Used(); used != 0 {
+		t.Errorf("expected used: 0, output used: %d", used)
+	}
+}
+
+func TestShardedGeneratorSplit(t *testing.T) {
+	testCases := []struct {
+		minValue int64
+		maxValue int64
+		n        int
+	}{
+		{1, 10, 1},
+		{1, 10, 3},
+		{1, 10, 10},
+		{0, 99, 4},
+		{-50, 50, 7},
+		{math.MinInt64, math.MaxInt64, 1},
+		{math.MinInt64, math.MaxInt64, 2},
+		{math.MinInt64, math.MaxInt64, 3},
+		{math.MinInt64, math.MaxInt64, 16},
+		{0, math.MaxInt64, 5},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(fmt.Sprintf("[%d, %d] into %d", testCase.minValue, testCase.maxValue, testCase.n), func(t *testing.T) {
+			idGenerator, err := NewShardedGenerator(testCase.minValue, testCase.maxValue, testCase.n, WithIntervalStore())
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the shards cover the range without gap or overlap
+			next := testCase.minValue
+			for i, shard := range idGenerator.shards {
+				if shard.minValue != next {
+					t.Fatalf("shard %d starts at %d, expected %d", i, shard.minValue, next)
+				}
+				for _, id := range []int64{shard.minValue, shard.maxValue} {
+					if owner := idGenerator.shard(id); owner != shard {
+						t.Errorf("ID[%d] routed to shard [%d, %d], expected shard %d", id, owner.minValue, owner.maxValue, i)
+					}
+				}
+				next = shard.maxValue + 1
+			}
+			if last := idGenerator.shards[len(idGenerator.shards)-1].maxValue; last != testCase.maxValue {
+				t.Errorf("last shard ends at %d, expected %d", last, testCase.maxValue)
+			}
+			// the sizes differ by one at most
+			smallest, largest := uint64(math.MaxUint64), uint64(0)
+			for _, shard := range idGenerator.shards {
+				size := uint64(shard.maxValue) - uint64(shard.minValue)
+				if size < smallest {
+					smallest = size
+				}
+				if size > largest {
+					largest = size
+				}
+			}
+			if largest-smallest > 1 {
+				t.Errorf("shard sizes differ by %d", largest-smallest)
+			}
+		})
+	}
+
+	for _, n := range []int{0, -1, 11} {
+		if _, err := NewShardedGenerator(1, 10, n); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%d shards: expected ErrInvalidRange, got %+v", n, err)
+		}
+	}
+	if _, err := NewShardedGenerator(10, 1, 2); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+}
+
+func TestShardedGeneratorConcurrency(t *testing.T) {
+	idGenerator, err := NewShardedGenerator(1, 10000, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mtx sync.Mutex
+	allocated := make(map[int64]bool)
+	wg := sync.WaitGroup{}
+	for routineID := 0; routineID < 16; routineID++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				id, err := idGenerator.Allocate()
+				if errors.Is(err, ErrPoolExhausted) {
+					return
+				}
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				mtx.Lock()
+				if allocated[id] {
+					t.Errorf("ID[%d] allocated twice", id)
+				}
+				allocated[id] = true
+				mtx.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(allocated) != 10000 {
+		t.Errorf("allocated %d IDs, expected 10000", len(allocated))
+	}
+}
+
+// BenchmarkContention compares a single lock with shards for GOMAXPROCS goroutines allocating and freeing
+func BenchmarkContention(b *testing.B) {
+	single := NewGenerator(1, 1<<20)
+	sharded, err := NewShardedGenerator(1, 1<<20, runtime.GOMAXPROCS(0))
+	if err != nil {
+		b.Fatal(err)
+	}
+	generators := []struct {
+		name     string
+		allocate func() (int64, error)
+		free     func(int64) error
+	}{
+		{"single", single.Allocate, single.FreeID},
+		{fmt.Sprintf("sharded-%d", runtime.GOMAXPROCS(0)), sharded.Allocate, sharded.FreeID},
+	}
+
+	for _, generator := range generators {
+		b.Run(generator.name, func(b *testing.B) {
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					id, err := generator.allocate()
+					if err != nil {
+						b.Error(err)
+						return
+					}
+					if err = generator.free(id); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
@@ -0,0 +1,318 @@
+package idgenerator
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"sync"
+	"time"
+)
+
+// FileSharedGenerator is a generator whose state is kept in a file shared by the processes of a host,
+// e.g. worker processes sharing a small range of ports, without a Redis or etcd server.
+// Every call locks the file path+".lock" against the other processes, and the other handles of the same process,
+// re-reads the state from the file under the lock, and writes the new state back atomically like
+// PersistentIDGenerator before releasing it, so that the state is never cached between two calls.
+// The lock is an flock on unix and a LockFileEx on windows: the system releases it when its process dies,
+// so a crashed process leaves no stale lock behind, and WithLockTimeout bounds the wait for a hung one.
+// The file holds the binary encoding of the state followed by its CRC-32C, a file failing the check
+// is rebuilt by the callback of WithRecovery.
+type FileSharedGenerator struct {
+	mu       sync.Mutex
+	path     string
+	lockFile *os.File
+	minValue int64
+	maxValue int64
+
+	generatorOpts []Option
+	clock         Clock
+	lockTimeout   time.Duration
+	recovery      func(path string, cause error) (Snapshot, error)
+	closed        bool
+}
+
+// FileSharedOption configures a FileSharedGenerator
+type FileSharedOption func(*FileSharedGenerator)
+
+// WithSharedGeneratorOptions passes opts to the IDGenerator the state is loaded into for each call.
+// The hooks of WithOnAllocate and WithOnFree only see the calls of their process.
+// The file holds the allocated IDs and the scan offset only, so NewFileSharedGenerator rejects the options
+// keeping state between calls, which would be lost with each load: WithReuseDelay, WithFIFORecycling,
+// WithStickyBindings, WithLeakDetection, WithHoldTimes, WithGenerations, WithAudit, WithRateLimit
+// and WithRangeProvider. For the same reason its methods do not lease, reserve or allocate for an owner.
+func WithSharedGeneratorOptions(opts ...Option) FileSharedOption {
+	return func(s *FileSharedGenerator) {
+		s.generatorOpts = append(s.generatorOpts, opts...)
+	}
+}
+
+// WithLockTimeout makes the calls fail with an error wrapping ErrLockTimeout if the file lock is not acquired
+// within timeout of the clock of WithClock, if passed with WithSharedGeneratorOptions,
+// rather than waiting for it however long it is held
+func WithLockTimeout(timeout time.Duration) FileSharedOption {
+	return func(s *FileSharedGenerator) {
+		s.lockTimeout = timeout
+	}
+}
+
+// WithRecovery rebuilds a corrupt file with the state returned by recovery, which is given the path
+// and the error of the check, e.g. the IDs held by the running workers. The file is rewritten with it
+// by the next call changing the state. The state returned must be over the range of the generator,
+// and if recovery fails the call fails with its error. Without it a corrupt file fails the calls
+// with an error wrapping ErrInvalidEncoding.
+func WithRecovery(recovery func(path string, cause error) (Snapshot, error)) FileSharedOption {
+	return func(s *FileSharedGenerator) {
+		s.recovery = recovery
+	}
+}
+
+// lockPollInterval is the interval between two attempts at the file lock with WithLockTimeout
+const lockPollInterval = 5 * time.Millisecond
+
+// NewFileSharedGenerator opens the generator shared in the file at path, or creates it if the file does not exist.
+// An existing file must hold a generator over [minValue, maxValue], otherwise an error wrapping
+// ErrInvalidRange is returned. Close releases the handle, not the IDs.
+func NewFileSharedGenerator(path string, minValue, maxValue int64, opts ...FileSharedOption) (
+	*FileSharedGenerator, error,
+) {
+	s := &FileSharedGenerator{path: path, minValue: minValue, maxValue: maxValue}
+	for _, opt := range opts {
+		opt(s)
+	}
+	generator, err := NewGeneratorWithOptions(minValue, maxValue, s.generatorOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if err = checkStateless(generator); err != nil {
+		return nil, err
+	}
+	s.clock = generator.clock
+	lockFile, err := os.OpenFile(path+".lock", os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	s.lockFile = lockFile
+	// the state is checked, and written if there is none yet
+	if err = s.update(true, func(*IDGenerator) error { return nil }); err != nil {
+		if closeErr := lockFile.Close(); closeErr != nil {
+			return nil, fmt.Errorf("%v (close %s: %v)", err, lockFile.Name(), closeErr)
+		}
+		return nil, err
+	}
+	return s, nil
+}
+
+// checkStateless returns an error naming the first option of generator keeping state between calls,
+// see WithSharedGeneratorOptions
+func checkStateless(generator *IDGenerator) error {
+	for _, option := range []struct {
+		name string
+		set  bool
+	}{
+		{"WithReuseDelay", generator.reuseDelay > 0},
+		{"WithFIFORecycling", generator.recycling != nil},
+		{"WithStickyBindings", generator.sticky != nil},
+		{"WithLeakDetection", generator.leaks != nil},
+		{"WithHoldTimes", generator.heldSince != nil},
+		{"WithGenerations", generator.generations != nil},
+		{"WithAudit", generator.audit != nil},
+		{"WithRateLimit", generator.limiter != nil},
+		{"WithRangeProvider", generator.rangeProvider != nil},
+	} {
+		if option.set {
+			return fmt.Errorf("shared generator: %s keeps state which is not in the file", option.name)
+		}
+	}
+	return nil
+}
+
+// Allocate allocates an ID like IDGenerator.Allocate from the shared state
+func (s *FileSharedGenerator) Allocate() (int64, error) {
+	var id int64
+	err := s.update(true, func(generator *IDGenerator) (err error) {
+		id, err = generator.Allocate()
+		return err
+	})
+	return id, err
+}
+
+// AllocateMany allocates n IDs like IDGenerator.AllocateMany from the shared state
+func (s *FileSharedGenerator) AllocateMany(n int) ([]int64, error) {
+	var ids []int64
+	err := s.update(true, func(generator *IDGenerator) (err error) {
+		ids, err = generator.AllocateMany(n)
+		return err
+	})
+	return ids, err
+}
+
+// AllocateSpecific allocates id like IDGenerator.AllocateSpecific in the shared state
+func (s *FileSharedGenerator) AllocateSpecific(id int64) error {
+	return s.update(true, func(generator *IDGenerator) error {
+		return generator.AllocateSpecific(id)
+	})
+}
+
+// FreeID frees id like IDGenerator.FreeID in the shared state
+func (s *FileSharedGenerator) FreeID(id int64) error {
+	return s.update(true, func(generator *IDGenerator) error {
+		return generator.FreeID(id)
+	})
+}
+
+// IsAllocated reports whether id is allocated in the shared state
+func (s *FileSharedGenerator) IsAllocated(id int64) (bool, error) {
+	var allocated bool
+	err := s.update(false, func(generator *IDGenerator) error {
+		allocated = generator.IsAllocated(id)
+		return nil
+	})
+	return allocated, err
+}
+
+// Used returns the number of IDs allocated in the shared state
+func (s *FileSharedGenerator) Used() (int64, error) {
+	var used int64
+	err := s.update(false, func(generator *IDGenerator) error {
+		used = generator.Used()
+		return nil
+	})
+	return used, err
+}
+
+// Available returns the number of IDs that can still be allocated from the shared state
+func (s *FileSharedGenerator) Available() (int64, error) {
+	var available int64
+	err := s.update(false, func(generator *IDGenerator) error {
+		available = generator.Available()
+		return nil
+	})
+	return available, err
+}
+
+// Snapshot returns the shared state
+func (s *FileSharedGenerator) Snapshot() (Snapshot, error) {
+	var snapshot Snapshot
+	err := s.update(false, func(generator *IDGenerator) error {
+		snapshot = generator.Snapshot()
+		return nil
+	})
+	return snapshot, err
+}
+
+// Close releases the handle, the calls fail with an error wrapping ErrClosed after it.
+// The IDs allocated are kept in the file for the other handles.
+func (s *FileSharedGenerator) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	return s.lockFile.Close()
+}
+
+// update calls op on the state loaded from the file under the file lock, exclusive if write,
+// and writes the state back if write and op succeeds
+func (s *FileSharedGenerator) update(write bool, op func(generator *IDGenerator) error) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return fmt.Errorf("%w: shared generator %s", ErrClosed, s.path)
+	}
+	if err = s.lock(write); err != nil {
+		return err
+	}
+	defer func() {
+		if unlockErr := unlockFile(s.lockFile); err == nil && unlockErr != nil {
+			err = fmt.Errorf("unlock %s: %w", s.lockFile.Name(), unlockErr)
+		}
+	}()
+	generator, err := s.load()
+	if err != nil {
+		return err
+	}
+	if err = op(generator); err != nil || !write {
+		return err
+	}
+	return writeFileAtomic(s.path, encodeShared(generator.Snapshot()))
+}
+
+// lock acquires the file lock, waiting no longer than lockTimeout if set
+func (s *FileSharedGenerator) lock(exclusive bool) error {
+	if s.lockTimeout <= 0 {
+		if err := lockFile(s.lockFile, exclusive); err != nil {
+			return fmt.Errorf("lock %s: %w", s.lockFile.Name(), err)
+		}
+		return nil
+	}
+	deadline := s.clock.Now().Add(s.lockTimeout)
+	for {
+		locked, err := tryLockFile(s.lockFile, exclusive)
+		if err != nil {
+			return fmt.Errorf("lock %s: %w", s.lockFile.Name(), err)
+		}
+		if locked {
+			return nil
+		}
+		if !s.clock.Now().Before(deadline) {
+			return fmt.Errorf("%w: %s held for more than %v", ErrLockTimeout, s.lockFile.Name(), s.lockTimeout)
+		}
+		<-s.clock.After(lockPollInterval)
+	}
+}
+
+// load returns a generator holding the state of the file, an empty one if there is no file,
+// or the state of the recovery if the file is corrupt. The caller must hold the file lock.
+func (s *FileSharedGenerator) load() (*IDGenerator, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return NewGeneratorWithOptions(s.minValue, s.maxValue, s.generatorOpts...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	snapshot, runs, err := decodeShared(data)
+	if err != nil {
+		if s.recovery == nil {
+			return nil, fmt.Errorf("load %s: %w", s.path, err)
+		}
+		runs = nil
+		if snapshot, err = s.recovery(s.path, err); err != nil {
+			return nil, fmt.Errorf("recover %s: %w", s.path, err)
+		}
+	}
+	if snapshot.MinValue != s.minValue || snapshot.MaxValue != s.maxValue {
+		return nil, fmt.Errorf("load %s: %w: file holds [%d, %d], requested [%d, %d]", s.path,
+			ErrInvalidRange, snapshot.MinValue, snapshot.MaxValue, s.minValue, s.maxValue)
+	}
+	generator, err := restoreGenerator(snapshot, runs, s.generatorOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("load %s: %w", s.path, err)
+	}
+	return generator, nil
+}
+
+// sharedChecksum is the CRC-32C table of the checksum ending the file of a FileSharedGenerator
+var sharedChecksum = crc32.MakeTable(crc32.Castagnoli)
+
+// encodeShared returns the binary encoding of snapshot followed by its CRC-32C in big-endian order
+func encodeShared(snapshot Snapshot) []byte {
+	data := encodeBinary(snapshot)
+	return binary.BigEndian.AppendUint32(data, crc32.Checksum(data, sharedChecksum))
+}
+
+// decodeShared returns the snapshot of data encoded by encodeShared and its used runs like decodeBinary,
+// failing with ErrInvalidEncoding if the checksum does not match
+func decodeShared(data []byte) (Snapshot, []usedRun, error) {
+	if len(data) < crc32.Size {
+		return Snapshot{}, nil, fmt.Errorf("%w: %d bytes, no checksum", ErrInvalidEncoding, len(data))
+	}
+	encoded, sum := data[:len(data)-crc32.Size], binary.BigEndian.Uint32(data[len(data)-crc32.Size:])
+	if crc32.Checksum(encoded, sharedChecksum) != sum {
+		return Snapshot{}, nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidEncoding)
+	}
+	return decodeBinary(encoded)
+}
@@ -0,0 +1,224 @@
+package idgenerator
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFileSharedGenerator(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports")
+	const handles, perHandle = 4, 25
+
+	// each goroutine opens its own handle, as a worker process would
+	var wg sync.WaitGroup
+	allocated := make(chan int64, handles*perHandle)
+	errs := make(chan error, handles)
+	for i := 0; i < handles; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			shared, err := NewFileSharedGenerator(path, 1, handles*perHandle)
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer func() {
+				if err := shared.Close(); err != nil {
+					errs <- err
+				}
+			}()
+			for j := 0; j < perHandle; j++ {
+				id, err := shared.Allocate()
+				if err != nil {
+					errs <- err
+					return
+				}
+				allocated <- id
+			}
+		}()
+	}
+	wg.Wait()
+	close(allocated)
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	seen := make(map[int64]bool)
+	for id := range allocated {
+		if seen[id] {
+			t.Fatalf("ID %d allocated twice", id)
+		}
+		seen[id] = true
+	}
+	if len(seen) != handles*perHandle {
+		t.Fatalf("expected %d IDs, output %d", handles*perHandle, len(seen))
+	}
+
+	shared, err := NewFileSharedGenerator(path, 1, handles*perHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewFileSharedGenerator(path, 1, handles*perHandle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = shared.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+	if err = other.FreeID(7); err != nil {
+		t.Fatal(err)
+	}
+	if allocated, err := shared.IsAllocated(7); err != nil || allocated {
+		t.Fatalf("expected ID 7 freed by the other handle, output %t, %+v", allocated, err)
+	}
+	if id, err := shared.Allocate(); err != nil || id != 7 {
+		t.Fatalf("expected ID 7, output %d, %+v", id, err)
+	}
+	if used, err := other.Used(); err != nil || used != handles*perHandle {
+		t.Errorf("expected %d IDs used, output %d, %+v", handles*perHandle, used, err)
+	}
+
+	if err = shared.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = shared.Allocate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %+v", err)
+	}
+	if _, err = NewFileSharedGenerator(path, 1, 10); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange, got %+v", err)
+	}
+}
+
+func TestFileSharedGeneratorRecovery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports")
+	shared, err := NewFileSharedGenerator(path, 1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = shared.AllocateSpecific(3); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a flipped bit is caught by the checksum
+	data[len(data)/2] ^= 1
+	if err = os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = shared.Used(); !errors.Is(err, ErrInvalidEncoding) {
+		t.Fatalf("expected ErrInvalidEncoding, got %+v", err)
+	}
+
+	var causes []error
+	recovered, err := NewFileSharedGenerator(path, 1, 10, WithRecovery(func(_ string, cause error) (Snapshot, error) {
+		causes = append(causes, cause)
+		return Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{3, 4}}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(causes) != 1 || !errors.Is(causes[0], ErrInvalidEncoding) {
+		t.Fatalf("expected one recovery of the invalid encoding, output %v", causes)
+	}
+	// the recovered state has been written back
+	if used, err := shared.Used(); err != nil || used != 2 {
+		t.Errorf("expected 2 IDs used, output %d, %+v", used, err)
+	}
+	if err = recovered.AllocateSpecific(4); !errors.Is(err, ErrAlreadyAllocated) {
+		t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+	}
+
+	failing, err := NewFileSharedGenerator(path, 1, 10, WithRecovery(func(string, error) (Snapshot, error) {
+		return Snapshot{MinValue: 1, MaxValue: 20}, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, []byte{1}, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = failing.Allocate(); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange for a recovered state of another range, got %+v", err)
+	}
+}
+
+func TestFileSharedGeneratorLockTimeout(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports")
+	clock := newFakeClock()
+	shared, err := NewFileSharedGenerator(path, 1, 10, WithLockTimeout(4*lockPollInterval),
+		WithSharedGeneratorOptions(WithClock(clock)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a hung process holding the lock
+	holder, err := os.OpenFile(path+".lock", os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := holder.Close(); err != nil {
+			t.Error(err)
+		}
+	}()
+	if err = lockFile(holder, true); err != nil {
+		t.Fatal(err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := shared.Allocate()
+		errs <- err
+	}()
+	// the lock is tried every lockPollInterval until the timeout
+	for i := 0; i < 4; i++ {
+		clock.waitForTimers(1)
+		clock.Advance(lockPollInterval)
+	}
+	if err = <-errs; !errors.Is(err, ErrLockTimeout) {
+		t.Fatalf("expected ErrLockTimeout, got %+v", err)
+	}
+	if err = unlockFile(holder); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := shared.Allocate(); err != nil || id != 1 {
+		t.Errorf("expected ID 1, output %d, %+v", id, err)
+	}
+}
+
+func TestFileSharedGeneratorStatefulOptions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ports")
+	for name, opt := range map[string]Option{
+		"WithReuseDelay":     WithReuseDelay(time.Hour),
+		"WithFIFORecycling":  WithFIFORecycling(RecycleFirst),
+		"WithStickyBindings": WithStickyBindings(time.Hour, 10),
+		"WithLeakDetection":  WithLeakDetection(time.Hour, func([]int64) {}),
+		"WithHoldTimes":      WithHoldTimes(nil),
+		"WithGenerations":    WithGenerations(),
+		"WithAudit":          WithAudit(10),
+		"WithRateLimit":      WithRateLimit(10, 1),
+		"WithRangeProvider": WithRangeProvider(func(current [2]int64) ([2]int64, error) {
+			return current, nil
+		}),
+	} {
+		_, err := NewFileSharedGenerator(path, 1, 3, WithSharedGeneratorOptions(opt))
+		if err == nil || !strings.Contains(err.Error(), name) {
+			t.Errorf("%s: expected an error naming the option, got %+v", name, err)
+		}
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("expected no file written for a rejected option, got %+v", err)
+	}
+	shared, err := NewFileSharedGenerator(path, 1, 3, WithSharedGeneratorOptions(WithPermanentIDs(1)))
+	if err != nil {
+		t.Fatalf("expected the options without state accepted, got %+v", err)
+	}
+	if err = shared.Close(); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,53 @@
+//go:build go1.21
+
+package idgenerator
+
+import (
+	"context"
+	"log/slog"
+)
+
+// slogLogger is the eventLogger of WithSlog
+type slogLogger struct {
+	logger *slog.Logger
+	level  slog.Level
+}
+
+func (l slogLogger) enabled() bool {
+	return l.logger.Enabled(context.Background(), l.level)
+}
+
+func (l slogLogger) logEvent(e logEvent) {
+	attrs := make([]slog.Attr, 0, 7)
+	attrs = append(attrs, slog.String("pool", e.pool))
+	if e.hasID {
+		attrs = append(attrs, slog.Int64("id", e.id))
+	}
+	attrs = append(attrs, slog.Int64("used", e.used), slog.Int64("capacity", e.capacity))
+	if e.err != nil {
+		attrs = append(attrs, slog.String("error", e.err.Error()))
+	}
+	if e.msg == logWatermarkCrossed {
+		attrs = append(attrs, slog.Float64("watermark", e.watermark), slog.Bool("high", e.high))
+	}
+	l.logger.LogAttrs(context.Background(), l.level, e.msg, attrs...)
+}
+
+// WithSlog logs the notable events of the generator to logger at level, as records with a message and attributes
+// rather than the lines of WithLogger: the failed allocations, the frees of IDs not allocated or out of range,
+// the crossings of the watermarks of WithHighWatermark and WithLowWatermark and the expiries of the leases.
+// Every record has the attributes pool, the name of WithName, and used and capacity, the usage of the generator
+// at the time of the event, along with id for the events of an ID, error for the failures,
+// and watermark and high for the crossings.
+// The records are logged after the lock of the generator is released, like the hooks, and nothing is built
+// for them if logger is not enabled at level. A nil logger logs nothing, which is the default.
+// It requires Go 1.21 for log/slog.
+func WithSlog(logger *slog.Logger, level slog.Level) Option {
+	return func(idGenerator *IDGenerator) {
+		if logger == nil {
+			idGenerator.eventLog = nil
+			return
+		}
+		idGenerator.eventLog = slogLogger{logger: logger, level: level}
+	}
+}
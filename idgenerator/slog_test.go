@@ -0,0 +1,142 @@
+//go:build go1.21
+
+package idgenerator
+
+import (
+	"context"
+	"log/slog"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recordHandler is a slog.Handler keeping the records it handles
+type recordHandler struct {
+	mtx     sync.Mutex
+	level   slog.Level
+	records []slog.Record
+}
+
+func (h *recordHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordHandler) WithGroup(string) slog.Handler { return h }
+
+// take returns the message and attributes of the records handled since the last call, without the error
+// attribute whose presence is reported by the "error" key set to true
+func (h *recordHandler) take() []map[string]interface{} {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	var records []map[string]interface{}
+	for _, r := range h.records {
+		attrs := map[string]interface{}{"msg": r.Message}
+		r.Attrs(func(a slog.Attr) bool {
+			if a.Key == "error" {
+				attrs[a.Key] = a.Value.String() != ""
+			} else {
+				attrs[a.Key] = a.Value.Any()
+			}
+			return true
+		})
+		records = append(records, attrs)
+	}
+	h.records = nil
+	return records
+}
+
+func TestSlogEvents(t *testing.T) {
+	handler := &recordHandler{level: slog.LevelInfo}
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 4, WithName("teid"), WithClock(clock),
+		WithSlog(slog.New(handler), slog.LevelWarn), WithHighWatermark(0.5, func(used, capacity int64) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(name string, want ...map[string]interface{}) {
+		t.Helper()
+		if got := handler.take(); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: records = %v, want %v", name, got, want)
+		}
+	}
+
+	if err = idGenerator.FreeID(3); err == nil {
+		t.Fatal("FreeID of an ID not allocated succeeded")
+	}
+	check("free not allocated", map[string]interface{}{
+		"msg": logFreeNotAllocated, "pool": "teid", "id": int64(3), "used": int64(0), "capacity": int64(4), "error": true,
+	})
+
+	if err = idGenerator.FreeID(5); err == nil {
+		t.Fatal("FreeID out of range succeeded")
+	}
+	check("free out of range", map[string]interface{}{
+		"msg": logFreeOutOfRange, "pool": "teid", "id": int64(5), "used": int64(0), "capacity": int64(4), "error": true,
+	})
+
+	if _, err = idGenerator.AllocateLease(time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	check("below watermark")
+	allocateN(t, idGenerator, 1)
+	check("watermark", map[string]interface{}{
+		"msg": logWatermarkCrossed, "pool": "teid", "used": int64(2), "capacity": int64(4),
+		"watermark": 0.5, "high": true,
+	})
+
+	allocateN(t, idGenerator, 2)
+	handler.take()
+	if _, err = idGenerator.Allocate(); err == nil {
+		t.Fatal("Allocate of a full generator succeeded")
+	}
+	check("allocate failed", map[string]interface{}{
+		"msg": logAllocateFailed, "pool": "teid", "used": int64(4), "capacity": int64(4), "error": true,
+	})
+
+	clock.Advance(time.Minute)
+	if used := idGenerator.Used(); used != 3 {
+		t.Fatalf("Used() = %d after the lease expired, want 3", used)
+	}
+	check("lease expired", map[string]interface{}{
+		"msg": logLeaseExpired, "pool": "teid", "id": int64(1), "used": int64(3), "capacity": int64(4),
+	})
+}
+
+func TestSlogDisabled(t *testing.T) {
+	handler := &recordHandler{level: slog.LevelError}
+	idGenerator, err := NewGeneratorWithOptions(1, 1, WithSlog(slog.New(handler), slog.LevelWarn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	silent := NewGenerator(1, 1)
+	allocateN(t, idGenerator, 1)
+	allocateN(t, silent, 1)
+
+	run := func(g *IDGenerator) float64 {
+		return testing.AllocsPerRun(100, func() {
+			if _, err := g.Allocate(); err == nil {
+				t.Fatal("Allocate of a full generator succeeded")
+			}
+			if err := g.FreeID(2); err == nil {
+				t.Fatal("FreeID out of range succeeded")
+			}
+		})
+	}
+	if got, want := run(idGenerator), run(silent); got != want {
+		t.Errorf("AllocsPerRun with a disabled level = %v, want %v as without WithSlog", got, want)
+	}
+	if records := handler.take(); len(records) != 0 {
+		t.Errorf("records = %v at a disabled level, want none", records)
+	}
+}
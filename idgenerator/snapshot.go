@@ -0,0 +1,295 @@
+package idgenerator
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot is the allocation state of an IDGenerator at one point in time.
+// Offset is where the next Allocate starts scanning, relative to MinValue,
+// so that a restored generator hands out IDs in the same order as the original.
+type Snapshot struct {
+	MinValue int64   `json:"minValue"`
+	MaxValue int64   `json:"maxValue"`
+	Offset   uint64  `json:"offset"`
+	Used     []int64 `json:"used"`
+}
+
+// Snapshot returns the current state of the generator, Used is sorted in ascending order.
+// Options such as the logger or the store are not part of the snapshot,
+// and leased IDs are recorded as plain allocations without their expiry.
+func (idGenerator *IDGenerator) Snapshot() Snapshot {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return idGenerator.snapshotLocked()
+}
+
+func (idGenerator *IDGenerator) snapshotLocked() Snapshot {
+	offsets := idGenerator.allocatedOffsetsLocked()
+	used := make([]int64, len(offsets))
+	for i, offset := range offsets {
+		used[i] = idGenerator.toID(offset)
+	}
+	return Snapshot{
+		MinValue: idGenerator.minValue,
+		MaxValue: idGenerator.maxValue,
+		Offset:   idGenerator.offset,
+		Used:     used,
+	}
+}
+
+// RestoreGenerator builds a generator from snapshot, then applies opts to it.
+// It fails if the range is invalid, if Offset is outside the range,
+// or if any used ID is out of range or listed twice.
+func RestoreGenerator(snapshot Snapshot, opts ...Option) (*IDGenerator, error) {
+	return restoreGenerator(snapshot, nil, opts...)
+}
+
+// restoreGenerator is RestoreGenerator with the used IDs of runs allocated as well
+func restoreGenerator(snapshot Snapshot, runs []usedRun, opts ...Option) (*IDGenerator, error) {
+	idGenerator, err := NewGeneratorWithOptions(snapshot.MinValue, snapshot.MaxValue, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	if err = idGenerator.loadRuns(snapshot, runs); err != nil {
+		return nil, err
+	}
+	return idGenerator, nil
+}
+
+// NewGeneratorWithUsed builds a generator over [minValue, maxValue] with the IDs of used allocated,
+// e.g. the IDs in use according to a database at startup, then applies opts to it.
+// The IDs are loaded at once like a snapshot, rather than allocated one by one, and Allocate carries on
+// just past the highest of them, wrapping around to minValue past maxValue.
+// It fails like RestoreGenerator if the range is invalid, or if any ID is out of range or listed twice.
+func NewGeneratorWithUsed(minValue, maxValue int64, used []int64, opts ...Option) (*IDGenerator, error) {
+	idGenerator, err := NewGeneratorWithOptions(minValue, maxValue, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snapshot: %w", err)
+	}
+	snapshot := Snapshot{MinValue: minValue, MaxValue: maxValue, Used: used}
+	if len(used) > 0 {
+		highest := used[0]
+		for _, id := range used[1:] {
+			if id > highest {
+				highest = id
+			}
+		}
+		// an offset out of range fails the load with the highest ID, not with the offset
+		if idGenerator.inRange(highest) && idGenerator.toOffset(highest) < idGenerator.lastOffset {
+			snapshot.Offset = idGenerator.toOffset(highest) + 1
+		}
+	}
+	if err = idGenerator.load(snapshot); err != nil {
+		return nil, err
+	}
+	return idGenerator, nil
+}
+
+// maxLoadedOffsets bounds the used IDs of the runs a load sets one by one, in the Stores without SetRange
+// or to track each of them: beyond, the memory and the time of the load would be those of an attack
+const maxLoadedOffsets = 1 << 32
+
+// load replaces the state of the generator with snapshot, leaving it unchanged on error.
+// The caller must hold lock or own the generator exclusively.
+func (idGenerator *IDGenerator) load(snapshot Snapshot) error {
+	return idGenerator.loadRuns(snapshot, nil)
+}
+
+// loadRuns is load with the used IDs of runs allocated as well, which must not overlap the Used of snapshot
+func (idGenerator *IDGenerator) loadRuns(snapshot Snapshot, runs []usedRun) error {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return err
+	}
+	if snapshot.MinValue > snapshot.MaxValue {
+		return fmt.Errorf("invalid snapshot: %w: minValue %d > maxValue %d",
+			ErrInvalidRange, snapshot.MinValue, snapshot.MaxValue)
+	}
+	restored := &IDGenerator{
+		clock:    idGenerator.clock,
+		minValue: snapshot.MinValue,
+		maxValue: snapshot.MaxValue,
+		step:     idGenerator.step.rebase(idGenerator.minValue, snapshot.MinValue),
+	}
+	var ok bool
+	if restored.lastOffset, ok = restored.step.lastOffset(snapshot.MinValue, snapshot.MaxValue); !ok {
+		return fmt.Errorf("invalid snapshot: %w: [%d, %d] has no ID of the step",
+			ErrInvalidRange, snapshot.MinValue, snapshot.MaxValue)
+	}
+	if snapshot.Offset > restored.lastOffset {
+		return fmt.Errorf("invalid snapshot: %w: offset %d not in [0, %d]",
+			ErrOutOfRange, snapshot.Offset, restored.lastOffset)
+	}
+	store, err := idGenerator.newStore(restored.lastOffset)
+	if err != nil {
+		return fmt.Errorf("invalid snapshot: %w: [%d, %d]: %v", ErrInvalidRange, snapshot.MinValue, snapshot.MaxValue, err)
+	}
+	restored.store = store
+	// the restored IDs get a new generation, the tokens from before cannot be trusted
+	restored.generations = idGenerator.movedGenerationsLocked(restored)
+	// the hold times restart with the load, the times from before are not known
+	if idGenerator.heldSince != nil {
+		restored.heldSince = make(map[uint64]time.Time)
+	}
+	// the exclusions outlive any state loaded into the generator
+	if len(idGenerator.excluded) > 0 {
+		restored.excluded = make(map[uint64]struct{}, len(idGenerator.excluded))
+		for offset := range idGenerator.excluded {
+			id := idGenerator.toID(offset)
+			if !restored.inRange(id) {
+				return fmt.Errorf("invalid snapshot: %w: excluded ID[%d] not in [%d, %d]",
+					ErrOutOfRange, id, restored.minValue, restored.maxValue)
+			}
+			restored.excluded[restored.toOffset(id)] = struct{}{}
+		}
+		restored.setExcluded(restored.store)
+	}
+	// the peak is reached by the whole load rather than by each ID, which spares a clock read per ID
+	restored.peakUsed = ^uint64(0)
+	for _, id := range snapshot.Used {
+		if !restored.inRange(id) {
+			return fmt.Errorf("invalid snapshot: %w", restored.outOfRangeError(id))
+		}
+		offset := restored.toOffset(id)
+		if restored.isExcluded(offset) {
+			return fmt.Errorf("invalid snapshot: %w", restored.reservedError(id))
+		}
+		if restored.store.Has(offset) {
+			return fmt.Errorf("invalid snapshot: %w: ID[%d] listed twice", ErrAlreadyAllocated, id)
+		}
+		restored.markUsed(offset)
+	}
+	if err = restored.loadRunsLocked(runs, idGenerator.leaks != nil); err != nil {
+		return err
+	}
+	restored.peakUsed, restored.peakAt = restored.used, restored.clock.Now()
+
+	idGenerator.minValue = restored.minValue
+	idGenerator.maxValue = restored.maxValue
+	idGenerator.lastOffset = restored.lastOffset
+	idGenerator.step = restored.step
+	idGenerator.offset = snapshot.Offset
+	idGenerator.store = restored.store
+	idGenerator.used = restored.used
+	if idGenerator.used > idGenerator.peakUsed {
+		idGenerator.peakUsed, idGenerator.peakAt = restored.peakUsed, restored.peakAt
+	}
+	idGenerator.excluded = restored.excluded
+	idGenerator.generations = restored.generations
+	idGenerator.heldSince = restored.heldSince
+	idGenerator.leases = nil
+	idGenerator.reservations = nil
+	idGenerator.leaseHeap = nil
+	idGenerator.expired = nil
+	idGenerator.owners = nil
+	idGenerator.ownerOf = nil
+	if idGenerator.leaks != nil {
+		idGenerator.leaks.reset()
+		for _, id := range snapshot.Used {
+			idGenerator.trackLocked(idGenerator.toOffset(id))
+		}
+		for _, run := range runs {
+			for offset := idGenerator.toOffset(run.first); ; offset++ {
+				idGenerator.trackLocked(offset)
+				if offset == idGenerator.toOffset(run.last) {
+					break
+				}
+			}
+		}
+	}
+	// the cached IDs go with the state they were allocated in
+	idGenerator.dropCacheLocked()
+	idGenerator.clearRefsLocked()
+	idGenerator.endQuarantineLocked()
+	idGenerator.setPermanentLocked()
+	idGenerator.resetRecyclingLocked()
+	idGenerator.stickyFreedLocked()
+	idGenerator.serveWaitersLocked()
+	return nil
+}
+
+// loadRunsLocked sets the IDs of runs in the store of a generator being restored by load. The runs are checked
+// before any of their IDs is set: a run out of the range, overlapping an ID set or longer than the store takes
+// fails the load. The runs are set at once in the stores implementing rangeSetter, unless each ID has a record
+// of its own, e.g. to be tracked as leaked.
+func (idGenerator *IDGenerator) loadRunsLocked(runs []usedRun, tracked bool) error {
+	setter, _ := idGenerator.store.(rangeSetter)
+	if idGenerator.generations != nil || idGenerator.heldSince != nil || tracked {
+		setter = nil
+	}
+	// expanded is the number of IDs set one by one, size the number of IDs of a run minus one,
+	// which cannot overflow
+	var expanded uint64
+	for _, run := range runs {
+		for _, id := range []int64{run.first, run.last} {
+			if !idGenerator.inRange(id) {
+				return fmt.Errorf("invalid snapshot: %w", idGenerator.outOfRangeError(id))
+			}
+		}
+		// the IDs between the ends are in the range unless a step skips them
+		if run.first < run.last && !idGenerator.inRange(run.first+1) {
+			return fmt.Errorf("invalid snapshot: %w", idGenerator.outOfRangeError(run.first+1))
+		}
+		first, last := idGenerator.toOffset(run.first), idGenerator.toOffset(run.last)
+		if offset, ok := idGenerator.store.NextSet(first); ok && offset <= last {
+			if idGenerator.isExcluded(offset) {
+				return fmt.Errorf("invalid snapshot: %w", idGenerator.reservedError(idGenerator.toID(offset)))
+			}
+			return fmt.Errorf("invalid snapshot: %w: ID[%d] listed twice", ErrAlreadyAllocated, idGenerator.toID(offset))
+		}
+		size := last - first
+		if setter != nil {
+			setter.SetRange(first, last)
+			idGenerator.used += size + 1
+			continue
+		}
+		if size >= maxLoadedOffsets-expanded {
+			return fmt.Errorf("invalid snapshot: %w: run [%d, %d] beyond the capacity of the store",
+				ErrInvalidRange, run.first, run.last)
+		}
+		expanded += size + 1
+		for offset := first; ; offset++ {
+			idGenerator.markUsed(offset)
+			if offset == last {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// DiffSnapshots returns the IDs allocated in b but not in a, and those freed, allocated in a but not in b,
+// in ascending order, e.g. to tell what changed between two Snapshots of the same generator.
+// It walks the Used lists of the snapshots once, which must be sorted in ascending order like those of Snapshot.
+// It returns an error wrapping ErrInvalidRange if the snapshots are of different ranges,
+// or an error if a Used list is not sorted or lists an ID twice.
+func DiffSnapshots(a, b Snapshot) (allocated, freed []int64, err error) {
+	if a.MinValue != b.MinValue || a.MaxValue != b.MaxValue {
+		return nil, nil, fmt.Errorf("%w: snapshots of [%d, %d] and [%d, %d]",
+			ErrInvalidRange, a.MinValue, a.MaxValue, b.MinValue, b.MaxValue)
+	}
+	for _, used := range [][]int64{a.Used, b.Used} {
+		for i := 1; i < len(used); i++ {
+			if used[i] <= used[i-1] {
+				return nil, nil, fmt.Errorf("invalid snapshot: ID[%d] after ID[%d], Used is not sorted", used[i], used[i-1])
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a.Used) && j < len(b.Used) {
+		switch {
+		case a.Used[i] < b.Used[j]:
+			freed = append(freed, a.Used[i])
+			i++
+		case a.Used[i] > b.Used[j]:
+			allocated = append(allocated, b.Used[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	freed = append(freed, a.Used[i:]...)
+	allocated = append(allocated, b.Used[j:]...)
+	return allocated, freed, nil
+}
@@ -0,0 +1,276 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotRestore(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(100, 109, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// run through the whole range once so that the next allocations wrap around
+			for i := 0; i < 10; i++ {
+				if _, err = idGenerator.Allocate(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, id := range []int64{101, 103, 104, 108} {
+				if err = idGenerator.FreeID(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if _, err = idGenerator.Allocate(); err != nil {
+				t.Fatal(err)
+			}
+
+			snapshot := idGenerator.Snapshot()
+			expected := Snapshot{
+				MinValue: 100,
+				MaxValue: 109,
+				Offset:   2,
+				Used:     []int64{100, 101, 102, 105, 106, 107, 109},
+			}
+			if !reflect.DeepEqual(snapshot, expected) {
+				t.Fatalf("expected snapshot %+v, output %+v", expected, snapshot)
+			}
+
+			restored, err := RestoreGenerator(snapshot, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(restored.Snapshot(), snapshot) {
+				t.Errorf("expected restored snapshot %+v, output %+v", snapshot, restored.Snapshot())
+			}
+
+			// both generators continue with the same allocation order
+			for i := 0; i < 3; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				restoredID, err := restored.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != restoredID {
+					t.Errorf("allocation %d: original returned %d, restored returned %d", i, id, restoredID)
+				}
+			}
+			if _, err = restored.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Errorf("expected ErrPoolExhausted, got %+v", err)
+			}
+		})
+	}
+}
+
+func TestRestoreGeneratorValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		snapshot    Snapshot
+		expectedErr error
+	}{
+		{"min > max", Snapshot{MinValue: 10, MaxValue: 1}, ErrInvalidRange},
+		{"offset far past range", Snapshot{MinValue: 1, MaxValue: 10, Offset: math.MaxUint64}, ErrOutOfRange},
+		{"offset past range", Snapshot{MinValue: 1, MaxValue: 10, Offset: 10}, ErrOutOfRange},
+		{"used below min", Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{0}}, ErrOutOfRange},
+		{"used above max", Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{5, 11}}, ErrOutOfRange},
+		{"duplicate used", Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{5, 5}}, ErrAlreadyAllocated},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			idGenerator, err := RestoreGenerator(testCase.snapshot)
+			if !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+			if idGenerator != nil {
+				t.Error("expected nil generator on error")
+			}
+		})
+	}
+}
+
+func TestNewGeneratorWithUsed(t *testing.T) {
+	idGenerator, err := NewGeneratorWithUsed(1, 10, []int64{7, 2, 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used := idGenerator.AllocatedIDs(); !reflect.DeepEqual(used, []int64{2, 3, 7}) {
+		t.Errorf("expected IDs [2 3 7] allocated, output %v", used)
+	}
+	// Allocate carries on past the highest ID, then wraps around
+	for _, expected := range []int64{8, 9, 10, 1, 4} {
+		if id, err := idGenerator.Allocate(); err != nil || id != expected {
+			t.Errorf("expected ID %d, output %d, %+v", expected, id, err)
+		}
+	}
+
+	idGenerator, err = NewGeneratorWithUsed(1, 10, []int64{10}, WithBitmapStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := idGenerator.Allocate(); err != nil || id != 1 {
+		t.Errorf("expected ID 1 past maxValue, output %d, %+v", id, err)
+	}
+	if idGenerator, err = NewGeneratorWithUsed(1, 10, nil); err != nil || idGenerator.Used() != 0 {
+		t.Errorf("expected an empty generator, got %+v", err)
+	}
+}
+
+func TestNewGeneratorWithUsedValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		minValue    int64
+		maxValue    int64
+		used        []int64
+		expectedErr error
+	}{
+		{"min > max", 10, 1, []int64{5}, ErrInvalidRange},
+		{"below min", 1, 10, []int64{0}, ErrOutOfRange},
+		{"above max", 1, 10, []int64{5, 11}, ErrOutOfRange},
+		{"duplicate", 1, 10, []int64{5, 5}, ErrAlreadyAllocated},
+	}
+	for _, testCase := range testCases {
+		idGenerator, err := NewGeneratorWithUsed(testCase.minValue, testCase.maxValue, testCase.used)
+		if !errors.Is(err, testCase.expectedErr) || idGenerator != nil {
+			t.Errorf("%s: expected %v, got %v, %+v", testCase.name, testCase.expectedErr, idGenerator, err)
+		}
+	}
+}
+
+func BenchmarkNewGeneratorWithUsed(b *testing.B) {
+	const n = 1000000
+	used := make([]int64, n)
+	for i := range used {
+		// every other ID of the range
+		used[i] = int64(2 * i)
+	}
+	b.Run("loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			idGenerator := NewGenerator(0, 2*n)
+			for _, id := range used {
+				if _, err := idGenerator.AllocateWithOffset(id); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("bulk", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			if _, err := NewGeneratorWithUsed(0, 2*n, used); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	testCases := []struct {
+		name      string
+		a, b      Snapshot
+		allocated []int64
+		freed     []int64
+	}{
+		{"empty pools", Snapshot{MinValue: 1, MaxValue: 10}, Snapshot{MinValue: 1, MaxValue: 10}, nil, nil},
+		{
+			"identical",
+			Snapshot{MinValue: 1, MaxValue: 10, Offset: 3, Used: []int64{1, 2, 5}},
+			Snapshot{MinValue: 1, MaxValue: 10, Offset: 6, Used: []int64{1, 2, 5}},
+			nil, nil,
+		},
+		{
+			"from empty",
+			Snapshot{MinValue: 1, MaxValue: 10},
+			Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{3, 4}},
+			[]int64{3, 4},
+			nil,
+		},
+		{
+			"to empty",
+			Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{3, 4}},
+			Snapshot{MinValue: 1, MaxValue: 10},
+			nil,
+			[]int64{3, 4},
+		},
+		{
+			"interleaved",
+			Snapshot{MinValue: -5, MaxValue: 10, Used: []int64{-5, -1, 2, 3, 9}},
+			Snapshot{MinValue: -5, MaxValue: 10, Used: []int64{-4, -1, 3, 7, 8, 10}},
+			[]int64{-4, 7, 8, 10},
+			[]int64{-5, 2, 9},
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allocated, freed, err := DiffSnapshots(testCase.a, testCase.b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(allocated, testCase.allocated) || !reflect.DeepEqual(freed, testCase.freed) {
+				t.Errorf("expected allocated %v and freed %v, got %v and %v",
+					testCase.allocated, testCase.freed, allocated, freed)
+			}
+		})
+	}
+}
+
+func TestDiffSnapshotsGenerator(t *testing.T) {
+	idGenerator := NewGenerator(1, 100000)
+	ids := allocateN(t, idGenerator, 50000)
+	before := idGenerator.Snapshot()
+	var freed []int64
+	for _, id := range ids {
+		if id%3 == 0 {
+			if err := idGenerator.FreeID(id); err != nil {
+				t.Fatal(err)
+			}
+			freed = append(freed, id)
+		}
+	}
+	allocated := allocateN(t, idGenerator, 1000)
+
+	gotAllocated, gotFreed, err := DiffSnapshots(before, idGenerator.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(gotAllocated, allocated) || !reflect.DeepEqual(gotFreed, freed) {
+		t.Errorf("expected %d allocated and %d freed IDs, got %d and %d",
+			len(allocated), len(freed), len(gotAllocated), len(gotFreed))
+	}
+}
+
+func TestDiffSnapshotsValidation(t *testing.T) {
+	testCases := []struct {
+		name        string
+		a, b        Snapshot
+		expectedErr error
+	}{
+		{"different min", Snapshot{MinValue: 1, MaxValue: 10}, Snapshot{MinValue: 2, MaxValue: 10}, ErrInvalidRange},
+		{"different max", Snapshot{MinValue: 1, MaxValue: 10}, Snapshot{MinValue: 1, MaxValue: 9}, ErrInvalidRange},
+		{"unsorted", Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{3, 2}}, Snapshot{MinValue: 1, MaxValue: 10}, nil},
+		{"duplicate", Snapshot{MinValue: 1, MaxValue: 10}, Snapshot{MinValue: 1, MaxValue: 10, Used: []int64{2, 2}}, nil},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			allocated, freed, err := DiffSnapshots(testCase.a, testCase.b)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if testCase.expectedErr != nil && !errors.Is(err, testCase.expectedErr) {
+				t.Errorf("expected %v, got %+v", testCase.expectedErr, err)
+			}
+			if allocated != nil || freed != nil {
+				t.Errorf("expected no IDs on error, got %v and %v", allocated, freed)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package idgenerator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// The bit widths of a SnowflakeConfig without any, those of the original Snowflake:
+// milliseconds for 69 years after the epoch, 1024 workers and 4096 IDs per millisecond and worker
+const (
+	DefaultSnowflakeTimestampBits = 41
+	DefaultSnowflakeWorkerBits    = 10
+	DefaultSnowflakeSequenceBits  = 12
+)
+
+// SnowflakeConfig is the layout of the IDs of a SnowflakeGenerator, from the highest bits to the lowest:
+// the milliseconds since Epoch, the worker ID and a sequence number within the millisecond.
+// The widths add up to 63 bits at most so that the IDs are positive, all of them 0 gives the default widths.
+type SnowflakeConfig struct {
+	Epoch         time.Time
+	TimestampBits uint
+	WorkerBits    uint
+	SequenceBits  uint
+	// Clock is the time source, the system clock if nil.
+	// NextID waits on the After of Clock for the next millisecond.
+	Clock Clock
+}
+
+// SnowflakeGenerator generates unique 64-bit IDs ordered by time, without coordination between the generators
+// as long as each has its own worker ID, e.g. allocated from a generator shared by the instances of a service.
+// The IDs of a generator are strictly increasing. It is safe for concurrent use.
+type SnowflakeGenerator struct {
+	mtx    sync.Mutex
+	config SnowflakeConfig
+	worker int64
+	// workers is the Allocator the worker ID comes from, which Close frees it to, nil if given explicitly
+	workers Allocator
+	// last is the millisecond of the last ID, sequence its sequence number
+	last     int64
+	sequence int64
+	closed   bool
+}
+
+// NewSnowflakeGenerator returns a SnowflakeGenerator of worker, which no other generator must use at the same time.
+// It returns an error wrapping ErrInvalidRange if config is invalid, without an epoch, with widths over 63 bits
+// or no bit for the timestamp or the sequence, or ErrOutOfRange if worker does not fit in WorkerBits.
+func NewSnowflakeGenerator(config SnowflakeConfig, worker int64) (*SnowflakeGenerator, error) {
+	if config.TimestampBits == 0 && config.WorkerBits == 0 && config.SequenceBits == 0 {
+		config.TimestampBits = DefaultSnowflakeTimestampBits
+		config.WorkerBits = DefaultSnowflakeWorkerBits
+		config.SequenceBits = DefaultSnowflakeSequenceBits
+	}
+	switch {
+	case config.Epoch.IsZero():
+		return nil, fmt.Errorf("%w: snowflake without epoch", ErrInvalidRange)
+	case config.TimestampBits == 0 || config.SequenceBits == 0 ||
+		config.TimestampBits+config.WorkerBits+config.SequenceBits > 63:
+		return nil, fmt.Errorf("%w: snowflake of %d timestamp, %d worker and %d sequence bits", ErrInvalidRange,
+			config.TimestampBits, config.WorkerBits, config.SequenceBits)
+	case worker < 0 || worker >= 1<<config.WorkerBits:
+		return nil, fmt.Errorf("%w: worker ID[%d] not in [0, %d]", ErrOutOfRange, worker, int64(1)<<config.WorkerBits-1)
+	}
+	if config.Clock == nil {
+		config.Clock = realClock{}
+	}
+	return &SnowflakeGenerator{config: config, worker: worker, last: -1}, nil
+}
+
+// NewSnowflakeGeneratorFrom returns a SnowflakeGenerator like NewSnowflakeGenerator
+// with a worker ID allocated from workers, which Close frees again.
+// It fails like the Allocate of workers, or like NewSnowflakeGenerator if the ID does not fit in WorkerBits,
+// the ID is freed then.
+func NewSnowflakeGeneratorFrom(config SnowflakeConfig, workers Allocator) (*SnowflakeGenerator, error) {
+	worker, err := workers.Allocate()
+	if err != nil {
+		return nil, fmt.Errorf("snowflake worker ID: %w", err)
+	}
+	generator, err := NewSnowflakeGenerator(config, worker)
+	if err != nil {
+		if freeErr := workers.FreeID(worker); freeErr != nil {
+			return nil, fmt.Errorf("%w, and freeing it: %v", err, freeErr)
+		}
+		return nil, err
+	}
+	generator.workers = workers
+	return generator, nil
+}
+
+// Worker returns the worker ID of the generator
+func (s *SnowflakeGenerator) Worker() int64 {
+	return s.worker
+}
+
+// NextID returns a new ID. It waits for the next millisecond if the sequence numbers of this one are used up.
+// It returns an error wrapping ErrClockBackwards if the clock is behind the last ID, rather than risk a duplicate,
+// ErrPoolExhausted if the milliseconds since the epoch do not fit in TimestampBits, or ErrClosed after Close.
+func (s *SnowflakeGenerator) NextID() (int64, error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return 0, fmt.Errorf("%w: snowflake worker ID[%d]", ErrClosed, s.worker)
+	}
+	now, err := s.millisecond()
+	if err != nil {
+		return 0, err
+	}
+	if now == s.last {
+		if s.sequence++; s.sequence >= 1<<s.config.SequenceBits {
+			for now <= s.last {
+				next := s.config.Epoch.Add(time.Duration(s.last+1) * time.Millisecond)
+				<-s.config.Clock.After(next.Sub(s.config.Clock.Now()))
+				if now, err = s.millisecond(); err != nil {
+					return 0, err
+				}
+			}
+			s.sequence = 0
+		}
+	} else {
+		s.sequence = 0
+	}
+	if now >= 1<<s.config.TimestampBits {
+		return 0, fmt.Errorf("%w: snowflake timestamp %d over %d bits", ErrPoolExhausted, now, s.config.TimestampBits)
+	}
+	s.last = now
+	return now<<(s.config.WorkerBits+s.config.SequenceBits) | s.worker<<s.config.SequenceBits | s.sequence, nil
+}
+
+// millisecond returns the milliseconds since the epoch, or an error if the clock is behind the last ID.
+// The caller must hold mtx.
+func (s *SnowflakeGenerator) millisecond() (int64, error) {
+	now := s.config.Clock.Now().Sub(s.config.Epoch).Milliseconds()
+	if now < s.last {
+		return 0, fmt.Errorf("%w: %v behind the last ID", ErrClockBackwards,
+			time.Duration(s.last-now)*time.Millisecond)
+	}
+	if now < 0 {
+		return 0, fmt.Errorf("%w: clock %v before the epoch %v", ErrClockBackwards,
+			s.config.Clock.Now(), s.config.Epoch)
+	}
+	return now, nil
+}
+
+// Decompose splits an ID of the generator into the time, the worker ID and the sequence number it was made of
+func (s *SnowflakeGenerator) Decompose(id int64) (at time.Time, worker, sequence int64) {
+	sequence = id & (1<<s.config.SequenceBits - 1)
+	worker = id >> s.config.SequenceBits & (1<<s.config.WorkerBits - 1)
+	milliseconds := id >> (s.config.WorkerBits + s.config.SequenceBits)
+	return s.config.Epoch.Add(time.Duration(milliseconds) * time.Millisecond), worker, sequence
+}
+
+// Close stops the generator and frees its worker ID to the Allocator of NewSnowflakeGeneratorFrom, if any,
+// so that another generator may take it. It returns the error of freeing it, closing twice does nothing.
+func (s *SnowflakeGenerator) Close() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.workers != nil {
+		return s.workers.FreeID(s.worker)
+	}
+	return nil
+}
@@ -0,0 +1,250 @@
+package idgenerator
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+var snowflakeEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestSnowflakeGenerator(t *testing.T) {
+	clock := newFakeClock()
+	config := SnowflakeConfig{Epoch: snowflakeEpoch, Clock: clock}
+	generator, err := NewSnowflakeGenerator(config, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var last int64
+	for i := int64(0); i < 3; i++ {
+		id, err := generator.NextID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id <= last {
+			t.Errorf("expected an ID above %d, output %d", last, id)
+		}
+		last = id
+		if at, worker, sequence := generator.Decompose(id); !at.Equal(clock.Now()) || worker != 5 || sequence != i {
+			t.Errorf("unexpected parts of %d: %v, worker %d, sequence %d", id, at, worker, sequence)
+		}
+	}
+	clock.Advance(time.Millisecond)
+	id, err := generator.NextID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, sequence := generator.Decompose(id); id <= last || sequence != 0 {
+		t.Errorf("expected the sequence to restart above %d, output %d, sequence %d", last, id, sequence)
+	}
+
+	// a clock going backwards is an error, not a duplicate
+	clock.Advance(-time.Second)
+	if _, err = generator.NextID(); !errors.Is(err, ErrClockBackwards) {
+		t.Errorf("expected ErrClockBackwards, got %+v", err)
+	}
+	clock.Advance(time.Second)
+	if next, err := generator.NextID(); err != nil || next <= id {
+		t.Errorf("expected an ID above %d once the clock caught up, output %d, %+v", id, next, err)
+	}
+
+	if err = generator.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = generator.NextID(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed after Close, got %+v", err)
+	}
+}
+
+func TestSnowflakeSequenceOverflow(t *testing.T) {
+	// 4 IDs per millisecond, NextID has to wait for the next ones
+	clock := newFakeClock()
+	generator, err := NewSnowflakeGenerator(SnowflakeConfig{
+		Epoch: snowflakeEpoch, TimestampBits: 41, WorkerBits: 4, SequenceBits: 2, Clock: clock,
+	}, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(chan int64, 40)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(ids)
+		for i := 0; i < 40; i++ {
+			id, err := generator.NextID()
+			if err != nil {
+				errs <- err
+				return
+			}
+			ids <- id
+		}
+	}()
+	// every 4 IDs NextID waits for the next millisecond
+	for i := 0; i < 9; i++ {
+		clock.waitForTimers(1)
+		clock.Advance(time.Millisecond)
+	}
+	perMillisecond := make(map[time.Time]int)
+	var last int64
+	for id := range ids {
+		if id <= last {
+			t.Fatalf("expected an ID above %d, output %d", last, id)
+		}
+		last = id
+		at, _, _ := generator.Decompose(id)
+		perMillisecond[at]++
+	}
+	select {
+	case err = <-errs:
+		t.Fatal(err)
+	default:
+	}
+	if len(perMillisecond) != 10 {
+		t.Errorf("expected 10 milliseconds, output %d", len(perMillisecond))
+	}
+	for at, n := range perMillisecond {
+		if n != 4 {
+			t.Errorf("%d IDs in the millisecond of %v", n, at)
+		}
+	}
+}
+
+func TestSnowflakeConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		config   SnowflakeConfig
+		worker   int64
+		sentinel error
+	}{
+		{"no epoch", SnowflakeConfig{}, 0, ErrInvalidRange},
+		{
+			"too wide",
+			SnowflakeConfig{Epoch: snowflakeEpoch, TimestampBits: 42, WorkerBits: 10, SequenceBits: 12},
+			0,
+			ErrInvalidRange,
+		},
+		{"no sequence", SnowflakeConfig{Epoch: snowflakeEpoch, TimestampBits: 41, WorkerBits: 10}, 0, ErrInvalidRange},
+		{"worker too high", SnowflakeConfig{Epoch: snowflakeEpoch}, 1024, ErrOutOfRange},
+		{"negative worker", SnowflakeConfig{Epoch: snowflakeEpoch}, -1, ErrOutOfRange},
+	}
+	for _, testCase := range testCases {
+		if _, err := NewSnowflakeGenerator(testCase.config, testCase.worker); !errors.Is(err, testCase.sentinel) {
+			t.Errorf("%s: expected %v, got %+v", testCase.name, testCase.sentinel, err)
+		}
+	}
+
+	// the timestamp bits run out
+	clock := newFakeClock()
+	generator, err := NewSnowflakeGenerator(SnowflakeConfig{
+		Epoch: clock.Now(), TimestampBits: 2, WorkerBits: 1, SequenceBits: 1, Clock: clock,
+	}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock.Advance(4 * time.Millisecond)
+	if _, err = generator.NextID(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	// as the clock before the epoch
+	clock.Advance(-time.Second)
+	if _, err = generator.NextID(); !errors.Is(err, ErrClockBackwards) {
+		t.Errorf("expected ErrClockBackwards, got %+v", err)
+	}
+}
+
+func TestSnowflakeWorkers(t *testing.T) {
+	workers := NewGenerator(0, 1)
+	config := SnowflakeConfig{Epoch: snowflakeEpoch, TimestampBits: 41, WorkerBits: 1, SequenceBits: 12}
+	first, err := NewSnowflakeGeneratorFrom(config, workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := NewSnowflakeGeneratorFrom(config, workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Worker() == second.Worker() {
+		t.Errorf("expected distinct workers, both %d", first.Worker())
+	}
+	if _, err = NewSnowflakeGeneratorFrom(config, workers); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+	// Close gives the worker ID back
+	if err = first.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = first.Close(); err != nil {
+		t.Errorf("expected closing twice to do nothing, got %+v", err)
+	}
+	third, err := NewSnowflakeGeneratorFrom(config, workers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if third.Worker() != first.Worker() {
+		t.Errorf("expected worker %d again, output %d", first.Worker(), third.Worker())
+	}
+
+	// a worker ID which does not fit is freed again
+	wide := NewGenerator(2, 3)
+	if _, err = NewSnowflakeGeneratorFrom(config, wide); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if used := wide.Used(); used != 0 {
+		t.Errorf("expected the worker ID freed, used %d", used)
+	}
+}
+
+// TestSnowflakeUnique generates IDs from several workers at once, with several goroutines each,
+// and checks that the IDs are increasing for each goroutine and unique overall
+func TestSnowflakeUnique(t *testing.T) {
+	const workers, routines = 4, 2
+	perRoutine := 500000
+	if testing.Short() {
+		perRoutine = 20000
+	}
+	allocator := NewGenerator(0, 1023)
+	var wg sync.WaitGroup
+	results := make([][]int64, workers*routines)
+	for w := 0; w < workers; w++ {
+		generator, err := NewSnowflakeGeneratorFrom(SnowflakeConfig{Epoch: snowflakeEpoch}, allocator)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for r := 0; r < routines; r++ {
+			wg.Add(1)
+			go func(ids *[]int64) {
+				defer wg.Done()
+				*ids = make([]int64, 0, perRoutine)
+				for i := 0; i < perRoutine; i++ {
+					id, err := generator.NextID()
+					if err != nil {
+						t.Error(err)
+						return
+					}
+					*ids = append(*ids, id)
+				}
+			}(&results[w*routines+r])
+		}
+	}
+	wg.Wait()
+
+	all := make([]int64, 0, workers*routines*perRoutine)
+	for i, ids := range results {
+		for j := 1; j < len(ids); j++ {
+			if ids[j] <= ids[j-1] {
+				t.Fatalf("goroutine %d: ID %d after %d", i, ids[j], ids[j-1])
+			}
+		}
+		all = append(all, ids...)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i] < all[j] })
+	for i := 1; i < len(all); i++ {
+		if all[i] == all[i-1] {
+			t.Fatalf("ID %d generated twice", all[i])
+		}
+	}
+	if len(all) != workers*routines*perRoutine {
+		t.Errorf("expected %d IDs, output %d", workers*routines*perRoutine, len(all))
+	}
+}
@@ -0,0 +1,152 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math/bits"
+	"time"
+)
+
+// Split partitions the range of the generator into n children with disjoint consecutive ranges,
+// so that n workers may allocate without sharing a lock, and Join returns the state of each child to it.
+// The ranges are cut so that the children have about the same number of free IDs rather than the same size:
+// splitting a nearly full generator gives children of uneven sizes, some without any free ID if fewer than n are.
+// The IDs the generator holds, allocated, excluded or quarantined, stay its own:
+// the child covering one holds it like an excluded ID, which it neither allocates nor frees.
+// The children have the options of the generator except the hooks of WithOnAllocate and WithOnFree,
+// the observer of WithHoldTimes, the watermarks, the rate limit, the audit and the quotas,
+// and their counters start at zero.
+// The generator must not be used for anything but Join until every child is joined, its state is stale until then,
+// and Split fails with an error wrapping ErrRangeInUse while children are live.
+// It returns an error wrapping ErrInvalidRange if n < 1 or the range has fewer than n IDs.
+func (idGenerator *IDGenerator) Split(n int) ([]*IDGenerator, error) {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return nil, err
+	}
+	if n < 1 || uint64(n)-1 > idGenerator.lastOffset {
+		return nil, fmt.Errorf("%w: cannot split [%d, %d] into %d generators", ErrInvalidRange,
+			idGenerator.minValue, idGenerator.maxValue, n)
+	}
+	if len(idGenerator.children) > 0 {
+		return nil, fmt.Errorf("%w: [%d, %d] is split into %d generators not joined yet", ErrRangeInUse,
+			idGenerator.minValue, idGenerator.maxValue, len(idGenerator.children))
+	}
+
+	free := idGenerator.availableLocked()
+	children := make([]*IDGenerator, n)
+	first := uint64(0)
+	for i := range children {
+		last := idGenerator.lastOffset
+		if i < n-1 {
+			last = idGenerator.splitPointLocked(free, i, n, first)
+		}
+		child, err := idGenerator.childLocked(first, last)
+		if err != nil {
+			return nil, err
+		}
+		children[i] = child
+		first = last + 1
+	}
+	idGenerator.children = make(map[*IDGenerator]struct{}, n)
+	for _, child := range children {
+		idGenerator.children[child] = struct{}{}
+	}
+	return children, nil
+}
+
+// splitPointLocked returns the last offset of child i of n of Split, which starts at first,
+// so that children 0 to i have about (i+1)/n of the free offsets. The caller must hold lock.
+func (idGenerator *IDGenerator) splitPointLocked(free uint64, i, n int, first uint64) uint64 {
+	// free*(i+1)/n, whose product may not fit in an uint64
+	hi, lo := bits.Mul64(free, uint64(i+1))
+	k, _ := bits.Div64(hi, lo, uint64(n))
+	last := first
+	if k > 0 {
+		if offset := idGenerator.store.NthClear(k - 1); offset > last {
+			last = offset
+		}
+	}
+	// every child after this one needs an offset
+	if limit := idGenerator.lastOffset - uint64(n-1-i); last > limit {
+		last = limit
+	}
+	return last
+}
+
+// childLocked returns a child of Split over the offsets [first, last] of the generator,
+// holding the offsets set in its store as excluded. The caller must hold lock.
+func (idGenerator *IDGenerator) childLocked(first, last uint64) (*IDGenerator, error) {
+	child := &IDGenerator{
+		logger:      idGenerator.logger,
+		filter:      idGenerator.filter,
+		band:        idGenerator.band,
+		fairWaiting: idGenerator.fairWaiting,
+		sticky:      idGenerator.sticky.settings(),
+		eventLog:    idGenerator.eventLog,
+		name:        idGenerator.name,
+		minValue:    idGenerator.toID(first),
+		maxValue:    idGenerator.toID(last),
+		lastOffset:  last - first,
+		step:        stepping{modulus: idGenerator.step.modulus},
+		newStore:    idGenerator.newStore,
+		reuseDelay:  idGenerator.reuseDelay,
+		clock:       idGenerator.clock,
+		strategy:    idGenerator.strategy,
+		random:      idGenerator.random,
+		formatter:   idGenerator.formatter,
+		uuids:       idGenerator.uuids,
+	}
+	store, err := child.newStore(child.lastOffset)
+	if err != nil {
+		return nil, fmt.Errorf("%w: [%d, %d]: %v", ErrInvalidRange, child.minValue, child.maxValue, err)
+	}
+	child.store = store
+	for offset, ok := idGenerator.store.NextSet(first); ok && offset <= last; {
+		if child.excluded == nil {
+			child.excluded = make(map[uint64]struct{})
+		}
+		child.excluded[offset-first] = struct{}{}
+		if offset == last {
+			break
+		}
+		offset, ok = idGenerator.store.NextSet(offset + 1)
+	}
+	child.setExcluded(store)
+	child.generations = idGenerator.movedGenerationsLocked(child)
+	if idGenerator.refs != nil {
+		child.refs = make(map[uint64]uint64)
+	}
+	if idGenerator.heldSince != nil {
+		child.heldSince = make(map[uint64]time.Time)
+	}
+	if idGenerator.recycling != nil {
+		child.recycling = &recycler{order: idGenerator.recycling.order}
+		child.resetRecyclingLocked()
+	}
+	return child, nil
+}
+
+// Join returns the state of child, one of the generators of Split, to the generator:
+// the IDs allocated by child are allocated in the generator with their leases, owners, references, generations
+// and hold times, the IDs it quarantined are quarantined and its counters are added to those of the generator.
+// The hooks of the generator are not called for them. child must not be used after Join.
+// The generator is back to normal once every child is joined.
+// It returns an error wrapping ErrNotChild if child is not a child of Split which is not joined yet.
+func (idGenerator *IDGenerator) Join(child *IDGenerator) error {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if _, ok := idGenerator.children[child]; !ok {
+		return fmt.Errorf("%w: [%d, %d] of [%d, %d]", ErrNotChild, child.minValue, child.maxValue,
+			idGenerator.minValue, idGenerator.maxValue)
+	}
+	child.lock.Lock()
+	child.expireLeasesLocked()
+	defer child.unlock()
+
+	idGenerator.absorbLocked(child)
+	delete(idGenerator.children, child)
+	return nil
+}
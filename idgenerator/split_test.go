@@ -0,0 +1,145 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSplit(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithExclusions(1, 100, []int64{100}, WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 10)
+	if err = idGenerator.AllocateSpecific(50); err != nil {
+		t.Fatal(err)
+	}
+
+	children, err := idGenerator.Split(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the 88 free IDs are shared out evenly, the allocated ones go along with the free ones around them
+	expected := [][3]int64{{1, 39, 29}, {40, 69, 29}, {70, 100, 30}}
+	for i, child := range children {
+		minValue, maxValue := child.bounds()
+		if output := [3]int64{minValue, maxValue, child.Available()}; output != expected[i] {
+			t.Errorf("child %d: expected [min, max, available] %v, output %v", i, expected[i], output)
+		}
+	}
+
+	// the IDs of the parent are held by the child covering them
+	if err = children[0].FreeID(5); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	if err = children[1].AllocateSpecific(50); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	if id, err := children[0].Allocate(); err != nil || id != 11 {
+		t.Errorf("expected ID 11, output %d, %+v", id, err)
+	}
+	lease, err := children[1].AllocateLease(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := allocateN(t, children[2], 30)
+	freeAll(t, children[2], ids[0])
+
+	if _, err = idGenerator.Split(2); !errors.Is(err, ErrRangeInUse) {
+		t.Errorf("expected ErrRangeInUse while split, got %+v", err)
+	}
+	for _, child := range children {
+		if err = idGenerator.Join(child); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err = idGenerator.Join(children[0]); !errors.Is(err, ErrNotChild) {
+		t.Errorf("expected ErrNotChild joining twice, got %+v", err)
+	}
+
+	// 11 of the parent, 11 and the lease of the children and 29 of the last one, whose first ID is quarantined
+	if used, quarantined := idGenerator.Used(), idGenerator.Quarantined(); used != 42 || quarantined != 1 {
+		t.Errorf("expected 42 used and 1 quarantined, output %d and %d", used, quarantined)
+	}
+	for _, id := range []int64{5, 11, 50, lease, ids[1]} {
+		if !idGenerator.IsAllocated(id) {
+			t.Errorf("expected ID %d allocated after Join", id)
+		}
+	}
+	if err = idGenerator.AllocateSpecific(ids[0]); !errors.Is(err, ErrQuarantined) {
+		t.Errorf("expected ErrQuarantined, got %+v", err)
+	}
+	if stats := idGenerator.Stats(); stats.Allocations != 43 || stats.Frees != 1 {
+		t.Errorf("unexpected stats: %#v", stats)
+	}
+	clock.Advance(2 * time.Hour)
+	if used, quarantined := idGenerator.Used(), idGenerator.Quarantined(); used != 41 || quarantined != 1 {
+		t.Errorf("expected the lease expired, output %d used and %d quarantined", used, quarantined)
+	}
+
+	// the parent is back to normal, it may be split again
+	if _, err = idGenerator.Split(2); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSplitNearlyFull(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	allocateN(t, idGenerator, 9)
+	children, err := idGenerator.Split(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranges [][2]int64
+	available := int64(0)
+	for _, child := range children {
+		minValue, maxValue := child.bounds()
+		ranges = append(ranges, [2]int64{minValue, maxValue})
+		available += child.Available()
+	}
+	if expected := [][2]int64{{1, 1}, {2, 2}, {3, 3}, {4, 10}}; !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("expected ranges %v, output %v", expected, ranges)
+	}
+	if available != 1 {
+		t.Errorf("expected one free ID across the children, output %d", available)
+	}
+	if id, err := children[3].Allocate(); err != nil || id != 10 {
+		t.Errorf("expected ID 10, output %d, %+v", id, err)
+	}
+}
+
+func TestSplitFullRange(t *testing.T) {
+	idGenerator := NewGenerator(math.MinInt64, math.MaxInt64)
+	children, err := idGenerator.Split(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	firstMin, firstMax := children[0].bounds()
+	secondMin, secondMax := children[1].bounds()
+	if firstMin != math.MinInt64 || secondMax != math.MaxInt64 || firstMax+1 != secondMin {
+		t.Errorf("unexpected ranges [%d, %d] and [%d, %d]", firstMin, firstMax, secondMin, secondMax)
+	}
+}
+
+func TestSplitErrors(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	for _, n := range []int{0, -1, 11} {
+		if _, err := idGenerator.Split(n); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("Split(%d): expected ErrInvalidRange, got %+v", n, err)
+		}
+	}
+	if err := idGenerator.Join(NewGenerator(1, 5)); !errors.Is(err, ErrNotChild) {
+		t.Errorf("expected ErrNotChild, got %+v", err)
+	}
+	children, err := idGenerator.Split(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = NewGenerator(1, 10).Join(children[0]); !errors.Is(err, ErrNotChild) {
+		t.Errorf("expected ErrNotChild joining to another generator, got %+v", err)
+	}
+}
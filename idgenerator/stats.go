@@ -0,0 +1,162 @@
+package idgenerator
+
+import (
+	"fmt"
+	"math"
+	"sync/atomic"
+	"time"
+)
+
+// Stats are the usage counters of an IDGenerator. Those up to PeakAt are cheap enough to be read
+// on every health check, Stats returns them only; the fields from LargestFreeBlock on walk the IDs
+// or the owners, they are filled by DetailedStats only.
+// MinValue and MaxValue are the bounds of the range, Offset is where the next sequential Allocate starts
+// searching, relative to MinValue. Free is the number of IDs which can still be allocated, capped at math.MaxUint64,
+// Quarantined the number of freed IDs waiting for the delay of WithReuseDelay, which are neither used nor free.
+// Reserved is the number of the used IDs reserved by Reserve, neither committed nor cancelled yet.
+// Permanent is the number of the used IDs marked by MarkPermanent, Used less Permanent is the dynamic usage.
+// Allocations and Frees count IDs, including those allocated by AllocateMany or freed by Reset,
+// AllocationFailures counts the calls which found no free ID or failed to read the random source,
+// AllocationRetries the retries of AllocateRetry.
+// The totals only grow, restoring a state into the generator does not change them.
+// References is the number of references to the used IDs, one each plus those added by Acquire.
+// Strategy is the allocation strategy, one of the Strategy constants.
+// Capacity is the size of the range less the excluded IDs, capped at math.MaxUint64 for the full int64 range.
+// PeakUsed is the high-water mark of Used, reached at PeakAt, see HighWaterMark; the sum of generators
+// adds up their marks, an upper bound of the mark of the sum, and keeps the latest PeakAt.
+// LargestFreeBlock and FreeFragments are those of LargestFreeBlock and FragmentCount, which take a step
+// per free block; the sum keeps the largest block and adds up the fragments,
+// which does not join the blocks at the border of two ranges.
+// MeanHoldTime and MaxHoldTime are the mean and the longest hold time of the used IDs, see WithHoldTimes,
+// which take a step per used ID, 0 without it; the sum weights the means by Used and keeps the longest.
+// Owners are the counters of the owners of AllocateFor holding IDs or with a quota of SetQuota,
+// nil if none; the sum adds them up by owner.
+// PriorityUsed and PriorityFree are the used and free IDs of the priority band of WithPriorityBand,
+// which take a step per ID set in it, 0 without one; they are part of Used and Free as well.
+type Stats struct {
+	MinValue           int64                 `json:"minValue"`
+	MaxValue           int64                 `json:"maxValue"`
+	Strategy           string                `json:"strategy"`
+	Used               int64                 `json:"used"`
+	References         uint64                `json:"references"`
+	Free               uint64                `json:"free"`
+	Quarantined        uint64                `json:"quarantined"`
+	Reserved           uint64                `json:"reserved"`
+	Permanent          uint64                `json:"permanent"`
+	Capacity           uint64                `json:"capacity"`
+	Offset             uint64                `json:"offset"`
+	Allocations        uint64                `json:"allocations"`
+	Frees              uint64                `json:"frees"`
+	AllocationFailures uint64                `json:"allocationFailures"`
+	AllocationRetries  uint64                `json:"allocationRetries"`
+	PeakUsed           int64                 `json:"peakUsed"`
+	PeakAt             time.Time             `json:"peakAt"`
+	LargestFreeBlock   uint64                `json:"largestFreeBlock"`
+	FreeFragments      uint64                `json:"freeFragments"`
+	MeanHoldTime       time.Duration         `json:"meanHoldTime"`
+	MaxHoldTime        time.Duration         `json:"maxHoldTime"`
+	Owners             map[string]OwnerStats `json:"owners,omitempty"`
+	PriorityUsed       int64                 `json:"priorityUsed"`
+	PriorityFree       uint64                `json:"priorityFree"`
+}
+
+// Stats returns the current counters of the generator, read without walking the IDs or the owners:
+// LargestFreeBlock, FreeFragments, MeanHoldTime, MaxHoldTime, Owners, PriorityUsed and PriorityFree
+// are left at 0, see DetailedStats
+func (idGenerator *IDGenerator) Stats() Stats {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	return idGenerator.countersLocked()
+}
+
+// DetailedStats returns the counters of Stats and the fields walking the IDs or the owners,
+// which hold the read lock for a step per free block, used ID and owner, e.g. for a debug endpoint
+func (idGenerator *IDGenerator) DetailedStats() Stats {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	stats := idGenerator.countersLocked()
+	stats.LargestFreeBlock, stats.FreeFragments = idGenerator.fragmentationLocked()
+	stats.MeanHoldTime, stats.MaxHoldTime = idGenerator.holdTimesLocked()
+	stats.Owners = idGenerator.ownerStatsLocked()
+	stats.PriorityUsed, stats.PriorityFree = idGenerator.bandStatsLocked()
+	return stats
+}
+
+// countersLocked returns the Stats read from the counters, without those walking the IDs or the owners,
+// the caller must hold lock
+func (idGenerator *IDGenerator) countersLocked() Stats {
+	return Stats{
+		MinValue:           idGenerator.minValue,
+		MaxValue:           idGenerator.maxValue,
+		Strategy:           idGenerator.strategyName(),
+		Used:               clampInt64(idGenerator.used),
+		References:         idGenerator.used + idGenerator.extraRefs,
+		Free:               idGenerator.availableLocked(),
+		Quarantined:        uint64(len(idGenerator.quarantined)),
+		Reserved:           uint64(len(idGenerator.reservations)),
+		Permanent:          uint64(len(idGenerator.permanent)),
+		Capacity:           idGenerator.capacityLocked(),
+		Offset:             idGenerator.offset,
+		Allocations:        idGenerator.allocations,
+		Frees:              idGenerator.frees,
+		AllocationFailures: atomic.LoadUint64(&idGenerator.allocateFailures),
+		AllocationRetries:  atomic.LoadUint64(&idGenerator.allocateRetries),
+		PeakUsed:           clampInt64(idGenerator.peakUsed),
+		PeakAt:             idGenerator.peakAt,
+	}
+}
+
+// capacityLocked returns the size of the range less the excluded IDs, capped at math.MaxUint64,
+// the caller must hold lock
+func (idGenerator *IDGenerator) capacityLocked() uint64 {
+	capacity := idGenerator.lastOffset - uint64(len(idGenerator.excluded)) + 1
+	if capacity == 0 && len(idGenerator.excluded) == 0 {
+		// the full int64 range
+		return math.MaxUint64
+	}
+	return capacity
+}
+
+// String returns a one line summary of stats, such as "idgenerator[100-200]: used=37/101 offset=52 fails=3"
+func (stats Stats) String() string {
+	return fmt.Sprintf("idgenerator[%d-%d]: used=%d/%d offset=%d fails=%d",
+		stats.MinValue, stats.MaxValue, stats.Used, stats.Capacity, stats.Offset, stats.AllocationFailures)
+}
+
+// add adds the counters of other to stats, saturating Free and Capacity at math.MaxUint64
+func (stats *Stats) add(other Stats) {
+	stats.Strategy = other.Strategy
+	if used := stats.Used + other.Used; used > 0 {
+		stats.MeanHoldTime = time.Duration((float64(stats.MeanHoldTime)*float64(stats.Used) +
+			float64(other.MeanHoldTime)*float64(other.Used)) / float64(used))
+	}
+	if other.MaxHoldTime > stats.MaxHoldTime {
+		stats.MaxHoldTime = other.MaxHoldTime
+	}
+	stats.Used += other.Used
+	stats.References += other.References
+	if stats.Free += other.Free; stats.Free < other.Free {
+		stats.Free = math.MaxUint64
+	}
+	stats.Quarantined += other.Quarantined
+	stats.Reserved += other.Reserved
+	stats.Permanent += other.Permanent
+	if stats.Capacity += other.Capacity; stats.Capacity < other.Capacity {
+		stats.Capacity = math.MaxUint64
+	}
+	stats.Allocations += other.Allocations
+	stats.Frees += other.Frees
+	stats.AllocationFailures += other.AllocationFailures
+	stats.AllocationRetries += other.AllocationRetries
+	stats.PeakUsed += other.PeakUsed
+	if other.PeakAt.After(stats.PeakAt) {
+		stats.PeakAt = other.PeakAt
+	}
+	if other.LargestFreeBlock > stats.LargestFreeBlock {
+		stats.LargestFreeBlock = other.LargestFreeBlock
+	}
+	stats.FreeFragments += other.FreeFragments
+	stats.Owners = addOwners(stats.Owners, other.Owners)
+	stats.PriorityUsed += other.PriorityUsed
+	stats.PriorityFree += other.PriorityFree
+}
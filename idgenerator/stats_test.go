@@ -0,0 +1,124 @@
+package idgenerator
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStats(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := idGenerator.AllocateMany(8); err != nil {
+		t.Fatal(err)
+	}
+	if err := idGenerator.FreeID(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := idGenerator.AllocateContiguous(3); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	if _, err := idGenerator.AllocateMany(4); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	// conflicts are not counted as failures
+	if err := idGenerator.AllocateSpecific(1); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	expected := Stats{
+		MinValue: 1, MaxValue: 10, Strategy: StrategySequential, Used: 7, References: 7, Free: 3, Capacity: 10, Offset: 8,
+		Allocations: 8, Frees: 1, AllocationFailures: 2, PeakUsed: 8, PeakAt: clock.Now(),
+		LargestFreeBlock: 2, FreeFragments: 2,
+	}
+	if stats := idGenerator.DetailedStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+	// Stats leaves the fields walking the free blocks at 0
+	expected.LargestFreeBlock, expected.FreeFragments = 0, 0
+	if stats := idGenerator.Stats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+	}
+
+	idGenerator.Reset()
+	expected = Stats{
+		MinValue: 1, MaxValue: 10, Strategy: StrategySequential, Used: 0, Free: 10, Capacity: 10,
+		Allocations: 8, Frees: 8, AllocationFailures: 2, PeakUsed: 8, PeakAt: clock.Now(),
+		LargestFreeBlock: 10, FreeFragments: 1,
+	}
+	if stats := idGenerator.DetailedStats(); !reflect.DeepEqual(stats, expected) {
+		t.Errorf("expected stats after Reset: %#v, output stats: %#v", expected, stats)
+	}
+
+	t.Run("concurrent", func(t *testing.T) {
+		idGenerator := NewGenerator(1, 4)
+
+		wg := sync.WaitGroup{}
+		var mtx sync.Mutex
+		var allocations, failures uint64
+		for routineID := 0; routineID < 8; routineID++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 1000; i++ {
+					id, err := idGenerator.Allocate()
+					mtx.Lock()
+					if err != nil {
+						failures++
+					} else {
+						allocations++
+					}
+					mtx.Unlock()
+					if err == nil {
+						if err = idGenerator.FreeID(id); err != nil {
+							t.Errorf("idGenerator.FreeID fail: %+v", err)
+						}
+					}
+				}
+			}()
+		}
+		wg.Wait()
+
+		expected := Stats{
+			MinValue:           1,
+			MaxValue:           4,
+			Strategy:           StrategySequential,
+			Free:               4,
+			Capacity:           4,
+			Allocations:        allocations,
+			Frees:              allocations,
+			AllocationFailures: failures,
+			LargestFreeBlock:   4,
+			FreeFragments:      1,
+		}
+		stats := idGenerator.DetailedStats()
+		// where the scan stopped and the peak depend on the interleaving
+		stats.Offset, stats.PeakUsed, stats.PeakAt = 0, 0, time.Time{}
+		if !reflect.DeepEqual(stats, expected) {
+			t.Errorf("expected stats: %#v, output stats: %#v", expected, stats)
+		}
+	})
+}
+
+func TestStatsString(t *testing.T) {
+	idGenerator := NewGenerator(100, 200)
+	if _, err := idGenerator.AllocateMany(52); err != nil {
+		t.Fatal(err)
+	}
+	for id := int64(100); id < 115; id++ {
+		if err := idGenerator.FreeID(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := idGenerator.AllocateContiguous(80); err == nil {
+		t.Fatal("expect return error, but error is nil")
+	}
+	expected := "idgenerator[100-200]: used=37/101 offset=52 fails=1"
+	if s := idGenerator.Stats().String(); s != expected {
+		t.Errorf("expected %q, output %q", expected, s)
+	}
+}
@@ -0,0 +1,106 @@
+package idgenerator
+
+import "fmt"
+
+// WithStep makes the generator allocate only the IDs with (id - minValue) % modulus == remainder,
+// e.g. the even IDs of the range with WithStep(2, 0) and the odd ones with WithStep(2, 1), so that two generators
+// of complementary remainders over the same range never hand out the same ID.
+// The other IDs are not IDs of the generator: Capacity, Available and Stats count only the IDs of the step,
+// AllocateSpecific, FreeID and the range methods fail with ErrOutOfRange for the others,
+// and Allocate goes from one ID of the step to the next rather than scanning those in between.
+// The blocks of FreeBlocks, AllocateContiguous and AllocateAligned are runs of consecutive IDs of the step,
+// whose alignment is counted in IDs of the step.
+// Resize, Merge and the range provider of WithRangeProvider keep the IDs of the step, Merge fails with
+// ErrInvalidRange for a generator of other steps; a Snapshot records the IDs, not the step.
+// NewGeneratorWithOptions fails with ErrInvalidRange if modulus is negative, remainder is not in
+// [0, modulus - 1] or the range has no ID of the step. A modulus of 1 allocates every ID, the default.
+// Each generator of a MultiRangeIDGenerator or ShardedIDGenerator steps from its own minValue.
+func WithStep(modulus, remainder int64) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.step = stepping{modulus: modulus, remainder: remainder}
+	}
+}
+
+// stepping maps the offsets of a generator to the IDs of WithStep, offset k is the ID
+// minValue + remainder + k*modulus. The zero stepping, or a modulus of 1, maps them to every ID.
+type stepping struct {
+	modulus   int64
+	remainder int64
+}
+
+func (s stepping) validate() error {
+	if s.modulus < 0 || s.modulus > 0 && (s.remainder < 0 || s.remainder >= s.modulus) ||
+		s.modulus == 0 && s.remainder != 0 {
+		return fmt.Errorf("%w: invalid step of modulus %d and remainder %d", ErrInvalidRange, s.modulus, s.remainder)
+	}
+	return nil
+}
+
+// lastOffset returns the last offset of [minValue, maxValue], false if the range has no ID of the step
+func (s stepping) lastOffset(minValue, maxValue int64) (uint64, bool) {
+	span := uint64(maxValue) - uint64(minValue)
+	if s.modulus <= 1 {
+		return span, true
+	}
+	if span < uint64(s.remainder) {
+		return 0, false
+	}
+	return (span - uint64(s.remainder)) / uint64(s.modulus), true
+}
+
+// contains reports whether id, in [minValue, maxValue], is an ID of the step
+func (s stepping) contains(minValue, id int64) bool {
+	return s.modulus <= 1 || (uint64(id)-uint64(minValue))%uint64(s.modulus) == uint64(s.remainder)
+}
+
+func (s stepping) toOffset(minValue, id int64) uint64 {
+	offset := uint64(id) - uint64(minValue)
+	if s.modulus <= 1 {
+		return offset
+	}
+	return (offset - uint64(s.remainder)) / uint64(s.modulus)
+}
+
+func (s stepping) toID(minValue int64, offset uint64) int64 {
+	if s.modulus <= 1 {
+		return int64(uint64(minValue) + offset)
+	}
+	return int64(uint64(minValue) + uint64(s.remainder) + offset*uint64(s.modulus))
+}
+
+// ceilOffset returns the offset of the lowest ID of the step from id, in [minValue, maxValue], on.
+// It may be past the last offset.
+func (s stepping) ceilOffset(minValue, id int64) uint64 {
+	offset := uint64(id) - uint64(minValue)
+	if s.modulus <= 1 {
+		return offset
+	}
+	if offset <= uint64(s.remainder) {
+		return 0
+	}
+	return (offset-uint64(s.remainder)-1)/uint64(s.modulus) + 1
+}
+
+// rebase returns the stepping from newMin over the same IDs as s from minValue, for a range moved by Resize
+func (s stepping) rebase(minValue, newMin int64) stepping {
+	if s.modulus <= 1 {
+		return s
+	}
+	modulus := uint64(s.modulus)
+	first := (stepClass(minValue, modulus) + uint64(s.remainder)) % modulus
+	return stepping{modulus: s.modulus, remainder: int64((first + modulus - stepClass(newMin, modulus)) % modulus)}
+}
+
+// sameIDs reports whether s from minValue and other from otherMin step over the same IDs
+func (s stepping) sameIDs(minValue int64, other stepping, otherMin int64) bool {
+	if s.modulus <= 1 || other.modulus <= 1 {
+		return s.modulus <= 1 && other.modulus <= 1
+	}
+	return s.modulus == other.modulus && other.rebase(otherMin, minValue).remainder == s.remainder
+}
+
+// stepClass returns id modulo modulus, in [0, modulus - 1] for a negative id as well
+func stepClass(id int64, modulus uint64) uint64 {
+	// flipping the sign bit adds 2^63 to id, which keeps the differences between the classes
+	return (uint64(id) ^ 1<<63) % modulus
+}
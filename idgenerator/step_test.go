@@ -0,0 +1,168 @@
+package idgenerator
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestStep(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			even, err := NewGeneratorWithOptions(0, 99, append(storeOption.opts, WithStep(2, 0))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			odd, err := NewGeneratorWithOptions(0, 99, append(storeOption.opts, WithStep(2, 1))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, idGenerator := range []*IDGenerator{even, odd} {
+				if capacity := idGenerator.Capacity(); capacity != 50 {
+					t.Errorf("expected a capacity of 50, got %d", capacity)
+				}
+			}
+
+			// the complementary generators share the range without colliding
+			seen := make(map[int64]bool)
+			for i, idGenerator := range []*IDGenerator{even, odd} {
+				for _, id := range allocateN(t, idGenerator, 50) {
+					if id%2 != int64(i) || seen[id] {
+						t.Fatalf("unexpected ID %d of the generator of remainder %d", id, i)
+					}
+					seen[id] = true
+				}
+				if available := idGenerator.Available(); available != 0 {
+					t.Errorf("expected no ID available, got %d", available)
+				}
+			}
+
+			// the sequential search wraps around on the IDs of the step
+			freeAll(t, even, 10, 90)
+			if ids := allocateN(t, even, 2); !reflect.DeepEqual(ids, []int64{10, 90}) {
+				t.Errorf("expected the freed IDs 10 and 90, got %v", ids)
+			}
+		})
+	}
+}
+
+func TestStepIDs(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(-10, 10, WithStep(3, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []int64{-9, -6, -3, 0, 3, 6, 9}
+	if capacity := idGenerator.Capacity(); capacity != int64(len(expected)) {
+		t.Errorf("expected a capacity of %d, got %d", len(expected), capacity)
+	}
+	for _, id := range []int64{-10, -8, 1, 10} {
+		if err = idGenerator.AllocateSpecific(id); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("AllocateSpecific(%d): expected ErrOutOfRange, got %v", id, err)
+		}
+		if err = idGenerator.FreeID(id); !errors.Is(err, ErrOutOfRange) {
+			t.Errorf("FreeID(%d): expected ErrOutOfRange, got %v", id, err)
+		}
+		if idGenerator.Contains(id) {
+			t.Errorf("expected %d not to be an ID of the generator", id)
+		}
+	}
+	if err = idGenerator.AllocateSpecific(3); err != nil {
+		t.Fatal(err)
+	}
+	if blocks, _, _ := idGenerator.FreeBlocksFrom(-1, 0); !reflect.DeepEqual(blocks, [][2]int64{{0, 0}, {6, 9}}) {
+		t.Errorf("expected the free blocks [[0 0] [6 9]] from -1, got %v", blocks)
+	}
+	if ids := allocateN(t, idGenerator, 6); !reflect.DeepEqual(ids, []int64{-9, -6, -3, 0, 6, 9}) {
+		t.Errorf("expected the IDs of the step, got %v", ids)
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %v", err)
+	}
+
+	multiples, err := NewGeneratorWithOptions(0, 1000, WithStep(16, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if capacity := multiples.Capacity(); capacity != 63 {
+		t.Errorf("expected 63 multiples of 16 in [0, 1000], got %d", capacity)
+	}
+	if ids := allocateN(t, multiples, 3); !reflect.DeepEqual(ids, []int64{0, 16, 32}) {
+		t.Errorf("expected the first multiples of 16, got %v", ids)
+	}
+}
+
+func TestStepInvalid(t *testing.T) {
+	testCases := []struct {
+		name               string
+		minValue, maxValue int64
+		modulus, remainder int64
+	}{
+		{"negative modulus", 0, 10, -2, 0},
+		{"remainder of zero modulus", 0, 10, 0, 1},
+		{"negative remainder", 0, 10, 2, -1},
+		{"remainder past modulus", 0, 10, 2, 2},
+		{"no ID of the step", 0, 2, 5, 4},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := NewGeneratorWithOptions(testCase.minValue, testCase.maxValue,
+				WithStep(testCase.modulus, testCase.remainder))
+			if !errors.Is(err, ErrInvalidRange) {
+				t.Errorf("expected ErrInvalidRange, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStepResizeMerge(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(0, 9, WithStep(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	// the odd IDs stay the IDs of the generator from an even minValue
+	if err = idGenerator.Resize(-6, 20); err != nil {
+		t.Fatal(err)
+	}
+	if capacity := idGenerator.Capacity(); capacity != 13 {
+		t.Errorf("expected the 13 odd IDs in [-6, 20], got %d", capacity)
+	}
+	if !idGenerator.IsAllocated(1) || !idGenerator.IsAllocated(3) {
+		t.Error("expected the allocated IDs kept")
+	}
+	for _, id := range allocateN(t, idGenerator, 11) {
+		if id%2 == 0 {
+			t.Errorf("allocated even ID %d", id)
+		}
+	}
+
+	even, err := NewGeneratorWithOptions(21, 29, WithStep(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Merge(even); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("expected ErrInvalidRange merging even IDs into odd ones, got %v", err)
+	}
+	odd, err := NewGeneratorWithOptions(21, 29, WithStep(2, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Merge(odd); err != nil {
+		t.Fatal(err)
+	}
+	if maxValue, capacity := idGenerator.MaxValue(), idGenerator.Capacity(); maxValue != 29 || capacity != 18 {
+		t.Errorf("expected 18 odd IDs up to 29, got %d up to %d", capacity, maxValue)
+	}
+
+	restored, err := RestoreGenerator(idGenerator.Snapshot(), WithStep(2, 1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Snapshot(), idGenerator.Snapshot()) {
+		t.Errorf("expected snapshot %+v, got %+v", idGenerator.Snapshot(), restored.Snapshot())
+	}
+	if _, err = RestoreGenerator(idGenerator.Snapshot(), WithStep(2, 0)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange restoring odd IDs with an even step, got %v", err)
+	}
+}
@@ -0,0 +1,211 @@
+package idgenerator
+
+import (
+	"container/list"
+	"time"
+)
+
+// defaultStickyBindings is the number of bindings AllocateSticky keeps without WithStickyBindings
+const defaultStickyBindings = 1 << 16
+
+// WithStickyBindings bounds the bindings of AllocateSticky: the binding of a freed ID is dropped retention after
+// the free, that of an allocated ID is kept, and the least recently used bindings are evicted beyond max
+// bindings. The IDs freed by Reset or by loading a snapshot, e.g. with UnmarshalJSON, are freed at the time
+// of the call. A retention of 0 or below keeps the bindings until they are evicted, a max of 0 or below
+// keeps the default of 65536 bindings.
+func WithStickyBindings(retention time.Duration, max int) Option {
+	return func(idGenerator *IDGenerator) {
+		if max <= 0 {
+			max = defaultStickyBindings
+		}
+		idGenerator.sticky = newStickyBindings(retention, max)
+	}
+}
+
+// stickyBindings are the key→ID bindings of AllocateSticky in LRU order, the front one used last.
+// The bindings are kept as IDs, so that they follow the range through Resize and loads, and byID makes
+// an ID bound to one key at most, the one which allocated it last.
+type stickyBindings struct {
+	retention time.Duration
+	max       int
+	keys      map[string]*list.Element
+	byID      map[int64]*list.Element
+	lru       *list.List
+}
+
+// stickyBinding is the ID bound to key, freedAt is the time of its last free, zero while the ID is allocated
+type stickyBinding struct {
+	key     string
+	id      int64
+	freedAt time.Time
+}
+
+func newStickyBindings(retention time.Duration, max int) *stickyBindings {
+	return &stickyBindings{
+		retention: retention,
+		max:       max,
+		keys:      make(map[string]*list.Element),
+		byID:      make(map[int64]*list.Element),
+		lru:       list.New(),
+	}
+}
+
+// AllocateSticky allocates the ID last allocated for key if it is still free, e.g. to give a reconnecting
+// subscriber the ID it had so that the caches downstream stay warm, or else an ID like Allocate, which key
+// is bound to from then on. The binding outlives the free of the ID, which is the point, until ForgetKey,
+// the retention of WithStickyBindings or its eviction; an ID bound to another key since,
+// excluded or quarantined is not free. It fails like Allocate.
+func (idGenerator *IDGenerator) AllocateSticky(key string) (int64, error) {
+	if err := idGenerator.limitRate(1); err != nil {
+		return 0, err
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	id, err := idGenerator.allocateStickyLocked(key)
+	idGenerator.unlock()
+	if err != nil {
+		idGenerator.refundRate(1)
+		idGenerator.allocateFailed(err)
+	}
+	return id, err
+}
+
+// allocateStickyLocked is AllocateSticky, the caller must hold lock
+func (idGenerator *IDGenerator) allocateStickyLocked(key string) (int64, error) {
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		return 0, err
+	}
+	if idGenerator.sticky == nil {
+		idGenerator.sticky = newStickyBindings(0, defaultStickyBindings)
+	}
+	now := idGenerator.clock.Now()
+	if b, ok := idGenerator.sticky.lookup(key, now); ok {
+		// excluded and quarantined offsets are set in store as well
+		if offset := idGenerator.toOffset(b.id); idGenerator.inRange(b.id) && !idGenerator.store.Has(offset) &&
+			(idGenerator.filter == nil || idGenerator.filter(b.id)) {
+			idGenerator.markUsed(offset)
+			idGenerator.sticky.bind(key, b.id, now)
+			return b.id, nil
+		}
+	}
+	id, err := idGenerator.allocateLocked()
+	if err != nil {
+		return 0, err
+	}
+	idGenerator.sticky.bind(key, id, now)
+	return id, nil
+}
+
+// StickyID returns the ID key is bound to by AllocateSticky, allocated or not, or false if it is bound to none
+func (idGenerator *IDGenerator) StickyID(key string) (int64, bool) {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	if idGenerator.sticky == nil {
+		return 0, false
+	}
+	b, ok := idGenerator.sticky.lookup(key, idGenerator.clock.Now())
+	if !ok {
+		return 0, false
+	}
+	return b.id, true
+}
+
+// ForgetKey drops the binding of key, so that its next AllocateSticky gets an ID like Allocate,
+// and reports whether key was bound. The ID bound stays allocated if it is.
+func (idGenerator *IDGenerator) ForgetKey(key string) bool {
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	defer idGenerator.unlock()
+	if idGenerator.sticky == nil {
+		return false
+	}
+	e, ok := idGenerator.sticky.keys[key]
+	if ok {
+		idGenerator.sticky.remove(e)
+	}
+	return ok
+}
+
+// lookup returns the binding of key, unless its retention is over, the caller must hold lock.
+// A lookup under the read lock of the generator leaves a binding past its retention for the next bind to drop.
+func (s *stickyBindings) lookup(key string, now time.Time) (*stickyBinding, bool) {
+	e, ok := s.keys[key]
+	if !ok {
+		return nil, false
+	}
+	b := e.Value.(*stickyBinding)
+	return b, !s.expired(b, now)
+}
+
+// expired reports whether the retention of b, whose ID may have been freed, is over at now
+func (s *stickyBindings) expired(b *stickyBinding, now time.Time) bool {
+	if s.retention <= 0 {
+		return false
+	}
+	return !b.freedAt.IsZero() && now.Sub(b.freedAt) > s.retention
+}
+
+// bind binds key to id, allocated, in place of the previous binding of either,
+// then drops the least recently used bindings past their retention or beyond max
+func (s *stickyBindings) bind(key string, id int64, now time.Time) {
+	if e, ok := s.keys[key]; ok {
+		s.remove(e)
+	}
+	if e, ok := s.byID[id]; ok {
+		s.remove(e)
+	}
+	e := s.lru.PushFront(&stickyBinding{key: key, id: id})
+	s.keys[key], s.byID[id] = e, e
+	for back := s.lru.Back(); back != e && (s.lru.Len() > s.max || s.expired(back.Value.(*stickyBinding), now)); {
+		s.remove(back)
+		back = s.lru.Back()
+	}
+}
+
+func (s *stickyBindings) remove(e *list.Element) {
+	b := s.lru.Remove(e).(*stickyBinding)
+	delete(s.keys, b.key)
+	delete(s.byID, b.id)
+}
+
+// freed notes the free of id, if it is bound, at the time of clock
+func (s *stickyBindings) freed(id int64, clock Clock) {
+	if e, ok := s.byID[id]; ok {
+		e.Value.(*stickyBinding).freedAt = clock.Now()
+	}
+}
+
+// stickyFreedLocked notes the free of the bound IDs not allocated any longer, e.g. after Reset,
+// the caller must hold lock
+func (idGenerator *IDGenerator) stickyFreedLocked() {
+	if idGenerator.sticky == nil {
+		return
+	}
+	now := idGenerator.clock.Now()
+	for e := idGenerator.sticky.lru.Front(); e != nil; e = e.Next() {
+		b := e.Value.(*stickyBinding)
+		offset := idGenerator.toOffset(b.id)
+		if b.freedAt.IsZero() &&
+			(!idGenerator.inRange(b.id) || !idGenerator.store.Has(offset) || idGenerator.isHeld(offset)) {
+			b.freedAt = now
+		}
+	}
+}
+
+// settings returns empty bindings with the retention and max of s, nil if s is
+func (s *stickyBindings) settings() *stickyBindings {
+	if s == nil {
+		return nil
+	}
+	return newStickyBindings(s.retention, s.max)
+}
+
+func (s *stickyBindings) clone() *stickyBindings {
+	clone := s.settings()
+	for e := s.lru.Back(); e != nil; e = e.Prev() {
+		b := *e.Value.(*stickyBinding)
+		copied := clone.lru.PushFront(&b)
+		clone.keys[b.key], clone.byID[b.id] = copied, copied
+	}
+	return clone
+}
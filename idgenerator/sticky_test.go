@@ -0,0 +1,143 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestAllocateSticky(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	first, err := idGenerator.AllocateSticky("imsi-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := idGenerator.AllocateSticky("imsi-2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the binding outlives the free
+	freeAll(t, idGenerator, first, other)
+	allocateN(t, idGenerator, 3)
+	if id, err := idGenerator.AllocateSticky("imsi-1"); err != nil || id != first {
+		t.Fatalf("expected the remembered ID %d, output %d, %+v", first, id, err)
+	}
+
+	// the remembered ID has been taken, the key is bound to a new one
+	freeAll(t, idGenerator, first)
+	if err = idGenerator.AllocateSpecific(first); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := idGenerator.AllocateSticky("imsi-1")
+	if err != nil || moved == first {
+		t.Fatalf("expected a new ID, output %d, %+v", moved, err)
+	}
+	if id, ok := idGenerator.StickyID("imsi-1"); !ok || id != moved {
+		t.Errorf("expected imsi-1 bound to %d, output %d, %v", moved, id, ok)
+	}
+
+	if !idGenerator.ForgetKey("imsi-2") || idGenerator.ForgetKey("imsi-2") {
+		t.Error("expected imsi-2 bound once")
+	}
+	if _, ok := idGenerator.StickyID("imsi-2"); ok {
+		t.Error("expected imsi-2 forgotten")
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAllocateStickyRebind(t *testing.T) {
+	idGenerator := NewGenerator(1, 2)
+	id, err := idGenerator.AllocateSticky("imsi-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	freeAll(t, idGenerator, id)
+	if err = idGenerator.AllocateSpecific(3 - id); err != nil {
+		t.Fatal(err)
+	}
+	// the only free ID is that of imsi-1, an ID is bound to the key which allocated it last
+	if taken, err := idGenerator.AllocateSticky("imsi-2"); err != nil || taken != id {
+		t.Fatalf("expected ID %d, output %d, %+v", id, taken, err)
+	}
+	if _, ok := idGenerator.StickyID("imsi-1"); ok {
+		t.Errorf("expected imsi-1 unbound once imsi-2 took ID %d", id)
+	}
+	if _, err = idGenerator.AllocateSticky("imsi-1"); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+}
+
+func TestStickyBindingsRetention(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithClock(clock), WithStickyBindings(time.Hour, 3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make(map[string]int64)
+	for i := 0; i < 3; i++ {
+		key := fmt.Sprintf("imsi-%d", i)
+		if ids[key], err = idGenerator.AllocateSticky(key); err != nil {
+			t.Fatal(err)
+		}
+		clock.Advance(time.Minute)
+	}
+	// the retention runs from the free, not from the allocation
+	clock.Advance(2 * time.Hour)
+	freeAll(t, idGenerator, ids["imsi-0"], ids["imsi-1"])
+	clock.Advance(time.Minute)
+	if id, err := idGenerator.AllocateSticky("imsi-0"); err != nil || id != ids["imsi-0"] {
+		t.Fatalf("expected the remembered ID %d, output %d, %+v", ids["imsi-0"], id, err)
+	}
+	clock.Advance(time.Hour)
+	if _, ok := idGenerator.StickyID("imsi-1"); ok {
+		t.Error("expected the binding of imsi-1 over an hour after the free")
+	}
+	// an allocated ID keeps its binding
+	if id, ok := idGenerator.StickyID("imsi-2"); !ok || id != ids["imsi-2"] {
+		t.Errorf("expected imsi-2 bound to %d, output %d, %v", ids["imsi-2"], id, ok)
+	}
+
+	// the least recently used binding is evicted beyond 3
+	for _, key := range []string{"imsi-3", "imsi-4"} {
+		if _, err = idGenerator.AllocateSticky(key); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for key, bound := range map[string]bool{"imsi-0": true, "imsi-1": false, "imsi-2": false, "imsi-4": true} {
+		if _, ok := idGenerator.StickyID(key); ok != bound {
+			t.Errorf("%s: expected bound %v", key, bound)
+		}
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Error(err)
+	}
+
+	// the IDs freed by Reset are freed at the time of the call
+	idGenerator.Reset()
+	clock.Advance(time.Hour + time.Second)
+	if _, ok := idGenerator.StickyID("imsi-4"); ok {
+		t.Error("expected the binding of imsi-4 over an hour after Reset")
+	}
+}
+
+func TestStickyClone(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	id, err := idGenerator.AllocateSticky("imsi-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clone := idGenerator.Clone()
+	idGenerator.ForgetKey("imsi-1")
+	if bound, ok := clone.StickyID("imsi-1"); !ok || bound != id {
+		t.Errorf("expected the clone to keep the binding to %d, output %d, %v", id, bound, ok)
+	}
+	if _, err = clone.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = clone.AllocateSticky("imsi-1"); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %+v", err)
+	}
+}
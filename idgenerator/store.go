@@ -0,0 +1,335 @@
+package idgenerator
+
+import "math/bits"
+
+// Store records which offsets in [0, last] are allocated, last being maxValue - minValue, for WithStore
+// to plug in another representation of the used IDs than those of the package.
+// The offsets are unsigned so that even the full int64 range has one for each ID,
+// loops over them must stop at last rather than beyond it, since last+1 may wrap around to 0.
+// The generator sets the excluded and quarantined offsets as well and only reads the store through these methods,
+// whose results must be those of a scan of the offsets for the allocation order to match the other stores.
+// It needs not be thread-safe, IDGenerator calls it with its lock held.
+type Store interface {
+	// Has reports whether offset is set
+	Has(offset uint64) bool
+	// Set sets offset, which may be set already
+	Set(offset uint64)
+	// Clear clears offset, which may be clear already
+	Clear(offset uint64)
+	// NextClear returns the lowest offset in [from, last] which is not set, false if none is or from > last
+	NextClear(from uint64) (uint64, bool)
+	// NextSet returns the lowest offset in [from, last] which is set, false if none is or from > last
+	NextSet(from uint64) (uint64, bool)
+	// NthClear returns the n-th lowest offset which is not set, counting from 0.
+	// n must be below the number of clear offsets.
+	NthClear(n uint64) uint64
+	// SetOffsets returns every set offset in ascending order
+	SetOffsets() []uint64
+	// Reset clears every offset
+	Reset()
+	// Clone returns a copy sharing no memory with the store
+	Clone() Store
+}
+
+// rangeSetter is implemented by the Stores which set a run of offsets without allocating memory for its length,
+// which lets a load take a long run of used IDs whatever its length
+type rangeSetter interface {
+	// SetRange sets the offsets in [first, last], first <= last, which may be set already
+	SetRange(first, last uint64)
+}
+
+// treeStore is the default Store, a tree of bitmaps of 64 children per node whose leaves have 64 words.
+// Each node keeps which of its children are full and which are not empty,
+// so nextClear, nextSet and nthClear take O(log n) steps however many offsets are set.
+// They return the same offsets as a scan would, so the allocation order is the same with every store.
+// The nodes are allocated on the first offset set below them and released once they are empty,
+// so its memory grows with the number of allocated IDs rather than with the range.
+type treeStore struct {
+	root *treeNode
+	// height is the number of levels of the tree, which covers at least [0, last]
+	height int
+	last   uint64
+	// rootPadding has the bits of the root children past last, they are marked full so nextClear skips them
+	rootPadding uint64
+}
+
+type treeNode struct {
+	// full has bit i set if child i has every offset set, nonzero if it has at least one
+	full    uint64
+	nonzero uint64
+	count   uint64
+	// children are the nodes of the level below, or words at the leaf level
+	children *[64]*treeNode
+	words    *[64]uint64
+}
+
+// treeShift returns the log2 of the number of offsets covered by each child of a node at level,
+// the leaves being at level 0 with a word of 64 offsets per child
+func treeShift(level int) uint {
+	return uint(6 + 6*level)
+}
+
+// NewTreeStore returns the default Store for the offsets in [0, last]
+func NewTreeStore(last uint64) (Store, error) {
+	s := &treeStore{
+		height: 1,
+		last:   last,
+	}
+	for treeShift(s.height-1)+6 < 64 && last>>(treeShift(s.height-1)+6) != 0 {
+		s.height++
+	}
+	// a shift by 64 gives 0, so a root with every child in use has no padding
+	s.rootPadding = ^(1<<(last>>treeShift(s.height-1)+1) - 1)
+	return s, nil
+}
+
+func newTreeNode(level int) *treeNode {
+	if level == 0 {
+		return &treeNode{words: new([64]uint64)}
+	}
+	return &treeNode{children: new([64]*treeNode)}
+}
+
+func (s *treeStore) Has(offset uint64) bool {
+	node := s.root
+	for level := s.height - 1; node != nil; level-- {
+		i := offset >> treeShift(level) & 63
+		if level == 0 {
+			return node.words[i]&(1<<(offset&63)) != 0
+		}
+		node = node.children[i]
+	}
+	return false
+}
+
+func (s *treeStore) Set(offset uint64) {
+	if s.root == nil {
+		s.root = newTreeNode(s.height - 1)
+		s.root.full = s.rootPadding
+	}
+	s.root.set(s.height-1, offset)
+}
+
+// set sets offset below n, which is at level, and reports whether it was clear
+func (n *treeNode) set(level int, offset uint64) bool {
+	i := offset >> treeShift(level) & 63
+	if level == 0 {
+		bit := uint64(1) << (offset & 63)
+		if n.words[i]&bit != 0 {
+			return false
+		}
+		n.words[i] |= bit
+		if n.words[i] == ^uint64(0) {
+			n.full |= 1 << i
+		}
+	} else {
+		child := n.children[i]
+		if child == nil {
+			child = newTreeNode(level - 1)
+			n.children[i] = child
+		}
+		if !child.set(level-1, offset) {
+			return false
+		}
+		if child.full == ^uint64(0) {
+			n.full |= 1 << i
+		}
+	}
+	n.nonzero |= 1 << i
+	n.count++
+	return true
+}
+
+func (s *treeStore) Clear(offset uint64) {
+	if s.root != nil && s.root.clear(s.height-1, offset) && s.root.count == 0 {
+		s.root = nil
+	}
+}
+
+// clear clears offset below n, which is at level, and reports whether it was set
+func (n *treeNode) clear(level int, offset uint64) bool {
+	i := offset >> treeShift(level) & 63
+	if level == 0 {
+		bit := uint64(1) << (offset & 63)
+		if n.words[i]&bit == 0 {
+			return false
+		}
+		n.words[i] &^= bit
+		if n.words[i] == 0 {
+			n.nonzero &^= 1 << i
+		}
+	} else {
+		child := n.children[i]
+		if child == nil || !child.clear(level-1, offset) {
+			return false
+		}
+		if child.count == 0 {
+			n.children[i] = nil
+			n.nonzero &^= 1 << i
+		}
+	}
+	n.full &^= 1 << i
+	n.count--
+	return true
+}
+
+func (s *treeStore) NextClear(from uint64) (uint64, bool) {
+	if from > s.last {
+		return 0, false
+	}
+	if s.root == nil {
+		return from, true
+	}
+	// the tree may cover offsets past last, which are never set
+	if offset, ok := s.root.nextClear(s.height-1, from); ok && offset <= s.last {
+		return offset, true
+	}
+	return 0, false
+}
+
+// nextClear returns the lowest offset from from up to the end of n, which is at level, which is not set
+func (n *treeNode) nextClear(level int, from uint64) (uint64, bool) {
+	shift := treeShift(level)
+	i := from >> shift & 63
+	if level == 0 {
+		// treat the bits below from as set
+		if word := n.words[i] | (1<<(from&63) - 1); word != ^uint64(0) {
+			return from&^63 + uint64(bits.TrailingZeros64(^word)), true
+		}
+	} else if child := n.children[i]; child == nil {
+		return from, true
+	} else if offset, ok := child.nextClear(level-1, from); ok {
+		return offset, true
+	}
+	// the children after i which are not full, a shift by 64 gives 0
+	candidates := ^n.full &^ (1<<(i+1) - 1)
+	if candidates == 0 {
+		return 0, false
+	}
+	j := uint64(bits.TrailingZeros64(candidates))
+	base := from&^(1<<(shift+6)-1) + j<<shift
+	if level == 0 {
+		return base + uint64(bits.TrailingZeros64(^n.words[j])), true
+	}
+	if child := n.children[j]; child != nil {
+		return child.nextClear(level-1, base)
+	}
+	return base, true
+}
+
+func (s *treeStore) NextSet(from uint64) (uint64, bool) {
+	if from > s.last || s.root == nil {
+		return 0, false
+	}
+	return s.root.nextSet(s.height-1, from)
+}
+
+// nextSet returns the lowest offset from from up to the end of n, which is at level, which is set
+func (n *treeNode) nextSet(level int, from uint64) (uint64, bool) {
+	shift := treeShift(level)
+	i := from >> shift & 63
+	if level == 0 {
+		// treat the bits below from as clear
+		if word := n.words[i] &^ (1<<(from&63) - 1); word != 0 {
+			return from&^63 + uint64(bits.TrailingZeros64(word)), true
+		}
+	} else if child := n.children[i]; child != nil {
+		if offset, ok := child.nextSet(level-1, from); ok {
+			return offset, true
+		}
+	}
+	candidates := n.nonzero &^ (1<<(i+1) - 1)
+	if candidates == 0 {
+		return 0, false
+	}
+	j := uint64(bits.TrailingZeros64(candidates))
+	base := from&^(1<<(shift+6)-1) + j<<shift
+	if level == 0 {
+		return base + uint64(bits.TrailingZeros64(n.words[j])), true
+	}
+	return n.children[j].nextSet(level-1, base)
+}
+
+func (s *treeStore) NthClear(n uint64) uint64 {
+	node, base := s.root, uint64(0)
+	for level := s.height - 1; node != nil; level-- {
+		shift := treeShift(level)
+		// the children past last may be counted as free, but they come after the n-th clear offset
+		for i := uint64(0); i < 64; i++ {
+			var free uint64
+			if level == 0 {
+				free = 64 - uint64(bits.OnesCount64(node.words[i]))
+			} else if child := node.children[i]; child == nil {
+				free = 1 << shift
+			} else {
+				free = 1<<shift - child.count
+			}
+			if n < free {
+				base += i << shift
+				if level == 0 {
+					word := ^node.words[i]
+					for ; n > 0; n-- {
+						word &= word - 1
+					}
+					return base + uint64(bits.TrailingZeros64(word))
+				}
+				node = node.children[i]
+				break
+			}
+			n -= free
+		}
+	}
+	// nothing is set below base
+	return base + n
+}
+
+func (s *treeStore) SetOffsets() []uint64 {
+	if s.root == nil {
+		return nil
+	}
+	offsets := make([]uint64, 0, s.root.count)
+	return s.root.appendSet(offsets, s.height-1, 0)
+}
+
+// appendSet appends the set offsets below n, which is at level and starts at base, in ascending order
+func (n *treeNode) appendSet(offsets []uint64, level int, base uint64) []uint64 {
+	shift := treeShift(level)
+	for nonzero := n.nonzero; nonzero != 0; nonzero &= nonzero - 1 {
+		i := uint64(bits.TrailingZeros64(nonzero))
+		if level > 0 {
+			offsets = n.children[i].appendSet(offsets, level-1, base+i<<shift)
+			continue
+		}
+		for word := n.words[i]; word != 0; word &= word - 1 {
+			offsets = append(offsets, base+i<<shift+uint64(bits.TrailingZeros64(word)))
+		}
+	}
+	return offsets
+}
+
+func (s *treeStore) Reset() {
+	s.root = nil
+}
+
+func (s *treeStore) Clone() Store {
+	c := *s
+	if s.root != nil {
+		c.root = s.root.clone()
+	}
+	return &c
+}
+
+func (n *treeNode) clone() *treeNode {
+	c := *n
+	if n.words != nil {
+		words := *n.words
+		c.words = &words
+		return &c
+	}
+	c.children = new([64]*treeNode)
+	for nonzero := n.nonzero; nonzero != 0; nonzero &= nonzero - 1 {
+		i := bits.TrailingZeros64(nonzero)
+		c.children[i] = n.children[i].clone()
+	}
+	return &c
+}
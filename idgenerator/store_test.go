@@ -0,0 +1,543 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"reflect"
+	"runtime"
+	"sort"
+	"testing"
+)
+
+var storeOptions = []struct {
+	name string
+	opts []Option
+}{
+	{"tree", nil},
+	{"bitmap", []Option{WithBitmapStore()}},
+	{"interval", []Option{WithIntervalStore()}},
+	{"map", []Option{WithStore(newMapStore)}},
+}
+
+// mapStore is a Store outside of the package stores, keeping the set offsets in a map,
+// the generator tests run over it as well to check that WithStore is all a Store needs
+type mapStore struct {
+	last uint64
+	set  map[uint64]struct{}
+}
+
+func newMapStore(last uint64) (Store, error) {
+	return &mapStore{last: last, set: make(map[uint64]struct{})}, nil
+}
+
+func (s *mapStore) Has(offset uint64) bool {
+	_, ok := s.set[offset]
+	return ok
+}
+
+func (s *mapStore) Set(offset uint64) {
+	s.set[offset] = struct{}{}
+}
+
+func (s *mapStore) Clear(offset uint64) {
+	delete(s.set, offset)
+}
+
+func (s *mapStore) NextClear(from uint64) (uint64, bool) {
+	for offset := from; offset <= s.last; offset++ {
+		if !s.Has(offset) {
+			return offset, true
+		}
+		if offset == s.last {
+			break
+		}
+	}
+	return 0, false
+}
+
+func (s *mapStore) NextSet(from uint64) (uint64, bool) {
+	for _, offset := range s.SetOffsets() {
+		if offset >= from {
+			return offset, true
+		}
+	}
+	return 0, false
+}
+
+func (s *mapStore) NthClear(n uint64) uint64 {
+	// every set offset up to the n-th clear one pushes it up by one
+	offset := n
+	for _, set := range s.SetOffsets() {
+		if set > offset {
+			break
+		}
+		offset++
+	}
+	return offset
+}
+
+func (s *mapStore) SetOffsets() []uint64 {
+	offsets := make([]uint64, 0, len(s.set))
+	for offset := range s.set {
+		offsets = append(offsets, offset)
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+func (s *mapStore) Reset() {
+	s.set = make(map[uint64]struct{})
+}
+
+func (s *mapStore) Clone() Store {
+	c := &mapStore{last: s.last, set: make(map[uint64]struct{}, len(s.set))}
+	for offset := range s.set {
+		c.set[offset] = struct{}{}
+	}
+	return c
+}
+
+func TestStoreSearch(t *testing.T) {
+	for _, size := range []uint64{1, 63, 64, 65, 200, 4097} {
+		for _, storeOption := range storeOptions {
+			t.Run(fmt.Sprintf("%s size %d", storeOption.name, size), func(t *testing.T) {
+				idGenerator, err := NewGeneratorWithOptions(0, int64(size)-1, storeOption.opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				store := idGenerator.store
+
+				// set runs of offsets and check nextClear and nextSet against a brute force search
+				for offset := uint64(0); offset < size; offset++ {
+					if offset%5 < 2 || offset%7 == 0 {
+						store.Set(offset)
+					}
+				}
+				for from := uint64(0); from <= size; from++ {
+					expectedClear, expectedClearOK := uint64(0), false
+					expectedSet, expectedSetOK := uint64(0), false
+					for i := size; i > from; i-- {
+						offset := i - 1
+						if store.Has(offset) {
+							expectedSet, expectedSetOK = offset, true
+						} else {
+							expectedClear, expectedClearOK = offset, true
+						}
+					}
+					if offset, ok := store.NextClear(from); offset != expectedClear || ok != expectedClearOK {
+						t.Errorf("nextClear(%d): expected (%d, %v), output (%d, %v)",
+							from, expectedClear, expectedClearOK, offset, ok)
+					}
+					if offset, ok := store.NextSet(from); offset != expectedSet || ok != expectedSetOK {
+						t.Errorf("nextSet(%d): expected (%d, %v), output (%d, %v)",
+							from, expectedSet, expectedSetOK, offset, ok)
+					}
+				}
+				n := uint64(0)
+				for offset := uint64(0); offset < size; offset++ {
+					if store.Has(offset) {
+						continue
+					}
+					if output := store.NthClear(n); output != offset {
+						t.Errorf("nthClear(%d): expected %d, output %d", n, offset, output)
+					}
+					n++
+				}
+
+				// a full store has nothing left, even in the padding of the last word
+				for offset := uint64(0); offset < size; offset++ {
+					store.Set(offset)
+				}
+				if offset, ok := store.NextClear(0); ok {
+					t.Errorf("nextClear(0) on a full store returned %d", offset)
+				}
+
+				store.Reset()
+				for offset := uint64(0); offset < size; offset++ {
+					if store.Has(offset) {
+						t.Errorf("offset %d is still set after reset", offset)
+					}
+				}
+				if offset, ok := store.NextSet(0); ok {
+					t.Errorf("nextSet(0) on an empty store returned %d", offset)
+				}
+			})
+		}
+	}
+}
+
+// TestStoreConformance runs the same random operations on every Store and checks them against a brute force model
+func TestStoreConformance(t *testing.T) {
+	stores := []struct {
+		name     string
+		newStore func(last uint64) (Store, error)
+	}{
+		{"tree", NewTreeStore},
+		{"bitmap", NewBitmapStore},
+		{"interval", NewIntervalStore},
+		{"map", newMapStore},
+	}
+	for _, last := range []uint64{0, 63, 64, 1000} {
+		for _, newStore := range stores {
+			t.Run(fmt.Sprintf("%s last %d", newStore.name, last), func(t *testing.T) {
+				store, err := newStore.newStore(last)
+				if err != nil {
+					t.Fatal(err)
+				}
+				model := make([]bool, last+1)
+				check := func(store Store, model []bool) {
+					var set []uint64
+					for offset, isSet := range model {
+						if store.Has(uint64(offset)) != isSet {
+							t.Fatalf("Has(%d): expected %v", offset, isSet)
+						}
+						if isSet {
+							set = append(set, uint64(offset))
+						}
+					}
+					if output := store.SetOffsets(); len(output) != len(set) || len(set) > 0 && !reflect.DeepEqual(output, set) {
+						t.Fatalf("SetOffsets: expected %v, output %v", set, output)
+					}
+					if free := uint64(len(model) - len(set)); free > 0 {
+						n := free / 2
+						for offset, isSet := range model {
+							if isSet {
+								continue
+							}
+							if n == 0 {
+								if output := store.NthClear(free / 2); output != uint64(offset) {
+									t.Fatalf("NthClear(%d): expected %d, output %d", free/2, offset, output)
+								}
+								break
+							}
+							n--
+						}
+					}
+				}
+
+				r := rand.New(rand.NewSource(1))
+				for i := 0; i < 3000; i++ {
+					offset := uint64(r.Int63n(int64(last) + 1))
+					if r.Intn(3) == 0 {
+						store.Clear(offset)
+						model[offset] = false
+					} else {
+						store.Set(offset)
+						model[offset] = true
+					}
+					from := uint64(r.Int63n(int64(last) + 2))
+					expectedClear, expectedSet := -1, -1
+					for j := int(from); j < len(model) && (expectedClear < 0 || expectedSet < 0); j++ {
+						if model[j] && expectedSet < 0 {
+							expectedSet = j
+						} else if !model[j] && expectedClear < 0 {
+							expectedClear = j
+						}
+					}
+					if next, ok := store.NextClear(from); ok != (expectedClear >= 0) || ok && next != uint64(expectedClear) {
+						t.Fatalf("NextClear(%d): expected %d, output (%d, %v)", from, expectedClear, next, ok)
+					}
+					if next, ok := store.NextSet(from); ok != (expectedSet >= 0) || ok && next != uint64(expectedSet) {
+						t.Fatalf("NextSet(%d): expected %d, output (%d, %v)", from, expectedSet, next, ok)
+					}
+					if i%500 == 0 {
+						check(store, model)
+					}
+				}
+
+				// the clone shares nothing with the store
+				clone, cloneModel := store.Clone(), append([]bool(nil), model...)
+				store.Reset()
+				for offset := range model {
+					model[offset] = false
+				}
+				check(store, model)
+				clone.Set(last)
+				cloneModel[last] = true
+				check(clone, cloneModel)
+				check(store, model)
+			})
+		}
+	}
+}
+
+func TestStoreAllocation(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(100, 229, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := int64(100); i <= 229; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != i {
+					t.Fatalf("expected id: %d, output id: %d", i, id)
+				}
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+			}
+
+			// the scan wraps around to the lowest free ID
+			for _, id := range []int64{170, 101, 230} {
+				if err = idGenerator.FreeID(id); err != nil && id != 230 {
+					t.Fatal(err)
+				}
+			}
+			for _, expected := range []int64{101, 170} {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id != expected {
+					t.Errorf("expected id: %d, output id: %d", expected, id)
+				}
+			}
+
+			if err = idGenerator.FreeID(164); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(164); err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.AllocateSpecific(164); !errors.Is(err, ErrAlreadyAllocated) {
+				t.Errorf("expected ErrAlreadyAllocated, got %+v", err)
+			}
+			if !idGenerator.IsAllocated(164) || idGenerator.Used() != 130 {
+				t.Errorf("unexpected state: IsAllocated(164) %v, Used %d", idGenerator.IsAllocated(164), idGenerator.Used())
+			}
+
+			idGenerator.Reset()
+			if id, err := idGenerator.Allocate(); err != nil || id != 100 {
+				t.Errorf("expected id 100 after Reset, got %d, %+v", id, err)
+			}
+		})
+	}
+}
+
+func TestTreeStore(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(math.MinInt64, math.MaxInt64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := idGenerator.store.(*treeStore)
+
+	// offsets at the edges of the leaves and nodes, up to the last one of the full uint64 range
+	edges := []uint64{
+		0, 4095, 4096, 1<<18 - 1, 1 << 18, 1<<36 + 7, 1<<60 - 1, 1 << 60,
+		math.MaxUint64 - 4096, math.MaxUint64 - 1, math.MaxUint64,
+	}
+	for _, offset := range edges {
+		store.Set(offset)
+		store.Set(offset)
+	}
+	if !reflect.DeepEqual(store.SetOffsets(), edges) {
+		t.Errorf("expected set offsets %v, output %v", edges, store.SetOffsets())
+	}
+	for i, offset := range edges {
+		froms := []uint64{offset}
+		if i > 0 {
+			froms = append(froms, edges[i-1]+1)
+		}
+		for _, from := range froms {
+			if next, ok := store.NextSet(from); !ok || next != offset {
+				t.Errorf("nextSet(%d): expected %d, output (%d, %v)", from, offset, next, ok)
+			}
+		}
+		expectedClear, expectedOK := offset+1, true
+		if i+1 < len(edges) && edges[i+1] == offset+1 {
+			expectedClear = offset + 2
+		}
+		if offset >= math.MaxUint64-1 {
+			expectedClear, expectedOK = 0, false
+		}
+		if next, ok := store.NextClear(offset); next != expectedClear || ok != expectedOK {
+			t.Errorf("nextClear(%d): expected (%d, %v), output (%d, %v)", offset, expectedClear, expectedOK, next, ok)
+		}
+	}
+
+	// a run of set offsets spanning several leaves is skipped at once
+	start := uint64(1<<30 - 5000)
+	for offset := start; offset <= 1<<30+5000; offset++ {
+		store.Set(offset)
+	}
+	if next, ok := store.NextClear(start); !ok || next != 1<<30+5001 {
+		t.Errorf("nextClear(%d): expected %d, output (%d, %v)", start, uint64(1<<30+5001), next, ok)
+	}
+	if n := store.NthClear(0); n != 1 {
+		t.Errorf("nthClear(0): expected 1, output %d", n)
+	}
+	// five edges are below the run
+	if n := store.NthClear(start - 5); n != start+10001 {
+		t.Errorf("nthClear(%d): expected %d, output %d", start-5, start+10001, n)
+	}
+	// the highest clear offset is the one below the last two
+	used := store.root.count
+	if n := store.NthClear(math.MaxUint64 - used); n != math.MaxUint64-2 {
+		t.Errorf("nthClear(%d): expected %d, output %d", uint64(math.MaxUint64)-used, uint64(math.MaxUint64-2), n)
+	}
+
+	for offset := start; offset <= 1<<30+5000; offset++ {
+		store.Clear(offset)
+	}
+	for _, offset := range edges {
+		store.Clear(offset)
+		store.Clear(offset)
+	}
+	if store.root != nil {
+		t.Errorf("empty tree still holds %d offsets", store.root.count)
+	}
+	if next, ok := store.NextSet(0); ok {
+		t.Errorf("nextSet(0) on an empty store returned %d", next)
+	}
+}
+
+func TestIntervalStore(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(0, math.MaxUint32, WithIntervalStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := idGenerator.store.(*intervalStore)
+
+	checkIntervals := func(expected ...freeInterval) {
+		t.Helper()
+		if !reflect.DeepEqual(store.free, expected) {
+			t.Errorf("expected free intervals %v, output %v", expected, store.free)
+		}
+	}
+
+	// the first and the last value of the range
+	if err = idGenerator.AllocateSpecific(0); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.AllocateSpecific(math.MaxUint32); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{1, math.MaxUint32 - 1})
+
+	ids, err := idGenerator.AllocateMany(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(ids, []int64{1, 2, 3}) {
+		t.Errorf("expected IDs [1 2 3], output %v", ids)
+	}
+	checkIntervals(freeInterval{4, math.MaxUint32 - 1})
+
+	// freeing 2 splits the space, freeing 1 and 3 merges everything back together
+	if err = idGenerator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{2, 2}, freeInterval{4, math.MaxUint32 - 1})
+	if err = idGenerator.FreeID(0); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{0, 0}, freeInterval{2, 2}, freeInterval{4, math.MaxUint32 - 1})
+	if err = idGenerator.FreeID(1); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{0, 2}, freeInterval{4, math.MaxUint32 - 1})
+	if err = idGenerator.FreeID(3); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{0, math.MaxUint32 - 1})
+	if err = idGenerator.FreeID(math.MaxUint32); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{0, math.MaxUint32})
+
+	// allocating the last value splits nothing off the front
+	if err = idGenerator.AllocateSpecific(math.MaxUint32 - 1); err != nil {
+		t.Fatal(err)
+	}
+	checkIntervals(freeInterval{0, math.MaxUint32 - 2}, freeInterval{math.MaxUint32, math.MaxUint32})
+	if idGenerator.Available() != math.MaxUint32 {
+		t.Errorf("expected available: %d, output available: %d", int64(math.MaxUint32), idGenerator.Available())
+	}
+}
+
+// BenchmarkStore measures the memory of a pool at 90% utilization
+// and the cost of a free followed by an allocation in it.
+func BenchmarkStore(b *testing.B) {
+	for _, size := range []int64{1 << 20, 1 << 24} {
+		for _, storeOption := range storeOptions {
+			b.Run(fmt.Sprintf("%s %d slots", storeOption.name, size), func(b *testing.B) {
+				var before, after runtime.MemStats
+				runtime.GC()
+				runtime.ReadMemStats(&before)
+
+				idGenerator, err := NewGeneratorWithOptions(1, size, storeOption.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err = idGenerator.AllocateMany(int(size / 10 * 9)); err != nil {
+					b.Fatal(err)
+				}
+
+				runtime.GC()
+				runtime.ReadMemStats(&after)
+
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// free an ID behind the scan offset so that allocation has to search for it
+					id := 1 + int64(i)%(size/2)
+					if err = idGenerator.FreeID(id); err != nil && !errors.Is(err, ErrNotAllocated) {
+						b.Fatal(err)
+					}
+					if _, err = idGenerator.Allocate(); err != nil {
+						b.Fatal(err)
+					}
+				}
+				b.ReportMetric(float64(after.HeapAlloc-before.HeapAlloc), "heap-bytes")
+				runtime.KeepAlive(idGenerator)
+			})
+		}
+	}
+}
+
+// BenchmarkAllocateUtilization measures a free followed by an allocation in a pool of 2^22 IDs
+// kept at 50%, 90% and 99.9% utilization, its free IDs spread evenly over the range.
+func BenchmarkAllocateUtilization(b *testing.B) {
+	const size = 1 << 22
+	for _, utilization := range []float64{0.5, 0.9, 0.999} {
+		for _, storeOption := range storeOptions {
+			b.Run(fmt.Sprintf("%s %.1f%%", storeOption.name, utilization*100), func(b *testing.B) {
+				idGenerator, err := NewGeneratorWithOptions(1, size, storeOption.opts...)
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err = idGenerator.AllocateMany(size); err != nil {
+					b.Fatal(err)
+				}
+				stride := int64(1 / (1 - utilization))
+				for id := int64(1); id <= size; id += stride {
+					if err = idGenerator.FreeID(id); err != nil {
+						b.Fatal(err)
+					}
+				}
+
+				// the allocated IDs, each freed one is replaced by the next allocation
+				allocated := idGenerator.AllocatedIDs()
+
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					// free an ID jumping around the pool, mostly behind the scan offset
+					j := i * 7919 % len(allocated)
+					if err = idGenerator.FreeID(allocated[j]); err != nil {
+						b.Fatal(err)
+					}
+					if allocated[j], err = idGenerator.Allocate(); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package idgenerator
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// Allocation strategies, they are reported in Stats
+const (
+	// StrategySequential allocates the next free ID after the last allocated one, wrapping around
+	// to minValue at the end of the range. It is the default, set with WithSequentialAllocation.
+	StrategySequential = "sequential"
+	// StrategyLowestFree allocates the lowest free ID, set with WithLowestFreeAllocation
+	StrategyLowestFree = "lowest-free"
+	// StrategyRandom allocates a uniformly random free ID, set with WithRandomAllocation
+	StrategyRandom = "random"
+)
+
+// randomAttempts is how many random offsets randomOffsetLocked tries before selecting among the free ones,
+// each attempt hits a free offset with a probability of at least 1/2
+const randomAttempts = 8
+
+// randomOffsetLocked returns a uniformly random offset which is not set in store, of which occupied are set.
+// The caller must hold lock and ensure one is clear.
+// A mostly empty pool is sampled directly, a fuller one by picking the n-th free offset,
+// which takes a single random number but a walk over the store.
+func (idGenerator *IDGenerator) randomOffsetLocked(store Store, occupied uint64) (uint64, error) {
+	if occupied <= idGenerator.lastOffset/2 {
+		for i := 0; i < randomAttempts; i++ {
+			offset, err := idGenerator.randomUpTo(idGenerator.lastOffset)
+			if err != nil {
+				return 0, err
+			}
+			if !store.Has(offset) {
+				return offset, nil
+			}
+		}
+	}
+	// the free offsets are numbered from 0 to lastOffset - occupied
+	n, err := idGenerator.randomUpTo(idGenerator.lastOffset - occupied)
+	if err != nil {
+		return 0, err
+	}
+	return store.NthClear(n), nil
+}
+
+// randomUpTo returns a uniformly random number in [0, last]
+func (idGenerator *IDGenerator) randomUpTo(last uint64) (uint64, error) {
+	bound := last + 1
+	// 2^64 mod bound, the values from 2^64 - rem up would make the lower results more likely
+	rem := uint64(0)
+	if bound != 0 {
+		rem = (math.MaxUint64%bound + 1) % bound
+	}
+	for {
+		if _, err := io.ReadFull(idGenerator.random, idGenerator.randomBuf[:]); err != nil {
+			return 0, fmt.Errorf("read random source: %w", err)
+		}
+		v := binary.BigEndian.Uint64(idGenerator.randomBuf[:])
+		if bound == 0 {
+			// last is math.MaxUint64, every value is in range
+			return v, nil
+		}
+		if rem == 0 || v <= math.MaxUint64-rem {
+			return v % bound, nil
+		}
+	}
+}
+
+func (idGenerator *IDGenerator) strategyName() string {
+	if idGenerator.strategy == "" {
+		return StrategySequential
+	}
+	return idGenerator.strategy
+}
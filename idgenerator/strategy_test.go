@@ -0,0 +1,312 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"sort"
+	"testing"
+	"testing/iotest"
+)
+
+func TestAllocationStrategies(t *testing.T) {
+	testCases := []struct {
+		strategy string
+		opts     []Option
+		// remaining are the IDs allocated after 1..8 and FreeID(6), FreeID(3) until exhaustion, nil if random
+		remaining []int64
+		// afterFreeAll is the ID allocated once every ID is freed, 0 if random
+		afterFreeAll int64
+	}{
+		{StrategySequential, nil, []int64{9, 10, 3, 6}, 7},
+		{StrategySequential, []Option{WithSequentialAllocation()}, []int64{9, 10, 3, 6}, 7},
+		{StrategyLowestFree, []Option{WithLowestFreeAllocation()}, []int64{3, 6, 9, 10}, 1},
+		{StrategyRandom, []Option{WithRandomAllocationFrom(rand.New(rand.NewSource(1)))}, nil, 0},
+		// the last strategy option wins
+		{StrategyLowestFree, []Option{WithRandomAllocation(), WithLowestFreeAllocation()}, []int64{3, 6, 9, 10}, 1},
+	}
+
+	for i, testCase := range testCases {
+		for _, storeOption := range storeOptions {
+			t.Run(fmt.Sprintf("case %d %s %s", i, testCase.strategy, storeOption.name), func(t *testing.T) {
+				opts := append(append([]Option{}, testCase.opts...), storeOption.opts...)
+				idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if strategy := idGenerator.Stats().Strategy; strategy != testCase.strategy {
+					t.Errorf("expected strategy: %s, output strategy: %s", testCase.strategy, strategy)
+				}
+
+				var ids []int64
+				for i := 0; i < 8; i++ {
+					id, err := idGenerator.Allocate()
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, id)
+				}
+				if testCase.remaining != nil && !reflect.DeepEqual(ids, []int64{1, 2, 3, 4, 5, 6, 7, 8}) {
+					t.Fatalf("expected the IDs 1 to 8 first, output %v", ids)
+				}
+				held := make(map[int64]bool)
+				for _, id := range ids {
+					held[id] = true
+				}
+				for _, id := range []int64{ids[5], ids[2]} {
+					if err = idGenerator.FreeID(id); err != nil {
+						t.Fatal(err)
+					}
+					delete(held, id)
+				}
+
+				// the pool wraps around to the freed IDs and is then exhausted
+				ids = nil
+				for idGenerator.Available() > 0 {
+					id, err := idGenerator.Allocate()
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids = append(ids, id)
+				}
+				if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+					t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+				}
+				if testCase.remaining != nil && !reflect.DeepEqual(ids, testCase.remaining) {
+					t.Errorf("expected remaining IDs: %v, output: %v", testCase.remaining, ids)
+				}
+				for _, id := range ids {
+					if held[id] {
+						t.Errorf("ID[%d] allocated twice", id)
+					}
+					held[id] = true
+				}
+
+				for id := int64(1); id <= 10; id++ {
+					if err = idGenerator.FreeID(id); err != nil {
+						t.Fatal(err)
+					}
+				}
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if testCase.afterFreeAll != 0 && id != testCase.afterFreeAll {
+					t.Errorf("expected id after freeing all: %d, output id: %d", testCase.afterFreeAll, id)
+				}
+			})
+		}
+	}
+}
+
+func TestPeek(t *testing.T) {
+	strategies := []struct {
+		strategy string
+		opts     []Option
+	}{
+		{StrategySequential, nil},
+		{StrategyLowestFree, []Option{WithLowestFreeAllocation()}},
+		{StrategyRandom, []Option{WithRandomAllocationFrom(rand.New(rand.NewSource(1)))}},
+	}
+	for _, strategy := range strategies {
+		for _, storeOption := range storeOptions {
+			t.Run(strategy.strategy+" "+storeOption.name, func(t *testing.T) {
+				opts := append(append([]Option{}, strategy.opts...), storeOption.opts...)
+				idGenerator, err := NewGeneratorWithOptions(1, 10, opts...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = idGenerator.AllocateSpecific(4); err != nil {
+					t.Fatal(err)
+				}
+
+				// each Allocate returns the ID peeked before it, however often
+				for idGenerator.Available() > 0 {
+					peeked, err := idGenerator.Peek()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if again, err := idGenerator.Peek(); err != nil || again != peeked {
+						t.Fatalf("expected the same ID from a second Peek: %d, output: %d, %+v", peeked, again, err)
+					}
+					if idGenerator.IsAllocated(peeked) {
+						t.Fatalf("peeked ID[%d] is allocated", peeked)
+					}
+					id, err := idGenerator.Allocate()
+					if err != nil {
+						t.Fatal(err)
+					}
+					if id != peeked {
+						t.Errorf("expected the peeked id: %d, output id: %d", peeked, id)
+					}
+				}
+				if _, err = idGenerator.Peek(); !errors.Is(err, ErrPoolExhausted) {
+					t.Errorf("expected ErrPoolExhausted, got %+v", err)
+				}
+				if failures := idGenerator.Stats().AllocationFailures; failures != 0 {
+					t.Errorf("Peek counted %d allocation failures", failures)
+				}
+
+				// a peeked ID taken by another call is not handed out twice
+				if err = idGenerator.FreeID(2); err != nil {
+					t.Fatal(err)
+				}
+				if err = idGenerator.FreeID(7); err != nil {
+					t.Fatal(err)
+				}
+				peeked, err := idGenerator.Peek()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err = idGenerator.AllocateSpecific(peeked); err != nil {
+					t.Fatal(err)
+				}
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if id == peeked || (id != 2 && id != 7) {
+					t.Errorf("expected the other free ID than %d, output id: %d", peeked, id)
+				}
+			})
+		}
+	}
+}
+
+func TestRandomAllocation(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			opts := append([]Option{WithRandomAllocationFrom(rand.New(rand.NewSource(1)))}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithOptions(100, 299, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the last allocations run with a nearly full pool and must still terminate
+			ids := make([]int64, 0, 200)
+			for i := 0; i < 200; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, id)
+			}
+			if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+				t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+			}
+			if sort.SliceIsSorted(ids, func(i, j int) bool { return ids[i] < ids[j] }) {
+				t.Error("random allocation handed out the IDs in order")
+			}
+			sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+			for i, id := range ids {
+				if id != int64(100+i) {
+					t.Fatalf("expected id: %d, output id: %d", 100+i, id)
+				}
+			}
+		})
+	}
+}
+
+func TestRandSource(t *testing.T) {
+	allocateAll := func(seed int64) []int64 {
+		idGenerator, err := NewGeneratorWithOptions(1, 100, WithRandSource(rand.NewSource(seed)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strategy := idGenerator.Stats().Strategy; strategy != StrategyRandom {
+			t.Errorf("expected strategy: %s, output strategy: %s", StrategyRandom, strategy)
+		}
+		ids := make([]int64, 0, 100)
+		for i := 0; i < 100; i++ {
+			id, err := idGenerator.Allocate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			ids = append(ids, id)
+		}
+		return ids
+	}
+
+	ids := allocateAll(42)
+	if again := allocateAll(42); !reflect.DeepEqual(again, ids) {
+		t.Errorf("the same seed gave another allocation order:\n%v\n%v", ids, again)
+	}
+	if other := allocateAll(43); reflect.DeepEqual(other, ids) {
+		t.Error("another seed gave the same allocation order")
+	}
+}
+
+func TestRandomAllocationUniform(t *testing.T) {
+	// half used is sampled directly, 8 of 10 used selects among the free ones
+	for _, used := range []int64{5, 8} {
+		t.Run(fmt.Sprintf("%d used", used), func(t *testing.T) {
+			idGenerator, err := NewGeneratorWithOptions(0, 9, WithRandomAllocationFrom(rand.New(rand.NewSource(1))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = idGenerator.ReserveRange(0, used-1); err != nil {
+				t.Fatal(err)
+			}
+
+			const rounds = 20000
+			counts := make(map[int64]int)
+			for i := 0; i < rounds; i++ {
+				id, err := idGenerator.Allocate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				counts[id]++
+				if err = idGenerator.FreeID(id); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expected := rounds / int(10-used)
+			for id := used; id < 10; id++ {
+				if count := counts[id]; count < expected*9/10 || count > expected*11/10 {
+					t.Errorf("ID[%d] allocated %d times, expected about %d", id, count, expected)
+				}
+			}
+			if len(counts) != int(10-used) {
+				t.Errorf("allocated %d distinct IDs, expected %d", len(counts), 10-used)
+			}
+		})
+	}
+}
+
+func TestRandomAllocationSourceError(t *testing.T) {
+	sourceErr := errors.New("source broken")
+	allocated := 0
+	idGenerator, err := NewGeneratorWithOptions(1, 10,
+		WithRandomAllocationFrom(iotest.ErrReader(sourceErr)),
+		WithOnAllocate(func(int64) { allocated++ }),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = idGenerator.Allocate(); !errors.Is(err, sourceErr) {
+		t.Errorf("expected the error of the source, got %+v", err)
+	}
+	if _, err = idGenerator.AllocateMany(3); !errors.Is(err, sourceErr) {
+		t.Errorf("expected the error of the source, got %+v", err)
+	}
+	if stats := idGenerator.Stats(); stats.Used != 0 || stats.AllocationFailures != 2 || allocated != 0 {
+		t.Errorf("a failed allocation changed the generator: %+v, %d hook calls", stats, allocated)
+	}
+}
+
+func TestRandomAllocationCryptoSource(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithRandomAllocation())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err = idGenerator.Allocate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrPoolExhausted) {
+		t.Fatalf("expected ErrPoolExhausted, got %+v", err)
+	}
+}
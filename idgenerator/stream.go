@@ -0,0 +1,59 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+)
+
+// Stream returns a channel fed with IDs allocated like AllocateCtx, e.g. to range over in a pipeline,
+// on which up to buffer IDs are allocated ahead of their consumer, plus the one waiting to be received.
+// The stream pauses while the pool is exhausted and resumes once IDs are freed, rather than closing.
+// Once ctx is done the stream stops: the IDs allocated ahead are freed and the channel is closed,
+// those received before are the consumer's to free. ctx must be cancelled eventually, or the goroutine feeding
+// the channel is never released. A buffer below 0 is taken as 0.
+// Close stops the stream too: its channel is closed once it allocates again, e.g. when an ID is received
+// from a full buffer, and the IDs allocated ahead, which cannot be freed after Close, are reported as leaked by it.
+// Cancel ctx and wait for the channel to be closed before Close to give them back.
+func (idGenerator *IDGenerator) Stream(ctx context.Context, buffer int) <-chan int64 {
+	if buffer < 0 {
+		buffer = 0
+	}
+	ids := make(chan int64, buffer)
+	go idGenerator.stream(ctx, ids)
+	return ids
+}
+
+// stream feeds ids until ctx is done or the generator is closed, then frees the IDs allocated ahead and closes ids
+func (idGenerator *IDGenerator) stream(ctx context.Context, ids chan int64) {
+	var ahead []int64
+	for {
+		id, err := idGenerator.AllocateCtx(ctx)
+		if err != nil {
+			break
+		}
+		select {
+		case ids <- id:
+			continue
+		case <-ctx.Done():
+			ahead = append(ahead, id)
+		}
+		break
+	}
+	for drained := false; !drained; {
+		select {
+		case id := <-ids:
+			ahead = append(ahead, id)
+		default:
+			drained = true
+		}
+	}
+	// the IDs are back once the channel is closed
+	defer close(ids)
+	if len(ahead) == 0 {
+		return
+	}
+	if _, errs := idGenerator.FreeMany(ahead); len(errs) > 0 && !errors.Is(errs[0], ErrClosed) {
+		minValue, maxValue := idGenerator.bounds()
+		idGenerator.logger.Printf("idgenerator[%d-%d]: free the IDs of a stream: %v", minValue, maxValue, errs)
+	}
+}
@@ -0,0 +1,87 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	ids := idGenerator.Stream(ctx, 4)
+	var received []int64
+	for id := range ids {
+		received = append(received, id)
+		if len(received) == 10 {
+			break
+		}
+	}
+	if expected := []int64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}; !reflect.DeepEqual(received, expected) {
+		t.Errorf("expected IDs %v, output %v", expected, received)
+	}
+	cancel()
+	for id := range ids {
+		// received between the break and the cancel
+		received = append(received, id)
+	}
+	// the IDs allocated ahead are back in the pool once the channel is closed
+	if used := idGenerator.Used(); used != int64(len(received)) {
+		t.Errorf("expected %d used, output %d", len(received), used)
+	}
+}
+
+func TestStreamExhausted(t *testing.T) {
+	idGenerator := NewGenerator(1, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ids := idGenerator.Stream(ctx, 0)
+	for i := 0; i < 3; i++ {
+		<-ids
+	}
+	// the stream pauses rather than closing
+	select {
+	case id, ok := <-ids:
+		t.Fatalf("expected the stream to pause, output %d, %v", id, ok)
+	case <-time.After(10 * time.Millisecond):
+	}
+	freeAll(t, idGenerator, 2)
+	select {
+	case id := <-ids:
+		if id != 2 {
+			t.Errorf("expected ID 2, output %d", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the stream to resume")
+	}
+}
+
+func TestStreamClose(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ids := idGenerator.Stream(ctx, 2)
+	first := <-ids
+	// the stream is blocked on the full buffer
+	for idGenerator.Used() < 4 {
+		time.Sleep(time.Millisecond)
+	}
+	leaked, err := idGenerator.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(leaked) != 4 || leaked[0] != first {
+		t.Errorf("expected the received ID and those allocated ahead leaked, output %v", leaked)
+	}
+	// the stream stops once it allocates again
+	for id := range ids {
+		if id < first || id > leaked[3] {
+			t.Errorf("expected only the IDs allocated ahead, output %d", id)
+		}
+	}
+	if _, err = idGenerator.Allocate(); !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %+v", err)
+	}
+}
@@ -0,0 +1,175 @@
+package idgenerator
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+	"strings"
+)
+
+// strictFrames is the number of frames captured of each allocation, free and Close in strict mode
+const strictFrames = 8
+
+// WithStrictMode makes the misuses of the generator panic with a *MisuseError, e.g. in development,
+// instead of returning an error: freeing an ID which is not allocated, freed already or out of range,
+// by FreeID, FreeMany, FreeToken or FreeString, and any call failing with ErrClosed after Close.
+// The panic is raised once lock is released, so a recovered one leaves the generator usable;
+// a Stream left running at Close panics in its own goroutine, which crashes the program.
+// The generator captures the call stack of every allocation, free and Close to report where the ID
+// came from, which costs a runtime.Callers each.
+func WithStrictMode() Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.strict = &strictState{
+			allocated: make(map[int64][]uintptr),
+			freed:     make(map[int64][]uintptr),
+		}
+	}
+}
+
+// MisuseError is the panic of WithStrictMode, it wraps the error the call would have returned otherwise
+type MisuseError struct {
+	Err error
+	// ID is the ID misused, 0 for a call after Close
+	ID int64
+	// Allocated and Freed are the stacks of the last allocation and free of ID, Closed the stack of Close,
+	// "" if there is none
+	Allocated string
+	Freed     string
+	Closed    string
+}
+
+func (e *MisuseError) Error() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "idgenerator misuse: %v", e.Err)
+	for _, site := range []struct{ what, stack string }{
+		{"allocated", e.Allocated}, {"freed", e.Freed}, {"closed", e.Closed},
+	} {
+		if site.stack != "" {
+			fmt.Fprintf(&b, "\n%s at:\n%s", site.what, site.stack)
+		}
+	}
+	return b.String()
+}
+
+func (e *MisuseError) Unwrap() error {
+	return e.Err
+}
+
+// strictState holds the stacks of WithStrictMode keyed by ID, so that Resize does not move them,
+// and the misuse of the call holding lock, which unlock panics with
+type strictState struct {
+	allocated map[int64][]uintptr
+	freed     map[int64][]uintptr
+	closed    []uintptr
+	misuse    *MisuseError
+}
+
+// clone returns a copy of the stacks, the stacks themselves are never modified and are shared
+func (s *strictState) clone() *strictState {
+	cloned := &strictState{
+		allocated: make(map[int64][]uintptr, len(s.allocated)),
+		freed:     make(map[int64][]uintptr, len(s.freed)),
+		closed:    s.closed,
+	}
+	for id, stack := range s.allocated {
+		cloned.allocated[id] = stack
+	}
+	for id, stack := range s.freed {
+		cloned.freed[id] = stack
+	}
+	return cloned
+}
+
+// callers returns the stack of the caller of the generator, the methods of the generator left out
+func callers() []uintptr {
+	pcs := make([]uintptr, strictFrames+16)
+	return pcs[:runtime.Callers(2, pcs)]
+}
+
+// formatStack spells the frames of pcs out of the package, the innermost first, one function and its line each
+func formatStack(pcs []uintptr) string {
+	if len(pcs) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	frames := runtime.CallersFrames(pcs)
+	for n := 0; n < strictFrames; {
+		frame, more := frames.Next()
+		if !strings.Contains(frame.Function, "idgenerator.(*IDGenerator).") {
+			fmt.Fprintf(&b, "\t%s\n\t\t%s:%d\n", frame.Function, frame.File, frame.Line)
+			n++
+		}
+		if !more {
+			break
+		}
+	}
+	return b.String()
+}
+
+// noteAllocatedLocked and noteFreedLocked capture the stack of the allocation and of the free of the ID at offset
+// in strict mode, the stack of the allocation is kept after the free for a double free. The caller must hold lock.
+func (idGenerator *IDGenerator) noteAllocatedLocked(offset uint64) {
+	if s := idGenerator.strict; s != nil {
+		id := idGenerator.toID(offset)
+		s.allocated[id] = callers()
+		delete(s.freed, id)
+	}
+}
+
+// unnoteAllocatedLocked forgets the allocation of offset undone by unmarkUsed, the caller must hold lock
+func (idGenerator *IDGenerator) unnoteAllocatedLocked(offset uint64) {
+	if s := idGenerator.strict; s != nil {
+		delete(s.allocated, idGenerator.toID(offset))
+	}
+}
+
+func (idGenerator *IDGenerator) noteFreedLocked(offset uint64) {
+	if s := idGenerator.strict; s != nil {
+		id := idGenerator.toID(offset)
+		s.freed[id] = callers()
+	}
+}
+
+// misuseLocked returns err, which unlock panics with in strict mode if it is a misuse of id.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) misuseLocked(err error, id int64) error {
+	if idGenerator.strict != nil && idGenerator.strict.misuse == nil {
+		idGenerator.strict.misuse = idGenerator.misuseError(err, id)
+	}
+	return err
+}
+
+// misuseError returns the MisuseError of err for id, nil if err is not a misuse.
+// The caller must hold lock, or own the generator if err is ErrOutOfRange.
+func (idGenerator *IDGenerator) misuseError(err error, id int64) *MisuseError {
+	s := idGenerator.strict
+	switch {
+	case errors.Is(err, ErrClosed):
+		return &MisuseError{Err: err, Closed: formatStack(s.closed)}
+	case errors.Is(err, ErrOutOfRange):
+		return &MisuseError{Err: err, ID: id}
+	case errors.Is(err, ErrNotAllocated):
+		return &MisuseError{Err: err, ID: id, Allocated: formatStack(s.allocated[id]), Freed: formatStack(s.freed[id])}
+	}
+	return nil
+}
+
+// outOfRangeFreeError returns the error of freeing id out of range, it panics with it in strict mode.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) outOfRangeFreeError(id int64) error {
+	err := idGenerator.outOfRangeError(id)
+	if idGenerator.strict != nil {
+		panic(idGenerator.misuseError(err, id))
+	}
+	return err
+}
+
+// takeMisuseLocked returns the misuse of the call holding lock and forgets it, nil if none
+func (idGenerator *IDGenerator) takeMisuseLocked() *MisuseError {
+	if idGenerator.strict == nil {
+		return nil
+	}
+	misuse := idGenerator.strict.misuse
+	idGenerator.strict.misuse = nil
+	return misuse
+}
@@ -0,0 +1,108 @@
+package idgenerator
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// misuse returns the *MisuseError f panics with, failing t if it does not
+func misuse(t *testing.T, f func() error) (misuse *MisuseError) {
+	t.Helper()
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			var ok bool
+			if misuse, ok = recovered.(*MisuseError); !ok {
+				t.Fatalf("expected a panic with a *MisuseError, got %+v", recovered)
+			}
+		}
+	}()
+	err := f()
+	t.Fatalf("expected a panic, got %+v", err)
+	return nil
+}
+
+func TestStrictMode(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 100, WithStrictMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocated := allocateN(t, idGenerator, 2)
+
+	t.Run("double free", func(t *testing.T) {
+		freeAll(t, idGenerator, allocated[0])
+		e := misuse(t, func() error { return idGenerator.FreeID(allocated[0]) })
+		if !errors.Is(e, ErrNotAllocated) || e.ID != allocated[0] {
+			t.Errorf("unexpected misuse %+v", e)
+		}
+		// both stacks lead to the callers out of the package
+		if !strings.Contains(e.Allocated, "allocateN") || !strings.Contains(e.Freed, "freeAll") {
+			t.Errorf("expected the stacks of the allocation and the free, output\n%s", e.Error())
+		}
+		msg := e.Error()
+		for _, part := range []string{"ID[1]", "allocated at:\n", "freed at:\n", "strict_test.go:"} {
+			if !strings.Contains(msg, part) {
+				t.Errorf("expected %q in the message, output\n%s", part, msg)
+			}
+		}
+	})
+
+	t.Run("never allocated", func(t *testing.T) {
+		e := misuse(t, func() error { return idGenerator.FreeID(50) })
+		if !errors.Is(e, ErrNotAllocated) || e.ID != 50 || e.Allocated != "" || e.Freed != "" {
+			t.Errorf("unexpected misuse %+v", e)
+		}
+	})
+
+	t.Run("out of range", func(t *testing.T) {
+		e := misuse(t, func() error { return idGenerator.FreeID(1000) })
+		if !errors.Is(e, ErrOutOfRange) || e.ID != 1000 {
+			t.Errorf("unexpected misuse %+v", e)
+		}
+		e = misuse(t, func() error {
+			if _, errs := idGenerator.FreeMany([]int64{allocated[1], -1}); len(errs) > 0 {
+				return errs[0]
+			}
+			return nil
+		})
+		if !errors.Is(e, ErrOutOfRange) || e.ID != -1 {
+			t.Errorf("unexpected misuse %+v", e)
+		}
+		// the valid IDs are freed with the misused ones
+		if idGenerator.IsAllocated(allocated[1]) {
+			t.Errorf("expected ID %d freed", allocated[1])
+		}
+	})
+
+	// a recovered panic leaves the generator usable
+	id := allocateN(t, idGenerator, 1)[0]
+	freeAll(t, idGenerator, id)
+
+	t.Run("closed", func(t *testing.T) {
+		if _, err := idGenerator.Close(); err != nil {
+			t.Fatal(err)
+		}
+		e := misuse(t, func() error {
+			_, err := idGenerator.Allocate()
+			return err
+		})
+		if !errors.Is(e, ErrClosed) || !strings.Contains(e.Closed, "TestStrictMode.func4") {
+			t.Errorf("expected the stack of Close, output %+v", e)
+		}
+		if e = misuse(t, func() error { return idGenerator.FreeID(id) }); !errors.Is(e, ErrClosed) {
+			t.Errorf("unexpected misuse %+v", e)
+		}
+	})
+}
+
+func TestStrictModeOff(t *testing.T) {
+	idGenerator := NewGenerator(1, 100)
+	allocateN(t, idGenerator, 1)
+	freeAll(t, idGenerator, 1)
+	if err := idGenerator.FreeID(1); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated without a panic, got %+v", err)
+	}
+	if err := idGenerator.FreeID(1000); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange without a panic, got %+v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package idgenerator
+
+import "math"
+
+// Uint32Generator allocates uint32 IDs in [1, math.MaxUint32], for IDs such as the GTP TEID or the PFCP SEID
+// where 0 is reserved. 0 is outside its range, so it is never allocated
+// and AllocateSpecific and FreeID reject it with an error wrapping ErrOutOfRange.
+type Uint32Generator struct {
+	*Generator[uint32]
+}
+
+// NewUint32Generator initializes a Uint32Generator and applies opts to it.
+// It keeps its state in the interval store, so that a nearly empty generator takes little memory
+// in spite of the size of its range, unless opts select another store.
+// It fails like NewGeneratorWithOptions with opts, e.g. with an error wrapping ErrInvalidRange
+// for WithPermanentIDs(0), as 0 is outside its range.
+func NewUint32Generator(opts ...Option) (*Uint32Generator, error) {
+	generator, err := NewTypedGenerator[uint32](1, math.MaxUint32, append([]Option{WithIntervalStore()}, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &Uint32Generator{Generator: generator}, nil
+}
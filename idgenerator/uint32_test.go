@@ -0,0 +1,107 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"testing"
+)
+
+func TestUint32Generator(t *testing.T) {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	idGenerator, err := NewUint32Generator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+	// a bitmap of the range would take 512 MiB
+	if grown := int64(after.HeapAlloc) - int64(before.HeapAlloc); grown > 1<<20 {
+		t.Errorf("an empty generator takes %d bytes", grown)
+	}
+	if capacity := idGenerator.Stats().Capacity; capacity != math.MaxUint32 {
+		t.Errorf("expected capacity: %d, output capacity: %d", uint32(math.MaxUint32), capacity)
+	}
+
+	for expected := uint32(1); expected <= 3; expected++ {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+
+	// 0 is never allocated nor freed
+	if err := idGenerator.AllocateSpecific(0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err := idGenerator.ReserveRange(0, 10); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if _, err := idGenerator.AllocateWithOffset(0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if _, err := idGenerator.AllocateAtOrAbove(0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err := idGenerator.FreeID(0); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if idGenerator.IsAllocated(0) {
+		t.Error("0 reported allocated")
+	}
+
+	// the scan wraps around from math.MaxUint32 to the lowest free ID, skipping 0
+	snapshot := idGenerator.IDGenerator().Snapshot()
+	snapshot.Offset = math.MaxUint32 - 1
+	if err := idGenerator.IDGenerator().load(snapshot); err != nil {
+		t.Fatal(err)
+	}
+	if err := idGenerator.FreeID(2); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []uint32{math.MaxUint32, 2, 4} {
+		id, err := idGenerator.Allocate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+}
+
+func TestUint32GeneratorOffset(t *testing.T) {
+	idGenerator, err := NewUint32Generator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, err := idGenerator.AllocateWithOffset(math.MaxUint32); err != nil || id != math.MaxUint32 {
+		t.Fatalf("expected ID %d, output %d, %+v", uint32(math.MaxUint32), id, err)
+	}
+	// the scan wraps around to 1, skipping 0
+	if id, err := idGenerator.AllocateWithOffset(math.MaxUint32); err != nil || id != 1 {
+		t.Errorf("expected ID 1, output %d, %+v", id, err)
+	}
+	if id, err := idGenerator.AllocateAtOrAbove(1); err != nil || id != 2 {
+		t.Errorf("expected ID 2, output %d, %+v", id, err)
+	}
+	if _, err := idGenerator.AllocateAtOrAbove(math.MaxUint32); !errors.Is(err, ErrPoolExhausted) {
+		t.Errorf("expected ErrPoolExhausted, got %+v", err)
+	}
+}
+
+func TestUint32GeneratorInvalid(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"permanent 0": WithPermanentIDs(0),
+		"step":        WithStep(2, 3),
+	} {
+		if _, err := NewUint32Generator(opt); !errors.Is(err, ErrInvalidRange) {
+			t.Errorf("%s: expected ErrInvalidRange, got %+v", name, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package idgenerator
+
+import "sync"
+
+// generatorLock is the lock of an IDGenerator, a sync.RWMutex which NewUnsyncedGenerator switches off
+// so that both run the same code
+type generatorLock struct {
+	mtx sync.RWMutex
+	off bool
+}
+
+func (l *generatorLock) Lock() {
+	if !l.off {
+		l.mtx.Lock()
+	}
+}
+
+func (l *generatorLock) Unlock() {
+	if !l.off {
+		l.mtx.Unlock()
+	}
+}
+
+func (l *generatorLock) RLock() {
+	if !l.off {
+		l.mtx.RLock()
+	}
+}
+
+func (l *generatorLock) RUnlock() {
+	if !l.off {
+		l.mtx.RUnlock()
+	}
+}
+
+// UnsyncedGenerator is an IDGenerator which does not lock, for a generator embedded in a structure
+// already guarded by a lock of its own, which makes the lock of the generator pure overhead.
+// It has the methods of IDGenerator and allocates exactly like it, the same code runs without taking the lock.
+//
+// It is not safe for concurrent use: the caller must serialize every call, including those of Stats,
+// the HTTP handler or any other reader, and the hooks run on the goroutine of the call which triggers them.
+// AllocateCtx and WaitForID can only be woken by a free from another goroutine and Stream allocates
+// on a goroutine of its own, so they need a synchronized IDGenerator.
+// The generators of Clone and Split are synchronized.
+type UnsyncedGenerator struct {
+	*IDGenerator
+}
+
+// NewUnsyncedGenerator initializes an UnsyncedGenerator with minValue and maxValue, then applies opts to it,
+// like NewGeneratorWithOptions.
+func NewUnsyncedGenerator(minValue, maxValue int64, opts ...Option) (*UnsyncedGenerator, error) {
+	idGenerator, err := NewGeneratorWithOptions(minValue, maxValue, opts...)
+	if err != nil {
+		return nil, err
+	}
+	idGenerator.lock.off = true
+	return &UnsyncedGenerator{IDGenerator: idGenerator}, nil
+}
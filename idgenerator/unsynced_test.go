@@ -0,0 +1,101 @@
+package idgenerator
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestUnsyncedGenerator(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			synced, err := NewGeneratorWithOptions(1, 200, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			unsynced, err := NewUnsyncedGenerator(1, 200, storeOption.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// the same calls give the same IDs and leave the same state
+			rng := rand.New(rand.NewSource(1))
+			for i := 0; i < 2000; i++ {
+				if rng.Intn(3) == 0 {
+					id := int64(rng.Intn(200) + 1)
+					if errSynced, errUnsynced := synced.FreeID(id), unsynced.FreeID(id); (errSynced == nil) !=
+						(errUnsynced == nil) {
+						t.Fatalf("FreeID(%d): expected %v, got %v", id, errSynced, errUnsynced)
+					}
+					continue
+				}
+				expected, errSynced := synced.Allocate()
+				id, errUnsynced := unsynced.Allocate()
+				if id != expected || (errSynced == nil) != (errUnsynced == nil) {
+					t.Fatalf("Allocate: expected %d, %v, got %d, %v", expected, errSynced, id, errUnsynced)
+				}
+			}
+			if !reflect.DeepEqual(unsynced.Snapshot(), synced.Snapshot()) {
+				t.Errorf("expected snapshot %+v, got %+v", synced.Snapshot(), unsynced.Snapshot())
+			}
+		})
+	}
+}
+
+func TestUnsyncedGeneratorDoesNotLock(t *testing.T) {
+	var freed []int64
+	var unsynced *UnsyncedGenerator
+	unsynced, err := NewUnsyncedGenerator(1, 10, WithOnAllocate(func(id int64) {
+		// the hooks may call back into the generator as with a synchronized one
+		if id == 2 {
+			if err := unsynced.FreeID(1); err != nil {
+				t.Error(err)
+			}
+		}
+	}), WithOnFree(func(id int64) {
+		freed = append(freed, id)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a call which took the lock would block forever
+	unsynced.lock.mtx.Lock()
+	defer unsynced.lock.mtx.Unlock()
+	allocateN(t, unsynced.IDGenerator, 2)
+	if !reflect.DeepEqual(freed, []int64{1}) {
+		t.Errorf("expected ID 1 freed by the hook, got %v", freed)
+	}
+	if used := unsynced.Used(); used != 1 {
+		t.Errorf("expected 1 ID used, got %d", used)
+	}
+
+	if _, err = NewUnsyncedGenerator(10, 1); err == nil {
+		t.Error("expected an error for an invalid range")
+	}
+}
+
+func BenchmarkUnsyncedAllocate(b *testing.B) {
+	run := func(b *testing.B, idGenerator *IDGenerator) {
+		for i := 0; i < b.N; i++ {
+			id, err := idGenerator.Allocate()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if err = idGenerator.FreeID(id); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	b.Run("synced", func(b *testing.B) {
+		run(b, NewGenerator(1, 1<<10))
+	})
+	b.Run("unsynced", func(b *testing.B) {
+		unsynced, err := NewUnsyncedGenerator(1, 1<<10)
+		if err != nil {
+			b.Fatal(err)
+		}
+		run(b, unsynced.IDGenerator)
+	})
+}
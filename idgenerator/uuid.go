@@ -0,0 +1,138 @@
+package idgenerator
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// uuidRounds is the number of rounds of the Feistel network encrypting the IDs, 4 make it a strong pseudorandom
+// permutation given a pseudorandom round function
+const uuidRounds = 4
+
+// UUIDMapper maps IDs to version 8 UUIDs such as "6f1c2e4a-9b3d-8e57-a1c9-0d2b7e4f6a81" and back, for the APIs
+// which expect opaque UUIDs: the ID is encrypted with a key so that the UUIDs of consecutive IDs are not guessable,
+// and a tag of the ciphertext fills the other bits so that a UUID not made by the mapper is rejected.
+// The encryption is a permutation of the 2^64 IDs, so two IDs never share a UUID and the reverse lookup
+// is a decryption rather than an index: the mapping only depends on the key and is the same across restarts,
+// so that the UUIDs persisted by a client can still be mapped back. An ID freed and allocated again
+// gets the same UUID as before.
+type UUIDMapper struct {
+	block cipher.Block
+}
+
+// NewUUIDMapper returns a UUIDMapper encrypting with key, an AES key of 16, 24 or 32 bytes which must be kept
+// secret for the UUIDs not to be guessable. It returns an error if key has another length.
+func NewUUIDMapper(key []byte) (*UUIDMapper, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid UUID key: %v", err)
+	}
+	return &UUIDMapper{block: block}, nil
+}
+
+// prf returns 64 pseudorandom bits of domain and value
+func (m *UUIDMapper) prf(domain byte, value uint64) uint64 {
+	var block [aes.BlockSize]byte
+	block[0] = domain
+	binary.BigEndian.PutUint64(block[8:], value)
+	m.block.Encrypt(block[:], block[:])
+	return binary.BigEndian.Uint64(block[:])
+}
+
+// encrypt permutes the 64 bits of id with a balanced Feistel network over AES
+func (m *UUIDMapper) encrypt(id int64) uint64 {
+	left, right := uint32(uint64(id)>>32), uint32(id)
+	for round := byte(0); round < uuidRounds; round++ {
+		left, right = right, left^uint32(m.prf(round, uint64(right)))
+	}
+	return uint64(left)<<32 | uint64(right)
+}
+
+func (m *UUIDMapper) decrypt(ciphertext uint64) int64 {
+	left, right := uint32(ciphertext>>32), uint32(ciphertext)
+	for round := byte(uuidRounds); round > 0; round-- {
+		left, right = right^uint32(m.prf(round-1, uint64(left))), left
+	}
+	return int64(uint64(left)<<32 | uint64(right))
+}
+
+// tag returns the 58 bits authenticating ciphertext in a UUID
+func (m *UUIDMapper) tag(ciphertext uint64) uint64 {
+	return m.prf(uuidRounds, ciphertext) >> 6
+}
+
+// UUID returns the UUID of id. The 64 bits of ciphertext are laid out around the version and variant bits,
+// in bytes 0 to 5, 7 and 9, and the 58 bits of the tag in the rest.
+func (m *UUIDMapper) UUID(id int64) string {
+	var c, tag [8]byte
+	var uuid [16]byte
+	ciphertext := m.encrypt(id)
+	binary.BigEndian.PutUint64(c[:], ciphertext)
+	binary.BigEndian.PutUint64(tag[:], m.tag(ciphertext))
+	// the 10 highest bits of the tag go in the 4 after the version and the 6 after the variant
+	high := binary.BigEndian.Uint16(tag[:2])
+	copy(uuid[:6], c[:6])
+	uuid[6] = 0x80 | byte(high>>6)&0x0f
+	uuid[7] = c[6]
+	uuid[8] = 0x80 | byte(high)&0x3f
+	uuid[9] = c[7]
+	copy(uuid[10:], tag[2:])
+	return fmt.Sprintf("%x-%x-%x-%x-%x", uuid[:4], uuid[4:6], uuid[6:8], uuid[8:10], uuid[10:])
+}
+
+// ID returns the ID of uuid, or an error wrapping ErrMalformedID if uuid is not a UUID of the mapper,
+// in the lower case canonical form UUID returns
+func (m *UUIDMapper) ID(uuid string) (int64, error) {
+	if len(uuid) != 36 || uuid[8] != '-' || uuid[13] != '-' || uuid[18] != '-' || uuid[23] != '-' {
+		return 0, fmt.Errorf("%w: %q is not a UUID", ErrMalformedID, uuid)
+	}
+	var b [16]byte
+	var c [8]byte
+	digits := uuid[:8] + uuid[9:13] + uuid[14:18] + uuid[19:23] + uuid[24:]
+	if _, err := hex.Decode(b[:], []byte(digits)); err != nil {
+		return 0, fmt.Errorf("%w: %q: %v", ErrMalformedID, uuid, err)
+	}
+	copy(c[:6], b[:6])
+	c[6], c[7] = b[7], b[9]
+	id := m.decrypt(binary.BigEndian.Uint64(c[:]))
+	// the UUID of the ID has the tag of the ciphertext, the version and the variant, in lower case
+	if m.UUID(id) != uuid {
+		return 0, fmt.Errorf("%w: %q is not a UUID of the mapper", ErrMalformedID, uuid)
+	}
+	return id, nil
+}
+
+// WithUUIDMapper sets the UUIDMapper of AllocateUUID and FreeUUID
+func WithUUIDMapper(m *UUIDMapper) Option {
+	return func(idGenerator *IDGenerator) {
+		idGenerator.uuids = m
+	}
+}
+
+// AllocateUUID allocates an ID like Allocate and returns it along with its UUID by the UUIDMapper of WithUUIDMapper.
+// It returns an error if the generator has no UUIDMapper.
+func (idGenerator *IDGenerator) AllocateUUID() (uuid string, id int64, err error) {
+	if idGenerator.uuids == nil {
+		return "", 0, fmt.Errorf("AllocateUUID: no UUIDMapper set with WithUUIDMapper")
+	}
+	if id, err = idGenerator.Allocate(); err != nil {
+		return "", 0, err
+	}
+	return idGenerator.uuids.UUID(id), id, nil
+}
+
+// FreeUUID frees the ID of uuid like FreeID.
+// It returns an error wrapping ErrMalformedID if uuid is not a UUID of the UUIDMapper of the generator.
+func (idGenerator *IDGenerator) FreeUUID(uuid string) error {
+	if idGenerator.uuids == nil {
+		return fmt.Errorf("FreeUUID: no UUIDMapper set with WithUUIDMapper")
+	}
+	id, err := idGenerator.uuids.ID(uuid)
+	if err != nil {
+		return err
+	}
+	return idGenerator.FreeID(id)
+}
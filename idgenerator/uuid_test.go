@@ -0,0 +1,147 @@
+package idgenerator
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var uuidTestKey = []byte("0123456789abcdef")
+
+func newTestUUIDMapper(t testing.TB) *UUIDMapper {
+	t.Helper()
+	m, err := NewUUIDMapper(uuidTestKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestUUIDMapperRoundTrip(t *testing.T) {
+	m := newTestUUIDMapper(t)
+	canonical := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-8[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ids := []int64{0, 1, -1, math.MinInt64, math.MaxInt64}
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		ids = append(ids, int64(random.Uint64()))
+	}
+	for _, id := range ids {
+		uuid := m.UUID(id)
+		if !canonical.MatchString(uuid) {
+			t.Errorf("ID %d: %q is not a canonical version 8 UUID", id, uuid)
+		}
+		if output, err := m.ID(uuid); err != nil || output != id {
+			t.Errorf("expected ID %d of %q, output %d, %+v", id, uuid, output, err)
+		}
+	}
+}
+
+func TestUUIDMapperNoCollision(t *testing.T) {
+	m := newTestUUIDMapper(t)
+	// the IDs of a generator are consecutive, their ciphertexts differ by construction
+	seen := make(map[string]int64)
+	for id := int64(-50000); id < 50000; id++ {
+		uuid := m.UUID(id)
+		if other, ok := seen[uuid]; ok {
+			t.Fatalf("IDs %d and %d share the UUID %q", other, id, uuid)
+		}
+		seen[uuid] = id
+	}
+}
+
+func TestUUIDMapperStable(t *testing.T) {
+	// the mapping only depends on the key, the UUIDs persisted before a restart are still mapped back
+	first, second := newTestUUIDMapper(t), newTestUUIDMapper(t)
+	if uuid := first.UUID(42); uuid != second.UUID(42) {
+		t.Errorf("expected the same UUID from the same key, output %q and %q", uuid, second.UUID(42))
+	}
+	other, err := NewUUIDMapper([]byte("fedcba9876543210"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uuid := first.UUID(42); uuid == other.UUID(42) {
+		t.Errorf("expected another UUID from another key, output %q twice", uuid)
+	}
+	if _, err = other.ID(first.UUID(42)); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("expected ErrMalformedID for the UUID of another key, got %+v", err)
+	}
+}
+
+func TestUUIDMapperMalformed(t *testing.T) {
+	m := newTestUUIDMapper(t)
+	uuid := m.UUID(7)
+	flipped := []byte(uuid)
+	if flipped[35] == '0' {
+		flipped[35] = '1'
+	} else {
+		flipped[35] = '0'
+	}
+	for _, malformed := range []string{
+		"",
+		uuid[:35],
+		strings.ReplaceAll(uuid, "-", ""),
+		strings.ToUpper(uuid),
+		uuid[:14] + "4" + uuid[15:],
+		uuid[:9] + "zzzz" + uuid[13:],
+		string(flipped),
+		"00000000-0000-8000-8000-000000000000",
+	} {
+		if id, err := m.ID(malformed); !errors.Is(err, ErrMalformedID) {
+			t.Errorf("%q: expected ErrMalformedID, output %d, %+v", malformed, id, err)
+		}
+	}
+}
+
+func TestNewUUIDMapperInvalidKey(t *testing.T) {
+	if _, err := NewUUIDMapper([]byte("short")); err == nil {
+		t.Error("expected an error for a key of 5 bytes")
+	}
+}
+
+func TestAllocateUUID(t *testing.T) {
+	m := newTestUUIDMapper(t)
+	idGenerator, err := NewGeneratorWithOptions(1, 2, WithUUIDMapper(m))
+	if err != nil {
+		t.Fatal(err)
+	}
+	uuid, id, err := idGenerator.AllocateUUID()
+	if err != nil || id != 1 || uuid != m.UUID(1) {
+		t.Fatalf("expected ID 1 and %q, output %d and %q, %+v", m.UUID(1), id, uuid, err)
+	}
+	if err = idGenerator.FreeUUID(uuid); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.FreeUUID(uuid); !errors.Is(err, ErrNotAllocated) {
+		t.Errorf("expected ErrNotAllocated, got %+v", err)
+	}
+	if err = idGenerator.FreeUUID("not-a-uuid"); !errors.Is(err, ErrMalformedID) {
+		t.Errorf("expected ErrMalformedID, got %+v", err)
+	}
+	// the UUID of an ID outside of the range maps back to it, FreeID rejects it
+	if err = idGenerator.FreeUUID(m.UUID(3)); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+
+	plain := NewGenerator(1, 2)
+	if _, _, err = plain.AllocateUUID(); err == nil {
+		t.Error("expected an error without UUIDMapper")
+	}
+	if err = plain.FreeUUID(uuid); err == nil {
+		t.Error("expected an error without UUIDMapper")
+	}
+	if plain.Used() != 0 {
+		t.Error("expected nothing allocated without UUIDMapper")
+	}
+}
+
+func BenchmarkUUIDMapper(b *testing.B) {
+	m := newTestUUIDMapper(b)
+	for i := 0; i < b.N; i++ {
+		if _, err := m.ID(m.UUID(int64(i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package idgenerator
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned by Validate when the state of the generator is inconsistent
+type ValidationError struct {
+	// Violations describe every broken invariant
+	Violations []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("inconsistent generator state: %s", strings.Join(e.Violations, "; "))
+}
+
+// Validate checks the invariants of the state of the generator under its lock, for the tests and health checks
+// to catch a drift: the used count matches the store, every allocated ID is in range, the excluded and
+// quarantined IDs are not counted as allocated, the freed IDs queued by WithFIFORecycling are free,
+// the owners, leases, reservations, hold times and references of WithRefCounting are those of allocated IDs,
+// the permanent IDs of MarkPermanent are allocated and not leased, the bindings of AllocateSticky are indexed
+// by key and by ID, and the next offset of the sequential Allocate is in range. It walks every allocated ID
+// and returns nil, or a *ValidationError listing every violation found.
+func (idGenerator *IDGenerator) Validate() error {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	var violations []string
+	violate := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Sprintf(format, args...))
+	}
+	allocated := func(offset uint64) bool {
+		return idGenerator.store.Has(offset) && !idGenerator.isHeld(offset)
+	}
+
+	if idGenerator.offset > idGenerator.lastOffset {
+		violate("offset %d beyond the last offset %d", idGenerator.offset, idGenerator.lastOffset)
+	}
+	set := idGenerator.store.SetOffsets()
+	used := uint64(0)
+	for _, offset := range set {
+		switch {
+		case offset > idGenerator.lastOffset:
+			violate("offset %d set beyond the last offset %d", offset, idGenerator.lastOffset)
+		case !idGenerator.isHeld(offset):
+			used++
+		}
+		if idGenerator.recycling != nil && !idGenerator.recycling.touched.Has(offset) {
+			violate("ID[%d] set but never used for recycling", idGenerator.toID(offset))
+		}
+	}
+	if used != idGenerator.used {
+		violate("used count %d, %d IDs allocated", idGenerator.used, used)
+	}
+	if occupied := idGenerator.occupiedLocked(); uint64(len(set)) != occupied {
+		violate("%d offsets set, %d occupied", len(set), occupied)
+	}
+	for offset := range idGenerator.excluded {
+		if !idGenerator.store.Has(offset) {
+			violate("excluded ID[%d] not set", idGenerator.toID(offset))
+		}
+		if idGenerator.isQuarantined(offset) {
+			violate("excluded ID[%d] quarantined", idGenerator.toID(offset))
+		}
+	}
+	for offset := range idGenerator.quarantined {
+		if !idGenerator.store.Has(offset) {
+			violate("quarantined ID[%d] not set", idGenerator.toID(offset))
+		}
+	}
+	if len(idGenerator.quarantine) != len(idGenerator.quarantined) {
+		violate("%d quarantine entries for %d quarantined IDs", len(idGenerator.quarantine), len(idGenerator.quarantined))
+	}
+	for _, entry := range idGenerator.quarantine {
+		if !idGenerator.isQuarantined(entry.offset) {
+			violate("quarantine entry of ID[%d] not quarantined", idGenerator.toID(entry.offset))
+		}
+	}
+	idGenerator.validateRecyclingLocked(violate)
+
+	for name, owner := range idGenerator.owners {
+		if owner.name != name || len(owner.offsets) == 0 {
+			violate("owner %q indexed as %q with %d IDs", owner.name, name, len(owner.offsets))
+		}
+		for offset := range owner.offsets {
+			if idGenerator.ownerOf[offset] != owner {
+				violate("ID[%d] of owner %q not indexed", idGenerator.toID(offset), name)
+			}
+		}
+	}
+	for offset, owner := range idGenerator.ownerOf {
+		if !allocated(offset) {
+			violate("ID[%d] of owner %q not allocated", idGenerator.toID(offset), owner.name)
+		}
+		if _, ok := owner.offsets[offset]; !ok || idGenerator.owners[owner.name] != owner {
+			violate("ID[%d] indexed to owner %q not listing it", idGenerator.toID(offset), owner.name)
+		}
+	}
+	for offset := range idGenerator.leases {
+		if !allocated(offset) {
+			violate("leased ID[%d] not allocated", idGenerator.toID(offset))
+		}
+	}
+	for offset := range idGenerator.reservations {
+		if _, ok := idGenerator.leases[offset]; !ok {
+			violate("reserved ID[%d] not leased", idGenerator.toID(offset))
+		}
+	}
+	for offset := range idGenerator.expired {
+		if allocated(offset) {
+			violate("expired ID[%d] allocated", idGenerator.toID(offset))
+		}
+	}
+	for offset := range idGenerator.heldSince {
+		if !allocated(offset) {
+			violate("held ID[%d] not allocated", idGenerator.toID(offset))
+		}
+	}
+	extraRefs := uint64(0)
+	for offset, refs := range idGenerator.refs {
+		if !allocated(offset) {
+			violate("referenced ID[%d] not allocated", idGenerator.toID(offset))
+		}
+		extraRefs += refs
+	}
+	if extraRefs != idGenerator.extraRefs {
+		violate("%d extra references counted, %d held", idGenerator.extraRefs, extraRefs)
+	}
+	for id := range idGenerator.permanent {
+		if !idGenerator.inRange(id) || !allocated(idGenerator.toOffset(id)) {
+			violate("permanent ID[%d] not allocated", id)
+		} else if _, ok := idGenerator.leases[idGenerator.toOffset(id)]; ok {
+			violate("permanent ID[%d] leased", id)
+		}
+	}
+	if s := idGenerator.sticky; s != nil && (len(s.keys) != s.lru.Len() || len(s.byID) != s.lru.Len()) {
+		violate("%d sticky bindings, %d keys and %d IDs indexed", s.lru.Len(), len(s.keys), len(s.byID))
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// validateRecyclingLocked checks the queue of WithFIFORecycling, the caller must hold lock
+func (idGenerator *IDGenerator) validateRecyclingLocked(violate func(format string, args ...interface{})) {
+	r := idGenerator.recycling
+	if r == nil {
+		return
+	}
+	if r.queue.Len() != len(r.queued) {
+		violate("%d freed IDs queued, %d indexed", r.queue.Len(), len(r.queued))
+	}
+	for e := r.queue.Front(); e != nil; e = e.Next() {
+		offset := e.Value.(uint64)
+		if r.queued[offset] != e {
+			violate("queued ID[%d] not indexed", idGenerator.toID(offset))
+		}
+		if idGenerator.store.Has(offset) {
+			violate("queued ID[%d] not free", idGenerator.toID(offset))
+		}
+	}
+	if touched := uint64(len(r.touched.SetOffsets())); touched != r.occupied {
+		violate("%d IDs used for recycling, %d counted", touched, r.occupied)
+	}
+}
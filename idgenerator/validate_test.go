@@ -0,0 +1,100 @@
+package idgenerator
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestValidateOperations(t *testing.T) {
+	options := []struct {
+		name string
+		opts []Option
+	}{
+		{"plain", nil},
+		{"recycling", []Option{WithFIFORecycling(RecycleFirst)}},
+		{"quarantine", []Option{WithReuseDelay(time.Second), WithHoldTimes(nil)}},
+		{"refcounting", []Option{WithRefCounting(), WithRandSource(rand.NewSource(1))}},
+	}
+	for _, storeOption := range storeOptions {
+		for _, option := range options {
+			t.Run(storeOption.name+"/"+option.name, func(t *testing.T) {
+				clock := newFakeClock()
+				opts := append([]Option{WithClock(clock)}, storeOption.opts...)
+				idGenerator, err := NewGeneratorWithExclusions(1, 64, []int64{7, 30}, append(opts, option.opts...)...)
+				if err != nil {
+					t.Fatal(err)
+				}
+				r := rand.New(rand.NewSource(1))
+				for i := 0; i < 2000; i++ {
+					id := r.Int63n(70)
+					// the errors are part of the sequence, only the consistency of the state matters
+					switch r.Intn(11) {
+					case 0, 1:
+						_, _ = idGenerator.Allocate()
+					case 2:
+						_, _ = idGenerator.AllocateFor("owner")
+					case 3:
+						_, _ = idGenerator.AllocateLease(time.Duration(r.Intn(3)) * time.Second)
+					case 4:
+						_ = idGenerator.AllocateSpecific(id)
+					case 5:
+						_, _, _ = idGenerator.Reserve(time.Second)
+					case 6:
+						_ = idGenerator.Acquire(id)
+					case 7, 9, 10:
+						_ = idGenerator.FreeID(id)
+					case 8:
+						clock.Advance(time.Second)
+					}
+					if r.Intn(500) == 0 {
+						idGenerator.Reset()
+					}
+					if err = idGenerator.Validate(); err != nil {
+						t.Fatalf("operation %d: %v", i, err)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestValidateViolations(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(1, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = idGenerator.AllocateFor("owner"); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	idGenerator.lock.Lock()
+	idGenerator.used++
+	idGenerator.ownLocked(idGenerator.toOffset(5), "owner")
+	idGenerator.offset = 100
+	idGenerator.lock.Unlock()
+
+	err = idGenerator.Validate()
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) {
+		t.Fatalf("expected a ValidationError, got %v", err)
+	}
+	expected := []string{
+		"offset 100 beyond the last offset 9",
+		"used count 2, 1 IDs allocated",
+		"ID[5] of owner \"owner\" not allocated",
+	}
+	for _, violation := range expected {
+		found := false
+		for _, got := range validationErr.Violations {
+			found = found || got == violation
+		}
+		if !found {
+			t.Errorf("expected violation %q in %q", violation, validationErr.Violations)
+		}
+	}
+}
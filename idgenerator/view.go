@@ -0,0 +1,68 @@
+package idgenerator
+
+import "math"
+
+// View is a read-only copy of the allocated IDs of an IDGenerator as of the call of IDGenerator.View,
+// the allocations and frees made afterwards are not seen in it. It is safe for concurrent use.
+type View struct {
+	minValue int64
+	maxValue int64
+	step     stepping
+	// store has the allocated offsets set, the excluded and quarantined ones clear
+	store Store
+	used  uint64
+}
+
+// View returns a View of the allocated IDs, e.g. to export them for a backup without stalling allocation
+// for the whole iteration. The lock is held only while the state is copied, which costs as much time
+// and memory as a Clone of the store: a bitmap of (maxValue - minValue + 1) / 8 bytes with WithBitmapStore,
+// the nodes holding the allocated IDs with the default store, the runs of them with WithIntervalStore.
+func (idGenerator *IDGenerator) View() *View {
+	idGenerator.rlock()
+	defer idGenerator.lock.RUnlock()
+	store := idGenerator.store.Clone()
+	for offset := range idGenerator.excluded {
+		store.Clear(offset)
+	}
+	for offset := range idGenerator.quarantined {
+		store.Clear(offset)
+	}
+	return &View{
+		minValue: idGenerator.minValue,
+		maxValue: idGenerator.maxValue,
+		step:     idGenerator.step,
+		store:    store,
+		used:     idGenerator.used,
+	}
+}
+
+// IsAllocated reports whether id was allocated, it is false for any id outside [minValue, maxValue]
+func (v *View) IsAllocated(id int64) bool {
+	return id >= v.minValue && id <= v.maxValue && v.step.contains(v.minValue, id) &&
+		v.store.Has(v.step.toOffset(v.minValue, id))
+}
+
+// Used returns the number of allocated IDs
+func (v *View) Used() int64 {
+	return clampInt64(v.used)
+}
+
+// AllocatedIDs returns the allocated IDs in ascending order
+func (v *View) AllocatedIDs() []int64 {
+	offsets := v.store.SetOffsets()
+	ids := make([]int64, len(offsets))
+	for i, offset := range offsets {
+		ids[i] = v.step.toID(v.minValue, offset)
+	}
+	return ids
+}
+
+// ForEachAllocated calls f with the allocated IDs in ascending order until f returns false,
+// without copying them like AllocatedIDs
+func (v *View) ForEachAllocated(f func(id int64) bool) {
+	for offset, ok := v.store.NextSet(0); ok; offset, ok = v.store.NextSet(offset + 1) {
+		if !f(v.step.toID(v.minValue, offset)) || offset == math.MaxUint64 {
+			return
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package idgenerator
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestView(t *testing.T) {
+	for _, storeOption := range storeOptions {
+		t.Run(storeOption.name, func(t *testing.T) {
+			opts := append([]Option{WithReuseDelay(time.Minute)}, storeOption.opts...)
+			idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{4}, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			allocateN(t, idGenerator, 5)
+			freeAll(t, idGenerator, 2)
+
+			view := idGenerator.View()
+			// the view is as of the call, the changes made afterwards are not seen in it
+			freeAll(t, idGenerator, 1)
+			allocateN(t, idGenerator, 2)
+			if err = idGenerator.AllocateSpecific(10); err != nil {
+				t.Fatal(err)
+			}
+
+			// the excluded and the quarantined IDs are not allocated
+			expected := []int64{1, 3, 5, 6}
+			if ids := view.AllocatedIDs(); !reflect.DeepEqual(ids, expected) {
+				t.Errorf("expected IDs %v, output %v", expected, ids)
+			}
+			var iterated []int64
+			view.ForEachAllocated(func(id int64) bool {
+				iterated = append(iterated, id)
+				return id < 5
+			})
+			if !reflect.DeepEqual(iterated, []int64{1, 3, 5}) {
+				t.Errorf("expected IDs up to 5, output %v", iterated)
+			}
+			if used := view.Used(); used != 4 {
+				t.Errorf("expected used: 4, output used: %d", used)
+			}
+			for id, allocated := range map[int64]bool{0: false, 1: true, 2: false, 4: false, 7: false, 10: false} {
+				if view.IsAllocated(id) != allocated {
+					t.Errorf("ID[%d]: expected allocated %v", id, allocated)
+				}
+			}
+			if ids := idGenerator.AllocatedIDs(); !reflect.DeepEqual(ids, []int64{3, 5, 6, 7, 8, 10}) {
+				t.Errorf("unexpected allocated IDs of the generator: %v", ids)
+			}
+		})
+	}
+}
+
+func TestViewFullRange(t *testing.T) {
+	idGenerator, err := NewGeneratorWithOptions(math.MinInt64, math.MaxInt64, WithIntervalStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []int64{math.MinInt64, 0, math.MaxInt64} {
+		if err = idGenerator.AllocateSpecific(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var ids []int64
+	idGenerator.View().ForEachAllocated(func(id int64) bool {
+		ids = append(ids, id)
+		return true
+	})
+	if expected := []int64{math.MinInt64, 0, math.MaxInt64}; !reflect.DeepEqual(ids, expected) {
+		t.Errorf("expected IDs %v, output %v", expected, ids)
+	}
+}
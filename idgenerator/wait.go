@@ -0,0 +1,228 @@
+package idgenerator
+
+import (
+	"container/list"
+	"context"
+)
+
+// waiter is an AllocateCtx, AllocateForCtx or WaitForID call blocked on an exhausted pool or a used ID,
+// it receives its ID on id once one is freed, or sees id closed by Close.
+// The ID of an AllocateForCtx call, tagged, is tagged with owner; queue and elem place a waiting call
+// of either in the waitQueue.
+type waiter struct {
+	id     chan int64
+	owner  string
+	tagged bool
+	queue  *ownerQueue
+	elem   *list.Element
+}
+
+// AllocateCtx allocates an ID like Allocate, but if the pool is exhausted it waits
+// until an ID is freed or ctx is done, whichever comes first.
+// Waiting calls are served in FIFO order, or round-robin per owner with WithFairWaiting; a freed ID goes
+// to a waiting call before any other allocation can take it. With WithRateLimit it first waits for the limit
+// to allow it. Close makes the waiting calls return an error wrapping ErrClosed.
+func (idGenerator *IDGenerator) AllocateCtx(ctx context.Context) (int64, error) {
+	return idGenerator.allocateWaiting(ctx, &waiter{id: make(chan int64, 1)})
+}
+
+// AllocateForCtx allocates an ID for owner like AllocateFor, waiting like AllocateCtx if the pool is exhausted.
+// It returns an error wrapping ErrQuotaExceeded at once if owner holds as many IDs as its quota,
+// a waiting call is only served once owner holds fewer IDs than its quota.
+func (idGenerator *IDGenerator) AllocateForCtx(ctx context.Context, owner string) (int64, error) {
+	return idGenerator.allocateWaiting(ctx, &waiter{id: make(chan int64, 1), owner: owner, tagged: true})
+}
+
+// allocateWaiting is AllocateCtx and AllocateForCtx for w, which is queued if the pool is exhausted
+func (idGenerator *IDGenerator) allocateWaiting(ctx context.Context, w *waiter) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	if err := idGenerator.waitRate(ctx); err != nil {
+		return 0, err
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		idGenerator.unlock()
+		idGenerator.refundRate(1)
+		return 0, err
+	}
+	if w.tagged {
+		if err := idGenerator.checkQuotaLocked(w.owner); err != nil {
+			idGenerator.unlock()
+			idGenerator.refundRate(1)
+			return 0, err
+		}
+	}
+	if idGenerator.waiters == nil || idGenerator.waiters.len == 0 {
+		if id, err := idGenerator.allocateLocked(); err == nil {
+			idGenerator.tagWaiterLocked(w, id)
+			idGenerator.unlock()
+			return id, nil
+		}
+	}
+	if idGenerator.waiters == nil {
+		idGenerator.waiters = newWaitQueue(idGenerator.fairWaiting)
+	}
+	idGenerator.waiters.push(w)
+	idGenerator.unlock()
+
+	select {
+	case id, ok := <-w.id:
+		if !ok {
+			idGenerator.refundRate(1)
+			return 0, idGenerator.closedError()
+		}
+		return id, nil
+	case <-ctx.Done():
+	}
+
+	idGenerator.refundRate(1)
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	select {
+	case id, ok := <-w.id:
+		// served while being cancelled, give the ID back to the pool unless Close closed w instead
+		if ok {
+			idGenerator.unmarkUsed(idGenerator.toOffset(id))
+			idGenerator.serveWaitersLocked()
+		}
+	default:
+		idGenerator.waiters.remove(w)
+	}
+	return 0, ctx.Err()
+}
+
+// tagWaiterLocked tags id, allocated for w, with the owner of an AllocateForCtx call, the caller must hold lock
+func (idGenerator *IDGenerator) tagWaiterLocked(w *waiter, id int64) {
+	if !w.tagged {
+		return
+	}
+	idGenerator.ownLocked(idGenerator.toOffset(id), w.owner)
+	if idGenerator.audit != nil {
+		idGenerator.audit.tagLast(w.owner)
+	}
+}
+
+// servableLocked reports whether w may be allocated an ID, which it may not while its owner is at its quota.
+// The caller must hold lock.
+func (idGenerator *IDGenerator) servableLocked(w *waiter) bool {
+	return !w.tagged || idGenerator.checkQuotaLocked(w.owner) == nil
+}
+
+// WaitForID allocates exactly id like AllocateSpecific, but if id is in use, or quarantined, it waits
+// until id is freed or ctx is done, whichever comes first, e.g. for a standby to take over the IDs of the primary
+// once their grace period is over. The calls waiting for the same ID are served in FIFO order,
+// and a freed ID goes to them before any other allocation can take it, AllocateCtx included.
+// It returns an error wrapping ErrOutOfRange if id is outside [minValue, maxValue] or ErrReserved if id is excluded,
+// which would never be freed, the error of ctx once it is done, or one wrapping ErrClosed after Close.
+func (idGenerator *IDGenerator) WaitForID(ctx context.Context, id int64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	idGenerator.lock.Lock()
+	idGenerator.expireLeasesLocked()
+	if !idGenerator.inRange(id) {
+		err := idGenerator.outOfRangeError(id)
+		idGenerator.unlock()
+		return err
+	}
+	if err := idGenerator.checkOpenLocked(); err != nil {
+		idGenerator.unlock()
+		return err
+	}
+	offset := idGenerator.toOffset(id)
+	if idGenerator.isExcluded(offset) {
+		idGenerator.unlock()
+		return idGenerator.reservedError(id)
+	}
+	// excluded and quarantined offsets are set in store as well
+	queue := idGenerator.idWaiters[id]
+	if !idGenerator.store.Has(offset) && queue == nil {
+		idGenerator.markUsed(offset)
+		idGenerator.unlock()
+		return nil
+	}
+	if queue == nil {
+		if idGenerator.idWaiters == nil {
+			idGenerator.idWaiters = make(map[int64]*list.List)
+		}
+		queue = list.New()
+		idGenerator.idWaiters[id] = queue
+	}
+	w := &waiter{id: make(chan int64, 1)}
+	elem := queue.PushBack(w)
+	idGenerator.unlock()
+
+	select {
+	case _, ok := <-w.id:
+		if !ok {
+			return idGenerator.closedError()
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	idGenerator.lock.Lock()
+	defer idGenerator.unlock()
+	select {
+	case _, ok := <-w.id:
+		// served while being cancelled, give the ID to the next waiter or back to the pool
+		if ok {
+			idGenerator.unmarkUsed(idGenerator.toOffset(id))
+			idGenerator.serveWaitersLocked()
+		}
+	default:
+		queue.Remove(elem)
+		if queue.Len() == 0 {
+			delete(idGenerator.idWaiters, id)
+		}
+	}
+	return ctx.Err()
+}
+
+// serveIDWaitersLocked allocates the free IDs waited for to the first WaitForID call waiting for each,
+// the caller must hold lock
+func (idGenerator *IDGenerator) serveIDWaitersLocked() {
+	for id, queue := range idGenerator.idWaiters {
+		if !idGenerator.inRange(id) {
+			// out of range after Resize, it may come back
+			continue
+		}
+		offset := idGenerator.toOffset(id)
+		if idGenerator.store.Has(offset) {
+			continue
+		}
+		idGenerator.markUsed(offset)
+		queue.Remove(queue.Front()).(*waiter).id <- id
+		if queue.Len() == 0 {
+			delete(idGenerator.idWaiters, id)
+		}
+	}
+}
+
+// serveWaitersLocked allocates free IDs to the waiting WaitForID calls, then to the waiting AllocateCtx
+// and AllocateForCtx calls in the order of the waitQueue, every operation which frees IDs calls it
+// before releasing lock
+func (idGenerator *IDGenerator) serveWaitersLocked() {
+	if len(idGenerator.idWaiters) > 0 {
+		idGenerator.serveIDWaitersLocked()
+	}
+	if idGenerator.waiters == nil {
+		return
+	}
+	for idGenerator.waiters.len > 0 {
+		w := idGenerator.waiters.next(idGenerator.servableLocked)
+		if w == nil {
+			return
+		}
+		id, err := idGenerator.allocateLocked()
+		if err != nil {
+			return
+		}
+		idGenerator.waiters.served(w)
+		idGenerator.tagWaiterLocked(w, id)
+		w.id <- id
+	}
+}
@@ -0,0 +1,292 @@
+package idgenerator
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+)
+
+// waitForWaiters blocks until n AllocateCtx calls are queued on idGenerator
+func waitForWaiters(t *testing.T, idGenerator *IDGenerator, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		idGenerator.lock.Lock()
+		queued := 0
+		if idGenerator.waiters != nil {
+			queued = idGenerator.waiters.len
+		}
+		idGenerator.lock.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters", n)
+}
+
+// waitForIDWaiters blocks until n WaitForID calls are queued on id
+func waitForIDWaiters(t *testing.T, idGenerator *IDGenerator, id int64, n int) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		idGenerator.lock.Lock()
+		queued := 0
+		if queue, ok := idGenerator.idWaiters[id]; ok {
+			queued = queue.Len()
+		}
+		idGenerator.lock.Unlock()
+		if queued == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d waiters on ID %d", n, id)
+}
+
+func TestAllocateCtx(t *testing.T) {
+	idGenerator := NewGenerator(1, 2)
+
+	for _, expected := range []int64{1, 2} {
+		id, err := idGenerator.AllocateCtx(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != expected {
+			t.Errorf("expected id: %d, output id: %d", expected, id)
+		}
+	}
+
+	t.Run("FIFO", func(t *testing.T) {
+		results := make([]chan int64, 3)
+		for i := range results {
+			results[i] = make(chan int64, 1)
+			go func(result chan int64) {
+				id, err := idGenerator.AllocateCtx(context.Background())
+				if err != nil {
+					t.Errorf("AllocateCtx fail: %+v", err)
+				}
+				result <- id
+			}(results[i])
+			// queue the waiters one after the other
+			waitForWaiters(t, idGenerator, i+1)
+		}
+
+		for i, id := range []int64{2, 1} {
+			if err := idGenerator.FreeID(id); err != nil {
+				t.Fatal(err)
+			}
+			if served := <-results[i]; served != id {
+				t.Errorf("waiter %d: expected id: %d, output id: %d", i, id, served)
+			}
+		}
+		if err := idGenerator.FreeID(2); err != nil {
+			t.Fatal(err)
+		}
+		if served := <-results[2]; served != 2 {
+			t.Errorf("waiter 2: expected id: 2, output id: %d", served)
+		}
+	})
+
+	t.Run("cancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error, 1)
+		go func() {
+			_, err := idGenerator.AllocateCtx(ctx)
+			done <- err
+		}()
+		waitForWaiters(t, idGenerator, 1)
+		cancel()
+		if err := <-done; !errors.Is(err, context.Canceled) {
+			t.Errorf("expected context.Canceled, got %+v", err)
+		}
+		waitForWaiters(t, idGenerator, 0)
+
+		// the freed ID is not handed to the cancelled waiter
+		if err := idGenerator.FreeID(1); err != nil {
+			t.Fatal(err)
+		}
+		if used := idGenerator.Used(); used != 1 {
+			t.Errorf("expected used: 1, output used: %d", used)
+		}
+
+		ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+		defer cancel()
+		if _, err := idGenerator.AllocateCtx(ctx); err != nil {
+			t.Errorf("expected the free ID to be allocated, got %+v", err)
+		}
+		if _, err := idGenerator.AllocateCtx(ctx); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected context.DeadlineExceeded, got %+v", err)
+		}
+	})
+}
+
+func TestAllocateCtxStress(t *testing.T) {
+	const routines = 16
+	idGenerator := NewGenerator(1, routines-1)
+
+	var holders sync.Map
+	wg := sync.WaitGroup{}
+	for routineID := 0; routineID < routines; routineID++ {
+		wg.Add(1)
+		go func(routineID int) {
+			defer wg.Done()
+			rnd := rand.New(rand.NewSource(int64(routineID)))
+			for i := 0; i < 200; i++ {
+				ctx, cancel := context.WithTimeout(context.Background(), time.Duration(rnd.Intn(200))*time.Microsecond)
+				id, err := idGenerator.AllocateCtx(ctx)
+				cancel()
+				if err != nil {
+					if !errors.Is(err, context.DeadlineExceeded) {
+						t.Errorf("AllocateCtx fail: %+v", err)
+					}
+					continue
+				}
+				if holder, loaded := holders.LoadOrStore(id, routineID); loaded {
+					t.Errorf("ID %d allocated to routine %d while held by routine %d", id, routineID, holder)
+				}
+				if rnd.Intn(2) == 0 {
+					time.Sleep(time.Duration(rnd.Intn(50)) * time.Microsecond)
+				}
+				holders.Delete(id)
+				if err = idGenerator.FreeID(id); err != nil {
+					t.Errorf("FreeID fail: %+v", err)
+				}
+			}
+		}(routineID)
+	}
+	wg.Wait()
+
+	// neither waiters nor IDs leak, whichever way the calls ended
+	if used := idGenerator.Used(); used != 0 {
+		t.Errorf("expected used: 0, output used: %d", used)
+	}
+	waitForWaiters(t, idGenerator, 0)
+}
+
+func TestWaitForID(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	// a free ID is allocated at once
+	if err := idGenerator.WaitForID(context.Background(), 5); err != nil || !idGenerator.IsAllocated(5) {
+		t.Fatalf("expected ID 5 allocated, got %+v", err)
+	}
+
+	// the waiters for the same ID are served in FIFO order, the others keep waiting
+	served := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(i int) {
+			if err := idGenerator.WaitForID(context.Background(), 5); err != nil {
+				t.Errorf("WaitForID fail: %+v", err)
+			}
+			served <- i
+		}(i)
+		waitForIDWaiters(t, idGenerator, 5, i+1)
+	}
+	// an AllocateCtx waiting for any ID does not take it
+	allocated := make(chan int64, 1)
+	allocateN(t, idGenerator, 9)
+	go func() {
+		id, err := idGenerator.AllocateCtx(context.Background())
+		if err != nil {
+			t.Errorf("AllocateCtx fail: %+v", err)
+		}
+		allocated <- id
+	}()
+	waitForWaiters(t, idGenerator, 1)
+
+	for i := 0; i < 3; i++ {
+		freeAll(t, idGenerator, 5)
+		if output := <-served; output != i {
+			t.Errorf("expected waiter %d served, output %d", i, output)
+		}
+		if !idGenerator.IsAllocated(5) {
+			t.Error("expected ID 5 allocated to the waiter")
+		}
+	}
+	waitForIDWaiters(t, idGenerator, 5, 0)
+	if _, ok := idGenerator.idWaiters[5]; ok {
+		t.Error("expected the queue of ID 5 dropped")
+	}
+	freeAll(t, idGenerator, 5)
+	if id := <-allocated; id != 5 {
+		t.Errorf("expected ID 5 for AllocateCtx once nobody waits for it, output %d", id)
+	}
+}
+
+func TestWaitForIDQuarantine(t *testing.T) {
+	clock := newFakeClock()
+	idGenerator, err := NewGeneratorWithOptions(1, 10, WithClock(clock), WithReuseDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- idGenerator.WaitForID(context.Background(), 1)
+	}()
+	waitForIDWaiters(t, idGenerator, 1, 1)
+	freeAll(t, idGenerator, 1)
+	select {
+	case err := <-done:
+		t.Fatalf("expected the waiter to wait for the quarantine, got %+v", err)
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Minute)
+	idGenerator.Used()
+	if err := <-done; err != nil || !idGenerator.IsAllocated(1) {
+		t.Errorf("expected ID 1 allocated after its quarantine, got %+v", err)
+	}
+}
+
+func TestWaitForIDCancel(t *testing.T) {
+	idGenerator := NewGenerator(1, 10)
+	allocateN(t, idGenerator, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- idGenerator.WaitForID(ctx, 1)
+	}()
+	waitForIDWaiters(t, idGenerator, 1, 1)
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %+v", err)
+	}
+	if len(idGenerator.idWaiters) != 0 {
+		t.Error("expected no waiter left after the cancel")
+	}
+	freeAll(t, idGenerator, 1)
+	if idGenerator.IsAllocated(1) {
+		t.Error("expected the freed ID not handed to the cancelled waiter")
+	}
+	if err := idGenerator.WaitForID(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled with a done context, got %+v", err)
+	}
+}
+
+func TestWaitForIDErrors(t *testing.T) {
+	idGenerator, err := NewGeneratorWithExclusions(1, 10, []int64{3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.WaitForID(context.Background(), 11); !errors.Is(err, ErrOutOfRange) {
+		t.Errorf("expected ErrOutOfRange, got %+v", err)
+	}
+	if err = idGenerator.WaitForID(context.Background(), 3); !errors.Is(err, ErrReserved) {
+		t.Errorf("expected ErrReserved, got %+v", err)
+	}
+	allocateN(t, idGenerator, 1)
+	done := make(chan error, 1)
+	go func() {
+		done <- idGenerator.WaitForID(context.Background(), 1)
+	}()
+	waitForIDWaiters(t, idGenerator, 1, 1)
+	if _, err = idGenerator.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; !errors.Is(err, ErrClosed) {
+		t.Errorf("expected ErrClosed, got %+v", err)
+	}
+}
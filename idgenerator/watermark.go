@@ -0,0 +1,95 @@
+package idgenerator
+
+// watermark is a utilization threshold of WithHighWatermark or WithLowWatermark,
+// armed while the callback is due on the next crossing
+type watermark struct {
+	high       bool
+	fraction   float64
+	hysteresis float64
+	fn         func(used, capacity int64)
+	armed      bool
+}
+
+// WatermarkOption configures a watermark of WithHighWatermark or WithLowWatermark
+type WatermarkOption func(*watermark)
+
+// WithHysteresis keeps a watermark from firing again until the utilization has moved back past it by fraction,
+// e.g. below 0.8 for a high watermark at 0.9, so that a pool hovering around the threshold does not flap.
+// A fraction below 0 is ignored.
+func WithHysteresis(fraction float64) WatermarkOption {
+	return func(w *watermark) {
+		if fraction >= 0 {
+			w.hysteresis = fraction
+		}
+	}
+}
+
+// WithHighWatermark calls fn once the utilization, the share of the capacity of Stats which cannot be allocated,
+// rises to fraction or above, e.g. to page someone before the pool is exhausted.
+// fn is called again only after the utilization has dropped below fraction, less the hysteresis of the options.
+// used counts the quarantined IDs of WithReuseDelay as well.
+// The utilization is checked at the end of every call which changes it, so an allocation of many IDs at once
+// crossing fraction calls fn as well. fn runs like the hooks of WithOnAllocate, after lock is released.
+// Several watermarks may be set on a generator, a fraction outside (0, 1] or a nil fn adds none.
+func WithHighWatermark(fraction float64, fn func(used, capacity int64), opts ...WatermarkOption) Option {
+	return withWatermark(&watermark{high: true, fraction: fraction, fn: fn, armed: true}, opts)
+}
+
+// WithLowWatermark calls fn once the utilization drops to fraction or below, after it has been above
+// fraction plus the hysteresis of the options, e.g. to scale a pool down again. It runs like WithHighWatermark.
+// A generator starts out empty, so fn is first called after the utilization has risen past the watermark.
+func WithLowWatermark(fraction float64, fn func(used, capacity int64), opts ...WatermarkOption) Option {
+	return withWatermark(&watermark{fraction: fraction, fn: fn}, opts)
+}
+
+func withWatermark(w *watermark, opts []WatermarkOption) Option {
+	return func(idGenerator *IDGenerator) {
+		if w.fraction <= 0 || w.fraction > 1 || w.fn == nil {
+			return
+		}
+		// each generator built with the option keeps its own state
+		configured := *w
+		for _, opt := range opts {
+			opt(&configured)
+		}
+		idGenerator.watermarks = append(idGenerator.watermarks, &configured)
+	}
+}
+
+// checkWatermarksLocked queues the callbacks of the watermarks crossed since the last check,
+// the caller must hold lock
+func (idGenerator *IDGenerator) checkWatermarksLocked() {
+	capacity := idGenerator.capacityLocked()
+	if capacity == 0 {
+		// every ID is excluded
+		return
+	}
+	used := capacity - idGenerator.availableLocked()
+	utilization := float64(used) / float64(capacity)
+	for _, w := range idGenerator.watermarks {
+		var crossed bool
+		if w.high {
+			crossed = utilization >= w.fraction
+			if !crossed && utilization < w.fraction-w.hysteresis {
+				w.armed = true
+			}
+		} else {
+			crossed = utilization <= w.fraction
+			if !crossed && utilization > w.fraction+w.hysteresis {
+				w.armed = true
+			}
+		}
+		if crossed && w.armed {
+			w.armed = false
+			fn, used, capacity := w.fn, clampInt64(used), clampInt64(capacity)
+			idGenerator.events = append(idGenerator.events, event{hook: func(int64) {
+				fn(used, capacity)
+			}})
+			if idGenerator.logEnabled() {
+				e := idGenerator.newLogEventLocked(logWatermarkCrossed, 0, false, nil)
+				e.watermark, e.high = w.fraction, w.high
+				idGenerator.queueLogLocked(e)
+			}
+		}
+	}
+}
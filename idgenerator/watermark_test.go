@@ -0,0 +1,103 @@
+package idgenerator
+
+import (
+	"reflect"
+	"testing"
+)
+
+// watermarkRecorder records the calls of a watermark callback
+type watermarkRecorder struct {
+	calls [][2]int64
+}
+
+func (r *watermarkRecorder) fn(used, capacity int64) {
+	r.calls = append(r.calls, [2]int64{used, capacity})
+}
+
+func TestHighWatermark(t *testing.T) {
+	var high, low watermarkRecorder
+	idGenerator, err := NewGeneratorWithOptions(1, 10,
+		WithHighWatermark(0.8, high.fn, WithHysteresis(0.3)), WithLowWatermark(0.2, low.fn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 7)
+	if len(high.calls) != 0 || len(low.calls) != 0 {
+		t.Fatalf("unexpected calls below the watermarks: %v, %v", high.calls, low.calls)
+	}
+	// the batch jumps across 0.8 in one step
+	if _, err = idGenerator.AllocateMany(2); err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][2]int64{{9, 10}}; !reflect.DeepEqual(high.calls, expected) {
+		t.Errorf("expected high watermark calls %v, output %v", expected, high.calls)
+	}
+	// within the hysteresis the watermark stays disarmed
+	if _, err = idGenerator.FreeRange(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.ReserveRange(1, 2); err != nil {
+		t.Fatal(err)
+	}
+	if len(high.calls) != 1 {
+		t.Errorf("high watermark fired again within the hysteresis: %v", high.calls)
+	}
+	if _, err = idGenerator.FreeRange(1, 5); err != nil {
+		t.Fatal(err)
+	}
+	if err = idGenerator.ReserveRange(1, 4); err != nil {
+		t.Fatal(err)
+	}
+	if expected := [][2]int64{{9, 10}, {8, 10}}; !reflect.DeepEqual(high.calls, expected) {
+		t.Errorf("expected high watermark calls %v, output %v", expected, high.calls)
+	}
+
+	idGenerator.Reset()
+	if expected := [][2]int64{{0, 10}}; !reflect.DeepEqual(low.calls, expected) {
+		t.Errorf("expected low watermark calls %v, output %v", expected, low.calls)
+	}
+	idGenerator.Reset()
+	if len(low.calls) != 1 {
+		t.Errorf("low watermark fired twice: %v", low.calls)
+	}
+}
+
+func TestWatermarkOutsideLock(t *testing.T) {
+	var idGenerator *IDGenerator
+	var available int64
+	idGenerator, err := NewGeneratorWithOptions(1, 4, WithHighWatermark(0.5, func(used, capacity int64) {
+		// the callback may call back into the generator
+		available = idGenerator.Available()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	allocateN(t, idGenerator, 2)
+	if available != 2 {
+		t.Errorf("expected 2 available in the callback, output %d", available)
+	}
+}
+
+func TestWatermarkIgnored(t *testing.T) {
+	var recorder watermarkRecorder
+	option := WithHighWatermark(0.5, recorder.fn)
+	idGenerators := make([]*IDGenerator, 2)
+	for i := range idGenerators {
+		idGenerator, err := NewGeneratorWithOptions(1, 2, option, WithHighWatermark(1.5, recorder.fn),
+			WithLowWatermark(0, recorder.fn), WithHighWatermark(0.5, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(idGenerator.watermarks) != 1 {
+			t.Fatalf("expected the invalid watermarks to be ignored, output %d", len(idGenerator.watermarks))
+		}
+		idGenerators[i] = idGenerator
+	}
+	// the generators sharing an option keep their own state
+	for _, idGenerator := range idGenerators {
+		allocateN(t, idGenerator, 1)
+	}
+	if len(recorder.calls) != 2 {
+		t.Errorf("expected a call for each generator, output %v", recorder.calls)
+	}
+}